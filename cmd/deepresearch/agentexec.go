@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// executionConfig chooses how non-interactive agent runs are launched
+type executionConfig struct {
+	// Mode is direct (default: start the agent binary itself) or pwsh (go through
+	// PowerShell, a fallback for Windows setups that relied on it)
+	Mode string `yaml:"mode"`
+}
+
+// Execution modes
+const (
+	execDirect = "direct"
+	execPwsh   = "pwsh"
+)
+
+// promptArgLimit is the longest prompt passed as a command-line argument; longer
+// ones go through a prompt file. Linux caps a single argument at 128 KiB and
+// Windows a whole command line at 32767 characters.
+func promptArgLimit() int {
+	if runtime.GOOS == "windows" {
+		return 24 * 1024
+	}
+	return 100 * 1024
+}
+
+// isBatchFile reports whether path is a Windows .cmd/.bat shim (as npm installs
+// agent CLIs), whose arguments cmd.exe re-parses: newlines end the command and
+// %, ^ and quotes are interpreted
+func isBatchFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".cmd" || ext == ".bat"
+}
+
+// agentCommandFunc builds the process of a non-interactive agent run, a
+// description of it for the console, and a cleanup for any prompt file it wrote
+type agentCommandFunc func(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error)
+
+// agentCommand is the agentCommandFunc of the agent runs the orchestrator does
+// not start through its runner, such as executors and section writers: in a
+// container with --sandbox, like containerRunner's, else on this machine
+func agentCommand(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error) {
+	if agentSandbox != nil {
+		return agentSandbox.agentCommand(cfg, model, prompt, workDir)
+	}
+	return hostCommand(cfg, model, prompt, workDir)
+}
+
+// hostCommand builds the process of a non-interactive agent run on this
+// machine, directly or via PowerShell as execution.mode says
+func hostCommand(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error) {
+	if config.Execution.Mode == execPwsh {
+		return pwshCommand(cfg, model, prompt, workDir)
+	}
+	return directCommand(cfg, model, prompt, workDir)
+}
+
+// hostRunner runs the agents of the orchestrator's steps on this machine
+type hostRunner struct {
+	workDir string
+	agentOf func(orchestrator.Phase) (agentName, model string)
+}
+
+func (r hostRunner) RunAgent(ctx context.Context, step orchestrator.Step, prompt string) error {
+	agentName, model := r.agentOf(step.Phase)
+	return runAgentWith(ctx, hostCommand, agentName, model, prompt, r.workDir)
+}
+
+// interactiveCommand builds the process of an interactive agent session with
+// args, in a container with --sandbox, and a cleanup to run once it exits
+func interactiveCommand(cfg AgentConfig, args []string, workDir string) (*exec.Cmd, func(), error) {
+	if agentSandbox != nil {
+		return agentSandbox.command(cfg, args, workDir, true)
+	}
+	cmd := exec.Command(cfg.Command, args...)
+	cmd.Dir = workDir
+	return cmd, func() {}, nil
+}
+
+// directCommand starts the agent binary with the prompt as an argument. Prompts
+// too long for the command line, or bound for a batch file, are written to
+// tmp/ and the agent is told to read them instead.
+func directCommand(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error) {
+	path, err := exec.LookPath(cfg.Command)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cleanup := func() {}
+	arg := prompt
+	if len(prompt) > promptArgLimit() || (runtime.GOOS == "windows" && isBatchFile(path)) {
+		file, remove, err := writePromptFile(prompt, workDir)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		arg, cleanup = promptFileArg(file), remove
+	}
+
+	args := cfg.Args(arg, model, workDir)
+	cmd := exec.Command(path, args...)
+	cmd.Dir = workDir
+	return cmd, strings.Join(append([]string{cfg.Command}, shownArgs(args, arg)...), " "), cleanup, nil
+}
+
+// writePromptFile writes a prompt to tmp/ in workDir, returning its path and a
+// cleanup that removes it
+func writePromptFile(prompt, workDir string) (string, func(), error) {
+	dir := filepath.Join(workDir, "tmp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, err
+	}
+	f, err := os.CreateTemp(dir, "agent-prompt-*.md")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create prompt file: %w", err)
+	}
+	_, err = f.WriteString(prompt)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", nil, fmt.Errorf("failed to write prompt file: %w", err)
+	}
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+// promptFileArg is the prompt that tells the agent to read a prompt file
+func promptFileArg(path string) string {
+	return fmt.Sprintf("Read %s and follow ALL instructions in it.", path)
+}
+
+// shownArgs are the arguments of an agent run as the console shows them, with
+// the prompt argument elided
+func shownArgs(args []string, prompt string) []string {
+	var shown []string
+	for _, a := range args {
+		if a == prompt {
+			a = "<prompt>"
+		} else if strings.ContainsAny(a, " \t") {
+			a = fmt.Sprintf("%q", a)
+		}
+		shown = append(shown, a)
+	}
+	return shown
+}
+
+// pwshCommand runs the agent through PowerShell, which reads the prompt from a
+// temp file to avoid command-line escaping issues
+func pwshCommand(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error) {
+	args := cfg.Args(prompt, model, workDir)
+
+	tmpFile, err := os.CreateTemp("", "deepresearch-prompt-*.txt")
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPromptPath := tmpFile.Name()
+	cleanup := func() { os.Remove(tmpPromptPath) }
+	if _, err := tmpFile.WriteString(prompt); err != nil {
+		tmpFile.Close()
+		cleanup()
+		return nil, "", nil, fmt.Errorf("failed to write prompt to temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	// $p = Get-Content -Raw 'tempfile'; copilot -p $p --yolo --add-dir ...
+	var psArgs, shownArgs []string
+	for _, arg := range args {
+		if arg == prompt {
+			// Inject the prompt via variable
+			psArgs = append(psArgs, "$p")
+			shownArgs = append(shownArgs, "<prompt>")
+			continue
+		}
+		// Quote if contains spaces
+		if strings.ContainsAny(arg, " \t") {
+			arg = fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", "''"))
+		}
+		psArgs = append(psArgs, arg)
+		shownArgs = append(shownArgs, arg)
+	}
+
+	psScript := fmt.Sprintf(
+		"$p = Get-Content -Raw '%s'; & '%s' %s",
+		strings.ReplaceAll(tmpPromptPath, "'", "''"),
+		cfg.Command,
+		strings.Join(psArgs, " "),
+	)
+	cmd := exec.Command("pwsh", "-NoProfile", "-Command", psScript)
+	cmd.Dir = workDir
+	return cmd, fmt.Sprintf("pwsh: %s %s", cfg.Command, strings.Join(shownArgs, " ")), cleanup, nil
+}
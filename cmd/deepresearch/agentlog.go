@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// agentLogsConfig keeps what the agents print under logs/, one file per phase
+// and iteration, with secrets masked
+type agentLogsConfig struct {
+	Enabled bool `yaml:"enabled"` // tee agent output to logs/<phase>-<iteration>.log (default true)
+	Quiet   bool `yaml:"quiet"`   // same as --quiet
+	// Redact are regular expressions of further secrets to mask, in addition
+	// to the built-in API key and token formats
+	Redact []string `yaml:"redact"`
+}
+
+// redactedSecret replaces a secret in agent output
+const redactedSecret = "[REDACTED]"
+
+// builtinSecretPatterns match the API keys and tokens of the providers the
+// agents use
+var builtinSecretPatterns = []string{
+	`\bsk-[A-Za-z0-9_-]{20,}`,                 // Anthropic and OpenAI API keys
+	`\bgh[pousr]_[A-Za-z0-9]{36,}`,            // GitHub tokens
+	`\bgithub_pat_[A-Za-z0-9_]{22,}`,          // GitHub fine-grained tokens
+	`\bAIza[0-9A-Za-z_-]{35}`,                 // Google API keys
+	`\bAKIA[0-9A-Z]{16}\b`,                    // AWS access key IDs
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,          // Slack tokens
+	`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`, // Authorization headers
+}
+
+// secretRedactor masks secrets in lines of agent output: the values of the
+// agents' credential variables and whatever its patterns match
+type secretRedactor struct {
+	patterns []*regexp.Regexp
+	values   []string
+}
+
+// redactor masks the agent output that is logged, shown and kept for failure
+// reports; see agentRedactor
+var (
+	redactor     *secretRedactor
+	redactorOnce sync.Once
+)
+
+// agentRedactor returns the redactor of the loaded config, built on first use
+func agentRedactor() *secretRedactor {
+	redactorOnce.Do(func() {
+		// loadConfig has checked the patterns
+		redactor, _ = newSecretRedactor(config.AgentLogs.Redact)
+	})
+	return redactor
+}
+
+// newSecretRedactor compiles the built-in patterns and extra, the patterns of
+// the config
+func newSecretRedactor(extra []string) (*secretRedactor, error) {
+	r := &secretRedactor{}
+	for _, p := range append(append([]string(nil), builtinSecretPatterns...), extra...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, env := range defaultSandboxEnv {
+		// Short values would mask ordinary words
+		if v := os.Getenv(env); len(v) >= 8 {
+			r.values = append(r.values, v)
+		}
+	}
+	return r, nil
+}
+
+// redact returns line with its secrets replaced by redactedSecret
+func (r *secretRedactor) redact(line string) string {
+	if r == nil {
+		return line
+	}
+	for _, v := range r.values {
+		line = strings.ReplaceAll(line, v, redactedSecret)
+	}
+	for _, re := range r.patterns {
+		line = re.ReplaceAllLiteralString(line, redactedSecret)
+	}
+	return line
+}
+
+// agentLog is the file that keeps the output of the agents of a phase
+// iteration, logs/<phase>-<iteration>.log: each line timestamped and labeled
+// with its stream (and, with --max-parallel, the executor's task)
+type agentLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	task string
+}
+
+// agentLogPath is the log of the agents of phase in iteration, e.g.
+// logs/research-supervisor-2.log; agents run outside a phase log to agent-0.log
+func agentLogPath(workDir, phase string, iteration int) string {
+	if phase == "" {
+		phase = "agent"
+	}
+	return filepath.Join(workDir, "logs", fmt.Sprintf("%s-%d.log", strings.ToLower(phase), iteration))
+}
+
+// openAgentLog opens the log of the running phase for appending, starting the
+// entry of an agent run (of an executor's task, if set) with its command line.
+// It returns nil when agent logs are off or the file cannot be opened; a nil
+// log discards what it is given.
+func openAgentLog(workDir, task, shown string) *agentLog {
+	if !config.AgentLogs.Enabled {
+		return nil
+	}
+	f, err := os.OpenFile(agentLogPath(workDir, currentPhase(), currentIteration()), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		warn("Could not open the agent log: %v", err)
+		return nil
+	}
+	l := &agentLog{f: f, task: task}
+	what := "Executing"
+	if task != "" {
+		what += " " + task
+	}
+	l.write("deepresearch", what+": "+agentRedactor().redact(shown))
+	return l
+}
+
+// stream returns the function that logs the lines of one output stream,
+// stdout or stderr
+func (l *agentLog) stream(name string) func(string) {
+	if l == nil {
+		return nil
+	}
+	if l.task != "" {
+		name = l.task + " " + name
+	}
+	return func(line string) { l.write(name, line) }
+}
+
+func (l *agentLog) write(label, line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.f, "[%s] [%s] %s\n", time.Now().Format("2006-01-02T15:04:05.000Z07:00"), label, line)
+}
+
+// Close closes the log file
+func (l *agentLog) Close() {
+	if l != nil {
+		l.f.Close()
+	}
+}
+
+// agentConsole is where the heartbeat h shows agent output on the console: w,
+// or nowhere with --quiet, which keeps it in the agent logs only
+func agentConsole(h *heartbeat, w io.Writer) io.Writer {
+	if config.AgentLogs.Quiet {
+		return io.Discard
+	}
+	return h.Writer(w)
+}
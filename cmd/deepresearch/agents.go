@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// agentsFileName defines extra agent CLIs next to config.yaml
+const agentsFileName = "agents.yaml"
+
+// agentPriority is the order detectAgent tries agents in; agents defined in
+// agents.yaml follow the built-ins
+var agentPriority = []string{"claude", "copilot", "gemini"}
+
+// agentDefinition describes an agent CLI in agents.yaml. Argument templates
+// may use {prompt} (which must be an argument of its own) and {workdir}.
+type agentDefinition struct {
+	Command         string   `yaml:"command"`
+	Args            []string `yaml:"args"`             // non-interactive invocation, e.g. ["--message", "{prompt}", "--yes-always"]
+	InteractiveArgs []string `yaml:"interactive_args"` // interactive invocation; default: args
+	ModelFlag       string   `yaml:"model_flag"`       // passed with the model when --model is set, e.g. "--model"
+	// Usage tells that the agent prints token usage in a format --budget reads
+	// (see README); it defaults to the built-in's
+	Usage *bool `yaml:"usage"`
+}
+
+// agentsFile is the layout of agents.yaml
+type agentsFile struct {
+	Agents map[string]agentDefinition `yaml:"agents"`
+}
+
+// defaultAgentsPath returns ~/.deepresearch/agents.yaml
+func defaultAgentsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deepresearch", agentsFileName)
+}
+
+// loadAgents merges the agent definitions in agents.yaml into agentConfigs. A
+// definition named like a built-in overrides the fields it sets; the others
+// keep their built-in behavior. A missing file is not an error.
+func loadAgents(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read agents %s: %w", path, err)
+	}
+	var f agentsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid agents %s: %w", path, err)
+	}
+	for _, name := range sortedKeys(f.Agents) {
+		if err := registerAgent(name, f.Agents[name]); err != nil {
+			return fmt.Errorf("invalid agents %s: agent %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// registerAgent adds or overrides an agent from its definition
+func registerAgent(name string, def agentDefinition) error {
+	cfg, builtin := agentConfigs[name]
+	if def.Command != "" {
+		cfg.Command = def.Command
+	}
+	if cfg.Command == "" {
+		return fmt.Errorf("command is required")
+	}
+	if def.ModelFlag != "" {
+		cfg.ModelArg = def.ModelFlag
+	}
+	if def.Usage != nil {
+		cfg.Usage = *def.Usage
+	}
+	if def.Args != nil {
+		if err := checkArgTemplate(def.Args); err != nil {
+			return fmt.Errorf("args: %w", err)
+		}
+		cfg.Args = templateArgs(def.Args, cfg.ModelArg)
+		cfg.InteractiveArgs = cfg.Args
+	} else if !builtin {
+		return fmt.Errorf("args is required")
+	}
+	if def.InteractiveArgs != nil {
+		if err := checkArgTemplate(def.InteractiveArgs); err != nil {
+			return fmt.Errorf("interactive_args: %w", err)
+		}
+		cfg.InteractiveArgs = templateArgs(def.InteractiveArgs, cfg.ModelArg)
+	}
+	if !builtin && !slices.Contains(agentPriority, name) {
+		agentPriority = append(agentPriority, name)
+	}
+	agentConfigs[name] = cfg
+	return nil
+}
+
+// checkArgTemplate requires {prompt} as an argument of its own, so the prompt
+// can be handed over without quoting it into a longer argument
+func checkArgTemplate(tmpl []string) error {
+	found := false
+	for _, a := range tmpl {
+		if a == "{prompt}" {
+			found = true
+		} else if strings.Contains(a, "{prompt}") {
+			return fmt.Errorf("{prompt} must be an argument of its own, not part of %q", a)
+		}
+	}
+	if !found {
+		return fmt.Errorf("missing {prompt}")
+	}
+	return nil
+}
+
+// templateArgs builds the argument function of an agents.yaml template
+func templateArgs(tmpl []string, modelFlag string) func(prompt, model, workDir string) []string {
+	return func(prompt, model, workDir string) []string {
+		args := make([]string, 0, len(tmpl)+2)
+		for _, a := range tmpl {
+			if a == "{prompt}" {
+				args = append(args, prompt)
+				continue
+			}
+			args = append(args, strings.ReplaceAll(a, "{workdir}", workDir))
+		}
+		if model != "" && modelFlag != "" {
+			args = append(args, modelFlag, model)
+		}
+		return args
+	}
+}
+
+// agentNames lists the agents --agent accepts, in detection order
+func agentNames() string {
+	return strings.Join(append(slices.Clone(agentPriority), apiAgentName), ", ")
+}
+
+// mustLoadAgents loads ~/.deepresearch/agents.yaml or exits
+func mustLoadAgents() {
+	if err := loadAgents(defaultAgentsPath()); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+}
+
+// phaseAgentConfig selects the agent and model of one phase, e.g. a cheap
+// model for the research loop and a premium one for planning and synthesis
+type phaseAgentConfig struct {
+	Agent string `yaml:"agent"` // default: the run's agent (--agent)
+	// Model defaults to the run's model (--model) when the phase uses the run's
+	// agent, and to the agent's own default otherwise
+	Model string `yaml:"model"`
+}
+
+// agentPhase is a phase that can have an agent of its own: its key in config
+// phases and the prefix of its --*-agent and --*-model flags
+type agentPhase struct{ key, flag, title string }
+
+var agentPhases = []agentPhase{
+	{"planner", "planner", "Planner"},
+	{"research-supervisor", "supervisor", "Research-Supervisor"},
+	{"executor", "executor", "parallel executors of --max-parallel (default: the Research-Supervisor's)"},
+	{"reflector", "reflector", "Reflector"},
+	{"synthesizer", "synthesizer", "Synthesizer"},
+}
+
+// normalizePhaseAgents lower-cases the phase keys of config phases, rejecting unknown ones
+func normalizePhaseAgents(phases map[string]phaseAgentConfig) (map[string]phaseAgentConfig, error) {
+	normalized := map[string]phaseAgentConfig{}
+	for key, sel := range phases {
+		key = strings.ToLower(key)
+		if !slices.ContainsFunc(agentPhases, func(p agentPhase) bool { return p.key == key }) {
+			return nil, fmt.Errorf("unknown phase %q in phases (expected planner, research-supervisor, executor, reflector or synthesizer)", key)
+		}
+		normalized[key] = sel
+	}
+	return normalized, nil
+}
+
+// phaseAgent returns the agent and model a phase runs with, given the run's.
+// phase is a manifest phase name such as RESEARCH-SUPERVISOR, or executor;
+// the research phase of --max-parallel runs executors.
+func phaseAgent(phase, agentName, model string) (string, string) {
+	key := strings.ToLower(phase)
+	if key == "research-supervisor" && maxParallel > 0 {
+		key = "executor"
+	}
+	sel := config.Phases[key]
+	if key == "executor" {
+		supervisor := config.Phases["research-supervisor"]
+		if sel.Agent == "" {
+			sel.Agent = supervisor.Agent
+		}
+		if sel.Model == "" && sel.Agent == supervisor.Agent {
+			sel.Model = supervisor.Model
+		}
+	}
+	if sel.Agent == "" || sel.Agent == agentName {
+		if sel.Model == "" {
+			sel.Model = model
+		}
+		return agentName, sel.Model
+	}
+	return sel.Agent, sel.Model
+}
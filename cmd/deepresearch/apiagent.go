@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// apiAgentName is the --agent that talks to a provider's HTTP API instead of
+// running an agent CLI
+const apiAgentName = "api"
+
+// apiAgentArg is the first argument the deepresearch binary gets when it runs
+// as the api agent; it is an agent process like any CLI, so limits, timeouts,
+// retries and transcripts apply to it unchanged
+const apiAgentArg = "api-agent"
+
+// apiConfig selects the provider of --agent api
+type apiConfig struct {
+	Provider  string `yaml:"provider"`    // openai, anthropic, gemini (default: from --model, else the first API key set)
+	BaseURL   string `yaml:"base_url"`    // API endpoint, e.g. an OpenAI-compatible server (default: the provider's)
+	APIKeyEnv string `yaml:"api_key_env"` // environment variable holding the API key (default: the provider's, e.g. OPENAI_API_KEY)
+	MaxTurns  int    `yaml:"max_turns"`   // model replies per agent run before it is stopped (default 200)
+	MaxTokens int    `yaml:"max_tokens"`  // output tokens per reply (default 8192)
+}
+
+// apiToolOutputLimit caps the text a tool hands back to the model
+const apiToolOutputLimit = 100 * 1024
+
+// apiFetchLimit caps the bytes web_fetch downloads
+const apiFetchLimit = 20 << 20
+
+func init() {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	args := func(interactive bool) func(prompt, model, workDir string) []string {
+		return func(prompt, model, workDir string) []string {
+			cfg := config.API
+			args := []string{apiAgentArg, "-workdir", workDir, "-max-turns", fmt.Sprint(cfg.MaxTurns), "-max-tokens", fmt.Sprint(cfg.MaxTokens)}
+			for _, f := range [][2]string{{"-provider", cfg.Provider}, {"-base-url", cfg.BaseURL}, {"-key-env", cfg.APIKeyEnv}, {"-model", model}} {
+				if f[1] != "" {
+					args = append(args, f[0], f[1])
+				}
+			}
+			if interactive {
+				args = append(args, "-interactive")
+			}
+			return append(args, "-p", prompt)
+		}
+	}
+	agentConfigs[apiAgentName] = AgentConfig{
+		Command:         exe,
+		Args:            args(false),
+		InteractiveArgs: args(true),
+		Usage:           true,
+		Check: func(model string) error {
+			_, _, err := resolveAPIProvider(config.API, model)
+			return err
+		},
+	}
+}
+
+// runAPIAgent runs one agent task against a provider API: the model works in
+// the workspace through tools until it answers without calling one. With
+// -interactive, each answer is shown and the user's reply continues the
+// conversation until stdin closes.
+func runAPIAgent(args []string) {
+	fs := flag.NewFlagSet(apiAgentArg, flag.ExitOnError)
+	prompt := fs.String("p", "", "Prompt")
+	workDir := fs.String("workdir", ".", "Workspace the tools may write to")
+	model := fs.String("model", "", "Model (default: the provider's)")
+	provider := fs.String("provider", "", "Provider: openai, anthropic, gemini")
+	baseURL := fs.String("base-url", "", "API endpoint")
+	keyEnv := fs.String("key-env", "", "Environment variable holding the API key")
+	maxTurns := fs.Int("max-turns", 0, "Model replies before giving up")
+	maxTokens := fs.Int("max-tokens", 0, "Output tokens per reply")
+	interactive := fs.Bool("interactive", false, "Continue the conversation with the user's replies from stdin")
+	fs.Parse(args)
+
+	cfg := apiConfig{Provider: *provider, BaseURL: *baseURL, APIKeyEnv: *keyEnv, MaxTurns: *maxTurns, MaxTokens: *maxTokens}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", apiAgentArg, err)
+		os.Exit(1)
+	}
+	chat, err := newChatProvider(cfg, *model)
+	if err != nil {
+		fail(err)
+	}
+	root, err := filepath.Abs(*workDir)
+	if err != nil {
+		fail(err)
+	}
+	tools := &apiTools{workDir: root, client: &http.Client{Timeout: 60 * time.Second}}
+	if dir, err := findPromptsDir(); err == nil {
+		tools.promptsDir = dir
+	}
+	if err := runAPIConversation(context.Background(), chat, tools, *prompt, max(cfg.MaxTurns, 1), *interactive); err != nil {
+		fail(err)
+	}
+}
+
+// runAPIConversation drives the model and its tool calls, printing the
+// model's text and each tool call as the agent's transcript
+func runAPIConversation(ctx context.Context, chat chatProvider, tools *apiTools, prompt string, maxTurns int, interactive bool) error {
+	var stdin *bufio.Scanner
+	if interactive {
+		stdin = bufio.NewScanner(os.Stdin)
+		stdin.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	}
+	msgs := []chatMessage{{Role: roleUser, Text: prompt}}
+	for turn := 0; turn < maxTurns; turn++ {
+		reply, err := chat.Complete(ctx, msgs, apiToolSpecs)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, reply)
+		if reply.Text != "" {
+			fmt.Println(reply.Text)
+		}
+		if reply.Usage.tokens() > 0 {
+			// Metered by the orchestrator, which reads the agent's output
+			fmt.Printf("[usage] model=%s input_tokens=%d output_tokens=%d\n", chat.Model(), reply.Usage.InputTokens, reply.Usage.OutputTokens)
+		}
+		if len(reply.Calls) == 0 {
+			if !interactive {
+				return nil
+			}
+			fmt.Print("> ")
+			if !stdin.Scan() {
+				return nil
+			}
+			msgs = append(msgs, chatMessage{Role: roleUser, Text: stdin.Text()})
+			continue
+		}
+		results := chatMessage{Role: roleUser}
+		for _, call := range reply.Calls {
+			out, err := tools.run(ctx, call)
+			res := toolResult{CallID: call.ID, Name: call.Name, Content: out}
+			if err != nil {
+				res.Content, res.IsError = err.Error(), true
+				fmt.Printf("[tool] %s %s: error: %v\n", call.Name, call.summary(), err)
+			} else {
+				fmt.Printf("[tool] %s %s\n", call.Name, call.summary())
+			}
+			results.Results = append(results.Results, res)
+		}
+		msgs = append(msgs, results)
+	}
+	return fmt.Errorf("no final answer after %d model replies (api.max_turns)", maxTurns)
+}
+
+// apiToolSpecs are the tools the model may call
+var apiToolSpecs = []toolSpec{
+	{
+		Name: "web_fetch",
+		Description: "Fetch a URL over HTTP(S) and return its text (HTML is reduced to text). " +
+			"Set save_as to also store the raw response in the workspace, e.g. assets/pdf/paper.pdf for documents that are not text. " +
+			"To search the web, fetch https://html.duckduckgo.com/html/?q=<query>.",
+		Params: map[string]any{
+			"url":     map[string]any{"type": "string", "description": "http or https URL"},
+			"save_as": map[string]any{"type": "string", "description": "Optional workspace path to save the raw response to"},
+		},
+		Required: []string{"url"},
+	},
+	{
+		Name:        "read_file",
+		Description: "Read a text file from the workspace or the prompt guides.",
+		Params: map[string]any{
+			"path": map[string]any{"type": "string", "description": "Path relative to the workspace, or absolute"},
+		},
+		Required: []string{"path"},
+	},
+	{
+		Name:        "write_file",
+		Description: "Create or overwrite a file in the workspace, creating its directories.",
+		Params: map[string]any{
+			"path":    map[string]any{"type": "string", "description": "Path relative to the workspace, or absolute within it"},
+			"content": map[string]any{"type": "string", "description": "Complete file content"},
+		},
+		Required: []string{"path", "content"},
+	},
+	{
+		Name:        "list_files",
+		Description: "List the files under a workspace directory, recursively.",
+		Params: map[string]any{
+			"path": map[string]any{"type": "string", "description": "Directory relative to the workspace (default: the workspace)"},
+		},
+	},
+	{
+		Name:        "delete_file",
+		Description: "Delete a file in the workspace.",
+		Params: map[string]any{
+			"path": map[string]any{"type": "string", "description": "Path relative to the workspace, or absolute within it"},
+		},
+		Required: []string{"path"},
+	},
+}
+
+// apiTools runs tool calls confined to the workspace; the prompt guides may be read too
+type apiTools struct {
+	workDir    string
+	promptsDir string
+	client     *http.Client
+}
+
+func (t *apiTools) run(ctx context.Context, call toolCall) (string, error) {
+	var args struct {
+		URL     string `json:"url"`
+		SaveAs  string `json:"save_as"`
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+	if len(call.Args) > 0 {
+		if err := json.Unmarshal(call.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	switch call.Name {
+	case "web_fetch":
+		return t.fetch(ctx, args.URL, args.SaveAs)
+	case "read_file":
+		path, err := t.resolve(args.Path, true)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return truncateToolOutput(string(data)), nil
+	case "write_file":
+		path, err := t.resolve(args.Path, false)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, []byte(args.Content), 0644); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("wrote %d bytes to %s", len(args.Content), args.Path), nil
+	case "list_files":
+		dir, err := t.resolve(args.Path, false)
+		if err != nil {
+			return "", err
+		}
+		var files []string
+		err = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				rel, _ := filepath.Rel(t.workDir, p)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(files)
+		return truncateToolOutput(strings.Join(files, "\n")), nil
+	case "delete_file":
+		path, err := t.resolve(args.Path, false)
+		if err != nil {
+			return "", err
+		}
+		if err := os.Remove(path); err != nil {
+			return "", err
+		}
+		return "deleted " + args.Path, nil
+	}
+	return "", fmt.Errorf("unknown tool %s", call.Name)
+}
+
+// resolve maps a tool path into the workspace. Reads may also reach the prompt
+// guides, named by their absolute path or as prompts/deep-research/...
+func (t *apiTools) resolve(p string, read bool) (string, error) {
+	if p == "" {
+		p = "."
+	}
+	if read && t.promptsDir != "" {
+		if rel, ok := strings.CutPrefix(filepath.ToSlash(p), "prompts/deep-research/"); ok {
+			p = filepath.Join(t.promptsDir, filepath.FromSlash(rel))
+		}
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(t.workDir, p)
+	}
+	p = filepath.Clean(p)
+	if within(t.workDir, p) || (read && t.promptsDir != "" && within(t.promptsDir, p)) {
+		return p, nil
+	}
+	return "", fmt.Errorf("%s is outside the workspace", p)
+}
+
+// within reports whether path is root or below it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fetch downloads a URL, optionally saving the raw response in the workspace
+func (t *apiTools) fetch(ctx context.Context, url, saveAs string) (string, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", fmt.Errorf("only http and https URLs can be fetched")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "deepresearch/"+version)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, apiFetchLimit))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	note := ""
+	if saveAs != "" {
+		path, err := t.resolve(saveAs, false)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, body, 0644); err != nil {
+			return "", err
+		}
+		note = fmt.Sprintf("[saved %d bytes to %s]\n", len(body), saveAs)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case strings.Contains(mediaType, "html"):
+		text := strings.Join(strings.Fields(html.UnescapeString(htmlTagRe.ReplaceAllString(string(body), " "))), " ")
+		return note + truncateToolOutput(text), nil
+	case mediaType == "" || strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") || strings.Contains(mediaType, "xml"):
+		return note + truncateToolOutput(string(body)), nil
+	}
+	if note == "" {
+		note = "[binary content not shown; fetch again with save_as to keep it]\n"
+	}
+	return fmt.Sprintf("%s[%s, %d bytes]", note, mediaType, len(body)), nil
+}
+
+// truncateToolOutput keeps tool output within apiToolOutputLimit, cutting at a
+// rune boundary
+func truncateToolOutput(s string) string {
+	if len(s) <= apiToolOutputLimit {
+		return s
+	}
+	n := apiToolOutputLimit
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + fmt.Sprintf("\n[truncated: %d of %d bytes shown]", n, len(s))
+}
+
+// summary describes a tool call for the transcript
+func (c toolCall) summary() string {
+	var args map[string]any
+	json.Unmarshal(c.Args, &args)
+	for _, key := range []string{"url", "path"} {
+		if v, ok := args[key].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// errNoAPIKey reports that no provider API key is set
+var errNoAPIKey = errors.New("no API key set: export OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY (or set api.api_key_env)")
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateToolOutputKeepsRunes(t *testing.T) {
+	// "研" is 3 bytes, so the limit falls inside a rune for two of the offsets
+	for pad := range 3 {
+		s := strings.Repeat("a", pad) + strings.Repeat("研", apiToolOutputLimit/3+1)
+		got := truncateToolOutput(s)
+		if !utf8.ValidString(got) {
+			t.Errorf("pad %d: truncated output is not valid UTF-8", pad)
+		}
+		kept, _, _ := strings.Cut(got, "\n[truncated")
+		if len(kept) > apiToolOutputLimit || len(kept) < apiToolOutputLimit-2 {
+			t.Errorf("pad %d: kept %d bytes, want up to %d", pad, len(kept), apiToolOutputLimit)
+		}
+	}
+	if s := strings.Repeat("研", 10); truncateToolOutput(s) != s {
+		t.Error("short output was changed")
+	}
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message roles of a conversation with a provider API
+const (
+	roleUser      = "user"
+	roleAssistant = "assistant"
+)
+
+// chatMessage is a provider-neutral conversation turn: the user's text, the
+// model's reply with its tool calls, or the results of those calls
+type chatMessage struct {
+	Role    string
+	Text    string
+	Calls   []toolCall
+	Results []toolResult
+	Usage   tokenUsage      // tokens of a reply, as the provider counts them
+	raw     json.RawMessage // the provider's own form of a reply, sent back as is
+}
+
+// toolCall is a tool the model asked to run
+type toolCall struct {
+	ID   string
+	Name string
+	Args json.RawMessage
+}
+
+// toolResult answers a toolCall
+type toolResult struct {
+	CallID  string
+	Name    string
+	Content string
+	IsError bool
+}
+
+// toolSpec declares a tool to the model
+type toolSpec struct {
+	Name        string
+	Description string
+	Params      map[string]any // JSON Schema of each parameter
+	Required    []string
+}
+
+// schema returns the JSON Schema of the tool's arguments
+func (s toolSpec) schema() map[string]any {
+	schema := map[string]any{"type": "object", "properties": s.Params}
+	if len(s.Required) > 0 {
+		schema["required"] = s.Required
+	}
+	return schema
+}
+
+// chatProvider sends a conversation to a model and returns its reply
+type chatProvider interface {
+	Complete(ctx context.Context, msgs []chatMessage, tools []toolSpec) (chatMessage, error)
+	Model() string
+}
+
+// apiProvider describes a supported provider API
+type apiProvider struct {
+	keyEnvs []string // environment variables that may hold the API key, in order
+	baseURL string
+	model   string // used when --model is not set
+}
+
+var apiProviders = map[string]apiProvider{
+	"anthropic": {[]string{"ANTHROPIC_API_KEY"}, "https://api.anthropic.com", "claude-sonnet-4-20250514"},
+	"openai":    {[]string{"OPENAI_API_KEY"}, "https://api.openai.com/v1", "gpt-4o"},
+	"gemini":    {[]string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}, "https://generativelanguage.googleapis.com", "gemini-2.0-flash"},
+}
+
+// apiProviderOrder is the order providers are picked in by the API key set
+var apiProviderOrder = []string{"anthropic", "openai", "gemini"}
+
+// resolveAPIProvider picks the provider for a model: api.provider, else the
+// one the model name belongs to, else the first whose API key is set. It
+// returns the provider's name and API key.
+func resolveAPIProvider(cfg apiConfig, model string) (string, string, error) {
+	name := cfg.Provider
+	if name == "" {
+		m := strings.ToLower(model)
+		switch {
+		case strings.HasPrefix(m, "claude"):
+			name = "anthropic"
+		case strings.HasPrefix(m, "gemini"):
+			name = "gemini"
+		case strings.HasPrefix(m, "gpt"), strings.HasPrefix(m, "chatgpt"), strings.HasPrefix(m, "o1"),
+			strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"):
+			name = "openai"
+		}
+	}
+	if name == "" && (cfg.APIKeyEnv != "" || cfg.BaseURL != "") {
+		name = "openai" // a custom endpoint is most likely OpenAI-compatible
+	}
+	if name == "" {
+		for _, n := range apiProviderOrder {
+			if apiKey(cfg, apiProviders[n]) != "" {
+				name = n
+				break
+			}
+		}
+	}
+	if name == "" {
+		return "", "", errNoAPIKey
+	}
+	p, ok := apiProviders[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown api.provider %q (expected openai, anthropic, gemini)", name)
+	}
+	key := apiKey(cfg, p)
+	if key == "" && cfg.BaseURL == "" {
+		env := cfg.APIKeyEnv
+		if env == "" {
+			env = strings.Join(p.keyEnvs, " or ")
+		}
+		return "", "", fmt.Errorf("%s is not set for the %s API", env, name)
+	}
+	return name, key, nil
+}
+
+// apiKey returns the API key of a provider from the environment
+func apiKey(cfg apiConfig, p apiProvider) string {
+	if cfg.APIKeyEnv != "" {
+		return os.Getenv(cfg.APIKeyEnv)
+	}
+	for _, env := range p.keyEnvs {
+		if key := os.Getenv(env); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// newChatProvider builds the client of the provider resolved for model
+func newChatProvider(cfg apiConfig, model string) (chatProvider, error) {
+	name, key, err := resolveAPIProvider(cfg, model)
+	if err != nil {
+		return nil, err
+	}
+	p := apiProviders[name]
+	c := apiClient{
+		baseURL:   strings.TrimSuffix(p.baseURL, "/"),
+		key:       key,
+		model:     p.model,
+		maxTokens: cfg.MaxTokens,
+		client:    &http.Client{Timeout: 10 * time.Minute},
+	}
+	if cfg.BaseURL != "" {
+		c.baseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	}
+	if model != "" {
+		c.model = model
+	}
+	if c.maxTokens <= 0 {
+		c.maxTokens = 8192
+	}
+	switch name {
+	case "anthropic":
+		return &anthropicChat{c}, nil
+	case "gemini":
+		return &geminiChat{c}, nil
+	}
+	return &openAIChat{c}, nil
+}
+
+// apiClient holds what every provider client needs
+type apiClient struct {
+	baseURL   string
+	key       string
+	model     string
+	maxTokens int
+	client    *http.Client
+}
+
+// Model returns the model the client talks to
+func (c apiClient) Model() string { return c.model }
+
+// apiMaxAttempts bounds the attempts of a request the provider rate limits or fails with a 5xx
+const apiMaxAttempts = 4
+
+// post sends a JSON request, retrying rate limits and server errors with
+// backoff (or the Retry-After the provider asks for), and decodes the response
+func (c apiClient) post(ctx context.Context, url string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode < 300 {
+			return json.Unmarshal(respBody, out)
+		}
+		apiErr := fmt.Errorf("%s returned %s: %s", url, resp.Status, apiErrorMessage(respBody))
+		if attempt >= apiMaxAttempts || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) {
+			return apiErr
+		}
+		delay := time.Duration(5<<(attempt-1)) * time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			delay = time.Duration(s) * time.Second
+		}
+		fmt.Fprintf(os.Stderr, "%v; retrying in %s\n", apiErr, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// apiErrorMessage extracts the error message of a provider's error response
+func apiErrorMessage(body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	s := strings.TrimSpace(string(body))
+	if len(s) > 500 {
+		s = s[:500] + "..."
+	}
+	return s
+}
+
+// openAIChat uses the OpenAI Chat Completions API, which many other servers implement too
+type openAIChat struct{ apiClient }
+
+func (c *openAIChat) Complete(ctx context.Context, msgs []chatMessage, tools []toolSpec) (chatMessage, error) {
+	type function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	}
+	type call struct {
+		ID       string   `json:"id"`
+		Type     string   `json:"type"`
+		Function function `json:"function"`
+	}
+	var messages []map[string]any
+	for _, m := range msgs {
+		switch {
+		case m.Role == roleAssistant:
+			msg := map[string]any{"role": "assistant", "content": m.Text}
+			var calls []call
+			for _, tc := range m.Calls {
+				calls = append(calls, call{ID: tc.ID, Type: "function", Function: function{Name: tc.Name, Arguments: string(tc.Args)}})
+			}
+			if len(calls) > 0 {
+				msg["tool_calls"] = calls
+			}
+			messages = append(messages, msg)
+		case len(m.Results) > 0:
+			for _, r := range m.Results {
+				messages = append(messages, map[string]any{"role": "tool", "tool_call_id": r.CallID, "content": r.Content})
+			}
+		default:
+			messages = append(messages, map[string]any{"role": "user", "content": m.Text})
+		}
+	}
+	var specs []map[string]any
+	for _, t := range tools {
+		specs = append(specs, map[string]any{"type": "function", "function": map[string]any{
+			"name": t.Name, "description": t.Description, "parameters": t.schema(),
+		}})
+	}
+	body := map[string]any{"model": c.model, "messages": messages, "tools": specs, "max_completion_tokens": c.maxTokens}
+
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []call `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int64 `json:"prompt_tokens"`
+			CompletionTokens int64 `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	headers := map[string]string{}
+	if c.key != "" {
+		headers["Authorization"] = "Bearer " + c.key
+	}
+	if err := c.post(ctx, c.baseURL+"/chat/completions", headers, body, &out); err != nil {
+		return chatMessage{}, err
+	}
+	if len(out.Choices) == 0 {
+		return chatMessage{}, fmt.Errorf("openai: empty response")
+	}
+	choice := out.Choices[0]
+	if choice.FinishReason == "length" {
+		return chatMessage{}, fmt.Errorf("openai: reply exceeded api.max_tokens (%d)", c.maxTokens)
+	}
+	reply := chatMessage{Role: roleAssistant, Text: choice.Message.Content}
+	reply.Usage = tokenUsage{InputTokens: out.Usage.PromptTokens, OutputTokens: out.Usage.CompletionTokens}
+	for _, tc := range choice.Message.ToolCalls {
+		args := tc.Function.Arguments
+		if args == "" {
+			args = "{}"
+		}
+		reply.Calls = append(reply.Calls, toolCall{ID: tc.ID, Name: tc.Function.Name, Args: json.RawMessage(args)})
+	}
+	return reply, nil
+}
+
+// anthropicChat uses the Anthropic Messages API
+type anthropicChat struct{ apiClient }
+
+func (c *anthropicChat) Complete(ctx context.Context, msgs []chatMessage, tools []toolSpec) (chatMessage, error) {
+	var messages []map[string]any
+	for _, m := range msgs {
+		switch {
+		case m.Role == roleAssistant:
+			messages = append(messages, map[string]any{"role": "assistant", "content": m.raw})
+		case len(m.Results) > 0:
+			var blocks []map[string]any
+			for _, r := range m.Results {
+				blocks = append(blocks, map[string]any{"type": "tool_result", "tool_use_id": r.CallID, "content": r.Content, "is_error": r.IsError})
+			}
+			messages = append(messages, map[string]any{"role": "user", "content": blocks})
+		default:
+			messages = append(messages, map[string]any{"role": "user", "content": m.Text})
+		}
+	}
+	var specs []map[string]any
+	for _, t := range tools {
+		specs = append(specs, map[string]any{"name": t.Name, "description": t.Description, "input_schema": t.schema()})
+	}
+	body := map[string]any{"model": c.model, "max_tokens": c.maxTokens, "messages": messages, "tools": specs}
+
+	var out struct {
+		Content    json.RawMessage `json:"content"`
+		StopReason string          `json:"stop_reason"`
+		Usage      struct {
+			InputTokens         int64 `json:"input_tokens"`
+			CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+			OutputTokens        int64 `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	headers := map[string]string{"x-api-key": c.key, "anthropic-version": "2023-06-01"}
+	if err := c.post(ctx, c.baseURL+"/v1/messages", headers, body, &out); err != nil {
+		return chatMessage{}, err
+	}
+	if out.StopReason == "max_tokens" {
+		return chatMessage{}, fmt.Errorf("anthropic: reply exceeded api.max_tokens (%d)", c.maxTokens)
+	}
+	var blocks []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(out.Content, &blocks); err != nil {
+		return chatMessage{}, fmt.Errorf("anthropic: %w", err)
+	}
+	reply := chatMessage{Role: roleAssistant, raw: out.Content}
+	reply.Usage = tokenUsage{
+		InputTokens:  out.Usage.InputTokens + out.Usage.CacheCreationTokens + out.Usage.CacheReadTokens,
+		OutputTokens: out.Usage.OutputTokens,
+	}
+	var text []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			text = append(text, b.Text)
+		case "tool_use":
+			reply.Calls = append(reply.Calls, toolCall{ID: b.ID, Name: b.Name, Args: b.Input})
+		}
+	}
+	if len(blocks) == 0 {
+		// An empty assistant turn cannot be sent back
+		reply.raw = json.RawMessage(`[{"type":"text","text":"(no reply)"}]`)
+	}
+	reply.Text = strings.Join(text, "\n")
+	return reply, nil
+}
+
+// geminiChat uses the Gemini generateContent API
+type geminiChat struct{ apiClient }
+
+func (c *geminiChat) Complete(ctx context.Context, msgs []chatMessage, tools []toolSpec) (chatMessage, error) {
+	var contents []any
+	for _, m := range msgs {
+		switch {
+		case m.Role == roleAssistant:
+			contents = append(contents, m.raw)
+		case len(m.Results) > 0:
+			var parts []map[string]any
+			for _, r := range m.Results {
+				response := map[string]any{"content": r.Content}
+				if r.IsError {
+					response = map[string]any{"error": r.Content}
+				}
+				parts = append(parts, map[string]any{"functionResponse": map[string]any{"name": r.Name, "response": response}})
+			}
+			contents = append(contents, map[string]any{"role": "user", "parts": parts})
+		default:
+			contents = append(contents, map[string]any{"role": "user", "parts": []map[string]any{{"text": m.Text}}})
+		}
+	}
+	var decls []map[string]any
+	for _, t := range tools {
+		decls = append(decls, map[string]any{"name": t.Name, "description": t.Description, "parameters": t.schema()})
+	}
+	body := map[string]any{
+		"contents":         contents,
+		"tools":            []map[string]any{{"functionDeclarations": decls}},
+		"generationConfig": map[string]any{"maxOutputTokens": c.maxTokens},
+	}
+
+	var out struct {
+		Candidates []struct {
+			Content      json.RawMessage `json:"content"`
+			FinishReason string          `json:"finishReason"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+		UsageMetadata struct {
+			PromptTokenCount     int64 `json:"promptTokenCount"`
+			CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+			ThoughtsTokenCount   int64 `json:"thoughtsTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent", c.baseURL, c.model)
+	if err := c.post(ctx, url, map[string]string{"x-goog-api-key": c.key}, body, &out); err != nil {
+		return chatMessage{}, err
+	}
+	if len(out.Candidates) == 0 {
+		return chatMessage{}, fmt.Errorf("gemini: no reply (block reason: %s)", out.PromptFeedback.BlockReason)
+	}
+	cand := out.Candidates[0]
+	if cand.FinishReason == "MAX_TOKENS" {
+		return chatMessage{}, fmt.Errorf("gemini: reply exceeded api.max_tokens (%d)", c.maxTokens)
+	}
+	var content struct {
+		Parts []struct {
+			Text         string `json:"text"`
+			Thought      bool   `json:"thought"`
+			FunctionCall *struct {
+				Name string          `json:"name"`
+				Args json.RawMessage `json:"args"`
+			} `json:"functionCall"`
+		} `json:"parts"`
+	}
+	if len(cand.Content) > 0 {
+		if err := json.Unmarshal(cand.Content, &content); err != nil {
+			return chatMessage{}, fmt.Errorf("gemini: %w", err)
+		}
+	}
+	reply := chatMessage{Role: roleAssistant, raw: cand.Content}
+	// Thinking is billed as output
+	reply.Usage = tokenUsage{
+		InputTokens:  out.UsageMetadata.PromptTokenCount,
+		OutputTokens: out.UsageMetadata.CandidatesTokenCount + out.UsageMetadata.ThoughtsTokenCount,
+	}
+	var text []string
+	for i, p := range content.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			args := p.FunctionCall.Args
+			if len(args) == 0 {
+				args = json.RawMessage("{}")
+			}
+			reply.Calls = append(reply.Calls, toolCall{ID: fmt.Sprintf("%s-%d", p.FunctionCall.Name, i), Name: p.FunctionCall.Name, Args: args})
+		case p.Text != "" && !p.Thought:
+			text = append(text, p.Text)
+		}
+	}
+	if len(content.Parts) == 0 {
+		reply.raw = json.RawMessage(`{"role":"model","parts":[{"text":"(no reply)"}]}`)
+	}
+	reply.Text = strings.Join(text, "\n")
+	return reply, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// assetManifestName is the asset manifest, in assets/
+const assetManifestName = "manifest.json"
+
+// assetEntry describes one archived source file under assets/
+type assetEntry struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+	SourceID string `json:"source_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Title    string `json:"title,omitempty"`
+	// Published is the publication or last-modified date (YYYY-MM-DD), PublishedFrom how it was found
+	Published     string       `json:"published,omitempty"`
+	PublishedFrom string       `json:"published_from,omitempty"`
+	License       *licenseInfo `json:"license,omitempty"`
+	Blocked       *blockInfo   `json:"blocked,omitempty"`
+	// Language is the detected ISO 639-1 language, Translation the working-language note for it
+	Language    string `json:"language,omitempty"`
+	Translation string `json:"translation,omitempty"`
+	// Stored is where the file went after synthesis: a .gz beside Path or a file
+	// under storage.archive_dir; Text the extracted text left in the workspace
+	Stored string `json:"stored,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+// assetManifest indexes everything archived under assets/
+type assetManifest struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Assets      []assetEntry `json:"assets"`
+}
+
+// registeredSource is a row of the Source Registry in task.md
+type registeredSource struct {
+	ID        string
+	URL       string
+	Title     string
+	Type      string
+	Accessed  string
+	LocalPath string
+}
+
+var (
+	// sourceIDRe matches a Source Registry ID cell such as "S01"
+	sourceIDRe = regexp.MustCompile(`^S\d+$`)
+	// isoDateRe matches an access date cell such as "2024-05-20"
+	isoDateRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// readSourceRegistry parses the Source Registry table rows in the task.md of
+// workDir. Column order varies between agents, so the URL and local path are
+// found by content.
+func readSourceRegistry(workDir string) []registeredSource {
+	content, err := os.ReadFile(taskFilePath(workDir))
+	if err != nil {
+		return nil
+	}
+	var sources []registeredSource
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		if len(cells) < 2 || !sourceIDRe.MatchString(cells[0]) {
+			continue
+		}
+		src := registeredSource{ID: cells[0]}
+		for _, c := range cells[1:] {
+			c = strings.Trim(c, "`")
+			switch {
+			case src.URL == "" && (strings.HasPrefix(c, "http://") || strings.HasPrefix(c, "https://")):
+				src.URL = c
+			case src.LocalPath == "" && isAssetPath(workDir, c):
+				src.LocalPath = filepath.ToSlash(c)
+			case src.Accessed == "" && isoDateRe.MatchString(c):
+				src.Accessed = c
+			case src.Title == "" && c != "" && !strings.HasPrefix(c, "http"):
+				src.Title = c
+			case src.Type == "" && c != "" && !strings.HasPrefix(c, "http"):
+				src.Type = c
+			}
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// buildAssetManifest scans assets/ and links each file to its Source Registry entry
+func buildAssetManifest(workDir string) *assetManifest {
+	byPath := map[string]registeredSource{}
+	for _, src := range readSourceRegistry(workDir) {
+		if src.LocalPath != "" {
+			byPath[src.LocalPath] = src
+		}
+	}
+
+	prev, _ := loadAssetManifest(workDir)
+	standIns := storedStandIns(prev)
+	m := &assetManifest{GeneratedAt: time.Now(), Assets: []assetEntry{}}
+	assetsDir := assetsPath(workDir)
+	filepath.WalkDir(assetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(workDir, path)
+		rel = filepath.ToSlash(rel)
+		if rel == assetRel(workDir, assetManifestName) || rel == assetRel(workDir, citationMetadataName) || standIns[rel] {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entry := assetEntry{Path: rel, Kind: assetKind(workDir, rel), Size: st.Size()}
+		entry.SHA256, _ = fileSHA256(path)
+		if src, ok := byPath[rel]; ok {
+			entry.SourceID, entry.URL, entry.Title = src.ID, src.URL, src.Title
+		}
+		if t, from := detectPublished(path); !t.IsZero() {
+			entry.Published, entry.PublishedFrom = t.Format("2006-01-02"), from
+		}
+		entry.License = detectLicenseFile(path)
+		entry.Blocked = detectBlockedFile(path)
+		if text, ok := extractText(path); ok && entry.Kind != "translations" {
+			entry.Language = detectLanguage(text)
+		}
+		m.Assets = append(m.Assets, entry)
+		return nil
+	})
+	keepStoredAssets(prev, m)
+	sort.Slice(m.Assets, func(i, j int) bool { return m.Assets[i].Path < m.Assets[j].Path })
+	linkTranslations(workDir, m)
+	return m
+}
+
+// assetKind returns the assets/ subdirectory a file of workDir lives in (web, pdf, ...)
+func assetKind(workDir, rel string) string {
+	parts := strings.Split(strings.TrimPrefix(rel, assetsDirName(workDir)+"/"), "/")
+	if len(parts) >= 2 {
+		return parts[0]
+	}
+	return "other"
+}
+
+// save writes the manifest to assets/manifest.json
+func (m *assetManifest) save(workDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(assetsPath(workDir, assetManifestName), data, 0644)
+}
+
+// loadAssetManifest reads assets/manifest.json
+func loadAssetManifest(workDir string) (*assetManifest, error) {
+	data, err := os.ReadFile(assetsPath(workDir, assetManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var m assetManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// updateAssetManifest rebuilds and saves the asset manifest, logging failures
+func updateAssetManifest(workDir string) *assetManifest {
+	m := buildAssetManifest(workDir)
+	if err := m.save(workDir); err != nil {
+		logEntry("WARN", "ASSETS", 0, "Could not write asset manifest", map[string]string{"error": err.Error()})
+	}
+	return m
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// batchLogName is the console output of a topic's run, under its session's logs/
+const batchLogName = "batch.log"
+
+// Outcomes of a batch topic besides those of its run's result.json
+const (
+	batchSkipped = "skipped" // not started: --fail-fast or an interrupt stopped the batch
+	batchUnknown = "unknown" // the run exited without writing result.json
+)
+
+// batchManifest is the topics file of `deepresearch batch`. Agent, model,
+// config and flags apply to every topic that does not set its own.
+type batchManifest struct {
+	Concurrency int          `yaml:"concurrency"`
+	Agent       string       `yaml:"agent"`
+	Model       string       `yaml:"model"`
+	Config      string       `yaml:"config"` // relative to the topics file
+	Flags       []string     `yaml:"flags"`  // research flags, e.g. ["--max-iterations", "3"]
+	Topics      []batchTopic `yaml:"topics"`
+}
+
+// batchTopic is one research topic of a batch: a prompt, or a file (relative
+// to the topics file) holding it. A plain string is a prompt.
+type batchTopic struct {
+	Prompt  string   `yaml:"prompt"`
+	File    string   `yaml:"file"`
+	Session string   `yaml:"session"` // default: <batch ID>-<NN>-<topic slug>
+	Agent   string   `yaml:"agent"`
+	Model   string   `yaml:"model"`
+	Config  string   `yaml:"config"`
+	Flags   []string `yaml:"flags"` // added to the manifest's flags
+}
+
+func (t *batchTopic) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		t.Prompt = value.Value
+		return nil
+	}
+	type plain batchTopic
+	return value.Decode((*plain)(t))
+}
+
+// label names a topic in progress lines and the summary
+func (t batchTopic) label() string {
+	s := t.Prompt
+	if s == "" {
+		s = t.File
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) > 60 {
+		s = string([]rune(s)[:57]) + "..."
+	}
+	return s
+}
+
+// batchTopicResult is the outcome of one topic in batch.json
+type batchTopicResult struct {
+	Topic       string  `json:"topic"`
+	Session     string  `json:"session"`
+	Dir         string  `json:"dir"`
+	Outcome     string  `json:"outcome"`
+	ErrorCode   string  `json:"error_code,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	DurationSec float64 `json:"duration_sec"`
+	CostUSD     float64 `json:"cost_usd,omitempty"`
+	Report      string  `json:"report,omitempty"`
+}
+
+// succeeded reports whether the topic's run produced a report
+func (r batchTopicResult) succeeded() bool {
+	return r.Outcome == outcomeCompleted || r.Outcome == outcomePartial
+}
+
+// batchResult is research/<batch ID>.json
+type batchResult struct {
+	ID          string             `json:"id"`
+	Manifest    string             `json:"manifest"`
+	StartedAt   time.Time          `json:"started_at"`
+	FinishedAt  time.Time          `json:"finished_at"`
+	Concurrency int                `json:"concurrency"`
+	Succeeded   int                `json:"succeeded"`
+	Failed      int                `json:"failed"`
+	Skipped     int                `json:"skipped"`
+	Topics      []batchTopicResult `json:"topics"`
+}
+
+// runBatch implements `deepresearch batch`: researches every topic of a
+// topics file in a session of its own, a few at a time, and summarizes which
+// succeeded. A failed topic does not stop the others unless -fail-fast is set.
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	file := fs.String("f", "", "Topics file (YAML)")
+	jobs := fs.Int("j", 0, "Topics researched at the same time (default: the file's concurrency, else 1)")
+	agent := fs.String("agent", "", "Agent of every topic, overriding the topics file")
+	model := fs.String("model", "", "Model of every topic, overriding the topics file")
+	failFast := fs.Bool("fail-fast", false, "Start no further topics once one fails")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch batch -f topics.yaml [-j N] [flags] [-- run flags for every topic]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *file == "" {
+		fatalCode(codeValidationFailed, "batch needs a topics file: -f topics.yaml")
+	}
+	m, err := loadBatchManifest(*file)
+	if err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	concurrency := m.Concurrency
+	if *jobs != 0 {
+		concurrency = *jobs
+	}
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	if concurrency < 0 {
+		fatalCode(codeValidationFailed, "Invalid concurrency: %d (expected 1 or more)", concurrency)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate the deepresearch executable: %v", err)
+	}
+	root, err := filepath.Abs(sessionsDirName)
+	if err != nil {
+		fatal("Failed to resolve %s: %v", sessionsDirName, err)
+	}
+	manifestPath, _ := filepath.Abs(*file)
+
+	result := batchResult{
+		ID:          "batch-" + time.Now().Format("20060102-150405"),
+		Manifest:    manifestPath,
+		StartedAt:   time.Now(),
+		Concurrency: min(concurrency, len(m.Topics)),
+		Topics:      make([]batchTopicResult, len(m.Topics)),
+	}
+	runArgs := make([][]string, len(m.Topics))
+	seen := map[string]int{}
+	for i, t := range m.Topics {
+		session := t.Session
+		if session == "" {
+			session = fmt.Sprintf("%s-%02d%s", result.ID, i+1, sessionSlug(t.Prompt+" "+strings.TrimSuffix(filepath.Base(t.File), filepath.Ext(t.File))))
+		}
+		if err := checkSessionName(session); err != nil {
+			fatalCode(codeValidationFailed, "Topic %d: %v", i+1, err)
+		}
+		if prev, ok := seen[session]; ok {
+			fatalCode(codeValidationFailed, "Topics %d and %d share the session %s", prev, i+1, session)
+		}
+		seen[session] = i + 1
+		result.Topics[i] = batchTopicResult{Topic: t.label(), Session: session, Dir: filepath.Join(root, session), Outcome: batchSkipped}
+		runArgs[i] = batchRunArgs(m, t, session, *agent, *model, fs.Args())
+	}
+
+	phase("BATCH", fmt.Sprintf("%d topic(s), %d at a time", len(m.Topics), result.Concurrency))
+	// Stop starting topics and interrupt the running ones on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var (
+		mu      sync.Mutex
+		failed  bool
+		wg      sync.WaitGroup
+		pending = make(chan int, len(m.Topics))
+	)
+	for i := range m.Topics {
+		pending <- i
+	}
+	close(pending)
+	for w := 0; w < result.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				mu.Lock()
+				stopped := ctx.Err() != nil || *failFast && failed
+				mu.Unlock()
+				if stopped {
+					continue
+				}
+				r := &result.Topics[i]
+				info("[%d/%d] %s: started in %s", i+1, len(m.Topics), r.Topic, r.Dir)
+				runBatchTopic(ctx, exe, runArgs[i], r)
+				mu.Lock()
+				if !r.succeeded() {
+					failed = true
+				}
+				mu.Unlock()
+				if r.succeeded() {
+					success("[%d/%d] %s: %s in %s", i+1, len(m.Topics), r.Topic, r.Outcome, formatDuration(time.Duration(r.DurationSec*float64(time.Second))))
+				} else {
+					warn("[%d/%d] %s: %s [%s] %s (see %s)", i+1, len(m.Topics), r.Topic, r.Outcome, orDash(r.ErrorCode), orDash(r.Error), filepath.Join(r.Dir, "logs", batchLogName))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	result.FinishedAt = time.Now()
+
+	for _, r := range result.Topics {
+		switch {
+		case r.succeeded():
+			result.Succeeded++
+		case r.Outcome == batchSkipped:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+	}
+	table := batchTable(result)
+	data, _ := json.MarshalIndent(result, "", "  ")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		warn("Could not create %s: %v", root, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, result.ID+".json"), data, 0644); err != nil {
+		warn("Could not write %s.json: %v", result.ID, err)
+	}
+	md := fmt.Sprintf("# Batch %s\n\n- Topics file: `%s`\n- Started: %s\n- Finished: %s\n- Succeeded: %d, failed: %d, skipped: %d\n\n%s",
+		result.ID, result.Manifest, result.StartedAt.Format(time.RFC3339), result.FinishedAt.Format(time.RFC3339),
+		result.Succeeded, result.Failed, result.Skipped, table)
+	summary := filepath.Join(root, result.ID+".md")
+	if err := os.WriteFile(summary, []byte(md), 0644); err != nil {
+		warn("Could not write %s: %v", summary, err)
+	}
+	fmt.Println()
+	fmt.Print(table)
+	fmt.Println()
+
+	switch {
+	case ctx.Err() != nil:
+		fatalCode(codeInterrupted, "Batch interrupted: %d of %d topic(s) succeeded (summary: %s)", result.Succeeded, len(m.Topics), summary)
+	case result.Failed > 0:
+		fatalCode(codeAgentFailed, "%d of %d topic(s) failed, %d skipped (summary: %s)", result.Failed, len(m.Topics), result.Skipped, summary)
+	}
+	success("%d topic(s) researched (summary: %s)", result.Succeeded, summary)
+}
+
+// loadBatchManifest reads and checks a topics file, resolving the paths in it
+// against the file's directory
+func loadBatchManifest(path string) (*batchManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the topics file: %w", err)
+	}
+	var m batchManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid topics file %s: %w", path, err)
+	}
+	if len(m.Topics) == 0 {
+		return nil, fmt.Errorf("%s lists no topics", path)
+	}
+	if m.Concurrency < 0 {
+		return nil, fmt.Errorf("invalid concurrency in %s: %d (expected 1 or more)", path, m.Concurrency)
+	}
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	m.Config = resolve(m.Config)
+	for i := range m.Topics {
+		t := &m.Topics[i]
+		if (strings.TrimSpace(t.Prompt) == "") == (t.File == "") {
+			return nil, fmt.Errorf("topic %d of %s needs a prompt or a file, not both", i+1, path)
+		}
+		t.File, t.Config = resolve(t.File), resolve(t.Config)
+		if t.File != "" && !fileExists(t.File) {
+			return nil, fmt.Errorf("topic %d of %s: %s does not exist", i+1, path, t.File)
+		}
+	}
+	return &m, nil
+}
+
+// batchRunArgs builds the research flags of a topic's run: the topic's own
+// settings over the manifest's, and the command line's over both
+func batchRunArgs(m *batchManifest, t batchTopic, session, agent, model string, extra []string) []string {
+	pick := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	args := []string{"-session", session}
+	if v := pick(agent, t.Agent, m.Agent); v != "" {
+		args = append(args, "-agent", v)
+	}
+	if v := pick(model, t.Model, m.Model); v != "" {
+		args = append(args, "-model", v)
+	}
+	if v := pick(t.Config, m.Config); v != "" {
+		args = append(args, "-config", v)
+	}
+	args = append(args, m.Flags...)
+	args = append(args, t.Flags...)
+	args = append(args, extra...)
+	if t.File != "" {
+		return append(args, "-f", t.File)
+	}
+	return append(args, "-p", t.Prompt)
+}
+
+// runBatchTopic researches one topic in a child deepresearch process, with its
+// console output in the session's logs/batch.log, and records the outcome from
+// its result.json and run manifest
+func runBatchTopic(ctx context.Context, exe string, args []string, r *batchTopicResult) {
+	started := time.Now()
+	defer func() { r.DurationSec = time.Since(started).Seconds() }()
+	r.Outcome = batchUnknown
+	logDir := filepath.Join(r.Dir, "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		r.Outcome, r.ErrorCode, r.Error = outcomeFailed, codeInternal, err.Error()
+		return
+	}
+	out, err := os.OpenFile(filepath.Join(logDir, batchLogName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		r.Outcome, r.ErrorCode, r.Error = outcomeFailed, codeInternal, err.Error()
+		return
+	}
+	defer out.Close()
+	// A reused session still holds the result of its last run
+	os.Remove(filepath.Join(r.Dir, resultFileName))
+	// Sessions resolve against the directory the batch runs in
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = out, out
+	setProcessGroup(cmd)
+	runErr := runBatchProcess(ctx, cmd)
+
+	var res runResult
+	if data, err := os.ReadFile(filepath.Join(r.Dir, resultFileName)); err == nil {
+		json.Unmarshal(data, &res)
+	}
+	if res.Outcome != "" {
+		r.Outcome = res.Outcome
+	}
+	r.ErrorCode, r.Error, r.Report = res.ErrorCode, res.Error, res.Report
+	if m, err := loadManifest(r.Dir); err == nil && m.Usage != nil {
+		r.CostUSD = m.Usage.CostUSD
+	}
+	if runErr != nil && r.succeeded() {
+		r.Outcome = outcomeFailed
+	}
+	if r.succeeded() {
+		return
+	}
+	if r.Outcome == batchUnknown && runErr != nil {
+		r.Outcome = outcomeFailed
+	}
+	// A run that fails before its workspace is set up, e.g. on an invalid
+	// flag, only reports the error on its console
+	if r.Error == "" {
+		r.ErrorCode, r.Error = lastLoggedError(filepath.Join(r.Dir, "logs", batchLogName))
+	}
+	if r.Error == "" && runErr != nil {
+		r.Error = runErr.Error()
+	}
+	if r.ErrorCode == "" {
+		r.ErrorCode = codeInternal
+	}
+}
+
+// batchErrorRe matches the fatal error line of a run's console output
+var batchErrorRe = regexp.MustCompile(`\[ERROR\](?:\x1b\[0m)? \[([A-Z_]+)\] (.+)`)
+
+// lastLoggedError returns the code and message of the last fatal error in a
+// run's console output
+func lastLoggedError(log string) (string, string) {
+	lines := tailFile(log, 20)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if m := batchErrorRe.FindStringSubmatch(lines[i]); m != nil {
+			return m[1], m[2]
+		}
+	}
+	return "", ""
+}
+
+// runBatchProcess runs cmd, letting it stop its agents and record the
+// interruption once ctx is canceled before killing what is left
+func runBatchProcess(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-ctx.Done():
+		terminateProcess(cmd)
+		select {
+		case err := <-exited:
+			killProcessTree(cmd)
+			return err
+		case <-time.After(shutdownGrace):
+			killProcessTree(cmd)
+			return <-exited
+		}
+	}
+}
+
+// batchTable renders the outcome of every topic as a Markdown table
+func batchTable(result batchResult) string {
+	var b strings.Builder
+	b.WriteString("| # | Topic | Outcome | Code | Duration | Cost | Session |\n|---|---|---|---|---|---|---|\n")
+	for i, r := range result.Topics {
+		duration, cost := "-", "-"
+		if r.Outcome != batchSkipped {
+			duration = formatDuration(time.Duration(r.DurationSec * float64(time.Second)))
+		}
+		if r.CostUSD > 0 {
+			cost = fmt.Sprintf("$%.2f", r.CostUSD)
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %s | %s |\n", i+1, strings.ReplaceAll(r.Topic, "|", `\|`), r.Outcome, orDash(r.ErrorCode), duration, cost, r.Session)
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Bibliography files written next to report.md
+const (
+	bibTeXFileName  = "references.bib"
+	cslJSONFileName = "references.json"
+)
+
+// cslName is a CSL-JSON name variable
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// cslDate is a CSL-JSON date variable
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// cslItem is one CSL-JSON bibliography entry
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	Accessed       *cslDate  `json:"accessed,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+}
+
+// toCSLDate converts YYYY[-MM[-DD]] into CSL date parts
+func toCSLDate(s string) *cslDate {
+	var parts []int
+	for _, p := range strings.SplitN(s, "-", 3) {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &cslDate{DateParts: [][]int{parts}}
+}
+
+// toCSL converts a citation into a CSL-JSON item
+func toCSL(c citation) cslItem {
+	item := cslItem{
+		ID:             c.ID,
+		Type:           c.Type,
+		Title:          c.Title,
+		Issued:         toCSLDate(c.Issued),
+		Accessed:       toCSLDate(c.Accessed),
+		ContainerTitle: c.Container,
+		Publisher:      c.Publisher,
+		URL:            c.URL,
+		DOI:            c.DOI,
+	}
+	for _, a := range c.Authors {
+		family, given := splitName(a)
+		if given == "" {
+			item.Author = append(item.Author, cslName{Literal: family})
+		} else {
+			item.Author = append(item.Author, cslName{Family: family, Given: given})
+		}
+	}
+	return item
+}
+
+// bibTeXTypes maps CSL item types to biblatex entry types
+var bibTeXTypes = map[string]string{
+	"article-journal":   "article",
+	"article-newspaper": "article",
+	"paper-conference":  "inproceedings",
+	"chapter":           "incollection",
+	"dataset":           "dataset",
+	"report":            "report",
+	"book":              "book",
+	"motion_picture":    "video",
+	"legal_case":        "jurisdiction",
+}
+
+// bibEscape escapes LaTeX special characters in a field value
+var bibEscape = strings.NewReplacer(`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`)
+
+// toBibTeX renders a citation as a biblatex entry
+func toBibTeX(c citation) string {
+	entryType := bibTeXTypes[c.Type]
+	if entryType == "" {
+		entryType = "online"
+	}
+	var fields [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{name, value})
+		}
+	}
+	add("title", bibEscape.Replace(c.Title))
+	var authors []string
+	for _, a := range c.Authors {
+		if family, given := splitName(a); given == "" {
+			authors = append(authors, "{"+bibEscape.Replace(family)+"}")
+		} else {
+			authors = append(authors, bibEscape.Replace(family+", "+given))
+		}
+	}
+	add("author", strings.Join(authors, " and "))
+	add("date", c.Issued)
+	switch entryType {
+	case "article":
+		add("journaltitle", bibEscape.Replace(c.Container))
+	case "inproceedings", "incollection":
+		add("booktitle", bibEscape.Replace(c.Container))
+	default:
+		add("organization", bibEscape.Replace(c.Container))
+	}
+	add("publisher", bibEscape.Replace(c.Publisher))
+	add("doi", c.DOI)
+	add("url", c.URL)
+	add("urldate", c.Accessed)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@%s{%s,\n", entryType, c.ID)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "  %s = {%s},\n", f[0], f[1])
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeBibliography writes references.bib and references.json for the run's
+// registered sources and returns how many entries were written
+func writeBibliography(workDir string) (int, error) {
+	citations := buildCitations(workDir)
+	if len(citations) == 0 {
+		return 0, nil
+	}
+	items := make([]cslItem, len(citations))
+	var bib strings.Builder
+	for i, c := range citations {
+		items[i] = toCSL(c)
+		if i > 0 {
+			bib.WriteString("\n")
+		}
+		bib.WriteString(toBibTeX(c))
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, cslJSONFileName), data, 0644); err != nil {
+		return 0, err
+	}
+	return len(citations), os.WriteFile(filepath.Join(workDir, bibTeXFileName), []byte(bib.String()), 0644)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/researchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// maxTaskMessage bounds a task or result message (the archived files dominate)
+const maxTaskMessage = 256 << 20
+
+// workersConfig connects research runs and `deepresearch worker` processes to
+// the broker that carries executor tasks between them
+type workersConfig struct {
+	Broker         string `yaml:"broker"`          // grpc (a `deepresearch serve` with grpc_addr) or redis; "" = executors run locally
+	Addr           string `yaml:"addr"`            // serve's gRPC address or a redis:// URL
+	TokenEnv       string `yaml:"token_env"`       // API token for a serve with tenants (grpc)
+	TLS            bool   `yaml:"tls"`             // use TLS for the gRPC connection
+	TimeoutMinutes int    `yaml:"timeout_minutes"` // how long dispatch waits for a worker (default 30)
+	Slots          int    `yaml:"slots"`           // tasks one worker runs concurrently (default 1)
+}
+
+// taskBroker carries executor tasks from dispatching runs to remote workers.
+// Dispatch blocks until a worker completes the task or ctx ends; Next waits up to
+// wait for a task and returns nil when none arrives.
+type taskBroker interface {
+	Dispatch(ctx context.Context, t *researchpb.Task) (*researchpb.TaskResult, error)
+	Next(ctx context.Context, worker string, wait time.Duration) (*researchpb.Task, error)
+	Complete(ctx context.Context, r *researchpb.TaskResult) error
+	Close() error
+}
+
+// taskBrokers maps a workers.broker name to its constructor
+var taskBrokers = map[string]func(cfg workersConfig) (taskBroker, error){
+	"grpc": dialGRPCBroker,
+}
+
+// registerTaskBroker adds a broker backend; called from init functions
+func registerTaskBroker(name string, open func(cfg workersConfig) (taskBroker, error)) {
+	taskBrokers[name] = open
+}
+
+// openTaskBroker opens the broker configured in workers
+func openTaskBroker(cfg workersConfig) (taskBroker, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("no workers.broker configured")
+	}
+	open, ok := taskBrokers[cfg.Broker]
+	if !ok {
+		return nil, fmt.Errorf("unknown broker %q (available: %s)", cfg.Broker, strings.Join(sortedKeys(taskBrokers), ", "))
+	}
+	return open(cfg)
+}
+
+// memoryBroker queues tasks in process memory; `deepresearch serve` keeps one per
+// tenant behind the Workers gRPC service
+type memoryBroker struct {
+	tasks   chan *researchpb.Task
+	mu      sync.Mutex
+	waiting map[string]chan *researchpb.TaskResult
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{tasks: make(chan *researchpb.Task, 1024), waiting: map[string]chan *researchpb.TaskResult{}}
+}
+
+func (b *memoryBroker) Dispatch(ctx context.Context, t *researchpb.Task) (*researchpb.TaskResult, error) {
+	done := make(chan *researchpb.TaskResult, 1)
+	b.mu.Lock()
+	b.waiting[t.Id] = done
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.waiting, t.Id)
+		b.mu.Unlock()
+	}()
+	select {
+	case b.tasks <- t:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case r := <-done:
+		return r, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *memoryBroker) Next(ctx context.Context, worker string, wait time.Duration) (*researchpb.Task, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case t := <-b.tasks:
+			// Skip tasks whose dispatcher gave up while they were queued
+			b.mu.Lock()
+			_, ok := b.waiting[t.Id]
+			b.mu.Unlock()
+			if ok {
+				return t, nil
+			}
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (b *memoryBroker) Complete(_ context.Context, r *researchpb.TaskResult) error {
+	b.mu.Lock()
+	done, ok := b.waiting[r.Id]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("task %s is no longer awaited", r.Id)
+	}
+	select {
+	case done <- r:
+	default:
+	}
+	return nil
+}
+
+func (b *memoryBroker) Close() error { return nil }
+
+// grpcBroker talks to the Workers service of a `deepresearch serve`
+type grpcBroker struct {
+	conn   *grpc.ClientConn
+	client researchpb.WorkersClient
+	token  string
+}
+
+func dialGRPCBroker(cfg workersConfig) (taskBroker, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("workers.addr is required for the grpc broker")
+	}
+	creds := insecure.NewCredentials()
+	if cfg.TLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.NewClient(cfg.Addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxTaskMessage), grpc.MaxCallSendMsgSize(maxTaskMessage)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	b := &grpcBroker{conn: conn, client: researchpb.NewWorkersClient(conn)}
+	if cfg.TokenEnv != "" {
+		b.token = os.Getenv(cfg.TokenEnv)
+	}
+	return b, nil
+}
+
+func (b *grpcBroker) auth(ctx context.Context) context.Context {
+	if b.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+b.token)
+}
+
+func (b *grpcBroker) Dispatch(ctx context.Context, t *researchpb.Task) (*researchpb.TaskResult, error) {
+	return b.client.Dispatch(b.auth(ctx), t)
+}
+
+func (b *grpcBroker) Next(ctx context.Context, worker string, wait time.Duration) (*researchpb.Task, error) {
+	t, err := b.client.Pull(b.auth(ctx), &researchpb.PullRequest{Worker: worker, WaitSeconds: int32(wait / time.Second)})
+	if err != nil || t.Id == "" {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (b *grpcBroker) Complete(ctx context.Context, r *researchpb.TaskResult) error {
+	_, err := b.client.Complete(b.auth(ctx), r)
+	return err
+}
+
+func (b *grpcBroker) Close() error { return b.conn.Close() }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/researchpb"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+)
+
+// Redis keys used by the redis task broker
+const (
+	redisTasksKey      = "deepresearch:tasks"    // list of queued tasks, oldest at the tail
+	redisResultsPrefix = "deepresearch:results:" // per-task list holding the worker's result
+)
+
+// redisResultTTL keeps an uncollected result around for a late dispatcher
+const redisResultTTL = time.Hour
+
+func init() {
+	registerTaskBroker("redis", openRedisBroker)
+}
+
+// redisBroker passes tasks and results through Redis lists, so workers need no
+// inbound connectivity and no `deepresearch serve`
+type redisBroker struct {
+	rdb *redis.Client
+}
+
+func openRedisBroker(cfg workersConfig) (taskBroker, error) {
+	dsn := cfg.Addr
+	if dsn == "" {
+		dsn = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Blocking pops outlast the default read timeout
+	opts.ReadTimeout = -1
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return &redisBroker{rdb: rdb}, nil
+}
+
+func (b *redisBroker) Dispatch(ctx context.Context, t *researchpb.Task) (*researchpb.TaskResult, error) {
+	data, err := proto.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.rdb.LPush(ctx, redisTasksKey, data).Err(); err != nil {
+		return nil, err
+	}
+	for {
+		// Pop in short rounds so a canceled context is noticed
+		v, err := b.rdb.BRPop(ctx, 5*time.Second, redisResultsPrefix+t.Id).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				// Withdraw the task if no worker has taken it yet
+				b.rdb.LRem(context.Background(), redisTasksKey, 1, data)
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		r := &researchpb.TaskResult{}
+		if err := proto.Unmarshal([]byte(v[1]), r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+}
+
+func (b *redisBroker) Next(ctx context.Context, worker string, wait time.Duration) (*researchpb.Task, error) {
+	v, err := b.rdb.BRPop(ctx, wait, redisTasksKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &researchpb.Task{}
+	if err := proto.Unmarshal([]byte(v[1]), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (b *redisBroker) Complete(ctx context.Context, r *researchpb.TaskResult) error {
+	data, err := proto.Marshal(r)
+	if err != nil {
+		return err
+	}
+	key := redisResultsPrefix + r.Id
+	pipe := b.rdb.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.Expire(ctx, key, redisResultTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (b *redisBroker) Close() error { return b.rdb.Close() }
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cachedSourcesFile lists the shared cache's sources for the agents of a run
+const cachedSourcesFile = "cached-sources.md"
+
+// sourceCacheIndex is the index of the source cache, relative to the shared cache directory
+const sourceCacheIndex = "sources/index.json"
+
+// cachedSourcesLimit caps the entries listed in cached-sources.md, most
+// recently used first
+const cachedSourcesLimit = 500
+
+// cachedSource is a fetched source kept in the shared cache. Files are stored
+// once per content hash, so URLs serving the same document share a file.
+type cachedSource struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	File   string `json:"file"` // relative to the shared cache directory
+	Kind   string `json:"kind"`
+	Title  string `json:"title,omitempty"`
+	Size   int64  `json:"size"`
+	// FetchedAt is when this content was first seen for the URL, which
+	// storage.cache_ttl_days counts from; UsedAt when a run last archived it
+	FetchedAt time.Time `json:"fetched_at"`
+	UsedAt    time.Time `json:"used_at"`
+}
+
+// sourceCache is the index of the shared source cache, keyed by URL
+type sourceCache struct {
+	dir     string
+	Sources map[string]*cachedSource `json:"sources"`
+}
+
+// cachedAtStart maps the URLs cached when the run started to their content
+// hash, to tell the sources this run reused from the ones it added
+var cachedAtStart map[string]string
+
+// sourceCacheEnabled reports whether the run reads and fills the shared source
+// cache. A replay keeps to the prior run's sources.
+func sourceCacheEnabled() bool {
+	return !config.Storage.NoCache && cacheDir() != "" && !offline()
+}
+
+// loadSourceCache reads the index of the shared source cache; a missing index is an empty cache
+func loadSourceCache() (*sourceCache, error) {
+	c := &sourceCache{dir: cacheDir(), Sources: map[string]*cachedSource{}}
+	data, err := os.ReadFile(filepath.Join(c.dir, filepath.FromSlash(sourceCacheIndex)))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", sourceCacheIndex, err)
+	}
+	if c.Sources == nil {
+		c.Sources = map[string]*cachedSource{}
+	}
+	return c, nil
+}
+
+// withSourceCache runs fn on the index of the shared source cache while holding
+// its lock, so concurrent runs never drop each other's entries or files
+func withSourceCache(fn func(c *sourceCache) error) error {
+	path := filepath.Join(cacheDir(), filepath.FromSlash(sourceCacheIndex))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return withFileLock(path, func() error {
+		c, err := loadSourceCache()
+		if err != nil {
+			return err
+		}
+		return fn(c)
+	})
+}
+
+func (c *sourceCache) save() error {
+	path := filepath.Join(c.dir, filepath.FromSlash(sourceCacheIndex))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// orphanGrace is how long a cached file no source refers to is kept: another
+// run may have copied it and not indexed it yet
+const orphanGrace = time.Hour
+
+// prune drops the sources fetched longer ago than storage.cache_ttl_days and
+// those whose file the cache quota evicted, then deletes the files no source
+// has referred to for orphanGrace. It returns the number of sources dropped.
+func (c *sourceCache) prune() int {
+	var cutoff time.Time
+	if days := config.Storage.CacheTTLDays; days > 0 {
+		cutoff = time.Now().AddDate(0, 0, -days)
+	}
+	dropped := 0
+	used := map[string]bool{}
+	for url, s := range c.Sources {
+		if s.FetchedAt.Before(cutoff) || !fileExists(filepath.Join(c.dir, filepath.FromSlash(s.File))) {
+			delete(c.Sources, url)
+			dropped++
+			continue
+		}
+		used[s.File] = true
+	}
+	files, _ := dirFiles(filepath.Join(c.dir, "sources"))
+	for _, f := range files {
+		if time.Since(f.mod) < orphanGrace {
+			continue
+		}
+		if rel := "sources/" + f.rel; !isSourceCacheIndex(rel) && !used[rel] && !strings.HasSuffix(rel, ".tmp") {
+			os.Remove(filepath.Join(c.dir, filepath.FromSlash(rel)))
+		}
+	}
+	return dropped
+}
+
+// isSourceCacheIndex reports whether rel, relative to the shared cache
+// directory, is the index of the source cache or its lock
+func isSourceCacheIndex(rel string) bool {
+	return rel == sourceCacheIndex || rel == sourceCacheIndex+".lock"
+}
+
+// prepareSourceCache expires old sources and lists the rest in
+// cached-sources.md, for agents to copy instead of fetching again
+func prepareSourceCache(workDir string) {
+	indexFile := filepath.Join(workDir, cachedSourcesFile)
+	os.Remove(indexFile)
+	if !sourceCacheEnabled() {
+		return
+	}
+	var c *sourceCache
+	err := withSourceCache(func(sc *sourceCache) error {
+		c = sc
+		if dropped := c.prune(); dropped > 0 {
+			if err := c.save(); err != nil {
+				warn("Could not update the source cache: %v", err)
+			}
+			logEntry("INFO", "CACHE", 0, "Expired cached sources", map[string]string{
+				"dropped": fmt.Sprintf("%d", dropped),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		warn("Could not read the source cache: %v", err)
+		return
+	}
+	cachedAtStart = map[string]string{}
+	for url, s := range c.Sources {
+		cachedAtStart[url] = s.SHA256
+	}
+	if len(c.Sources) == 0 {
+		return
+	}
+	if err := os.WriteFile(indexFile, []byte(c.markdown()), 0644); err != nil {
+		warn("Could not write %s: %v", cachedSourcesFile, err)
+		return
+	}
+	info("%d source(s) fetched by earlier runs are listed in %s for reuse", len(c.Sources), cachedSourcesFile)
+	logEntry("INFO", "CACHE", 0, "Listed cached sources", map[string]string{
+		"sources": fmt.Sprintf("%d", len(c.Sources)),
+		"cache":   c.dir,
+	})
+}
+
+// markdown renders cached-sources.md
+func (c *sourceCache) markdown() string {
+	sources := make([]*cachedSource, 0, len(c.Sources))
+	for _, s := range c.Sources {
+		sources = append(sources, s)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if !sources[i].UsedAt.Equal(sources[j].UsedAt) {
+			return sources[i].UsedAt.After(sources[j].UsedAt)
+		}
+		return sources[i].URL < sources[j].URL
+	})
+	var b strings.Builder
+	b.WriteString("# Cached Sources\n\n")
+	b.WriteString("Sources fetched by earlier runs, kept in the shared cache. Copy a cached file into\n")
+	b.WriteString("assets/ instead of fetching its URL again.\n\n")
+	if len(sources) > cachedSourcesLimit {
+		fmt.Fprintf(&b, "The %d most recently used of %d cached sources are listed.\n\n", cachedSourcesLimit, len(sources))
+		sources = sources[:cachedSourcesLimit]
+	}
+	b.WriteString("| URL | Type | Title | Fetched | Cached file |\n")
+	b.WriteString("|-----|------|-------|---------|-------------|\n")
+	cell := func(s string) string { return strings.ReplaceAll(s, "|", "\\|") }
+	for _, s := range sources {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", cell(s.URL), s.Kind, cell(s.Title),
+			s.FetchedAt.Format("2006-01-02"), filepath.Join(c.dir, filepath.FromSlash(s.File)))
+	}
+	return b.String()
+}
+
+// cacheSources adds the run's archived sources to the shared cache. Content
+// already cached is only marked as used; sources withheld by a paywall, and
+// everything when fetched content must not outlive the run, stay out.
+func cacheSources(workDir string, m *assetManifest) {
+	if m == nil || !sourceCacheEnabled() || !config.Privacy.PersistFetched || config.Privacy.Ephemeral {
+		return
+	}
+	// Files are copied before the index is locked, so a slow copy never holds
+	// up other runs; prune keeps them for orphanGrace until they are indexed
+	dir := cacheDir()
+	now := time.Now().UTC()
+	type copiedSource struct {
+		asset assetEntry
+		file  string
+	}
+	var copied []copiedSource
+	for _, a := range m.Assets {
+		if a.URL == "" || a.SHA256 == "" || a.Stored != "" || a.Blocked != nil || a.Kind == "translations" {
+			continue
+		}
+		file := "sources/" + a.SHA256[:2] + "/" + a.SHA256 + strings.ToLower(filepath.Ext(a.Path))
+		dst := filepath.Join(dir, filepath.FromSlash(file))
+		if fileExists(dst) {
+			// Keeps the file from the least recently used eviction of storage.max_cache_mb
+			os.Chtimes(dst, now, now)
+		} else if err := copyToCache(filepath.Join(workDir, filepath.FromSlash(a.Path)), dst); err != nil {
+			logEntry("WARN", "CACHE", 0, "Could not cache source", map[string]string{
+				"path":  a.Path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		copied = append(copied, copiedSource{a, file})
+	}
+	if len(copied) == 0 {
+		return
+	}
+	added, used := 0, 0
+	err := withSourceCache(func(c *sourceCache) error {
+		for _, cs := range copied {
+			a := cs.asset
+			s := c.Sources[a.URL]
+			if s == nil || s.SHA256 != a.SHA256 {
+				s = &cachedSource{URL: a.URL, SHA256: a.SHA256, File: cs.file, FetchedAt: now}
+				c.Sources[a.URL] = s
+				added++
+			} else {
+				used++
+			}
+			s.Kind, s.Size, s.UsedAt = a.Kind, a.Size, now
+			if a.Title != "" {
+				s.Title = a.Title
+			}
+		}
+		return c.save()
+	})
+	if err != nil {
+		logEntry("WARN", "CACHE", 0, "Could not update the source cache", map[string]string{"error": err.Error()})
+		return
+	}
+	logEntry("INFO", "CACHE", 0, "Cached archived sources", map[string]string{
+		"added": fmt.Sprintf("%d", added),
+		"used":  fmt.Sprintf("%d", used),
+	})
+}
+
+// reportSourceCache tells how many of the run's sources came from the cache and
+// how many it added
+func reportSourceCache(m *assetManifest) {
+	if m == nil || cachedAtStart == nil {
+		return
+	}
+	reused, added := 0, 0
+	for _, a := range m.Assets {
+		if a.URL == "" || a.SHA256 == "" || a.Blocked != nil || a.Kind == "translations" {
+			continue
+		}
+		if cachedAtStart[a.URL] == a.SHA256 {
+			reused++
+		} else if config.Privacy.PersistFetched && !config.Privacy.Ephemeral {
+			added++
+		}
+	}
+	if reused+added > 0 {
+		info("Source cache: %d source(s) reused, %d added", reused, added)
+	}
+}
+
+// copyToCache copies a source file into the cache, so readers never see a partial file
+func copyToCache(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// buildCachedSourcesNote points agents at cached-sources.md, or "" if there is none
+func buildCachedSourcesNote(workDir string) string {
+	if offline() || !fileExists(filepath.Join(workDir, cachedSourcesFile)) {
+		return ""
+	}
+	return fmt.Sprintf(`
+CACHED_SOURCES: %s lists pages and documents earlier runs already fetched, with their cached
+file. Before fetching a URL, look it up there. If it is listed, copy the cached file into assets/
+under your usual name (e.g. cp <cached file> assets/web/s03_name.html) instead of downloading it,
+and register it with the listed URL and its fetch date as the access date. Fetch it anew only if
+the task needs a more recent version than the cached one.
+`, cachedSourcesFile)
+}
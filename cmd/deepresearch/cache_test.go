@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheTestRun creates a workspace with n archived sources and returns it with its manifest
+func cacheTestRun(t *testing.T, name string, n int) (string, *assetManifest) {
+	t.Helper()
+	workDir := t.TempDir()
+	m := &assetManifest{}
+	for i := range n {
+		rel := fmt.Sprintf("assets/web/%s_%02d.html", name, i)
+		data := []byte(fmt.Sprintf("<html>%s %d</html>", name, i))
+		path := filepath.Join(workDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		m.Assets = append(m.Assets, assetEntry{
+			Path: rel, Kind: "web", Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:]),
+			URL: fmt.Sprintf("https://example.com/%s/%d", name, i),
+		})
+	}
+	return workDir, m
+}
+
+func TestSourceCacheInterleavedRuns(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.Storage.CacheDir = t.TempDir()
+
+	// Run A has copied a source into the cache but not indexed it yet when
+	// run B starts and prunes the cache
+	workA, a := cacheTestRun(t, "a", 1)
+	file := "sources/" + a.Assets[0].SHA256[:2] + "/" + a.Assets[0].SHA256 + ".html"
+	dst := filepath.Join(config.Storage.CacheDir, filepath.FromSlash(file))
+	if err := copyToCache(filepath.Join(workA, filepath.FromSlash(a.Assets[0].Path)), dst); err != nil {
+		t.Fatal(err)
+	}
+	prepareSourceCache(t.TempDir())
+	if !fileExists(dst) {
+		t.Fatalf("pruning deleted %s, copied by a run that had not indexed it yet", file)
+	}
+
+	// Once the grace period is over, a file no source refers to is deleted
+	old := time.Now().Add(-2 * orphanGrace)
+	os.Chtimes(dst, old, old)
+	prepareSourceCache(t.TempDir())
+	if fileExists(dst) {
+		t.Fatalf("pruning kept the orphaned %s", file)
+	}
+
+	// Concurrent runs each add their sources to the index
+	const runs = 6
+	var wg sync.WaitGroup
+	var manifests []*assetManifest
+	for i := range runs {
+		workDir, m := cacheTestRun(t, fmt.Sprintf("run%d", i), 5)
+		manifests = append(manifests, m)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cacheSources(workDir, m)
+		}()
+		// Another process expiring the cache at startup
+		go func() {
+			defer wg.Done()
+			err := withSourceCache(func(c *sourceCache) error {
+				c.prune()
+				return c.save()
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c, err := loadSourceCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range manifests {
+		for _, asset := range m.Assets {
+			s := c.Sources[asset.URL]
+			if s == nil {
+				t.Errorf("%s is missing from the index", asset.URL)
+				continue
+			}
+			if !fileExists(filepath.Join(c.dir, filepath.FromSlash(s.File))) {
+				t.Errorf("the cached file of %s was deleted", asset.URL)
+			}
+		}
+	}
+}
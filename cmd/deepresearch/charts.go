@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Chart formats accepted by --charts
+const (
+	chartsOff      = "off"
+	chartsMermaid  = "mermaid"
+	chartsVegaLite = "vega-lite"
+)
+
+// chartFormat is the active --charts setting
+var chartFormat = chartsOff
+
+// minChartRows is the smallest table worth charting
+const minChartRows = 3
+
+var (
+	tableSepRe  = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	headingRe   = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	yearLabelRe = regexp.MustCompile(`^(?:19|20)\d{2}(?:\s*[QH][1-4])?$`)
+	numCleanRe  = regexp.MustCompile(`[\s,$€£¥%*~≈+]|(?i)\b(?:usd|eur|gbp|cny|rmb)\b`)
+)
+
+// chartTable is a Markdown table with a label column and numeric series
+type chartTable struct {
+	Title    string
+	Labels   []string
+	Series   []chartSeries
+	Temporal bool
+}
+
+type chartSeries struct {
+	Name   string
+	Values []float64
+}
+
+// parseNumber reads a table cell such as "$1,200", "45%" or "3.2B" (the unit
+// suffix is dropped, so series are only comparable within a column)
+func parseNumber(cell string) (float64, bool) {
+	s := numCleanRe.ReplaceAllString(cell, "")
+	s = strings.TrimRight(s, "KMBTkmbtn")
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// splitRow splits a Markdown table row into trimmed cells
+func splitRow(line string) []string {
+	cells := strings.Split(strings.Trim(strings.TrimSpace(line), "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(cells[i], "**", ""))
+	}
+	return cells
+}
+
+// toChartTable returns the chartable part of a table: the first column as labels
+// and every column whose cells are all numeric as a series
+func toChartTable(title string, header []string, rows [][]string) (chartTable, bool) {
+	t := chartTable{Title: title, Temporal: true}
+	if len(rows) < minChartRows || len(header) < 2 {
+		return t, false
+	}
+	for _, r := range rows {
+		if len(r) == 0 {
+			return t, false
+		}
+		t.Labels = append(t.Labels, r[0])
+		if !yearLabelRe.MatchString(r[0]) {
+			t.Temporal = false
+		}
+	}
+	for col := 1; col < len(header); col++ {
+		s := chartSeries{Name: header[col]}
+		ok := true
+		for _, r := range rows {
+			if col >= len(r) {
+				ok = false
+				break
+			}
+			v, isNum := parseNumber(r[col])
+			if !isNum {
+				ok = false
+				break
+			}
+			s.Values = append(s.Values, v)
+		}
+		if ok {
+			t.Series = append(t.Series, s)
+		}
+	}
+	return t, len(t.Series) > 0
+}
+
+// mermaidChart renders a table as a Mermaid xychart (line for time series, bar otherwise)
+func mermaidChart(t chartTable) string {
+	var b strings.Builder
+	b.WriteString("```mermaid\nxychart-beta\n")
+	fmt.Fprintf(&b, "    title %q\n", t.Title)
+	labels := make([]string, len(t.Labels))
+	for i, l := range t.Labels {
+		labels[i] = strconv.Quote(l)
+	}
+	fmt.Fprintf(&b, "    x-axis [%s]\n", strings.Join(labels, ", "))
+	if len(t.Series) == 1 {
+		fmt.Fprintf(&b, "    y-axis %q\n", t.Series[0].Name)
+	}
+	mark := "bar"
+	if t.Temporal {
+		mark = "line"
+	}
+	for _, s := range t.Series {
+		vals := make([]string, len(s.Values))
+		for i, v := range s.Values {
+			vals[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		fmt.Fprintf(&b, "    %s [%s]\n", mark, strings.Join(vals, ", "))
+	}
+	b.WriteString("```")
+	return b.String()
+}
+
+// vegaLiteChart renders a table as a Vega-Lite spec in long format
+func vegaLiteChart(t chartTable) string {
+	var values []map[string]any
+	for _, s := range t.Series {
+		for i, v := range s.Values {
+			values = append(values, map[string]any{"label": t.Labels[i], "series": s.Name, "value": v})
+		}
+	}
+	mark, xType := "bar", "nominal"
+	if t.Temporal {
+		mark, xType = "line", "ordinal"
+	}
+	spec := map[string]any{
+		"$schema": "https://vega.github.io/schema/vega-lite/v5.json",
+		"title":   t.Title,
+		"data":    map[string]any{"values": values},
+		"mark":    map[string]any{"type": mark, "point": t.Temporal, "tooltip": true},
+		"encoding": map[string]any{
+			"x":     map[string]any{"field": "label", "type": xType, "sort": nil, "title": nil},
+			"y":     map[string]any{"field": "value", "type": "quantitative", "title": nil},
+			"color": map[string]any{"field": "series", "type": "nominal", "title": nil},
+		},
+	}
+	if !t.Temporal && len(t.Series) > 1 {
+		spec["encoding"].(map[string]any)["xOffset"] = map[string]any{"field": "series"}
+	}
+	data, _ := json.MarshalIndent(spec, "", "  ")
+	return "```vega-lite\n" + string(data) + "\n```"
+}
+
+// addCharts inserts a chart after every quantitative table in report.md that does
+// not already have one and returns how many were added
+func addCharts(workDir, format string) (int, error) {
+	reportFile := reportFilePath(workDir)
+	content, err := os.ReadFile(reportFile)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(content), "\n")
+	charts := map[int]string{}
+	for _, tbl := range parseMarkdownTables(lines) {
+		// Skip tables that are already followed by a chart
+		next := tbl.End
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next < len(lines) && (strings.HasPrefix(strings.TrimSpace(lines[next]), "```mermaid") || strings.HasPrefix(strings.TrimSpace(lines[next]), "```vega-lite")) {
+			continue
+		}
+		t, ok := toChartTable(tbl.Title, tbl.Header, tbl.Rows)
+		if !ok {
+			continue
+		}
+		if format == chartsVegaLite {
+			charts[tbl.End] = vegaLiteChart(t)
+		} else {
+			charts[tbl.End] = mermaidChart(t)
+		}
+	}
+	if len(charts) == 0 {
+		return 0, nil
+	}
+	var out []string
+	for i := 0; i <= len(lines); i++ {
+		if chart, ok := charts[i]; ok {
+			out = append(out, "", chart)
+		}
+		if i < len(lines) {
+			out = append(out, lines[i])
+		}
+	}
+	return len(charts), os.WriteFile(reportFile, []byte(strings.Join(out, "\n")), 0644)
+}
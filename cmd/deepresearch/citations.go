@@ -0,0 +1,103 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// citation is the bibliographic record of a registered source, assembled from the
+// Source Registry in task.md, the asset manifest and resolved metadata
+type citation struct {
+	ID        string
+	Type      string // CSL item type: article-journal, webpage, report, book, ...
+	Title     string
+	URL       string
+	DOI       string
+	Authors   []string // "Family, Given" or a single literal name
+	Issued    string   // YYYY, YYYY-MM or YYYY-MM-DD
+	Container string   // journal, site or outlet
+	Publisher string
+	Accessed  string // YYYY-MM-DD
+}
+
+// doiRe matches a DOI in a URL or text
+var doiRe = regexp.MustCompile(`\b(10\.\d{4,9}/[^\s"'<>?#]+)`)
+
+// extractDOI returns the DOI in s, if any
+func extractDOI(s string) string {
+	m := doiRe.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimRight(m[1], ".,;)")
+}
+
+// citationType maps a Source Registry type cell and the source URL to a CSL item type
+func citationType(registryType, url, doi string) string {
+	t := strings.ToLower(registryType)
+	switch {
+	case strings.Contains(t, "paper") || strings.Contains(t, "journal") || (strings.Contains(t, "article") && doi != ""):
+		return "article-journal"
+	case strings.Contains(t, "book"):
+		return "book"
+	case strings.Contains(t, "report") || strings.Contains(t, "whitepaper"):
+		return "report"
+	case strings.Contains(t, "case") || strings.Contains(t, "opinion") || strings.Contains(t, "docket") ||
+		strings.Contains(url, "courtlistener.com"):
+		return "legal_case"
+	case strings.Contains(t, "forum") || strings.Contains(t, "community") || strings.Contains(t, "reddit") ||
+		strings.Contains(url, "news.ycombinator.com"):
+		return "post"
+	case strings.Contains(t, "news"):
+		return "article-newspaper"
+	case strings.Contains(t, "video"):
+		return "motion_picture"
+	case doi != "" || strings.Contains(url, "arxiv.org"):
+		return "article-journal"
+	case strings.Contains(t, "pdf"):
+		return "report"
+	}
+	return "webpage"
+}
+
+// buildCitations returns a citation for every registered source
+func buildCitations(workDir string) []citation {
+	published := map[string]string{}
+	if m, err := loadAssetManifest(workDir); err == nil {
+		for _, a := range m.Assets {
+			if a.SourceID != "" && a.Published != "" {
+				published[a.SourceID] = a.Published
+			}
+		}
+	}
+	meta := loadCitationMetadata(workDir)
+	var citations []citation
+	for _, s := range readSourceRegistry(workDir) {
+		c := citation{
+			ID:       s.ID,
+			Title:    s.Title,
+			URL:      s.URL,
+			DOI:      extractDOI(s.URL),
+			Issued:   published[s.ID],
+			Accessed: s.Accessed,
+		}
+		c.Type = citationType(s.Type, s.URL, c.DOI)
+		if m, ok := meta[s.ID]; ok {
+			c = applyMetadata(c, m)
+		}
+		citations = append(citations, c)
+	}
+	return citations
+}
+
+// splitName splits "Family, Given" (or "Given Family") into family and given names
+func splitName(name string) (family, given string) {
+	if f, g, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(f), strings.TrimSpace(g)
+	}
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name, ""
+	}
+	return parts[len(parts)-1], strings.Join(parts[:len(parts)-1], " ")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// clarifyConfig has a clarifier ask the user about the request before the
+// planner runs (the CLARIFIER phase), so a vague prompt is not planned on guesses
+type clarifyConfig struct {
+	Enabled bool `yaml:"enabled"` // same as --clarify
+	// Answers is the Markdown file that answers the questions in
+	// non-interactive runs; same as --clarify-answers. A run that finds it
+	// missing writes the questions there and stops.
+	Answers string `yaml:"answers"`
+}
+
+// answerHeadingRe matches the heading of a question in an answers file, e.g.
+// "## 2. Which countries should the comparison cover?"
+var answerHeadingRe = regexp.MustCompile(`^##\s+\d+\.\s+(.+?)\s*$`)
+
+// questionsPendingError stops a run once the clarifier's questions are written
+// to the answers file, for the user to answer before running again
+type questionsPendingError struct {
+	path      string
+	questions int
+}
+
+func (e *questionsPendingError) Error() string {
+	return fmt.Sprintf("%d clarifying question(s) await answers in %s", e.questions, e.path)
+}
+
+// Answer asks the user the clarifier's questions on the terminal, or, with an
+// answers file, writes them there and stops the run (--clarify)
+func (w *researchWorkflow) Answer(ctx context.Context, questions []string) ([]string, error) {
+	if path := config.Clarify.Answers; path != "" {
+		if err := writeAnswersFile(path, w.topic, questions); err != nil {
+			return nil, fmt.Errorf("cannot write the questions to %s: %w", path, err)
+		}
+		return nil, &questionsPendingError{path: path, questions: len(questions)}
+	}
+	title := tr("Clarifying questions")
+	if plainOutput {
+		fmt.Printf("[CLARIFY] %s\n", title)
+	} else {
+		fmt.Printf("\n%s── %s ──%s\n", colorCyan, title, colorReset)
+	}
+	info("Answer each question, or press Enter to leave it to the planner")
+	answers := make([]string, len(questions))
+	for i, q := range questions {
+		a, err := readAnswer(ctx, fmt.Sprintf("%d. %s\n> ", i+1, q))
+		if err != nil {
+			if errors.Is(err, errNoAnswer) {
+				err = fmt.Errorf("the clarifying questions got no answer: %w (answer them in a file with --clarify-answers)", err)
+			}
+			return nil, err
+		}
+		answers[i] = a
+	}
+	fmt.Println()
+	return answers, nil
+}
+
+// buildClarifierPrompt instructs the clarifier, which also sees the user's context files
+func buildClarifierPrompt(promptsDir, workDir, userPrompt string) string {
+	return orchestrator.ClarifierPrompt(promptsDir, workDir, userPrompt) + buildContextNote(workDir)
+}
+
+// answeredClarifications are the answers the planner gets without running the
+// clarifier: those in the answers file, or else those the clarifier of the
+// interrupted run recorded when resumed is set; nil if there are none
+func answeredClarifications(workDir string, resumed bool) *orchestrator.Clarifications {
+	if path := config.Clarify.Answers; path != "" && fileExists(path) {
+		c, err := readAnswersFile(path)
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot read the answers in %s: %v", path, err)
+		}
+		info("Using the answers to %d clarifying question(s) in %s", len(c.Questions), path)
+		return c
+	}
+	if resumed {
+		if c, err := orchestrator.ReadClarifications(workDir); err == nil && c.Answered() {
+			return c
+		}
+	}
+	return nil
+}
+
+// writeAnswersFile writes the clarifier's questions about topic to path, each
+// under a heading for the user to write its answer below
+func writeAnswersFile(path, topic string, questions []string) error {
+	var b strings.Builder
+	b.WriteString("# Clarifying questions\n\n")
+	for _, line := range strings.Split(topic, "\n") {
+		fmt.Fprintf(&b, "> %s\n", line)
+	}
+	b.WriteString("\nWrite each answer below its question, then run deepresearch again with --clarify-answers\n")
+	b.WriteString("pointing to this file. The planner decides on the questions left unanswered.\n")
+	for i, q := range questions {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, q)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// readAnswersFile reads the questions of an answers file and the answer below
+// each, "" where the user left it empty
+func readAnswersFile(path string) (*orchestrator.Clarifications, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &orchestrator.Clarifications{Answers: []string{}}
+	var answer []string
+	next := func() {
+		if len(c.Answers) < len(c.Questions) {
+			c.Answers = append(c.Answers, strings.TrimSpace(strings.Join(answer, "\n")))
+		}
+		answer = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if m := answerHeadingRe.FindStringSubmatch(line); m != nil {
+			next()
+			c.Questions = append(c.Questions, m[1])
+			continue
+		}
+		answer = append(answer, line)
+	}
+	next()
+	if len(c.Questions) == 0 {
+		return nil, errors.New(`no questions in it (expected "## 1. <question>" headings, each followed by its answer)`)
+	}
+	return c, nil
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default endpoints of the community discussion APIs
+const (
+	hnSearchAPI = "https://hn.algolia.com/api/v1"
+	redditAPI   = "https://www.reddit.com"
+)
+
+// maxThreadComments caps how many comments of a thread are archived
+const maxThreadComments = 40
+
+func init() {
+	registerConnector(&connector{
+		Name:    "hackernews",
+		Summary: "Hacker News stories and comment threads (anecdotal practitioner sentiment; free, no key)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 20] [-days 365] — stories sorted by relevance, with points and dates`,
+			"thread": `<story id> — story and top comments, saved to assets/community`,
+		},
+		Run: runHackerNews,
+	})
+	registerConnector(&connector{
+		Name:    "reddit",
+		Summary: "Reddit posts and comment threads (anecdotal practitioner sentiment; free, no key)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 20] [-sub name] [-days 365] — posts with scores and dates`,
+			"thread": `<post URL or permalink> — post and top comments, saved to assets/community`,
+		},
+		Run: runReddit,
+	})
+}
+
+// communityPost is a provider-independent discussion post or comment
+type communityPost struct {
+	ID       string
+	Title    string
+	Author   string
+	Score    int
+	Comments int
+	Date     time.Time
+	URL      string
+	Text     string
+	Depth    int
+}
+
+// printCommunityPosts prints a search result table
+func printCommunityPosts(t *toolContext, site, rawPath string, posts []communityPost) {
+	t.printf("%d %s post(s). Raw results: %s\n\n| ID | Date | Score | Comments | Title | URL |\n|---|---|---|---|---|---|\n", len(posts), site, rawPath)
+	for _, p := range posts {
+		t.printf("| %s | %s | %d | %d | %s | %s |\n", p.ID, p.Date.Format("2006-01-02"), p.Score, p.Comments, mdCell(p.Title), p.URL)
+	}
+	t.printf("\nUse `thread` to archive a discussion you cite. Community threads are ANECDOTAL sources: register them with Credibility `Anecdotal` and use them for practitioner sentiment, not facts.\n")
+}
+
+// saveThread archives a post and its comments as an anecdotal source
+func saveThread(t *toolContext, site string, post communityPost, comments []communityPost) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "# %s\n\n*%s — %d points, %d comments, posted %s by %s. Anecdotal source: individual opinions, not verified facts.*\n\n%s\n",
+		post.Title, site, post.Score, post.Comments, post.Date.Format("2006-01-02"), post.Author, post.Text)
+	if len(comments) > 0 {
+		body.WriteString("\n## Comments\n\n")
+		for _, c := range comments {
+			indent := strings.Repeat("  ", c.Depth)
+			text := strings.ReplaceAll(strings.TrimSpace(c.Text), "\n", "\n"+indent+"  ")
+			meta := c.Date.Format("2006-01-02")
+			if c.Score != 0 { // Hacker News does not expose comment scores
+				meta += fmt.Sprintf(", %d points", c.Score)
+			}
+			fmt.Fprintf(&body, "%s- **%s** (%s): %s\n", indent, c.Author, meta, text)
+		}
+	}
+	path, err := t.saveDocument("community", site+"_"+post.ID+"_"+post.Title, [][2]string{
+		{"title", post.Title},
+		{"url", post.URL},
+		{"site", site},
+		{"credibility", "anecdotal"},
+		{"score", fmt.Sprint(post.Score)},
+		{"published", post.Date.Format("2006-01-02")},
+	}, body.String())
+	if err != nil {
+		return err
+	}
+	t.printf("%s\nArchived: %s\n", body.String(), path)
+	return nil
+}
+
+// hnText converts Hacker News comment HTML to plain text
+func hnText(s string) string {
+	s = strings.NewReplacer("<p>", "\n\n", "<i>", "*", "</i>", "*").Replace(s)
+	return html.UnescapeString(htmlTagRe.ReplaceAllString(s, ""))
+}
+
+func runHackerNews(t *toolContext, action string, args []string) error {
+	base := t.endpoint(hnSearchAPI)
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 20)
+		days := fs.Int("days", 365, "How many days back to search")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		params := url.Values{
+			"query":          {query},
+			"tags":           {"story"},
+			"hitsPerPage":    {fmt.Sprint(min(*n, 100))},
+			"numericFilters": {fmt.Sprintf("created_at_i>%d", time.Now().AddDate(0, 0, -*days).Unix())},
+		}
+		var resp struct {
+			Hits []struct {
+				ID        string `json:"objectID"`
+				Title     string `json:"title"`
+				URL       string `json:"url"`
+				Author    string `json:"author"`
+				Points    int    `json:"points"`
+				Comments  int    `json:"num_comments"`
+				CreatedAt int64  `json:"created_at_i"`
+			} `json:"hits"`
+		}
+		raw, err := t.getJSON(base+"/search?"+params.Encode(), nil, &resp)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "hackernews_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		var posts []communityPost
+		for _, h := range resp.Hits {
+			posts = append(posts, communityPost{
+				ID: h.ID, Title: h.Title, Author: h.Author, Score: h.Points, Comments: h.Comments,
+				Date: time.Unix(h.CreatedAt, 0).UTC(), URL: "https://news.ycombinator.com/item?id=" + h.ID,
+			})
+		}
+		printCommunityPosts(t, "Hacker News", path, posts)
+		return nil
+
+	case "thread":
+		if len(args) == 0 {
+			return fmt.Errorf("a story ID is required")
+		}
+		type hnItem struct {
+			ID        int      `json:"id"`
+			Title     string   `json:"title"`
+			Author    string   `json:"author"`
+			Text      string   `json:"text"`
+			URL       string   `json:"url"`
+			Points    int      `json:"points"`
+			CreatedAt int64    `json:"created_at_i"`
+			Children  []hnItem `json:"children"`
+		}
+		var item hnItem
+		if _, err := t.getJSON(base+"/items/"+url.PathEscape(args[0]), nil, &item); err != nil {
+			return err
+		}
+		post := communityPost{
+			ID: fmt.Sprint(item.ID), Title: item.Title, Author: item.Author, Score: item.Points,
+			Date: time.Unix(item.CreatedAt, 0).UTC(), URL: fmt.Sprintf("https://news.ycombinator.com/item?id=%d", item.ID),
+			Text: hnText(item.Text),
+		}
+		if item.URL != "" {
+			post.Text = strings.TrimSpace("Link: " + item.URL + "\n\n" + post.Text)
+		}
+		var comments []communityPost
+		var walk func(children []hnItem, depth int)
+		walk = func(children []hnItem, depth int) {
+			for _, c := range children {
+				if len(comments) == maxThreadComments {
+					return
+				}
+				if c.Text != "" {
+					comments = append(comments, communityPost{Author: c.Author, Date: time.Unix(c.CreatedAt, 0).UTC(), Text: hnText(c.Text), Depth: depth})
+					post.Comments++
+				}
+				if depth < 2 {
+					walk(c.Children, depth+1)
+				}
+			}
+		}
+		walk(item.Children, 0)
+		return saveThread(t, "Hacker News", post, comments)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// redditThing is the data of a Reddit listing child (post or comment)
+type redditThing struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Author      string          `json:"author"`
+	Subreddit   string          `json:"subreddit"`
+	Score       int             `json:"score"`
+	NumComments int             `json:"num_comments"`
+	Created     float64         `json:"created_utc"`
+	Permalink   string          `json:"permalink"`
+	SelfText    string          `json:"selftext"`
+	Body        string          `json:"body"`
+	Replies     json.RawMessage `json:"replies"` // "" or a listing
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Kind string      `json:"kind"`
+			Data redditThing `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+func (r redditThing) post() communityPost {
+	return communityPost{
+		ID: r.ID, Title: r.Title, Author: r.Author, Score: r.Score, Comments: r.NumComments,
+		Date: time.Unix(int64(r.Created), 0).UTC(), URL: "https://www.reddit.com" + r.Permalink, Text: r.SelfText,
+	}
+}
+
+func runReddit(t *toolContext, action string, args []string) error {
+	base := t.endpoint(redditAPI)
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 20)
+		sub := fs.String("sub", "", "Restrict to one subreddit")
+		days := fs.Int("days", 365, "How many days back to search (maps to Reddit's hour/day/week/month/year/all windows)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		window := "all"
+		for _, w := range []struct {
+			name string
+			days int
+		}{{"day", 1}, {"week", 7}, {"month", 31}, {"year", 365}} {
+			if *days <= w.days {
+				window = w.name
+				break
+			}
+		}
+		params := url.Values{"q": {query}, "sort": {"relevance"}, "t": {window}, "limit": {fmt.Sprint(min(*n, 100))}}
+		path := "/search.json"
+		subreddit := strings.TrimPrefix(*sub, "r/")
+		if subreddit != "" {
+			path = "/r/" + url.PathEscape(subreddit) + "/search.json"
+			params.Set("restrict_sr", "1")
+		}
+		var resp redditListing
+		raw, err := t.getJSON(base+path+"?"+params.Encode(), nil, &resp)
+		if err != nil {
+			return err
+		}
+		rawPath, err := t.saveAsset("data", "reddit_"+subreddit+"_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		var posts []communityPost
+		for _, c := range resp.Data.Children {
+			p := c.Data.post()
+			p.Title = "r/" + c.Data.Subreddit + ": " + p.Title
+			posts = append(posts, p)
+		}
+		printCommunityPosts(t, "Reddit", rawPath, posts)
+		return nil
+
+	case "thread":
+		if len(args) == 0 {
+			return fmt.Errorf("a post URL or permalink is required")
+		}
+		permalink := args[0]
+		if u, err := url.Parse(permalink); err == nil && u.Host != "" {
+			permalink = u.Path
+		}
+		var listings []redditListing
+		params := url.Values{"limit": {fmt.Sprint(maxThreadComments)}, "sort": {"top"}, "depth": {"3"}}
+		if _, err := t.getJSON(base+strings.TrimSuffix(permalink, "/")+".json?"+params.Encode(), nil, &listings); err != nil {
+			return err
+		}
+		if len(listings) < 2 || len(listings[0].Data.Children) == 0 {
+			return fmt.Errorf("no Reddit thread at %s", args[0])
+		}
+		op := listings[0].Data.Children[0].Data
+		post := op.post()
+		post.Title = "r/" + op.Subreddit + ": " + op.Title
+		var comments []communityPost
+		var walk func(l redditListing, depth int)
+		walk = func(l redditListing, depth int) {
+			for _, c := range l.Data.Children {
+				if c.Kind != "t1" || len(comments) == maxThreadComments {
+					continue
+				}
+				d := c.Data
+				comments = append(comments, communityPost{Author: d.Author, Score: d.Score, Date: time.Unix(int64(d.Created), 0).UTC(), Text: d.Body, Depth: depth})
+				var replies redditListing
+				if depth < 2 && json.Unmarshal(d.Replies, &replies) == nil {
+					walk(replies, depth+1)
+				}
+			}
+		}
+		walk(listings[1], 0)
+		return saveThread(t, "Reddit", post, comments)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
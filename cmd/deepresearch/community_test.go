@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHackerNewsSearch(t *testing.T) {
+	srv := recordedAPI(t, "community", map[string]string{"/search": "hn_search.json"})
+	dir, out, err := runRecordedTool(t, "hackernews", connectorConfig{Endpoint: srv.URL}, "search", "-q", "postgres kubernetes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 Hacker News post(s).",
+		"| ID | Date | Score | Comments | Title | URL |",
+		"| 39512345 | 2024-02-26 | 532 | 214 | Ask HN: Is anyone running Postgres on Kubernetes in production? | https://news.ycombinator.com/item?id=39512345 |",
+		`| 38000001 | 2023-10-20 | 48 | 12 | Show HN: pg_operator \| a tiny Postgres operator |`,
+		"ANECDOTAL",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "hackernews-postgres-kubernetes") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestHackerNewsThread(t *testing.T) {
+	srv := recordedAPI(t, "community", map[string]string{"/items/39512345": "hn_item.json"})
+	dir, out, err := runRecordedTool(t, "hackernews", connectorConfig{Endpoint: srv.URL}, "thread", "39512345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# Ask HN: Is anyone running Postgres on Kubernetes in production?",
+		"*Hacker News — 532 points, 3 comments, posted 2024-02-26 by tptacek.",
+		"Curious how people handle *stateful* workloads.",
+		"- **dang_fan** (2024-02-26): We've run CloudNativePG for two years.",
+		"  - **sre_jane** (2024-02-26): Same here, but backups with *pgBackRest*",
+		"    - **too_deep** (2024-02-26): A reply three levels down.",
+	)
+	files := assetFiles(t, dir, "community")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "hacker-news-39512345") {
+		t.Fatalf("archived %v, want the thread", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "community", files[0]))
+	checkContains(t, string(doc), `credibility: "anecdotal"`, `url: "https://news.ycombinator.com/item?id=39512345"`)
+}
+
+func TestRedditSearch(t *testing.T) {
+	srv := recordedAPI(t, "community", map[string]string{"/r/kubernetes/search.json": "reddit_search.json"})
+	dir, out, err := runRecordedTool(t, "reddit", connectorConfig{Endpoint: srv.URL}, "search", "-q", "postgres operator", "-sub", "r/kubernetes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 Reddit post(s).",
+		"| 1b0xyz9 | 2024-02-25 | 187 | 96 | r/kubernetes: Postgres on k8s in 2024? | https://www.reddit.com/r/kubernetes/comments/1b0xyz9/postgres_on_k8s_in_2024/ |",
+		`| 1a9abc1 | 2024-01-23 | 64 | 31 | r/PostgreSQL: Operators compared \| CNPG vs Zalando |`,
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "reddit-kubernetes-postgres-operator") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestRedditThread(t *testing.T) {
+	srv := recordedAPI(t, "community", map[string]string{"/r/kubernetes/comments/1b0xyz9/postgres_on_k8s_in_2024.json": "reddit_thread.json"})
+	dir, out, err := runRecordedTool(t, "reddit", connectorConfig{Endpoint: srv.URL},
+		"thread", "https://www.reddit.com/r/kubernetes/comments/1b0xyz9/postgres_on_k8s_in_2024/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# r/kubernetes: Postgres on k8s in 2024?",
+		"*Reddit — 187 points, 96 comments, posted 2024-02-25 by k8s_admin.",
+		"- **ops_guy** (2024-02-25, 88 points): CNPG has been solid for us.\n  Failover takes seconds.",
+		"  - **skeptic** (2024-02-25, 12 points): Until the PVC gets stuck.",
+		"- **managed_fan** (2024-02-25, 40 points): Just use RDS.",
+	)
+	if files := assetFiles(t, dir, "community"); len(files) != 1 {
+		t.Errorf("archived %v, want the thread", files)
+	}
+}
+
+func TestCommunityNoResults(t *testing.T) {
+	hn := recordedAPI(t, "community", map[string]string{"/search": "hn_empty.json"})
+	reddit := recordedAPI(t, "community", map[string]string{"/search.json": "reddit_empty.json"})
+	for _, c := range []struct{ name, endpoint, want string }{
+		{"hackernews", hn.URL, "0 Hacker News post(s)."},
+		{"reddit", reddit.URL, "0 Reddit post(s)."},
+	} {
+		_, out, err := runRecordedTool(t, c.name, connectorConfig{Endpoint: c.endpoint}, "search", "-q", "no such story")
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkContains(t, out, c.want)
+		if strings.Count(out, "\n| ") > 1 {
+			t.Errorf("%s: expected only the table header:\n%s", c.name, out)
+		}
+	}
+}
+
+func TestCommunityErrors(t *testing.T) {
+	checkHTTPErrors(t, "hackernews", connectorConfig{}, "search", "-q", "postgres")
+	checkHTTPErrors(t, "hackernews", connectorConfig{}, "thread", "39512345")
+	checkHTTPErrors(t, "reddit", connectorConfig{}, "search", "-q", "postgres")
+	checkHTTPErrors(t, "reddit", connectorConfig{}, "thread", "/r/kubernetes/comments/1b0xyz9/postgres_on_k8s_in_2024/")
+}
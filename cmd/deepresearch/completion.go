@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// An interactive planner ends the conversation with one of these signals,
+// whichever comes first: it writes the sentinel file .locks/.planner.done, it
+// deletes .locks/.planner.lock (the original protocol), or task.md, absent
+// when the planner started, appears and stays unchanged and free of lint
+// errors for interactive.settle_seconds.
+const (
+	plannerLockName = ".planner.lock"
+	plannerDoneName = ".planner.done"
+)
+
+// interactiveConfig tunes how deepresearch ends an interactive agent session
+type interactiveConfig struct {
+	SettleSeconds    int `yaml:"settle_seconds"`     // quiet time after the planner writes task.md before it counts as done (default 5)
+	StopGraceSeconds int `yaml:"stop_grace_seconds"` // time a finished agent gets to exit after an interrupt before it is killed (default 10)
+	// PTY runs the agent in a pseudo-terminal, for agents that need a TTY:
+	// auto (default: where the platform has one), on or off
+	PTY string `yaml:"pty"`
+	// ReviewPlan has the user approve, edit or send back the plan before
+	// research starts, whatever the topic came from (same as --review-plan)
+	ReviewPlan bool `yaml:"review_plan"`
+}
+
+// completionPollInterval re-checks the signals where file events are missing,
+// e.g. on network file systems
+const completionPollInterval = 5 * time.Second
+
+// Statuses of the sentinel file
+const (
+	doneOK     = "done"
+	doneFailed = "failed"
+)
+
+// doneMarker is the payload of the sentinel file: JSON such as
+// {"status": "done", "message": "..."}, or a first line of DONE or
+// "FAILED: reason"
+type doneMarker struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// parseDoneMarker reads a sentinel file; ok is false while it holds no
+// complete marker, e.g. while the agent is still writing it
+func parseDoneMarker(data []byte) (m doneMarker, ok bool) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "{") {
+		if json.Unmarshal([]byte(text), &m) != nil {
+			return m, false
+		}
+		m.Status = strings.ToLower(strings.TrimSpace(m.Status))
+		return m, m.Status == doneOK || m.Status == doneFailed
+	}
+	line, _, _ := strings.Cut(text, "\n")
+	status, message, _ := strings.Cut(strings.TrimSpace(line), ":")
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "DONE":
+		return doneMarker{Status: doneOK, Message: strings.TrimSpace(message)}, true
+	case "FAILED":
+		return doneMarker{Status: doneFailed, Message: strings.TrimSpace(message)}, true
+	}
+	return m, false
+}
+
+// completion is how an interactive agent signalled that it finished
+type completion struct {
+	reason string // what gave the signal, for the log
+	marker doneMarker
+}
+
+// plannerCompletion watches a workspace for the end of an interactive planner
+type plannerCompletion struct {
+	workDir     string
+	lockFile    string
+	doneFile    string
+	taskFile    string
+	taskExisted bool // task.md predates the planner, so only the lock and sentinel count
+	settle      time.Duration
+}
+
+// newPlannerCompletion creates the planner's lock file and clears a sentinel
+// left over from an earlier run
+func newPlannerCompletion(workDir string) (*plannerCompletion, error) {
+	lockDir := filepath.Join(workDir, ".locks")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create .locks dir: %w", err)
+	}
+	c := &plannerCompletion{
+		workDir:  workDir,
+		lockFile: filepath.Join(lockDir, plannerLockName),
+		doneFile: filepath.Join(lockDir, plannerDoneName),
+		taskFile: taskFilePath(workDir),
+		settle:   time.Duration(config.Interactive.SettleSeconds) * time.Second,
+	}
+	c.taskExisted = fileExists(c.taskFile)
+	os.Remove(c.doneFile)
+	if err := os.WriteFile(c.lockFile, []byte("planner in progress"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	return c, nil
+}
+
+// cleanup removes the lock and sentinel files
+func (c *plannerCompletion) cleanup() {
+	os.Remove(c.lockFile)
+	os.Remove(c.doneFile)
+}
+
+// check returns the signal the workspace holds now, if any
+func (c *plannerCompletion) check() (completion, bool) {
+	if data, err := os.ReadFile(c.doneFile); err == nil {
+		if m, ok := parseDoneMarker(data); ok {
+			return completion{reason: plannerDoneName + " written", marker: m}, true
+		}
+	}
+	if !fileExists(c.lockFile) {
+		return completion{reason: plannerLockName + " deleted", marker: doneMarker{Status: doneOK}}, true
+	}
+	if !c.taskExisted {
+		st, err := os.Stat(c.taskFile)
+		if err == nil && time.Since(st.ModTime()) >= c.settle {
+			if data, err := os.ReadFile(c.taskFile); err == nil && len(lintErrors(lintTask(string(data)))) == 0 {
+				return completion{reason: taskFileName(c.workDir) + " written", marker: doneMarker{Status: doneOK}}, true
+			}
+		}
+	}
+	return completion{}, false
+}
+
+// wait returns the first completion signal, or false when ctx ends first. It
+// follows file events in the workspace and .locks/, and polls as a fallback.
+func (c *plannerCompletion) wait(ctx context.Context) (completion, bool) {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w, err := fsnotify.NewWatcher(); err == nil {
+		defer w.Close()
+		if w.Add(c.workDir) == nil && w.Add(filepath.Dir(c.lockFile)) == nil {
+			events, errs = w.Events, w.Errors
+		}
+	}
+	poll := time.NewTicker(completionPollInterval)
+	defer poll.Stop()
+	// A settle timer re-checks task.md once it has been left alone long enough
+	settle := time.NewTimer(c.settle)
+	settle.Stop()
+	for {
+		if done, ok := c.check(); ok {
+			return done, true
+		}
+		select {
+		case <-ctx.Done():
+			return completion{}, false
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+			} else if ev.Name == c.taskFile {
+				settle.Reset(c.settle)
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-settle.C:
+		case <-poll.C:
+		}
+	}
+}
+
+// stopGracefully interrupts an agent that has finished its work, as Ctrl+C
+// would, and kills its process tree if it has not exited within
+// interactive.stop_grace_seconds. exited receives the result of cmd.Wait,
+// which stopGracefully returns.
+func stopGracefully(cmd *exec.Cmd, exited <-chan error) error {
+	interruptProcess(cmd)
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(time.Duration(config.Interactive.StopGraceSeconds) * time.Second):
+		warn("The agent did not exit within %ds of the interrupt; killing it", config.Interactive.StopGraceSeconds)
+		killProcessTree(cmd)
+		return <-exited
+	}
+}
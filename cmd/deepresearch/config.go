@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the active user configuration. Command-line flags take precedence
+// over values loaded from the config file.
+var config = defaultConfig()
+
+// configFile is the config file config was loaded from ("" = defaults only)
+var configFile string
+
+// fileConfig is the optional configuration file (~/.deepresearch/config.yaml or --config)
+type fileConfig struct {
+	Privacy       privacyConfig       `yaml:"privacy"`
+	Freshness     freshnessConfig     `yaml:"freshness"`
+	Translation   translationConfig   `yaml:"translation"`
+	Zotero        zoteroConfig        `yaml:"zotero"`
+	Crossref      crossrefConfig      `yaml:"crossref"`
+	Server        serverConfig        `yaml:"server"`
+	Workers       workersConfig       `yaml:"workers"`
+	Run           runConfig           `yaml:"run"`
+	Reflection    reflectionConfig    `yaml:"reflection"`
+	Limits        limitsConfig        `yaml:"limits"`
+	Execution     executionConfig     `yaml:"execution"`
+	Sandbox       sandboxConfig       `yaml:"sandbox"`
+	AgentLogs     agentLogsConfig     `yaml:"agent_logs"`
+	Git           gitConfig           `yaml:"git"`
+	Clarify       clarifyConfig       `yaml:"clarify"`
+	Report        reportConfig        `yaml:"report"`
+	Interactive   interactiveConfig   `yaml:"interactive"`
+	API           apiConfig           `yaml:"api"`
+	Cost          costConfig          `yaml:"cost"`
+	Export        exportConfig        `yaml:"export"`
+	Verification  verificationConfig  `yaml:"verification"`
+	Storage       storageConfig       `yaml:"storage"`
+	Knowledge     knowledgeConfig     `yaml:"knowledge"`
+	Workspace     workspaceConfig     `yaml:"workspace"`
+	Telemetry     telemetryConfig     `yaml:"telemetry"`
+	Notify        notifyConfig        `yaml:"notifications"`
+	Observability observabilityConfig `yaml:"observability"`
+	// Phases selects the agent and model of single phases, keyed by phase name
+	// (planner, research-supervisor, executor, reflector, synthesizer)
+	Phases map[string]phaseAgentConfig `yaml:"phases"`
+	// Connectors holds per-connector settings keyed by connector name (see `deepresearch tool list`)
+	Connectors map[string]connectorConfig `yaml:"connectors"`
+}
+
+// privacyConfig controls what a run persists and for how long
+type privacyConfig struct {
+	PersistPrompts     bool `yaml:"persist_prompts"`     // keep generated agent instructions (tmp/) and the topic in the manifest
+	PersistTranscripts bool `yaml:"persist_transcripts"` // keep agent transcripts and result logs under logs/
+	PersistFetched     bool `yaml:"persist_fetched"`     // keep fetched source content under assets/
+	RetentionDays      int  `yaml:"retention_days"`      // delete persisted files older than this many days (0 = keep forever)
+	Ephemeral          bool `yaml:"ephemeral"`           // wipe tmp, transcripts and caches after producing the report
+}
+
+func defaultConfig() fileConfig {
+	return fileConfig{
+		Privacy: privacyConfig{
+			PersistPrompts:     true,
+			PersistTranscripts: true,
+			PersistFetched:     true,
+		},
+		Freshness: freshnessConfig{
+			MaxAgeDays: 730,
+			FastMoving: "auto",
+		},
+		Translation: translationConfig{
+			TargetLanguage: "en",
+			Engine:         "agent",
+			MaxChars:       20000,
+		},
+		Crossref: crossrefConfig{
+			Enabled: true,
+		},
+		Telemetry: telemetryConfig{
+			Mode: telemetryOff,
+		},
+		Run: runConfig{
+			MaxIterations:       10,
+			RetryBackoffSeconds: 30,
+			Handoff:             handoffCheck,
+		},
+		Reflection: reflectionConfig{
+			StopStalled: 3,
+		},
+		Execution: executionConfig{
+			Mode: execDirect,
+		},
+		Sandbox: sandboxConfig{
+			Mode:    sandboxOff,
+			Network: networkBridge,
+			Env:     defaultSandboxEnv,
+		},
+		AgentLogs: agentLogsConfig{
+			Enabled: true,
+		},
+		Export: exportConfig{
+			Formats: []string{formatMarkdown},
+		},
+		Verification: verificationConfig{
+			Mode:           verifyOff,
+			Rounds:         1,
+			CheckLinks:     true,
+			TimeoutSeconds: 15,
+			Concurrency:    8,
+		},
+		Storage: storageConfig{
+			CacheTTLDays: 30,
+		},
+		Knowledge: knowledgeConfig{
+			Dir:         "knowledge",
+			MaxFindings: 200,
+		},
+		Notify: notifyConfig{
+			TimeoutSeconds: 10,
+		},
+		Interactive: interactiveConfig{
+			SettleSeconds:    5,
+			StopGraceSeconds: 10,
+			PTY:              ptyAuto,
+		},
+		API: apiConfig{
+			MaxTurns:  200,
+			MaxTokens: 8192,
+		},
+		Cost: costConfig{
+			OnBudget: budgetSynthesize,
+		},
+		Server: serverConfig{
+			Addr:         "127.0.0.1:8090",
+			Root:         "runs",
+			Workers:      2,
+			Retries:      1,
+			Queue:        "memory",
+			Executor:     "local",
+			Coordination: "file",
+		},
+		Workers: workersConfig{
+			TimeoutMinutes: 30,
+			Slots:          1,
+		},
+	}
+}
+
+// defaultConfigPath returns ~/.deepresearch/config.yaml
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deepresearch", "config.yaml")
+}
+
+// loadConfig reads the config file at path over the defaults. A missing file is
+// only an error when the path was given explicitly.
+func loadConfig(path string, explicit bool) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	cfg := defaultConfig()
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if _, err := newSecretRedactor(cfg.AgentLogs.Redact); err != nil {
+		return fmt.Errorf("invalid config %s: agent_logs.redact %w", path, err)
+	}
+	config = cfg
+	configFile, _ = filepath.Abs(path)
+	return nil
+}
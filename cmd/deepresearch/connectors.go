@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// connector is a structured data source executors query through
+// `deepresearch tool <name> <action>`. Results are printed as Markdown for the
+// agent and the raw response is archived under assets/.
+type connector struct {
+	Name    string
+	Summary string
+	// Actions maps an action name to its one-line usage
+	Actions map[string]string
+	Run     func(t *toolContext, action string, args []string) error
+}
+
+// connectorConfig holds per-connector settings from the config file
+type connectorConfig struct {
+	Disabled  bool   `yaml:"disabled"`
+	Provider  string `yaml:"provider"`    // for pluggable connectors (e.g. news: gdelt, newsapi)
+	Endpoint  string `yaml:"endpoint"`    // override the provider's API base URL
+	APIKeyEnv string `yaml:"api_key_env"` // environment variable holding the API key
+	Contact   string `yaml:"contact"`     // contact address for APIs that require one (edgar)
+	// Scopes limits internal connectors to these Confluence space keys or SharePoint
+	// site/library URLs, which are also offered to the planner
+	Scopes []string `yaml:"scopes"`
+}
+
+// connectors is the registry of available connectors, keyed by name
+var connectors = map[string]*connector{}
+
+// registerConnector adds a connector to the registry; called from init functions
+func registerConnector(c *connector) {
+	connectors[c.Name] = c
+}
+
+// enabledConnectors returns the connectors not disabled in config, sorted by name
+func enabledConnectors() []*connector {
+	var list []*connector
+	for name, c := range connectors {
+		if !config.Connectors[name].Disabled {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// toolContext is what a connector action gets to work with
+type toolContext struct {
+	WorkDir string
+	Config  connectorConfig
+	Out     io.Writer
+	client  *http.Client
+}
+
+// apiKey returns the connector's API key from the configured environment variable,
+// falling back to defaultEnv
+func (t *toolContext) apiKey(defaultEnv string) string {
+	env := t.Config.APIKeyEnv
+	if env == "" {
+		env = defaultEnv
+	}
+	return os.Getenv(env)
+}
+
+// endpoint returns the configured API base URL or the connector's default
+func (t *toolContext) endpoint(def string) string {
+	if t.Config.Endpoint != "" {
+		return strings.TrimRight(t.Config.Endpoint, "/")
+	}
+	return def
+}
+
+// getJSON fetches a URL and decodes the JSON response into out, returning the raw body
+func (t *toolContext) getJSON(u string, headers map[string]string, out any) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "deepresearch/"+version)
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{Host: req.URL.Host, Status: resp.Status, Code: resp.StatusCode, Body: body}
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return nil, fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+		}
+	}
+	return body, nil
+}
+
+// httpStatusError is an API response other than 200 OK
+type httpStatusError struct {
+	Host   string
+	Status string // e.g. "429 Too Many Requests"
+	Code   int
+	Body   []byte // for APIs that explain the error in the response
+}
+
+func (e *httpStatusError) Error() string { return e.Host + " returned " + e.Status }
+
+// saveAsset archives data under assets/<kind>/<name>_<date>.<ext> and returns the relative path
+func (t *toolContext) saveAsset(kind, name, ext string, data []byte) (string, error) {
+	slug := slugify(name)
+	if slug == "" {
+		slug = "result"
+	}
+	rel := assetRel(t.WorkDir, kind+"/"+fmt.Sprintf("%s_%s.%s", slug, time.Now().Format("20060102"), ext))
+	path := filepath.Join(t.WorkDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return rel, os.WriteFile(path, data, 0644)
+}
+
+// saveDocument archives a retrieved document as Markdown with YAML front matter
+// (keys such as published/url are picked up by the asset manifest) and returns
+// the relative path
+func (t *toolContext) saveDocument(kind, name string, meta [][2]string, body string) (string, error) {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, kv := range meta {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "%s: %q\n", kv[0], kv[1])
+		}
+	}
+	fmt.Fprintf(&b, "retrieved: %q\n---\n\n%s\n", time.Now().UTC().Format(time.RFC3339), strings.TrimSpace(body))
+	return t.saveAsset(kind, name, "md", []byte(b.String()))
+}
+
+// tokenAuth returns an Authorization header value for a personal access token
+// (Bearer) or, when the token has the form "user:secret", basic auth as used by
+// Atlassian Cloud API tokens
+func tokenAuth(token string) string {
+	if strings.Contains(token, ":") {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(token))
+	}
+	return "Bearer " + token
+}
+
+// printf writes formatted output for the calling agent
+func (t *toolContext) printf(format string, args ...any) {
+	fmt.Fprintf(t.Out, format, args...)
+}
+
+// mdCell makes a value safe for a Markdown table cell
+func mdCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) > 160 {
+		s = truncateRunes(s, 157) + "..."
+	}
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// searchFlagSet returns a flag set with the -q and -n flags shared by search actions
+func searchFlagSet(action string, defaultLimit int) (*flag.FlagSet, *string, *int) {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	q := fs.String("q", "", "Search query")
+	n := fs.Int("n", defaultLimit, "Maximum number of results")
+	return fs, q, n
+}
+
+// queryArg returns the -q value or, if it is empty, the positional arguments
+func queryArg(fs *flag.FlagSet, q string) (string, error) {
+	if q == "" {
+		q = strings.Join(fs.Args(), " ")
+	}
+	if q == "" {
+		return "", fmt.Errorf("a search query is required (-q)")
+	}
+	return q, nil
+}
+
+// runTool implements `deepresearch tool`: lists connectors or runs a connector action
+func runTool(args []string) {
+	fs := flag.NewFlagSet("tool", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Research workspace to archive results in")
+	configPath := fs.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch tool [-dir DIR] <connector> <action> [flags]")
+		fmt.Fprintln(os.Stderr, "       deepresearch tool list")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+
+	rest := fs.Args()
+	if offline() && len(rest) > 0 && rest[0] != "list" {
+		fatalCode(codeValidationFailed, "deepresearch tool is disabled during an offline replay; use the sources under assets/")
+	}
+	if len(rest) == 0 || rest[0] == "list" {
+		for _, c := range enabledConnectors() {
+			fmt.Printf("%s — %s\n", c.Name, c.Summary)
+			for _, a := range sortedKeys(c.Actions) {
+				fmt.Printf("    %-8s %s\n", a, c.Actions[a])
+			}
+		}
+		return
+	}
+	c, ok := connectors[rest[0]]
+	if !ok || config.Connectors[rest[0]].Disabled {
+		fatalCode(codeValidationFailed, "Unknown or disabled connector: %s (run `deepresearch tool list`)", rest[0])
+	}
+	if len(rest) < 2 || c.Actions[rest[1]] == "" {
+		fatalCode(codeValidationFailed, "Usage: deepresearch tool %s <%s>", c.Name, strings.Join(sortedKeys(c.Actions), "|"))
+	}
+	workDir, err := filepath.Abs(*dir)
+	if err != nil {
+		fatal("Failed to resolve workspace: %v", err)
+	}
+	if err := runConnector(c, workDir, rest[1], rest[2:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", c.Name, rest[1], err)
+		os.Exit(1)
+	}
+}
+
+// runConnector runs a connector action with the connector's configured
+// settings, writing its Markdown to out and archiving responses in workDir
+func runConnector(c *connector, workDir, action string, args []string, out io.Writer) error {
+	t := &toolContext{
+		WorkDir: workDir,
+		Config:  config.Connectors[c.Name],
+		Out:     out,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+	return c.Run(t, action, args)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildToolsNote tells the supervisor which connectors executors can call, or ""
+// if none are enabled
+func buildToolsNote(workDir string) string {
+	list := enabledConnectors()
+	if len(list) == 0 {
+		return ""
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "deepresearch"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+TOOLS: Structured connectors are available to executors as shell commands. Prefer them over generic
+web search for the data they cover; each prints Markdown results and archives the raw response
+under assets/ (register the archived file in the Source Registry when you cite it):
+  %s tool -dir %s <connector> <action> [flags]
+`, exe, workDir)
+	for _, c := range list {
+		fmt.Fprintf(&b, "- %s: %s (actions: %s)\n", c.Name, c.Summary, strings.Join(sortedKeys(c.Actions), ", "))
+	}
+	fmt.Fprintf(&b, "Run `%s tool list` for the flags of each action.\n", exe)
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMdCellTruncatesRunes(t *testing.T) {
+	for _, s := range []string{strings.Repeat("研究", 100), strings.Repeat("é|", 100)} {
+		got := mdCell(s)
+		if !utf8.ValidString(got) {
+			t.Errorf("mdCell(%q) = %q, not valid UTF-8", s, got)
+		}
+		if n := utf8.RuneCountInString(strings.ReplaceAll(got, "\\|", "|")); n != 160 {
+			t.Errorf("mdCell(%q) kept %d characters, want 160", s, n)
+		}
+	}
+}
+
+// recordedAPI serves API responses recorded in testdata/connectors/<name>/;
+// routes maps a request path to the file that answers it. A request for any
+// other path fails the test. "{{api}}" in a response stands for the server's
+// URL, so links between resources lead back to it.
+func recordedAPI(t *testing.T, name string, routes map[string]string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := routes[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "connectors", name, file))
+		if err != nil {
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(bytes.ReplaceAll(data, []byte("{{api}}"), []byte(srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// recordedFault answers every request with status and the response recorded
+// in testdata/connectors/<name>/<file>
+func recordedFault(t *testing.T, name, file string, status int) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "connectors", name, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// runRecordedTool runs a connector action in a fresh workspace with settings
+// as the connector's config, returning the workspace and the printed Markdown
+func runRecordedTool(t *testing.T, name string, settings connectorConfig, action string, args ...string) (string, string, error) {
+	t.Helper()
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.Connectors = map[string]connectorConfig{name: settings}
+	dir := t.TempDir()
+	var out bytes.Buffer
+	err := runConnector(connectors[name], dir, action, args, &out)
+	return dir, out.String(), err
+}
+
+// assetFiles returns the files archived under assets/<kind>/ of dir
+func assetFiles(t *testing.T, dir, kind string) []string {
+	t.Helper()
+	files, err := filepath.Glob(assetsPath(dir, kind, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range files {
+		files[i] = filepath.Base(f)
+	}
+	return files
+}
+
+// checkContains fails the test for each part missing from out
+func checkContains(t *testing.T, out string, parts ...string) {
+	t.Helper()
+	for _, p := range parts {
+		if !strings.Contains(out, p) {
+			t.Errorf("output lacks %q:\n%s", p, out)
+		}
+	}
+}
+
+// checkHTTPErrors runs the action against an API that rate limits and one that
+// fails, expecting an error naming the status and nothing archived
+func checkHTTPErrors(t *testing.T, name string, settings connectorConfig, action string, args ...string) {
+	t.Helper()
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(status), status)
+		}))
+		settings.Endpoint = srv.URL
+		dir, _, err := runRecordedTool(t, name, settings, action, args...)
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("%s %s with HTTP %d: error %v, want one naming the status", name, action, status, err)
+		}
+		if files, _ := filepath.Glob(assetsPath(dir, "*", "*")); len(files) > 0 {
+			t.Errorf("%s %s with HTTP %d archived %v", name, action, status, files)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// sandboxConfig runs every agent invocation in a throwaway container that sees
+// only the workspace (and, read-only, the prompt guides), instead of on this
+// machine with the run's permissions
+type sandboxConfig struct {
+	Mode  string `yaml:"mode"`  // same as --sandbox: off (default), docker or podman
+	Image string `yaml:"image"` // same as --sandbox-image: image containing the agent CLIs
+	// Network is bridge (default: the engine's network, unrestricted), none
+	// (no network) or allowlist (only AllowHosts, through an egress proxy)
+	Network    string   `yaml:"network"`
+	AllowHosts []string `yaml:"allow_hosts"` // same as --sandbox-allow, e.g. api.anthropic.com, *.wikipedia.org
+	// ProxyImage runs the egress proxy of network: allowlist; it must contain
+	// deepresearch (default: image)
+	ProxyImage string   `yaml:"proxy_image"`
+	Env        []string `yaml:"env"`  // host environment variables passed to the agents (default: the agents' API keys and tokens)
+	User       string   `yaml:"user"` // user the agents run as (default: yours, so the files they write are yours)
+	Args       []string `yaml:"args"` // extra arguments of the engine's run command, e.g. ["--memory", "4g"]
+}
+
+// Sandbox modes and networks
+const (
+	sandboxOff       = "off"
+	sandboxDocker    = "docker"
+	sandboxPodman    = "podman"
+	networkBridge    = "bridge"
+	networkNone      = "none"
+	networkAllowlist = "allowlist"
+)
+
+// sandboxLabel marks the containers and networks of sandboxed agents, e.g.
+// to find ones a killed run left behind: docker ps -a --filter label=deepresearch.sandbox
+const sandboxLabel = "deepresearch.sandbox"
+
+// sandboxProxyPort is where the egress proxy of an allowlisted sandbox listens
+const sandboxProxyPort = "3128"
+
+// defaultSandboxEnv are the credentials of the built-in agents, passed to the
+// container when they are set
+var defaultSandboxEnv = []string{
+	"ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN",
+	"OPENAI_API_KEY", "GEMINI_API_KEY", "GOOGLE_API_KEY",
+	"GH_TOKEN", "GITHUB_TOKEN", "COPILOT_GITHUB_TOKEN",
+}
+
+// agentSandbox runs the agents when --sandbox asks for it; nil runs them on
+// this machine
+var agentSandbox *containerSandbox
+
+// containerSandbox starts each agent in a fresh container with the workspace
+// bind-mounted at the same path, so the files the agent writes are in the
+// workspace when it exits and the rest of the workflow does not know the
+// difference
+type containerSandbox struct {
+	cfg        sandboxConfig
+	engine     string // path of the docker or podman CLI
+	promptsDir string // mounted read-only: the prompts name the guides in it
+}
+
+// containerRunner runs the agents of the orchestrator's steps in containers
+// of the sandbox, in place of hostRunner
+type containerRunner struct {
+	sb      *containerSandbox
+	workDir string
+	agentOf func(orchestrator.Phase) (agentName, model string)
+}
+
+func (r *containerRunner) RunAgent(ctx context.Context, step orchestrator.Step, prompt string) error {
+	agentName, model := r.agentOf(step.Phase)
+	return runAgentWith(ctx, r.sb.agentCommand, agentName, model, prompt, r.workDir)
+}
+
+// sandboxSeq numbers the containers of this process
+var sandboxSeq atomic.Int64
+
+// newContainerSandbox validates the sandbox settings and finds the engine
+func newContainerSandbox(cfg sandboxConfig) (*containerSandbox, error) {
+	if cfg.Mode != sandboxDocker && cfg.Mode != sandboxPodman {
+		return nil, fmt.Errorf("unknown mode %s (expected off, docker or podman)", cfg.Mode)
+	}
+	if cfg.Image == "" {
+		return nil, fmt.Errorf("an image containing the agent CLIs is required (--sandbox-image or sandbox.image)")
+	}
+	switch cfg.Network {
+	case "":
+		cfg.Network = networkBridge
+	case networkBridge, networkNone:
+	case networkAllowlist:
+		if len(cfg.AllowHosts) == 0 {
+			return nil, fmt.Errorf("network allowlist needs allow_hosts (--sandbox-allow)")
+		}
+	default:
+		return nil, fmt.Errorf("unknown network %s (expected bridge, none or allowlist)", cfg.Network)
+	}
+	if cfg.ProxyImage == "" {
+		cfg.ProxyImage = cfg.Image
+	}
+	engine, err := exec.LookPath(cfg.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not installed or not in PATH", cfg.Mode)
+	}
+	return &containerSandbox{cfg: cfg, engine: engine}, nil
+}
+
+// describe sums the sandbox up for the console and the logs
+func (s *containerSandbox) describe() string {
+	network := s.cfg.Network
+	if network == networkAllowlist {
+		network += " " + strings.Join(s.cfg.AllowHosts, ", ")
+	}
+	return fmt.Sprintf("%s containers of %s (network: %s)", s.cfg.Mode, s.cfg.Image, network)
+}
+
+// containerPath is where a host path is mounted in the container: the same
+// path, or on Windows the Docker Desktop form, /c/Users/... for C:\Users\...
+func containerPath(p string) string {
+	if runtime.GOOS != "windows" {
+		return p
+	}
+	vol := filepath.VolumeName(p)
+	return "/" + strings.ToLower(strings.TrimSuffix(vol, ":")) + filepath.ToSlash(strings.TrimPrefix(p, vol))
+}
+
+// pathMapper rewrites the host paths of the mounted directories in a prompt
+// or an argument to their container paths
+func (s *containerSandbox) pathMapper(workDir string) *strings.Replacer {
+	var pairs []string
+	for _, dir := range []string{workDir, s.promptsDir} {
+		if dir == "" || containerPath(dir) == dir {
+			continue
+		}
+		pairs = append(pairs, dir+string(filepath.Separator), containerPath(dir)+"/", dir, containerPath(dir))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// agentCommand builds the process of a non-interactive agent run in a
+// container, like directCommand does on this machine
+func (s *containerSandbox) agentCommand(cfg AgentConfig, model, prompt, workDir string) (*exec.Cmd, string, func(), error) {
+	arg, cleanup := prompt, func() {}
+	if len(prompt) > promptArgLimit() {
+		path, remove, err := writePromptFile(prompt, workDir)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		arg, cleanup = promptFileArg(containerPath(path)), remove
+	}
+	args := cfg.Args(arg, model, workDir)
+	cmd, stop, err := s.command(cfg, args, workDir, false)
+	if err != nil {
+		cleanup()
+		return nil, "", nil, err
+	}
+	shown := fmt.Sprintf("%s (%s): %s", s.cfg.Mode, s.cfg.Image, strings.Join(append([]string{sandboxAgentCommand(cfg)}, shownArgs(args, arg)...), " "))
+	return cmd, shown, func() { stop(); cleanup() }, nil
+}
+
+// sandboxAgentCommand is the agent CLI to run in the image: a path on this
+// machine, e.g. this binary for --agent api, is looked up there by its name
+func sandboxAgentCommand(cfg AgentConfig) string {
+	if !filepath.IsAbs(cfg.Command) {
+		return cfg.Command
+	}
+	return strings.TrimSuffix(filepath.Base(cfg.Command), filepath.Ext(cfg.Command))
+}
+
+// command builds the engine process that runs the agent with args in a new
+// container, and a cleanup that removes the container and its egress proxy
+// should they outlive the process (the engine CLI was killed). An interactive
+// agent keeps stdin and gets a terminal where the console has one.
+func (s *containerSandbox) command(cfg AgentConfig, args []string, workDir string, interactive bool) (*exec.Cmd, func(), error) {
+	name := fmt.Sprintf("deepresearch-agent-%d-%d", os.Getpid(), sandboxSeq.Add(1))
+	mapper := s.pathMapper(workDir)
+	run := []string{"run", "--rm", "--init", "--name", name, "--label", sandboxLabel + "=agent",
+		"-v", workDir + ":" + containerPath(workDir), "-w", containerPath(workDir)}
+	if s.promptsDir != "" {
+		run = append(run, "-v", s.promptsDir+":"+containerPath(s.promptsDir)+":ro")
+	}
+	switch {
+	case s.cfg.User != "":
+		run = append(run, "--user", s.cfg.User)
+	case s.cfg.Mode == sandboxPodman:
+		// Rootless podman maps the user's own ID into the container
+		run = append(run, "--userns=keep-id", "-e", "HOME=/tmp")
+	case runtime.GOOS != "windows":
+		// The image has no home for the user; agents keep their state in /tmp
+		run = append(run, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), "-e", "HOME=/tmp")
+	}
+	for _, env := range s.cfg.Env {
+		if _, ok := os.LookupEnv(env); ok {
+			run = append(run, "-e", env) // the engine copies the value
+		}
+	}
+	teardown := func() {}
+	switch s.cfg.Network {
+	case networkNone:
+		run = append(run, "--network", networkNone)
+	case networkAllowlist:
+		network, proxy, err := s.startProxy(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to start the sandbox egress proxy: %w", err)
+		}
+		teardown = func() { s.engineRun("rm", "-f", proxy); s.engineRun("network", "rm", network) }
+		url := "http://" + proxy + ":" + sandboxProxyPort
+		run = append(run, "--network", network)
+		for _, env := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			run = append(run, "-e", env+"="+url)
+		}
+		run = append(run, "-e", "NO_PROXY=localhost,127.0.0.1", "-e", "no_proxy=localhost,127.0.0.1")
+	}
+	if interactive {
+		run = append(run, "-i")
+		if isTerminal(os.Stdin) {
+			run = append(run, "-t")
+		}
+	}
+	run = append(run, s.cfg.Args...)
+	run = append(run, s.cfg.Image, sandboxAgentCommand(cfg))
+	for _, a := range args {
+		run = append(run, mapper.Replace(a))
+	}
+	cmd := exec.Command(s.engine, run...)
+	cmd.Dir = workDir
+	cleanup := func() {
+		s.engineRun("rm", "-f", name)
+		teardown()
+	}
+	return cmd, cleanup, nil
+}
+
+// startProxy creates an internal network for the agent container, without a
+// route out, and starts the egress proxy on both it and the engine's default
+// network. The proxy forwards requests to the allowed hosts only.
+func (s *containerSandbox) startProxy(name string) (network, proxy string, err error) {
+	network, proxy = name+"-net", name+"-proxy"
+	if err := s.engineRun("network", "create", "--internal", "--label", sandboxLabel+"=network", network); err != nil {
+		return "", "", err
+	}
+	if err := s.engineRun("run", "-d", "--rm", "--name", proxy, "--label", sandboxLabel+"=proxy", s.cfg.ProxyImage,
+		"deepresearch", "sandbox-proxy", "-listen", ":"+sandboxProxyPort, "-allow", strings.Join(s.cfg.AllowHosts, ",")); err != nil {
+		s.engineRun("network", "rm", network)
+		return "", "", err
+	}
+	if err := s.engineRun("network", "connect", network, proxy); err != nil {
+		s.engineRun("rm", "-f", proxy)
+		s.engineRun("network", "rm", network)
+		return "", "", err
+	}
+	return network, proxy, nil
+}
+
+// engineRun runs an engine command to completion, returning its output as the
+// error if it fails
+func (s *containerSandbox) engineRun(args ...string) error {
+	out, err := exec.Command(s.engine, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %s", s.cfg.Mode, args[0], msg)
+		}
+		return fmt.Errorf("%s %s: %w", s.cfg.Mode, args[0], err)
+	}
+	return nil
+}
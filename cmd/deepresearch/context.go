@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// contextDirName holds user-supplied context material (requirements, tickets,
+// discussions) that frames the research rather than serving as external evidence
+const contextDirName = "context"
+
+// contextIngester fetches the material a --context spec refers to and returns
+// the documents to write into context/, keyed by file name
+type contextIngester func(t *toolContext, arg string) (map[string]string, error)
+
+// contextIngesters maps a --context scheme ("jira:EPIC-123") to its ingester
+var contextIngesters = map[string]contextIngester{}
+
+// registerContextIngester adds a --context scheme; called from init functions
+func registerContextIngester(scheme string, ingest contextIngester) {
+	contextIngesters[scheme] = ingest
+}
+
+// contextFileIngester converts a local export (an .mbox file, a directory of
+// .eml files) into context documents
+type contextFileIngester func(path string) (map[string]string, error)
+
+// contextFileIngesters maps a file extension to its ingester
+var contextFileIngesters = map[string]contextFileIngester{}
+
+// registerContextFileIngester adds a local file type for --context
+func registerContextFileIngester(ext string, ingest contextFileIngester) {
+	contextFileIngesters[ext] = ingest
+}
+
+// fileIngester returns the ingester for a local path, or nil if spec is not an
+// existing file of a supported type. A directory is treated by the type of the
+// first supported file it contains.
+func fileIngester(spec string) contextFileIngester {
+	st, err := os.Stat(spec)
+	if err != nil {
+		return nil
+	}
+	if !st.IsDir() {
+		return contextFileIngesters[strings.ToLower(filepath.Ext(spec))]
+	}
+	entries, _ := os.ReadDir(spec)
+	for _, e := range entries {
+		if ingest, ok := contextFileIngesters[strings.ToLower(filepath.Ext(e.Name()))]; ok && !e.IsDir() {
+			return ingest
+		}
+	}
+	return nil
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// ingestContext resolves each --context spec into documents under context/ and
+// returns the relative paths written
+func ingestContext(workDir string, specs []string) ([]string, error) {
+	dir := filepath.Join(workDir, contextDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, spec := range specs {
+		var docs map[string]string
+		var err error
+		if ingestFile := fileIngester(spec); ingestFile != nil {
+			docs, err = ingestFile(spec)
+		} else {
+			scheme, arg, ok := strings.Cut(spec, ":")
+			ingest, known := contextIngesters[scheme]
+			if !ok || !known {
+				return written, fmt.Errorf("unsupported --context source %q (expected %s)", spec, strings.Join(contextSchemes(), ", "))
+			}
+			t := &toolContext{
+				WorkDir: workDir,
+				Config:  config.Connectors[scheme],
+				Out:     os.Stdout,
+				client:  &http.Client{Timeout: 60 * time.Second},
+			}
+			docs, err = ingest(t, arg)
+		}
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", spec, err)
+		}
+		for name, content := range docs {
+			rel := filepath.ToSlash(filepath.Join(contextDirName, name))
+			if err := os.WriteFile(filepath.Join(workDir, filepath.FromSlash(rel)), []byte(content), 0644); err != nil {
+				return written, err
+			}
+			written = append(written, rel)
+		}
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// contextSchemes returns the supported --context forms for error messages
+func contextSchemes() []string {
+	var schemes []string
+	for s := range contextIngesters {
+		schemes = append(schemes, s+":...")
+	}
+	for ext := range contextFileIngesters {
+		schemes = append(schemes, "a "+ext+" file")
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// contextFiles lists the documents in context/
+func contextFiles(workDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(workDir, contextDirName))
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			files = append(files, contextDirName+"/"+e.Name())
+		}
+	}
+	return files
+}
+
+// buildContextNote points agents at the context corpus, or "" if it is empty
+func buildContextNote(workDir string) string {
+	files := contextFiles(workDir)
+	if len(files) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+CONTEXT: The user supplied background material in %s/ (read it before planning or dispatching):
+- %s
+It describes the user's actual situation (requirements, problems, prior discussion). Let it shape
+the research questions and keep findings relevant to it, but treat it as the problem statement:
+it is not external evidence, and claims in it still need independent sources before they are
+reported as fact. Refer to it as "internal context" rather than registering it as a source.
+`, contextDirName, strings.Join(files, "\n- "))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// jobLeaseTTL is how long a replica's claim on a running job lasts without
+// renewal; jobs whose lease lapsed belong to a dead replica and are re-queued
+const jobLeaseTTL = 30 * time.Second
+
+// coordinator lets several serve replicas share work without running a job
+// twice. A replica holds a lease on every job it runs and renews it while the
+// run is alive; checkpoints record each running job's progress for any replica
+// to report.
+type coordinator interface {
+	// Acquire takes or renews the lease on key for owner; false when another
+	// owner holds an unexpired lease
+	Acquire(key, owner string, ttl time.Duration) (bool, error)
+	Release(key, owner string) error
+	SaveCheckpoint(id string, cp *checkpoint) error
+	// LoadCheckpoint returns nil when the job has no checkpoint
+	LoadCheckpoint(id string) (*checkpoint, error)
+	Close() error
+}
+
+// checkpoint is the last known progress of a running job
+type checkpoint struct {
+	Owner     string `json:"owner"`
+	Attempt   int    `json:"attempt"`
+	Phase     string `json:"phase,omitempty"`
+	Iteration int    `json:"iteration,omitempty"`
+	// TasksDone of TasksTotal tasks of task.md are completed
+	TasksDone  int    `json:"tasks_done"`
+	TasksTotal int    `json:"tasks_total"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// coordinators maps a server.coordination backend name to its constructor
+var coordinators = map[string]func(dsn string) (coordinator, error){
+	"file": openFileCoordinator,
+}
+
+// registerCoordinator adds a coordination backend; called from init functions
+func registerCoordinator(name string, open func(dsn string) (coordinator, error)) {
+	coordinators[name] = open
+}
+
+// openCoordinator opens the named coordination backend
+func openCoordinator(name, dsn string) (coordinator, error) {
+	open, ok := coordinators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown coordination backend %q (available: %s)", name, strings.Join(sortedKeys(coordinators), ", "))
+	}
+	return open(dsn)
+}
+
+// readCheckpoint derives a job's progress from the run manifest in its workspace
+func readCheckpoint(j *job, owner string) *checkpoint {
+	cp := &checkpoint{Owner: owner, Attempt: j.Attempts, UpdatedAt: time.Now().Format(time.RFC3339)}
+	if f, err := taskfile.Read(taskFilePath(j.Dir)); err == nil {
+		cp.TasksTotal = len(f.Tasks)
+		cp.TasksDone = cp.TasksTotal - len(f.Pending())
+	}
+	data, err := os.ReadFile(filepath.Join(j.Dir, manifestFileName))
+	if err != nil {
+		return cp
+	}
+	var m runManifest
+	if json.Unmarshal(data, &m) == nil && len(m.Phases) > 0 {
+		last := m.Phases[len(m.Phases)-1]
+		cp.Phase, cp.Iteration = last.Name, last.Iteration
+	}
+	return cp
+}
+
+// fileCoordinator keeps leases and checkpoints in a directory, which replicas
+// can share over a network file system
+type fileCoordinator struct {
+	dir string
+}
+
+// fileLease is the content of a lease file
+type fileLease struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func openFileCoordinator(dsn string) (coordinator, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("the file coordinator needs a directory")
+	}
+	for _, sub := range []string{"leases", "checkpoints"} {
+		if err := os.MkdirAll(filepath.Join(dsn, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &fileCoordinator{dir: dsn}, nil
+}
+
+// withFileLock runs fn while holding an exclusive lock file next to path. A
+// lock left behind by a crashed process is broken after ten seconds.
+func withFileLock(path string, fn func() error) error {
+	lock := path + ".lock"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		if st, err := os.Stat(lock); err == nil && time.Since(st.ModTime()) > 10*time.Second {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s", lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer os.Remove(lock)
+	return fn()
+}
+
+// fileKey turns a lease key or job ID into a file name
+func fileKey(key string) string {
+	return strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(key)
+}
+
+func (c *fileCoordinator) leasePath(key string) string {
+	return filepath.Join(c.dir, "leases", fileKey(key)+".json")
+}
+
+// writeFileAtomic replaces path so readers never see a partial file. The
+// temporary file is unique, so concurrent writers never write into each other's.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func (c *fileCoordinator) Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	path := c.leasePath(key)
+	acquired := false
+	err := withFileLock(path, func() error {
+		var l fileLease
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &l) == nil {
+			if l.Owner != owner && time.Now().Before(l.Expires) {
+				return nil
+			}
+		}
+		data, err := json.Marshal(fileLease{Owner: owner, Expires: time.Now().Add(ttl)})
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	return acquired, err
+}
+
+func (c *fileCoordinator) Release(key, owner string) error {
+	path := c.leasePath(key)
+	return withFileLock(path, func() error {
+		var l fileLease
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &l) == nil && l.Owner == owner {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (c *fileCoordinator) SaveCheckpoint(id string, cp *checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.dir, "checkpoints", fileKey(id)+".json"), data)
+}
+
+func (c *fileCoordinator) LoadCheckpoint(id string) (*checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, "checkpoints", fileKey(id)+".json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (c *fileCoordinator) Close() error { return nil }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys used by the redis coordinator
+const (
+	redisLeasePrefix    = "deepresearch:lease:"      // string holding the lease owner, expiring with the lease
+	redisCheckpointsKey = "deepresearch:checkpoints" // hash of job ID -> checkpoint JSON
+)
+
+// acquireLeaseScript sets the lease unless another owner holds it
+var acquireLeaseScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if cur == false or cur == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+  return 1
+end
+return 0`)
+
+// releaseLeaseScript deletes the lease only if owner still holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+func init() {
+	registerCoordinator("redis", openRedisCoordinator)
+}
+
+// redisCoordinator keeps leases and checkpoints in Redis for replicas that share
+// a redis queue
+type redisCoordinator struct {
+	rdb *redis.Client
+}
+
+// openRedisCoordinator connects to a redis:// URL (default redis://localhost:6379/0)
+func openRedisCoordinator(dsn string) (coordinator, error) {
+	if dsn == "" {
+		dsn = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return &redisCoordinator{rdb: rdb}, nil
+}
+
+func (c *redisCoordinator) Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	n, err := acquireLeaseScript.Run(context.Background(), c.rdb, []string{redisLeasePrefix + key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (c *redisCoordinator) Release(key, owner string) error {
+	return releaseLeaseScript.Run(context.Background(), c.rdb, []string{redisLeasePrefix + key}, owner).Err()
+}
+
+func (c *redisCoordinator) SaveCheckpoint(id string, cp *checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return c.rdb.HSet(context.Background(), redisCheckpointsKey, id, data).Err()
+}
+
+func (c *redisCoordinator) LoadCheckpoint(id string) (*checkpoint, error) {
+	data, err := c.rdb.HGet(context.Background(), redisCheckpointsKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (c *redisCoordinator) Close() error { return c.rdb.Close() }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Actions when a run's estimated spend reaches its budget (cost.on_budget, --on-budget)
+const (
+	budgetAbort      = "abort"      // stop the running agent and fail the run
+	budgetSynthesize = "synthesize" // finish the running step, then write the report from the findings so far
+)
+
+// costConfig prices the tokens agents report and bounds a run's spend
+type costConfig struct {
+	BudgetUSD float64 `yaml:"budget_usd"` // estimated spend that ends the run (0 = no limit)
+	OnBudget  string  `yaml:"on_budget"`  // abort, synthesize (default)
+	// Prices adds or overrides USD prices per million tokens, keyed by model name
+	// prefix; the longest matching prefix wins
+	Prices map[string]modelPrice `yaml:"prices"`
+}
+
+// modelPrice is the USD price of a million input and output tokens
+type modelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// defaultPrices are list prices of common models, used for agents that report
+// tokens but not what they cost
+var defaultPrices = map[string]modelPrice{
+	"claude-opus-4":     {15, 75},
+	"claude-opus-4-5":   {5, 25},
+	"claude-opus-4.5":   {5, 25},
+	"claude-sonnet-4":   {3, 15},
+	"claude-3-7-sonnet": {3, 15},
+	"claude-3-5-sonnet": {3, 15},
+	"claude-haiku-4":    {1, 5},
+	"claude-3-5-haiku":  {0.8, 4},
+	"gpt-5":             {1.25, 10},
+	"gpt-5-mini":        {0.25, 2},
+	"gpt-5-nano":        {0.05, 0.4},
+	"gpt-4.1":           {2, 8},
+	"gpt-4.1-mini":      {0.4, 1.6},
+	"gpt-4.1-nano":      {0.1, 0.4},
+	"gpt-4o":            {2.5, 10},
+	"gpt-4o-mini":       {0.15, 0.6},
+	"o3":                {2, 8},
+	"o3-mini":           {1.1, 4.4},
+	"o4-mini":           {1.1, 4.4},
+	"gemini-2.5-pro":    {1.25, 10},
+	"gemini-2.5-flash":  {0.3, 2.5},
+	"gemini-2.0-flash":  {0.1, 0.4},
+}
+
+// priceOf returns the price of a model from cost.prices and the built-in list
+func priceOf(model string) (modelPrice, bool) {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:] // e.g. "anthropic/claude-sonnet-4" or "models/gemini-2.5-pro"
+	}
+	var best string
+	var price modelPrice
+	for _, prices := range []map[string]modelPrice{defaultPrices, config.Cost.Prices} {
+		for prefix, p := range prices {
+			prefix = strings.ToLower(prefix)
+			// A configured price replaces a built-in one of the same prefix
+			if strings.HasPrefix(model, prefix) && len(prefix) >= len(best) {
+				best, price = prefix, p
+			}
+		}
+	}
+	return price, best != ""
+}
+
+// tokenUsage is what agent runs used, as far as the agents report it
+type tokenUsage struct {
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+func (u *tokenUsage) add(o tokenUsage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CostUSD += o.CostUSD
+}
+
+// tokens returns the input and output tokens together
+func (u tokenUsage) tokens() int64 { return u.InputTokens + u.OutputTokens }
+
+var (
+	// apiUsageRe matches the line the api agent prints after each model reply
+	apiUsageRe = regexp.MustCompile(`^\[usage\] model=(\S*) input_tokens=(\d+) output_tokens=(\d+)$`)
+	// copilotUsageRe matches a "Usage by model" line of the Copilot CLI, e.g.
+	// "    claude-sonnet-4.5   16.2k input, 312 output, 0 cache read, ..."
+	copilotUsageRe = regexp.MustCompile(`^\s+([a-z][\w.\-/]*)\s+([\d.]+[kmKM]?) input, ([\d.]+[kmKM]?) output\b`)
+	// aiderUsageRe matches aider's per-message line, e.g.
+	// "Tokens: 3.2k sent, 89 received. Cost: $0.01 message, $0.02 session."
+	aiderUsageRe = regexp.MustCompile(`^Tokens: ([\d.]+[kmKM]?) sent,.*?([\d.]+[kmKM]?) received\.(?:\s*Cost: \$([\d.]+) message)?`)
+)
+
+// parseUsage reads the usage an agent reports in a line of its output. It
+// returns the model the line names ("" if none) and whether the agent
+// reported the cost itself.
+func parseUsage(line string) (usage tokenUsage, model string, priced, ok bool) {
+	if m := apiUsageRe.FindStringSubmatch(line); m != nil {
+		in, _ := strconv.ParseInt(m[2], 10, 64)
+		out, _ := strconv.ParseInt(m[3], 10, 64)
+		return tokenUsage{InputTokens: in, OutputTokens: out}, m[1], false, true
+	}
+	if m := copilotUsageRe.FindStringSubmatch(line); m != nil {
+		return tokenUsage{InputTokens: tokenCount(m[2]), OutputTokens: tokenCount(m[3])}, m[1], false, true
+	}
+	if m := aiderUsageRe.FindStringSubmatch(line); m != nil {
+		u := tokenUsage{InputTokens: tokenCount(m[1]), OutputTokens: tokenCount(m[2])}
+		if m[3] != "" {
+			u.CostUSD, _ = strconv.ParseFloat(m[3], 64)
+		}
+		return u, "", m[3] != "", true
+	}
+	// The result event of the Claude CLI with --output-format json or stream-json
+	if strings.HasPrefix(line, "{") && strings.Contains(line, `"total_cost_usd"`) {
+		var r struct {
+			Type      string  `json:"type"`
+			TotalCost float64 `json:"total_cost_usd"`
+			Usage     struct {
+				InputTokens         int64 `json:"input_tokens"`
+				CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+				CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+				OutputTokens        int64 `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		if json.Unmarshal([]byte(line), &r) == nil && r.Type == "result" {
+			return tokenUsage{
+				InputTokens:  r.Usage.InputTokens + r.Usage.CacheCreationTokens + r.Usage.CacheReadTokens,
+				OutputTokens: r.Usage.OutputTokens,
+				CostUSD:      r.TotalCost,
+			}, "", true, true
+		}
+	}
+	return tokenUsage{}, "", false, false
+}
+
+// streamJSONText returns the text to show for a line of the Claude CLI's
+// stream-json output: what the assistant writes and the tools it calls, or ""
+// for events with nothing to show. ok is false for lines that are not events.
+func streamJSONText(line string) (text string, ok bool) {
+	if !strings.HasPrefix(line, `{"type":`) {
+		return "", false
+	}
+	var ev struct {
+		Type    string `json:"type"`
+		IsError bool   `json:"is_error"`
+		Result  string `json:"result"`
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+				Name string `json:"name"`
+			} `json:"content"`
+		} `json:"message"`
+	}
+	if json.Unmarshal([]byte(line), &ev) != nil {
+		return "", false
+	}
+	switch ev.Type {
+	case "assistant":
+		var parts []string
+		for _, c := range ev.Message.Content {
+			switch {
+			case c.Type == "text" && c.Text != "":
+				parts = append(parts, c.Text)
+			case c.Type == "tool_use":
+				parts = append(parts, "→ "+c.Name)
+			}
+		}
+		return strings.Join(parts, "\n"), true
+	case "result":
+		// The result repeats the last reply, unless the run failed
+		if ev.IsError {
+			return ev.Result, true
+		}
+	}
+	return "", true
+}
+
+// tokenCount parses a token count such as "312", "16.2k" or "1.1m"
+func tokenCount(s string) int64 {
+	mult := 1.0
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "k":
+		mult, s = 1e3, s[:len(s)-1]
+	case "m":
+		mult, s = 1e6, s[:len(s)-1]
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return int64(f * mult)
+}
+
+// usageMeter adds up the usage agents report in their output to the run
+// manifest, per phase and in total, and enforces the budget
+var meter = &usageMeter{}
+
+type usageMeter struct {
+	mu       sync.Mutex
+	model    string // of the run, for lines that name none
+	cancel   context.CancelCauseFunc
+	unpriced map[string]bool // models whose tokens have no price
+	reached  bool            // the estimated spend reached the budget
+}
+
+// watchBudget prepares the meter for a run of model and returns a context
+// that --on-budget abort cancels once the estimated spend reaches the budget
+func watchBudget(ctx context.Context, model string) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	meter.model = model
+	meter.cancel = cancel
+	// The spend of a resumed run's earlier phases counts too
+	meter.check()
+	return ctx
+}
+
+// observe records the usage an agent reports in a line of its output
+func (m *usageMeter) observe(line string) {
+	u, model, priced, ok := parseUsage(line)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if manifest == nil {
+		return // not a research run, e.g. a remote worker
+	}
+	if model == "" {
+		model = m.model
+		if n := len(manifest.Phases); n > 0 && manifest.Phases[n-1].Agent != "" {
+			model = manifest.Phases[n-1].Model // of a phase with an agent of its own
+		}
+	}
+	if !priced {
+		if p, ok := priceOf(model); ok {
+			u.CostUSD = (float64(u.InputTokens)*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+		} else if u.tokens() > 0 {
+			if m.unpriced == nil {
+				m.unpriced = map[string]bool{}
+			}
+			if model == "" {
+				model = "(default)"
+			}
+			m.unpriced[model] = true
+		}
+	}
+	manifest.addUsage(u)
+	m.check()
+}
+
+// check acts on the budget once the estimated spend reaches it
+func (m *usageMeter) check() {
+	budget := config.Cost.BudgetUSD
+	if m.reached || budget <= 0 || manifest == nil || manifest.Usage == nil || manifest.Usage.CostUSD < budget {
+		return
+	}
+	m.reached = true
+	spent := manifest.Usage.CostUSD
+	logEntry("WARN", "BUDGET", currentIteration(), "Estimated spend reached the budget", map[string]string{
+		"spent_usd":  fmt.Sprintf("%.4f", spent),
+		"budget_usd": fmt.Sprintf("%.2f", budget),
+		"action":     config.Cost.OnBudget,
+	})
+	if config.Cost.OnBudget == budgetAbort && m.cancel != nil {
+		warn("Estimated spend $%.2f reached the budget of $%.2f; stopping the run", spent, budget)
+		m.cancel(withCode(codeBudgetExceeded, fmt.Errorf("estimated spend $%.2f reached the budget of $%.2f (--budget, cost.budget_usd)", spent, budget)))
+		return
+	}
+	warn("Estimated spend $%.2f reached the budget of $%.2f; writing the report from the findings so far", spent, budget)
+}
+
+// stopResearch reports whether the research loop must end for the budget
+func (m *usageMeter) stopResearch() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reached && config.Cost.OnBudget == budgetSynthesize
+}
+
+// unpricedModels lists the models whose tokens are missing from the estimated cost
+func (m *usageMeter) unpricedModels() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedKeys(m.unpriced)
+}
+
+// formatTokens renders a token count briefly, e.g. "950", "16.2k" or "1.35M"
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.2fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
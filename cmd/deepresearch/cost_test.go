@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestStreamJSONText(t *testing.T) {
+	tests := []struct {
+		line string
+		text string
+		ok   bool
+	}{
+		{`plain output`, "", false},
+		{`{"type":"system","subtype":"init","session_id":"x"}`, "", true},
+		{`{"type":"assistant","message":{"content":[{"type":"text","text":"Reading the plan"}]}}`, "Reading the plan", true},
+		{`{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash","input":{"command":"ls"}}]}}`, "→ Bash", true},
+		{`{"type":"user","message":{"content":[{"type":"tool_result","content":"task.md"}]}}`, "", true},
+		{`{"type":"result","subtype":"success","is_error":false,"result":"Done","total_cost_usd":0.5}`, "", true},
+		{`{"type":"result","subtype":"error_during_execution","is_error":true,"result":"Overloaded"}`, "Overloaded", true},
+	}
+	for _, tt := range tests {
+		text, ok := streamJSONText(tt.line)
+		if text != tt.text || ok != tt.ok {
+			t.Errorf("streamJSONText(%s) = %q, %v, want %q, %v", tt.line, text, ok, tt.text, tt.ok)
+		}
+	}
+}
+
+func TestParseUsageClaudeStreamJSON(t *testing.T) {
+	line := `{"type":"result","subtype":"success","total_cost_usd":0.42,"usage":{"input_tokens":100,"cache_creation_input_tokens":20,"cache_read_input_tokens":30,"output_tokens":50}}`
+	u, _, priced, ok := parseUsage(line)
+	if !ok || !priced {
+		t.Fatalf("parseUsage = ok %v, priced %v, want both", ok, priced)
+	}
+	want := tokenUsage{InputTokens: 150, OutputTokens: 50, CostUSD: 0.42}
+	if u != want {
+		t.Errorf("usage = %+v, want %+v", u, want)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// citationMetadataName caches resolved bibliographic metadata, in assets/
+const citationMetadataName = "metadata.json"
+
+// crossrefAPI is the default Crossref REST API base URL
+const crossrefAPI = "https://api.crossref.org"
+
+// crossrefConfig controls DOI resolution through Crossref
+type crossrefConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Mailto   string `yaml:"mailto"`   // contact address for Crossref's polite pool
+	Endpoint string `yaml:"endpoint"` // override the API base URL
+}
+
+// sourceMetadata is authoritative metadata resolved for a source
+type sourceMetadata struct {
+	DOI        string   `json:"doi"`
+	Title      string   `json:"title,omitempty"`
+	Authors    []string `json:"authors,omitempty"`
+	Issued     string   `json:"issued,omitempty"`
+	Container  string   `json:"container,omitempty"`
+	Publisher  string   `json:"publisher,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Resolver   string   `json:"resolver"`
+	ResolvedAt string   `json:"resolved_at"`
+}
+
+// loadCitationMetadata reads assets/metadata.json, keyed by source ID
+func loadCitationMetadata(workDir string) map[string]sourceMetadata {
+	meta := map[string]sourceMetadata{}
+	if data, err := os.ReadFile(assetsPath(workDir, citationMetadataName)); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+// crossrefWork is the subset of a Crossref work record that is used
+type crossrefWork struct {
+	DOI            string   `json:"DOI"`
+	Type           string   `json:"type"`
+	Title          []string `json:"title"`
+	ContainerTitle []string `json:"container-title"`
+	Publisher      string   `json:"publisher"`
+	Author         []struct {
+		Given  string `json:"given"`
+		Family string `json:"family"`
+		Name   string `json:"name"`
+	} `json:"author"`
+	Issued struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"issued"`
+}
+
+// crossrefTypes maps Crossref work types to CSL item types
+var crossrefTypes = map[string]string{
+	"journal-article":     "article-journal",
+	"proceedings-article": "paper-conference",
+	"posted-content":      "article",
+	"book":                "book",
+	"book-chapter":        "chapter",
+	"report":              "report",
+	"dataset":             "dataset",
+}
+
+func (w crossrefWork) metadata() sourceMetadata {
+	m := sourceMetadata{DOI: strings.ToLower(w.DOI), Publisher: w.Publisher, Type: crossrefTypes[w.Type], Resolver: "crossref"}
+	if len(w.Title) > 0 {
+		m.Title = w.Title[0]
+	}
+	if len(w.ContainerTitle) > 0 {
+		m.Container = w.ContainerTitle[0]
+	}
+	for _, a := range w.Author {
+		switch {
+		case a.Family != "" && a.Given != "":
+			m.Authors = append(m.Authors, a.Family+", "+a.Given)
+		case a.Family != "":
+			m.Authors = append(m.Authors, a.Family)
+		case a.Name != "":
+			m.Authors = append(m.Authors, a.Name)
+		}
+	}
+	if len(w.Issued.DateParts) > 0 {
+		var parts []string
+		for i, p := range w.Issued.DateParts[0] {
+			if i == 0 {
+				parts = append(parts, fmt.Sprintf("%04d", p))
+			} else {
+				parts = append(parts, fmt.Sprintf("%02d", p))
+			}
+		}
+		m.Issued = strings.Join(parts, "-")
+	}
+	return m
+}
+
+// crossrefClient queries the Crossref REST API
+type crossrefClient struct {
+	base   string
+	mailto string
+	client *http.Client
+}
+
+func (c *crossrefClient) get(u string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	ua := "deepresearch/" + version
+	if c.mailto != "" {
+		ua += " (mailto:" + c.mailto + ")"
+	}
+	req.Header.Set("User-Agent", ua)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("crossref returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// byDOI resolves a DOI
+func (c *crossrefClient) byDOI(doi string) (crossrefWork, error) {
+	var resp struct {
+		Message crossrefWork `json:"message"`
+	}
+	err := c.get(c.base+"/works/"+url.PathEscape(doi), &resp)
+	return resp.Message, err
+}
+
+// byTitle returns the best bibliographic match for a title, if it is close enough
+func (c *crossrefClient) byTitle(title string) (crossrefWork, bool, error) {
+	var resp struct {
+		Message struct {
+			Items []crossrefWork `json:"items"`
+		} `json:"message"`
+	}
+	q := url.Values{"query.bibliographic": {title}, "rows": {"1"}}
+	if err := c.get(c.base+"/works?"+q.Encode(), &resp); err != nil {
+		return crossrefWork{}, false, err
+	}
+	if len(resp.Message.Items) == 0 || len(resp.Message.Items[0].Title) == 0 {
+		return crossrefWork{}, false, nil
+	}
+	w := resp.Message.Items[0]
+	return w, normalizeTitle(w.Title[0]) == normalizeTitle(title), nil
+}
+
+// normalizeTitle lowercases a title and drops punctuation for comparison
+func normalizeTitle(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// assetDOI looks for a DOI in the archived copy of a source (citation_doi meta tags,
+// PDF front matter)
+func assetDOI(workDir, localPath string) string {
+	if localPath == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, localPath))
+	if err != nil {
+		return ""
+	}
+	if len(data) > 256*1024 {
+		data = data[:256*1024]
+	}
+	return extractDOI(string(data))
+}
+
+// looksLikePaper reports whether a source of workDir is worth a title lookup without a DOI
+func looksLikePaper(workDir string, s registeredSource) bool {
+	t := strings.ToLower(s.Type)
+	return strings.Contains(t, "paper") || strings.Contains(t, "journal") || strings.Contains(t, "preprint") ||
+		strings.Contains(s.URL, "arxiv.org") || strings.Contains(s.URL, "ssrn.com") || strings.HasPrefix(s.LocalPath, assetRel(workDir, "pdf/"))
+}
+
+// enrichCitations resolves authoritative metadata for sources with a DOI or that
+// look like papers, caching results in assets/metadata.json. Returns the number of
+// newly resolved sources.
+func enrichCitations(workDir string) (int, error) {
+	if !config.Crossref.Enabled {
+		return 0, nil
+	}
+	meta := loadCitationMetadata(workDir)
+	cr := &crossrefClient{base: crossrefAPI, mailto: config.Crossref.Mailto, client: &http.Client{Timeout: 30 * time.Second}}
+	if config.Crossref.Endpoint != "" {
+		cr.base = strings.TrimRight(config.Crossref.Endpoint, "/")
+	}
+	resolved := 0
+	for _, s := range readSourceRegistry(workDir) {
+		if _, ok := meta[s.ID]; ok {
+			continue
+		}
+		doi := extractDOI(s.URL)
+		if doi == "" {
+			doi = assetDOI(workDir, s.LocalPath)
+		}
+		var work crossrefWork
+		var err error
+		switch {
+		case doi != "":
+			work, err = cr.byDOI(doi)
+		case s.Title != "" && looksLikePaper(workDir, s):
+			var ok bool
+			work, ok, err = cr.byTitle(s.Title)
+			if err == nil && !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			logEntry("WARN", "CITATION", 0, "Crossref lookup failed", map[string]string{
+				"source": s.ID,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		m := work.metadata()
+		m.ResolvedAt = time.Now().Format(time.RFC3339)
+		meta[s.ID] = m
+		resolved++
+	}
+	if resolved == 0 {
+		return 0, nil
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return resolved, os.WriteFile(assetsPath(workDir, citationMetadataName), data, 0644)
+}
+
+// applyMetadata overlays resolved metadata onto a citation built from scraped values
+func applyMetadata(c citation, m sourceMetadata) citation {
+	if m.DOI != "" {
+		c.DOI = m.DOI
+	}
+	if m.Title != "" {
+		c.Title = m.Title
+	}
+	if len(m.Authors) > 0 {
+		c.Authors = m.Authors
+	}
+	if m.Issued != "" {
+		c.Issued = m.Issued
+	}
+	if m.Container != "" {
+		c.Container = m.Container
+	}
+	if m.Publisher != "" {
+		c.Publisher = m.Publisher
+	}
+	if m.Type != "" {
+		c.Type = m.Type
+	}
+	return c
+}
+
+// buildCitationMetadataNote gives the synthesizer the authoritative records to use
+// in the References section, or "" if none were resolved
+func buildCitationMetadataNote(workDir string) string {
+	meta := loadCitationMetadata(workDir)
+	if len(meta) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, c := range buildCitations(workDir) {
+		if _, ok := meta[c.ID]; !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s (%s). %s. %s. https://doi.org/%s",
+			c.ID, strings.Join(c.Authors, "; "), c.Issued, c.Title, c.Container, c.DOI))
+	}
+	return fmt.Sprintf(`
+CITATION_METADATA: Authoritative metadata resolved via Crossref. In the References section use these
+authors, titles, venues, years and DOIs instead of values scraped from web pages:
+%s
+`, strings.Join(lines, "\n"))
+}
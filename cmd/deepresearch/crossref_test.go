@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// registryWorkspace creates a workspace whose Source Registry lists rows
+func registryWorkspace(t *testing.T, rows ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	task := "# Research Task: Deep learning\n\n## Knowledge Graph\n\n### Source Registry\n| ID | URL | Title | Type | Date Accessed | Local Path |\n|----|-----|-------|------|---------------|------------|\n" +
+		strings.Join(rows, "\n") + "\n"
+	if err := os.MkdirAll(assetsPath(dir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(taskFilePath(dir), []byte(task), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// useCrossref enables Crossref lookups against endpoint for the test
+func useCrossref(t *testing.T, endpoint string) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.Crossref = crossrefConfig{Enabled: true, Endpoint: endpoint}
+}
+
+func TestEnrichCitations(t *testing.T) {
+	srv := recordedAPI(t, "crossref", map[string]string{
+		"/works/10.1038/nature14539": "doi.json",
+		"/works":                     "search.json",
+	})
+	useCrossref(t, srv.URL)
+	dir := registryWorkspace(t,
+		"| S1 | https://doi.org/10.1038/nature14539 | Deep learning | Paper | 2025-03-01 | |",
+		"| S2 | https://arxiv.org/abs/1706.03762 | Attention is all you need | Preprint | 2025-03-01 | |",
+		"| S3 | https://example.com/blog/transformers | Transformers explained | Web | 2025-03-01 | |",
+	)
+	n, err := enrichCitations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("resolved %d source(s), want 2", n)
+	}
+	meta := loadCitationMetadata(dir)
+	s1 := meta["S1"]
+	if s1.DOI != "10.1038/nature14539" || s1.Container != "Nature" || s1.Issued != "2015-05-27" || s1.Type != "article-journal" ||
+		strings.Join(s1.Authors, "; ") != "LeCun, Yann; Bengio, Yoshua; Hinton, Geoffrey" {
+		t.Errorf("S1 = %+v", s1)
+	}
+	s2 := meta["S2"]
+	if s2.DOI != "10.48550/arxiv.1706.03762" || s2.Issued != "2017" || strings.Join(s2.Authors, "; ") != "Vaswani, Ashish; Google Brain" {
+		t.Errorf("S2 = %+v", s2)
+	}
+	if _, ok := meta["S3"]; ok {
+		t.Error("S3 is not a paper but was looked up")
+	}
+}
+
+func TestEnrichCitationsNoMatch(t *testing.T) {
+	srv := recordedAPI(t, "crossref", map[string]string{"/works": "search_empty.json"})
+	useCrossref(t, srv.URL)
+	dir := registryWorkspace(t, "| S1 | https://arxiv.org/abs/2401.00001 | An unpublished preprint | Preprint | 2025-03-01 | |")
+	n, err := enrichCitations(dir)
+	if err != nil || n != 0 {
+		t.Fatalf("enrichCitations = %d, %v; want nothing resolved", n, err)
+	}
+	if _, err := os.Stat(assetsPath(dir, citationMetadataName)); !os.IsNotExist(err) {
+		t.Errorf("%s written without a match", citationMetadataName)
+	}
+}
+
+func TestEnrichCitationsHTTPErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(status), status)
+		}))
+		useCrossref(t, srv.URL)
+		dir := registryWorkspace(t, "| S1 | https://doi.org/10.1038/nature14539 | Deep learning | Paper | 2025-03-01 | |")
+		n, err := enrichCitations(dir)
+		srv.Close()
+		// A failed lookup is logged and retried on the next run, not fatal
+		if err != nil || n != 0 {
+			t.Errorf("HTTP %d: enrichCitations = %d, %v; want nothing resolved", status, n, err)
+		}
+		if len(loadCitationMetadata(dir)) != 0 {
+			t.Errorf("HTTP %d: metadata cached for a failed lookup", status)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dataDirName holds the CSV files extracted from the report, relative to the working directory
+const dataDirName = "data"
+
+// mdTable is a Markdown table found in a document
+type mdTable struct {
+	Title  string // nearest preceding heading
+	Header []string
+	Rows   [][]string
+	Start  int // line index of the header row
+	End    int // line index just after the last row
+}
+
+var (
+	cellCiteRe   = regexp.MustCompile(`\[(S\d+)\](?:\([^)]*\))?`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasisRe = regexp.MustCompile("\\*\\*|__|`")
+)
+
+// parseMarkdownTables returns the tables in lines, skipping fenced code blocks
+func parseMarkdownTables(lines []string) []mdTable {
+	var tables []mdTable
+	title, inFence := "", false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			title = strings.TrimSpace(m[1])
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "|") || i+1 >= len(lines) || !tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+		t := mdTable{Title: title, Header: splitRow(trimmed), Start: i}
+		j := i + 2
+		for ; j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|"); j++ {
+			t.Rows = append(t.Rows, splitRow(lines[j]))
+		}
+		t.End = j
+		tables = append(tables, t)
+		i = j - 1
+	}
+	return tables
+}
+
+// normalizeCell strips Markdown formatting and citations from a cell and returns
+// the plain value and the source IDs it cited
+func normalizeCell(cell string) (string, []string) {
+	var ids []string
+	for _, c := range cellCiteRe.FindAllStringSubmatch(cell, -1) {
+		ids = append(ids, c[1])
+	}
+	cell = cellCiteRe.ReplaceAllString(cell, "")
+	cell = mdLinkRe.ReplaceAllString(cell, "$1")
+	cell = mdEmphasisRe.ReplaceAllString(cell, "")
+	return strings.TrimSpace(cell), ids
+}
+
+// normalizeTable converts a report table into CSV records: formatting is stripped,
+// all-numeric columns are written as plain numbers, and citations move to a
+// trailing "sources" column
+func normalizeTable(t mdTable) [][]string {
+	rows := make([][]string, len(t.Rows))
+	sources := make([][]string, len(t.Rows))
+	for i, r := range t.Rows {
+		rows[i] = make([]string, len(t.Header))
+		for j := range t.Header {
+			if j < len(r) {
+				v, ids := normalizeCell(r[j])
+				rows[i][j] = v
+				sources[i] = append(sources[i], ids...)
+			}
+		}
+	}
+	header := make([]string, len(t.Header))
+	for j, h := range t.Header {
+		header[j], _ = normalizeCell(h)
+		numeric := true
+		for i := range rows {
+			if _, ok := parseNumber(rows[i][j]); !ok && rows[i][j] != "" {
+				numeric = false
+				break
+			}
+		}
+		if !numeric || j == 0 {
+			continue
+		}
+		for i := range rows {
+			if v, ok := parseNumber(rows[i][j]); ok {
+				rows[i][j] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+	}
+
+	records := [][]string{append(header, "sources")}
+	for i, r := range rows {
+		records = append(records, append(r, strings.Join(dedupe(sources[i]), " ")))
+	}
+	return records
+}
+
+// dedupe returns ids without repeats, keeping first-seen order
+func dedupe(ids []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// reportTables returns the data tables in report.md (the reference list is not data)
+func reportTables(workDir string) ([]mdTable, error) {
+	content, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return nil, err
+	}
+	var tables []mdTable
+	for _, t := range parseMarkdownTables(strings.Split(string(content), "\n")) {
+		if len(t.Rows) < 2 || strings.Contains(strings.ToLower(t.Title), "reference") {
+			continue
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// exportDataCSV writes each data table in report.md to data/NN-slug.csv and
+// returns the files written
+func exportDataCSV(workDir string) ([]string, error) {
+	tables, err := reportTables(workDir)
+	if err != nil || len(tables) == 0 {
+		return nil, err
+	}
+	dir := filepath.Join(workDir, dataDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// Replace tables exported by an earlier synthesis of this workspace
+	old, _ := filepath.Glob(filepath.Join(dir, "[0-9][0-9]*.csv"))
+	for _, f := range old {
+		os.Remove(f)
+	}
+	var files []string
+	for i, t := range tables {
+		name := fmt.Sprintf("%02d", i+1)
+		if slug := slugify(t.Title); slug != "" {
+			name += "-" + slug
+		}
+		name += ".csv"
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return files, err
+		}
+		w := csv.NewWriter(f)
+		w.WriteAll(normalizeTable(t))
+		if err := w.Error(); err != nil {
+			f.Close()
+			return files, err
+		}
+		if err := f.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, filepath.ToSlash(filepath.Join(dataDirName, name)))
+	}
+	return files, nil
+}
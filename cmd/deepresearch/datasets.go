@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default endpoints of the dataset hubs
+const (
+	huggingFaceAPI = "https://huggingface.co"
+	kaggleAPI      = "https://www.kaggle.com/api/v1"
+)
+
+func init() {
+	registerConnector(&connector{
+		Name:    "huggingface",
+		Summary: "Hugging Face Hub dataset search with licenses, sizes and dataset cards",
+		Actions: map[string]string{
+			"search":  `-q "query" [-n 10] — datasets sorted by downloads`,
+			"dataset": `<owner/name> — dataset card and metadata, saved to assets/datasets`,
+		},
+		Run: runHuggingFace,
+	})
+	registerConnector(&connector{
+		Name:    "kaggle",
+		Summary: "Kaggle dataset search with licenses and sizes (requires Kaggle API credentials)",
+		Actions: map[string]string{
+			"search":  `-q "query" [-n 10] — datasets sorted by relevance`,
+			"dataset": `<owner/slug> — dataset description and metadata, saved to assets/datasets`,
+		},
+		Run: runKaggle,
+	})
+}
+
+// datasetRecord is a provider-independent dataset description
+type datasetRecord struct {
+	ID          string
+	Title       string
+	URL         string
+	License     string
+	Size        string
+	Updated     string // YYYY-MM-DD
+	Popularity  string
+	Description string
+}
+
+// saveDataset archives a dataset description and prints it
+func saveDataset(t *toolContext, hub string, d datasetRecord) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "# %s\n\n- Hub: %s\n- ID: %s\n- License: %s\n- Size: %s\n- Last updated: %s\n- Popularity: %s\n\n%s\n",
+		d.Title, hub, d.ID, orUnknown(d.License), orUnknown(d.Size), d.Updated, d.Popularity, d.Description)
+	path, err := t.saveDocument("datasets", hub+"_"+d.ID, [][2]string{
+		{"title", d.Title},
+		{"url", d.URL},
+		{"license", d.License},
+		{"size", d.Size},
+		{"published", d.Updated},
+		{"hub", hub},
+	}, body.String())
+	if err != nil {
+		return err
+	}
+	t.printf("%s\nArchived: %s\n\nRecord datasets you rely on in the Source Registry (type: dataset) with their license and size.\n", body.String(), path)
+	return nil
+}
+
+// printDatasets prints a search result table
+func printDatasets(t *toolContext, rawPath string, list []datasetRecord) {
+	t.printf("%d dataset(s). Raw results: %s\n\n| ID | Title | License | Size | Updated | Popularity |\n|---|---|---|---|---|---|\n", len(list), rawPath)
+	for _, d := range list {
+		t.printf("| %s | %s | %s | %s | %s | %s |\n", d.ID, mdCell(d.Title), orUnknown(d.License), orUnknown(d.Size), d.Updated, d.Popularity)
+	}
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// humanBytes formats a byte count as a short human-readable size
+func humanBytes(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// hfDataset is the subset of a Hugging Face Hub dataset record that is reported
+type hfDataset struct {
+	ID           string   `json:"id"`
+	Downloads    int      `json:"downloads"`
+	Likes        int      `json:"likes"`
+	LastModified string   `json:"lastModified"`
+	Tags         []string `json:"tags"`
+	Description  string   `json:"description"`
+	CardData     struct {
+		License any    `json:"license"`
+		Pretty  string `json:"pretty_name"`
+	} `json:"cardData"`
+}
+
+// record extracts license and size from the dataset's tags ("license:mit",
+// "size_categories:1K<n<10K")
+func (d hfDataset) record() datasetRecord {
+	r := datasetRecord{
+		ID: d.ID, Title: d.ID, URL: "https://huggingface.co/datasets/" + d.ID,
+		Popularity:  fmt.Sprintf("%d downloads, %d likes", d.Downloads, d.Likes),
+		Description: d.Description,
+	}
+	if d.CardData.Pretty != "" {
+		r.Title = d.CardData.Pretty
+	}
+	if len(d.LastModified) >= 10 {
+		r.Updated = d.LastModified[:10]
+	}
+	for _, tag := range d.Tags {
+		if v, ok := strings.CutPrefix(tag, "license:"); ok && r.License == "" {
+			r.License = v
+		}
+		if v, ok := strings.CutPrefix(tag, "size_categories:"); ok {
+			r.Size = v + " rows"
+		}
+	}
+	return r
+}
+
+// hfCardBody drops the YAML metadata block at the top of a dataset card, which
+// the API already returns as cardData
+func hfCardBody(card string) string {
+	if rest, ok := strings.CutPrefix(card, "---\n"); ok {
+		if _, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			return strings.TrimSpace(body)
+		}
+	}
+	return card
+}
+
+func runHuggingFace(t *toolContext, action string, args []string) error {
+	base := t.endpoint(huggingFaceAPI)
+	var headers map[string]string
+	if token := t.apiKey("HF_TOKEN"); token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + token}
+	}
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		params := url.Values{"search": {query}, "limit": {fmt.Sprint(min(*n, 100))}, "sort": {"downloads"}, "full": {"true"}}
+		var resp []hfDataset
+		raw, err := t.getJSON(base+"/api/datasets?"+params.Encode(), headers, &resp)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "huggingface_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		var list []datasetRecord
+		for _, d := range resp {
+			list = append(list, d.record())
+		}
+		printDatasets(t, path, list)
+		return nil
+
+	case "dataset":
+		if len(args) == 0 {
+			return fmt.Errorf("a dataset ID (owner/name) is required")
+		}
+		var d hfDataset
+		if _, err := t.getJSON(base+"/api/datasets/"+args[0], headers, &d); err != nil {
+			return err
+		}
+		r := d.record()
+		if r.License == "" && d.CardData.License != nil {
+			r.License = fmt.Sprint(d.CardData.License)
+		}
+		// The dataset card (README) carries the description, collection method and caveats
+		if card, err := t.getJSON(base+"/datasets/"+args[0]+"/raw/main/README.md", map[string]string{"Accept": "text/plain"}, nil); err == nil {
+			r.Description = hfCardBody(string(card))
+		}
+		return saveDataset(t, "huggingface", r)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// kaggleCredentials returns the Kaggle username and key from $KAGGLE_USERNAME and
+// the configured key variable (default $KAGGLE_KEY), falling back to ~/.kaggle/kaggle.json
+func kaggleCredentials(t *toolContext) (string, string, error) {
+	user, key := os.Getenv("KAGGLE_USERNAME"), t.apiKey("KAGGLE_KEY")
+	if user != "" && key != "" {
+		return user, key, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		var creds struct {
+			Username string `json:"username"`
+			Key      string `json:"key"`
+		}
+		if data, err := os.ReadFile(filepath.Join(home, ".kaggle", "kaggle.json")); err == nil && json.Unmarshal(data, &creds) == nil {
+			return creds.Username, creds.Key, nil
+		}
+	}
+	return "", "", fmt.Errorf("kaggle requires $KAGGLE_USERNAME and $KAGGLE_KEY or ~/.kaggle/kaggle.json")
+}
+
+func runKaggle(t *toolContext, action string, args []string) error {
+	user, key, err := kaggleCredentials(t)
+	if err != nil {
+		return err
+	}
+	req := func(u string, out any) ([]byte, error) {
+		return t.getJSON(u, map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+key))}, out)
+	}
+	base := t.endpoint(kaggleAPI)
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		var resp []struct {
+			Ref           string `json:"ref"`
+			Title         string `json:"title"`
+			Subtitle      string `json:"subtitle"`
+			TotalBytes    int64  `json:"totalBytes"`
+			LastUpdated   string `json:"lastUpdated"`
+			DownloadCount int    `json:"downloadCount"`
+			VoteCount     int    `json:"voteCount"`
+			LicenseName   string `json:"licenseName"`
+		}
+		raw, err := req(base+"/datasets/list?"+url.Values{"search": {query}, "sortBy": {"relevance"}}.Encode(), &resp)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "kaggle_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		var list []datasetRecord
+		for i, d := range resp {
+			if i == *n {
+				break
+			}
+			r := datasetRecord{
+				ID: d.Ref, Title: d.Title, License: d.LicenseName, Size: humanBytes(d.TotalBytes),
+				Popularity: fmt.Sprintf("%d downloads, %d votes", d.DownloadCount, d.VoteCount),
+			}
+			if len(d.LastUpdated) >= 10 {
+				r.Updated = d.LastUpdated[:10]
+			}
+			list = append(list, r)
+		}
+		printDatasets(t, path, list)
+		return nil
+
+	case "dataset":
+		if len(args) == 0 || !strings.Contains(args[0], "/") {
+			return fmt.Errorf("a dataset reference (owner/slug) is required")
+		}
+		var resp struct {
+			Info struct {
+				Title       string `json:"title"`
+				Subtitle    string `json:"subtitle"`
+				Description string `json:"description"`
+				Licenses    []struct {
+					Name string `json:"name"`
+				} `json:"licenses"`
+			} `json:"info"`
+			ErrorMessage string `json:"errorMessage"`
+		}
+		if _, err := req(base+"/datasets/metadata/"+args[0], &resp); err != nil {
+			return err
+		}
+		if resp.ErrorMessage != "" {
+			return fmt.Errorf("kaggle: %s", resp.ErrorMessage)
+		}
+		r := datasetRecord{
+			ID: args[0], Title: resp.Info.Title, URL: "https://www.kaggle.com/datasets/" + args[0],
+			Description: strings.TrimSpace(resp.Info.Subtitle + "\n\n" + resp.Info.Description),
+		}
+		if len(resp.Info.Licenses) > 0 {
+			r.License = resp.Info.Licenses[0].Name
+		}
+		return saveDataset(t, "kaggle", r)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
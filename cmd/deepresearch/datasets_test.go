@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// kaggleSettings points the kaggle connector at endpoint with test credentials
+func kaggleSettings(t *testing.T, endpoint string) connectorConfig {
+	t.Setenv("KAGGLE_USERNAME", "researcher")
+	t.Setenv("TEST_KAGGLE_KEY", "test-key")
+	return connectorConfig{Endpoint: endpoint, APIKeyEnv: "TEST_KAGGLE_KEY"}
+}
+
+func TestHuggingFaceSearch(t *testing.T) {
+	srv := recordedAPI(t, "datasets", map[string]string{"/api/datasets": "hf_search.json"})
+	dir, out, err := runRecordedTool(t, "huggingface", connectorConfig{Endpoint: srv.URL}, "search", "-q", "question answering")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 dataset(s).",
+		"| ID | Title | License | Size | Updated | Popularity |",
+		"| rajpurkar/squad | SQuAD | cc-by-sa-4.0 | 10K<n<100K rows | 2024-03-04 | 91873 downloads, 301 likes |",
+		"| example-org/qa-pairs-zh | example-org/qa-pairs-zh | unknown | unknown | 2023-11-20 | 412 downloads, 7 likes |",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "huggingface-question-answering") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestHuggingFaceDataset(t *testing.T) {
+	srv := recordedAPI(t, "datasets", map[string]string{
+		"/api/datasets/rajpurkar/squad":                "hf_dataset.json",
+		"/datasets/rajpurkar/squad/raw/main/README.md": "hf_card.md",
+	})
+	dir, out, err := runRecordedTool(t, "huggingface", connectorConfig{Endpoint: srv.URL}, "dataset", "rajpurkar/squad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "# SQuAD", "- License: cc-by-sa-4.0", "- Size: 10K<n<100K rows", "# Dataset Card for SQuAD")
+	if strings.Contains(out, "annotations_creators") {
+		t.Errorf("the card's metadata block was kept:\n%s", out)
+	}
+	files := assetFiles(t, dir, "datasets")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "huggingface-rajpurkar-squad") {
+		t.Fatalf("archived %v, want the dataset card", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "datasets", files[0]))
+	checkContains(t, string(doc), `url: "https://huggingface.co/datasets/rajpurkar/squad"`, `license: "cc-by-sa-4.0"`)
+}
+
+func TestKaggle(t *testing.T) {
+	srv := recordedAPI(t, "datasets", map[string]string{
+		"/datasets/list": "kaggle_search.json",
+		"/datasets/metadata/mlg-ulb/creditcardfraud": "kaggle_metadata.json",
+		"/datasets/metadata/mlg-ulb/no-such-dataset": "kaggle_metadata_error.json",
+	})
+	settings := kaggleSettings(t, srv.URL)
+	_, out, err := runRecordedTool(t, "kaggle", settings, "search", "-q", "credit card fraud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 dataset(s).",
+		"| mlg-ulb/creditcardfraud | Credit Card Fraud Detection | Database: Open Database, Contents: Database Contents | 66.0 MB | 2018-03-23 | 912345 downloads, 12456 votes |",
+		"| example/tiny-transactions | Tiny Transactions | unknown | unknown | 2021-06-01 | 12 downloads, 0 votes |",
+	)
+
+	dir, out, err := runRecordedTool(t, "kaggle", settings, "dataset", "mlg-ulb/creditcardfraud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "# Credit Card Fraud Detection", "- License: DbCL-1.0", "by European cardholders.")
+	if files := assetFiles(t, dir, "datasets"); len(files) != 1 || !strings.HasPrefix(files[0], "kaggle-mlg-ulb-creditcardfraud") {
+		t.Errorf("archived %v, want the dataset description", files)
+	}
+
+	dir, _, err = runRecordedTool(t, "kaggle", settings, "dataset", "mlg-ulb/no-such-dataset")
+	if err == nil || !strings.Contains(err.Error(), "kaggle: Dataset mlg-ulb/no-such-dataset not found") {
+		t.Errorf("missing dataset: error %v, want Kaggle's message", err)
+	}
+	if files := assetFiles(t, dir, "datasets"); len(files) != 0 {
+		t.Errorf("missing dataset: archived %v", files)
+	}
+}
+
+func TestDatasetsNoResults(t *testing.T) {
+	hf := recordedAPI(t, "datasets", map[string]string{"/api/datasets": "hf_empty.json"})
+	kaggle := recordedAPI(t, "datasets", map[string]string{"/datasets/list": "kaggle_empty.json"})
+	for name, settings := range map[string]connectorConfig{
+		"huggingface": {Endpoint: hf.URL},
+		"kaggle":      kaggleSettings(t, kaggle.URL),
+	} {
+		_, out, err := runRecordedTool(t, name, settings, "search", "-q", "no such data")
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkContains(t, out, "0 dataset(s).")
+		if strings.Count(out, "\n| ") > 1 {
+			t.Errorf("%s: expected only the table header:\n%s", name, out)
+		}
+	}
+}
+
+func TestDatasetsErrors(t *testing.T) {
+	checkHTTPErrors(t, "huggingface", connectorConfig{}, "search", "-q", "question answering")
+	checkHTTPErrors(t, "huggingface", connectorConfig{}, "dataset", "rajpurkar/squad")
+	checkHTTPErrors(t, "kaggle", kaggleSettings(t, ""), "search", "-q", "credit card fraud")
+
+	t.Setenv("KAGGLE_USERNAME", "")
+	t.Setenv("HOME", t.TempDir())
+	if _, _, err := runRecordedTool(t, "kaggle", connectorConfig{}, "search", "-q", "fraud"); err == nil || !strings.Contains(err.Error(), "kaggle.json") {
+		t.Errorf("kaggle without credentials: error %v, want one asking for them", err)
+	}
+}
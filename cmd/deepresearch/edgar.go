@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default endpoints of SEC EDGAR
+const (
+	edgarDataAPI  = "https://data.sec.gov"
+	edgarArchives = "https://www.sec.gov"
+)
+
+func init() {
+	registerConnector(&connector{
+		Name:    "edgar",
+		Summary: "SEC EDGAR filings (10-K, 10-Q, 8-K, ...) and exhibits for US-listed companies (free, no key)",
+		Actions: map[string]string{
+			"company": `-q "name or ticker" [-n 10] — look up a company's CIK`,
+			"filings": `<ticker|CIK> [-form 10-K] [-n 10] — recent filings`,
+			"get":     `<ticker|CIK> <accession number> [-exhibits] — download a filing, saved to assets/filings`,
+		},
+		Run: runEdgar,
+	})
+}
+
+// edgarCompany is an entry of SEC's ticker list
+type edgarCompany struct {
+	CIK    int    `json:"cik_str"`
+	Ticker string `json:"ticker"`
+	Title  string `json:"title"`
+}
+
+// edgarFiling is one filing from a company's submission history
+type edgarFiling struct {
+	Accession   string
+	Form        string
+	FilingDate  string
+	ReportDate  string
+	Document    string
+	Description string
+}
+
+// edgarExhibitRe matches exhibit file names in a filing index (ex31.htm, aapl-20230930xex311.htm, d123dex991.htm)
+var edgarExhibitRe = regexp.MustCompile(`(?i)ex-?\d{1,3}[^/]*\.(htm|html|txt)$`)
+
+// edgarHeaders identifies the client with the connector's contact address, as
+// SEC's fair-access policy requires
+func edgarHeaders(t *toolContext) map[string]string {
+	return map[string]string{"User-Agent": "deepresearch/" + version + " " + t.Config.Contact}
+}
+
+// edgarGet is getJSON for SEC, which answers clients over its rate limit with a bare 403
+func edgarGet(t *toolContext, u string, headers map[string]string, out any) ([]byte, error) {
+	raw, err := t.getJSON(u, headers, out)
+	var status *httpStatusError
+	if errors.As(err, &status) && status.Code == http.StatusForbidden {
+		return nil, fmt.Errorf("%w (SEC blocks clients above 10 requests per second for ten minutes)", err)
+	}
+	return raw, err
+}
+
+// edgarCompanies loads SEC's ticker-to-CIK list
+func edgarCompanies(t *toolContext) ([]edgarCompany, error) {
+	var index map[string]edgarCompany
+	if _, err := edgarGet(t, t.endpoint(edgarArchives)+"/files/company_tickers.json", edgarHeaders(t), &index); err != nil {
+		return nil, err
+	}
+	list := make([]edgarCompany, 0, len(index))
+	for _, c := range index {
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// resolveCIK returns the CIK for a ticker or numeric CIK
+func resolveCIK(t *toolContext, id string) (int, error) {
+	if cik, err := strconv.Atoi(strings.TrimLeft(id, "0")); err == nil {
+		return cik, nil
+	}
+	companies, err := edgarCompanies(t)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range companies {
+		if strings.EqualFold(c.Ticker, id) {
+			return c.CIK, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown ticker %q (use `company -q` to find the CIK)", id)
+}
+
+// edgarSubmissions fetches a company's name and recent filings
+func edgarSubmissions(t *toolContext, cik int) (string, []edgarFiling, []byte, error) {
+	var resp struct {
+		Name    string `json:"name"`
+		Filings struct {
+			Recent struct {
+				Accession   []string `json:"accessionNumber"`
+				FilingDate  []string `json:"filingDate"`
+				ReportDate  []string `json:"reportDate"`
+				Form        []string `json:"form"`
+				Document    []string `json:"primaryDocument"`
+				Description []string `json:"primaryDocDescription"`
+			} `json:"recent"`
+		} `json:"filings"`
+	}
+	raw, err := edgarGet(t, fmt.Sprintf("%s/submissions/CIK%010d.json", t.endpoint(edgarDataAPI), cik), edgarHeaders(t), &resp)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	r := resp.Filings.Recent
+	at := func(list []string, i int) string {
+		if i < len(list) {
+			return list[i]
+		}
+		return ""
+	}
+	filings := make([]edgarFiling, len(r.Accession))
+	for i, acc := range r.Accession {
+		filings[i] = edgarFiling{
+			Accession: acc, Form: at(r.Form, i), FilingDate: at(r.FilingDate, i), ReportDate: at(r.ReportDate, i),
+			Document: at(r.Document, i), Description: at(r.Description, i),
+		}
+	}
+	return resp.Name, filings, raw, nil
+}
+
+func runEdgar(t *toolContext, action string, args []string) error {
+	// SEC refuses requests without a contact address with a bare 403
+	if strings.TrimSpace(t.Config.Contact) == "" {
+		return fmt.Errorf("SEC requires a contact address: set connectors.edgar.contact in config.yaml (e.g. research@example.com)")
+	}
+	switch action {
+	case "company":
+		fs, q, n := searchFlagSet(action, 10)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		companies, err := edgarCompanies(t)
+		if err != nil {
+			return err
+		}
+		t.printf("| CIK | Ticker | Company |\n|---|---|---|\n")
+		found := 0
+		for _, c := range companies {
+			if found == *n {
+				break
+			}
+			if strings.EqualFold(c.Ticker, query) || strings.Contains(strings.ToLower(c.Title), strings.ToLower(query)) {
+				t.printf("| %d | %s | %s |\n", c.CIK, c.Ticker, mdCell(c.Title))
+				found++
+			}
+		}
+		if found == 0 {
+			t.printf("\nNo company matches %q.\n", query)
+		}
+		return nil
+
+	case "filings":
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		form := fs.String("form", "", "Only this form type (e.g. 10-K, 10-Q, 8-K)")
+		n := fs.Int("n", 10, "Maximum number of results")
+		if err := fs.Parse(reorderArgs(args)); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return fmt.Errorf("a ticker or CIK is required")
+		}
+		cik, err := resolveCIK(t, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		name, filings, raw, err := edgarSubmissions(t, cik)
+		if err != nil {
+			return err
+		}
+		rawPath, err := t.saveAsset("data", fmt.Sprintf("edgar_%d_submissions", cik), "json", raw)
+		if err != nil {
+			return err
+		}
+		t.printf("## %s (CIK %d)\n\nRaw submissions: %s\n\n| Form | Filed | Period | Accession | Document |\n|---|---|---|---|---|\n", name, cik, rawPath)
+		shown := 0
+		for _, f := range filings {
+			if shown == *n {
+				break
+			}
+			if *form != "" && !strings.EqualFold(f.Form, *form) {
+				continue
+			}
+			t.printf("| %s | %s | %s | %s | %s |\n", f.Form, f.FilingDate, f.ReportDate, f.Accession, mdCell(f.Description))
+			shown++
+		}
+		t.printf("\nUse `get %d <accession>` to archive a filing you cite.\n", cik)
+		return nil
+
+	case "get":
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		exhibits := fs.Bool("exhibits", false, "Also download the filing's exhibits")
+		if err := fs.Parse(reorderArgs(args)); err != nil {
+			return err
+		}
+		if fs.NArg() < 2 {
+			return fmt.Errorf("a ticker or CIK and an accession number are required")
+		}
+		cik, err := resolveCIK(t, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		name, filings, _, err := edgarSubmissions(t, cik)
+		if err != nil {
+			return err
+		}
+		var filing *edgarFiling
+		for i := range filings {
+			if filings[i].Accession == fs.Arg(1) {
+				filing = &filings[i]
+				break
+			}
+		}
+		if filing == nil {
+			return fmt.Errorf("accession %s not found among %s's recent filings", fs.Arg(1), name)
+		}
+		return edgarDownload(t, cik, name, filing, *exhibits)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// edgarDownload archives a filing's primary document (and optionally its exhibits)
+// with a Markdown summary carrying the filing metadata
+func edgarDownload(t *toolContext, cik int, company string, f *edgarFiling, exhibits bool) error {
+	base := fmt.Sprintf("%s/Archives/edgar/data/%d/%s", t.endpoint(edgarArchives), cik, strings.ReplaceAll(f.Accession, "-", ""))
+	prefix := fmt.Sprintf("%s_%s_%s", company, f.Form, f.FilingDate)
+
+	documents := []string{f.Document}
+	if exhibits {
+		var index struct {
+			Directory struct {
+				Items []struct {
+					Name string `json:"name"`
+				} `json:"item"`
+			} `json:"directory"`
+		}
+		if _, err := edgarGet(t, base+"/index.json", edgarHeaders(t), &index); err != nil {
+			return err
+		}
+		for _, item := range index.Directory.Items {
+			if item.Name != f.Document && edgarExhibitRe.MatchString(item.Name) {
+				documents = append(documents, item.Name)
+			}
+		}
+	}
+
+	var saved []string
+	for _, doc := range documents {
+		headers := edgarHeaders(t)
+		headers["Accept"] = "*/*"
+		data, err := edgarGet(t, base+"/"+url.PathEscape(doc), headers, nil)
+		if err != nil {
+			return err
+		}
+		ext := strings.TrimPrefix(path.Ext(doc), ".")
+		rel, err := t.saveAsset("filings", prefix+"_"+strings.TrimSuffix(doc, path.Ext(doc)), ext, data)
+		if err != nil {
+			return err
+		}
+		saved = append(saved, rel)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "# %s — %s filed %s\n\n", company, f.Form, f.FilingDate)
+	if f.ReportDate != "" {
+		fmt.Fprintf(&body, "Period of report: %s\n\n", f.ReportDate)
+	}
+	body.WriteString("Archived documents:\n\n")
+	for _, s := range saved {
+		fmt.Fprintf(&body, "- %s\n", s)
+	}
+	summary, err := t.saveDocument("filings", prefix, [][2]string{
+		{"title", fmt.Sprintf("%s %s (%s)", company, f.Form, f.FilingDate)},
+		{"url", base + "/" + f.Document},
+		{"form", f.Form},
+		{"cik", strconv.Itoa(cik)},
+		{"accession", f.Accession},
+		{"published", f.FilingDate},
+	}, body.String())
+	if err != nil {
+		return err
+	}
+	t.printf("%s\nSummary: %s\nRead the archived documents directly; cite the filing by company, form and filing date.\n", body.String(), summary)
+	return nil
+}
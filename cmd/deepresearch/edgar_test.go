@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const edgarFilingPath = "/Archives/edgar/data/320193/000032019324000123"
+
+// edgarAPI serves the recorded ticker list, Apple's submissions and its 10-K
+func edgarAPI(t *testing.T) *httptest.Server {
+	return recordedAPI(t, "edgar", map[string]string{
+		"/files/company_tickers.json":                "company_tickers.json",
+		"/submissions/CIK0000320193.json":            "submissions.json",
+		edgarFilingPath + "/index.json":              "index.json",
+		edgarFilingPath + "/aapl-20240928.htm":       "aapl-20240928.htm",
+		edgarFilingPath + "/aapl-20240928xex311.htm": "aapl-20240928xex311.htm",
+	})
+}
+
+func TestEdgarCompany(t *testing.T) {
+	srv := edgarAPI(t)
+	settings := connectorConfig{Endpoint: srv.URL, Contact: "research@example.org"}
+	_, out, err := runRecordedTool(t, "edgar", settings, "company", "-q", "apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "| CIK | Ticker | Company |", "| 320193 | AAPL | Apple Inc. |", "| 1418091 | APLE | Apple Hospitality REIT, Inc. |")
+
+	_, out, err = runRecordedTool(t, "edgar", settings, "company", "-q", "no such company")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, `No company matches "no such company".`)
+}
+
+func TestEdgarFilings(t *testing.T) {
+	srv := edgarAPI(t)
+	dir, out, err := runRecordedTool(t, "edgar", connectorConfig{Endpoint: srv.URL, Contact: "research@example.org"}, "filings", "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"## Apple Inc. (CIK 320193)",
+		"| Form | Filed | Period | Accession | Document |",
+		"| 10-K | 2024-11-01 | 2024-09-28 | 0000320193-24-000123 | 10-K |",
+		`| 8-K | 2024-05-03 | 2024-05-02 | 0000320193-24-000069 | 8-K \| earnings release |`,
+		"`get 320193 <accession>`",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "edgar-320193-submissions") {
+		t.Errorf("archived %v, want the submissions", files)
+	}
+
+	_, out, err = runRecordedTool(t, "edgar", connectorConfig{Endpoint: srv.URL, Contact: "research@example.org"}, "filings", "320193", "-form", "20-F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+}
+
+func TestEdgarGet(t *testing.T) {
+	srv := edgarAPI(t)
+	dir, out, err := runRecordedTool(t, "edgar", connectorConfig{Endpoint: srv.URL, Contact: "research@example.org"},
+		"get", "AAPL", "0000320193-24-000123", "-exhibits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "# Apple Inc. — 10-K filed 2024-11-01", "Period of report: 2024-09-28", "assets/filings/")
+	files := assetFiles(t, dir, "filings")
+	if len(files) != 3 {
+		t.Fatalf("archived %v, want the 10-K, its exhibit and the summary", files)
+	}
+	var summary string
+	for _, f := range files {
+		if strings.HasSuffix(f, ".md") {
+			summary = f
+		}
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "filings", summary))
+	checkContains(t, string(doc), `form: "10-K"`, `accession: "0000320193-24-000123"`, edgarFilingPath+`/aapl-20240928.htm"`)
+
+	if _, _, err := runRecordedTool(t, "edgar", connectorConfig{Endpoint: srv.URL, Contact: "research@example.org"},
+		"get", "AAPL", "0000320193-99-000001"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown accession: error %v, want not found", err)
+	}
+}
+
+func TestEdgarErrors(t *testing.T) {
+	settings := connectorConfig{Contact: "research@example.org"}
+	checkHTTPErrors(t, "edgar", settings, "filings", "320193")
+	srv := recordedFault(t, "edgar", "throttled.html", http.StatusForbidden)
+	settings.Endpoint = srv.URL
+	if _, _, err := runRecordedTool(t, "edgar", settings, "company", "-q", "apple"); err == nil || !strings.Contains(err.Error(), "10 requests per second") {
+		t.Errorf("throttled: error %v, want one explaining SEC's rate limit", err)
+	}
+	if _, _, err := runRecordedTool(t, "edgar", connectorConfig{}, "company", "-q", "apple"); err == nil || !strings.Contains(err.Error(), "contact") {
+		t.Errorf("without a contact: error %v, want one asking for it", err)
+	}
+}
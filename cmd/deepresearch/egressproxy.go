@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// egressProxy is the HTTP proxy of a sandbox with network: allowlist. The
+// agent container has no route out but to it, and it forwards requests and
+// CONNECT tunnels to the allowed hosts only.
+type egressProxy struct {
+	allow     []string
+	transport *http.Transport
+}
+
+// allowed reports whether host (without port) matches an entry of the
+// allowlist: the host itself, or with *.example.com any subdomain
+func (p *egressProxy) allowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, a := range p.allow {
+		a = strings.ToLower(a)
+		if suffix, ok := strings.CutPrefix(a, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if r.Method == http.MethodConnect {
+		host, _, _ = net.SplitHostPort(r.Host)
+	}
+	if !p.allowed(host) {
+		fmt.Fprintf(os.Stderr, "denied %s %s\n", r.Method, r.Host)
+		http.Error(w, "deepresearch sandbox: "+host+" is not in the allowlist", http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if r.URL.Scheme != "http" {
+		http.Error(w, "deepresearch sandbox: not a proxy request", http.StatusBadRequest)
+		return
+	}
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel connects the client to the host of a CONNECT request and relays the
+// bytes both ways, e.g. a TLS session
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "deepresearch sandbox: cannot tunnel", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	fmt.Fprint(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(upstream, buf); done <- struct{}{} }()
+	go func() { io.Copy(client, upstream); done <- struct{}{} }()
+	<-done
+	client.Close()
+	upstream.Close()
+}
+
+// runSandboxProxy is the egress proxy a sandbox starts in its own container
+// (deepresearch sandbox-proxy -allow HOSTS)
+func runSandboxProxy(args []string) {
+	fs := flag.NewFlagSet("sandbox-proxy", flag.ExitOnError)
+	listen := fs.String("listen", ":"+sandboxProxyPort, "Address to listen on")
+	allow := fs.String("allow", "", "Hosts to forward to, comma-separated; *.example.com allows the subdomains of example.com")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch sandbox-proxy -allow HOSTS [-listen ADDR]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	p := &egressProxy{transport: &http.Transport{Proxy: nil, ResponseHeaderTimeout: 5 * time.Minute}}
+	for _, h := range strings.Split(*allow, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			p.allow = append(p.allow, h)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "sandbox-proxy: listening on %s, allowing %s\n", *listen, strings.Join(p.allow, ", "))
+	if err := http.ListenAndServe(*listen, p); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox-proxy: %v\n", err)
+		os.Exit(1)
+	}
+}
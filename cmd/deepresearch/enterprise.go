@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// graphAPI is the default Microsoft Graph base URL used for SharePoint
+const graphAPI = "https://graph.microsoft.com/v1.0"
+
+func init() {
+	registerConnector(&connector{
+		Name:    "confluence",
+		Summary: "Internal Confluence pages, read-only (needs connectors.confluence.endpoint and a token)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 10] [-space KEY] — pages matching the text`,
+			"page":   `<page id> — page content, saved to assets/internal`,
+		},
+		Run: runConfluence,
+	})
+	registerConnector(&connector{
+		Name:    "sharepoint",
+		Summary: "Internal SharePoint documents via Microsoft Graph, read-only (needs a Graph access token)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 10] [-site URL] — documents and list items matching the text`,
+			"get":    `<driveId/itemId> — download a document (Office files as PDF), saved to assets/internal`,
+		},
+		Run: runSharePoint,
+	})
+}
+
+// internalNotice is appended to results from internal systems
+const internalNotice = "\nINTERNAL source: register it with Type `internal` and cite it like any other source; do not quote it outside the report.\n"
+
+func runConfluence(t *toolContext, action string, args []string) error {
+	if t.Config.Endpoint == "" {
+		return fmt.Errorf("confluence requires connectors.confluence.endpoint (e.g. https://acme.atlassian.net/wiki)")
+	}
+	base := t.endpoint("")
+	token := t.apiKey("CONFLUENCE_TOKEN")
+	if token == "" {
+		return fmt.Errorf("confluence requires a token (connectors.confluence.api_key_env, default $CONFLUENCE_TOKEN)")
+	}
+	headers := map[string]string{"Authorization": tokenAuth(token)}
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		space := fs.String("space", "", "Restrict to a space key")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		cql := fmt.Sprintf("type=page AND text ~ %q", query)
+		if *space != "" {
+			cql += fmt.Sprintf(" AND space = %q", *space)
+		} else if len(t.Config.Scopes) > 0 {
+			cql += " AND space in (" + quoteAll(t.Config.Scopes) + ")"
+		}
+		var resp struct {
+			Results []struct {
+				Content struct {
+					ID    string `json:"id"`
+					Title string `json:"title"`
+				} `json:"content"`
+				Excerpt      string `json:"excerpt"`
+				URL          string `json:"url"`
+				LastModified string `json:"lastModified"`
+				Container    struct {
+					Title string `json:"title"`
+				} `json:"resultGlobalContainer"`
+			} `json:"results"`
+		}
+		params := url.Values{"cql": {cql}, "limit": {fmt.Sprint(min(*n, 100))}}
+		if _, err := t.getJSON(base+"/rest/api/search?"+params.Encode(), headers, &resp); err != nil {
+			return err
+		}
+		t.printf("| ID | Space | Title | Modified | Excerpt |\n|---|---|---|---|---|\n")
+		for _, r := range resp.Results {
+			modified := r.LastModified
+			if len(modified) > 10 {
+				modified = modified[:10]
+			}
+			// Matches come wrapped in @@@hl@@@ ... @@@endhl@@@ markers
+			excerpt := strings.NewReplacer("@@@hl@@@", "", "@@@endhl@@@", "").Replace(r.Excerpt)
+			t.printf("| %s | %s | %s | %s | %s |\n", r.Content.ID, mdCell(r.Container.Title), mdCell(r.Content.Title), modified,
+				mdCell(html.UnescapeString(htmlTagRe.ReplaceAllString(excerpt, ""))))
+		}
+		t.printf("\nUse `page <ID>` to archive a page you cite.\n")
+		return nil
+
+	case "page":
+		if len(args) == 0 {
+			return fmt.Errorf("a page ID is required")
+		}
+		var page struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+			Space struct {
+				Key  string `json:"key"`
+				Name string `json:"name"`
+			} `json:"space"`
+			Version struct {
+				When   string `json:"when"`
+				Number int    `json:"number"`
+				By     struct {
+					Name string `json:"displayName"`
+				} `json:"by"`
+			} `json:"version"`
+			Body struct {
+				Storage struct {
+					Value string `json:"value"`
+				} `json:"storage"`
+			} `json:"body"`
+			Links struct {
+				Base  string `json:"base"`
+				WebUI string `json:"webui"`
+			} `json:"_links"`
+		}
+		u := base + "/rest/api/content/" + url.PathEscape(args[0]) + "?expand=body.storage,version,space"
+		if _, err := t.getJSON(u, headers, &page); err != nil {
+			return err
+		}
+		if len(t.Config.Scopes) > 0 && !containsFold(t.Config.Scopes, page.Space.Key) {
+			return fmt.Errorf("space %s is not in connectors.confluence.scopes", page.Space.Key)
+		}
+		text := strings.NewReplacer("</p>", "\n\n", "<br/>", "\n", "</li>", "\n", "<li>", "- ",
+			"</h1>", "\n\n", "</h2>", "\n\n", "</h3>", "\n\n", "</tr>", "\n").Replace(page.Body.Storage.Value)
+		text = strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(text, " ")))
+		modified := page.Version.When
+		if len(modified) > 10 {
+			modified = modified[:10]
+		}
+		body := fmt.Sprintf("# %s\n\n*Confluence space %s (%s), version %d by %s, %s.*\n\n%s\n",
+			page.Title, page.Space.Name, page.Space.Key, page.Version.Number, page.Version.By.Name, modified, text)
+		pathRel, err := t.saveDocument("internal", "confluence_"+page.Space.Key+"_"+page.Title, [][2]string{
+			{"title", page.Title},
+			{"url", page.Links.Base + page.Links.WebUI},
+			{"space", page.Space.Key},
+			{"internal", "true"},
+			{"published", modified},
+		}, body)
+		if err != nil {
+			return err
+		}
+		t.printf("%s\nArchived: %s\n%s", body, pathRel, internalNotice)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+func runSharePoint(t *toolContext, action string, args []string) error {
+	token := t.apiKey("SHAREPOINT_TOKEN")
+	if token == "" {
+		return fmt.Errorf("sharepoint requires a Microsoft Graph access token (connectors.sharepoint.api_key_env, default $SHAREPOINT_TOKEN)")
+	}
+	base := t.endpoint(graphAPI)
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		site := fs.String("site", "", "Restrict to a site or library URL")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		// KQL path: restrictions keep searches inside the configured sites/libraries
+		scopes := t.Config.Scopes
+		if *site != "" {
+			scopes = []string{*site}
+		}
+		if len(scopes) > 0 {
+			var paths []string
+			for _, s := range scopes {
+				paths = append(paths, fmt.Sprintf("path:%q", s))
+			}
+			query += " (" + strings.Join(paths, " OR ") + ")"
+		}
+		body, _ := json.Marshal(map[string]any{"requests": []map[string]any{{
+			"entityTypes": []string{"driveItem", "listItem"},
+			"query":       map[string]string{"queryString": query},
+			"size":        min(*n, 100),
+		}}})
+		var resp struct {
+			Value []struct {
+				HitsContainers []struct {
+					Hits []struct {
+						Summary  string `json:"summary"`
+						Resource struct {
+							ID           string `json:"id"`
+							Name         string `json:"name"`
+							WebURL       string `json:"webUrl"`
+							LastModified string `json:"lastModifiedDateTime"`
+							Parent       struct {
+								DriveID string `json:"driveId"`
+							} `json:"parentReference"`
+						} `json:"resource"`
+					} `json:"hits"`
+				} `json:"hitsContainers"`
+			} `json:"value"`
+		}
+		if err := postJSON(t.client, base+"/search/query", headers, body, &resp); err != nil {
+			return err
+		}
+		t.printf("| Reference | Name | Modified | URL | Summary |\n|---|---|---|---|---|\n")
+		for _, v := range resp.Value {
+			for _, c := range v.HitsContainers {
+				for _, h := range c.Hits {
+					r := h.Resource
+					modified := r.LastModified
+					if len(modified) > 10 {
+						modified = modified[:10]
+					}
+					t.printf("| %s/%s | %s | %s | %s | %s |\n", r.Parent.DriveID, r.ID, mdCell(r.Name), modified, r.WebURL,
+						mdCell(html.UnescapeString(htmlTagRe.ReplaceAllString(h.Summary, ""))))
+				}
+			}
+		}
+		t.printf("\nUse `get <driveId/itemId>` to archive a document you cite.\n")
+		return nil
+
+	case "get":
+		driveID, itemID, ok := strings.Cut(strings.Join(args, ""), "/")
+		if !ok {
+			return fmt.Errorf("a document reference (driveId/itemId) is required")
+		}
+		itemURL := base + "/drives/" + url.PathEscape(driveID) + "/items/" + url.PathEscape(itemID)
+		var item struct {
+			Name         string `json:"name"`
+			WebURL       string `json:"webUrl"`
+			LastModified string `json:"lastModifiedDateTime"`
+		}
+		if _, err := t.getJSON(itemURL, headers, &item); err != nil {
+			return err
+		}
+		if len(t.Config.Scopes) > 0 && !hasPrefixFold(item.WebURL, t.Config.Scopes) {
+			return fmt.Errorf("%s is outside connectors.sharepoint.scopes", item.WebURL)
+		}
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(item.Name), "."))
+		contentURL := itemURL + "/content"
+		switch ext {
+		case "doc", "docx", "ppt", "pptx", "xls", "xlsx", "odt", "rtf":
+			contentURL += "?format=pdf" // Graph converts Office formats so the PDF pipeline can read them
+			ext = "pdf"
+		}
+		dl := map[string]string{"Authorization": headers["Authorization"], "Accept": "*/*"}
+		data, err := t.getJSON(contentURL, dl, nil)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(item.Name, path.Ext(item.Name))
+		rel, err := t.saveAsset("internal", "sharepoint_"+name, ext, data)
+		if err != nil {
+			return err
+		}
+		t.printf("Archived %s (%s, modified %s): %s\n%s", item.Name, item.WebURL, item.LastModified, rel, internalNotice)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// quoteAll quotes values for a CQL "in" list
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefixFold(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildInternalSourcesNote tells the planner which internal spaces and libraries
+// are configured, so the plan can include them, or "" if none are
+func buildInternalSourcesNote() string {
+	var lines []string
+	for _, name := range []string{"confluence", "sharepoint"} {
+		cfg, ok := config.Connectors[name]
+		if !ok || cfg.Disabled || (name == "confluence" && cfg.Endpoint == "") {
+			continue
+		}
+		scope := "all readable content"
+		if len(cfg.Scopes) > 0 {
+			scope = strings.Join(cfg.Scopes, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", name, scope))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return `
+INTERNAL_SOURCES: The user's internal knowledge bases are connected (read-only). Where the request
+concerns the user's own organization, products or decisions, add executor tasks that search them
+alongside external research, and mark those tasks "Sources: internal (<connector>)":
+` + strings.Join(lines, "\n") + "\n"
+}
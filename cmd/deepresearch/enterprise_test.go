@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const sharePointItem = "/drives/b!Xk1sCw0jbUuWIhZYNi7lNQ/items/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K"
+
+// enterpriseSettings points an enterprise connector at endpoint with a test token
+func enterpriseSettings(t *testing.T, endpoint string) connectorConfig {
+	t.Setenv("TEST_INTERNAL_TOKEN", "test-token")
+	return connectorConfig{Endpoint: endpoint, APIKeyEnv: "TEST_INTERNAL_TOKEN"}
+}
+
+func TestConfluenceSearch(t *testing.T) {
+	srv := recordedAPI(t, "enterprise", map[string]string{"/rest/api/search": "confluence_search.json"})
+	_, out, err := runRecordedTool(t, "confluence", enterpriseSettings(t, srv.URL), "search", "-q", "checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"| ID | Space | Title | Modified | Excerpt |",
+		`| 2456789 | Engineering | Incident review: checkout outage | 2025-02-14 | On 12 Feb the checkout service returned 5xx for 41 minutes & orders \| payments were lost |`,
+		"| 2400001 | Operations | Checkout runbook | 2024-11-03 | Steps to fail over the checkout database |",
+	)
+	if strings.Contains(out, "@@@") {
+		t.Errorf("highlight markers left in the excerpt:\n%s", out)
+	}
+}
+
+func TestConfluencePage(t *testing.T) {
+	srv := recordedAPI(t, "enterprise", map[string]string{"/rest/api/content/2456789": "confluence_page.json"})
+	settings := enterpriseSettings(t, srv.URL)
+	dir, out, err := runRecordedTool(t, "confluence", settings, "page", "2456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# Incident review: checkout outage",
+		"*Confluence space Engineering (ENG), version 4 by Priya Raman, 2025-02-14.*",
+		"The checkout service returned 5xx for 41",
+		"- Add a circuit breaker",
+		"INTERNAL source",
+	)
+	files := assetFiles(t, dir, "internal")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "confluence-eng-incident-review") {
+		t.Fatalf("archived %v, want the page", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "internal", files[0]))
+	checkContains(t, string(doc), `url: "https://acme.atlassian.net/wiki/spaces/ENG/pages/2456789/Incident+review+checkout+outage"`, `internal: "true"`)
+
+	settings.Scopes = []string{"OPS"}
+	dir, _, err = runRecordedTool(t, "confluence", settings, "page", "2456789")
+	if err == nil || !strings.Contains(err.Error(), "space ENG is not in connectors.confluence.scopes") {
+		t.Errorf("page outside the scopes: error %v", err)
+	}
+	if files := assetFiles(t, dir, "internal"); len(files) != 0 {
+		t.Errorf("page outside the scopes: archived %v", files)
+	}
+}
+
+func TestSharePointSearch(t *testing.T) {
+	srv := recordedAPI(t, "enterprise", map[string]string{"/search/query": "sharepoint_search.json"})
+	_, out, err := runRecordedTool(t, "sharepoint", enterpriseSettings(t, srv.URL), "search", "-q", "pricing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"| Reference | Name | Modified | URL | Summary |",
+		"| b!Xk1sCw0jbUuWIhZYNi7lNQ/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K | Pricing strategy FY26.docx | 2025-01-30 | https://acme.sharepoint.com/sites/Strategy/Shared Documents/Pricing strategy FY26.docx | Proposed pricing tiers for FY26 & discount rules |",
+	)
+}
+
+func TestSharePointGet(t *testing.T) {
+	srv := recordedAPI(t, "enterprise", map[string]string{
+		sharePointItem:              "sharepoint_item.json",
+		sharePointItem + "/content": "sharepoint_item.pdf",
+	})
+	settings := enterpriseSettings(t, srv.URL)
+	dir, out, err := runRecordedTool(t, "sharepoint", settings, "get", "b!Xk1sCw0jbUuWIhZYNi7lNQ/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "Archived Pricing strategy FY26.docx (", "modified 2025-01-30T16:45:12Z", "INTERNAL source")
+	files := assetFiles(t, dir, "internal")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "sharepoint-pricing-strategy-fy26") || !strings.HasSuffix(files[0], ".pdf") {
+		t.Fatalf("archived %v, want the document as PDF", files)
+	}
+
+	settings.Scopes = []string{"https://acme.sharepoint.com/sites/HR"}
+	dir, _, err = runRecordedTool(t, "sharepoint", settings, "get", "b!Xk1sCw0jbUuWIhZYNi7lNQ/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K")
+	if err == nil || !strings.Contains(err.Error(), "outside connectors.sharepoint.scopes") {
+		t.Errorf("document outside the scopes: error %v", err)
+	}
+	if files := assetFiles(t, dir, "internal"); len(files) != 0 {
+		t.Errorf("document outside the scopes: archived %v", files)
+	}
+}
+
+func TestEnterpriseNoResults(t *testing.T) {
+	srv := recordedAPI(t, "enterprise", map[string]string{
+		"/rest/api/search": "confluence_empty.json",
+		"/search/query":    "sharepoint_empty.json",
+	})
+	for _, name := range []string{"confluence", "sharepoint"} {
+		_, out, err := runRecordedTool(t, name, enterpriseSettings(t, srv.URL), "search", "-q", "no such page")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(out, "\n| ") > 1 {
+			t.Errorf("%s: expected only the table header:\n%s", name, out)
+		}
+	}
+}
+
+func TestEnterpriseErrors(t *testing.T) {
+	settings := enterpriseSettings(t, "")
+	checkHTTPErrors(t, "confluence", settings, "search", "-q", "checkout")
+	checkHTTPErrors(t, "confluence", settings, "page", "2456789")
+	checkHTTPErrors(t, "sharepoint", settings, "search", "-q", "pricing")
+	checkHTTPErrors(t, "sharepoint", settings, "get", "b!Xk1sCw0jbUuWIhZYNi7lNQ/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K")
+
+	if _, _, err := runRecordedTool(t, "confluence", settings, "search", "-q", "checkout"); err == nil || !strings.Contains(err.Error(), "connectors.confluence.endpoint") {
+		t.Errorf("confluence without an endpoint: error %v", err)
+	}
+	t.Setenv("TEST_INTERNAL_TOKEN", "")
+	settings.Endpoint = "https://acme.atlassian.net/wiki"
+	for _, name := range []string{"confluence", "sharepoint"} {
+		if _, _, err := runRecordedTool(t, name, settings, "search", "-q", "checkout"); err == nil || !strings.Contains(err.Error(), "token") {
+			t.Errorf("%s without a token: error %v", name, err)
+		}
+	}
+}
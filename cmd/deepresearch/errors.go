@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stable error codes emitted in events.jsonl and result.json so automation can
+// react differently to different failure classes
+const (
+	codeAgentNotFound    = "AGENT_NOT_FOUND"
+	codeAgentTimeout     = "AGENT_TIMEOUT"
+	codeRateLimited      = "RATE_LIMITED"
+	codeArtifactMissing  = "ARTIFACT_MISSING"
+	codeBudgetExceeded   = "BUDGET_EXCEEDED"
+	codeValidationFailed = "VALIDATION_FAILED"
+	codeAgentFailed      = "AGENT_FAILED"
+	codeInterrupted      = "INTERRUPTED" // stopped by SIGINT or SIGTERM
+	codeInternal         = "INTERNAL"
+)
+
+// Exit statuses of a failed run, so scripts can tell failures apart without
+// reading failure.json. A cause the code names comes first, else the phase
+// that failed; an interrupted run exits with 128+signal.
+const (
+	exitFailed            = 1  // any other failure
+	exitUsage             = 2  // VALIDATION_FAILED: invalid flags, config or input
+	exitAgentNotFound     = 3  // AGENT_NOT_FOUND
+	exitAgentTimeout      = 4  // AGENT_TIMEOUT
+	exitBudgetExceeded    = 5  // BUDGET_EXCEEDED
+	exitRateLimited       = 6  // RATE_LIMITED
+	exitPlannerFailed     = 10 // the planner failed
+	exitSupervisorFailed  = 11 // the research step failed: the supervisor or its executors
+	exitReflectorFailed   = 12 // the reflector failed
+	exitSynthesizerFailed = 13 // the synthesizer failed
+	exitVerifierFailed    = 14 // the citation check failed
+	exitClarifierFailed   = 15 // the clarifier failed
+	exitTranslatorFailed  = 16 // the report's translation failed
+)
+
+// codeExitStatus are the exit statuses of the error codes that name a cause
+var codeExitStatus = map[string]int{
+	codeValidationFailed: exitUsage,
+	codeAgentNotFound:    exitAgentNotFound,
+	codeAgentTimeout:     exitAgentTimeout,
+	codeBudgetExceeded:   exitBudgetExceeded,
+	codeRateLimited:      exitRateLimited,
+}
+
+// phaseExitStatus are the exit statuses of failures in a phase
+var phaseExitStatus = map[string]int{
+	"CLARIFIER":           exitClarifierFailed,
+	"PLANNER":             exitPlannerFailed,
+	"RESEARCH-SUPERVISOR": exitSupervisorFailed,
+	"REFLECTOR":           exitReflectorFailed,
+	"SYNTHESIZER":         exitSynthesizerFailed,
+	"VERIFIER":            exitVerifierFailed,
+	"TRANSLATOR":          exitTranslatorFailed,
+}
+
+// exitStatus is the exit status of a run that failed with code in phase (nil
+// outside one)
+func exitStatus(code string, phase *phaseRecord) int {
+	if status, ok := codeExitStatus[code]; ok {
+		return status
+	}
+	if phase != nil {
+		if status, ok := phaseExitStatus[phase.Name]; ok {
+			return status
+		}
+	}
+	return exitFailed
+}
+
+// resultFileName is the machine-readable outcome written at the end of every run
+const resultFileName = "result.json"
+
+// codedError attaches a stable error code to an error
+type codedError struct {
+	Code string
+	Err  error
+}
+
+func (e *codedError) Error() string { return e.Err.Error() }
+func (e *codedError) Unwrap() error { return e.Err }
+
+// withCode wraps err with an error code
+func withCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{Code: code, Err: err}
+}
+
+// errorCode returns the code attached to err, or classifies it from its message
+func errorCode(err error) string {
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	return classifyError(err.Error(), agentOutputTail.Lines())
+}
+
+// classifyError derives an error code from an error message and recent agent output
+func classifyError(msg string, tail []string) string {
+	lower := strings.ToLower(msg)
+	output := strings.ToLower(strings.Join(tail, "\n"))
+	switch {
+	case strings.Contains(lower, "executable file not found"),
+		strings.Contains(lower, "not installed or not in path"),
+		strings.Contains(lower, "no supported agent cli found"):
+		return codeAgentNotFound
+	case strings.Contains(lower, "deadline exceeded"), strings.Contains(lower, "timed out"), strings.Contains(lower, "timeout"):
+		return codeAgentTimeout
+	case strings.Contains(lower, "budget"):
+		return codeBudgetExceeded
+	case strings.Contains(lower, "did not create"):
+		return codeArtifactMissing
+	case strings.Contains(lower+output, "rate limit"), strings.Contains(lower+output, "too many requests"), strings.Contains(lower+output, " 429"):
+		return codeRateLimited
+	case strings.Contains(lower, "agent exited"), strings.Contains(lower, "failed to start agent"):
+		return codeAgentFailed
+	}
+	return codeInternal
+}
+
+// ========== EVENT STREAM ==========
+
+var (
+	eventsMu   sync.Mutex
+	eventsFile *os.File
+)
+
+// eventSchemaVersion is bumped when a runEvent field changes meaning or goes away
+const eventSchemaVersion = 1
+
+// runEvent is one line of logs/events.jsonl (and of orchestrator.log with --log-format json)
+type runEvent struct {
+	Schema     int               `json:"schema"`
+	Time       string            `json:"ts"`
+	Level      string            `json:"level"`
+	Type       string            `json:"type"`
+	Phase      string            `json:"phase,omitempty"`
+	Iteration  int               `json:"iteration,omitempty"`
+	Agent      string            `json:"agent,omitempty"`
+	DurationMs int64             `json:"duration_ms,omitempty"` // phase duration so far on AGENT_DONE and AGENT_FAILED, executor duration on EXECUTOR_*
+	ExitCode   *int              `json:"exit_code,omitempty"`   // agent exit status, on AGENT_FAILED
+	Message    string            `json:"message"`
+	Code       string            `json:"code,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+// initEventsFile opens logs/events.jsonl for appending
+func initEventsFile(baseDir string) {
+	f, err := os.OpenFile(filepath.Join(baseDir, "logs", "events.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	eventsFile = f
+}
+
+// closeEventsFile closes the event stream
+func closeEventsFile() {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if eventsFile != nil {
+		eventsFile.Close()
+		eventsFile = nil
+	}
+}
+
+// newEvent builds an event. The "code", "phase", "agent", "duration_ms" and
+// "exit_code" fields, if present, are promoted to the top level; phase and
+// agent default to the running phase and the run's agent.
+func newEvent(level, eventType string, iteration int, message string, fields map[string]string) runEvent {
+	ev := runEvent{
+		Schema:    eventSchemaVersion,
+		Time:      time.Now().Format(time.RFC3339Nano),
+		Level:     level,
+		Type:      eventType,
+		Iteration: iteration,
+		Message:   message,
+	}
+	if manifest != nil {
+		ev.Agent = manifest.Agent
+		if n := len(manifest.Phases); n > 0 && manifest.Phases[n-1].Status == outcomeRunning {
+			ev.Phase = manifest.Phases[n-1].Name
+			ev.Agent, _ = manifest.phaseAgent(manifest.Phases[n-1])
+			if eventType == "AGENT_DONE" || eventType == "AGENT_FAILED" {
+				ev.DurationMs = phaseElapsed().Milliseconds()
+			}
+		}
+	}
+	for k, v := range fields {
+		switch k {
+		case "code":
+			ev.Code = v
+		case "phase":
+			ev.Phase = v
+		case "agent":
+			ev.Agent = v
+		case "duration_ms":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				ev.DurationMs = n
+			}
+		case "exit_code":
+			if n, err := strconv.Atoi(v); err == nil {
+				ev.ExitCode = &n
+			}
+		default:
+			if ev.Fields == nil {
+				ev.Fields = map[string]string{}
+			}
+			ev.Fields[k] = v
+		}
+	}
+	return ev
+}
+
+// emitEvent appends one JSON event to logs/events.jsonl
+func emitEvent(ev runEvent) {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if eventsFile == nil {
+		return
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	eventsFile.Write(append(data, '\n'))
+}
+
+// failureFields describes a failed agent run for the log: the error and, when
+// the agent exited with a status, its exit code
+func failureFields(err error) map[string]string {
+	fields := map[string]string{"error": err.Error()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		fields["exit_code"] = strconv.Itoa(exitErr.ExitCode())
+	}
+	return fields
+}
+
+// ========== RESULT FILE ==========
+
+// runResult is the machine-readable outcome of a run
+type runResult struct {
+	RunID      string `json:"run_id,omitempty"`
+	Outcome    string `json:"outcome"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	Report     string `json:"report,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"` // why the research loop ended, once it has
+	FinishedAt string `json:"finished_at"`
+}
+
+// writeResult writes result.json to the working directory. It is a no-op before
+// the run manifest exists.
+func writeResult(outcome, code, msg string) {
+	if manifest == nil {
+		return
+	}
+	res := runResult{
+		RunID:      manifest.RunID,
+		Outcome:    outcome,
+		ErrorCode:  code,
+		Error:      msg,
+		FinishedAt: time.Now().Format(time.RFC3339),
+	}
+	if code != "" && len(manifest.Phases) > 0 {
+		res.Phase = manifest.Phases[len(manifest.Phases)-1].Name
+	}
+	if report := reportFilePath(manifest.WorkDir); fileExists(report) {
+		res.Report = report
+	}
+	if manifest.Loop != nil {
+		res.StopReason = string(manifest.Loop.Reason)
+	}
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(manifest.WorkDir, resultFileName), data, 0644)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyKeep is the number of recent samples kept per phase
+const historyKeep = 20
+
+// Global phase-duration history, loaded at startup
+var history *phaseHistory
+
+// phaseHistory persists recent phase durations per agent/model so that
+// later runs can display a rough ETA. Stored in ~/.deepresearch/history.json.
+type phaseHistory struct {
+	Durations  map[string][]int64 `json:"durations_ms"`
+	Iterations map[string][]int   `json:"iterations"`
+
+	path string
+}
+
+// historyPath returns the location of the shared history file
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deepresearch", "history.json")
+}
+
+// loadHistory reads the phase history, returning an empty history if none exists
+func loadHistory() *phaseHistory {
+	return readHistory(historyPath())
+}
+
+// readHistory reads the history at path; a missing or unreadable file reads as empty
+func readHistory(path string) *phaseHistory {
+	h := &phaseHistory{path: path}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, h)
+		}
+	}
+	if h.Durations == nil {
+		h.Durations = map[string][]int64{}
+	}
+	if h.Iterations == nil {
+		h.Iterations = map[string][]int{}
+	}
+	return h
+}
+
+// update applies change to the latest history on disk under a lock, or only to h when it cannot be saved
+func (h *phaseHistory) update(change func(*phaseHistory)) {
+	if h.path == "" || os.MkdirAll(filepath.Dir(h.path), 0755) != nil {
+		change(h)
+		return
+	}
+	saved := false
+	err := withFileLock(h.path, func() error {
+		latest := readHistory(h.path)
+		change(latest)
+		data, err := json.MarshalIndent(latest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(h.path, data); err != nil {
+			return err
+		}
+		h.Durations, h.Iterations, saved = latest.Durations, latest.Iterations, true
+		return nil
+	})
+	if err != nil && !saved {
+		change(h)
+	}
+}
+
+func historyKey(agentName, model, name string) string {
+	if model == "" {
+		model = "default"
+	}
+	return agentName + "|" + model + "|" + name
+}
+
+// recordPhase stores the duration of a successfully completed phase
+func (h *phaseHistory) recordPhase(agentName, model, name string, d time.Duration) {
+	if h == nil {
+		return
+	}
+	key := historyKey(agentName, model, name)
+	h.update(func(h *phaseHistory) {
+		samples := append(h.Durations[key], d.Milliseconds())
+		if len(samples) > historyKeep {
+			samples = samples[len(samples)-historyKeep:]
+		}
+		h.Durations[key] = samples
+	})
+}
+
+// recordIterations stores the number of research iterations a completed run needed
+func (h *phaseHistory) recordIterations(agentName, model string, n int) {
+	if h == nil {
+		return
+	}
+	key := historyKey(agentName, model, "ITERATIONS")
+	h.update(func(h *phaseHistory) {
+		samples := append(h.Iterations[key], n)
+		if len(samples) > historyKeep {
+			samples = samples[len(samples)-historyKeep:]
+		}
+		h.Iterations[key] = samples
+	})
+}
+
+// estimatePhase returns the average historical duration of a phase
+func (h *phaseHistory) estimatePhase(agentName, model, name string) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	samples := h.Durations[historyKey(agentName, model, name)]
+	if len(samples) == 0 {
+		return 0, false
+	}
+	var total int64
+	for _, s := range samples {
+		total += s
+	}
+	return time.Duration(total/int64(len(samples))) * time.Millisecond, true
+}
+
+// estimateIterations returns the average number of research iterations per run (at least 1)
+func (h *phaseHistory) estimateIterations(agentName, model string) int {
+	if h == nil {
+		return 1
+	}
+	samples := h.Iterations[historyKey(agentName, model, "ITERATIONS")]
+	if len(samples) == 0 {
+		return 1
+	}
+	total := 0
+	for _, s := range samples {
+		total += s
+	}
+	if avg := (total + len(samples)/2) / len(samples); avg > 1 {
+		return avg
+	}
+	return 1
+}
+
+// estimateRemaining estimates the time left in the run from the start of the given phase.
+// It returns false when there is not enough history to produce an estimate.
+func (h *phaseHistory) estimateRemaining(agentName, model, name string, iteration int) (time.Duration, bool) {
+	est := func(phase string) (time.Duration, bool) { return h.estimatePhase(agentName, model, phase) }
+	sup, okSup := est("RESEARCH-SUPERVISOR")
+	ref, okRef := est("REFLECTOR")
+	syn, okSyn := est("SYNTHESIZER")
+	if !okSup || !okRef || !okSyn {
+		return 0, false
+	}
+
+	loops := h.estimateIterations(agentName, model)
+	later := func(done int) time.Duration {
+		if left := loops - done; left > 0 {
+			return time.Duration(left) * (sup + ref)
+		}
+		return 0
+	}
+
+	switch name {
+	case "CLARIFIER":
+		clarify, okClarify := est("CLARIFIER")
+		plan, okPlan := est("PLANNER")
+		if !okClarify || !okPlan {
+			return 0, false
+		}
+		return clarify + plan + later(0) + syn, true
+	case "PLANNER":
+		plan, ok := est("PLANNER")
+		if !ok {
+			return 0, false
+		}
+		return plan + later(0) + syn, true
+	case "RESEARCH-SUPERVISOR":
+		return sup + ref + later(iteration) + syn, true
+	case "REFLECTOR":
+		return ref + later(iteration) + syn, true
+	case "SYNTHESIZER":
+		return syn, true
+	case "TRANSLATOR":
+		return est("TRANSLATOR")
+	}
+	return 0, false
+}
+
+// showETA prints the elapsed time and a rough ETA for the phase about to run
+func showETA(agentName, model, name string, iteration int) {
+	elapsed := time.Duration(0)
+	if manifest != nil {
+		elapsed = time.Since(manifest.StartedAt)
+	}
+	phaseETA := tr("unknown")
+	if d, ok := history.estimatePhase(agentName, model, name); ok {
+		phaseETA = "~" + formatDuration(d)
+	}
+	runETA := tr("unknown")
+	if d, ok := history.estimateRemaining(agentName, model, name, iteration); ok {
+		runETA = "~" + formatDuration(d)
+	}
+	info("Elapsed: %s | Phase ETA: %s | Run ETA: %s", formatDuration(elapsed), phaseETA, runETA)
+}
+
+// phaseElapsed returns how long the current phase has been running
+func phaseElapsed() time.Duration {
+	if manifest == nil || len(manifest.Phases) == 0 {
+		return 0
+	}
+	return time.Since(manifest.Phases[len(manifest.Phases)-1].StartedAt)
+}
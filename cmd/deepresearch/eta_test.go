@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPhaseHistoryMergesConcurrentRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	// Each run loads the history at startup, before the others record
+	const runs = 8
+	loaded := make([]*phaseHistory, runs)
+	for i := range loaded {
+		loaded[i] = readHistory(path)
+	}
+	var wg sync.WaitGroup
+	for i, h := range loaded {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.recordPhase("claude", "", "PLANNER", time.Duration(i+1)*time.Second)
+		}()
+	}
+	wg.Wait()
+
+	got := readHistory(path).Durations[historyKey("claude", "", "PLANNER")]
+	slices.Sort(got)
+	want := []int64{1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000}
+	if !slices.Equal(got, want) {
+		t.Errorf("samples = %v, want %v", got, want)
+	}
+}
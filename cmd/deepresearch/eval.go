@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runScore holds the quantitative measures of a finished run that prompt and
+// workflow changes are compared on
+type runScore struct {
+	Dir              string  `json:"dir"`
+	Outcome          string  `json:"outcome"`
+	DurationSec      float64 `json:"duration_sec"`
+	Iterations       int     `json:"iterations"`
+	Tokens           int     `json:"tokens"`
+	CostUSD          float64 `json:"cost_usd"` // estimated from the usage the agents reported
+	TasksPlanned     int     `json:"tasks_planned"`
+	TasksCompleted   int     `json:"tasks_completed"`
+	Sources          int     `json:"sources"`
+	CitedSources     int     `json:"cited_sources"`
+	Facts            int     `json:"facts"`
+	ReportWords      int     `json:"report_words"`
+	Paragraphs       int     `json:"paragraphs"`
+	CitedParagraphs  int     `json:"cited_paragraphs"`
+	UnknownCitations int     `json:"unknown_citations"` // report citations missing from the Source Registry
+	BlockedCitations int     `json:"blocked_citations"` // report citations of sources whose fetch was blocked
+}
+
+// citationCoverage is the share of report paragraphs that cite a source
+func (s runScore) citationCoverage() float64 {
+	if s.Paragraphs == 0 {
+		return 0
+	}
+	return float64(s.CitedParagraphs) / float64(s.Paragraphs)
+}
+
+// scoreRun evaluates the workspace of a finished run
+func scoreRun(workDir string) (runScore, error) {
+	s := runScore{Dir: workDir}
+	m, err := loadManifest(workDir)
+	if err != nil {
+		return s, err
+	}
+	s.Outcome = m.Outcome
+	if m.FinishedAt != nil {
+		s.DurationSec = m.FinishedAt.Sub(m.StartedAt).Seconds()
+	}
+	for _, p := range m.Phases {
+		s.Iterations = max(s.Iterations, p.Iteration)
+	}
+	if m.Usage != nil {
+		s.CostUSD = m.Usage.CostUSD
+	}
+
+	taskFile := taskFilePath(workDir)
+	s.TasksPlanned, s.TasksCompleted, s.Sources = taskStats(taskFile)
+	s.Tokens = jobTokens(workDir)
+	s.Facts = len(readFacts(taskFile))
+	registered := map[string]bool{}
+	for _, src := range readSourceRegistry(workDir) {
+		registered[src.ID] = true
+	}
+
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return s, nil
+	}
+	s.ReportWords = len(strings.Fields(string(report)))
+	cited := map[string]bool{}
+	for _, c := range citationRe.FindAllStringSubmatch(string(report), -1) {
+		if registered[c[1]] {
+			cited[c[1]] = true
+		} else {
+			s.UnknownCitations++
+		}
+	}
+	s.CitedSources = len(cited)
+	for _, block := range strings.Split(string(report), "\n\n") {
+		block = strings.TrimSpace(block)
+		// Prose only: headings, tables, lists of references and code are not paragraphs
+		if len(strings.Fields(block)) < 20 || strings.HasPrefix(block, "#") || strings.HasPrefix(block, "|") || strings.HasPrefix(block, "```") {
+			continue
+		}
+		s.Paragraphs++
+		if citationRe.MatchString(block) {
+			s.CitedParagraphs++
+		}
+	}
+	if assets, err := loadAssetManifest(workDir); err == nil {
+		s.BlockedCitations = len(checkBlockedCitations(workDir, assets))
+	}
+	return s, nil
+}
+
+// scoreRows lists the compared measures and how to read each from a score
+var scoreRows = []struct {
+	Name  string
+	Value func(s runScore) float64
+	Unit  string
+}{
+	{"Outcome completed", func(s runScore) float64 { return boolScore(s.Outcome == outcomeCompleted) }, ""},
+	{"Duration (min)", func(s runScore) float64 { return s.DurationSec / 60 }, ""},
+	{"Iterations", func(s runScore) float64 { return float64(s.Iterations) }, ""},
+	{"Tokens", func(s runScore) float64 { return float64(s.Tokens) }, ""},
+	{"Cost (USD)", func(s runScore) float64 { return s.CostUSD }, ""},
+	{"Tasks completed", func(s runScore) float64 { return float64(s.TasksCompleted) }, ""},
+	{"Sources registered", func(s runScore) float64 { return float64(s.Sources) }, ""},
+	{"Sources cited", func(s runScore) float64 { return float64(s.CitedSources) }, ""},
+	{"Facts", func(s runScore) float64 { return float64(s.Facts) }, ""},
+	{"Report words", func(s runScore) float64 { return float64(s.ReportWords) }, ""},
+	{"Cited paragraphs", func(s runScore) float64 { return 100 * s.citationCoverage() }, "%"},
+	{"Unknown citations", func(s runScore) float64 { return float64(s.UnknownCitations) }, ""},
+	{"Blocked citations", func(s runScore) float64 { return float64(s.BlockedCitations) }, ""},
+}
+
+func boolScore(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// formatScore prints a measure without needless decimals
+func formatScore(v float64, unit string) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d%s", int64(v), unit)
+	}
+	return fmt.Sprintf("%.1f%s", v, unit)
+}
+
+// scoreTable renders runs side by side as a Markdown table, with the change
+// from the first run when there are two
+func scoreTable(names []string, scores []runScore) string {
+	var b strings.Builder
+	b.WriteString("| Measure | " + strings.Join(names, " | ") + " |")
+	if len(scores) == 2 {
+		b.WriteString(" Δ |")
+	}
+	b.WriteString("\n|---|" + strings.Repeat("---|", len(names)))
+	if len(scores) == 2 {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, row := range scoreRows {
+		b.WriteString("| " + row.Name + " |")
+		for _, s := range scores {
+			b.WriteString(" " + formatScore(row.Value(s), row.Unit) + " |")
+		}
+		if len(scores) == 2 {
+			d := row.Value(scores[1]) - row.Value(scores[0])
+			sign := ""
+			if d > 0 {
+				sign = "+"
+			}
+			b.WriteString(" " + sign + formatScore(d, row.Unit) + " |")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// runEval implements `deepresearch eval`: scores one or more finished runs and
+// prints them side by side
+func runEval(args []string) {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the scores as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch eval [-json] <workspace>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var scores []runScore
+	for _, dir := range dirs {
+		s, err := scoreRun(dir)
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot evaluate %s: %v", dir, err)
+		}
+		scores = append(scores, s)
+	}
+	if *asJSON {
+		data, _ := json.MarshalIndent(scores, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(scoreTable(dirs, scores))
+}
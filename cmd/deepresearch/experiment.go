@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// experimentVariant is one arm of a prompt or workflow experiment
+type experimentVariant struct {
+	Name    string   `json:"name"`
+	Prompts string   `json:"prompts"`
+	Config  string   `json:"config,omitempty"`
+	Dir     string   `json:"dir"`
+	Error   string   `json:"error,omitempty"`
+	Score   runScore `json:"score"`
+}
+
+// experimentResult is experiment.json
+type experimentResult struct {
+	StartedAt time.Time           `json:"started_at"`
+	Reuse     string              `json:"reuse"`
+	Variants  []experimentVariant `json:"variants"`
+}
+
+// runExperiment implements `deepresearch experiment`: researches one topic with
+// two prompt packs or configs and scores both runs side by side. Variant B
+// replays the sources variant A fetched, so the comparison is not skewed by
+// what each run happened to find.
+func runExperiment(args []string) {
+	fs := flag.NewFlagSet("experiment", flag.ExitOnError)
+	prompt := fs.String("p", "", "Research topic")
+	promptFile := fs.String("f", "", "Read the research topic from a file")
+	packA := fs.String("a", "", "Prompt pack of variant A (default: the installed prompts)")
+	packB := fs.String("b", "", "Prompt pack of variant B (default: same as A)")
+	configA := fs.String("config-a", "", "Config file of variant A")
+	configB := fs.String("config-b", "", "Config file of variant B")
+	agent := fs.String("agent", "", "Agent both variants use")
+	model := fs.String("model", "", "Model both variants use")
+	reuse := fs.String("reuse", replayResearch, "What B takes from A: research (A's fetched sources, B researches and synthesizes), synthesis (A's findings, B only synthesizes), none (independent runs)")
+	dir := fs.String("dir", "", "Experiment directory (default experiment-<timestamp>)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch experiment -p TOPIC -a PACK -b PACK [flags] [-- run flags for both variants]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (*prompt == "") == (*promptFile == "") {
+		fatalCode(codeValidationFailed, "experiment needs a topic: -p or -f")
+	}
+	if *packB == "" && *configB == "" {
+		fatalCode(codeValidationFailed, "experiment needs a variant: -b and/or -config-b")
+	}
+	switch *reuse {
+	case replayResearch, replaySynthesis, "none":
+	default:
+		fatalCode(codeValidationFailed, "Invalid -reuse value: %s (expected research, synthesis or none)", *reuse)
+	}
+	if *packA == "" {
+		pack, err := findPromptsDir()
+		if err != nil {
+			fatal("Cannot load prompts: %v", err)
+		}
+		*packA = pack
+	}
+	if *packB == "" {
+		*packB = *packA
+	}
+	if *dir == "" {
+		*dir = "experiment-" + time.Now().Format("20060102-150405")
+	}
+	root, err := filepath.Abs(*dir)
+	if err != nil {
+		fatal("Failed to resolve experiment directory: %v", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate the deepresearch executable: %v", err)
+	}
+
+	abs := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		a, err := filepath.Abs(p)
+		if err != nil {
+			fatal("Failed to resolve %s: %v", p, err)
+		}
+		return a
+	}
+	result := experimentResult{StartedAt: time.Now(), Reuse: *reuse, Variants: []experimentVariant{
+		{Name: "A", Prompts: abs(*packA), Config: abs(*configA), Dir: filepath.Join(root, "a")},
+		{Name: "B", Prompts: abs(*packB), Config: abs(*configB), Dir: filepath.Join(root, "b")},
+	}}
+	common := []string{}
+	if *prompt != "" {
+		common = append(common, "-p", *prompt)
+	} else {
+		common = append(common, "-f", abs(*promptFile))
+	}
+	if *agent != "" {
+		common = append(common, "-agent", *agent)
+	}
+	if *model != "" {
+		common = append(common, "-model", *model)
+	}
+
+	for i := range result.Variants {
+		v := &result.Variants[i]
+		runArgs := append([]string{"-in-place"}, common...)
+		if v.Config != "" {
+			runArgs = append(runArgs, "-config", v.Config)
+		}
+		if v.Name == "B" && *reuse != "none" {
+			runArgs = append(runArgs, "-replay", result.Variants[0].Dir, "-replay-from", *reuse)
+		}
+		runArgs = append(runArgs, fs.Args()...)
+
+		phase("EXPERIMENT", fmt.Sprintf("Variant %s: prompts %s", v.Name, v.Prompts))
+		if err := os.MkdirAll(v.Dir, 0755); err != nil {
+			fatal("Failed to create %s: %v", v.Dir, err)
+		}
+		cmd := exec.Command(exe, runArgs...)
+		cmd.Dir = v.Dir
+		cmd.Env = append(os.Environ(), promptsEnv+"="+v.Prompts)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			v.Error = err.Error()
+			warn("Variant %s failed: %v", v.Name, err)
+			if v.Name == "A" && *reuse != "none" {
+				fatalCode(codeAgentFailed, "Variant B needs the sources of variant A; stopping the experiment")
+			}
+		}
+		if s, err := scoreRun(v.Dir); err == nil {
+			v.Score = s
+		}
+	}
+
+	table := scoreTable([]string{"A", "B"}, []runScore{result.Variants[0].Score, result.Variants[1].Score})
+	data, _ := json.MarshalIndent(result, "", "  ")
+	if err := os.WriteFile(filepath.Join(root, "experiment.json"), data, 0644); err != nil {
+		warn("Could not write experiment.json: %v", err)
+	}
+	md := fmt.Sprintf("# Experiment %s\n\n- A: prompts `%s`%s\n- B: prompts `%s`%s\n- B reuses from A: %s\n\n%s",
+		filepath.Base(root), result.Variants[0].Prompts, configNote(result.Variants[0].Config),
+		result.Variants[1].Prompts, configNote(result.Variants[1].Config), *reuse, table)
+	if err := os.WriteFile(filepath.Join(root, "experiment.md"), []byte(md), 0644); err != nil {
+		warn("Could not write experiment.md: %v", err)
+	}
+	fmt.Println()
+	fmt.Print(table)
+	success("Experiment results: %s", filepath.Join(root, "experiment.md"))
+}
+
+// configNote describes a variant's config file for experiment.md
+func configNote(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf(", config `%s`", path)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Report formats for --output-format; md is report.md itself
+const (
+	formatMarkdown = "md"
+	formatHTML     = "html"
+	formatPDF      = "pdf"
+)
+
+// exportedReportName is the file the report of workDir is exported to in
+// format, next to report.md and named after it, e.g. report.html
+func exportedReportName(workDir, format string) string {
+	report := reportFileName(workDir)
+	return strings.TrimSuffix(report, path.Ext(report)) + "." + format
+}
+
+// exportConfig sets the formats report.md is exported to after synthesis
+type exportConfig struct {
+	Formats []string `yaml:"formats"` // same as --output-format: md, html, pdf (default: md)
+	// PDFFont is a TrueType font for report.pdf; the built-in Helvetica only
+	// covers Western European scripts
+	PDFFont   string `yaml:"pdf_font"`
+	PaperSize string `yaml:"paper_size"` // A4 (default), Letter
+}
+
+// parseOutputFormats validates a comma-separated --output-format value
+func parseOutputFormats(s string) ([]string, error) {
+	var formats []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch f {
+		case formatMarkdown, formatHTML, formatPDF:
+			formats = append(formats, f)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown format %q (expected md, html or pdf)", f)
+		}
+	}
+	return formats, nil
+}
+
+// exportReport writes report.md in each of formats and returns the files written
+func exportReport(workDir string, formats []string) []string {
+	var written []string
+	for _, f := range formats {
+		var file string
+		var err error
+		switch f {
+		case formatHTML:
+			file, err = exportedReportName(workDir, formatHTML), exportHTML(workDir)
+		case formatPDF:
+			var lost int
+			file = exportedReportName(workDir, formatPDF)
+			if lost, err = exportPDF(workDir); err == nil && lost > 0 {
+				warn("%s: %d character(s) are outside the PDF font and were replaced; set export.pdf_font to a TrueType font that covers them", file, lost)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			warn("Could not write %s: %v", file, err)
+			continue
+		}
+		written = append(written, file)
+	}
+	if len(written) > 0 {
+		logEntry("INFO", "EXPORT", 0, "Report exported", map[string]string{
+			"files": strings.Join(written, ","),
+		})
+	}
+	return written
+}
+
+// reportTitle returns the first level-one heading of a report, or "Research Report"
+func reportTitle(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return "Research Report"
+}
+
+// reportHTMLPage is report.html: the rendered report with its images embedded,
+// readable offline and shareable as a single file. Mermaid and Vega-Lite
+// charts are drawn when the viewer is online and stay as source otherwise.
+var reportHTMLPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; }
+main { max-width: 900px; margin: 32px auto; padding: 0 24px; line-height: 1.6; }
+h1, h2 { border-bottom: 1px solid #d0d7de; padding-bottom: 4px; }
+a { color: #0969da; }
+img { max-width: 100%; }
+table { border-collapse: collapse; width: 100%; margin: 12px 0; }
+th, td { border: 1px solid #d0d7de; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 12px; overflow: auto; }
+code { font-size: 90%; }
+blockquote { margin: 0; padding: 0 16px; color: #57606a; border-left: 4px solid #d0d7de; }
+@media print { main { max-width: none; margin: 0; } pre { white-space: pre-wrap; } }
+</style>
+</head>
+<body>
+<main>
+{{.Content}}
+</main>
+<script>
+function loadScript(src, done) { var s = document.createElement("script"); s.src = src; s.onload = done; document.head.appendChild(s); }
+var mermaidBlocks = document.querySelectorAll("code.language-mermaid");
+if (mermaidBlocks.length) {
+  loadScript("https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js", function () {
+    mermaidBlocks.forEach(function (c) { var d = document.createElement("div"); d.className = "mermaid"; d.textContent = c.textContent; c.parentNode.replaceWith(d); });
+    mermaid.initialize({ startOnLoad: false }); mermaid.run();
+  });
+}
+var vegaBlocks = document.querySelectorAll("code.language-vega-lite");
+if (vegaBlocks.length) {
+  loadScript("https://cdn.jsdelivr.net/npm/vega@5", function () {
+    loadScript("https://cdn.jsdelivr.net/npm/vega-lite@5", function () {
+      loadScript("https://cdn.jsdelivr.net/npm/vega-embed@6", function () {
+        vegaBlocks.forEach(function (c) { var d = document.createElement("div"); var pre = c.parentNode; pre.replaceWith(d); vegaEmbed(d, JSON.parse(c.textContent)); });
+      });
+    });
+  });
+}
+</script>
+</body>
+</html>
+`))
+
+// exportHTML renders report.md into report.html
+func exportHTML(workDir string) error {
+	src, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return err
+	}
+	content, err := renderMarkdown(src)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = reportHTMLPage.Execute(&buf, struct {
+		Title   string
+		Content template.HTML
+	}{reportTitle(src), template.HTML(embedImages(workDir, content))})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workDir, filepath.FromSlash(exportedReportName(workDir, formatHTML))), buf.Bytes(), 0644)
+}
+
+// imgSrcRe matches the src attribute of an img tag
+var imgSrcRe = regexp.MustCompile(`(<img\b[^>]*?\bsrc=")([^"]*)(")`)
+
+// embedImages replaces references to image files in the workspace, such as
+// assets/images/, with data URIs so the page needs no files next to it
+func embedImages(workDir string, content []byte) []byte {
+	return imgSrcRe.ReplaceAllFunc(content, func(tag []byte) []byte {
+		m := imgSrcRe.FindSubmatch(tag)
+		path, ok := workspaceFile(workDir, html.UnescapeString(string(m[2])))
+		if !ok {
+			return tag
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return tag
+		}
+		mediaType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+		if mediaType == "" {
+			mediaType = http.DetectContentType(data)
+		}
+		uri := "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+		return []byte(string(m[1]) + uri + string(m[3]))
+	})
+}
+
+// workspaceFile resolves a relative link of the report to a file inside the
+// workspace; links to the web, anchors and paths leaving the workspace are not
+func workspaceFile(workDir, link string) (string, bool) {
+	if link == "" || strings.HasPrefix(link, "#") || strings.Contains(link, ":") {
+		return "", false
+	}
+	if p, err := url.PathUnescape(link); err == nil {
+		link = p
+	}
+	link, _, _ = strings.Cut(link, "#")
+	path := filepath.Join(workDir, filepath.FromSlash(link))
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, fileExists(path)
+}
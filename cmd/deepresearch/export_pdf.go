@@ -0,0 +1,452 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Font sizes (pt) of the PDF report; line heights are in mm
+const (
+	pdfBodySize = 10.5
+	pdfCodeSize = 9
+)
+
+// pdfHeadingSizes are the font sizes of heading levels 1 to 4 and below
+var pdfHeadingSizes = []float64{20, 16, 13, 11.5}
+
+// pdfReport lays out the Markdown of report.md on PDF pages. It covers what
+// reports use: headings (also as the document outline), paragraphs with
+// emphasis, code and links, lists, block quotes, code blocks, tables,
+// workspace images and footnotes. Raw HTML is left out.
+type pdfReport struct {
+	pdf     *gofpdf.Fpdf
+	src     []byte
+	workDir string
+	family  string              // of the text; code uses Courier unless a TrueType font is set
+	utf8    bool                // family is a TrueType font
+	tr      func(string) string // into the code page of the built-in fonts
+	lost    int                 // characters the font cannot show
+	size    float64
+	indent  float64 // of lists and quotes, in mm
+	outline int     // level of the last bookmark
+}
+
+// exportPDF renders report.md into report.pdf and returns the number of
+// characters the font could not show
+func exportPDF(workDir string) (int, error) {
+	src, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return 0, err
+	}
+	paper := config.Export.PaperSize
+	if paper == "" {
+		paper = "A4"
+	}
+	pdf := gofpdf.New("P", "mm", paper, "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(reportTitle(src), true)
+	pdf.SetCreator("deepresearch", true)
+	pdf.AliasNbPages("")
+	r := &pdfReport{pdf: pdf, src: src, workDir: workDir, family: "Helvetica", tr: func(s string) string { return s }, outline: -1}
+	if font := config.Export.PDFFont; font != "" {
+		ttf, err := os.ReadFile(font)
+		if err != nil {
+			return 0, fmt.Errorf("export.pdf_font: %w", err)
+		}
+		// One file serves all styles; emphasis then keeps the font's own weight
+		for _, style := range []string{"", "B", "I", "BI"} {
+			pdf.AddUTF8FontFromBytes("report", style, ttf)
+		}
+		if err := pdf.Error(); err != nil {
+			return 0, fmt.Errorf("export.pdf_font: %w", err)
+		}
+		r.family, r.utf8 = "report", true
+	} else {
+		r.tr = pdf.UnicodeTranslatorFromDescriptor("")
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(r.family, "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	doc := markdown.Parser().Parse(text.NewReader(src))
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		r.block(n)
+	}
+	if err := pdf.Error(); err != nil {
+		return r.lost, err
+	}
+	return r.lost, pdf.OutputFileAndClose(filepath.Join(workDir, filepath.FromSlash(exportedReportName(workDir, formatPDF))))
+}
+
+// lineHeight is the line height of the current font size
+func (r *pdfReport) lineHeight() float64 { return r.size * 0.5 }
+
+// text converts s for the font, counting characters it cannot show
+func (r *pdfReport) text(s string) string {
+	if r.utf8 {
+		return s
+	}
+	for _, c := range s {
+		if c >= 0x80 && r.tr(string(c)) == "." {
+			r.lost++
+		}
+	}
+	return r.tr(s)
+}
+
+// setFont selects the text font, or Courier for code
+func (r *pdfReport) setFont(style string, size float64, code bool) {
+	r.size = size
+	if code && !r.utf8 {
+		r.pdf.SetFont("Courier", style, size)
+		return
+	}
+	r.pdf.SetFont(r.family, style, size)
+}
+
+// margin moves the left margin to the current indent
+func (r *pdfReport) margin() {
+	r.pdf.SetLeftMargin(20 + r.indent)
+	r.pdf.SetX(20 + r.indent)
+}
+
+// gap adds vertical space before a block, except at the top of a page
+func (r *pdfReport) gap(h float64) {
+	_, top, _, _ := r.pdf.GetMargins()
+	if r.pdf.GetY() > top+0.1 {
+		r.pdf.Ln(h)
+	}
+}
+
+func (r *pdfReport) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		size := pdfHeadingSizes[min(n.Level, len(pdfHeadingSizes))-1]
+		r.gap(size * 0.35)
+		// The outline must not skip levels
+		level := min(n.Level-1, r.outline+1)
+		r.pdf.Bookmark(r.text(plainText(n, r.src)), level, -1)
+		r.outline = level
+		r.pdf.SetTextColor(31, 35, 40)
+		r.inlines(n, "B", size)
+		r.pdf.Ln(r.lineHeight() + 1)
+	case *ast.Paragraph, *ast.TextBlock:
+		r.inlines(n, "", pdfBodySize)
+		r.pdf.Ln(r.lineHeight())
+		if _, ok := n.(*ast.Paragraph); ok {
+			r.pdf.Ln(2)
+		}
+	case *ast.List:
+		r.list(n)
+	case *ast.Blockquote:
+		r.gap(1)
+		page, y := r.pdf.PageNo(), r.pdf.GetY()
+		r.indent += 5
+		r.margin()
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			r.block(c)
+		}
+		r.indent -= 5
+		r.margin()
+		if r.pdf.PageNo() == page {
+			r.pdf.SetDrawColor(208, 215, 222)
+			r.pdf.SetLineWidth(0.8)
+			r.pdf.Line(20+r.indent+1, y, 20+r.indent+1, r.pdf.GetY()-2)
+		}
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		r.code(n)
+	case *ast.ThematicBreak:
+		r.gap(2)
+		r.pdf.SetDrawColor(208, 215, 222)
+		r.pdf.SetLineWidth(0.3)
+		w, _ := r.pdf.GetPageSize()
+		r.pdf.Line(20+r.indent, r.pdf.GetY(), w-20, r.pdf.GetY())
+		r.pdf.Ln(4)
+	case *east.Table:
+		r.table(n)
+	case *east.FootnoteList:
+		r.gap(4)
+		r.pdf.SetDrawColor(208, 215, 222)
+		r.pdf.SetLineWidth(0.3)
+		r.pdf.Line(20, r.pdf.GetY(), 70, r.pdf.GetY())
+		r.pdf.Ln(2)
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if fn, ok := c.(*east.Footnote); ok {
+				r.setFont("", 8.5, false)
+				r.pdf.Write(r.lineHeight(), fmt.Sprintf("%d. ", fn.Index))
+				for p := fn.FirstChild(); p != nil; p = p.NextSibling() {
+					r.inlines(p, "", 8.5)
+					r.pdf.Ln(r.lineHeight())
+				}
+			}
+		}
+	case *ast.HTMLBlock:
+		// Anchors and collapsible blocks have no place on paper
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			r.block(c)
+		}
+	}
+}
+
+// list writes a list, nested lists further indented
+func (r *pdfReport) list(n *ast.List) {
+	num := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "•"
+		if n.IsOrdered() {
+			marker = fmt.Sprintf("%d.", num)
+			num++
+		}
+		r.setFont("", pdfBodySize, false)
+		r.pdf.SetX(20 + r.indent)
+		r.pdf.CellFormat(6, r.lineHeight(), r.text(marker), "", 0, "R", false, 0, "")
+		r.indent += 7
+		r.margin()
+		for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+			r.block(c)
+		}
+		r.indent -= 7
+		r.margin()
+	}
+	if !n.IsTight {
+		r.pdf.Ln(1)
+	}
+	r.pdf.Ln(1)
+}
+
+// code writes a code block on a shaded background
+func (r *pdfReport) code(n ast.Node) {
+	r.gap(1)
+	r.setFont("", pdfCodeSize, true)
+	r.pdf.SetFillColor(246, 248, 250)
+	r.pdf.SetTextColor(31, 35, 40)
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		line := strings.TrimRight(string(seg.Value(r.src)), "\r\n")
+		r.pdf.MultiCell(0, r.lineHeight(), r.text(strings.ReplaceAll(line, "\t", "    ")), "", "L", true)
+	}
+	r.pdf.Ln(3)
+}
+
+// table writes a table with equal column widths, wrapping cell text
+func (r *pdfReport) table(n *east.Table) {
+	var rows [][]string
+	var header int // rows of the header
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, r.text(plainText(cell, r.src)))
+		}
+		if _, ok := row.(*east.TableHeader); ok {
+			header++
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return
+	}
+	r.gap(1)
+	pageW, pageH := r.pdf.GetPageSize()
+	_, _, _, bottom := r.pdf.GetMargins()
+	cols := len(rows[0])
+	w := (pageW - 40 - r.indent) / float64(cols)
+	size := pdfBodySize - 1.5
+	r.pdf.SetDrawColor(208, 215, 222)
+	r.pdf.SetLineWidth(0.2)
+	r.pdf.SetFillColor(246, 248, 250)
+	r.pdf.SetTextColor(31, 35, 40)
+	for i, cells := range rows {
+		style := ""
+		if i < header {
+			style = "B"
+		}
+		r.setFont(style, size, false)
+		// Rows are as high as their longest cell
+		split := make([][]string, cols)
+		lines := 1
+		for c := 0; c < cols && c < len(cells); c++ {
+			split[c] = r.split(cells[c], w)
+			lines = max(lines, len(split[c]))
+		}
+		h := float64(lines)*r.lineHeight() + 2
+		if r.pdf.GetY()+h > pageH-bottom {
+			r.pdf.AddPage()
+		}
+		y := r.pdf.GetY()
+		for c := 0; c < cols; c++ {
+			x := 20 + r.indent + float64(c)*w
+			fill := "D"
+			if i < header {
+				fill = "FD"
+			}
+			r.pdf.Rect(x, y, w, h, fill)
+			for l, line := range split[c] {
+				r.pdf.SetXY(x, y+1+float64(l)*r.lineHeight())
+				r.pdf.CellFormat(w, r.lineHeight(), line, "", 0, alignOf(n, c), false, 0, "")
+			}
+		}
+		r.pdf.SetXY(20+r.indent, y+h)
+	}
+	r.pdf.Ln(3)
+}
+
+// split wraps text, already converted for the font, to lines of width w
+func (r *pdfReport) split(s string, w float64) []string {
+	if r.utf8 {
+		return r.pdf.SplitText(s, w)
+	}
+	var lines []string
+	for _, l := range r.pdf.SplitLines([]byte(s), w) {
+		lines = append(lines, string(l))
+	}
+	return lines
+}
+
+// alignOf returns the CellFormat alignment of a table column
+func alignOf(t *east.Table, col int) string {
+	if col < len(t.Alignments) {
+		switch t.Alignments[col] {
+		case east.AlignRight:
+			return "R"
+		case east.AlignCenter:
+			return "C"
+		}
+	}
+	return "L"
+}
+
+// inlines writes the inline content of a block
+func (r *pdfReport) inlines(n ast.Node, style string, size float64) {
+	r.setFont(style, size, false)
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		r.inline(c, style, size)
+	}
+}
+
+func (r *pdfReport) inline(n ast.Node, style string, size float64) {
+	switch n := n.(type) {
+	case *ast.Text:
+		r.write(string(n.Value(r.src)), style, size, "")
+		if n.HardLineBreak() {
+			r.pdf.Ln(r.lineHeight())
+		} else if n.SoftLineBreak() {
+			r.write(" ", style, size, "")
+		}
+	case *ast.String:
+		r.write(string(n.Value), style, size, "")
+	case *ast.Emphasis:
+		add := "I"
+		if n.Level >= 2 {
+			add = "B"
+		}
+		if !strings.Contains(style, add) {
+			style = strings.Replace(style+add, "IB", "BI", 1)
+		}
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			r.inline(c, style, size)
+		}
+	case *ast.CodeSpan:
+		r.setFont("", size-1, true)
+		r.pdf.Write(r.lineHeight(), r.text(plainText(n, r.src)))
+		r.setFont(style, size, false)
+	case *ast.Link:
+		r.write(plainText(n, r.src), style, size, string(n.Destination))
+	case *ast.AutoLink:
+		r.write(string(n.Label(r.src)), style, size, string(n.URL(r.src)))
+	case *ast.Image:
+		r.image(string(n.Destination))
+	case *east.FootnoteLink:
+		r.setFont(style, size-2, false)
+		r.pdf.Write(r.lineHeight(), fmt.Sprintf("[%d]", n.Index))
+		r.setFont(style, size, false)
+	case *east.TaskCheckBox:
+		mark := "[ ] "
+		if n.IsChecked {
+			mark = "[x] "
+		}
+		r.write(mark, style, size, "")
+	case *ast.RawHTML, *east.FootnoteBacklink:
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			r.inline(c, style, size)
+		}
+	}
+}
+
+// write writes text, as a link to the web if link is one
+func (r *pdfReport) write(s, style string, size float64, link string) {
+	r.setFont(style, size, false)
+	if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+		r.pdf.SetTextColor(9, 105, 218)
+		r.pdf.WriteLinkString(r.lineHeight(), r.text(s), link)
+		r.pdf.SetTextColor(31, 35, 40)
+		return
+	}
+	r.pdf.Write(r.lineHeight(), r.text(s))
+}
+
+// image places a workspace image (PNG, JPEG or GIF) at the full text width,
+// or less if it is smaller
+func (r *pdfReport) image(dest string) {
+	path, ok := workspaceFile(r.workDir, dest)
+	if !ok {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".gif" {
+		return
+	}
+	opts := gofpdf.ImageOptions{ReadDpi: true}
+	info := r.pdf.RegisterImageOptions(path, opts)
+	if info == nil || !r.pdf.Ok() {
+		// A broken image must not cost the whole PDF
+		r.pdf.ClearError()
+		return
+	}
+	pageW, pageH := r.pdf.GetPageSize()
+	w := min(info.Width(), pageW-40-r.indent)
+	// Tall images are scaled down to fit a page
+	if h := w * info.Height() / info.Width(); h > pageH-50 {
+		w *= (pageH - 50) / h
+	}
+	r.pdf.Ln(r.lineHeight())
+	r.pdf.ImageOptions(path, 20+r.indent, -1, w, 0, true, opts, 0, "")
+	r.pdf.Ln(2)
+}
+
+// plainText returns the text of a node's inline content
+func plainText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Value(src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.AutoLink:
+			b.Write(c.Label(src))
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
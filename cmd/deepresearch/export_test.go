@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportHTMLDropsScripts(t *testing.T) {
+	dir := t.TempDir()
+	report := "# Report\n\nQuoted page:\n\n<script>alert('block')</script>\n\n" +
+		"Inline <img src=x onerror=\"alert('inline')\"> and [a link](javascript:alert('link')).\n"
+	if err := os.WriteFile(reportFilePath(dir), []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportHTML(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, exportedReportName(dir, formatHTML)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, script := range []string{"alert('block')", "onerror", "javascript:"} {
+		if strings.Contains(out, script) {
+			t.Errorf("report.html contains %q", script)
+		}
+	}
+	if !strings.Contains(out, "Quoted page:") {
+		t.Error("report.html lost the report text")
+	}
+}
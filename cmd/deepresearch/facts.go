@@ -0,0 +1,25 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// fact is a Knowledge Graph entry in task.md
+type fact = taskfile.Finding
+
+var (
+	factLineRe  = regexp.MustCompile(`^\s*(?:[-*]\s*)?\[(Fact-\d+)\]\s*(.*)$`)
+	factFieldRe = regexp.MustCompile(`^\s*[-*]\s*(Source|Confidence|Raw_File)\s*:\s*(.*)$`)
+	factSrcRe   = regexp.MustCompile(`\bS\d+\b`)
+)
+
+// readFacts parses the Knowledge Graph entries in task.md
+func readFacts(taskFile string) []fact {
+	f, err := taskfile.Read(taskFile)
+	if err != nil {
+		return nil
+	}
+	return f.Findings
+}
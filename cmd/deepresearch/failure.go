@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failureFileName is the triage report written to the working directory on fatal errors
+const failureFileName = "failure.md"
+
+// failureJSONFileName is the machine-readable counterpart of failure.md
+const failureJSONFileName = "failure.json"
+
+// agentOutputTail keeps the most recent lines of agent output for failure triage
+var agentOutputTail = newLineRing(60)
+
+// agentStderrTail keeps the most recent lines agents wrote to stderr
+var agentStderrTail = newLineRing(20)
+
+// lineRing is a fixed-size, concurrency-safe buffer of the most recent lines
+type lineRing struct {
+	mu    sync.Mutex
+	lines []string
+	size  int
+}
+
+func newLineRing(size int) *lineRing {
+	return &lineRing{size: size}
+}
+
+// Add appends a line, dropping the oldest when full
+func (r *lineRing) Add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, line)
+	if len(r.lines) > r.size {
+		r.lines = r.lines[len(r.lines)-r.size:]
+	}
+}
+
+// Lines returns a copy of the buffered lines
+func (r *lineRing) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+// failureHint maps a substring of the error or agent output to a suggested fix
+type failureHint struct {
+	match string
+	hint  string
+}
+
+var failureHints = []failureHint{
+	{"executable file not found", "The agent CLI (or pwsh, with execution.mode: pwsh) is not on PATH. Install it or pass --agent to pick an installed one."},
+	{"not installed or not in path", "Install the selected agent CLI or choose another with --agent."},
+	{"no supported agent cli found", "Install an agent CLI, or export OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY to use --agent api."},
+	{"no api key set", "Export OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY, or name the variable in api.api_key_env."},
+	{"rate limit", "The provider is rate limiting requests. Wait a few minutes or switch --model."},
+	{"429", "The provider returned HTTP 429 (rate limited). Wait a few minutes or switch --model."},
+	{"unauthorized", "The agent CLI is not authenticated. Log in with the agent CLI before re-running."},
+	{"401", "The agent CLI is not authenticated. Log in with the agent CLI before re-running."},
+	{"quota", "The account quota appears exhausted. Check your plan or switch --agent/--model."},
+	{"context length", "The prompt exceeded the model context. Try a model with a larger context window or --sectioned synthesis."},
+	{"planner did not create", "The planner finished without writing the research plan. Check the agent output above; the agent may lack file-write permissions."},
+	{"synthesizer did not create", "The synthesizer finished without writing the report. Try --sectioned to produce the report incrementally."},
+	{"cannot load prompts", "Point --prompts-dir (or DEEPRESEARCH_PROMPTS) at an existing directory of prompt files, or unset it to use the built-in prompts."},
+	{"reached the budget", "The estimated spend reached --budget. Raise --budget (cost.budget_usd) and continue with --resume, or use --on-budget synthesize to get a report from the findings so far."},
+	{"cannot connect to the docker daemon", "--sandbox docker needs a running Docker daemon. Start Docker, or run without --sandbox."},
+	{"unable to find image", "The --sandbox-image image is not available. Build or pull it, or check its name in sandbox.image."},
+	{"sandbox egress proxy", "The egress proxy of --sandbox-allow did not start. Its image (sandbox.proxy_image, default sandbox.image) must contain deepresearch."},
+	{"exit status", "The agent exited with an error. Re-run the agent command manually in this directory to see its full error output."},
+}
+
+// suggestFixes returns hints matching the error message or the recent agent output
+func suggestFixes(msg string, tail []string) []string {
+	haystack := strings.ToLower(msg + "\n" + strings.Join(tail, "\n"))
+	var hints []string
+	seen := map[string]bool{}
+	for _, h := range failureHints {
+		if strings.Contains(haystack, h.match) && !seen[h.hint] {
+			seen[h.hint] = true
+			hints = append(hints, h.hint)
+		}
+	}
+	if len(hints) == 0 {
+		hints = append(hints, "Inspect logs/orchestrator.log and the agent output excerpt above, then re-run.")
+	}
+	return hints
+}
+
+// writeFailureReport writes failure.md summarizing a fatal error with code for
+// debugging, and failure.json for automation; status is the exit status of
+// the run. It is a no-op before the run manifest exists (e.g. invalid flags).
+func writeFailureReport(code, msg string, status int) {
+	if manifest == nil {
+		return
+	}
+	tail := agentOutputTail.Lines()
+	phase := manifest.failedPhase()
+	recovery := failureRecoveryFor(code, phase)
+	hints := suggestFixes(msg, tail)
+	agentLog := failedAgentLog(phase)
+	writeFailureJSON(code, msg, status, phase, agentLog, recovery, hints)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Research Run Failure\n\n")
+	fmt.Fprintf(&b, "- **Error**: %s\n", msg)
+	fmt.Fprintf(&b, "- **Code**: %s (exit status %d)\n", code, status)
+	failedPhase := "(before first phase)"
+	iteration := 0
+	if phase != nil {
+		failedPhase = phase.Name
+		iteration = phase.Iteration
+	} else if n := len(manifest.Phases); n > 0 {
+		failedPhase = "(after " + manifest.Phases[n-1].Name + ")"
+	}
+	fmt.Fprintf(&b, "- **Phase**: %s\n", failedPhase)
+	if iteration > 0 {
+		fmt.Fprintf(&b, "- **Iteration**: %d\n", iteration)
+	}
+	if agentLog != "" {
+		fmt.Fprintf(&b, "- **Agent log**: %s\n", agentLog)
+	}
+	fmt.Fprintf(&b, "- **Time**: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- **Run ID**: %s\n\n", manifest.RunID)
+
+	fmt.Fprintf(&b, "## Suggested Fixes\n\n")
+	if recovery.Command != "" {
+		fmt.Fprintf(&b, "- Recovery (%s): `%s`\n", recovery.Action, recovery.Command)
+	}
+	for _, h := range hints {
+		fmt.Fprintf(&b, "- %s\n", h)
+	}
+
+	fmt.Fprintf(&b, "\n## Last Agent Output\n\n")
+	if !config.Privacy.PersistTranscripts || config.Privacy.Ephemeral {
+		fmt.Fprintf(&b, "(omitted by privacy policy)\n")
+	} else if len(tail) == 0 {
+		fmt.Fprintf(&b, "(no agent output captured)\n")
+	} else {
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.Join(tail, "\n"))
+	}
+
+	fmt.Fprintf(&b, "\n## Recent Log Lines\n\n")
+	if lines := tailFile(filepath.Join(manifest.WorkDir, "logs", "orchestrator.log"), 20); len(lines) > 0 {
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.Join(lines, "\n"))
+	} else {
+		fmt.Fprintf(&b, "(log unavailable)\n")
+	}
+
+	fmt.Fprintf(&b, "\n## Environment\n\n")
+	fmt.Fprintf(&b, "| Item | Value |\n|------|-------|\n")
+	fmt.Fprintf(&b, "| Orchestrator | %s |\n", version)
+	fmt.Fprintf(&b, "| Go | %s |\n", runtime.Version())
+	fmt.Fprintf(&b, "| OS/Arch | %s/%s |\n", runtime.GOOS, runtime.GOARCH)
+	agentVer := manifest.Versions[manifest.Agent]
+	if agentVer == "" {
+		agentVer = "unknown"
+	}
+	fmt.Fprintf(&b, "| Agent | %s (%s) |\n", manifest.Agent, agentVer)
+	if manifest.Model != "" {
+		fmt.Fprintf(&b, "| Model | %s |\n", manifest.Model)
+	}
+	fmt.Fprintf(&b, "| Execution mode | %s |\n", config.Execution.Mode)
+	if config.Execution.Mode == execPwsh {
+		_, pwshErr := exec.LookPath("pwsh")
+		fmt.Fprintf(&b, "| pwsh available | %v |\n", pwshErr == nil)
+	}
+	if manifest.Sandbox != "" {
+		fmt.Fprintf(&b, "| Sandbox | %s |\n", manifest.Sandbox)
+	}
+	fmt.Fprintf(&b, "| Working directory | %s |\n", manifest.WorkDir)
+	fmt.Fprintf(&b, "| Prompts directory | %s |\n", manifest.PromptsDir)
+
+	path := filepath.Join(manifest.WorkDir, failureFileName)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err == nil {
+		fmt.Printf("%s[INFO]%s Failure details written to: %s\n", colorBlue, colorReset, path)
+	}
+}
+
+// failedAgentLog is the agent log of the failed phase, relative to the
+// workspace, or "" if there is none or the privacy policy removes it
+func failedAgentLog(phase *phaseRecord) string {
+	if phase == nil || !config.Privacy.PersistTranscripts || config.Privacy.Ephemeral {
+		return ""
+	}
+	path := agentLogPath(manifest.WorkDir, phase.Name, phase.Iteration)
+	if !fileExists(path) {
+		return ""
+	}
+	return "logs/" + filepath.Base(path)
+}
+
+// tailFile returns the last n lines of a text file
+func tailFile(path string, n int) []string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// Recovery actions failure.json suggests
+const (
+	recoverResume       = "resume"        // continue the run with --resume, e.g. after an agent failed once
+	recoverRetryLater   = "retry-later"   // wait for the provider's rate limit, then resume
+	recoverRaiseBudget  = "raise-budget"  // resume with a higher --budget
+	recoverRaiseTimeout = "raise-timeout" // resume with a longer --phase-timeout
+	recoverInstallAgent = "install-agent" // install the agent CLI, or pick another with --agent
+	recoverFixInput     = "fix-input"     // correct the flags, config or input and start again
+	recoverInspect      = "inspect"       // read failure.md and the logs; re-running alone is unlikely to help
+)
+
+// failureRecovery is what to do about a failure
+type failureRecovery struct {
+	Action string `json:"action"`
+	// Command carries the action out, where a command line does: resuming the
+	// run, with a raised limit where one was hit
+	Command string `json:"command,omitempty"`
+}
+
+// failureRecoveryFor suggests the recovery of a failure with code in phase
+// (nil outside one)
+func failureRecoveryFor(code string, phase *phaseRecord) failureRecovery {
+	resume := ""
+	if fileExists(statePath(manifest.WorkDir)) {
+		dir := manifest.WorkDir
+		if strings.ContainsAny(dir, " \t'\"") {
+			dir = fmt.Sprintf("%q", dir)
+		}
+		resume = "deepresearch --resume --workdir " + dir
+	}
+	switch code {
+	case codeAgentNotFound:
+		return failureRecovery{Action: recoverInstallAgent}
+	case codeValidationFailed:
+		return failureRecovery{Action: recoverFixInput}
+	case codeBudgetExceeded:
+		if resume != "" && config.Cost.BudgetUSD > 0 {
+			resume += fmt.Sprintf(" --budget %g", 2*config.Cost.BudgetUSD)
+		}
+		return failureRecovery{Action: recoverRaiseBudget, Command: resume}
+	case codeAgentTimeout:
+		if resume != "" && phase != nil {
+			if t := config.Limits.timeout(phase.Name); t > 0 {
+				resume += fmt.Sprintf(" --phase-timeout %d", 2*int(t.Minutes()))
+			}
+		}
+		return failureRecovery{Action: recoverRaiseTimeout, Command: resume}
+	case codeRateLimited:
+		return failureRecovery{Action: recoverRetryLater, Command: resume}
+	}
+	if phase == nil || resume == "" {
+		return failureRecovery{Action: recoverInspect}
+	}
+	return failureRecovery{Action: recoverResume, Command: resume}
+}
+
+// failureReport is failure.json: what failed, where, and what to do about it
+type failureReport struct {
+	RunID      string `json:"run_id"`
+	ErrorCode  string `json:"error_code"`
+	ExitStatus int    `json:"exit_status"`
+	Error      string `json:"error"`
+	Phase      string `json:"phase,omitempty"` // that failed; empty outside a phase
+	Iteration  int    `json:"iteration,omitempty"`
+	Agent      string `json:"agent,omitempty"`
+	Model      string `json:"model,omitempty"`
+	AgentLog   string `json:"agent_log,omitempty"` // the output of the phase's agents, e.g. logs/reflector-1.log
+	// StderrTail is what the agents last wrote to stderr, unless the privacy
+	// policy keeps agent output
+	StderrTail []string        `json:"agent_stderr_tail"`
+	Recovery   failureRecovery `json:"recovery"`
+	Hints      []string        `json:"hints"`
+	Time       string          `json:"time"`
+}
+
+// writeFailureJSON writes failure.json
+func writeFailureJSON(code, msg string, status int, phase *phaseRecord, agentLog string, recovery failureRecovery, hints []string) {
+	rep := failureReport{
+		RunID:      manifest.RunID,
+		ErrorCode:  code,
+		ExitStatus: status,
+		Error:      msg,
+		Agent:      manifest.Agent,
+		Model:      manifest.Model,
+		AgentLog:   agentLog,
+		StderrTail: []string{},
+		Recovery:   recovery,
+		Hints:      hints,
+		Time:       time.Now().Format(time.RFC3339),
+	}
+	if phase != nil {
+		rep.Phase, rep.Iteration = phase.Name, phase.Iteration
+		rep.Agent, rep.Model = manifest.phaseAgent(*phase)
+	}
+	if config.Privacy.PersistTranscripts && !config.Privacy.Ephemeral {
+		if tail := agentStderrTail.Lines(); len(tail) > 0 {
+			rep.StderrTail = tail
+		}
+	}
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(manifest.WorkDir, failureJSONFileName), data, 0644)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// maxFigures caps how many images the synthesizer is asked to embed
+const maxFigures = 8
+
+// imageExts are the image formats that render in Markdown and the HTML exports
+var imageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true,
+}
+
+// imageRefRe matches a Markdown image and captures its path
+var imageRefRe = regexp.MustCompile(`!\[[^\]]*\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+
+// figureCandidate is an archived image with the attribution resolved from its source
+type figureCandidate struct {
+	Path     string
+	SourceID string
+	URL      string
+	Title    string
+	License  string
+}
+
+// figureCandidates returns the images under assets/images with their attribution.
+// Executors name images {source_id}_{description}.png, so unregistered images are
+// linked to a source by file-name prefix.
+func figureCandidates(m *assetManifest) []figureCandidate {
+	if m == nil {
+		return nil
+	}
+	sources := map[string]assetEntry{}
+	for _, a := range m.Assets {
+		if a.SourceID != "" && !imageExts[strings.ToLower(filepath.Ext(a.Path))] {
+			sources[a.SourceID] = a
+		}
+	}
+	var figures []figureCandidate
+	for _, a := range m.Assets {
+		if a.Kind != "images" || !imageExts[strings.ToLower(filepath.Ext(a.Path))] {
+			continue
+		}
+		f := figureCandidate{Path: a.Path, SourceID: a.SourceID, URL: a.URL, Title: a.Title}
+		if f.SourceID == "" {
+			if prefix, _, ok := strings.Cut(filepath.Base(a.Path), "_"); ok && sourceIDRe.MatchString(prefix) {
+				f.SourceID = prefix
+			}
+		}
+		if src, ok := sources[f.SourceID]; ok {
+			if f.URL == "" {
+				f.URL = src.URL
+			}
+			if f.Title == "" {
+				f.Title = src.Title
+			}
+			if src.License != nil {
+				f.License = src.License.Name
+				if src.License.Restrictive {
+					f.License += " (restrictive)"
+				}
+			}
+		}
+		figures = append(figures, f)
+	}
+	return figures
+}
+
+// buildFiguresNote tells the synthesizer which archived images it may embed and
+// how to caption and attribute them, or "" if there are none
+func buildFiguresNote(m *assetManifest) string {
+	figures := figureCandidates(m)
+	if len(figures) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+FIGURES: These images were archived during research. Select up to %d that directly support a
+finding (charts, diagrams, tables, key screenshots) and embed them where that finding is discussed:
+
+    ![Figure N: <short caption>](<path>)
+    *Figure N: <one-sentence caption describing what it shows>. Source: [<ID>](<URL>)<, license>.*
+
+Number figures in order of appearance, use the exact relative paths below, and skip decorative,
+duplicate or unreadable images. Images from sources marked restrictive may only be embedded if
+essential; prefer describing them in text.
+`, maxFigures)
+	for _, f := range figures {
+		id := f.SourceID
+		if id == "" {
+			id = "(unregistered)"
+		}
+		line := fmt.Sprintf("- %s — %s", f.Path, id)
+		if f.URL != "" {
+			line += " " + f.URL
+		}
+		if f.Title != "" {
+			line += fmt.Sprintf(" %q", f.Title)
+		}
+		if f.License != "" {
+			line += " [" + f.License + "]"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// checkFigures warns about images in report.md that do not exist on disk or
+// lack a source citation in their caption
+func checkFigures(workDir string) []string {
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(report), "\n")
+	var warnings []string
+	for i, line := range lines {
+		for _, ref := range imageRefRe.FindAllStringSubmatch(line, -1) {
+			path := ref[1]
+			if strings.Contains(path, "://") || strings.HasPrefix(path, "data:") {
+				continue
+			}
+			if !fileExists(filepath.Join(workDir, filepath.FromSlash(path))) {
+				warnings = append(warnings, fmt.Sprintf("Report embeds missing image: %s", path))
+				continue
+			}
+			caption := line
+			if i+1 < len(lines) {
+				caption += lines[i+1]
+			}
+			if !citationRe.MatchString(caption) && !strings.Contains(caption, "Source:") {
+				warnings = append(warnings, fmt.Sprintf("Figure %s has no source attribution in its caption", path))
+			}
+		}
+	}
+	return warnings
+}
+
+// synthesisNotes returns the language, asset and citation notes appended to synthesizer prompts
+func synthesisNotes(workDir string) string {
+	notes := orchestrator.ReportLanguageNote(config.Report.language()) + buildCitationMetadataNote(workDir) + buildContextNote(workDir) + buildReplayNote()
+	if m, err := loadAssetManifest(workDir); err == nil {
+		notes = buildTranslationsNote(m) + buildFiguresNote(m) + notes
+	}
+	return notes
+}
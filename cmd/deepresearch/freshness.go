@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// freshnessConfig controls source staleness warnings
+type freshnessConfig struct {
+	MaxAgeDays int    `yaml:"max_age_days"` // sources older than this are stale on fast-moving topics
+	FastMoving string `yaml:"fast_moving"`  // auto (detect from topic), always, never
+}
+
+// datePatterns extract a publication or last-modified date from archived content,
+// most authoritative first
+var datePatterns = []struct {
+	re   *regexp.Regexp
+	from string
+}{
+	{regexp.MustCompile(`(?i)(?:article:published_time|datePublished|citation_publication_date|dc\.date|pubdate)["']?\s*(?:content=|:)\s*["']?(\d{4}[-/]\d{2}[-/]\d{2})`), "published metadata"},
+	{regexp.MustCompile(`(?i)(?:article:modified_time|dateModified|last-modified)["']?\s*(?:content=|:)\s*["']?(\d{4}[-/]\d{2}[-/]\d{2})`), "modified metadata"},
+	{regexp.MustCompile(`(?i)<time[^>]+datetime=["'](\d{4}-\d{2}-\d{2})`), "time element"},
+	{regexp.MustCompile(`(?im)^(?:published|date|updated)\s*:\s*["']?(\d{4}-\d{2}-\d{2})`), "frontmatter"},
+	{regexp.MustCompile(`/CreationDate\s*\(D:(\d{8})`), "pdf metadata"},
+}
+
+// fileYearRe matches the year component of asset names such as smith_ai_agents_2023.pdf
+var fileYearRe = regexp.MustCompile(`[_-]((?:19|20)\d{2})\.[A-Za-z0-9]+$`)
+
+// fastMovingKeywords mark topics where stale sources can invalidate conclusions
+var fastMovingKeywords = []string{
+	"ai", "llm", "gpt", "model", "agent", "market", "price", "pricing", "stock", "crypto",
+	"latest", "current", "trend", "regulation", "policy", "election", "security", "vulnerability",
+	"startup", "funding", "benchmark", "release", "version", "2024", "2025", "2026",
+}
+
+// detectPublished returns the publication (or last-modified) date of an asset and how it was found
+func detectPublished(path string) (time.Time, string) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if len(data) > 512*1024 {
+			data = data[:512*1024]
+		}
+		for _, p := range datePatterns {
+			m := p.re.FindSubmatch(data)
+			if m == nil {
+				continue
+			}
+			s := strings.ReplaceAll(string(m[1]), "/", "-")
+			layout := "2006-01-02"
+			if len(s) == 8 {
+				layout = "20060102"
+			}
+			if t, err := time.Parse(layout, s); err == nil && t.Before(time.Now().Add(48*time.Hour)) {
+				return t, p.from
+			}
+		}
+	}
+	if m := fileYearRe.FindStringSubmatch(filepath.Base(path)); m != nil {
+		if t, err := time.Parse("2006", m[1]); err == nil {
+			return t, "file name"
+		}
+	}
+	return time.Time{}, ""
+}
+
+// isFastMovingTopic decides whether staleness warnings apply to the topic
+func isFastMovingTopic(topic string) bool {
+	switch config.Freshness.FastMoving {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	words := strings.FieldsFunc(strings.ToLower(topic), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, w := range words {
+		for _, k := range fastMovingKeywords {
+			if w == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// freshnessProfile summarizes the age distribution of the sources a report relies on
+type freshnessProfile struct {
+	Total      int
+	UnderYear  int
+	OneToThree int
+	Older      int
+	Unknown    int
+	MedianAge  time.Duration
+	Stale      []assetEntry
+}
+
+// buildFreshnessProfile computes the age profile of the sources cited in report.md
+// (all registered sources if the report cites none)
+func buildFreshnessProfile(workDir string, m *assetManifest) freshnessProfile {
+	var p freshnessProfile
+	if m == nil {
+		return p
+	}
+	cited := map[string]bool{}
+	if report, err := os.ReadFile(reportFilePath(workDir)); err == nil {
+		for _, c := range citationRe.FindAllStringSubmatch(string(report), -1) {
+			cited[c[1]] = true
+		}
+	}
+
+	maxAge := time.Duration(config.Freshness.MaxAgeDays) * 24 * time.Hour
+	var ages []time.Duration
+	for _, a := range m.Assets {
+		if a.SourceID == "" || (len(cited) > 0 && !cited[a.SourceID]) {
+			continue
+		}
+		p.Total++
+		if a.Published == "" {
+			p.Unknown++
+			continue
+		}
+		t, err := time.Parse("2006-01-02", a.Published)
+		if err != nil {
+			p.Unknown++
+			continue
+		}
+		age := time.Since(t)
+		ages = append(ages, age)
+		switch {
+		case age < 365*24*time.Hour:
+			p.UnderYear++
+		case age < 3*365*24*time.Hour:
+			p.OneToThree++
+		default:
+			p.Older++
+		}
+		if maxAge > 0 && age > maxAge {
+			p.Stale = append(p.Stale, a)
+		}
+	}
+	if len(ages) > 0 {
+		sort.Slice(ages, func(i, j int) bool { return ages[i] < ages[j] })
+		p.MedianAge = ages[len(ages)/2]
+	}
+	return p
+}
+
+// String renders the profile as a single line
+func (p freshnessProfile) String() string {
+	s := fmt.Sprintf("%d <1y, %d 1-3y, %d >3y, %d undated", p.UnderYear, p.OneToThree, p.Older, p.Unknown)
+	if p.MedianAge > 0 {
+		s += fmt.Sprintf(" (median age %d months)", int(p.MedianAge.Hours()/24/30))
+	}
+	return s
+}
+
+// reportFreshness prints and logs the freshness profile and warns about stale
+// sources on fast-moving topics
+func reportFreshness(workDir, topic string, m *assetManifest) {
+	p := buildFreshnessProfile(workDir, m)
+	if p.Total == 0 {
+		return
+	}
+	info("Source freshness: %s", p)
+	logEntry("INFO", "FRESHNESS", 0, "Source freshness profile", map[string]string{
+		"under_1y": fmt.Sprintf("%d", p.UnderYear),
+		"1y_3y":    fmt.Sprintf("%d", p.OneToThree),
+		"over_3y":  fmt.Sprintf("%d", p.Older),
+		"undated":  fmt.Sprintf("%d", p.Unknown),
+		"stale":    fmt.Sprintf("%d", len(p.Stale)),
+	})
+	if len(p.Stale) == 0 || !isFastMovingTopic(topic) {
+		return
+	}
+	var ids []string
+	for _, a := range p.Stale {
+		ids = append(ids, fmt.Sprintf("%s (%s)", a.SourceID, a.Published))
+	}
+	warn("Fast-moving topic relies on %d source(s) older than %d days: %s",
+		len(p.Stale), config.Freshness.MaxAgeDays, strings.Join(ids, ", "))
+}
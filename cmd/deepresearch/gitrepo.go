@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitConfig versions the workflow's artifacts with a git commit after each
+// phase, so git diff shows what every phase changed
+type gitConfig struct {
+	Enabled bool `yaml:"enabled"` // same as --git
+	// Paths are further files or directories of the workspace to commit, e.g.
+	// [sections, logs/handoffs], besides the task file, the report, its
+	// translations and the asset manifest
+	Paths []string `yaml:"paths"`
+}
+
+// gitIdentity commits in repositories without a configured user
+var gitIdentity = []string{"-c", "user.name=deepresearch", "-c", "user.email=deepresearch@localhost"}
+
+// workspaceRepo commits the artifacts with --git; nil without
+var workspaceRepo *gitRepo
+
+// gitRepo is the git repository the workspace is versioned in: the one it
+// lies in, or else a new one in the workspace itself
+type gitRepo struct {
+	git     string   // path of the git CLI
+	workDir string   // the workspace; pathspecs are relative to it
+	top     string   // top-level directory of the repository
+	config  []string // -c options every command gets, e.g. gitIdentity
+}
+
+// openGitRepo finds the repository of workDir, initializing one in workDir if
+// it lies in none or in one that ignores it
+func openGitRepo(workDir string) (*gitRepo, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git is not installed or not in PATH")
+	}
+	r := &gitRepo{git: git, workDir: workDir}
+	top, err := r.output("rev-parse", "--show-toplevel")
+	if err != nil || r.run("check-ignore", "-q", ".") == nil {
+		if err := r.run("init", "-q"); err != nil {
+			return nil, err
+		}
+		info("Initialized a git repository in %s", workDir)
+		if top, err = r.output("rev-parse", "--show-toplevel"); err != nil {
+			return nil, err
+		}
+	}
+	r.top = top
+	if email, _ := r.output("config", "user.email"); email == "" {
+		r.config = gitIdentity
+	}
+	return r, nil
+}
+
+// artifacts are the paths of the versioned files that exist, relative to the
+// workspace
+func (r *gitRepo) artifacts() []string {
+	paths := []string{taskFileName(r.workDir), reportFileName(r.workDir), assetsDirName(r.workDir) + "/" + assetManifestName}
+	paths = append(paths, translatedReports(r.workDir)...)
+	paths = append(paths, config.Git.Paths...)
+	var existing []string
+	for _, p := range paths {
+		if fileExists(filepath.Join(r.workDir, filepath.FromSlash(p))) {
+			existing = append(existing, p)
+		}
+	}
+	return existing
+}
+
+// commit commits the artifacts, and only them, with message, returning the
+// short hash of the commit or "" when none of them changed
+func (r *gitRepo) commit(message string) (string, error) {
+	paths := r.artifacts()
+	if len(paths) == 0 {
+		return "", nil
+	}
+	if err := r.run(append([]string{"add", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	if r.run(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...) == nil {
+		return "", nil
+	}
+	// With pathspecs, git commits those paths alone, whatever else is staged
+	if err := r.run(append([]string{"commit", "-q", "--no-verify", "-m", message, "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return r.output("rev-parse", "--short", "HEAD")
+}
+
+// run runs a git command in the workspace, returning its output as the error
+// if it fails
+func (r *gitRepo) run(args ...string) error {
+	_, err := r.output(args...)
+	return err
+}
+
+// output runs a git command in the workspace and returns its trimmed output
+func (r *gitRepo) output(args ...string) (string, error) {
+	cmd := exec.Command(r.git, append(append([]string(nil), r.config...), args...)...)
+	cmd.Dir = r.workDir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// phaseCommitMessage describes the phase p ran by agentName with model: a
+// subject naming it, and trailers git log --grep and
+// git log --format=%(trailers) can select and show the commits by
+func phaseCommitMessage(p phaseRecord, agentName, model string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "deepresearch: %s", p.Name)
+	if p.Iteration > 0 {
+		fmt.Fprintf(&b, " (iteration %d)", p.Iteration)
+	}
+	fmt.Fprintf(&b, "\n\nRun-Id: %s\nPhase: %s\n", manifest.RunID, p.Name)
+	if p.Iteration > 0 {
+		fmt.Fprintf(&b, "Iteration: %d\n", p.Iteration)
+	}
+	fmt.Fprintf(&b, "Agent: %s\n", agentName)
+	if model != "" {
+		fmt.Fprintf(&b, "Model: %s\n", model)
+	}
+	fmt.Fprintf(&b, "Duration: %s\n", formatDuration(time.Duration(p.DurationMs)*time.Millisecond))
+	return b.String()
+}
+
+// commitPhase commits the artifacts as the phase that just completed left
+// them, with --git, and records the commit in the run manifest. A failed
+// commit is only a warning: the run goes on.
+func commitPhase() {
+	if workspaceRepo == nil || manifest == nil || len(manifest.Phases) == 0 {
+		return
+	}
+	p := &manifest.Phases[len(manifest.Phases)-1]
+	agentName, model := manifest.phaseAgent(*p)
+	hash, err := workspaceRepo.commit(phaseCommitMessage(*p, agentName, model))
+	if err != nil {
+		warn("Could not commit the artifacts of %s: %v", p.Name, err)
+		return
+	}
+	if hash == "" {
+		return
+	}
+	p.Commit = hash
+	manifest.save()
+	logEntry("INFO", "GIT_COMMIT", p.Iteration, "Committed the artifacts of "+p.Name, map[string]string{
+		"commit": hash,
+	})
+}
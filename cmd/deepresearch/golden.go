@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// goldenSuiteDir is where `deepresearch golden` looks for cases by default
+const goldenSuiteDir = "testdata/golden"
+
+// goldenArtifacts are the run outputs a golden case records, besides the
+// data/ CSV exports and the phase trace: the clarifier's questions and answers,
+// task.md, report.md and its translations where the run's layout put them, and
+// the bibliography
+func goldenArtifacts(workDir string) []string {
+	files := []string{orchestrator.ClarificationsFile, taskFileName(workDir), reportFileName(workDir)}
+	files = append(files, translatedReports(workDir)...)
+	return append(files, bibTeXFileName, cslJSONFileName)
+}
+
+// goldenPhasesFile is the phase trace of a case, derived from run-manifest.json
+const goldenPhasesFile = "phases.txt"
+
+// goldenCase is one recorded run under the suite directory:
+//
+//	<case>/prompt.txt   research request passed with -p
+//	<case>/args         extra run flags, whitespace-separated (optional)
+//	<case>/config.yaml  run config (optional; ~/.deepresearch is never read)
+//	<case>/stdin        the user's input to the run, e.g. plan review answers (optional)
+//	<case>/fixture/     mock agent steps, e.g. planner/, reflector-1/
+//	<case>/golden/      expected artifacts
+type goldenCase struct {
+	Name string
+	Dir  string
+}
+
+// runGolden implements `deepresearch golden`: runs the full pipeline against
+// the mock agent for every recorded case and compares the artifacts with the
+// golden files, so orchestration changes can be checked without live agents
+func runGolden(args []string) {
+	fs := flag.NewFlagSet("golden", flag.ExitOnError)
+	update := fs.Bool("update", false, "Rewrite the golden files from this run instead of comparing")
+	only := fs.String("run", "", "Only run cases whose name contains this string")
+	keep := fs.Bool("keep", false, "Keep the run workspaces for inspection")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: deepresearch golden [-update] [-run NAME] [suite dir (default %s)]\n", goldenSuiteDir)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	suite := goldenSuiteDir
+	if fs.NArg() > 0 {
+		suite = fs.Arg(0)
+	}
+
+	cases, err := goldenCases(suite, *only)
+	if err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	if len(cases) == 0 {
+		fatalCode(codeValidationFailed, "No golden cases in %s", suite)
+	}
+	promptsDir, err := findPromptsDir()
+	if err != nil {
+		fatal("Cannot load prompts: %v", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate the deepresearch executable: %v", err)
+	}
+
+	failed := 0
+	for _, c := range cases {
+		problems, err := runGoldenCase(c, exe, promptsDir, *update, *keep)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "%s%s%s %s: %v\n", colorRed, failMark, colorReset, c.Name, err)
+		case len(problems) > 0:
+			failed++
+			fmt.Fprintf(os.Stderr, "%s%s%s %s\n", colorRed, failMark, colorReset, c.Name)
+			for _, p := range problems {
+				fmt.Fprintf(os.Stderr, "    %s\n", p)
+			}
+		case *update:
+			info("Updated %s", filepath.Join(c.Dir, "golden"))
+		default:
+			success("%s", c.Name)
+		}
+	}
+	if failed > 0 {
+		fatalCode(codeValidationFailed, "%d of %d golden case(s) failed (rerun with -update if the change is intended)", failed, len(cases))
+	}
+	if *update {
+		success("Recorded %d golden case(s)", len(cases))
+		return
+	}
+	success("%d golden case(s) passed", len(cases))
+}
+
+// goldenCases lists the cases under suite, sorted by name
+func goldenCases(suite, only string) ([]goldenCase, error) {
+	entries, err := os.ReadDir(suite)
+	if err != nil {
+		return nil, err
+	}
+	var cases []goldenCase
+	for _, e := range entries {
+		dir := filepath.Join(suite, e.Name())
+		if !e.IsDir() || !fileExists(filepath.Join(dir, "prompt.txt")) || !strings.Contains(e.Name(), only) {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, goldenCase{Name: e.Name(), Dir: abs})
+	}
+	return cases, nil
+}
+
+// runGoldenCase runs one case in a scratch workspace with its own home directory
+// and returns how the artifacts differ from the golden files (or rewrites them
+// when update is set)
+func runGoldenCase(c goldenCase, exe, promptsDir string, update, keep bool) ([]string, error) {
+	prompt, err := os.ReadFile(filepath.Join(c.Dir, "prompt.txt"))
+	if err != nil {
+		return nil, err
+	}
+	runArgs := []string{"-in-place", "-agent", "mock", "-p", strings.TrimSpace(string(prompt)), "-salvage", salvageOff}
+	if cfg := filepath.Join(c.Dir, "config.yaml"); fileExists(cfg) {
+		runArgs = append(runArgs, "-config", cfg)
+	}
+	if extra, err := os.ReadFile(filepath.Join(c.Dir, "args")); err == nil {
+		runArgs = append(runArgs, strings.Fields(string(extra))...)
+	}
+
+	scratch, err := os.MkdirTemp("", "deepresearch-golden-")
+	if err != nil {
+		return nil, err
+	}
+	if keep {
+		info("%s: workspace kept in %s", c.Name, scratch)
+	} else {
+		defer os.RemoveAll(scratch)
+	}
+	workDir := filepath.Join(scratch, "work")
+	home := filepath.Join(scratch, "home")
+	bin := filepath.Join(scratch, "bin")
+	for _, dir := range []string{workDir, home, bin} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	if err := linkMockAgent(exe, bin); err != nil {
+		return nil, fmt.Errorf("cannot install the mock agent: %w", err)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(exe, runArgs...)
+	cmd.Dir = workDir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if input, err := os.ReadFile(filepath.Join(c.Dir, "stdin")); err == nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	cmd.Env = append(os.Environ(),
+		"HOME="+home,
+		"USERPROFILE="+home,
+		"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		mockFixtureEnv+"="+filepath.Join(c.Dir, "fixture"),
+		promptsEnv+"="+promptsDir,
+	)
+	runErr := cmd.Run()
+	os.WriteFile(filepath.Join(scratch, "run.log"), out.Bytes(), 0644)
+
+	status := 0
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		status = exitErr.ExitCode()
+	}
+	got, err := collectGoldenArtifacts(workDir, status)
+	if err != nil {
+		return nil, err
+	}
+	goldenDir := filepath.Join(c.Dir, "golden")
+	if update {
+		return nil, writeGoldenArtifacts(goldenDir, got)
+	}
+	want, err := readGoldenDir(goldenDir)
+	if err != nil {
+		return nil, fmt.Errorf("no golden files (run with -update to record them): %w", err)
+	}
+	problems := diffGoldenArtifacts(want, got)
+	if len(problems) > 0 && runErr != nil {
+		problems = append(problems, fmt.Sprintf("run exited with %v; last output:", runErr))
+		problems = append(problems, tailLines(out.String(), 15)...)
+	}
+	return problems, nil
+}
+
+// linkMockAgent makes exe available in bin as the mock agent command, copying
+// it where links are not permitted
+func linkMockAgent(exe, bin string) error {
+	name := mockAgentCommand
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	dst := filepath.Join(bin, name)
+	if os.Link(exe, dst) == nil || os.Symlink(exe, dst) == nil {
+		return nil
+	}
+	in, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// collectGoldenArtifacts reads the compared outputs of a run that exited with
+// status, with the scratch workspace path and line endings normalized
+func collectGoldenArtifacts(workDir string, status int) (map[string]string, error) {
+	got := map[string]string{}
+	normalize := func(data []byte) string {
+		s := strings.ReplaceAll(string(data), "\r\n", "\n")
+		s = strings.ReplaceAll(s, workDir, "{{WORKING_DIR}}")
+		return strings.ReplaceAll(s, filepath.ToSlash(workDir), "{{WORKING_DIR}}")
+	}
+	for _, name := range goldenArtifacts(workDir) {
+		if data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(name))); err == nil {
+			got[name] = normalize(data)
+		}
+	}
+	csvs, _ := filepath.Glob(filepath.Join(workDir, dataDirName, "*.csv"))
+	for _, path := range csvs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		got[dataDirName+"/"+filepath.Base(path)] = normalize(data)
+	}
+	m, err := loadManifest(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("the run wrote no %s: %w", manifestFileName, err)
+	}
+	got[goldenPhasesFile] = phaseTrace(m) + failureTrace(workDir, status)
+	return got, nil
+}
+
+// phaseTrace renders the timing-free part of a run manifest: which phases ran in
+// which iteration, how each ended and what the agents reported it used, why the
+// research loop ended and the run's outcome
+func phaseTrace(m *runManifest) string {
+	var b strings.Builder
+	for _, p := range m.Phases {
+		name := p.Name
+		if p.Iteration > 0 {
+			name = fmt.Sprintf("%s #%d", p.Name, p.Iteration)
+		}
+		fmt.Fprintf(&b, "%s: %s", name, p.Status)
+		if p.Usage != nil {
+			fmt.Fprintf(&b, " (%d tokens, $%.2f)", p.Usage.tokens(), p.Usage.CostUSD)
+		}
+		b.WriteString("\n")
+	}
+	if m.Loop != nil {
+		fmt.Fprintf(&b, "research loop: %s after iteration %d\n", m.Loop.Reason, m.Loop.Iteration)
+	}
+	fmt.Fprintf(&b, "outcome: %s\n", m.Outcome)
+	if m.ErrorCode != "" {
+		fmt.Fprintf(&b, "error_code: %s\n", m.ErrorCode)
+	}
+	return b.String()
+}
+
+// failureTrace renders how a failed run exited and the recovery its
+// failure.json suggests; nothing for a run that exited with 0
+func failureTrace(workDir string, status int) string {
+	if status == 0 {
+		return ""
+	}
+	trace := fmt.Sprintf("exit status: %d\n", status)
+	var rep failureReport
+	if data, err := os.ReadFile(filepath.Join(workDir, failureJSONFileName)); err == nil && json.Unmarshal(data, &rep) == nil {
+		trace += fmt.Sprintf("recovery: %s\n", rep.Recovery.Action)
+	}
+	return trace
+}
+
+// readGoldenDir reads every file under dir, keyed by slash-separated path
+func readGoldenDir(dir string) (map[string]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	want := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		want[filepath.ToSlash(rel)] = strings.ReplaceAll(string(data), "\r\n", "\n")
+		return nil
+	})
+	return want, err
+}
+
+// writeGoldenArtifacts replaces the golden files in dir with got
+func writeGoldenArtifacts(dir string, got map[string]string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for rel, content := range got {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffGoldenArtifacts describes missing, unexpected and changed artifacts,
+// showing the first differing line of each changed file
+func diffGoldenArtifacts(want, got map[string]string) []string {
+	names := map[string]bool{}
+	for name := range want {
+		names[name] = true
+	}
+	for name := range got {
+		names[name] = true
+	}
+	var problems []string
+	for _, name := range sortedKeys(names) {
+		w, inWant := want[name]
+		g, inGot := got[name]
+		switch {
+		case !inGot:
+			problems = append(problems, fmt.Sprintf("%s: not produced", name))
+		case !inWant:
+			problems = append(problems, fmt.Sprintf("%s: produced but not in golden/", name))
+		case w != g:
+			problems = append(problems, fmt.Sprintf("%s: %s", name, firstDiff(w, g)))
+		}
+	}
+	return problems
+}
+
+// firstDiff locates the first line where got departs from want
+func firstDiff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g || i >= len(wl) || i >= len(gl) {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, w, g)
+		}
+	}
+	return "differs"
+}
+
+// tailLines returns the last n non-empty lines of s
+func tailLines(s string, n int) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
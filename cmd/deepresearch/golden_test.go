@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata/golden from this run")
+
+// TestGolden runs every case under testdata/golden against the mock agent, as
+// `deepresearch golden` does, with a binary built from this tree
+func TestGolden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the binary and runs the full pipeline")
+	}
+	cases, err := goldenCases(goldenSuiteDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("no golden cases in %s", goldenSuiteDir)
+	}
+	exe := filepath.Join(t.TempDir(), "deepresearch")
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	if out, err := exec.Command("go", "build", "-o", exe, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	promptsDir, err := findPromptsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			problems, err := runGoldenCase(c, exe, promptsDir, *updateGolden, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				t.Error(p)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/researchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative researchpb/research.proto
+
+// artifactChunkSize is the payload size of one GetArtifact message
+const artifactChunkSize = 64 << 10
+
+// tenantKey carries the authenticated tenant in a gRPC request context
+type tenantKey struct{}
+
+// researchService implements the gRPC API on top of the job server
+type researchService struct {
+	researchpb.UnimplementedResearchServer
+	s *jobServer
+}
+
+// workersService brokers executor tasks between research runs and remote
+// workers, with a separate queue per tenant
+type workersService struct {
+	researchpb.UnimplementedWorkersServer
+	mu      sync.Mutex
+	brokers map[string]*memoryBroker
+}
+
+// broker returns the task queue of the caller's tenant
+func (w *workersService) broker(ctx context.Context) *memoryBroker {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	name := tenantFrom(ctx).Name
+	b, ok := w.brokers[name]
+	if !ok {
+		b = newMemoryBroker()
+		w.brokers[name] = b
+	}
+	return b
+}
+
+func (w *workersService) Dispatch(ctx context.Context, t *researchpb.Task) (*researchpb.TaskResult, error) {
+	if t.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "task id is required")
+	}
+	r, err := w.broker(ctx).Dispatch(ctx, t)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return r, nil
+}
+
+func (w *workersService) Pull(ctx context.Context, req *researchpb.PullRequest) (*researchpb.Task, error) {
+	wait := time.Duration(req.WaitSeconds) * time.Second
+	if wait <= 0 || wait > time.Minute {
+		wait = time.Minute
+	}
+	t, err := w.broker(ctx).Next(ctx, req.Worker, wait)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if t == nil {
+		return &researchpb.Task{}, nil
+	}
+	return t, nil
+}
+
+func (w *workersService) Complete(ctx context.Context, r *researchpb.TaskResult) (*researchpb.CompleteResponse, error) {
+	if err := w.broker(ctx).Complete(ctx, r); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &researchpb.CompleteResponse{}, nil
+}
+
+// serveGRPC serves the Research and Workers services on addr, authenticating every call the
+// same way as the REST API (an "authorization: Bearer <token>" metadata entry)
+func (s *jobServer) serveGRPC(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxTaskMessage),
+		grpc.MaxSendMsgSize(maxTaskMessage),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			ctx, err := s.grpcAuth(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return h(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			ctx, err := s.grpcAuth(ss.Context())
+			if err != nil {
+				return err
+			}
+			return h(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+	researchpb.RegisterResearchServer(srv, &researchService{s: s})
+	researchpb.RegisterWorkersServer(srv, &workersService{brokers: map[string]*memoryBroker{}})
+	return srv.Serve(lis)
+}
+
+// grpcAuth resolves the caller's tenant from the request metadata
+func (s *jobServer) grpcAuth(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var authorization string
+	if v := md.Get("authorization"); len(v) > 0 {
+		authorization = v[0]
+	}
+	t := s.authenticate(authorization)
+	if t == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid API token")
+	}
+	return context.WithValue(ctx, tenantKey{}, t), nil
+}
+
+// tenantStream overrides a server stream's context with the authenticated one
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (t *tenantStream) Context() context.Context { return t.ctx }
+
+func tenantFrom(ctx context.Context) *tenant {
+	t, _ := ctx.Value(tenantKey{}).(*tenant)
+	return t
+}
+
+// grpcError maps an apiError's HTTP status to a gRPC status
+func grpcError(err error) error {
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code := codes.Internal
+	switch ae.Status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, ae.Msg)
+}
+
+func jobProto(j *job) *researchpb.Job {
+	return &researchpb.Job{
+		Id:         j.ID,
+		Tenant:     j.Tenant,
+		Prompt:     j.Prompt,
+		Status:     j.Status,
+		Attempts:   int32(j.Attempts),
+		ErrorCode:  j.ErrorCode,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+		Priority:   int32(j.Priority),
+		Tokens:     int64(j.Tokens),
+	}
+}
+
+func (g *researchService) SubmitResearch(ctx context.Context, req *researchpb.SubmitResearchRequest) (*researchpb.Job, error) {
+	j, err := g.s.submit(tenantFrom(ctx), jobRequest{Prompt: req.Prompt, Agent: req.Agent, Model: req.Model, Flags: req.Flags, Priority: int(req.Priority)})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobProto(j), nil
+}
+
+func (g *researchService) GetJob(ctx context.Context, req *researchpb.GetJobRequest) (*researchpb.Job, error) {
+	j, err := g.s.lookup(tenantFrom(ctx), req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobProto(j), nil
+}
+
+func (g *researchService) Cancel(ctx context.Context, req *researchpb.CancelRequest) (*researchpb.Job, error) {
+	j, err := g.s.cancel(tenantFrom(ctx), req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobProto(j), nil
+}
+
+func (g *researchService) StreamEvents(req *researchpb.StreamEventsRequest, stream grpc.ServerStreamingServer[researchpb.Event]) error {
+	ctx := stream.Context()
+	j, err := g.s.lookup(tenantFrom(ctx), req.Id)
+	if err != nil {
+		return grpcError(err)
+	}
+	path := filepath.Join(j.Dir, "logs", "events.jsonl")
+	var offset int64
+	for {
+		// Check the state before reading so the final events are always sent
+		cur, err := g.s.store.Get(j.ID)
+		finished := err == nil && jobFinished(cur.Status)
+		if offset, err = sendEvents(path, offset, stream); err != nil {
+			return err
+		}
+		if !req.Follow || finished {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// sendEvents streams the events of events.jsonl after offset and returns the
+// new offset
+func sendEvents(path string, offset int64, stream grpc.ServerStreamingServer[researchpb.Event]) (int64, error) {
+	lines, next, err := readEventLines(path, offset)
+	if err != nil {
+		return offset, err
+	}
+	for _, line := range lines {
+		var ev runEvent
+		if json.Unmarshal(line, &ev) != nil {
+			continue
+		}
+		err := stream.Send(&researchpb.Event{
+			Ts: ev.Time, Level: ev.Level, Type: ev.Type, Iteration: int32(ev.Iteration),
+			Message: ev.Message, Code: ev.Code, Fields: eventFields(ev),
+		})
+		if err != nil {
+			return offset, err
+		}
+	}
+	return next, nil
+}
+
+// eventFields returns the fields of an event for the wire, including the
+// top-level ones researchpb.Event has no field for
+func eventFields(ev runEvent) map[string]string {
+	fields := map[string]string{}
+	for k, v := range ev.Fields {
+		fields[k] = v
+	}
+	if ev.Phase != "" {
+		fields["phase"] = ev.Phase
+	}
+	if ev.Agent != "" {
+		fields["agent"] = ev.Agent
+	}
+	if ev.DurationMs > 0 {
+		fields["duration_ms"] = strconv.FormatInt(ev.DurationMs, 10)
+	}
+	if ev.ExitCode != nil {
+		fields["exit_code"] = strconv.Itoa(*ev.ExitCode)
+	}
+	return fields
+}
+
+func (g *researchService) GetArtifact(req *researchpb.GetArtifactRequest, stream grpc.ServerStreamingServer[researchpb.ArtifactChunk]) error {
+	j, err := g.s.lookup(tenantFrom(stream.Context()), req.Id)
+	if err != nil {
+		return grpcError(err)
+	}
+	path, err := artifactPath(j, req.Path)
+	if err != nil {
+		return grpcError(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+	buf := make([]byte, artifactChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&researchpb.ArtifactChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// Values of run.handoff and --handoff
+const (
+	handoffCheck   = "check"
+	handoffRequire = "require"
+	handoffOff     = "off"
+)
+
+// handoffModes maps run.handoff to the orchestrator's handoff mode
+var handoffModes = map[string]orchestrator.HandoffMode{
+	handoffCheck:   orchestrator.HandoffCheck,
+	handoffRequire: orchestrator.HandoffRequire,
+	handoffOff:     orchestrator.HandoffOff,
+}
+
+// handoffMode is the orchestrator's handoff mode for run.handoff
+func handoffMode() orchestrator.HandoffMode {
+	return handoffModes[config.Run.Handoff]
+}
+
+// buildHandoffNote asks the agent of a step in workDir for its handoff unless handoffs are off
+func buildHandoffNote(promptsDir, workDir string, step orchestrator.Step) string {
+	return orchestrator.HandoffNote(promptsDir, step, handoffMode(), workspaceLayout(workDir))
+}
+
+// writeResearchHandoff hands off a research step the orchestrator dispatched
+// to executors itself: tasks still pending are the follow-ups
+func writeResearchHandoff(workDir string, iteration int) error {
+	if handoffMode() == orchestrator.HandoffOff {
+		return nil
+	}
+	f, err := taskfile.Read(taskFilePath(workDir))
+	if err != nil {
+		return err
+	}
+	h := orchestrator.Handoff{
+		Phase:   orchestrator.PhaseResearch,
+		Status:  orchestrator.HandoffCompleted,
+		Outputs: []string{taskFileName(workDir)},
+		Summary: fmt.Sprintf("Executors ran the research tasks of iteration %d", iteration),
+	}
+	for _, t := range f.Pending() {
+		h.Status = orchestrator.HandoffPartial
+		h.FollowUps = append(h.FollowUps, t.ID+": "+t.Description)
+	}
+	return orchestrator.WriteHandoff(workDir, h)
+}
+
+// writeSynthesisHandoff hands off a synthesis assembled from sections
+func writeSynthesisHandoff(workDir string) error {
+	if handoffMode() == orchestrator.HandoffOff {
+		return nil
+	}
+	return orchestrator.WriteHandoff(workDir, orchestrator.Handoff{
+		Phase:   orchestrator.PhaseSynthesizer,
+		Status:  orchestrator.HandoffCompleted,
+		Outputs: []string{reportFileName(workDir), sectionsDirName},
+		Summary: "Report assembled from the sections written one by one",
+	})
+}
+
+// logHandoff records what the agent of a completed step handed off
+func logHandoff(step orchestrator.Step, h *orchestrator.Handoff) {
+	if h == nil {
+		if handoffMode() != orchestrator.HandoffOff {
+			logEntry("INFO", "HANDOFF", step.Iteration, step.Phase.Title()+" wrote no handoff", nil)
+		}
+		return
+	}
+	fields := map[string]string{
+		"status":  h.Status,
+		"outputs": strings.Join(h.Outputs, ","),
+	}
+	if h.Confidence != nil {
+		fields["confidence"] = strconv.FormatFloat(*h.Confidence, 'f', -1, 64)
+	}
+	if len(h.FollowUps) > 0 {
+		fields["follow_ups"] = strings.Join(h.FollowUps, " | ")
+	}
+	if h.Summary != "" {
+		fields["summary"] = h.Summary
+	}
+	logEntry("INFO", "HANDOFF", step.Iteration, step.Phase.Title()+" handed off", fields)
+	if h.Status == orchestrator.HandoffPartial {
+		warn("%s handed off partial work with %d follow-up(s)", step.Phase.Title(), len(h.FollowUps))
+	}
+}
+
+// failHandoff exits with the diagnostics of a malformed or missing handoff
+func failHandoff(err error, promptsDir string) {
+	var invalid *orchestrator.HandoffError
+	if !errors.As(err, &invalid) {
+		return
+	}
+	schema := filepath.Join(promptsDir, orchestrator.HandoffSchema)
+	if len(invalid.Problems) == 0 {
+		fatalCode(codeArtifactMissing, "%s (schema: %s; --handoff check accepts steps without one)", invalid.Error(), schema)
+	}
+	fatalCode(codeValidationFailed, "%s (schema: %s; the file is kept for inspection)", invalid.Error(), schema)
+}
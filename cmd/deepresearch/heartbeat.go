@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// heartbeatIdle is how long an agent must be silent before the spinner appears
+const heartbeatIdle = 5 * time.Second
+
+// heartbeatPlainInterval is how often plain output reports a silent agent
+const heartbeatPlainInterval = time.Minute
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// heartbeat shows an in-place spinner with elapsed time while an agent produces
+// no output, so users can tell a working agent from a hung one.
+type heartbeat struct {
+	mu         sync.Mutex
+	label      string
+	started    time.Time
+	lastOutput time.Time
+	showing    bool
+	frame      int
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// startHeartbeat starts the spinner goroutine. In plain output mode it prints a
+// line for each minute of silence instead.
+func startHeartbeat(label string) *heartbeat {
+	now := time.Now()
+	h := &heartbeat{
+		label:      label,
+		started:    now,
+		lastOutput: now,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if plainOutput {
+		go h.plainLoop()
+		return h
+	}
+	go h.loop()
+	return h
+}
+
+func (h *heartbeat) plainLoop() {
+	defer close(h.done)
+	ticker := time.NewTicker(heartbeatPlainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			if silent := time.Since(h.lastOutput); silent >= heartbeatPlainInterval {
+				fmt.Fprintf(os.Stdout, "[WAIT] "+tr("%s working... %s elapsed, no output for %s")+"\n",
+					h.label, formatDuration(time.Since(h.started)), formatDuration(silent))
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+func (h *heartbeat) loop() {
+	defer close(h.done)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			h.mu.Lock()
+			h.clearLocked()
+			h.mu.Unlock()
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			silent := time.Since(h.lastOutput)
+			if silent >= heartbeatIdle {
+				h.frame = (h.frame + 1) % len(spinnerFrames)
+				fmt.Fprintf(os.Stdout, "\r\033[K%s%s %s%s", colorCyan, spinnerFrames[h.frame],
+					fmt.Sprintf(tr("%s working... %s elapsed, no output for %s"), h.label,
+						formatDuration(time.Since(h.started)), formatDuration(silent)), colorReset)
+				h.showing = true
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// clearLocked erases the spinner line; the caller must hold h.mu
+func (h *heartbeat) clearLocked() {
+	if h.showing {
+		fmt.Fprint(os.Stdout, "\r\033[K")
+		h.showing = false
+	}
+}
+
+// Stop removes the spinner and waits for the goroutine to exit
+func (h *heartbeat) Stop() {
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+	close(h.stop)
+	<-h.done
+}
+
+// Writer wraps w so that agent output clears the spinner and resets the idle timer
+func (h *heartbeat) Writer(w io.Writer) io.Writer {
+	return heartbeatWriter{h: h, w: w}
+}
+
+type heartbeatWriter struct {
+	h *heartbeat
+	w io.Writer
+}
+
+func (hw heartbeatWriter) Write(p []byte) (int, error) {
+	hw.h.mu.Lock()
+	defer hw.h.mu.Unlock()
+	hw.h.clearLocked()
+	hw.h.lastOutput = time.Now()
+	return hw.w.Write(p)
+}
+
+// isTerminal reports whether f is attached to a character device (TTY)
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return st.Mode()&os.ModeCharDevice != 0
+}
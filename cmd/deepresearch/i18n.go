@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// locale is the language of CLI messages; "en" shows the source strings
+var locale = "en"
+
+// catalogs holds a message catalog per language, mapping English format strings
+// (as passed to info, warn, success, fatal and phase) to their translations.
+// Messages missing from a catalog are shown in English.
+var catalogs = map[string]map[string]string{}
+
+// registerCatalog adds the message catalog of a language
+func registerCatalog(lang string, messages map[string]string) {
+	catalogs[lang] = messages
+}
+
+// localeEnv overrides the system locale for CLI messages
+const localeEnv = "DEEPRESEARCH_LANG"
+
+func init() {
+	locale = parseLocale(detectLocale())
+}
+
+// detectLocale returns the first locale set in DEEPRESEARCH_LANG, LC_ALL,
+// LC_MESSAGES or LANG
+func detectLocale() string {
+	for _, name := range []string{localeEnv, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseLocale reduces a locale such as "zh_CN.UTF-8" or "ja-JP" to its language
+func parseLocale(v string) string {
+	v = strings.ToLower(v)
+	if i := strings.IndexAny(v, "_-.@"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" || v == "c" || v == "posix" {
+		return "en"
+	}
+	return v
+}
+
+// setLocale selects the language of CLI messages
+func setLocale(lang string) error {
+	lang = parseLocale(lang)
+	if _, ok := catalogs[lang]; !ok && lang != "en" {
+		return fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(supportedLocales(), ", "))
+	}
+	locale = lang
+	return nil
+}
+
+// supportedLocales lists the languages CLI messages are available in
+func supportedLocales() []string {
+	return append([]string{"en"}, sortedKeys(catalogs)...)
+}
+
+// tr translates a message into the current locale
+func tr(msg string) string {
+	if t, ok := catalogs[locale][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// displayWidth is the number of terminal columns s occupies: CJK and fullwidth
+// characters take two
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if isWide(r) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+func isWide(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115F ||
+		(r >= 0x2E80 && r <= 0xA4CF && r != 0x303F) ||
+		(r >= 0xAC00 && r <= 0xD7A3) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0xFE30 && r <= 0xFE4F) ||
+		(r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6))
+}
+
+// padRight pads s with spaces to width terminal columns
+func padRight(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// padLeft right-aligns s in width terminal columns
+func padLeft(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// noLineStart is punctuation that hangs past the margin rather than begin a line
+const noLineStart = "、。，．：；！？）」』】"
+
+// wrapText breaks s into lines of at most width terminal columns, at spaces
+// where there are any and between characters in scripts written without them
+func wrapText(s string, width int) []string {
+	var lines []string
+	line := ""
+	flush := func() {
+		lines = append(lines, strings.TrimRight(line, " "))
+		line = ""
+	}
+	for _, word := range strings.Fields(s) {
+		sep := " "
+		if line == "" {
+			sep = ""
+		}
+		if displayWidth(line+sep+word) <= width {
+			line += sep + word
+			continue
+		}
+		if line != "" && displayWidth(word) <= width && displayWidth(word) == utf8.RuneCountInString(word) {
+			flush()
+			line = word
+			continue
+		}
+		// CJK text, or too long for a line of its own: break between characters
+		line += sep
+		for len(word) > 0 {
+			r, size := utf8.DecodeRuneInString(word)
+			if displayWidth(line+string(r)) > width && !strings.ContainsRune(noLineStart, r) {
+				flush()
+			}
+			line += string(r)
+			word = word[size:]
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// printBanner draws a boxed banner with a title and wrapped paragraphs
+func printBanner(title string, paragraphs []string) {
+	const width = 64
+	row := func(s string) {
+		fmt.Printf("%s║  %s║%s\n", colorCyan, padRight(s, width-2), colorReset)
+	}
+	fmt.Println()
+	fmt.Printf("%s╔%s╗%s\n", colorCyan, strings.Repeat("═", width), colorReset)
+	row(title)
+	fmt.Printf("%s╠%s╣%s\n", colorCyan, strings.Repeat("═", width), colorReset)
+	for i, p := range paragraphs {
+		if i > 0 {
+			row("")
+		}
+		for _, l := range wrapText(p, width-6) {
+			row(l)
+		}
+	}
+	fmt.Printf("%s╚%s╝%s\n", colorCyan, strings.Repeat("═", width), colorReset)
+	fmt.Println()
+}
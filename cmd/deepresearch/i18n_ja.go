@@ -0,0 +1,425 @@
+package main
+
+func init() {
+	registerCatalog("ja", map[string]string{
+		// Startup
+		"Enter your research topic: ":                                                   "リサーチのテーマを入力してください：",
+		"Read prompt from file: %s":                                                     "ファイルからプロンプトを読み込みました：%s",
+		"Auto-detected agent: %s":                                                       "エージェントを自動検出しました：%s",
+		"Using prompts from: %s":                                                        "プロンプトディレクトリ：%s",
+		"Working directory: %s":                                                         "作業ディレクトリ：%s",
+		"Using model: %s":                                                               "使用モデル：%s",
+		"Zotero import failed: %v":                                                      "Zotero からのインポートに失敗しました：%v",
+		"Ingested context: %s":                                                          "コンテキストを取り込みました：%s",
+		"Starting %s in interactive mode with -i flag...":                               "%s を対話モード（-i）で起動しています…",
+		"The planner is done (%s), stopping the agent...":                               "プランナーが完了しました（%s）。エージェントを停止します…",
+		"The agent did not exit within %ds of the interrupt; killing it":                "エージェントが割り込みから %d 秒以内に終了しないため、強制終了します",
+		"Imported %d pre-approved source(s) from Zotero into %s":                        "Zotero から承認済みソース %d 件を %s にインポートしました",
+		"Replaying run %s from %s (%d cached file(s)); agents work offline":             "%[2]s から実行 %[1]s を再生しています（キャッシュ済みファイル %[3]d 件）。エージェントはオフラインで動作します",
+		"Interactive mode: You can discuss and refine the research plan with the agent": "対話モード：エージェントとリサーチ計画を相談しながら改善できます",
+		"The agent will automatically exit after creating %s":                           "エージェントは %s を作成すると自動的に終了します",
+		"Resuming run %s after %s":                                                      "実行 %[1]s を %[2]s の後から再開します",
+		"Resuming run %s: no phase had completed, starting over with the planner":       "実行 %s を再開します。完了したフェーズがないため、プランナーからやり直します",
+		"Cannot resume: %v":                                                             "再開できません：%v",
+		"Session: %s":                                                                   "セッション：%s",
+		"Cannot create the session: %v":                                                 "セッションを作成できません：%v",
+
+		// Interactive planner banner
+		"INTERACTIVE PLANNER MODE": "対話型プランナーモード",
+		"The agent will process your research request and generate a research plan. You can then discuss and refine the plan.": "エージェントがリサーチ依頼を処理し、リサーチ計画を作成します。その後、計画について相談し改善できます。",
+		"When you approve the plan, the agent will create %s and the workflow will automatically continue.":                    "計画を承認すると、エージェントが %s を作成し、ワークフローが自動的に続行されます。",
+
+		// Phases
+		"Finding what the request leaves open":             "リクエストで未確定の点を洗い出しています",
+		"Creating research plan":                           "リサーチ計画を作成しています",
+		"Executing research tasks (iteration %d)":          "リサーチタスクを実行しています（%d 回目）",
+		"Analyzing research quality":                       "リサーチの品質を分析しています",
+		"Generating final report":                          "最終レポートを生成しています",
+		"Translating the report into %s":                   "レポートを %s に翻訳しています",
+		"Generating partial report from gathered findings": "収集した知見から部分レポートを生成しています",
+		"Clarifying questions written: %s":                 "確認事項を書き出しました：%s",
+		"Research plan created: %s":                        "リサーチ計画を作成しました：%s",
+		"Research tasks completed":                         "リサーチタスクが完了しました",
+		"Reflection completed":                             "振り返りが完了しました",
+		"Report created: %s":                               "レポートを作成しました：%s",
+		"Report translated: %s":                            "レポートを翻訳しました：%s",
+		"Partial report created: %s":                       "部分レポートを作成しました：%s",
+		"Elapsed: %s | Phase ETA: %s | Run ETA: %s":        "経過：%s | フェーズ残り見込み：%s | 全体残り見込み：%s",
+		"unknown": "不明",
+		"%s working... %s elapsed, no output for %s":                                                     "%s 作業中… 経過 %s、%s 出力なし",
+		"Reflector indicates research is sufficient":                                                     "リフレクターはリサーチが十分と判断しました",
+		"Reflector added %d new task(s), continuing research loop...":                                    "リフレクターが新しいタスクを %d 件追加しました。リサーチを続行します…",
+		"Reached max iterations (%d) while reflector still requested more research":                      "最大反復回数（%d）に達しましたが、リフレクターはまだ追加のリサーチを求めています",
+		"Budget reached; skipping %s and the rest of the research loop":                                  "予算に達しました。%sと残りのリサーチループをスキップします",
+		"Estimated spend $%.2f reached the budget of $%.2f; stopping the run":                            "推定支出 $%.2f が予算 $%.2f に達しました。実行を停止します",
+		"Estimated spend $%.2f reached the budget of $%.2f; writing the report from the findings so far": "推定支出 $%.2f が予算 $%.2f に達しました。これまでの調査結果からレポートを作成します",
+
+		// Sectioned synthesis
+		"Writing report outline: %s/outline.md":                                           "レポートの構成を作成しています：%s/outline.md",
+		"Section %d/%d already written: %s":                                               "セクション %d/%d は作成済みです：%s",
+		"Writing section %d/%d: %s":                                                       "セクション %d/%d を作成しています：%s",
+		"Assembled %d/%d sections into %s":                                                "%d/%d セクションを %s にまとめました",
+		"Partial report with %d section(s) saved to %s; written sections are kept in %s/": "%d セクションの部分レポートを %s に保存しました。作成済みセクションは %s/ に残っています",
+
+		// Outputs
+		"Could not save citation metadata: %v":                "引用メタデータを保存できませんでした：%v",
+		"Resolved metadata for %d source(s) via Crossref":     "Crossref で %d 件のソースのメタデータを取得しました",
+		"Could not add charts to %s: %v":                      "%s にグラフを追加できませんでした：%v",
+		"Added %d %s chart(s) to %s":                          "%d 件の %s グラフを %s に追加しました",
+		"Could not export data tables: %v":                    "データ表をエクスポートできませんでした：%v",
+		"Exported %d data table(s) to %s/":                    "%d 件のデータ表を %s/ にエクスポートしました",
+		"Could not write bibliography: %v":                    "参考文献を書き込めませんでした：%v",
+		"Bibliography with %d source(s) written to %s and %s": "%d 件のソースの参考文献を %s と %s に書き込みました",
+		"Could not write %s: %v":                              "%s を書き込めませんでした：%v",
+		"Data appendix written to %s":                         "データ付録を %s に書き込みました",
+		"Report exported to %s":                               "レポートを %s にエクスポートしました",
+		"Checking citations":                                  "引用を検証しています",
+		"Citations checked":                                   "引用の検証が完了しました",
+		"All %d cited source(s) check out against the workspace; links were not requested":                   "引用された %d 件のソースはすべてワークスペースと一致しました。リンクは確認していません",
+		"All %d cited source(s) and %d link(s) check out":                                                    "引用された %d 件のソースと %d 件のリンクはすべて確認できました",
+		"Found %d citation problem(s); added re-sourcing task(s) %s":                                         "引用の問題が %d 件見つかりました。再調査タスク %s を追加しました",
+		"Found %d citation problem(s); marked with † in %s and listed under Citation Check":                  "引用の問題が %d 件見つかりました。%s で † を付け、Citation Check セクションに記載しました",
+		"Researching replacements for broken citations, then synthesizing again...":                          "無効な引用の代替ソースを調査し、その後レポートを再生成します...",
+		"--tui needs a terminal; showing the console output instead":                                         "--tui には端末が必要です。代わりにコンソール出力を表示します",
+		"Could not start the dashboard: %v":                                                                  "ダッシュボードを開始できませんでした：%v",
+		"Full console and agent output: %s":                                                                  "コンソールとエージェントの全出力：%s",
+		"Could not send %s notification: %v":                                                                 "%s 通知を送信できませんでした：%v",
+		"The %s runs %s with model %s":                                                                       "%s は %s をモデル %s で実行します",
+		"the agent's default":                                                                                "エージェントの既定モデル",
+		"Research plan saved to: %s":                                                                         "調査計画を保存しました：%s",
+		"Review or edit it, then continue this run with --resume, or run it anywhere with --execute-plan %s": "確認・編集後、--resume でこの実行を続けるか、--execute-plan %s で任意の場所から実行できます",
+		"Executing the plan in %s (%d pending task(s)); the planner is skipped":                              "%s の計画を実行します（未完了タスク %d 件）。プランナーはスキップします",
+		"Could not read the source cache: %v":                                                                "ソースキャッシュを読み込めませんでした：%v",
+		"Could not update the source cache: %v":                                                              "ソースキャッシュを更新できませんでした：%v",
+		"%d source(s) fetched by earlier runs are listed in %s for reuse":                                    "以前の実行で取得したソース %d 件を再利用できるよう %s に一覧化しました",
+		"Source cache: %d source(s) reused, %d added":                                                        "ソースキャッシュ：%d 件を再利用、%d 件を追加",
+		"Serving Prometheus metrics at http://%s/metrics":                                                    "Prometheus メトリクスを http://%s/metrics で公開しています",
+		"Could not send traces to %s: %v":                                                                    "トレースを %s に送信できませんでした：%v",
+		"%s handed off partial work with %d follow-up(s)":                                                    "%s は作業を一部完了の状態で引き継ぎました（フォローアップ %d 件）",
+		"%s (schema: %s; --handoff check accepts steps without one)":                                         "%s（スキーマ：%s。--handoff check ではこのファイルのないステップも受け付けます）",
+		"%s (schema: %s; the file is kept for inspection)":                                                   "%s（スキーマ：%s。ファイルは確認用に残してあります）",
+		"Ending the research loop: the reflector's confidence reached the --stop-confidence threshold":       "リサーチループを終了します：リフレクターの確信度が --stop-confidence のしきい値に達しました",
+		"Ending the research loop: %d iteration(s) in a row made no progress (--stop-stalled)":               "リサーチループを終了します：%d 回連続で反復に進展がありませんでした（--stop-stalled）",
+		"Ending the research loop: it reached its limit of %d minute(s) (--max-research-minutes)":            "リサーチループを終了します：%d 分の上限に達しました（--max-research-minutes）",
+		"%s reads as %s rather than %s":                                                                      "%[1]s は %[3]s ではなく %[2]s で書かれているようです",
+		"%s lacks %d citation(s) of %s: %s":                                                                  "%[1]s には %[3]s の引用が %[2]d 件欠けています：%[4]s",
+		"%s lacks %d figure(s) of %s: %s":                                                                    "%[1]s には %[3]s の図が %[2]d 件欠けています：%[4]s",
+		"Translations saved to: %s":                                                                          "翻訳の保存先：%s",
+		"Answer each question, or press Enter to leave it to the planner":                                    "各質問に回答してください。Enter を押すとプランナーに任せます",
+		"Using the answers to %d clarifying question(s) in %s":                                               "%[2]s にある %[1]d 件の確認事項への回答を使用します",
+		"The clarifier found the request specific enough to plan; no questions to ask":                       "クラリファイアはリクエストが計画に十分具体的だと判断しました。質問はありません",
+		"The planner gets your answers to %d of %d question(s)":                                              "%[2]d 件中 %[1]d 件の質問への回答をプランナーに渡します",
+		"Approve the plan [a], edit it [e], or send feedback to the planner [f]: ":                           "計画を承認 [a]、編集 [e]、またはプランナーにフィードバックを送信 [f]：",
+		"Sending the plan back to the planner with your feedback...":                                         "フィードバックを添えて計画をプランナーに差し戻しています...",
+		"Research plan (revision %d)":                                                                        "リサーチ計画（改訂 %d）",
+		"%d task(s); the full plan is in %s":                                                                 "%d 件のタスク。計画の全文は %s にあります",
+		"What should the planner change? ":                                                                   "プランナーに何を変更させますか？",
+		"Could not edit the plan: %v":                                                                        "計画を編集できませんでした：%v",
+		"Initialized a git repository in %s":                                                                 "%s に git リポジトリを初期化しました",
+		"Committing the artifacts to the git repository %s after each phase":                                 "各フェーズの後に成果物を git リポジトリ %s にコミットします",
+		"Could not commit the artifacts of %s: %v":                                                           "%s の成果物をコミットできませんでした: %v",
+		"Could not open the agent log: %v":                                                                   "エージェントログを開けませんでした: %v",
+		"execution.mode pwsh does not apply to sandboxed agents; they run directly in their containers":      "execution.mode pwsh はサンドボックス内のエージェントには適用されません。エージェントはコンテナ内で直接実行されます",
+		"No agent to detect: with --sandbox the agent CLIs run in the image, so pass --agent":                "エージェントを検出できません：--sandbox ではエージェント CLI はイメージ内で実行されるため、--agent を指定してください",
+		"Agents run in %s":     "エージェントの実行環境：%s",
+		"Loop ended:":          "ループ終了：",
+		"research sufficient":  "リサーチ十分",
+		"Plan approved":        "計画が承認されました",
+		"Research plan":        "リサーチ計画",
+		"Clarifying questions": "確認事項",
+		"confidence reached":   "確信度に到達",
+		"no progress":          "進展なし",
+		"time limit reached":   "時間上限に到達",
+		"iterations exhausted": "反復回数を使い切り",
+		"budget reached":       "予算に到達",
+		"The run keeps the layout of the run it continues: task file %s, report file %s, assets directory %s": "この実行は継続元の実行のレイアウトを引き継ぎます：タスクファイル %s、レポートファイル %s、アセットディレクトリ %s",
+		"Could not read the knowledge base: %v":                                "ナレッジベースを読み込めませんでした：%v",
+		"Skipped %d malformed line(s) of %s":                                   "不正な行 %d 行をスキップしました（%s）",
+		"None of the %d finding(s) in the knowledge base relate to this topic": "ナレッジベースの %d 件の知見はいずれもこのトピックに関連しません",
+		"%d finding(s) of earlier runs are listed in %s":                       "以前の実行の知見 %d 件を %s に一覧化しました",
+		"Knowledge base: %d finding(s) added, %d already known":                "ナレッジベース：知見 %d 件を追加、%d 件は登録済み",
+		"elapsed %s":         "経過 %s",
+		"Phases":             "フェーズ",
+		"Tasks (%d/%d done)": "タスク（%d/%d 完了）",
+		"Log":                "ログ",
+		"Agent output: %s  ·  Ctrl-C stops the run": "エージェント出力：%s  ·  Ctrl-C で実行を停止",
+		"(running)":        "（実行中）",
+		"(ready)":          "（実行可能）",
+		"(waiting for %s)": "（%s 待ち）",
+		"%s: %d character(s) are outside the PDF font and were replaced; set export.pdf_font to a TrueType font that covers them": "%s：%d 文字が PDF フォントの範囲外のため置き換えられました。これらを含む TrueType フォントを export.pdf_font に設定してください",
+		"Zotero export failed: %v":               "Zotero へのエクスポートに失敗しました：%v",
+		"Added %d citation(s) to Zotero":         "Zotero に %d 件の引用を追加しました",
+		"Research complete! Report saved to: %s": "リサーチ完了！レポートの保存先：%s",
+		"Report: %s":                             "レポート：%s",
+		"Could not open report: %v":              "レポートを開けませんでした：%v",
+
+		// Salvage
+		"Research has gathered findings. Generate a partial report from them? [y/N]: ": "リサーチで知見が集まっています。部分レポートを生成しますか？ [y/N]：",
+		"Salvage synthesis failed: %v":         "救済用の統合に失敗しました：%v",
+		"Salvage synthesis did not create %s":  "救済用の統合で %s が作成されませんでした",
+		"Could not mark report as partial: %v": "レポートを部分レポートとしてマークできませんでした：%v",
+		"Run aborted (%s); %s is PARTIAL":      "実行が中断されました（%s）。%s は部分レポートです",
+
+		// Errors
+		"Failed to resolve working directory: %v":          "作業ディレクトリを解決できませんでした：%v",
+		"Failed to read prompt file: %v":                   "プロンプトファイルを読み込めませんでした：%v",
+		"Prompt file is empty":                             "プロンプトファイルが空です",
+		"Failed to read input: %v":                         "入力を読み込めませんでした：%v",
+		"Research topic cannot be empty":                   "リサーチのテーマは空にできません",
+		"No supported agent CLI found. Install one of: %s": "対応するエージェント CLI が見つかりません。%s のいずれかをインストールしてください",
+		"Unknown agent: %s. Supported: %s":                 "不明なエージェント：%s。対応：%s",
+		"Agent '%s' is not installed or not in PATH":       "エージェント '%s' がインストールされていないか、PATH にありません",
+		"Agent '%s' cannot run: %v":                        "エージェント '%s' を実行できません: %v",
+		"Cannot load prompts: %v":                          "プロンプトを読み込めません: %v",
+		"Context ingestion failed: %v":                     "コンテキストの取り込みに失敗しました：%v",
+		"Planner failed: %v":                               "プランナーが失敗しました：%v",
+		"Planner did not create %s":                        "プランナーが %s を作成しませんでした",
+		"Research-Supervisor failed: %v":                   "リサーチスーパーバイザーが失敗しました：%v",
+		"Reflector failed: %v":                             "リフレクターが失敗しました：%v",
+		"Synthesizer failed: %v":                           "シンセサイザーが失敗しました：%v",
+		"Synthesizer did not create report.md":             "シンセサイザーが report.md を作成しませんでした",
+		"Invalid --lang value: %v":                         "--lang の値が無効です：%v",
+
+		// Run summary
+		"Run summary":                         "実行サマリー",
+		"RUN SUMMARY":                         "実行サマリー",
+		"Phase":                               "フェーズ",
+		"Duration":                            "所要時間",
+		"Status":                              "状態",
+		"Total time:":                         "合計時間：",
+		"Iterations:":                         "反復：",
+		"Tasks:":                              "タスク：",
+		"%d / %d completed":                   "%d / %d 完了",
+		"Sources:":                            "ソース：",
+		"Estimated cost:":                     "推定コスト：",
+		"n/a":                                 "なし",
+		"%d tokens":                           "%d トークン",
+		"Tokens":                              "トークン",
+		"Cost":                                "コスト",
+		"$%.2f (%s input + %s output tokens)": "$%.2f（入力 %s + 出力 %s トークン）",
+		" of $%.2f budget":                    "（予算 $%.2f）",
+		"No price for %s; their tokens are not in the estimated cost (set cost.prices)": "%s の価格が不明なため、そのトークンは推定コストに含まれていません（cost.prices を設定してください）",
+		"--budget had no effect: the agent reported no token usage":                     "--budget は効果がありませんでした: エージェントがトークン使用量を報告しませんでした",
+		"--budget cannot be enforced while %s runs: it reports no token usage":          "%s の実行中は --budget を適用できません: トークン使用量を報告しません",
+		"Warnings:": "警告：",
+
+		// Validation
+		"Invalid config: %v": "設定が無効です：%v",
+		"Invalid --cache-ttl-days value: %d (expected days, or 0 for no expiry)":                                                                 "--cache-ttl-days の値が無効です：%d（日数、または期限なしの 0 を指定してください）",
+		"Invalid storage.cache_ttl_days value: %d (expected days, or 0 for no expiry)":                                                           "storage.cache_ttl_days の値が無効です：%d（日数、または期限なしの 0 を指定してください）",
+		"Invalid knowledge.max_findings value: %d (expected a count, or 0 for all)":                                                              "knowledge.max_findings の値が無効です：%d（件数、またはすべての 0 を指定してください）",
+		"Invalid --max-iterations value: %d (expected 1 or more)":                                                                                "--max-iterations の値が無効です：%d（1 以上を指定してください）",
+		"Invalid run.max_iterations value: %d (expected 1 or more)":                                                                              "run.max_iterations の値が無効です：%d（1 以上を指定してください）",
+		"Invalid --stop-confidence value: %g (expected 0 to 1, or 0 for off)":                                                                    "--stop-confidence の値が無効です：%g（0〜1、または無効にする 0 を指定してください）",
+		"Invalid --max-research-minutes value: %d (expected minutes, or 0 for no limit)":                                                         "--max-research-minutes の値が無効です：%d（分数、または無制限の 0 を指定してください）",
+		"Invalid reflection config: stop_stalled and max_minutes cannot be negative":                                                             "reflection の設定が無効です：stop_stalled と max_minutes は負の値にできません",
+		"Invalid --phase-timeout value: %d (expected minutes, or 0 for no limit)":                                                                "--phase-timeout の値が無効です：%d（分数、または無制限の 0 を指定してください）",
+		"Invalid run config: retries and retry_backoff_seconds cannot be negative":                                                               "run の設定が無効です：retries と retry_backoff_seconds は負の値にできません",
+		"Invalid --handoff value: %s (expected check, require or off)":                                                                           "--handoff の値が無効です：%s（check、require、off のいずれかを指定してください）",
+		"Invalid interactive config: settle_seconds and stop_grace_seconds cannot be negative":                                                   "interactive の設定が無効です：settle_seconds と stop_grace_seconds は負の値にできません",
+		"Invalid interactive.pty value: %s (expected auto, on or off)":                                                                           "interactive.pty の値が無効です：%s（auto、on、off のいずれかを指定してください）",
+		"--review-plan cannot be combined with --tui, whose dashboard takes over the console":                                                    "--review-plan は --tui と併用できません。--tui のダッシュボードがコンソールを占有します",
+		"--clarify cannot be combined with --tui, whose dashboard takes over the console; answer the questions in a file with --clarify-answers": "--clarify は --tui と併用できません。--tui のダッシュボードがコンソールを占有します。質問への回答はファイルに書き、--clarify-answers で渡してください",
+		"Invalid workspace layout: %v":                                                                                                           "ワークスペースのレイアウトが無効です：%v",
+		"--workdir cannot be combined with --session or --in-place":                                                                              "--workdir は --session や --in-place と併用できません",
+		"Invalid --output-format value: %v":                                                                                                      "--output-format の値が無効です：%v",
+		"Invalid --report-lang value: %v":                                                                                                        "--report-lang の値が無効です：%v",
+		"--report-lang lists %d languages, but report.md is written in one; add --translate-report to translate it into each":                    "--report-lang に %d 言語が指定されていますが、report.md は 1 言語で書かれます。各言語に翻訳するには --translate-report を追加してください",
+		"--translate-report needs the languages to translate the report into, e.g. --report-lang ja":                                             "--translate-report には翻訳先の言語が必要です（例：--report-lang ja）",
+		"Invalid --sandbox setup: %v":                                                                                                            "--sandbox の設定が無効です：%v",
+		"Invalid --verify-citations value: %s (expected off, annotate or resource)":                                                              "--verify-citations の値が無効です：%s（off、annotate、resource のいずれかを指定してください）",
+		"Invalid verification config: rounds cannot be negative, timeout_seconds and concurrency must be 1 or more":                              "verification の設定が無効です：rounds は負の値にできず、timeout_seconds と concurrency は 1 以上が必要です",
+		"Invalid --budget value: %g (expected USD, or 0 for no limit)":                                                                           "--budget の値が無効です：%g（USD、または無制限の 0 を指定してください）",
+		"Invalid cost.budget_usd value: %g (expected USD, or 0 for no limit)":                                                                    "cost.budget_usd の値が無効です：%g（USD、または無制限の 0 を指定してください）",
+		"Invalid --on-budget value: %s (expected synthesize or abort)":                                                                           "--on-budget の値が無効です：%s（synthesize または abort を指定してください）",
+		"Invalid --telemetry value: %s (expected off, local or on)":                                                                              "--telemetry の値が無効です：%s（off、local、on のいずれかを指定してください）",
+		"Invalid notifications config: %v":                                                                                                       "notifications の設定が無効です：%v",
+		"Invalid observability config: %v":                                                                                                       "observability の設定が無効です：%v",
+		"Invalid --salvage value: %s (expected ask, auto or off)":                                                                                "--salvage の値が無効です：%s（ask、auto、off のいずれかを指定してください）",
+		"Invalid --max-parallel value: %d (expected 1 or more, or 0 to leave dispatch to the Research-Supervisor)":                               "--max-parallel の値が無効です：%d（1 以上、またはリサーチスーパーバイザーに割り振りを任せる 0 を指定してください）",
+		"Invalid --log-format value: %s (expected text or json)":                                                                                 "--log-format の値が無効です：%s（text または json を指定してください）",
+		"Invalid --charts value: %s (expected off, mermaid or vega-lite)":                                                                        "--charts の値が無効です：%s（off、mermaid、vega-lite のいずれかを指定してください）",
+		"Invalid --replay-from value: %s (expected synthesis or research)":                                                                       "--replay-from の値が無効です：%s（synthesis または research を指定してください）",
+		"--replay cannot be combined with --watch":                                                                                               "--replay は --watch と併用できません",
+		"--plan-only cannot be combined with --execute-plan":                                                                                     "--plan-only は --execute-plan と併用できません",
+		"--plan-only and --execute-plan cannot be combined with --replay, --resume or --watch":                                                   "--plan-only と --execute-plan は --replay、--resume、--watch と併用できません",
+		"--resume cannot be combined with --replay or --watch":                                                                                   "--resume は --replay や --watch と併用できません",
+		"--watch requires -f (and no -p)":                                                                                                        "--watch には -f が必要です（-p は指定できません）",
+
+		// Runs
+		"Cannot replay %s: %v":  "%s を再生できません：%v",
+		"Cannot execute %s: %v": "%s を実行できません：%v",
+		"%s records no Original Request; pass the topic with -p or -f":         "%s に Original Request が記録されていません。-p または -f でテーマを指定してください",
+		"The prior run did not persist its topic; pass it with -p or -f":       "以前の実行はテーマを保存していません。-p または -f で指定してください",
+		"The interrupted run did not persist its topic; pass it with -p or -f": "中断された実行はテーマを保存していません。-p または -f で指定してください",
+		"Cannot use %s as the workspace: %v":                                   "%s をワークスペースとして使用できません：%v",
+		"Cannot serve metrics on %s: %v":                                       "%s でメトリクスを提供できません：%v",
+		"Cannot version the workspace with --git: %v":                          "--git でワークスペースをバージョン管理できません：%v",
+		"Executing (%s): %s":                   "実行中（%s）：%s",
+		"Warning: Could not open log file: %v": "警告：ログファイルを開けません：%v",
+		"Failed to create directory %s: %v":    "ディレクトリ %s を作成できませんでした：%v",
+		"Answer the %d clarifying question(s) in %s, then run again with --clarify-answers %s": "%[2]s にある %[1]d 件の確認事項に回答し、--clarify-answers %[3]s を付けて再実行してください",
+		"Cannot read the answers in %s: %v":                                                    "%s の回答を読み込めません：%v",
+		"Source freshness: %s":                                                                 "ソースの鮮度：%s",
+		"Fast-moving topic relies on %d source(s) older than %d days: %s":                      "変化の速いテーマが %[2]d 日より古いソース %[1]d 件に依存しています：%[3]s",
+
+		// Parallel research
+		"Wave %d: dispatching %s (up to %d at once)": "ウェーブ %d：%s を割り振っています（同時に最大 %d 件）",
+		"%s failed: %v": "%s が失敗しました：%v",
+		"%s reported no facts or sources; check logs/%s_result.md": "%s は事実もソースも報告しませんでした。logs/%s_result.md を確認してください",
+		"%s completed: %d fact(s), %d source(s) (%s)":              "%s が完了しました：事実 %d 件、ソース %d 件（%s）",
+		"%d research task(s) failed and stay pending: %s":          "リサーチタスク %d 件が失敗し、未完了のまま残っています：%s",
+		"Executing %s: %s": "%s を実行しています：%s",
+		"Agent attempt %d/%d failed: %v; retrying in %s":                                         "エージェントの試行 %d/%d が失敗しました：%v。%s 後に再試行します",
+		"Agent resource limits are not enforced: %v":                                             "エージェントのリソース制限は適用されていません：%v",
+		"Cgroups unavailable (%v); limiting each agent process's address space to %d MB instead": "cgroups を使用できません（%v）。代わりに各エージェントプロセスのアドレス空間を %d MB に制限します",
+
+		// Shutdown
+		"Received %s, stopping the running agents...": "%s を受信しました。実行中のエージェントを停止しています…",
+		"Run interrupted; continue it with --resume":  "実行が中断されました。--resume で続行できます",
+		"Agents still running after %s; leaving them": "%s 経過後もエージェントが実行中です。そのままにします",
+
+		// Privacy, replay and reproducibility
+		"Retention policy: removed %d file(s) older than %d days":         "保持ポリシー：%[2]d 日より古いファイル %[1]d 件を削除しました",
+		"Ephemeral mode: removed temporary files, transcripts and caches": "エフェメラルモード：一時ファイル、トランスクリプト、キャッシュを削除しました",
+		"Archived asset %s is unavailable: %v":                            "アーカイブ済みのアセット %s を利用できません：%v",
+		"Compressed asset %s is unavailable: %v":                          "圧縮済みのアセット %s を利用できません：%v",
+		"Reproducibility bundle failed: %v":                               "再現用バンドルの作成に失敗しました：%v",
+		"Reproducibility bundle (%d files): %s":                           "再現用バンドル（%d ファイル）：%s",
+		"The privacy policy kept %s out of the bundle":                    "プライバシーポリシーにより %s はバンドルに含めていません",
+
+		// Storage
+		"assets/ exceeded storage.max_assets_mb (%d MB): evicted %d uncited file(s), %.1f MB":                                    "assets/ が storage.max_assets_mb（%d MB）を超えました：引用されていないファイル %d 件（%.1f MB）を削除しました",
+		"assets/ holds %.1f MB of cited sources, over storage.max_assets_mb (%d MB); raise the limit or expect the disk to fill": "assets/ の引用済みソースは %.1f MB で、storage.max_assets_mb（%d MB）を超えています。上限を引き上げないとディスクが一杯になる恐れがあります",
+		"assets/ is at %.1f of %d MB (storage.max_assets_mb); beyond it the oldest uncited files are evicted":                    "assets/ は %.1f / %d MB（storage.max_assets_mb）です。超えると最も古い未引用ファイルから削除されます",
+		"Shared cache exceeded storage.max_cache_mb (%d MB): evicted %d least recently used file(s)":                             "共有キャッシュが storage.max_cache_mb（%d MB）を超えました：最近使われていないファイル %d 件を削除しました",
+		"Shared cache %s is at %.1f of %d MB (storage.max_cache_mb)":                                                             "共有キャッシュ %s は %.1f / %d MB（storage.max_cache_mb）です",
+		"Could not keep the text of %s: %v":                         "%s のテキストを保存できませんでした：%v",
+		"Could not archive %s: %v":                                  "%s をアーカイブできませんでした：%v",
+		"Could not compress %s: %v":                                 "%s を圧縮できませんでした：%v",
+		"Compressed %d large text asset(s) to .gz":                  "大きなテキストアセット %d 件を .gz に圧縮しました",
+		"Archived %d raw asset(s) to %s (see assets/manifest.json)": "元のアセット %d 件を %s にアーカイブしました（assets/manifest.json を参照）",
+
+		// Translation
+		"Translating %d non-%s source(s) with %s": "%[3]s で %[2]s 以外のソース %[1]d 件を翻訳しています",
+		"Translation agent failed: %v":            "翻訳エージェントが失敗しました：%v",
+		"Translation disabled: %v":                "翻訳は無効です：%v",
+		"Could not translate %s: %v":              "%s を翻訳できませんでした：%v",
+		"Could not write translation for %s: %v":  "%s の翻訳を書き込めませんでした：%v",
+
+		// Lint, verify, view and watch
+		"Cannot lint: %v":                "lint を実行できません：%v",
+		"%s: %d error(s), %d warning(s)": "%s：エラー %d 件、警告 %d 件",
+		"%s: no errors, %d warning(s)":   "%s：エラーなし、警告 %d 件",
+		"task.md has %d lint error(s) after %s (run `deepresearch lint` for details):": "%[2]s の後、task.md に lint エラーが %[1]d 件あります（詳細は `deepresearch lint` を実行）：",
+		"Verification failed: %d problem(s) in %s":                                     "検証に失敗しました：%[2]s に問題が %[1]d 件あります",
+		"Verified %d asset(s), findings and report citations in %s":                    "%[2]s のアセット %[1]d 件、調査結果、レポートの引用を検証しました",
+		"Serving %s at http://%s (Ctrl-C to stop)":                                     "%s を http://%s で公開しています（Ctrl-C で停止）",
+		"Viewer server failed: %v":                                                     "ビューアサーバーが失敗しました：%v",
+		"Watch: starting run %d":                                                       "監視：実行 %d を開始します",
+		"Watch: run %d failed: %v":                                                     "監視：実行 %d が失敗しました：%v",
+		"Watch: waiting for changes to %s or %s/ (Ctrl+C to stop)":                     "監視：%s または %s/ の変更を待っています（Ctrl+C で停止）",
+		"Watch: change detected":                                                       "監視：変更を検出しました",
+
+		// Batch
+		"batch needs a topics file: -f topics.yaml":                    "batch にはテーマファイルが必要です：-f topics.yaml",
+		"Invalid concurrency: %d (expected 1 or more)":                 "並列数が無効です：%d（1 以上を指定してください）",
+		"Cannot locate the deepresearch executable: %v":                "deepresearch の実行ファイルが見つかりません：%v",
+		"Failed to resolve %s: %v":                                     "%s を解決できませんでした：%v",
+		"Topic %d: %v":                                                 "テーマ %d：%v",
+		"Topics %d and %d share the session %s":                        "テーマ %d と %d が同じセッション %s を使っています",
+		"[%d/%d] %s: started in %s":                                    "[%d/%d] %s：%s で開始しました",
+		"[%d/%d] %s: %s in %s":                                         "[%d/%d] %s：%s（所要時間 %s）",
+		"[%d/%d] %s: %s [%s] %s (see %s)":                              "[%d/%d] %s：%s [%s] %s（%s を参照）",
+		"Could not create %s: %v":                                      "%s を作成できませんでした：%v",
+		"Could not write %s.json: %v":                                  "%s.json を書き込めませんでした：%v",
+		"Batch interrupted: %d of %d topic(s) succeeded (summary: %s)": "バッチが中断されました：%[2]d 件中 %[1]d 件のテーマが成功しました（概要：%[3]s）",
+		"%d of %d topic(s) failed, %d skipped (summary: %s)":           "%[2]d 件中 %[1]d 件のテーマが失敗し、%[3]d 件をスキップしました（概要：%[4]s）",
+		"%d topic(s) researched (summary: %s)":                         "%d 件のテーマを調査しました（概要：%s）",
+
+		// Experiments, evaluation and golden cases
+		"experiment needs a topic: -p or -f":                                "experiment にはテーマが必要です：-p または -f",
+		"experiment needs a variant: -b and/or -config-b":                   "experiment にはバリアントが必要です：-b と -config-b のいずれかまたは両方",
+		"Invalid -reuse value: %s (expected research, synthesis or none)":   "-reuse の値が無効です：%s（research、synthesis、none のいずれかを指定してください）",
+		"Failed to resolve experiment directory: %v":                        "実験ディレクトリを解決できませんでした：%v",
+		"Failed to create %s: %v":                                           "%s を作成できませんでした：%v",
+		"Variant %s failed: %v":                                             "バリアント %s が失敗しました：%v",
+		"Variant B needs the sources of variant A; stopping the experiment": "バリアント B にはバリアント A のソースが必要です。実験を中止します",
+		"Could not write experiment.json: %v":                               "experiment.json を書き込めませんでした：%v",
+		"Could not write experiment.md: %v":                                 "experiment.md を書き込めませんでした：%v",
+		"Experiment results: %s":                                            "実験結果：%s",
+		"Cannot evaluate %s: %v":                                            "%s を評価できません：%v",
+		"No golden cases in %s":                                             "%s にゴールデンケースがありません",
+		"Updated %s":                                                        "%s を更新しました",
+		"%d of %d golden case(s) failed (rerun with -update if the change is intended)": "%[2]d 件中 %[1]d 件のゴールデンケースが失敗しました（意図した変更なら -update を付けて再実行してください）",
+		"Recorded %d golden case(s)": "ゴールデンケース %d 件を記録しました",
+		"%d golden case(s) passed":   "ゴールデンケース %d 件が成功しました",
+		"%s: workspace kept in %s":   "%s：ワークスペースを %s に残しました",
+
+		// Connectors
+		"deepresearch tool is disabled during an offline replay; use the sources under assets/": "オフライン再生中は deepresearch tool を使用できません。assets/ 以下のソースを使ってください",
+		"Unknown or disabled connector: %s (run `deepresearch tool list`)":                      "不明または無効なコネクタです：%s（`deepresearch tool list` を実行してください）",
+		"Usage: deepresearch tool %s <%s>":                                                      "使い方：deepresearch tool %s <%s>",
+		"Failed to resolve workspace: %v":                                                       "ワークスペースを解決できませんでした：%v",
+		"Jira: %s matches %d tickets; only the first %d were ingested":                          "Jira：%s に一致するチケットが %d 件あります。最初の %d 件のみ取り込みました",
+		"Skipped %d unreadable message(s) in %s":                                                "%[2]s の読み取れないメッセージ %[1]d 件をスキップしました",
+
+		// Server
+		"Failed to resolve job root: %v":  "ジョブのルートを解決できませんでした：%v",
+		"Failed to create job root: %v":   "ジョブのルートを作成できませんでした：%v",
+		"Cannot open %s queue: %v":        "%s キューを開けません：%v",
+		"Cannot open %s coordination: %v": "%s コーディネーションを開けません：%v",
+		"Invalid server.tenants: %v":      "server.tenants が無効です：%v",
+		"No server.tenants configured: anyone who can reach %s can submit and read jobs": "server.tenants が未設定です：%s にアクセスできる誰もがジョブを投入・閲覧できます",
+		"Invalid server.kubernetes: %v":                              "server.kubernetes が無効です：%v",
+		"Invalid server.executor: %s (expected local or kubernetes)": "server.executor が無効です：%s（local または kubernetes を指定してください）",
+		"Cannot read the job queue: %v":                              "ジョブキューを読み込めません：%v",
+		"Re-queued %d job(s) interrupted by a stopped server":        "停止したサーバーで中断されたジョブ %d 件を再投入しました",
+		"Queue: %v": "キュー：%v",
+		"Re-queued %d job(s) from a server that stopped renewing them": "リースの更新が止まったサーバーのジョブ %d 件を再投入しました",
+		"gRPC server failed: %v":     "gRPC サーバーが失敗しました：%v",
+		"Serving the gRPC API at %s": "gRPC API を %s で公開しています",
+		"Serving research jobs at http://%s (%s queue, %d worker(s), %d tenant(s), workspaces in %s)": "リサーチジョブを http://%s で公開しています（%s キュー、ワーカー %d 個、テナント %d 個、ワークスペースは %s）",
+		"Server failed: %v":          "サーバーが失敗しました：%v",
+		"Coordination: %v":           "コーディネーション：%v",
+		"Job %s: attempt %d started": "ジョブ %s：試行 %d を開始しました",
+		"Job %s: lease lost to another server; abandoning attempt %d": "ジョブ %s：リースを別のサーバーに取られました。試行 %d を中止します",
+		"Coordination: cannot checkpoint job %s: %v":                  "コーディネーション：ジョブ %s のチェックポイントを保存できません：%v",
+		"Job %s: completed":                           "ジョブ %s：完了しました",
+		"Job %s: attempt %d failed [%s] %s; retrying": "ジョブ %s：試行 %d が失敗しました [%s] %s。再試行します",
+		"Job %s: failed [%s] %s":                      "ジョブ %s：失敗しました [%s] %s",
+		"Queue: cannot save job %s: %v":               "キュー：ジョブ %s を保存できません：%v",
+		"Job %s: canceled":                            "ジョブ %s：キャンセルされました",
+
+		// Remote workers
+		"Failed to resolve research directory: %v":                               "リサーチディレクトリを解決できませんでした：%v",
+		"Cannot connect to the workers broker: %v":                               "ワーカーのブローカーに接続できません：%v",
+		"Cannot pack task inputs: %v":                                            "タスクの入力をまとめられません：%v",
+		"%s completed by %s in %s (%d file(s))":                                  "%s が %s により完了しました（所要時間 %s、%d ファイル）",
+		"%d task(s) failed: %s":                                                  "タスク %d 件が失敗しました：%s",
+		"Worker %s waiting for executor tasks (%s broker, agent %s, %d slot(s))": "ワーカー %s が実行タスクを待っています（%s ブローカー、エージェント %s、スロット %d 個）",
+		"Cannot fetch a task: %v":                                                "タスクを取得できません：%v",
+		"Task %s (%s) started":                                                   "タスク %s（%s）を開始しました",
+		"Cannot report task %s: %v":                                              "タスク %s を報告できません：%v",
+		"Task %s failed: %s":                                                     "タスク %s が失敗しました：%s",
+		"Task %s completed":                                                      "タスク %s が完了しました",
+
+		// Sessions
+		"Cannot list sessions: %v": "セッションを一覧表示できません：%v",
+		"No sessions in %s/":       "%s/ にセッションがありません",
+		"Session %s has no %s":     "セッション %s に %s がありません",
+		"Name the sessions to clean, or select them with -all, -older-than or -status": "削除するセッションを指定するか、-all、-older-than、-status で選択してください",
+		"Skipping %s: its run may still be going on (use -force to clean it anyway)":   "%s をスキップします：実行がまだ続いている可能性があります（それでも削除するには -force を使用）",
+		"Could not remove %s: %v":           "%s を削除できませんでした：%v",
+		"Cleaned %d session(s), freeing %s": "セッション %d 件を削除し、%s を解放しました",
+
+		// Telemetry
+		"Telemetry: off (DO_NOT_TRACK=1)":                                                       "テレメトリ：オフ（DO_NOT_TRACK=1）",
+		"Telemetry: on, reports are sent to %s":                                                 "テレメトリ：オン、レポートは %s に送信されます",
+		"Telemetry: on, but telemetry.endpoint is not set; reports are only recorded":           "テレメトリ：オンですが telemetry.endpoint が未設定のため、レポートは記録のみされます",
+		"Telemetry: local, reports are recorded but never sent":                                 "テレメトリ：ローカル、レポートは記録されますが送信されません",
+		"Telemetry: off. Opt in with --telemetry=local or on, or telemetry.mode in config.yaml": "テレメトリ：オフ。--telemetry=local または on、あるいは config.yaml の telemetry.mode で有効にできます",
+		"No reports recorded in %s":                                                             "%s に記録されたレポートはありません",
+		"%d report(s) recorded in %s":                                                           "%[2]s にレポート %[1]d 件が記録されています",
+	})
+}
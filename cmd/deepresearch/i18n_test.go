@@ -0,0 +1,141 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// translatedArgs maps the output helpers that translate a message to the
+// position of its format string
+var translatedArgs = map[string]int{
+	"info":          0,
+	"success":       0,
+	"warn":          0,
+	"fatal":         0,
+	"fatalCode":     1,
+	"completePhase": 0,
+	"phase":         1,
+	"tr":            0,
+}
+
+// formatVerbRe matches a fmt verb, e.g. %s, %.1f or %[2]d
+var formatVerbRe = regexp.MustCompile(`%[-+# 0-9.\[\]*]*[a-zA-Z%]`)
+
+// translatableMessages returns the literal messages the package passes to the
+// output helpers, with the file and line of their first use
+func translatableMessages(t *testing.T) map[string]string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	messages := map[string]string{}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := call.Fun.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			i, ok := translatedArgs[fn.Name]
+			if !ok || i >= len(call.Args) {
+				return true
+			}
+			lit, ok := call.Args[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			msg, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatalf("%s: %v", fset.Position(lit.Pos()), err)
+			}
+			// Messages that are only verbs and punctuation need no translation
+			if strings.IndexFunc(formatVerbRe.ReplaceAllString(msg, ""), isLetter) < 0 {
+				return true
+			}
+			if _, seen := messages[msg]; !seen {
+				messages[msg] = fset.Position(lit.Pos()).String()
+			}
+			return true
+		})
+	}
+	return messages
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// TestCatalogsComplete fails for every message shown to the user that a
+// registered catalog does not translate
+func TestCatalogsComplete(t *testing.T) {
+	messages := translatableMessages(t)
+	if len(messages) == 0 {
+		t.Fatal("found no messages")
+	}
+	for _, lang := range sortedKeys(catalogs) {
+		var missing []string
+		for msg, pos := range messages {
+			if _, ok := catalogs[lang][msg]; !ok {
+				missing = append(missing, pos+": "+strconv.Quote(msg))
+			}
+		}
+		slices.Sort(missing)
+		for _, m := range missing {
+			t.Errorf("%s catalog has no translation for %s", lang, m)
+		}
+	}
+}
+
+// TestCatalogsKeepVerbs checks that translations use the verbs of their
+// message for the same arguments
+func TestCatalogsKeepVerbs(t *testing.T) {
+	argIndexRe := regexp.MustCompile(`\[(\d+)\]`)
+	verbs := func(s string) []string {
+		// Verbs are keyed by argument, so %[2]s %[1]d matches %d %s
+		byArg := map[int]string{}
+		arg := 0
+		for _, m := range formatVerbRe.FindAllString(s, -1) {
+			if m == "%%" {
+				continue
+			}
+			if idx := argIndexRe.FindStringSubmatch(m); idx != nil {
+				arg, _ = strconv.Atoi(idx[1])
+			} else {
+				arg++
+			}
+			byArg[arg] = argIndexRe.ReplaceAllString(m, "")
+		}
+		v := make([]string, 0, len(byArg))
+		for _, i := range slices.Sorted(maps.Keys(byArg)) {
+			v = append(v, byArg[i])
+		}
+		return v
+	}
+	for _, lang := range sortedKeys(catalogs) {
+		for msg, translation := range catalogs[lang] {
+			if !slices.Equal(verbs(msg), verbs(translation)) {
+				t.Errorf("%s: %q has verbs %v, its translation %q has %v", lang, msg, verbs(msg), translation, verbs(translation))
+			}
+		}
+	}
+}
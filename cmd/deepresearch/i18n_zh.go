@@ -0,0 +1,425 @@
+package main
+
+func init() {
+	registerCatalog("zh", map[string]string{
+		// Startup
+		"Enter your research topic: ":                                                   "请输入研究主题：",
+		"Read prompt from file: %s":                                                     "已从文件读取提示词：%s",
+		"Auto-detected agent: %s":                                                       "自动检测到代理：%s",
+		"Using prompts from: %s":                                                        "使用提示词目录：%s",
+		"Working directory: %s":                                                         "工作目录：%s",
+		"Using model: %s":                                                               "使用模型：%s",
+		"Zotero import failed: %v":                                                      "Zotero 导入失败：%v",
+		"Ingested context: %s":                                                          "已导入上下文：%s",
+		"Starting %s in interactive mode with -i flag...":                               "正在以交互模式（-i）启动 %s……",
+		"The planner is done (%s), stopping the agent...":                               "规划者已完成（%s），正在停止代理……",
+		"The agent did not exit within %ds of the interrupt; killing it":                "代理在中断后 %d 秒内未退出，正在强制终止",
+		"Imported %d pre-approved source(s) from Zotero into %s":                        "已从 Zotero 导入 %d 个预先批准的来源到 %s",
+		"Replaying run %s from %s (%d cached file(s)); agents work offline":             "正在从 %[2]s 重放运行 %[1]s（%[3]d 个缓存文件）；代理将离线工作",
+		"Interactive mode: You can discuss and refine the research plan with the agent": "交互模式：你可以与代理讨论并完善研究计划",
+		"The agent will automatically exit after creating %s":                           "代理创建 %s 后将自动退出",
+		"Resuming run %s after %s":                                                      "正在从 %[2]s 之后继续运行 %[1]s",
+		"Resuming run %s: no phase had completed, starting over with the planner":       "正在继续运行 %s：尚无已完成的阶段，从规划者重新开始",
+		"Cannot resume: %v":                                                             "无法继续运行：%v",
+		"Session: %s":                                                                   "会话：%s",
+		"Cannot create the session: %v":                                                 "无法创建会话：%v",
+
+		// Interactive planner banner
+		"INTERACTIVE PLANNER MODE": "交互式规划模式",
+		"The agent will process your research request and generate a research plan. You can then discuss and refine the plan.": "代理将处理你的研究请求并生成研究计划。随后你可以与代理讨论并完善该计划。",
+		"When you approve the plan, the agent will create %s and the workflow will automatically continue.":                    "计划获得你的批准后，代理会创建 %s，工作流随即自动继续。",
+
+		// Phases
+		"Finding what the request leaves open":             "正在找出请求中尚未明确的内容",
+		"Creating research plan":                           "正在制定研究计划",
+		"Executing research tasks (iteration %d)":          "正在执行研究任务（第 %d 轮）",
+		"Analyzing research quality":                       "正在分析研究质量",
+		"Generating final report":                          "正在生成最终报告",
+		"Translating the report into %s":                   "正在将报告翻译为 %s",
+		"Generating partial report from gathered findings": "正在根据已收集的发现生成部分报告",
+		"Clarifying questions written: %s":                 "澄清问题已写入：%s",
+		"Research plan created: %s":                        "研究计划已创建：%s",
+		"Research tasks completed":                         "研究任务已完成",
+		"Reflection completed":                             "反思已完成",
+		"Report created: %s":                               "报告已创建：%s",
+		"Report translated: %s":                            "报告译文已创建：%s",
+		"Partial report created: %s":                       "部分报告已创建：%s",
+		"Elapsed: %s | Phase ETA: %s | Run ETA: %s":        "已用时：%s | 阶段预计：%s | 总体预计：%s",
+		"unknown": "未知",
+		"%s working... %s elapsed, no output for %s":                                                     "%s 工作中……已用时 %s，%s 无输出",
+		"Reflector indicates research is sufficient":                                                     "反思者认为研究已充分",
+		"Reflector added %d new task(s), continuing research loop...":                                    "反思者添加了 %d 个新任务，继续研究循环……",
+		"Reached max iterations (%d) while reflector still requested more research":                      "已达到最大迭代次数（%d），但反思者仍要求继续研究",
+		"Budget reached; skipping %s and the rest of the research loop":                                  "已达到预算；跳过%s及剩余的研究循环",
+		"Estimated spend $%.2f reached the budget of $%.2f; stopping the run":                            "预估花费 $%.2f 已达到预算 $%.2f；正在停止运行",
+		"Estimated spend $%.2f reached the budget of $%.2f; writing the report from the findings so far": "预估花费 $%.2f 已达到预算 $%.2f；将根据目前的发现撰写报告",
+
+		// Sectioned synthesis
+		"Writing report outline: %s/outline.md":                                           "正在撰写报告大纲：%s/outline.md",
+		"Section %d/%d already written: %s":                                               "第 %d/%d 节已写好：%s",
+		"Writing section %d/%d: %s":                                                       "正在撰写第 %d/%d 节：%s",
+		"Assembled %d/%d sections into %s":                                                "已将 %d/%d 节合并为 %s",
+		"Partial report with %d section(s) saved to %s; written sections are kept in %s/": "含 %d 节的部分报告已保存到 %s；已写好的章节保留在 %s/",
+
+		// Outputs
+		"Could not save citation metadata: %v":                "无法保存引用元数据：%v",
+		"Resolved metadata for %d source(s) via Crossref":     "已通过 Crossref 解析 %d 个来源的元数据",
+		"Could not add charts to %s: %v":                      "无法向 %s 添加图表：%v",
+		"Added %d %s chart(s) to %s":                          "已添加 %d 个 %s 图表到 %s",
+		"Could not export data tables: %v":                    "无法导出数据表：%v",
+		"Exported %d data table(s) to %s/":                    "已导出 %d 个数据表到 %s/",
+		"Could not write bibliography: %v":                    "无法写入参考文献：%v",
+		"Bibliography with %d source(s) written to %s and %s": "包含 %d 个来源的参考文献已写入 %s 和 %s",
+		"Could not write %s: %v":                              "无法写入 %s：%v",
+		"Data appendix written to %s":                         "数据附录已写入 %s",
+		"Report exported to %s":                               "报告已导出为 %s",
+		"Checking citations":                                  "正在检查引用",
+		"Citations checked":                                   "引用检查完成",
+		"All %d cited source(s) check out against the workspace; links were not requested":                   "%d 个被引用的来源均与工作区核对无误；未请求链接",
+		"All %d cited source(s) and %d link(s) check out":                                                    "%d 个被引用的来源和 %d 个链接均核对无误",
+		"Found %d citation problem(s); added re-sourcing task(s) %s":                                         "发现 %d 个引用问题；已添加重新取证任务 %s",
+		"Found %d citation problem(s); marked with † in %s and listed under Citation Check":                  "发现 %d 个引用问题；已在 %s 中以 † 标注并列入 Citation Check 一节",
+		"Researching replacements for broken citations, then synthesizing again...":                          "正在为失效引用寻找替代来源，随后重新综合...",
+		"--tui needs a terminal; showing the console output instead":                                         "--tui 需要终端；改为显示控制台输出",
+		"Could not start the dashboard: %v":                                                                  "无法启动仪表盘：%v",
+		"Full console and agent output: %s":                                                                  "完整的控制台与代理输出：%s",
+		"Could not send %s notification: %v":                                                                 "无法发送 %s 通知：%v",
+		"The %s runs %s with model %s":                                                                       "%s 使用 %s，模型 %s",
+		"the agent's default":                                                                                "代理的默认模型",
+		"Research plan saved to: %s":                                                                         "研究计划已保存到：%s",
+		"Review or edit it, then continue this run with --resume, or run it anywhere with --execute-plan %s": "审阅或编辑后，用 --resume 继续本次运行，或在任意位置用 --execute-plan %s 执行",
+		"Executing the plan in %s (%d pending task(s)); the planner is skipped":                              "执行 %s 中的计划（%d 个待办任务）；跳过规划者",
+		"Could not read the source cache: %v":                                                                "无法读取来源缓存：%v",
+		"Could not update the source cache: %v":                                                              "无法更新来源缓存：%v",
+		"%d source(s) fetched by earlier runs are listed in %s for reuse":                                    "之前运行抓取的 %d 个来源已列入 %s，可供复用",
+		"Source cache: %d source(s) reused, %d added":                                                        "来源缓存：复用 %d 个来源，新增 %d 个",
+		"Serving Prometheus metrics at http://%s/metrics":                                                    "Prometheus 指标地址：http://%s/metrics",
+		"Could not send traces to %s: %v":                                                                    "无法将追踪数据发送到 %s：%v",
+		"%s handed off partial work with %d follow-up(s)":                                                    "%s 交接了部分完成的工作，附 %d 项后续事项",
+		"%s (schema: %s; --handoff check accepts steps without one)":                                         "%s（schema：%s；--handoff check 允许步骤不写入该文件）",
+		"%s (schema: %s; the file is kept for inspection)":                                                   "%s（schema：%s；该文件已保留以供检查）",
+		"Ending the research loop: the reflector's confidence reached the --stop-confidence threshold":       "结束研究循环：反思者的置信度已达到 --stop-confidence 阈值",
+		"Ending the research loop: %d iteration(s) in a row made no progress (--stop-stalled)":               "结束研究循环：连续 %d 次迭代没有进展（--stop-stalled）",
+		"Ending the research loop: it reached its limit of %d minute(s) (--max-research-minutes)":            "结束研究循环：已达到 %d 分钟的时长上限（--max-research-minutes）",
+		"%s reads as %s rather than %s":                                                                      "%s 的语言似乎是 %s，而不是 %s",
+		"%s lacks %d citation(s) of %s: %s":                                                                  "%[1]s 缺少 %[3]s 中的 %[2]d 处引用：%[4]s",
+		"%s lacks %d figure(s) of %s: %s":                                                                    "%[1]s 缺少 %[3]s 中的 %[2]d 张图：%[4]s",
+		"Translations saved to: %s":                                                                          "译文已保存到：%s",
+		"Answer each question, or press Enter to leave it to the planner":                                    "请逐一回答问题，或按 Enter 交由规划者决定",
+		"Using the answers to %d clarifying question(s) in %s":                                               "使用 %[2]s 中对 %[1]d 个澄清问题的回答",
+		"The clarifier found the request specific enough to plan; no questions to ask":                       "澄清者认为请求已足够明确，可以直接规划；无需提问",
+		"The planner gets your answers to %d of %d question(s)":                                              "规划者将获得你对 %[2]d 个问题中 %[1]d 个的回答",
+		"Approve the plan [a], edit it [e], or send feedback to the planner [f]: ":                           "批准计划 [a]、编辑计划 [e]，或向规划者反馈意见 [f]：",
+		"Sending the plan back to the planner with your feedback...":                                         "正在将计划连同你的反馈退回给规划者...",
+		"Research plan (revision %d)":                                                                        "研究计划（第 %d 次修订）",
+		"%d task(s); the full plan is in %s":                                                                 "共 %d 个任务；完整计划见 %s",
+		"What should the planner change? ":                                                                   "规划者应修改什么？",
+		"Could not edit the plan: %v":                                                                        "无法编辑计划：%v",
+		"Initialized a git repository in %s":                                                                 "已在 %s 中初始化 git 仓库",
+		"Committing the artifacts to the git repository %s after each phase":                                 "每个阶段结束后将产物提交到 git 仓库 %s",
+		"Could not commit the artifacts of %s: %v":                                                           "无法提交 %s 的产物：%v",
+		"Could not open the agent log: %v":                                                                   "无法打开代理日志：%v",
+		"execution.mode pwsh does not apply to sandboxed agents; they run directly in their containers":      "execution.mode pwsh 不适用于沙箱中的代理；它们直接在各自的容器中运行",
+		"No agent to detect: with --sandbox the agent CLIs run in the image, so pass --agent":                "无法自动检测代理：使用 --sandbox 时代理 CLI 在镜像中运行，请通过 --agent 指定",
+		"Agents run in %s":     "代理运行于 %s",
+		"Loop ended:":          "循环结束：",
+		"research sufficient":  "研究已充分",
+		"Plan approved":        "计划已批准",
+		"Research plan":        "研究计划",
+		"Clarifying questions": "澄清问题",
+		"confidence reached":   "已达到置信度",
+		"no progress":          "没有进展",
+		"time limit reached":   "已达到时长上限",
+		"iterations exhausted": "迭代次数已用尽",
+		"budget reached":       "已达到预算",
+		"The run keeps the layout of the run it continues: task file %s, report file %s, assets directory %s": "本次运行沿用所续运行的布局：任务文件 %s，报告文件 %s，资料目录 %s",
+		"Could not read the knowledge base: %v":                                "无法读取知识库：%v",
+		"Skipped %d malformed line(s) of %s":                                   "已跳过 %d 行格式错误的内容（%s）",
+		"None of the %d finding(s) in the knowledge base relate to this topic": "知识库中的 %d 条发现均与本主题无关",
+		"%d finding(s) of earlier runs are listed in %s":                       "之前运行的 %d 条发现已列入 %s",
+		"Knowledge base: %d finding(s) added, %d already known":                "知识库：新增 %d 条发现，%d 条已存在",
+		"elapsed %s":         "已用时 %s",
+		"Phases":             "阶段",
+		"Tasks (%d/%d done)": "任务（已完成 %d/%d）",
+		"Log":                "日志",
+		"Agent output: %s  ·  Ctrl-C stops the run": "代理输出：%s  ·  Ctrl-C 停止运行",
+		"(running)":        "（运行中）",
+		"(ready)":          "（就绪）",
+		"(waiting for %s)": "（等待 %s）",
+		"%s: %d character(s) are outside the PDF font and were replaced; set export.pdf_font to a TrueType font that covers them": "%s：有 %d 个字符超出 PDF 字体范围，已被替换；请将 export.pdf_font 设置为包含这些字符的 TrueType 字体",
+		"Zotero export failed: %v":               "Zotero 导出失败：%v",
+		"Added %d citation(s) to Zotero":         "已向 Zotero 添加 %d 条引用",
+		"Research complete! Report saved to: %s": "研究完成！报告已保存到：%s",
+		"Report: %s":                             "报告：%s",
+		"Could not open report: %v":              "无法打开报告：%v",
+
+		// Salvage
+		"Research has gathered findings. Generate a partial report from them? [y/N]: ": "研究已收集到一些发现。是否据此生成部分报告？[y/N]：",
+		"Salvage synthesis failed: %v":         "抢救性综合失败：%v",
+		"Salvage synthesis did not create %s":  "抢救性综合未生成 %s",
+		"Could not mark report as partial: %v": "无法将报告标记为部分报告：%v",
+		"Run aborted (%s); %s is PARTIAL":      "运行已中止（%s）；%s 为部分报告",
+
+		// Errors
+		"Failed to resolve working directory: %v":          "无法解析工作目录：%v",
+		"Failed to read prompt file: %v":                   "无法读取提示词文件：%v",
+		"Prompt file is empty":                             "提示词文件为空",
+		"Failed to read input: %v":                         "无法读取输入：%v",
+		"Research topic cannot be empty":                   "研究主题不能为空",
+		"No supported agent CLI found. Install one of: %s": "未找到受支持的代理 CLI。请安装以下之一：%s",
+		"Unknown agent: %s. Supported: %s":                 "未知代理：%s。支持：%s",
+		"Agent '%s' is not installed or not in PATH":       "代理 '%s' 未安装或不在 PATH 中",
+		"Agent '%s' cannot run: %v":                        "代理 '%s' 无法运行：%v",
+		"Cannot load prompts: %v":                          "无法加载提示词: %v",
+		"Context ingestion failed: %v":                     "上下文导入失败：%v",
+		"Planner failed: %v":                               "规划者失败：%v",
+		"Planner did not create %s":                        "规划者未创建 %s",
+		"Research-Supervisor failed: %v":                   "研究主管失败：%v",
+		"Reflector failed: %v":                             "反思者失败：%v",
+		"Synthesizer failed: %v":                           "综合者失败：%v",
+		"Synthesizer did not create report.md":             "综合者未创建 report.md",
+		"Invalid --lang value: %v":                         "无效的 --lang 值：%v",
+
+		// Run summary
+		"Run summary":                         "运行摘要",
+		"RUN SUMMARY":                         "运行摘要",
+		"Phase":                               "阶段",
+		"Duration":                            "耗时",
+		"Status":                              "状态",
+		"Total time:":                         "总耗时：",
+		"Iterations:":                         "迭代：",
+		"Tasks:":                              "任务：",
+		"%d / %d completed":                   "已完成 %d / %d",
+		"Sources:":                            "来源：",
+		"Estimated cost:":                     "预估成本：",
+		"n/a":                                 "不适用",
+		"%d tokens":                           "%d 个令牌",
+		"Tokens":                              "令牌",
+		"Cost":                                "成本",
+		"$%.2f (%s input + %s output tokens)": "$%.2f（输入 %s + 输出 %s 令牌）",
+		" of $%.2f budget":                    "，预算 $%.2f",
+		"No price for %s; their tokens are not in the estimated cost (set cost.prices)": "%s 没有价格；其令牌未计入预估成本（请设置 cost.prices）",
+		"--budget had no effect: the agent reported no token usage":                     "--budget 未生效：代理未报告令牌用量",
+		"--budget cannot be enforced while %s runs: it reports no token usage":          "--budget 无法约束 %s：它不报告令牌用量",
+		"Warnings:": "警告：",
+
+		// Validation
+		"Invalid config: %v": "无效的配置：%v",
+		"Invalid --cache-ttl-days value: %d (expected days, or 0 for no expiry)":                                                                 "无效的 --cache-ttl-days 值：%d（应为天数，0 表示永不过期）",
+		"Invalid storage.cache_ttl_days value: %d (expected days, or 0 for no expiry)":                                                           "无效的 storage.cache_ttl_days 值：%d（应为天数，0 表示永不过期）",
+		"Invalid knowledge.max_findings value: %d (expected a count, or 0 for all)":                                                              "无效的 knowledge.max_findings 值：%d（应为数量，0 表示全部）",
+		"Invalid --max-iterations value: %d (expected 1 or more)":                                                                                "无效的 --max-iterations 值：%d（应为 1 或更大）",
+		"Invalid run.max_iterations value: %d (expected 1 or more)":                                                                              "无效的 run.max_iterations 值：%d（应为 1 或更大）",
+		"Invalid --stop-confidence value: %g (expected 0 to 1, or 0 for off)":                                                                    "无效的 --stop-confidence 值：%g（应在 0 到 1 之间，0 表示关闭）",
+		"Invalid --max-research-minutes value: %d (expected minutes, or 0 for no limit)":                                                         "无效的 --max-research-minutes 值：%d（应为分钟数，0 表示不限）",
+		"Invalid reflection config: stop_stalled and max_minutes cannot be negative":                                                             "无效的 reflection 配置：stop_stalled 和 max_minutes 不能为负数",
+		"Invalid --phase-timeout value: %d (expected minutes, or 0 for no limit)":                                                                "无效的 --phase-timeout 值：%d（应为分钟数，0 表示不限）",
+		"Invalid run config: retries and retry_backoff_seconds cannot be negative":                                                               "无效的 run 配置：retries 和 retry_backoff_seconds 不能为负数",
+		"Invalid --handoff value: %s (expected check, require or off)":                                                                           "无效的 --handoff 值：%s（应为 check、require 或 off）",
+		"Invalid interactive config: settle_seconds and stop_grace_seconds cannot be negative":                                                   "无效的 interactive 配置：settle_seconds 和 stop_grace_seconds 不能为负数",
+		"Invalid interactive.pty value: %s (expected auto, on or off)":                                                                           "无效的 interactive.pty 值：%s（应为 auto、on 或 off）",
+		"--review-plan cannot be combined with --tui, whose dashboard takes over the console":                                                    "--review-plan 不能与 --tui 同时使用，后者的仪表盘会占用控制台",
+		"--clarify cannot be combined with --tui, whose dashboard takes over the console; answer the questions in a file with --clarify-answers": "--clarify 不能与 --tui 同时使用，后者的仪表盘会占用控制台；请在文件中作答并使用 --clarify-answers",
+		"Invalid workspace layout: %v":                                                                                                           "无效的工作区布局：%v",
+		"--workdir cannot be combined with --session or --in-place":                                                                              "--workdir 不能与 --session 或 --in-place 同时使用",
+		"Invalid --output-format value: %v":                                                                                                      "无效的 --output-format 值：%v",
+		"Invalid --report-lang value: %v":                                                                                                        "无效的 --report-lang 值：%v",
+		"--report-lang lists %d languages, but report.md is written in one; add --translate-report to translate it into each":                    "--report-lang 列出了 %d 种语言，但 report.md 只用一种语言撰写；请添加 --translate-report 以翻译成每种语言",
+		"--translate-report needs the languages to translate the report into, e.g. --report-lang ja":                                             "--translate-report 需要指定报告的目标语言，例如 --report-lang ja",
+		"Invalid --sandbox setup: %v":                                                                                                            "无效的 --sandbox 设置：%v",
+		"Invalid --verify-citations value: %s (expected off, annotate or resource)":                                                              "无效的 --verify-citations 值：%s（应为 off、annotate 或 resource）",
+		"Invalid verification config: rounds cannot be negative, timeout_seconds and concurrency must be 1 or more":                              "无效的 verification 配置：rounds 不能为负数，timeout_seconds 和 concurrency 必须为 1 或更大",
+		"Invalid --budget value: %g (expected USD, or 0 for no limit)":                                                                           "无效的 --budget 值：%g（应为美元金额，0 表示不限）",
+		"Invalid cost.budget_usd value: %g (expected USD, or 0 for no limit)":                                                                    "无效的 cost.budget_usd 值：%g（应为美元金额，0 表示不限）",
+		"Invalid --on-budget value: %s (expected synthesize or abort)":                                                                           "无效的 --on-budget 值：%s（应为 synthesize 或 abort）",
+		"Invalid --telemetry value: %s (expected off, local or on)":                                                                              "无效的 --telemetry 值：%s（应为 off、local 或 on）",
+		"Invalid notifications config: %v":                                                                                                       "无效的 notifications 配置：%v",
+		"Invalid observability config: %v":                                                                                                       "无效的 observability 配置：%v",
+		"Invalid --salvage value: %s (expected ask, auto or off)":                                                                                "无效的 --salvage 值：%s（应为 ask、auto 或 off）",
+		"Invalid --max-parallel value: %d (expected 1 or more, or 0 to leave dispatch to the Research-Supervisor)":                               "无效的 --max-parallel 值：%d（应为 1 或更大，0 表示由研究主管分派）",
+		"Invalid --log-format value: %s (expected text or json)":                                                                                 "无效的 --log-format 值：%s（应为 text 或 json）",
+		"Invalid --charts value: %s (expected off, mermaid or vega-lite)":                                                                        "无效的 --charts 值：%s（应为 off、mermaid 或 vega-lite）",
+		"Invalid --replay-from value: %s (expected synthesis or research)":                                                                       "无效的 --replay-from 值：%s（应为 synthesis 或 research）",
+		"--replay cannot be combined with --watch":                                                                                               "--replay 不能与 --watch 同时使用",
+		"--plan-only cannot be combined with --execute-plan":                                                                                     "--plan-only 不能与 --execute-plan 同时使用",
+		"--plan-only and --execute-plan cannot be combined with --replay, --resume or --watch":                                                   "--plan-only 和 --execute-plan 不能与 --replay、--resume 或 --watch 同时使用",
+		"--resume cannot be combined with --replay or --watch":                                                                                   "--resume 不能与 --replay 或 --watch 同时使用",
+		"--watch requires -f (and no -p)":                                                                                                        "--watch 需要 -f（且不能使用 -p）",
+
+		// Runs
+		"Cannot replay %s: %v":  "无法重放 %s：%v",
+		"Cannot execute %s: %v": "无法执行 %s：%v",
+		"%s records no Original Request; pass the topic with -p or -f":         "%s 没有记录 Original Request；请用 -p 或 -f 传入主题",
+		"The prior run did not persist its topic; pass it with -p or -f":       "先前的运行未保存其主题；请用 -p 或 -f 传入",
+		"The interrupted run did not persist its topic; pass it with -p or -f": "被中断的运行未保存其主题；请用 -p 或 -f 传入",
+		"Cannot use %s as the workspace: %v":                                   "无法将 %s 用作工作区：%v",
+		"Cannot serve metrics on %s: %v":                                       "无法在 %s 上提供指标：%v",
+		"Cannot version the workspace with --git: %v":                          "无法用 --git 对工作区进行版本管理：%v",
+		"Executing (%s): %s":                   "正在执行（%s）：%s",
+		"Warning: Could not open log file: %v": "警告：无法打开日志文件：%v",
+		"Failed to create directory %s: %v":    "无法创建目录 %s：%v",
+		"Answer the %d clarifying question(s) in %s, then run again with --clarify-answers %s": "请在 %[2]s 中回答 %[1]d 个澄清问题，然后使用 --clarify-answers %[3]s 重新运行",
+		"Cannot read the answers in %s: %v":                                                    "无法读取 %s 中的回答：%v",
+		"Source freshness: %s":                                                                 "来源时效性：%s",
+		"Fast-moving topic relies on %d source(s) older than %d days: %s":                      "快速变化的主题依赖 %d 个超过 %d 天的来源：%s",
+
+		// Parallel research
+		"Wave %d: dispatching %s (up to %d at once)": "第 %d 波：正在分派 %s（最多同时 %d 个）",
+		"%s failed: %v": "%s 失败：%v",
+		"%s reported no facts or sources; check logs/%s_result.md": "%s 未报告任何事实或来源；请查看 logs/%s_result.md",
+		"%s completed: %d fact(s), %d source(s) (%s)":              "%s 已完成：%d 条事实，%d 个来源（%s）",
+		"%d research task(s) failed and stay pending: %s":          "%d 个研究任务失败，仍保持待办：%s",
+		"Executing %s: %s": "正在执行 %s：%s",
+		"Agent attempt %d/%d failed: %v; retrying in %s":                                         "代理第 %d/%d 次尝试失败：%v；%s 后重试",
+		"Agent resource limits are not enforced: %v":                                             "代理资源限制未生效：%v",
+		"Cgroups unavailable (%v); limiting each agent process's address space to %d MB instead": "cgroups 不可用（%v）；改为将每个代理进程的地址空间限制为 %d MB",
+
+		// Shutdown
+		"Received %s, stopping the running agents...": "收到 %s，正在停止运行中的代理……",
+		"Run interrupted; continue it with --resume":  "运行已中断；可用 --resume 继续",
+		"Agents still running after %s; leaving them": "%s 后仍有代理在运行；不再等待",
+
+		// Privacy, replay and reproducibility
+		"Retention policy: removed %d file(s) older than %d days":         "保留策略：已删除 %d 个超过 %d 天的文件",
+		"Ephemeral mode: removed temporary files, transcripts and caches": "临时模式：已删除临时文件、对话记录和缓存",
+		"Archived asset %s is unavailable: %v":                            "已归档的资料 %s 不可用：%v",
+		"Compressed asset %s is unavailable: %v":                          "已压缩的资料 %s 不可用：%v",
+		"Reproducibility bundle failed: %v":                               "可复现包生成失败：%v",
+		"Reproducibility bundle (%d files): %s":                           "可复现包（%d 个文件）：%s",
+		"The privacy policy kept %s out of the bundle":                    "根据隐私策略，%s 未放入包中",
+
+		// Storage
+		"assets/ exceeded storage.max_assets_mb (%d MB): evicted %d uncited file(s), %.1f MB":                                    "assets/ 超出 storage.max_assets_mb（%d MB）：已清除 %d 个未被引用的文件，共 %.1f MB",
+		"assets/ holds %.1f MB of cited sources, over storage.max_assets_mb (%d MB); raise the limit or expect the disk to fill": "assets/ 中被引用的来源占用 %.1f MB，超过 storage.max_assets_mb（%d MB）；请提高上限，否则磁盘可能被占满",
+		"assets/ is at %.1f of %d MB (storage.max_assets_mb); beyond it the oldest uncited files are evicted":                    "assets/ 已用 %.1f / %d MB（storage.max_assets_mb）；超出后将清除最旧的未被引用文件",
+		"Shared cache exceeded storage.max_cache_mb (%d MB): evicted %d least recently used file(s)":                             "共享缓存超出 storage.max_cache_mb（%d MB）：已清除 %d 个最近最少使用的文件",
+		"Shared cache %s is at %.1f of %d MB (storage.max_cache_mb)":                                                             "共享缓存 %s 已用 %.1f / %d MB（storage.max_cache_mb）",
+		"Could not keep the text of %s: %v":                         "无法保留 %s 的文本：%v",
+		"Could not archive %s: %v":                                  "无法归档 %s：%v",
+		"Could not compress %s: %v":                                 "无法压缩 %s：%v",
+		"Compressed %d large text asset(s) to .gz":                  "已将 %d 个大型文本资料压缩为 .gz",
+		"Archived %d raw asset(s) to %s (see assets/manifest.json)": "已将 %d 个原始资料归档到 %s（见 assets/manifest.json）",
+
+		// Translation
+		"Translating %d non-%s source(s) with %s": "正在用 %[3]s 翻译 %[1]d 个非%[2]s来源",
+		"Translation agent failed: %v":            "翻译代理失败：%v",
+		"Translation disabled: %v":                "翻译已禁用：%v",
+		"Could not translate %s: %v":              "无法翻译 %s：%v",
+		"Could not write translation for %s: %v":  "无法写入 %s 的译文：%v",
+
+		// Lint, verify, view and watch
+		"Cannot lint: %v":                "无法检查：%v",
+		"%s: %d error(s), %d warning(s)": "%s：%d 个错误，%d 个警告",
+		"%s: no errors, %d warning(s)":   "%s：无错误，%d 个警告",
+		"task.md has %d lint error(s) after %s (run `deepresearch lint` for details):": "%[2]s 之后 task.md 有 %[1]d 个检查错误（运行 `deepresearch lint` 查看详情）：",
+		"Verification failed: %d problem(s) in %s":                                     "校验失败：%[2]s 中有 %[1]d 个问题",
+		"Verified %d asset(s), findings and report citations in %s":                    "已校验 %[2]s 中的 %[1]d 个资料、研究发现和报告引用",
+		"Serving %s at http://%s (Ctrl-C to stop)":                                     "正在 http://%[2]s 提供 %[1]s（按 Ctrl-C 停止）",
+		"Viewer server failed: %v":                                                     "查看器服务失败：%v",
+		"Watch: starting run %d":                                                       "监视：开始第 %d 次运行",
+		"Watch: run %d failed: %v":                                                     "监视：第 %d 次运行失败：%v",
+		"Watch: waiting for changes to %s or %s/ (Ctrl+C to stop)":                     "监视：等待 %s 或 %s/ 发生变化（按 Ctrl+C 停止）",
+		"Watch: change detected":                                                       "监视：检测到变化",
+
+		// Batch
+		"batch needs a topics file: -f topics.yaml":                    "batch 需要主题文件：-f topics.yaml",
+		"Invalid concurrency: %d (expected 1 or more)":                 "无效的并发数：%d（应为 1 或更大）",
+		"Cannot locate the deepresearch executable: %v":                "找不到 deepresearch 可执行文件：%v",
+		"Failed to resolve %s: %v":                                     "无法解析 %s：%v",
+		"Topic %d: %v":                                                 "主题 %d：%v",
+		"Topics %d and %d share the session %s":                        "主题 %d 和 %d 共用会话 %s",
+		"[%d/%d] %s: started in %s":                                    "[%d/%d] %s：已在 %s 中开始",
+		"[%d/%d] %s: %s in %s":                                         "[%d/%d] %s：%s，用时 %s",
+		"[%d/%d] %s: %s [%s] %s (see %s)":                              "[%d/%d] %s：%s [%s] %s（见 %s）",
+		"Could not create %s: %v":                                      "无法创建 %s：%v",
+		"Could not write %s.json: %v":                                  "无法写入 %s.json：%v",
+		"Batch interrupted: %d of %d topic(s) succeeded (summary: %s)": "批处理已中断：%[2]d 个主题中 %[1]d 个成功（摘要：%[3]s）",
+		"%d of %d topic(s) failed, %d skipped (summary: %s)":           "%[2]d 个主题中 %[1]d 个失败，%[3]d 个跳过（摘要：%[4]s）",
+		"%d topic(s) researched (summary: %s)":                         "已研究 %d 个主题（摘要：%s）",
+
+		// Experiments, evaluation and golden cases
+		"experiment needs a topic: -p or -f":                                "experiment 需要主题：-p 或 -f",
+		"experiment needs a variant: -b and/or -config-b":                   "experiment 需要一个变体：-b 和/或 -config-b",
+		"Invalid -reuse value: %s (expected research, synthesis or none)":   "无效的 -reuse 值：%s（应为 research、synthesis 或 none）",
+		"Failed to resolve experiment directory: %v":                        "无法解析实验目录：%v",
+		"Failed to create %s: %v":                                           "无法创建 %s：%v",
+		"Variant %s failed: %v":                                             "变体 %s 失败：%v",
+		"Variant B needs the sources of variant A; stopping the experiment": "变体 B 需要变体 A 的来源；正在停止实验",
+		"Could not write experiment.json: %v":                               "无法写入 experiment.json：%v",
+		"Could not write experiment.md: %v":                                 "无法写入 experiment.md：%v",
+		"Experiment results: %s":                                            "实验结果：%s",
+		"Cannot evaluate %s: %v":                                            "无法评估 %s：%v",
+		"No golden cases in %s":                                             "%s 中没有黄金用例",
+		"Updated %s":                                                        "已更新 %s",
+		"%d of %d golden case(s) failed (rerun with -update if the change is intended)": "%[2]d 个黄金用例中 %[1]d 个失败（如果变更是预期的，请加 -update 重新运行）",
+		"Recorded %d golden case(s)": "已记录 %d 个黄金用例",
+		"%d golden case(s) passed":   "%d 个黄金用例通过",
+		"%s: workspace kept in %s":   "%s：工作区保留在 %s",
+
+		// Connectors
+		"deepresearch tool is disabled during an offline replay; use the sources under assets/": "离线重放期间 deepresearch tool 已禁用；请使用 assets/ 下的来源",
+		"Unknown or disabled connector: %s (run `deepresearch tool list`)":                      "未知或已禁用的连接器：%s（运行 `deepresearch tool list` 查看）",
+		"Usage: deepresearch tool %s <%s>":                                                      "用法：deepresearch tool %s <%s>",
+		"Failed to resolve workspace: %v":                                                       "无法解析工作区：%v",
+		"Jira: %s matches %d tickets; only the first %d were ingested":                          "Jira：%s 匹配 %d 个工单；只导入了前 %d 个",
+		"Skipped %d unreadable message(s) in %s":                                                "已跳过 %[2]s 中 %[1]d 封无法读取的邮件",
+
+		// Server
+		"Failed to resolve job root: %v":  "无法解析任务根目录：%v",
+		"Failed to create job root: %v":   "无法创建任务根目录：%v",
+		"Cannot open %s queue: %v":        "无法打开 %s 队列：%v",
+		"Cannot open %s coordination: %v": "无法打开 %s 协调：%v",
+		"Invalid server.tenants: %v":      "无效的 server.tenants：%v",
+		"No server.tenants configured: anyone who can reach %s can submit and read jobs": "未配置 server.tenants：任何能访问 %s 的人都可以提交和读取任务",
+		"Invalid server.kubernetes: %v":                              "无效的 server.kubernetes：%v",
+		"Invalid server.executor: %s (expected local or kubernetes)": "无效的 server.executor：%s（应为 local 或 kubernetes）",
+		"Cannot read the job queue: %v":                              "无法读取任务队列：%v",
+		"Re-queued %d job(s) interrupted by a stopped server":        "已将 %d 个因服务器停止而中断的任务重新排队",
+		"Queue: %v": "队列：%v",
+		"Re-queued %d job(s) from a server that stopped renewing them": "已将 %d 个来自停止续租的服务器的任务重新排队",
+		"gRPC server failed: %v":     "gRPC 服务失败：%v",
+		"Serving the gRPC API at %s": "正在 %s 提供 gRPC API",
+		"Serving research jobs at http://%s (%s queue, %d worker(s), %d tenant(s), workspaces in %s)": "正在 http://%s 提供研究任务（%s 队列，%d 个工作进程，%d 个租户，工作区位于 %s）",
+		"Server failed: %v":          "服务失败：%v",
+		"Coordination: %v":           "协调：%v",
+		"Job %s: attempt %d started": "任务 %s：第 %d 次尝试已开始",
+		"Job %s: lease lost to another server; abandoning attempt %d": "任务 %s：租约已被另一台服务器取得；放弃第 %d 次尝试",
+		"Coordination: cannot checkpoint job %s: %v":                  "协调：无法为任务 %s 保存检查点：%v",
+		"Job %s: completed":                           "任务 %s：已完成",
+		"Job %s: attempt %d failed [%s] %s; retrying": "任务 %s：第 %d 次尝试失败 [%s] %s；正在重试",
+		"Job %s: failed [%s] %s":                      "任务 %s：失败 [%s] %s",
+		"Queue: cannot save job %s: %v":               "队列：无法保存任务 %s：%v",
+		"Job %s: canceled":                            "任务 %s：已取消",
+
+		// Remote workers
+		"Failed to resolve research directory: %v":                               "无法解析研究目录：%v",
+		"Cannot connect to the workers broker: %v":                               "无法连接工作节点代理：%v",
+		"Cannot pack task inputs: %v":                                            "无法打包任务输入：%v",
+		"%s completed by %s in %s (%d file(s))":                                  "%s 已由 %s 完成，用时 %s（%d 个文件）",
+		"%d task(s) failed: %s":                                                  "%d 个任务失败：%s",
+		"Worker %s waiting for executor tasks (%s broker, agent %s, %d slot(s))": "工作节点 %s 正在等待执行任务（%s 代理服务，代理 %s，%d 个槽位）",
+		"Cannot fetch a task: %v":                                                "无法获取任务：%v",
+		"Task %s (%s) started":                                                   "任务 %s（%s）已开始",
+		"Cannot report task %s: %v":                                              "无法报告任务 %s：%v",
+		"Task %s failed: %s":                                                     "任务 %s 失败：%s",
+		"Task %s completed":                                                      "任务 %s 已完成",
+
+		// Sessions
+		"Cannot list sessions: %v": "无法列出会话：%v",
+		"No sessions in %s/":       "%s/ 中没有会话",
+		"Session %s has no %s":     "会话 %s 没有 %s",
+		"Name the sessions to clean, or select them with -all, -older-than or -status": "请指定要清理的会话，或用 -all、-older-than 或 -status 选择",
+		"Skipping %s: its run may still be going on (use -force to clean it anyway)":   "跳过 %s：其运行可能仍在进行（使用 -force 强制清理）",
+		"Could not remove %s: %v":           "无法删除 %s：%v",
+		"Cleaned %d session(s), freeing %s": "已清理 %d 个会话，释放 %s",
+
+		// Telemetry
+		"Telemetry: off (DO_NOT_TRACK=1)":                                                       "遥测：关闭（DO_NOT_TRACK=1）",
+		"Telemetry: on, reports are sent to %s":                                                 "遥测：开启，报告发送到 %s",
+		"Telemetry: on, but telemetry.endpoint is not set; reports are only recorded":           "遥测：开启，但未设置 telemetry.endpoint；报告仅在本地记录",
+		"Telemetry: local, reports are recorded but never sent":                                 "遥测：本地，报告仅记录、从不发送",
+		"Telemetry: off. Opt in with --telemetry=local or on, or telemetry.mode in config.yaml": "遥测：关闭。可用 --telemetry=local 或 on，或 config.yaml 中的 telemetry.mode 开启",
+		"No reports recorded in %s":                                                             "%s 中没有已记录的报告",
+		"%d report(s) recorded in %s":                                                           "%[2]s 中记录了 %[1]d 份报告",
+	})
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// linearAPI is the default Linear GraphQL endpoint
+const linearAPI = "https://api.linear.app/graphql"
+
+// maxIssues caps how many tickets one --context spec pulls in
+const maxIssues = 200
+
+func init() {
+	registerContextIngester("jira", ingestJira)
+	registerContextIngester("linear", ingestLinear)
+}
+
+// issueKeyRe matches a tracker issue key such as EPIC-123 or ENG-42
+var issueKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-\d+$`)
+
+// trackerIssue is a provider-independent ticket
+type trackerIssue struct {
+	Key         string
+	Title       string
+	Type        string
+	Status      string
+	Priority    string
+	Labels      []string
+	Created     string
+	Updated     string
+	URL         string
+	Description string
+	Comments    []string // "Author: text"
+}
+
+// renderIssues writes tickets as one Markdown document, the root issue first
+func renderIssues(tracker, selector string, issues []trackerIssue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s tickets: %s\n\n%d ticket(s). Retrieved as research context.\n\n", tracker, selector, len(issues))
+	b.WriteString("| Key | Type | Status | Priority | Title |\n|---|---|---|---|---|\n")
+	for _, is := range issues {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", is.Key, is.Type, is.Status, is.Priority, mdCell(is.Title))
+	}
+	for _, is := range issues {
+		fmt.Fprintf(&b, "\n## %s: %s\n\n", is.Key, is.Title)
+		meta := []string{"Status: " + is.Status}
+		if is.Priority != "" {
+			meta = append(meta, "Priority: "+is.Priority)
+		}
+		if len(is.Labels) > 0 {
+			meta = append(meta, "Labels: "+strings.Join(is.Labels, ", "))
+		}
+		meta = append(meta, "Updated: "+dateOnly(is.Updated), is.URL)
+		fmt.Fprintf(&b, "*%s*\n\n%s\n", strings.Join(meta, " · "), strings.TrimSpace(is.Description))
+		if len(is.Comments) > 0 {
+			b.WriteString("\n### Comments\n\n")
+			for _, c := range is.Comments {
+				fmt.Fprintf(&b, "- %s\n", strings.ReplaceAll(strings.TrimSpace(c), "\n", "\n  "))
+			}
+		}
+	}
+	return b.String()
+}
+
+// dateOnly trims an ISO timestamp to its date
+func dateOnly(ts string) string {
+	if len(ts) > 10 {
+		return ts[:10]
+	}
+	return ts
+}
+
+// ingestJira pulls tickets from Jira. The argument is an epic or parent key
+// (the issue and its children), label=NAME, or jql=QUERY.
+func ingestJira(t *toolContext, arg string) (map[string]string, error) {
+	if t.Config.Endpoint == "" {
+		return nil, fmt.Errorf("jira requires connectors.jira.endpoint (e.g. https://acme.atlassian.net)")
+	}
+	token := t.apiKey("JIRA_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("jira requires a token (connectors.jira.api_key_env, default $JIRA_TOKEN; email:api-token for Cloud)")
+	}
+	var jql string
+	switch {
+	case strings.HasPrefix(arg, "label="):
+		jql = fmt.Sprintf("labels = %q ORDER BY priority DESC, updated DESC", strings.TrimPrefix(arg, "label="))
+	case strings.HasPrefix(arg, "jql="):
+		jql = strings.TrimPrefix(arg, "jql=")
+	case issueKeyRe.MatchString(arg):
+		jql = fmt.Sprintf(`key = %[1]s OR parent = %[1]s OR "Epic Link" = %[1]s ORDER BY key`, arg)
+	default:
+		return nil, fmt.Errorf("expected an issue key, label=NAME or jql=QUERY, got %q", arg)
+	}
+	params := url.Values{
+		"jql":        {jql},
+		"fields":     {"summary,description,status,priority,labels,issuetype,created,updated,comment"},
+		"maxResults": {fmt.Sprint(maxIssues)},
+	}
+	var resp struct {
+		Total  int `json:"total"`
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary     string   `json:"summary"`
+				Description string   `json:"description"`
+				Labels      []string `json:"labels"`
+				Created     string   `json:"created"`
+				Updated     string   `json:"updated"`
+				Status      struct {
+					Name string `json:"name"`
+				} `json:"status"`
+				Priority *struct {
+					Name string `json:"name"`
+				} `json:"priority"`
+				IssueType struct {
+					Name string `json:"name"`
+				} `json:"issuetype"`
+				Comment struct {
+					Comments []struct {
+						Author struct {
+							Name string `json:"displayName"`
+						} `json:"author"`
+						Body string `json:"body"`
+					} `json:"comments"`
+				} `json:"comment"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	base := t.endpoint("")
+	if _, err := t.getJSON(base+"/rest/api/2/search?"+params.Encode(), map[string]string{"Authorization": tokenAuth(token)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Issues) == 0 {
+		return nil, fmt.Errorf("no tickets match %s", jql)
+	}
+	var issues []trackerIssue
+	for _, r := range resp.Issues {
+		f := r.Fields
+		is := trackerIssue{
+			Key: r.Key, Title: f.Summary, Type: f.IssueType.Name, Status: f.Status.Name, Labels: f.Labels,
+			Created: f.Created, Updated: f.Updated, URL: base + "/browse/" + r.Key, Description: f.Description,
+		}
+		if f.Priority != nil {
+			is.Priority = f.Priority.Name
+		}
+		for _, c := range f.Comment.Comments {
+			is.Comments = append(is.Comments, c.Author.Name+": "+c.Body)
+		}
+		issues = append(issues, is)
+	}
+	if resp.Total > len(issues) {
+		warn("Jira: %s matches %d tickets; only the first %d were ingested", arg, resp.Total, len(issues))
+	}
+	return map[string]string{"jira-" + slugify(arg) + ".md": renderIssues("Jira", arg, issues)}, nil
+}
+
+// linearIssueFields is the GraphQL selection for an issue
+const linearIssueFields = `identifier title description url priorityLabel createdAt updatedAt
+	state { name } labels { nodes { name } } comments { nodes { body user { name } } }`
+
+type linearIssue struct {
+	Identifier    string `json:"identifier"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	URL           string `json:"url"`
+	PriorityLabel string `json:"priorityLabel"`
+	CreatedAt     string `json:"createdAt"`
+	UpdatedAt     string `json:"updatedAt"`
+	State         struct {
+		Name string `json:"name"`
+	} `json:"state"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		Nodes []struct {
+			Body string `json:"body"`
+			User *struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"nodes"`
+	} `json:"comments"`
+	Children struct {
+		Nodes []linearIssue `json:"nodes"`
+	} `json:"children"`
+}
+
+func (l linearIssue) issue() trackerIssue {
+	is := trackerIssue{
+		Key: l.Identifier, Title: l.Title, Type: "Issue", Status: l.State.Name, Priority: l.PriorityLabel,
+		Created: l.CreatedAt, Updated: l.UpdatedAt, URL: l.URL, Description: l.Description,
+	}
+	for _, n := range l.Labels.Nodes {
+		is.Labels = append(is.Labels, n.Name)
+	}
+	for _, c := range l.Comments.Nodes {
+		author := "unknown"
+		if c.User != nil {
+			author = c.User.Name
+		}
+		is.Comments = append(is.Comments, author+": "+c.Body)
+	}
+	return is
+}
+
+// ingestLinear pulls issues from Linear. The argument is a parent issue key (the
+// issue and its sub-issues), label=NAME or project=NAME.
+func ingestLinear(t *toolContext, arg string) (map[string]string, error) {
+	key := t.apiKey("LINEAR_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("linear requires an API key (connectors.linear.api_key_env, default $LINEAR_API_KEY)")
+	}
+	var query string
+	variables := map[string]any{}
+	switch {
+	case strings.HasPrefix(arg, "label="):
+		query = `query($v: String!) { issues(first: 200, filter: { labels: { name: { eq: $v } } }) { nodes { ` + linearIssueFields + ` } } }`
+		variables["v"] = strings.TrimPrefix(arg, "label=")
+	case strings.HasPrefix(arg, "project="):
+		query = `query($v: String!) { issues(first: 200, filter: { project: { name: { eq: $v } } }) { nodes { ` + linearIssueFields + ` } } }`
+		variables["v"] = strings.TrimPrefix(arg, "project=")
+	case issueKeyRe.MatchString(arg):
+		query = `query($v: String!) { issue(id: $v) { ` + linearIssueFields + ` children(first: 200) { nodes { ` + linearIssueFields + ` } } } }`
+		variables["v"] = arg
+	default:
+		return nil, fmt.Errorf("expected an issue key, label=NAME or project=NAME, got %q", arg)
+	}
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	var resp struct {
+		Data struct {
+			Issue  *linearIssue `json:"issue"`
+			Issues struct {
+				Nodes []linearIssue `json:"nodes"`
+			} `json:"issues"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := postJSON(t.client, t.endpoint(linearAPI), map[string]string{"Authorization": key}, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	var issues []trackerIssue
+	if root := resp.Data.Issue; root != nil {
+		issues = append(issues, root.issue())
+		for _, c := range root.Children.Nodes {
+			issues = append(issues, c.issue())
+		}
+	}
+	for _, n := range resp.Data.Issues.Nodes {
+		issues = append(issues, n.issue())
+	}
+	if len(issues) == 0 {
+		return nil, fmt.Errorf("no issues match %s", arg)
+	}
+	return map[string]string{"linear-" + slugify(arg) + ".md": renderIssues("Linear", arg, issues)}, nil
+}
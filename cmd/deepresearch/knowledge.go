@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// knowledgeConfig is the knowledge base that runs started in the same
+// directory share: an append-only history of the runs and the findings of
+// those that produced a report
+type knowledgeConfig struct {
+	Enabled     bool   `yaml:"enabled"`      // same as --kb
+	Dir         string `yaml:"dir"`          // relative to the directory deepresearch is started in
+	MaxFindings int    `yaml:"max_findings"` // prior findings listed for a run, most relevant first
+}
+
+// Files of the knowledge base, one JSON object per line, only ever appended to
+const (
+	knowledgeRunsFile     = "runs.jsonl"
+	knowledgeFindingsFile = "findings.jsonl"
+)
+
+// priorKnowledgeFile lists the knowledge base's findings for the agents of a run
+const priorKnowledgeFile = "prior-knowledge.md"
+
+// knowledgeRun is a line of runs.jsonl
+type knowledgeRun struct {
+	RunID      string    `json:"run_id"`
+	Topic      string    `json:"topic,omitempty"`
+	WorkDir    string    `json:"work_dir"`
+	Agent      string    `json:"agent"`
+	Model      string    `json:"model,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	Findings   int       `json:"findings"` // added to findings.jsonl by this run
+}
+
+// knowledgeSource is a source a finding cites, as its run registered it
+type knowledgeSource struct {
+	ID    string `json:"id"` // in the run's Source Registry
+	URL   string `json:"url,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// knowledgeFinding is a line of findings.jsonl. Its ID, e.g. K0042, is stable,
+// so agents and reports can refer to it across runs.
+type knowledgeFinding struct {
+	ID         string            `json:"id"`
+	RunID      string            `json:"run_id"`
+	Topic      string            `json:"topic,omitempty"`
+	RecordedAt time.Time         `json:"recorded_at"`
+	Fact       string            `json:"fact"` // the finding's ID in its run's task.md
+	Statement  string            `json:"statement"`
+	Dimension  string            `json:"dimension,omitempty"`
+	Confidence string            `json:"confidence,omitempty"`
+	Sources    []knowledgeSource `json:"sources,omitempty"`
+}
+
+// knowledgeEnabled reports whether the run reads and adds to the knowledge base
+func knowledgeEnabled() bool {
+	return config.Knowledge.Enabled && config.Knowledge.Dir != ""
+}
+
+// knowledgeDir returns the absolute knowledge base directory
+func knowledgeDir() string {
+	dir, err := filepath.Abs(config.Knowledge.Dir)
+	if err != nil {
+		return config.Knowledge.Dir
+	}
+	return dir
+}
+
+// loadKnowledgeFindings reads findings.jsonl; a missing file is an empty
+// knowledge base. Lines that do not parse are skipped and counted.
+func loadKnowledgeFindings(dir string) ([]knowledgeFinding, int, error) {
+	f, err := os.Open(filepath.Join(dir, knowledgeFindingsFile))
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var findings []knowledgeFinding
+	skipped := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var k knowledgeFinding
+		if err := json.Unmarshal([]byte(line), &k); err != nil || k.ID == "" {
+			skipped++
+			continue
+		}
+		findings = append(findings, k)
+	}
+	return findings, skipped, sc.Err()
+}
+
+// prepareKnowledge lists the knowledge base's findings related to topic in
+// prior-knowledge.md, for the planner to build on and the reflector to check
+// this run's findings against
+func prepareKnowledge(workDir, topic string) {
+	indexFile := filepath.Join(workDir, priorKnowledgeFile)
+	os.Remove(indexFile)
+	if !knowledgeEnabled() {
+		return
+	}
+	dir := knowledgeDir()
+	findings, skipped, err := loadKnowledgeFindings(dir)
+	if err != nil {
+		warn("Could not read the knowledge base: %v", err)
+		return
+	}
+	if skipped > 0 {
+		warn("Skipped %d malformed line(s) of %s", skipped, filepath.Join(dir, knowledgeFindingsFile))
+	}
+	related := relatedFindings(findings, topic, config.Knowledge.MaxFindings)
+	logEntry("INFO", "KNOWLEDGE", 0, "Listed prior findings", map[string]string{
+		"findings": fmt.Sprintf("%d", len(findings)),
+		"related":  fmt.Sprintf("%d", len(related)),
+		"dir":      dir,
+	})
+	if len(related) == 0 {
+		if len(findings) > 0 {
+			info("None of the %d finding(s) in the knowledge base relate to this topic", len(findings))
+		}
+		return
+	}
+	if err := os.WriteFile(indexFile, []byte(priorKnowledgeMarkdown(related, len(findings), dir)), 0644); err != nil {
+		warn("Could not write %s: %v", priorKnowledgeFile, err)
+		return
+	}
+	info("%d finding(s) of earlier runs are listed in %s", len(related), priorKnowledgeFile)
+}
+
+// knowledgeWordRe matches the words topics and findings are matched on
+var knowledgeWordRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// knowledgeStopWords are too common to relate a finding to a topic
+var knowledgeStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true, "that": true,
+	"this": true, "what": true, "how": true, "why": true, "are": true, "was": true, "were": true,
+	"has": true, "have": true, "its": true, "their": true, "about": true, "between": true,
+	"compare": true, "research": true, "report": true, "analysis": true, "overview": true,
+}
+
+// knowledgeWords returns the distinct words of s that relate findings to a topic
+func knowledgeWords(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range knowledgeWordRe.FindAllString(strings.ToLower(s), -1) {
+		if utf8.RuneCountInString(w) >= 3 && !knowledgeStopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// relatedFindings ranks findings by the words they share with topic, the
+// newest first among equals, and keeps at most limit (0 = all) that share any
+func relatedFindings(findings []knowledgeFinding, topic string, limit int) []knowledgeFinding {
+	topicWords := knowledgeWords(topic)
+	type scored struct {
+		k     knowledgeFinding
+		score int
+	}
+	var related []scored
+	for _, k := range findings {
+		score := 0
+		for w := range knowledgeWords(k.Topic + " " + k.Dimension + " " + k.Statement) {
+			if topicWords[w] {
+				score++
+			}
+		}
+		if score > 0 {
+			related = append(related, scored{k, score})
+		}
+	}
+	sort.SliceStable(related, func(i, j int) bool {
+		if related[i].score != related[j].score {
+			return related[i].score > related[j].score
+		}
+		return related[i].k.RecordedAt.After(related[j].k.RecordedAt)
+	})
+	if limit > 0 && len(related) > limit {
+		related = related[:limit]
+	}
+	out := make([]knowledgeFinding, len(related))
+	for i, r := range related {
+		out[i] = r.k
+	}
+	return out
+}
+
+// priorKnowledgeMarkdown renders prior-knowledge.md
+func priorKnowledgeMarkdown(findings []knowledgeFinding, total int, dir string) string {
+	var b strings.Builder
+	b.WriteString("# Prior Knowledge\n\n")
+	fmt.Fprintf(&b, "Findings of earlier runs on related topics, from the knowledge base in %s\n", dir)
+	fmt.Fprintf(&b, "(%d of %d findings, most related first). K-IDs stay the same across runs.\n\n", len(findings), total)
+	b.WriteString("| ID | Finding | Confidence | Sources | Recorded | Topic of the run |\n")
+	b.WriteString("|----|---------|------------|---------|----------|------------------|\n")
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", "\\|")
+	}
+	for _, k := range findings {
+		var sources []string
+		for _, s := range k.Sources {
+			switch {
+			case s.URL != "":
+				sources = append(sources, s.URL)
+			case s.Title != "":
+				sources = append(sources, s.Title)
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", k.ID, cell(k.Statement), orDash(k.Confidence),
+			orDash(cell(strings.Join(sources, " ; "))), k.RecordedAt.Format("2006-01-02"), orDash(cell(k.Topic)))
+	}
+	return b.String()
+}
+
+// recordKnowledge appends the run to runs.jsonl and, when it produced a
+// report, the findings of its Knowledge Graph that are new to findings.jsonl
+func recordKnowledge(outcome string) {
+	if !knowledgeEnabled() || manifest == nil || config.Privacy.Ephemeral {
+		return
+	}
+	dir := knowledgeDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logEntry("WARN", "KNOWLEDGE", 0, "Could not create the knowledge base", map[string]string{"error": err.Error()})
+		return
+	}
+	run := knowledgeRun{
+		RunID:      manifest.RunID,
+		Topic:      manifest.Topic,
+		WorkDir:    manifest.WorkDir,
+		Agent:      manifest.Agent,
+		Model:      manifest.Model,
+		StartedAt:  manifest.StartedAt,
+		FinishedAt: time.Now().UTC(),
+		Outcome:    outcome,
+		ErrorCode:  manifest.ErrorCode,
+	}
+	known := 0
+	// Runs of a batch finish side by side; the lock keeps K-IDs unique
+	err := withFileLock(filepath.Join(dir, knowledgeFindingsFile), func() error {
+		if outcome == outcomeCompleted || outcome == outcomePartial {
+			added, dup, err := appendFindings(dir, run)
+			if err != nil {
+				return err
+			}
+			run.Findings, known = added, dup
+		}
+		return appendJSONLine(filepath.Join(dir, knowledgeRunsFile), run)
+	})
+	if err != nil {
+		logEntry("WARN", "KNOWLEDGE", 0, "Could not update the knowledge base", map[string]string{"error": err.Error()})
+		return
+	}
+	logEntry("INFO", "KNOWLEDGE", 0, "Recorded the run in the knowledge base", map[string]string{
+		"outcome":  outcome,
+		"added":    fmt.Sprintf("%d", run.Findings),
+		"known":    fmt.Sprintf("%d", known),
+		"dir":      dir,
+		"findings": knowledgeFindingsFile,
+	})
+	if run.Findings+known > 0 {
+		info("Knowledge base: %d finding(s) added, %d already known", run.Findings, known)
+	}
+}
+
+// appendFindings appends the findings of the run's task.md that the knowledge
+// base does not hold yet, and returns how many it added and skipped
+func appendFindings(dir string, run knowledgeRun) (int, int, error) {
+	taskFile := taskFilePath(run.WorkDir)
+	f, err := taskfile.Read(taskFile)
+	if err != nil {
+		return 0, 0, nil
+	}
+	existing, _, err := loadKnowledgeFindings(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	seen := map[string]bool{}
+	next := 1
+	for _, k := range existing {
+		seen[knowledgeKey(k.Statement)] = true
+		var n int
+		if _, err := fmt.Sscanf(k.ID, "K%d", &n); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	registry := map[string]registeredSource{}
+	for _, s := range readSourceRegistry(run.WorkDir) {
+		registry[s.ID] = s
+	}
+	out, err := os.OpenFile(filepath.Join(dir, knowledgeFindingsFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer out.Close()
+	added, dup := 0, 0
+	now := time.Now().UTC()
+	for _, fact := range f.Findings {
+		key := knowledgeKey(fact.Statement)
+		if key == "" {
+			continue
+		}
+		if seen[key] {
+			dup++
+			continue
+		}
+		seen[key] = true
+		k := knowledgeFinding{
+			ID:         fmt.Sprintf("K%04d", next),
+			RunID:      run.RunID,
+			Topic:      run.Topic,
+			RecordedAt: now,
+			Fact:       fact.ID,
+			Statement:  fact.Statement,
+			Dimension:  fact.Dimension,
+			Confidence: fact.Confidence,
+		}
+		for _, id := range fact.Sources {
+			s := registry[id]
+			k.Sources = append(k.Sources, knowledgeSource{ID: id, URL: s.URL, Title: s.Title})
+		}
+		data, err := json.Marshal(k)
+		if err != nil {
+			return added, dup, err
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			return added, dup, err
+		}
+		next++
+		added++
+	}
+	return added, dup, nil
+}
+
+// knowledgeKey identifies a finding's statement regardless of case, spacing
+// and punctuation, so a finding restated by a later run is not added twice
+func knowledgeKey(statement string) string {
+	return strings.Join(knowledgeWordRe.FindAllString(strings.ToLower(statement), -1), " ")
+}
+
+// appendJSONLine appends v to a JSON lines file
+func appendJSONLine(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// buildKnowledgeNote points the planner or reflector at prior-knowledge.md, or
+// returns "" if there is none
+func buildKnowledgeNote(workDir string, reflector bool) string {
+	if !fileExists(filepath.Join(workDir, priorKnowledgeFile)) {
+		return ""
+	}
+	if reflector {
+		return fmt.Sprintf(`
+PRIOR_KNOWLEDGE: %s lists findings of earlier runs on related topics by K-ID.
+During conflict detection, also compare this run's Knowledge Graph with them. A finding that
+contradicts a prior one is a conflict like any other: name the K-ID and its sources, weigh both by
+the evidence rules, and add a C* task unless this run's evidence plainly supersedes it (e.g. more
+recent data).
+`, priorKnowledgeFile)
+	}
+	return fmt.Sprintf(`
+PRIOR_KNOWLEDGE: %s lists findings of earlier runs on related topics, with their
+sources. Build the plan on them: aim tasks at the gaps they leave rather than re-researching what
+they settle, unless a finding is dated, low-confidence or central to the request. A prior finding
+enters this run's Knowledge Graph only after a task re-checks its source; note its K-ID there.
+`, priorKnowledgeFile)
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeConfigDir is where a job pod mounts the Secret holding the config it runs
+// with, as kubeConfigKey
+const (
+	kubeConfigDir = "/etc/deepresearch-job"
+	kubeConfigKey = "config.yaml"
+)
+
+// kubePollInterval is how often the kubernetes executor checks a Job's status
+const kubePollInterval = 5 * time.Second
+
+// kubeConfig configures the kubernetes executor. The workspace is shared either
+// through a PersistentVolumeClaim mounted at the server root in both the server
+// and the job pods, or through object storage with upload/download commands.
+type kubeConfig struct {
+	APIServer      string            `yaml:"api_server"`      // default: in-cluster ($KUBERNETES_SERVICE_HOST)
+	TokenEnv       string            `yaml:"token_env"`       // bearer token variable (default: the pod's service account)
+	CAFile         string            `yaml:"ca_file"`         // API server CA (default: the pod's service account CA)
+	Namespace      string            `yaml:"namespace"`       // default: the server pod's namespace, else "default"
+	Image          string            `yaml:"image"`           // image containing deepresearch and the agent CLIs
+	ServiceAccount string            `yaml:"service_account"` // service account for job pods
+	PVC            string            `yaml:"pvc"`             // claim mounted at the server root in job pods
+	EnvSecret      string            `yaml:"env_secret"`      // secret exposed as environment variables (agent credentials)
+	CPU            string            `yaml:"cpu"`             // CPU limit, e.g. "2"
+	Memory         string            `yaml:"memory"`          // memory limit, e.g. "4Gi"
+	NodeSelector   map[string]string `yaml:"node_selector"`
+	TTLSeconds     int               `yaml:"ttl_seconds"` // delete finished Jobs after this many seconds (default 3600)
+	// Upload runs in the job pod after the run when there is no PVC, e.g.
+	// "aws s3 sync . s3://bucket/runs/{job}"; Download runs on the server
+	// afterwards, e.g. "aws s3 sync s3://bucket/runs/{job} {dir}"
+	Upload   string `yaml:"upload"`
+	Download string `yaml:"download"`
+}
+
+// kubeRunner runs each job as a Kubernetes Job through the API server's REST API
+type kubeRunner struct {
+	cfg       kubeConfig
+	root      string
+	api       string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// newKubeRunner validates the kubernetes settings and resolves cluster access
+func newKubeRunner(cfg kubeConfig, root string) (*kubeRunner, error) {
+	if cfg.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if cfg.PVC == "" && (cfg.Upload == "" || cfg.Download == "") {
+		return nil, fmt.Errorf("set pvc, or upload and download commands for object storage")
+	}
+	if cfg.TTLSeconds == 0 {
+		cfg.TTLSeconds = 3600
+	}
+	k := &kubeRunner{cfg: cfg, root: root, api: strings.TrimRight(cfg.APIServer, "/"), namespace: cfg.Namespace}
+	if k.api == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" {
+			return nil, fmt.Errorf("not running in a cluster; set api_server")
+		}
+		k.api = "https://" + host + ":" + port
+	}
+	if cfg.TokenEnv != "" {
+		k.token = os.Getenv(cfg.TokenEnv)
+	} else if data, err := os.ReadFile(serviceAccountDir + "/token"); err == nil {
+		k.token = strings.TrimSpace(string(data))
+	}
+	if k.namespace == "" {
+		k.namespace = "default"
+		if data, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+			k.namespace = strings.TrimSpace(string(data))
+		}
+	}
+	caFile := cfg.CAFile
+	if caFile == "" && fileExists(serviceAccountDir+"/ca.crt") {
+		caFile = serviceAccountDir + "/ca.crt"
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	k.client = &http.Client{Timeout: 60 * time.Second, Transport: transport}
+	return k, nil
+}
+
+// request calls the Kubernetes API and decodes the response into out
+func (k *kubeRunner) request(method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, k.api+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var st struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &st) == nil && st.Message != "" {
+			return fmt.Errorf("kubernetes %s %s: %s", method, path, st.Message)
+		}
+		return fmt.Errorf("kubernetes %s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if b, ok := out.(*[]byte); ok {
+		*b = data
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// expandJobVars fills {job}, {tenant} and {dir} in an upload/download command
+func expandJobVars(command string, j *job) string {
+	return strings.NewReplacer("{job}", j.ID, "{tenant}", j.Tenant, "{dir}", j.Dir).Replace(command)
+}
+
+// jobLabels are the labels of a job's Kubernetes objects
+func jobLabels(j *job) map[string]string {
+	return map[string]string{"app.kubernetes.io/name": "deepresearch", "deepresearch/job": j.ID}
+}
+
+// jobManifest builds the batch/v1 Job for one attempt; with a config, the pod
+// mounts the Secret of the same name holding it
+func (k *kubeRunner) jobManifest(name string, j *job, args []string, config bool) map[string]any {
+	container := map[string]any{
+		"name":  "deepresearch",
+		"image": k.cfg.Image,
+	}
+	// The workspace is mounted at the same path as on the server, so job
+	// directories match
+	script := fmt.Sprintf(`mkdir -p %q && cd %q && deepresearch "$@"`, j.Dir, j.Dir)
+	volume := map[string]any{"name": "workspace"}
+	if k.cfg.PVC != "" {
+		volume["persistentVolumeClaim"] = map[string]any{"claimName": k.cfg.PVC}
+	} else {
+		volume["emptyDir"] = map[string]any{}
+		script += fmt.Sprintf("; rc=$?; %s; exit $rc", expandJobVars(k.cfg.Upload, j))
+	}
+	container["command"] = append([]string{"sh", "-c", script, "deepresearch"}, args...)
+	mounts := []any{map[string]any{"name": "workspace", "mountPath": k.root}}
+	volumes := []any{volume}
+	if config {
+		mounts = append(mounts, map[string]any{"name": "config", "mountPath": kubeConfigDir, "readOnly": true})
+		volumes = append(volumes, map[string]any{"name": "config", "secret": map[string]any{"secretName": name}})
+	}
+	container["volumeMounts"] = mounts
+	if k.cfg.EnvSecret != "" {
+		container["envFrom"] = []any{map[string]any{"secretRef": map[string]any{"name": k.cfg.EnvSecret}}}
+	}
+	limits := map[string]string{}
+	if k.cfg.CPU != "" {
+		limits["cpu"] = k.cfg.CPU
+	}
+	if k.cfg.Memory != "" {
+		limits["memory"] = k.cfg.Memory
+	}
+	if len(limits) > 0 {
+		container["resources"] = map[string]any{"limits": limits}
+	}
+	pod := map[string]any{
+		"restartPolicy": "Never",
+		"containers":    []any{container},
+		"volumes":       volumes,
+	}
+	if k.cfg.ServiceAccount != "" {
+		pod["serviceAccountName"] = k.cfg.ServiceAccount
+	}
+	if len(k.cfg.NodeSelector) > 0 {
+		pod["nodeSelector"] = k.cfg.NodeSelector
+	}
+	labels := jobLabels(j)
+	return map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]any{"name": name, "labels": labels},
+		"spec": map[string]any{
+			"backoffLimit":            0, // the queue handles retries
+			"ttlSecondsAfterFinished": k.cfg.TTLSeconds,
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": labels},
+				"spec":     pod,
+			},
+		},
+	}
+}
+
+// configSecretManifest builds the Secret holding the config of a Job. It is
+// owned by the Job, so Kubernetes deletes it with the Job if Run cannot.
+func (k *kubeRunner) configSecretManifest(name, uid string, j *job, config []byte) map[string]any {
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]any{
+			"name":   name,
+			"labels": jobLabels(j),
+			"ownerReferences": []any{map[string]any{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"name":       name,
+				"uid":        uid,
+			}},
+		},
+		"type": "Opaque",
+		"data": map[string]any{kubeConfigKey: config}, // []byte marshals as base64
+	}
+}
+
+func (k *kubeRunner) Run(j *job, args []string, log io.Writer, canceled <-chan struct{}) error {
+	name := fmt.Sprintf("deepresearch-%s-%d", j.ID, j.Attempts)
+	jobsPath := "/apis/batch/v1/namespaces/" + k.namespace + "/jobs"
+	secretsPath := "/api/v1/namespaces/" + k.namespace + "/secrets"
+	// The server's config path does not exist in the pod: ship its content in
+	// a Secret, as it may hold webhook URLs and headers
+	var config []byte
+	args = append([]string{}, args...)
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--config" {
+			data, err := os.ReadFile(args[i+1])
+			if err != nil {
+				return err
+			}
+			config, args[i+1] = data, kubeConfigDir+"/"+kubeConfigKey
+		}
+	}
+	var created struct {
+		Metadata struct {
+			UID string `json:"uid"`
+		} `json:"metadata"`
+	}
+	if err := k.request("POST", jobsPath, k.jobManifest(name, j, args, config != nil), &created); err != nil {
+		return err
+	}
+	deleteJob := func() { k.request("DELETE", jobsPath+"/"+name+"?propagationPolicy=Background", nil, nil) }
+	if config != nil {
+		// The pod waits for the Secret before it starts
+		if err := k.request("POST", secretsPath, k.configSecretManifest(name, created.Metadata.UID, j, config), nil); err != nil {
+			deleteJob()
+			return fmt.Errorf("cannot store the job config: %w", err)
+		}
+		defer k.request("DELETE", secretsPath+"/"+name, nil, nil)
+	}
+	fmt.Fprintf(log, "Submitted Kubernetes Job %s/%s\n", k.namespace, name)
+
+	var runErr error
+	ticker := time.NewTicker(kubePollInterval)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case <-canceled:
+			deleteJob()
+			return fmt.Errorf("canceled")
+		case <-ticker.C:
+		}
+		var status struct {
+			Status struct {
+				Succeeded  int `json:"succeeded"`
+				Failed     int `json:"failed"`
+				Conditions []struct {
+					Type    string `json:"type"`
+					Status  string `json:"status"`
+					Message string `json:"message"`
+				} `json:"conditions"`
+			} `json:"status"`
+		}
+		if err := k.request("GET", jobsPath+"/"+name, nil, &status); err != nil {
+			fmt.Fprintf(log, "Cannot read Job status: %v\n", err)
+			continue
+		}
+		switch {
+		case status.Status.Succeeded > 0:
+			break wait
+		case status.Status.Failed > 0:
+			runErr = fmt.Errorf("kubernetes job %s failed", name)
+			for _, c := range status.Status.Conditions {
+				if c.Type == "Failed" && c.Status == "True" && c.Message != "" {
+					runErr = fmt.Errorf("kubernetes job %s failed: %s", name, c.Message)
+				}
+			}
+			break wait
+		}
+	}
+
+	k.copyLogs(name, log)
+	if k.cfg.PVC == "" {
+		cmd := exec.Command("sh", "-c", expandJobVars(k.cfg.Download, j))
+		cmd.Stdout, cmd.Stderr = log, log
+		if err := cmd.Run(); err != nil && runErr == nil {
+			runErr = fmt.Errorf("downloading the workspace failed: %w", err)
+		}
+	}
+	return runErr
+}
+
+// copyLogs appends the job pod's output to the job log
+func (k *kubeRunner) copyLogs(name string, log io.Writer) {
+	podsPath := "/api/v1/namespaces/" + k.namespace + "/pods"
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := k.request("GET", podsPath+"?labelSelector="+url.QueryEscape("job-name="+name), nil, &pods); err != nil || len(pods.Items) == 0 {
+		fmt.Fprintf(log, "Cannot fetch pod logs for %s: %v\n", name, err)
+		return
+	}
+	var out []byte
+	if err := k.request("GET", podsPath+"/"+pods.Items[0].Metadata.Name+"/log", nil, &out); err != nil {
+		fmt.Fprintf(log, "Cannot fetch pod logs for %s: %v\n", name, err)
+		return
+	}
+	log.Write(out)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// stopwords are high-frequency function words used to tell Latin-script languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "with", "are"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "von", "auf"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "pour", "que"},
+	"es": {"el", "la", "los", "las", "y", "que", "del", "en", "por", "una"},
+	"pt": {"o", "os", "as", "e", "que", "do", "da", "em", "para", "uma"},
+	"it": {"il", "di", "che", "e", "la", "per", "una", "sono", "del", "gli"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "niet"},
+}
+
+// scriptLanguages are the languages detectLanguage identifies by their script
+var scriptLanguages = []string{"ja", "zh", "ko", "ru", "ar", "hi"}
+
+// detectsLanguage reports whether detectLanguage can identify lang
+func detectsLanguage(lang string) bool {
+	_, latin := stopwords[lang]
+	return latin || slices.Contains(scriptLanguages, lang)
+}
+
+// detectLanguage returns an ISO 639-1 guess for text, or "" if undetermined.
+// Non-Latin scripts are identified by character ranges; Latin-script languages
+// by stopword frequency.
+func detectLanguage(text string) string {
+	if len(text) > 20000 {
+		text = text[:20000]
+	}
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+	if letters < 50 {
+		return ""
+	}
+	// Japanese mixes kana with Han; any meaningful kana share means Japanese
+	if counts["ja"]*10 > letters {
+		return "ja"
+	}
+	if counts["han"]*3 > letters {
+		return "zh"
+	}
+	for _, lang := range []string{"ko", "ru", "ar", "hi"} {
+		if counts[lang]*2 > letters {
+			return lang
+		}
+	}
+	if counts["latin"]*2 <= letters {
+		return ""
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	freq := map[string]int{}
+	for _, w := range words {
+		freq[w]++
+	}
+	best, bestScore := "", 0
+	for _, lang := range []string{"en", "de", "fr", "es", "pt", "it", "nl"} {
+		score := 0
+		for _, sw := range stopwords[lang] {
+			score += freq[sw]
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	if bestScore < 5 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// courtListenerAPI is the default CourtListener REST API base URL
+const courtListenerAPI = "https://www.courtlistener.com/api/rest/v4"
+
+func init() {
+	registerConnector(&connector{
+		Name:    "legal",
+		Summary: "Case law and dockets from CourtListener/RECAP with Bluebook-style citations",
+		Actions: map[string]string{
+			"opinions": `-q "query" [-n 10] [-court scotus] [-after 2000-01-01] — search court opinions`,
+			"dockets":  `-q "query" [-n 10] [-court nysd] — search RECAP federal dockets`,
+			"opinion":  `<cluster id> — full opinion text, saved to assets/legal`,
+		},
+		Run: runLegal,
+	})
+}
+
+// legalCase is a provider-independent court decision or docket
+type legalCase struct {
+	ID        string
+	Name      string
+	Court     string // citation abbreviation, e.g. "9th Cir."
+	CourtID   string
+	Date      string // YYYY-MM-DD filed
+	Docket    string
+	Citations []string // reporter citations, e.g. "410 U.S. 113"
+	URL       string
+	Snippet   string
+	Text      string
+}
+
+// legalProvider searches and fetches case law from one service
+type legalProvider interface {
+	Search(kind, query, court, after string, limit int) ([]legalCase, []byte, error)
+	Opinion(id string) (legalCase, error)
+}
+
+// bluebookCite formats a case citation following the Bluebook convention:
+// "Case Name, 123 F.3d 456 (9th Cir. 2001)". The court is omitted for the
+// U.S. Reports, and unreported decisions cite the docket number and full date.
+func bluebookCite(c legalCase) string {
+	year := c.Date
+	if len(year) >= 4 {
+		year = year[:4]
+	}
+	if len(c.Citations) > 0 {
+		cite := c.Citations[0]
+		if strings.Contains(cite, " U.S. ") || c.Court == "" {
+			return fmt.Sprintf("%s, %s (%s)", c.Name, cite, year)
+		}
+		return fmt.Sprintf("%s, %s (%s %s)", c.Name, cite, c.Court, year)
+	}
+	parts := []string{c.Name}
+	if c.Docket != "" {
+		parts = append(parts, "No. "+c.Docket)
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(parts, ", "), strings.TrimSpace(c.Court+" "+c.Date))
+}
+
+func runLegal(t *toolContext, action string, args []string) error {
+	var p legalProvider
+	switch t.Config.Provider {
+	case "", "courtlistener":
+		p = &courtListenerProvider{t: t}
+	default:
+		return fmt.Errorf("unknown legal provider %q (expected courtlistener)", t.Config.Provider)
+	}
+
+	switch action {
+	case "opinions", "dockets":
+		fs, q, n := searchFlagSet(action, 10)
+		court := fs.String("court", "", "Court ID(s), space separated (e.g. scotus ca9)")
+		after := fs.String("after", "", "Only decisions filed on or after this date (YYYY-MM-DD)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		cases, raw, err := p.Search(action, query, *court, *after, *n)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "legal_"+action+"_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		t.printf("%d result(s). Raw results: %s\n\n| ID | Filed | Citation | URL |\n|---|---|---|---|\n", len(cases), path)
+		for _, c := range cases {
+			t.printf("| %s | %s | %s | %s |\n", c.ID, c.Date, mdCell(bluebookCite(c)), c.URL)
+		}
+		if action == "opinions" {
+			t.printf("\nUse `opinion <ID>` to archive the full text of a decision you cite.\n")
+		}
+		return nil
+
+	case "opinion":
+		if len(args) == 0 {
+			return fmt.Errorf("a cluster ID is required")
+		}
+		c, err := p.Opinion(args[0])
+		if err != nil {
+			return err
+		}
+		cite := bluebookCite(c)
+		path, err := t.saveDocument("legal", c.Name+"_"+c.Date, [][2]string{
+			{"title", c.Name},
+			{"citation", cite},
+			{"court", c.Court},
+			{"docket", c.Docket},
+			{"published", c.Date},
+			{"url", c.URL},
+		}, "# "+cite+"\n\n"+c.Text)
+		if err != nil {
+			return err
+		}
+		excerpt := c.Text
+		if len(excerpt) > 3000 {
+			n := 3000
+			for !utf8.RuneStart(excerpt[n]) {
+				n--
+			}
+			excerpt = excerpt[:n] + "\n\n[...]"
+		}
+		t.printf("## %s\n\n%s\n\nArchived: %s\n", cite, excerpt, path)
+		t.printf("\nCite cases in legal form (the heading above) and pin-cite pages or paragraphs where the reporter allows.\n")
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// courtListenerProvider queries the Free Law Project's CourtListener API; a token
+// ($COURTLISTENER_TOKEN) raises rate limits and is required for full text
+type courtListenerProvider struct {
+	t *toolContext
+}
+
+func (p *courtListenerProvider) get(u string, out any) ([]byte, error) {
+	var headers map[string]string
+	if token := p.t.apiKey("COURTLISTENER_TOKEN"); token != "" {
+		headers = map[string]string{"Authorization": "Token " + token}
+	}
+	return p.t.getJSON(u, headers, out)
+}
+
+// absolute turns a CourtListener site path into a URL
+func (p *courtListenerProvider) absolute(path string) string {
+	if path == "" || strings.HasPrefix(path, "http") {
+		return path
+	}
+	return "https://www.courtlistener.com" + path
+}
+
+func (p *courtListenerProvider) Search(kind, query, court, after string, limit int) ([]legalCase, []byte, error) {
+	params := url.Values{"q": {query}, "type": {"o"}, "order_by": {"score desc"}}
+	if kind == "dockets" {
+		params.Set("type", "r")
+	}
+	if court != "" {
+		params.Set("court", court)
+	}
+	if after != "" {
+		params.Set("filed_after", after)
+	}
+	var resp struct {
+		Results []struct {
+			ClusterID   int      `json:"cluster_id"`
+			DocketID    int      `json:"docket_id"`
+			CaseName    string   `json:"caseName"`
+			Court       string   `json:"court"`
+			CourtID     string   `json:"court_id"`
+			CourtCite   string   `json:"court_citation_string"`
+			DateFiled   string   `json:"dateFiled"`
+			Docket      string   `json:"docketNumber"`
+			Citation    []string `json:"citation"`
+			AbsoluteURL string   `json:"absolute_url"`
+			Snippet     string   `json:"snippet"`
+		} `json:"results"`
+	}
+	raw, err := p.get(p.t.endpoint(courtListenerAPI)+"/search/?"+params.Encode(), &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cases []legalCase
+	for i, r := range resp.Results {
+		if i == limit {
+			break
+		}
+		id := fmt.Sprint(r.ClusterID)
+		if kind == "dockets" {
+			id = fmt.Sprint(r.DocketID)
+		}
+		cases = append(cases, legalCase{
+			ID: id, Name: r.CaseName, Court: r.CourtCite, CourtID: r.CourtID, Date: r.DateFiled,
+			Docket: r.Docket, Citations: r.Citation, URL: p.absolute(r.AbsoluteURL), Snippet: r.Snippet,
+		})
+	}
+	return cases, raw, nil
+}
+
+func (p *courtListenerProvider) Opinion(id string) (legalCase, error) {
+	base := p.t.endpoint(courtListenerAPI)
+	var cluster struct {
+		CaseName  string `json:"case_name"`
+		DateFiled string `json:"date_filed"`
+		URL       string `json:"absolute_url"`
+		Docket    string `json:"docket"`
+		Citations []struct {
+			Volume   any    `json:"volume"`
+			Reporter string `json:"reporter"`
+			Page     string `json:"page"`
+		} `json:"citations"`
+		SubOpinions []string `json:"sub_opinions"`
+	}
+	if _, err := p.get(base+"/clusters/"+url.PathEscape(id)+"/", &cluster); err != nil {
+		return legalCase{}, err
+	}
+	c := legalCase{ID: id, Name: cluster.CaseName, Date: cluster.DateFiled, URL: p.absolute(cluster.URL)}
+	for _, ct := range cluster.Citations {
+		c.Citations = append(c.Citations, fmt.Sprintf("%v %s %s", ct.Volume, ct.Reporter, ct.Page))
+	}
+	if cluster.Docket != "" {
+		var docket struct {
+			Number string `json:"docket_number"`
+			Court  string `json:"court_id"`
+		}
+		if _, err := p.get(cluster.Docket, &docket); err == nil {
+			c.Docket, c.CourtID = docket.Number, docket.Court
+			var court struct {
+				Citation string `json:"citation_string"`
+			}
+			if _, err := p.get(base+"/courts/"+url.PathEscape(docket.Court)+"/", &court); err == nil {
+				c.Court = court.Citation
+			}
+		}
+	}
+	var texts []string
+	for _, u := range cluster.SubOpinions {
+		var op struct {
+			Type      string `json:"type"`
+			PlainText string `json:"plain_text"`
+			HTML      string `json:"html_with_citations"`
+		}
+		if _, err := p.get(u, &op); err != nil {
+			return legalCase{}, err
+		}
+		text := op.PlainText
+		if text == "" {
+			text = strings.TrimSpace(htmlTagRe.ReplaceAllString(op.HTML, " ")) // opinions only available as HTML
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	c.Text = strings.Join(texts, "\n\n---\n\n")
+	return c, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLegalOpinions(t *testing.T) {
+	srv := recordedAPI(t, "legal", map[string]string{"/search/": "opinions.json"})
+	dir, out, err := runRecordedTool(t, "legal", connectorConfig{Endpoint: srv.URL}, "opinions", "-q", "abortion privacy", "-court", "scotus gand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 result(s).",
+		"| ID | Filed | Citation | URL |",
+		"| 108713 | 1973-01-22 | Roe v. Wade, 410 U.S. 113 (1973) | https://www.courtlistener.com/opinion/108713/roe-v-wade/ |",
+		`| 4234567 | 1974-03-15 | Doe v. Bolton \| On Remand, No. 13676 (N.D. Ga. 1974-03-15) |`,
+		"`opinion <ID>`",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "legal-opinions-abortion-privacy") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestLegalDockets(t *testing.T) {
+	srv := recordedAPI(t, "legal", map[string]string{"/search/": "dockets.json"})
+	_, out, err := runRecordedTool(t, "legal", connectorConfig{Endpoint: srv.URL}, "dockets", "-q", "openai copyright")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "| 67890123 | 2023-09-19 | Authors Guild v. OpenAI Inc., No. 1:23-cv-08292 (S.D.N.Y. 2023-09-19) | https://www.courtlistener.com/docket/67890123/authors-guild-v-openai-inc/ |")
+}
+
+func TestLegalOpinion(t *testing.T) {
+	srv := recordedAPI(t, "legal", map[string]string{
+		"/clusters/108713/": "cluster.json",
+		"/dockets/68361/":   "docket.json",
+		"/courts/scotus/":   "court.json",
+		"/opinions/108713/": "opinion_lead.json",
+		"/opinions/108714/": "opinion_dissent.json",
+	})
+	dir, out, err := runRecordedTool(t, "legal", connectorConfig{Endpoint: srv.URL}, "opinion", "108713")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "## Roe v. Wade, 410 U.S. 113 (1973)", "MR. JUSTICE BLACKMUN delivered the opinion of the Court.", "[...]")
+	if !utf8.ValidString(out) {
+		t.Error("the excerpt splits a character")
+	}
+	files := assetFiles(t, dir, "legal")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "roe-v-wade-1973-01-22") {
+		t.Fatalf("archived %v, want the opinion", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "legal", files[0]))
+	checkContains(t, string(doc), `court: "SCOTUS"`, `docket: "70-18"`, "MR. JUSTICE REHNQUIST,", "dissenting")
+}
+
+func TestLegalNoResults(t *testing.T) {
+	srv := recordedAPI(t, "legal", map[string]string{"/search/": "empty.json"})
+	_, out, err := runRecordedTool(t, "legal", connectorConfig{Endpoint: srv.URL}, "opinions", "-q", "no such case")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "0 result(s).")
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+}
+
+func TestLegalErrors(t *testing.T) {
+	checkHTTPErrors(t, "legal", connectorConfig{}, "opinions", "-q", "abortion privacy")
+	checkHTTPErrors(t, "legal", connectorConfig{}, "opinion", "108713")
+	if _, _, err := runRecordedTool(t, "legal", connectorConfig{Provider: "westlaw"}, "opinions", "-q", "privacy"); err == nil {
+		t.Error("an unknown provider succeeded")
+	}
+}
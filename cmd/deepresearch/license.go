@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// quoteWordLimit is how many quoted words from a single restrictively licensed
+// source the report may contain before a warning is raised
+const quoteWordLimit = 150
+
+// licenseInfo records the license/terms signals detected in an asset
+type licenseInfo struct {
+	Name        string   `json:"name"`
+	Restrictive bool     `json:"restrictive"`
+	Signals     []string `json:"signals"`
+}
+
+// licenseRule maps a pattern found in source content to a license
+type licenseRule struct {
+	re          *regexp.Regexp
+	name        string
+	restrictive bool
+}
+
+// licenseRules are checked in order; the first match determines the license name,
+// later matches are kept as additional signals
+var licenseRules = []licenseRule{
+	{regexp.MustCompile(`(?i)creativecommons\.org/publicdomain/zero|\bCC0\b`), "CC0", false},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by-nc-nd/|\bCC[ -]BY[ -]NC[ -]ND\b`), "CC BY-NC-ND", true},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by-nc-sa/|\bCC[ -]BY[ -]NC[ -]SA\b`), "CC BY-NC-SA", true},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by-nd/|\bCC[ -]BY[ -]ND\b`), "CC BY-ND", true},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by-nc/|\bCC[ -]BY[ -]NC\b`), "CC BY-NC", true},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by-sa/|\bCC[ -]BY[ -]SA\b`), "CC BY-SA", false},
+	{regexp.MustCompile(`(?i)creativecommons\.org/licenses/by/|\bCC[ -]BY\b`), "CC BY", false},
+	{regexp.MustCompile(`(?i)\bin the public domain\b|\bpublic domain mark\b`), "Public Domain", false},
+	{regexp.MustCompile(`(?i)\bMIT License\b`), "MIT", false},
+	{regexp.MustCompile(`(?i)\bApache License,? Version 2\.0\b`), "Apache-2.0", false},
+	{regexp.MustCompile(`(?i)\bGNU (?:General|Lesser General|Affero General) Public License\b`), "GPL-family", false},
+	{regexp.MustCompile(`(?i)\ball rights reserved\b`), "All rights reserved", true},
+	{regexp.MustCompile(`(?i)(?:©|\(c\)|copyright)\s*(?:©\s*)?(?:19|20)\d{2}`), "Copyrighted", true},
+	{regexp.MustCompile(`(?i)\b(?:may not be reproduced|reproduction (?:is )?prohibited|without (?:the )?(?:prior )?written permission)\b`), "Reproduction prohibited", true},
+	{regexp.MustCompile(`(?i)\bterms of (?:use|service)\b|\bapi terms\b`), "Terms of use", false},
+}
+
+// textAssetExts are the asset types scanned for license signals
+var textAssetExts = map[string]bool{
+	".md": true, ".html": true, ".htm": true, ".txt": true, ".json": true, ".xml": true,
+}
+
+// detectLicenseFile scans a text asset for license/terms signals; nil if none found
+func detectLicenseFile(path string) *licenseInfo {
+	if !textAssetExts[strings.ToLower(filepath.Ext(path))] {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return detectLicense(string(content))
+}
+
+// detectLicense returns the license signals found in content, or nil if none.
+// The first matching rule (explicit licenses before generic copyright notices)
+// determines the license; later matches are kept as supporting signals.
+func detectLicense(content string) *licenseInfo {
+	var lic *licenseInfo
+	for _, rule := range licenseRules {
+		m := rule.re.FindString(content)
+		if m == "" {
+			continue
+		}
+		if lic == nil {
+			lic = &licenseInfo{Name: rule.name, Restrictive: rule.restrictive}
+		}
+		lic.Signals = append(lic.Signals, strings.TrimSpace(m))
+	}
+	return lic
+}
+
+var (
+	blockquoteRe  = regexp.MustCompile(`(?m)^>\s?(.*)$`)
+	inlineQuoteRe = regexp.MustCompile(`"([^"]{40,})"|“([^”]{40,})”`)
+	citationRe    = regexp.MustCompile(`\[(S\d+)\]`)
+)
+
+// checkRestrictedQuotes warns when report.md quotes heavily from assets whose
+// license is restrictive. Returns the warning messages.
+func checkRestrictedQuotes(workDir string, assets *assetManifest) []string {
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil || assets == nil {
+		return nil
+	}
+	restricted := map[string]string{} // source ID -> license name
+	for _, a := range assets.Assets {
+		if a.SourceID != "" && a.License != nil && a.License.Restrictive {
+			restricted[a.SourceID] = a.License.Name
+		}
+	}
+	if len(restricted) == 0 {
+		return nil
+	}
+
+	quoted := map[string]int{}
+	for _, para := range strings.Split(string(report), "\n\n") {
+		words := 0
+		for _, m := range blockquoteRe.FindAllStringSubmatch(para, -1) {
+			words += len(strings.Fields(m[1]))
+		}
+		for _, m := range inlineQuoteRe.FindAllStringSubmatch(para, -1) {
+			words += len(strings.Fields(m[1] + m[2]))
+		}
+		if words == 0 {
+			continue
+		}
+		for _, c := range citationRe.FindAllStringSubmatch(para, -1) {
+			if _, ok := restricted[c[1]]; ok {
+				quoted[c[1]] += words
+			}
+		}
+	}
+
+	var ids []string
+	for id := range quoted {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var warnings []string
+	for _, id := range ids {
+		if quoted[id] > quoteWordLimit {
+			warnings = append(warnings, fmt.Sprintf("Report quotes ~%d words from %s (%s); check reuse rights", quoted[id], id, restricted[id]))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// limitsConfig bounds the resources of every agent process together with the
+// shells, CLIs and headless browsers it spawns (0 = unlimited). Linux enforces
+// them with a cgroup v2 per agent run, Windows with a job object.
+type limitsConfig struct {
+	MemoryMB       int     `yaml:"memory_mb"`       // memory of the whole process tree
+	CPUs           float64 `yaml:"cpus"`            // CPU cores, e.g. 2 or 0.5
+	MaxProcesses   int     `yaml:"max_processes"`   // processes alive at once
+	TimeoutMinutes int     `yaml:"timeout_minutes"` // wall-clock time per non-interactive agent run
+	// PhaseTimeoutMinutes overrides TimeoutMinutes for the agent runs of a phase,
+	// keyed by phase name (planner, research-supervisor, reflector, synthesizer)
+	PhaseTimeoutMinutes map[string]int `yaml:"phase_timeout_minutes"`
+	// CgroupParent is a delegated cgroup v2 (relative to /sys/fs/cgroup) to create
+	// agent cgroups under; default: the cgroup deepresearch runs in
+	CgroupParent string `yaml:"cgroup_parent"`
+}
+
+// enabled reports whether any resource (not time) limit is set
+func (l limitsConfig) enabled() bool {
+	return l.MemoryMB > 0 || l.CPUs > 0 || l.MaxProcesses > 0
+}
+
+// timeout returns the time limit of an agent run in the given phase (0 = none)
+func (l limitsConfig) timeout(phase string) time.Duration {
+	minutes := l.TimeoutMinutes
+	for name, m := range l.PhaseTimeoutMinutes {
+		if strings.EqualFold(name, phase) {
+			minutes = m
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// sandbox confines one agent process tree. prepare runs before the process
+// starts, attach right after; kill stops the whole tree; exceeded names a limit
+// the tree ran into, or ""; close releases the sandbox and kills leftovers.
+type sandbox interface {
+	prepare(cmd *exec.Cmd)
+	attach(cmd *exec.Cmd) error
+	kill()
+	exceeded() string
+	close()
+}
+
+// sandboxWarning is printed once when limits cannot be fully enforced
+var sandboxWarning sync.Once
+
+// limitedProcess is an agent process started under config.Limits
+type limitedProcess struct {
+	cmd      *exec.Cmd
+	ctx      context.Context
+	stop     func() bool // stops killing the process when ctx is cancelled
+	sb       sandbox
+	timer    *time.Timer
+	timeout  time.Duration
+	timedOut atomic.Bool
+}
+
+// startLimited starts cmd under config.Limits, killing it with everything it
+// spawned when ctx is cancelled. The time limit only applies when timed is set
+// (interactive sessions run as long as the user needs).
+func startLimited(ctx context.Context, cmd *exec.Cmd, timed bool) (*limitedProcess, error) {
+	l := config.Limits
+	p := &limitedProcess{cmd: cmd, ctx: ctx}
+	if l.enabled() {
+		sb, err := newSandbox(l)
+		if err != nil {
+			sandboxWarning.Do(func() { warn("Agent resource limits are not enforced: %v", err) })
+		} else {
+			p.sb = sb
+			sb.prepare(cmd)
+		}
+	}
+	if timed {
+		// Interactive agents stay in the terminal's process group to read from it
+		setProcessGroup(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		if p.sb != nil {
+			p.sb.close()
+		}
+		return nil, err
+	}
+	agentsRunning.Add(1)
+	p.stop = context.AfterFunc(ctx, p.kill)
+	if p.sb != nil {
+		if err := p.sb.attach(cmd); err != nil {
+			sandboxWarning.Do(func() { warn("Agent resource limits are not enforced: %v", err) })
+		}
+	}
+	if timed {
+		p.timeout = l.timeout(currentPhase())
+	}
+	if p.timeout > 0 {
+		p.timer = time.AfterFunc(p.timeout, func() {
+			p.timedOut.Store(true)
+			p.kill()
+		})
+	}
+	return p, nil
+}
+
+// kill stops the agent and everything it spawned
+func (p *limitedProcess) kill() {
+	if p.sb != nil {
+		p.sb.kill()
+		return
+	}
+	killProcessTree(p.cmd)
+}
+
+// Wait waits for the agent and reports a breached limit as a coded error
+func (p *limitedProcess) Wait() error {
+	err := p.cmd.Wait()
+	p.stop()
+	agentsRunning.Done()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	var exceeded string
+	if p.sb != nil {
+		exceeded = p.sb.exceeded()
+		p.sb.close()
+	}
+	switch {
+	case p.ctx.Err() != nil && errorCode(context.Cause(p.ctx)) == codeBudgetExceeded:
+		return fmt.Errorf("agent stopped: %w", context.Cause(p.ctx))
+	case p.ctx.Err() != nil:
+		return withCode(codeInterrupted, fmt.Errorf("agent stopped: %w", context.Cause(p.ctx)))
+	case p.timedOut.Load():
+		return withCode(codeAgentTimeout, fmt.Errorf("agent exceeded the %s time limit (--phase-timeout, limits.timeout_minutes)", formatDuration(p.timeout)))
+	case err != nil && exceeded != "":
+		return withCode(codeAgentFailed, fmt.Errorf("agent exited with error: %w (%s)", err, exceeded))
+	case err != nil:
+		return fmt.Errorf("agent exited with error: %w", err)
+	}
+	return nil
+}
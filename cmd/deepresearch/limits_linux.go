@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupSeq numbers the agent cgroups of this process
+var cgroupSeq atomic.Int64
+
+// newSandbox creates a cgroup for one agent run. Without a writable cgroup v2
+// hierarchy it falls back to per-process rlimits, which bound memory only.
+func newSandbox(l limitsConfig) (sandbox, error) {
+	sb, err := newCgroupSandbox(l)
+	if err == nil {
+		return sb, nil
+	}
+	if l.MemoryMB == 0 {
+		return nil, fmt.Errorf("cgroups unavailable (%v); set limits.cgroup_parent to a delegated cgroup", err)
+	}
+	sandboxWarning.Do(func() {
+		warn("Cgroups unavailable (%v); limiting each agent process's address space to %d MB instead", err, l.MemoryMB)
+	})
+	return &rlimitSandbox{l: l}, nil
+}
+
+// ownCgroup returns the cgroup v2 path of this process, e.g. /user.slice/...
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "0::"); ok {
+			return rest, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 hierarchy")
+}
+
+// cgroupSandbox holds an agent run in its own cgroup
+type cgroupSandbox struct {
+	dir string
+	fd  *os.File
+}
+
+func newCgroupSandbox(l limitsConfig) (*cgroupSandbox, error) {
+	parent := l.CgroupParent
+	if parent == "" {
+		var err error
+		if parent, err = ownCgroup(); err != nil {
+			return nil, err
+		}
+	}
+	parentDir := filepath.Join(cgroupRoot, parent)
+	// Hybrid hosts mount cgroup v1 (or a tmpfs) at the root instead
+	var st unix.Statfs_t
+	if err := unix.Statfs(parentDir, &st); err != nil {
+		return nil, err
+	}
+	if st.Type != unix.CGROUP2_SUPER_MAGIC {
+		return nil, fmt.Errorf("%s is not a cgroup v2 hierarchy", parentDir)
+	}
+	// Enable the controllers for children; harmless when already enabled
+	writeCgroupFile(parentDir, "cgroup.subtree_control", "+memory +cpu +pids")
+	dir := filepath.Join(parentDir, fmt.Sprintf("deepresearch-agent-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+	sb := &cgroupSandbox{dir: dir}
+	var settings [][2]string
+	if l.MemoryMB > 0 {
+		settings = append(settings, [2]string{"memory.max", strconv.FormatInt(int64(l.MemoryMB)<<20, 10)})
+	}
+	if l.CPUs > 0 {
+		const period = 100000
+		settings = append(settings, [2]string{"cpu.max", fmt.Sprintf("%d %d", int64(l.CPUs*period), period)})
+	}
+	if l.MaxProcesses > 0 {
+		settings = append(settings, [2]string{"pids.max", strconv.Itoa(l.MaxProcesses)})
+	}
+	for _, s := range settings {
+		if err := writeCgroupFile(dir, s[0], s[1]); err != nil {
+			sb.close()
+			return nil, fmt.Errorf("cannot set %s: %w", s[0], err)
+		}
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		sb.close()
+		return nil, err
+	}
+	sb.fd = fd
+	return sb, nil
+}
+
+// writeCgroupFile writes an existing cgroup interface file; unlike os.WriteFile
+// it never creates one the kernel does not provide
+func writeCgroupFile(dir, name, value string) error {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// prepare makes the kernel start the agent directly inside the cgroup, so not
+// even its first child can escape
+func (c *cgroupSandbox) prepare(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(c.fd.Fd())
+}
+
+func (c *cgroupSandbox) attach(*exec.Cmd) error { return nil }
+
+func (c *cgroupSandbox) kill() {
+	if writeCgroupFile(c.dir, "cgroup.kill", "1") == nil {
+		return
+	}
+	// Kernels before 5.14 have no cgroup.kill
+	data, _ := os.ReadFile(filepath.Join(c.dir, "cgroup.procs"))
+	for _, f := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(f); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
+
+func (c *cgroupSandbox) exceeded() string {
+	data, err := os.ReadFile(filepath.Join(c.dir, "memory.events"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if n, ok := strings.CutPrefix(line, "oom_kill "); ok && n != "0" {
+			return "killed for exceeding limits.memory_mb"
+		}
+	}
+	return ""
+}
+
+func (c *cgroupSandbox) close() {
+	if c.fd != nil {
+		c.fd.Close()
+	}
+	c.kill()
+	// The cgroup can only be removed once its processes are gone
+	for i := 0; i < 20; i++ {
+		if err := os.Remove(c.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// rlimitExecArg is the first argument the deepresearch binary gets when the
+// rlimit sandbox starts an agent through it
+const rlimitExecArg = "rlimit-exec"
+
+// Dispatched before main, so any binary of this package (tests too) can start agents
+func init() {
+	if len(os.Args) > 1 && os.Args[1] == rlimitExecArg {
+		runRlimitExec(os.Args[2:])
+	}
+}
+
+// runRlimitExec caps its own address space, then execs the agent in its place:
+// <limit in bytes> <agent path> <agent argv...>
+func runRlimitExec(args []string) {
+	if len(args) < 3 {
+		fmt.Fprintf(os.Stderr, "%s: usage: %s <bytes> <path> <argv...>\n", rlimitExecArg, rlimitExecArg)
+		os.Exit(2)
+	}
+	limit, err := strconv.ParseUint(args[0], 10, 64)
+	if err == nil {
+		err = unix.Setrlimit(unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit})
+	}
+	if err == nil {
+		err = syscall.Exec(args[1], args[2:], os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "%s: %v\n", rlimitExecArg, err)
+	os.Exit(127)
+}
+
+// rlimitSandbox caps the address space of the agent; children inherit the limit
+type rlimitSandbox struct {
+	l       limitsConfig
+	cmd     *exec.Cmd
+	wrapped bool // the agent starts through runRlimitExec, already limited
+}
+
+// prepare starts the agent through runRlimitExec, so the limit binds before
+// the agent runs, let alone forks
+func (r *rlimitSandbox) prepare(cmd *exec.Cmd) {
+	exe, err := os.Executable()
+	if err != nil || cmd.Err != nil {
+		return // attach limits the agent once started
+	}
+	limit := strconv.FormatUint(uint64(r.l.MemoryMB)<<20, 10)
+	cmd.Args = append([]string{exe, rlimitExecArg, limit, cmd.Path}, cmd.Args...)
+	cmd.Path = exe
+	r.wrapped = true
+}
+
+func (r *rlimitSandbox) attach(cmd *exec.Cmd) error {
+	r.cmd = cmd
+	if r.wrapped {
+		return nil
+	}
+	limit := uint64(r.l.MemoryMB) << 20
+	return unix.Prlimit(cmd.Process.Pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}, nil)
+}
+
+// kill stops the agent's process group (see startLimited), which holds
+// everything it spawned that did not start a group of its own
+func (r *rlimitSandbox) kill() {
+	if r.cmd != nil {
+		killProcessTree(r.cmd)
+	}
+}
+
+func (r *rlimitSandbox) exceeded() string { return "" }
+func (r *rlimitSandbox) close()           {}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited (a zombie awaiting its reaper counts)
+func processGone(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// The state follows the parenthesized command name
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestRlimitSandboxKillsProcessTree(t *testing.T) {
+	saved := config.Limits
+	t.Cleanup(func() { config.Limits = saved })
+	// A cgroup parent that does not exist forces the rlimit fallback
+	config.Limits = limitsConfig{MemoryMB: 1024, CgroupParent: "deepresearch-test-missing"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.Command("sh", "-c", "sleep 100 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := startLimited(ctx, cmd, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.sb.(*rlimitSandbox); !ok {
+		t.Fatalf("sandbox is %T, want *rlimitSandbox", p.sb)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleepPID, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatalf("sleep PID %q: %v", line, err)
+	}
+	t.Cleanup(func() {
+		if proc, err := os.FindProcess(sleepPID); err == nil {
+			proc.Kill()
+		}
+	})
+
+	// The grandchild inherited the limit set before the agent ran
+	limits, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", sleepPID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strconv.Itoa(1024 << 20)
+	for _, l := range strings.Split(string(limits), "\n") {
+		if strings.HasPrefix(l, "Max address space") && !strings.Contains(l, want) {
+			t.Errorf("grandchild limit %q, want %s bytes", l, want)
+		}
+	}
+
+	cancel()
+	if err := p.Wait(); errorCode(err) != codeInterrupted {
+		t.Errorf("Wait() = %v, want an interruption", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !processGone(sleepPID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("grandchild %d still running after the agent was stopped", sleepPID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package main
+
+import "fmt"
+
+// newSandbox reports that only the time limit is enforced on this platform
+func newSandbox(limitsConfig) (sandbox, error) {
+	return nil, fmt.Errorf("memory, CPU and process limits need Linux cgroups or Windows job objects")
+}
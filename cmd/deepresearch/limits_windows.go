@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Job object CPU rate control (JOBOBJECT_CPU_RATE_CONTROL_INFORMATION)
+const (
+	jobCPURateControlEnable  = 0x1
+	jobCPURateControlHardCap = 0x4
+)
+
+type jobCPURateControl struct {
+	ControlFlags uint32
+	CPURate      uint32 // in 1/100 of a percent of all processors
+}
+
+// jobSandbox holds an agent run in a job object; closing the last handle kills
+// whatever is still running in it
+type jobSandbox struct {
+	l   limitsConfig
+	job windows.Handle
+}
+
+func newSandbox(l limitsConfig) (sandbox, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if l.MemoryMB > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(l.MemoryMB) << 20
+	}
+	if l.MaxProcesses > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_ACTIVE_PROCESS
+		info.BasicLimitInformation.ActiveProcessLimit = uint32(l.MaxProcesses)
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return nil, err
+	}
+	if l.CPUs > 0 {
+		rate := uint32(l.CPUs / float64(runtime.NumCPU()) * 10000)
+		rate = max(1, min(rate, 10000))
+		cpu := jobCPURateControl{ControlFlags: jobCPURateControlEnable | jobCPURateControlHardCap, CPURate: rate}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&cpu)), uint32(unsafe.Sizeof(cpu))); err != nil {
+			windows.CloseHandle(job)
+			return nil, fmt.Errorf("cannot set the CPU rate: %w", err)
+		}
+	}
+	return &jobSandbox{l: l, job: job}, nil
+}
+
+func (j *jobSandbox) prepare(*exec.Cmd) {}
+
+// attach moves the agent into the job; processes it starts afterwards join too
+func (j *jobSandbox) attach(cmd *exec.Cmd) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.AssignProcessToJobObject(j.job, h)
+}
+
+func (j *jobSandbox) kill() {
+	windows.TerminateJobObject(j.job, 1)
+}
+
+func (j *jobSandbox) exceeded() string {
+	if j.l.MemoryMB == 0 {
+		return ""
+	}
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if windows.QueryInformationJobObject(j.job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil) != nil {
+		return ""
+	}
+	// Allocations fail just short of the limit
+	if info.PeakJobMemoryUsed+(1<<20) >= info.JobMemoryLimit {
+		return "reached limits.memory_mb"
+	}
+	return ""
+}
+
+func (j *jobSandbox) close() {
+	windows.CloseHandle(j.job)
+}
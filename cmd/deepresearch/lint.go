@@ -0,0 +1,432 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// Lint severities: errors break the workflow or the report, warnings are likely mistakes
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// lintIssue is one problem found in task.md
+type lintIssue struct {
+	Line     int    `json:"line,omitempty"` // 1-based; 0 for the file as a whole
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i lintIssue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Severity, i.Message)
+}
+
+// taskStatuses are the values the planner and reflector set in Metadata
+var taskStatuses = map[string]bool{"RESEARCHING": true, "SYNTHESIZING": true, "COMPLETED": true, "ERROR": true}
+
+// taskPriorities are the values a DAG task's Priority field takes
+var taskPriorities = map[string]bool{"HIGH": true, "MEDIUM": true, "LOW": true}
+
+var (
+	// checkboxRe matches anything that looks like a task checkbox, well-formed or not
+	checkboxRe    = regexp.MustCompile(`^\s*[-*]\s*\[[ xX]?\]`)
+	statusFieldRe = regexp.MustCompile(`(?i)^\s*[-*]?\s*\**status\**\s*:\s*\**\s*([A-Za-z_]*)`)
+)
+
+// dagTask is a task line of the Research DAG
+type dagTask struct {
+	ID      string
+	Done    bool
+	Line    int
+	Depends []string
+}
+
+// lintTask validates the content of a task.md: required sections, the metadata
+// status, task line syntax, task ID uniqueness, dependencies (unknown, self and
+// circular), and Knowledge Graph references to the Source Registry. Tasks are
+// read with taskfile.Parse, as the orchestrator reads them.
+func lintTask(content string) []lintIssue {
+	var issues []lintIssue
+	add := func(line int, severity, format string, args ...any) {
+		issues = append(issues, lintIssue{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var tasks []dagTask
+	taskLines := map[int]bool{}
+	firstTask := map[string]int{}
+	for _, t := range taskfile.Parse([]byte(content)).Tasks {
+		taskLines[t.Line] = true
+		if t.Priority != "" && !taskPriorities[t.Priority] {
+			add(t.Line, lintWarning, "%s: unknown priority %q (expected HIGH, MEDIUM or LOW)", t.ID, t.Priority)
+		}
+		if first, dup := firstTask[t.ID]; dup {
+			add(t.Line, lintError, "task ID %s is already used on line %d; give each task a unique ID", t.ID, first)
+		} else {
+			firstTask[t.ID] = t.Line
+		}
+		tasks = append(tasks, dagTask{ID: t.ID, Done: t.Done, Line: t.Line, Depends: t.Dependencies})
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	sections := map[string]int{} // lower-cased heading -> line
+	section := ""
+	status, statusLine := "", 0
+	hasRequest := false
+	factLines := map[string]int{}
+	sourceLines := map[string]int{}
+	type citation struct {
+		source string
+		fact   string
+		line   int
+	}
+	var citations []citation
+	lastFact := ""
+	inFence, inComment := false, false
+
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if inComment {
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "<!--") {
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			title := strings.ToLower(strings.TrimSpace(m[1]))
+			if _, seen := sections[title]; !seen {
+				sections[title] = n
+			}
+			if strings.HasPrefix(trimmed, "## ") {
+				section = title
+			}
+			continue
+		}
+
+		switch section {
+		case "", "metadata":
+			// The status may also sit in the YAML frontmatter above the title
+			if m := statusFieldRe.FindStringSubmatch(line); m != nil && statusLine == 0 {
+				status, statusLine = strings.ToUpper(m[1]), n
+			}
+			if strings.Contains(strings.ToLower(line), "original request") {
+				hasRequest = true
+			}
+		case "research dag":
+			if !taskLines[n] && checkboxRe.MatchString(line) {
+				add(n, lintError, "malformed task line; expected \"- [ ] E1: description\" or \"- [x] E1: description\"")
+			}
+		}
+
+		// Knowledge Graph entries may sit under ## Knowledge Graph or their own sections
+		if m := factLineRe.FindStringSubmatch(line); m != nil {
+			lastFact = m[1]
+			if first, dup := factLines[lastFact]; dup {
+				add(n, lintError, "fact ID %s is already used on line %d", lastFact, first)
+			} else {
+				factLines[lastFact] = n
+			}
+			// Inline form: [Fact-001] Statement | Source: S01 | Confidence: HIGH
+			if _, rest, ok := strings.Cut(m[2], "Source:"); ok {
+				for _, id := range factSrcRe.FindAllString(strings.SplitN(rest, "|", 2)[0], -1) {
+					citations = append(citations, citation{id, lastFact, n})
+				}
+			}
+			continue
+		}
+		if m := factFieldRe.FindStringSubmatch(line); m != nil && lastFact != "" && m[1] == "Source" {
+			for _, id := range factSrcRe.FindAllString(m[2], -1) {
+				citations = append(citations, citation{id, lastFact, n})
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "|") {
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			id := strings.TrimSpace(cells[0])
+			if len(cells) >= 2 && sourceIDRe.MatchString(id) {
+				if first, dup := sourceLines[id]; dup {
+					add(n, lintError, "source ID %s is already registered on line %d", id, first)
+				} else {
+					sourceLines[id] = n
+				}
+			}
+			continue
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "-") && !strings.HasPrefix(trimmed, "*") {
+			lastFact = ""
+		}
+	}
+
+	// Schema
+	if title, n := firstContentLine(lines); !strings.HasPrefix(title, "# ") {
+		add(n, lintWarning, "task.md should start with a \"# Research Task: <topic>\" title")
+	}
+	for _, name := range []string{"Metadata", "Research DAG", "Knowledge Graph"} {
+		if _, ok := sections[strings.ToLower(name)]; !ok {
+			add(0, lintError, "missing \"## %s\" section", name)
+		}
+	}
+	hasRegistry := false
+	for title := range sections {
+		hasRegistry = hasRegistry || strings.Contains(title, "source registry")
+	}
+	if !hasRegistry {
+		add(0, lintWarning, "missing \"Source Registry\" table; executors append sources to it")
+	}
+	switch {
+	case statusLine == 0:
+		add(sections["metadata"], lintError, "Metadata has no \"- Status:\" line (expected RESEARCHING or SYNTHESIZING)")
+	case !taskStatuses[status]:
+		add(statusLine, lintError, "unknown status %q (expected RESEARCHING, SYNTHESIZING, COMPLETED or ERROR)", status)
+	}
+	if !hasRequest {
+		add(sections["metadata"], lintWarning, "Metadata has no \"Original Request\"; the synthesizer answers it verbatim")
+	}
+
+	// DAG integrity
+	if _, ok := sections["research dag"]; ok && len(tasks) == 0 {
+		add(sections["research dag"], lintError, "Research DAG has no tasks")
+	}
+	byID := map[string]dagTask{}
+	for _, t := range tasks {
+		if _, dup := byID[t.ID]; !dup {
+			byID[t.ID] = t
+		}
+	}
+	pending := 0
+	for _, t := range tasks {
+		if !t.Done {
+			pending++
+		}
+		for _, dep := range t.Depends {
+			d, ok := byID[dep]
+			switch {
+			case dep == t.ID:
+				add(t.Line, lintError, "%s depends on itself", t.ID)
+			case !ok:
+				add(t.Line, lintError, "%s depends on %s, which is not in the Research DAG", t.ID, dep)
+			case t.Done && !d.Done:
+				add(t.Line, lintWarning, "%s is complete but its dependency %s is still pending", t.ID, dep)
+			}
+		}
+	}
+	for _, cycle := range dagCycles(byID) {
+		add(byID[cycle[0]].Line, lintError, "circular dependency %s; no task in it can ever start", strings.Join(append(cycle, cycle[0]), " → "))
+	}
+	if status == "SYNTHESIZING" && pending > 0 {
+		add(statusLine, lintWarning, "status is SYNTHESIZING but %d task(s) are still pending; the orchestrator keeps researching while any \"- [ ]\" remains", pending)
+	}
+
+	// Knowledge Graph references
+	for _, c := range citations {
+		if _, ok := sourceLines[c.source]; !ok {
+			add(c.line, lintError, "%s cites %s, which is not in the Source Registry", c.fact, c.source)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// firstContentLine returns the first non-blank line after any YAML frontmatter
+// and its 1-based number
+func firstContentLine(lines []string) (string, int) {
+	inFrontmatter := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "---" && (i == 0 || inFrontmatter):
+			inFrontmatter = !inFrontmatter
+		case inFrontmatter, trimmed == "":
+		default:
+			return trimmed, i + 1
+		}
+	}
+	return "", 1
+}
+
+// dagCycles returns each dependency cycle once, starting at its smallest task ID
+func dagCycles(tasks map[string]dagTask) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	seen := map[string]bool{}
+	var cycles [][]string
+	var stack []string
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range tasks[id].Depends {
+			if _, ok := tasks[dep]; !ok || dep == id {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := 0
+				for i, s := range stack {
+					if s == dep {
+						start = i
+					}
+				}
+				cycle := append([]string(nil), stack[start:]...)
+				// Rotate to the smallest ID so each cycle is reported once
+				first := 0
+				for i := range cycle {
+					if cycle[i] < cycle[first] {
+						first = i
+					}
+				}
+				cycle = append(cycle[first:], cycle[:first]...)
+				if key := strings.Join(cycle, ","); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+	for _, id := range sortedKeys(tasks) {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// lintErrors returns only the error-severity issues
+func lintErrors(issues []lintIssue) []lintIssue {
+	var errs []lintIssue
+	for _, i := range issues {
+		if i.Severity == lintError {
+			errs = append(errs, i)
+		}
+	}
+	return errs
+}
+
+// runLint implements `deepresearch lint`: validates a hand-edited or generated
+// task.md and exits non-zero when it has errors
+func runLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the issues as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch lint [-json] [task.md]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	path := taskFilePath(".")
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if st, err := os.Stat(path); err == nil && st.IsDir() {
+		path = taskFilePath(path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Cannot lint: %v", err)
+	}
+
+	issues := lintTask(string(content))
+	errs := lintErrors(issues)
+	if *asJSON {
+		if issues == nil {
+			issues = []lintIssue{}
+		}
+		data, _ := json.MarshalIndent(issues, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, i := range issues {
+			color := colorYellow
+			if i.Severity == lintError {
+				color = colorRed
+			}
+			line := ""
+			if i.Line > 0 {
+				line = fmt.Sprintf("%d:", i.Line)
+			}
+			fmt.Printf("%s:%s %s%s%s: %s\n", path, line, color, i.Severity, colorReset, i.Message)
+		}
+	}
+	if len(errs) > 0 {
+		if !*asJSON {
+			fatalCode(codeValidationFailed, "%s: %d error(s), %d warning(s)", path, len(errs), len(issues)-len(errs))
+		}
+		os.Exit(1)
+	}
+	if !*asJSON {
+		success("%s: no errors, %d warning(s)", path, len(issues))
+	}
+}
+
+// lintAfterPhase checks task.md once an agent has written it and reports the
+// errors, which the next agent is asked to fix (see buildLintNote)
+func lintAfterPhase(taskFile, phase string, iteration int) {
+	content, err := os.ReadFile(taskFile)
+	if err != nil {
+		return
+	}
+	errs := lintErrors(lintTask(string(content)))
+	if len(errs) == 0 {
+		return
+	}
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.String())
+	}
+	logEntry("WARN", "LINT", iteration, "task.md has lint errors", map[string]string{
+		"phase":  phase,
+		"errors": strings.Join(msgs, "; "),
+	})
+	warn("task.md has %d lint error(s) after %s (run `deepresearch lint` for details):", len(errs), phase)
+	for _, m := range msgs[:min(len(msgs), 5)] {
+		fmt.Printf("  %s\n", m)
+	}
+}
+
+// buildLintNote asks the next agent to repair task.md lint errors, or "" if
+// there are none
+func buildLintNote(taskFile string) string {
+	content, err := os.ReadFile(taskFile)
+	if err != nil {
+		return ""
+	}
+	errs := lintErrors(lintTask(string(content)))
+	if len(errs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+TASK_MD_ERRORS: task.md does not validate. Fix these before anything else, keeping its structure:
+`)
+	for _, e := range errs {
+		fmt.Fprintf(&b, "- %s\n", e)
+	}
+	return b.String()
+}
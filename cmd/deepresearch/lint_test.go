@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const lintTestTask = `# Research Task: heat pumps
+
+## Metadata
+- Status: RESEARCHING
+- Original Request: compare heat pumps
+
+## Research DAG
+- [x] E1: Survey vendors | Priority: HIGH
+* [ ] E2: Compare prices | Depends: E1
+- [ ] E3: Check subsidies | Priority: URGENT | Depends: E9
+-[ ] E4 no colon
+
+## Knowledge Graph
+[Fact-001] Heat pumps are efficient
+- Source: [S01]
+
+## Source Registry
+| ID | URL |
+|----|-----|
+| S01 | https://example.com |
+`
+
+func TestLintTask(t *testing.T) {
+	var got []string
+	for _, issue := range lintTask(lintTestTask) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`line 10: warning: E3: unknown priority "URGENT" (expected HIGH, MEDIUM or LOW)`,
+		`line 10: error: E3 depends on E9, which is not in the Research DAG`,
+		`line 11: error: malformed task line; expected "- [ ] E1: description" or "- [x] E1: description"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintTaskCycles(t *testing.T) {
+	content := strings.Replace(lintTestTask, "Depends: E9", "Depends: E2", 1)
+	content = strings.Replace(content, "Depends: E1", "Depends: E3", 1)
+	found := false
+	for _, issue := range lintTask(content) {
+		found = found || strings.Contains(issue.Message, "circular dependency")
+	}
+	if !found {
+		t.Error("the cycle E2 → E3 → E2 was not reported")
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerContextFileIngester(".mbox", ingestMail)
+	registerContextFileIngester(".eml", ingestMail)
+}
+
+// mailMessage is a decoded email with the headers needed for threading
+type mailMessage struct {
+	ID         string
+	InReplyTo  string
+	References []string
+	From       string
+	To         string
+	Cc         string
+	Date       time.Time
+	Subject    string
+	Body       string
+}
+
+// mailThread is a reconstructed conversation
+type mailThread struct {
+	Subject  string
+	Messages []*mailMessage
+}
+
+// replyPrefixRe strips reply/forward prefixes (Re:, Fwd:, AW:, WG:, 回复:) when grouping by subject
+var replyPrefixRe = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|wg|sv|vs|antw|回复|答复|转发)\s*(\[\d+\])?\s*[:：]\s*)+`)
+
+// quoteHeaderRe matches the attribution line that starts quoted history ("On Mon, ... wrote:")
+var quoteHeaderRe = regexp.MustCompile(`(?m)^(On .{5,200} wrote:|-{2,}\s*Original Message\s*-{2,}|Am .{5,200} schrieb .*:)\s*$`)
+
+// readMbox splits an mbox file into raw messages, undoing mboxrd ">From " quoting
+func readMbox(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var messages [][]byte
+	var cur bytes.Buffer
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 64<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "From ") {
+			if cur.Len() > 0 {
+				messages = append(messages, append([]byte(nil), cur.Bytes()...))
+				cur.Reset()
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		cur.WriteString(line + "\n")
+	}
+	if cur.Len() > 0 {
+		messages = append(messages, cur.Bytes())
+	}
+	return messages, sc.Err()
+}
+
+// parseMail decodes a raw RFC 5322 message
+func parseMail(raw []byte) (*mailMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	h := msg.Header
+	dec := new(mime.WordDecoder)
+	decode := func(s string) string {
+		if d, err := dec.DecodeHeader(s); err == nil {
+			return d
+		}
+		return s
+	}
+	m := &mailMessage{
+		ID:      strings.Trim(h.Get("Message-ID"), "<> "),
+		Subject: decode(h.Get("Subject")),
+		From:    decode(h.Get("From")),
+		To:      decode(h.Get("To")),
+		Cc:      decode(h.Get("Cc")),
+	}
+	if ids := strings.Fields(h.Get("In-Reply-To")); len(ids) > 0 {
+		m.InReplyTo = strings.Trim(ids[0], "<>")
+	}
+	for _, r := range strings.Fields(h.Get("References")) {
+		m.References = append(m.References, strings.Trim(r, "<>"))
+	}
+	m.Date, _ = h.Date()
+	body, err := mailText(h.Get("Content-Type"), h.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.Body = trimQuoted(body)
+	return m, nil
+}
+
+// mailText extracts the readable text of a (possibly multipart) body, preferring
+// text/plain over HTML
+func mailText(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		var plain, html string
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if p.FileName() != "" {
+				continue // attachments
+			}
+			text, err := mailText(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p)
+			if err != nil {
+				continue
+			}
+			ct := strings.ToLower(p.Header.Get("Content-Type"))
+			switch {
+			case plain == "" && (ct == "" || strings.HasPrefix(ct, "text/plain") || strings.HasPrefix(ct, "multipart/")):
+				plain = text
+			case html == "" && strings.HasPrefix(ct, "text/html"):
+				html = text
+			}
+		}
+		if plain != "" {
+			return plain, nil
+		}
+		return html, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, 4<<20))
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if mediaType == "text/html" {
+		text = strings.Join(strings.Fields(htmlTagRe.ReplaceAllString(text, " ")), " ")
+	}
+	return text, nil
+}
+
+// newlineStripper drops CR/LF so line-wrapped base64 decodes
+type newlineStripper struct{ r io.Reader }
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	k, err := n.r.Read(buf)
+	j := 0
+	for _, b := range buf[:k] {
+		if b != '\r' && b != '\n' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
+
+// trimQuoted removes quoted history so each message contributes only what it adds
+func trimQuoted(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	if loc := quoteHeaderRe.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), ">") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// threadMails groups messages into conversations via References/In-Reply-To,
+// falling back to the normalized subject for clients that drop those headers
+func threadMails(messages []*mailMessage) []*mailThread {
+	parent := map[*mailMessage]*mailMessage{}
+	byID := map[string]*mailMessage{}
+	for _, m := range messages {
+		if m.ID != "" {
+			byID[m.ID] = m
+		}
+	}
+	root := func(m *mailMessage) *mailMessage {
+		for seen := 0; parent[m] != nil && seen < len(messages); seen++ {
+			m = parent[m]
+		}
+		return m
+	}
+	bySubject := map[string]*mailMessage{}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Date.Before(messages[j].Date) })
+	for _, m := range messages {
+		refs := append([]string{m.InReplyTo}, m.References...)
+		for _, r := range refs {
+			if p, ok := byID[r]; ok && p != m {
+				parent[m] = p
+				break
+			}
+		}
+		subject := strings.ToLower(strings.TrimSpace(replyPrefixRe.ReplaceAllString(m.Subject, "")))
+		if parent[m] == nil && subject != "" {
+			if first, ok := bySubject[subject]; ok && first != m {
+				parent[m] = first
+			}
+		}
+		if _, ok := bySubject[subject]; !ok {
+			bySubject[subject] = m
+		}
+	}
+	threads := map[*mailMessage]*mailThread{}
+	var order []*mailThread
+	for _, m := range messages {
+		r := root(m)
+		th, ok := threads[r]
+		if !ok {
+			th = &mailThread{Subject: strings.TrimSpace(replyPrefixRe.ReplaceAllString(r.Subject, ""))}
+			threads[r] = th
+			order = append(order, th)
+		}
+		th.Messages = append(th.Messages, m)
+	}
+	return order
+}
+
+// renderMailThreads writes threads as Markdown with participants and dates
+func renderMailThreads(source string, threads []*mailThread) string {
+	var b strings.Builder
+	total := 0
+	for _, th := range threads {
+		total += len(th.Messages)
+	}
+	fmt.Fprintf(&b, "# Email: %s\n\n%d message(s) in %d thread(s). Quoted history is removed from replies.\n", source, total, len(threads))
+	for _, th := range threads {
+		seen := map[string]bool{}
+		var names, domains []string
+		for _, m := range th.Messages {
+			if !seen[m.From] {
+				seen[m.From] = true
+				names = append(names, m.From)
+			}
+			if d := senderDomain(m.From); d != "" && !seen["@"+d] {
+				seen["@"+d] = true
+				domains = append(domains, d)
+			}
+		}
+		first, last := th.Messages[0].Date, th.Messages[len(th.Messages)-1].Date
+		fmt.Fprintf(&b, "\n## %s\n\n*%d message(s), %s to %s. Participants: %s. Organizations: %s*\n", orUnknown(th.Subject), len(th.Messages),
+			first.Format("2006-01-02"), last.Format("2006-01-02"), strings.Join(names, "; "), strings.Join(domains, ", "))
+		for _, m := range th.Messages {
+			fmt.Fprintf(&b, "\n### %s — %s\n\n", m.From, m.Date.Format("2006-01-02 15:04 MST"))
+			if m.To != "" {
+				fmt.Fprintf(&b, "To: %s\n", m.To)
+			}
+			if m.Cc != "" {
+				fmt.Fprintf(&b, "Cc: %s\n", m.Cc)
+			}
+			fmt.Fprintf(&b, "\n%s\n", m.Body)
+		}
+	}
+	return b.String()
+}
+
+// senderDomain returns the domain of a From address, which identifies the
+// sender's organization (vendor, customer, internal)
+func senderDomain(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return ""
+	}
+	_, domain, _ := strings.Cut(addr.Address, "@")
+	return strings.ToLower(domain)
+}
+
+// ingestMail handles --context with an .mbox file, an .eml file or a directory of .eml files
+func ingestMail(path string) (map[string]string, error) {
+	var raws [][]byte
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case st.IsDir():
+		files, _ := filepath.Glob(filepath.Join(path, "*.eml"))
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			raws = append(raws, data)
+		}
+	case strings.EqualFold(filepath.Ext(path), ".eml"):
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		raws = [][]byte{data}
+	default:
+		if raws, err = readMbox(path); err != nil {
+			return nil, err
+		}
+	}
+	var messages []*mailMessage
+	skipped := 0
+	for _, raw := range raws {
+		m, err := parseMail(raw)
+		if err != nil {
+			skipped++
+			continue
+		}
+		messages = append(messages, m)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no readable messages in %s", path)
+	}
+	if skipped > 0 {
+		warn("Skipped %d unreadable message(s) in %s", skipped, path)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return map[string]string{"mail-" + slugify(name) + ".md": renderMailThreads(filepath.Base(path), threadMails(messages))}, nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -9,8 +11,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
 )
 
 // Global log file handle
@@ -22,12 +29,15 @@ type AgentConfig struct {
 	Args            func(prompt, model, workDir string) []string
 	InteractiveArgs func(prompt, model, workDir string) []string // Args for interactive mode with initial prompt
 	ModelArg        string                                       // The CLI argument name for model (e.g., "--model")
+	Check           func(model string) error                     // Reports why the agent cannot run; nil checks that Command is on PATH
+	Usage           bool                                         // Prints token usage parseUsage reads, so --budget can be enforced
 }
 
 var agentConfigs = map[string]AgentConfig{
 	"copilot": {
 		Command:  "copilot",
 		ModelArg: "--model",
+		Usage:    true,
 		Args: func(prompt, model, workDir string) []string {
 			args := []string{"-p", prompt, "--yolo", "--add-dir", workDir}
 			if model != "" {
@@ -47,8 +57,10 @@ var agentConfigs = map[string]AgentConfig{
 	"claude": {
 		Command:  "claude",
 		ModelArg: "--model",
+		Usage:    true,
 		Args: func(prompt, model, workDir string) []string {
-			args := []string{"-p", prompt, "--dangerously-skip-permissions"}
+			// stream-json reports the cost of the run; streamOutput shows its text
+			args := []string{"-p", prompt, "--dangerously-skip-permissions", "--output-format", "stream-json", "--verbose"}
 			if model != "" {
 				args = append(args, "--model", model)
 			}
@@ -84,14 +96,474 @@ var agentConfigs = map[string]AgentConfig{
 	},
 }
 
+// maxIterations bounds the research/reflection loop (run.max_iterations)
+var maxIterations = 10
+
+// subcommands maps a subcommand name to its entry point; without one, main runs the research workflow
+var subcommands = map[string]func(args []string){
+	"view":       runView,
+	"tool":       runTool,
+	"serve":      runServe,
+	"dispatch":   runDispatch,
+	"worker":     runWorker,
+	"verify":     runVerify,
+	"eval":       runEval,
+	"experiment": runExperiment,
+	"golden":     runGolden,
+	"lint":       runLint,
+	"telemetry":  runTelemetryCommand,
+	"list":       runList,
+	"show":       runShow,
+	"clean":      runClean,
+	"batch":      runBatch,
+	// The egress proxy of a sandbox with network: allowlist, run in its container
+	"sandbox-proxy": runSandboxProxy,
+}
+
 func main() {
+	if isMockAgent() {
+		runMockAgent(os.Args[1:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == apiAgentArg {
+		runAPIAgent(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			if os.Args[1] != "telemetry" {
+				reportCommandTelemetry(os.Args[1])
+			}
+			return
+		}
+	}
+
 	// Parse command line arguments
-	prompt := flag.String("p", "", "Direct prompt input (skips interactive approval)")
+	prompt := flag.String("p", "", "Direct prompt input (skips interactive approval unless --review-plan)")
 	promptFile := flag.String("f", "", "Read prompt from file")
-	agent := flag.String("agent", "", "Agent to use: copilot, claude, gemini (auto-detect if not specified)")
+	agent := flag.String("agent", "", "Agent to use: copilot, claude, gemini or one defined in ~/.deepresearch/agents.yaml (auto-detect if not specified)")
 	model := flag.String("model", "", "Model to use (e.g., claude-sonnet-4-20250514, gpt-4o, gemini-2.0-flash)")
+	phaseFlags := map[string]*phaseAgentConfig{}
+	for _, p := range agentPhases {
+		sel := &phaseAgentConfig{}
+		flag.StringVar(&sel.Agent, p.flag+"-agent", "", fmt.Sprintf("Agent of the %s (default from config, else --agent)", p.title))
+		flag.StringVar(&sel.Model, p.flag+"-model", "", fmt.Sprintf("Model of the %s (default from config, else --model if it runs the --agent agent)", p.title))
+		phaseFlags[p.key] = sel
+	}
+	configPath := flag.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	ephemeral := flag.Bool("ephemeral", false, "Wipe tmp, transcripts and caches after producing the report")
+	maxAssetsMB := flag.Int("max-assets-mb", 0, "Cap assets/ at this many MB, evicting the oldest uncited files (default from config: unlimited)")
+	noCache := flag.Bool("no-cache", false, "Neither reuse sources earlier runs fetched nor add this run's to the shared cache (~/.deepresearch/cache)")
+	kb := flag.Bool("kb", false, "Seed the run with related findings of earlier runs from the knowledge base (./knowledge/) and add this run's findings to it")
+	cacheTTL := flag.Int("cache-ttl-days", 0, "Reuse cached sources fetched at most this many days ago (default from config: 30)")
+	maxSourceAge := flag.Int("max-source-age", 0, "Warn when a fast-moving topic cites sources older than this many days (default from config: 730)")
+	salvage := flag.String("salvage", salvageAsk, "On abort after findings exist, generate a partial report: ask, auto, off")
+	sectioned := flag.Bool("sectioned", false, "Synthesize the report section by section into sections/ (survives synthesizer crashes)")
+	openReport := flag.Bool("open", false, "Open the report in the default viewer when the run finishes")
+	reproBundle := flag.Bool("repro-bundle", false, "Pack prompts, config, versions, fetched sources and transcripts into repro-bundle.tar.gz")
+	verifyCitations := flag.String("verify-citations", "", "Check the report's citations after synthesis: off, annotate (mark dead links and unbacked sources in report.md) or resource (research replacements first) (default from config: off)")
+	outputFormat := flag.String("output-format", "", "Report formats to write after synthesis, comma-separated: md, html (self-contained report.html), pdf (default from config: md)")
+	reportLang := flag.String("report-lang", "", "Language of the report, an ISO 639-1 code such as ja: the synthesizer writes report.md in it, whatever the languages of the request and the sources; with --translate-report, the languages to translate it into, comma-separated (default from config: the request's)")
+	translateReport := flag.Bool("translate-report", false, "Keep report.md as synthesized and have a translator agent write report.<lang>.md next to it for each --report-lang language, keeping its citations and figures")
+	xlsx := flag.Bool("xlsx", false, "Also export data tables, sources and findings to report-data.xlsx")
+	zoteroCollection := flag.String("zotero-collection", "", "Import this Zotero collection as pre-approved sources (default from config)")
+	zoteroExport := flag.Bool("zotero-export", false, "Add the run's citations to the Zotero library configured in config.yaml")
+	charts := flag.String("charts", chartsOff, "Chart quantitative tables in the report: off, mermaid, vega-lite")
+	replay := flag.String("replay", "", "Re-run from a prior run's workspace or --repro-bundle archive, offline, using only its cached sources and findings")
+	replayFrom := flag.String("replay-from", replaySynthesis, "With --replay: synthesis (new report from the recorded findings) or research (re-run the research loop on the recorded plan)")
+	clarify := flag.Bool("clarify", false, "Before planning, have a clarifier agent ask 2-5 questions about the request, and give the planner your answers (default from config: off)")
+	clarifyAnswers := flag.String("clarify-answers", "", "Markdown file answering the clarifying questions, for non-interactive runs; if it is missing, the questions are written there and the run stops (implies --clarify)")
+	reviewPlan := flag.Bool("review-plan", false, "Show the plan once the planner has written it, and approve it, edit it in $EDITOR or send it back to the planner with feedback before research starts (default from config: off)")
+	planOnly := flag.Bool("plan-only", false, "Stop once the planner has written task.md, to review or edit the plan; continue with --resume or --execute-plan")
+	executePlan := flag.String("execute-plan", "", "Skip the planner: research, reflect and synthesize from this task.md, e.g. one --plan-only wrote and you edited (the topic defaults to its Original Request)")
+	resume := flag.Bool("resume", false, "Continue an interrupted run after its last completed phase (from .deepresearch/state.json): the --session one, the current directory's, or the latest session's")
+	session := flag.String("session", "", "Run in the session ./research/NAME/, reusing it if it exists (default: a new session named after the start time and topic)")
+	inPlace := flag.Bool("in-place", false, "Use the current directory as the workspace instead of a session under ./research/")
+	workdir := flag.String("workdir", "", "Use this directory as the workspace, created if missing, instead of a session under ./research/ (e.g. a folder of your repository)")
+	taskFile := flag.String("task-file", "", "Path of the research plan inside the workspace (default from config: task.md)")
+	reportFile := flag.String("report-file", "", "Path of the report inside the workspace (default from config: report.md)")
+	assetsDir := flag.String("assets-dir", "", "Directory of the archived sources inside the workspace (default from config: assets)")
+	watch := flag.Bool("watch", false, "With -f, re-run and extend the research whenever the prompt file or context/ changes")
+	tui := flag.Bool("tui", false, "Show a live dashboard of phases, tasks and the orchestrator log while the workflow runs; console and agent output goes to logs/console.log")
+	plain := flag.Bool("plain", false, "Plain output without colors, banners or spinner (default when stdout is not a terminal)")
+	gitFlag := flag.Bool("git", false, "Commit the task file, the asset manifest and the report to git after each phase, initializing a repository in the workspace unless it lies in one")
+	quiet := flag.Bool("quiet", false, "Do not stream agent output to the console; it is still written to logs/<phase>-<iteration>.log")
+	notifyWebhook := flag.String("notify-webhook", "", "POST a JSON event to this URL when a phase starts or completes, the plan (--plan-only) or the report is ready or the run fails (default from config)")
+	notifySlack := flag.String("notify-slack", "", "Post to this Slack incoming webhook URL when the plan (--plan-only) or the report is ready or the run fails (default from config)")
+	notifyDesktop := flag.Bool("notify-desktop", false, "Show a desktop notification when the plan (--plan-only) or the report is ready or the run fails")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics (phase durations, iteration, agent failures, tasks completed) at http://ADDR/metrics while the run lasts (default from config)")
+	otlpEndpointFlag := flag.String("otlp-endpoint", "", "Send a trace of the run, with a span per phase and agent invocation, to this OTLP/HTTP collector, e.g. http://localhost:4318 (default from config, else $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	telemetry := flag.String("telemetry", "", "Anonymous usage reporting: off, local (record to ~/.deepresearch/telemetry.jsonl only), on (also send to telemetry.endpoint) (default from config: off)")
+	flag.IntVar(&maxParallel, "max-parallel", 0, "Run up to N independent research tasks at once, dispatched by the orchestrator as separate executor agents (default: the Research-Supervisor agent runs them)")
+	maxIterationsFlag := flag.Int("max-iterations", 0, "Stop the research/reflection loop after N iterations (default from config: 10)")
+	stopConfidence := flag.Float64("stop-confidence", 0, "End the research loop once the reflector's confidence reaches this value from 0 to 1 (default from config: off)")
+	stopStalled := flag.Int("stop-stalled", -1, "End the research loop after N iterations in a row without new findings, a rise in the reflector's confidence or fewer open questions; 0 never (default from config: 3)")
+	maxResearchMinutes := flag.Int("max-research-minutes", 0, "Start no further research iteration once the loop has run this many minutes, and synthesize (default from config: unlimited)")
+	phaseTimeout := flag.Int("phase-timeout", 0, "Kill an agent that runs longer than this many minutes in a phase (default from config: unlimited)")
+	handoff := flag.String("handoff", "", "Structured handoff.json of each agent: check (validate it when written), require (fail a step without one) or off (default from config: check)")
+	sandboxFlag := flag.String("sandbox", "", "Run each agent in a throwaway container that sees only the workspace: off, docker or podman (default from config: off)")
+	sandboxImage := flag.String("sandbox-image", "", "With --sandbox, the image containing the agent CLIs (default from config)")
+	sandboxAllow := flag.String("sandbox-allow", "", "With --sandbox, let the agents reach only these hosts, comma-separated, through an egress proxy, e.g. api.anthropic.com,*.wikipedia.org (default from config: unrestricted)")
+	retries := flag.Int("retries", -1, "Extra attempts for a failed or timed-out agent run, with exponential backoff (default from config: 0)")
+	budget := flag.Float64("budget", 0, "Stop once the estimated agent spend reaches this many USD (default from config: no limit)")
+	onBudget := flag.String("on-budget", "", "When --budget is reached: synthesize (finish the running step, then write the report from the findings so far) or abort (default from config: synthesize)")
+	logFormatFlag := flag.String("log-format", logText, "Format of logs/orchestrator.log: text, or json (one event per line, as in logs/events.jsonl)")
+	promptsDirFlag := flag.String("prompts-dir", "", "Directory of prompt files (planner.md, reflector.md, ...) overriding the built-in ones (default from DEEPRESEARCH_PROMPTS)")
+	lang := flag.String("lang", "", "Language of CLI messages: en, zh, ja (default from DEEPRESEARCH_LANG, LC_ALL, LC_MESSAGES or LANG)")
+	var contextSpecs stringList
+	flag.Var(&contextSpecs, "context", "Ingest context material before planning, e.g. jira:EPIC-123, linear:label=NAME, slack:CHANNEL, mail.mbox, a .eml file or folder (repeatable)")
 	flag.Parse()
 
+	if *lang != "" {
+		if err := setLocale(*lang); err != nil {
+			fatalCode(codeValidationFailed, "Invalid --lang value: %v", err)
+		}
+	}
+	if *plain {
+		usePlainOutput()
+	}
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	mustLoadAgents()
+	phases, err := normalizePhaseAgents(config.Phases)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Invalid config: %v", err)
+	}
+	config.Phases = phases
+	for key, sel := range phaseFlags {
+		// A phase agent from the command line drops the configured phase model
+		if sel.Agent != "" || sel.Model != "" {
+			cfg := config.Phases[key]
+			if sel.Agent != "" {
+				cfg = phaseAgentConfig{Agent: sel.Agent}
+			}
+			if sel.Model != "" {
+				cfg.Model = sel.Model
+			}
+			config.Phases[key] = cfg
+		}
+	}
+	if *ephemeral {
+		config.Privacy.Ephemeral = true
+	}
+	if *maxAssetsMB > 0 {
+		config.Storage.MaxAssetsMB = *maxAssetsMB
+	}
+	if *noCache {
+		config.Storage.NoCache = true
+	}
+	if *cacheTTL < 0 {
+		fatalCode(codeValidationFailed, "Invalid --cache-ttl-days value: %d (expected days, or 0 for no expiry)", *cacheTTL)
+	}
+	if *cacheTTL > 0 {
+		config.Storage.CacheTTLDays = *cacheTTL
+	}
+	if config.Storage.CacheTTLDays < 0 {
+		fatalCode(codeValidationFailed, "Invalid storage.cache_ttl_days value: %d (expected days, or 0 for no expiry)", config.Storage.CacheTTLDays)
+	}
+	if *kb {
+		config.Knowledge.Enabled = true
+	}
+	if config.Knowledge.MaxFindings < 0 {
+		fatalCode(codeValidationFailed, "Invalid knowledge.max_findings value: %d (expected a count, or 0 for all)", config.Knowledge.MaxFindings)
+	}
+	if *maxSourceAge > 0 {
+		config.Freshness.MaxAgeDays = *maxSourceAge
+	}
+	if *zoteroCollection != "" {
+		config.Zotero.ImportCollection = *zoteroCollection
+	}
+	if *maxIterationsFlag < 0 {
+		fatalCode(codeValidationFailed, "Invalid --max-iterations value: %d (expected 1 or more)", *maxIterationsFlag)
+	}
+	if *maxIterationsFlag > 0 {
+		config.Run.MaxIterations = *maxIterationsFlag
+	}
+	if config.Run.MaxIterations < 1 {
+		fatalCode(codeValidationFailed, "Invalid run.max_iterations value: %d (expected 1 or more)", config.Run.MaxIterations)
+	}
+	maxIterations = config.Run.MaxIterations
+	if *stopConfidence != 0 {
+		config.Reflection.StopConfidence = *stopConfidence
+	}
+	if c := config.Reflection.StopConfidence; c < 0 || c > 1 {
+		fatalCode(codeValidationFailed, "Invalid --stop-confidence value: %g (expected 0 to 1, or 0 for off)", c)
+	}
+	if *stopStalled >= 0 {
+		config.Reflection.StopStalled = *stopStalled
+	}
+	if *maxResearchMinutes < 0 {
+		fatalCode(codeValidationFailed, "Invalid --max-research-minutes value: %d (expected minutes, or 0 for no limit)", *maxResearchMinutes)
+	}
+	if *maxResearchMinutes > 0 {
+		config.Reflection.MaxMinutes = *maxResearchMinutes
+	}
+	if config.Reflection.StopStalled < 0 || config.Reflection.MaxMinutes < 0 {
+		fatalCode(codeValidationFailed, "Invalid reflection config: stop_stalled and max_minutes cannot be negative")
+	}
+	if *phaseTimeout < 0 {
+		fatalCode(codeValidationFailed, "Invalid --phase-timeout value: %d (expected minutes, or 0 for no limit)", *phaseTimeout)
+	}
+	if *phaseTimeout > 0 {
+		// An explicit limit applies to every phase
+		config.Limits.TimeoutMinutes = *phaseTimeout
+		config.Limits.PhaseTimeoutMinutes = nil
+	}
+	if *retries >= 0 {
+		config.Run.Retries = *retries
+	}
+	if config.Run.Retries < 0 || config.Run.RetryBackoffSeconds < 0 {
+		fatalCode(codeValidationFailed, "Invalid run config: retries and retry_backoff_seconds cannot be negative")
+	}
+	if *handoff != "" {
+		config.Run.Handoff = *handoff
+	}
+	if _, ok := handoffModes[config.Run.Handoff]; !ok {
+		fatalCode(codeValidationFailed, "Invalid --handoff value: %s (expected check, require or off)", config.Run.Handoff)
+	}
+	if config.Interactive.SettleSeconds < 0 || config.Interactive.StopGraceSeconds < 0 {
+		fatalCode(codeValidationFailed, "Invalid interactive config: settle_seconds and stop_grace_seconds cannot be negative")
+	}
+	if p := config.Interactive.PTY; p != ptyAuto && p != ptyOn && p != ptyOff {
+		fatalCode(codeValidationFailed, "Invalid interactive.pty value: %s (expected auto, on or off)", p)
+	}
+	if *reviewPlan {
+		config.Interactive.ReviewPlan = true
+	}
+	if config.Interactive.ReviewPlan && *tui {
+		fatalCode(codeValidationFailed, "--review-plan cannot be combined with --tui, whose dashboard takes over the console")
+	}
+	if *clarify {
+		config.Clarify.Enabled = true
+	}
+	if *clarifyAnswers != "" {
+		config.Clarify.Enabled = true
+		config.Clarify.Answers = *clarifyAnswers
+	}
+	if config.Clarify.Answers != "" {
+		config.Clarify.Answers, _ = filepath.Abs(config.Clarify.Answers)
+	} else if config.Clarify.Enabled && *tui {
+		fatalCode(codeValidationFailed, "--clarify cannot be combined with --tui, whose dashboard takes over the console; answer the questions in a file with --clarify-answers")
+	}
+	if *taskFile != "" {
+		config.Workspace.TaskFile = *taskFile
+	}
+	if *reportFile != "" {
+		config.Workspace.ReportFile = *reportFile
+	}
+	if *assetsDir != "" {
+		config.Workspace.AssetsDir = *assetsDir
+	}
+	if err := config.Workspace.layout().Check(); err != nil {
+		fatalCode(codeValidationFailed, "Invalid workspace layout: %v", err)
+	}
+	if *workdir != "" && (*session != "" || *inPlace) {
+		fatalCode(codeValidationFailed, "--workdir cannot be combined with --session or --in-place")
+	}
+	if *outputFormat != "" {
+		config.Export.Formats = strings.Split(*outputFormat, ",")
+	}
+	outputFormats, err := parseOutputFormats(strings.Join(config.Export.Formats, ","))
+	if err != nil {
+		fatalCode(codeValidationFailed, "Invalid --output-format value: %v", err)
+	}
+	if *reportLang != "" {
+		config.Report.Languages = strings.Split(*reportLang, ",")
+	}
+	if *translateReport {
+		config.Report.Translate = true
+	}
+	if config.Report.Languages, err = parseReportLanguages(strings.Join(config.Report.Languages, ",")); err != nil {
+		fatalCode(codeValidationFailed, "Invalid --report-lang value: %v", err)
+	}
+	switch {
+	case len(config.Report.Languages) > 1 && !config.Report.Translate:
+		fatalCode(codeValidationFailed, "--report-lang lists %d languages, but report.md is written in one; add --translate-report to translate it into each", len(config.Report.Languages))
+	case config.Report.Translate && len(config.Report.Languages) == 0:
+		fatalCode(codeValidationFailed, "--translate-report needs the languages to translate the report into, e.g. --report-lang ja")
+	}
+	if *sandboxFlag != "" {
+		config.Sandbox.Mode = *sandboxFlag
+	}
+	if *sandboxImage != "" {
+		config.Sandbox.Image = *sandboxImage
+	}
+	if *sandboxAllow != "" {
+		config.Sandbox.Network = networkAllowlist
+		config.Sandbox.AllowHosts = strings.Split(*sandboxAllow, ",")
+	}
+	if config.Sandbox.Mode != sandboxOff && config.Sandbox.Mode != "" {
+		if agentSandbox, err = newContainerSandbox(config.Sandbox); err != nil {
+			fatalCode(codeValidationFailed, "Invalid --sandbox setup: %v", err)
+		}
+		if config.Execution.Mode == execPwsh {
+			warn("execution.mode pwsh does not apply to sandboxed agents; they run directly in their containers")
+		}
+	}
+	if *quiet {
+		config.AgentLogs.Quiet = true
+	}
+	if *gitFlag {
+		config.Git.Enabled = true
+	}
+	if *verifyCitations != "" {
+		config.Verification.Mode = *verifyCitations
+	}
+	switch {
+	case config.Verification.Mode != verifyOff && config.Verification.Mode != verifyAnnotate && config.Verification.Mode != verifyResource:
+		fatalCode(codeValidationFailed, "Invalid --verify-citations value: %s (expected off, annotate or resource)", config.Verification.Mode)
+	case config.Verification.Rounds < 0 || config.Verification.TimeoutSeconds < 1 || config.Verification.Concurrency < 1:
+		fatalCode(codeValidationFailed, "Invalid verification config: rounds cannot be negative, timeout_seconds and concurrency must be 1 or more")
+	}
+	if *budget < 0 {
+		fatalCode(codeValidationFailed, "Invalid --budget value: %g (expected USD, or 0 for no limit)", *budget)
+	}
+	if *budget > 0 {
+		config.Cost.BudgetUSD = *budget
+	}
+	if *onBudget != "" {
+		config.Cost.OnBudget = *onBudget
+	}
+	switch {
+	case config.Cost.BudgetUSD < 0:
+		fatalCode(codeValidationFailed, "Invalid cost.budget_usd value: %g (expected USD, or 0 for no limit)", config.Cost.BudgetUSD)
+	case config.Cost.OnBudget != budgetSynthesize && config.Cost.OnBudget != budgetAbort:
+		fatalCode(codeValidationFailed, "Invalid --on-budget value: %s (expected synthesize or abort)", config.Cost.OnBudget)
+	}
+	switch *telemetry {
+	case "":
+	case telemetryOff, telemetryLocal, telemetryOn:
+		config.Telemetry.Mode = *telemetry
+	default:
+		fatalCode(codeValidationFailed, "Invalid --telemetry value: %s (expected off, local or on)", *telemetry)
+	}
+	if *notifyWebhook != "" {
+		config.Notify.Webhook = *notifyWebhook
+	}
+	if *notifySlack != "" {
+		config.Notify.Slack = *notifySlack
+	}
+	if *notifyDesktop {
+		config.Notify.Desktop = true
+	}
+	if err := validateNotifyConfig(config.Notify); err != nil {
+		fatalCode(codeValidationFailed, "Invalid notifications config: %v", err)
+	}
+	startNotifications(config.Notify)
+	if *metricsAddr != "" {
+		config.Observability.MetricsAddr = *metricsAddr
+	}
+	if *otlpEndpointFlag != "" {
+		config.Observability.OTLPEndpoint = *otlpEndpointFlag
+	}
+	if err := validateObservabilityConfig(config.Observability); err != nil {
+		fatalCode(codeValidationFailed, "Invalid observability config: %v", err)
+	}
+
+	switch *salvage {
+	case salvageAsk, salvageAuto, salvageOff:
+		salvagePolicy = *salvage
+	default:
+		fatalCode(codeValidationFailed, "Invalid --salvage value: %s (expected ask, auto or off)", *salvage)
+	}
+	if maxParallel < 0 {
+		fatalCode(codeValidationFailed, "Invalid --max-parallel value: %d (expected 1 or more, or 0 to leave dispatch to the Research-Supervisor)", maxParallel)
+	}
+	switch *logFormatFlag {
+	case logText, logJSON:
+		logFormat = *logFormatFlag
+	default:
+		fatalCode(codeValidationFailed, "Invalid --log-format value: %s (expected text or json)", *logFormatFlag)
+	}
+	switch *charts {
+	case chartsOff, chartsMermaid, chartsVegaLite:
+		chartFormat = *charts
+	default:
+		fatalCode(codeValidationFailed, "Invalid --charts value: %s (expected off, mermaid or vega-lite)", *charts)
+	}
+
+	var replayOf *runManifest
+	if *replay != "" {
+		if *replayFrom != replaySynthesis && *replayFrom != replayResearch {
+			fatalCode(codeValidationFailed, "Invalid --replay-from value: %s (expected synthesis or research)", *replayFrom)
+		}
+		if *watch {
+			fatalCode(codeValidationFailed, "--replay cannot be combined with --watch")
+		}
+		m, err := readReplayManifest(*replay)
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot replay %s: %v", *replay, err)
+		}
+		replayOf = m
+		keepLayout(m.Layout)
+	}
+
+	var plan *taskfile.File
+	switch {
+	case *planOnly && *executePlan != "":
+		fatalCode(codeValidationFailed, "--plan-only cannot be combined with --execute-plan")
+	case (*planOnly || *executePlan != "") && (*replay != "" || *resume || *watch):
+		fatalCode(codeValidationFailed, "--plan-only and --execute-plan cannot be combined with --replay, --resume or --watch")
+	case *executePlan != "":
+		if plan, err = readPlan(*executePlan); err != nil {
+			fatalCode(codeValidationFailed, "Cannot execute %s: %v", *executePlan, err)
+		}
+	}
+
+	var resumeFrom *runState
+	resumeDir := "."
+	if *resume {
+		if *replay != "" || *watch {
+			fatalCode(codeValidationFailed, "--resume cannot be combined with --replay or --watch")
+		}
+		switch {
+		case *workdir != "":
+			resumeDir = *workdir
+		case !*inPlace:
+			var err error
+			if resumeDir, err = resumeWorkspace(*session); err != nil {
+				fatalCode(codeValidationFailed, "Cannot resume: %v", err)
+			}
+		}
+		s, err := loadState(resumeDir)
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot resume: %v", err)
+		}
+		resumeFrom = s
+		if m, err := loadManifest(resumeDir); err == nil {
+			keepLayout(m.Layout)
+		}
+	}
+
+	if *watch {
+		if *promptFile == "" || *prompt != "" {
+			fatalCode(codeValidationFailed, "--watch requires -f (and no -p)")
+		}
+		workDir, err := filepath.Abs(".")
+		if err != nil {
+			fatal("Failed to resolve working directory: %v", err)
+		}
+		// Every run of the watch extends the same session
+		var sessionArgs []string
+		if !*inPlace && *workdir == "" {
+			brief, _ := os.ReadFile(*promptFile)
+			dir, err := sessionWorkspace(*session, string(brief))
+			if err != nil {
+				fatalCode(codeValidationFailed, "Cannot create the session: %v", err)
+			}
+			sessionArgs = []string{"-session", filepath.Base(dir)}
+			info("Session: %s", filepath.Base(dir))
+		}
+		runWatch(*promptFile, workDir, sessionArgs)
+		return
+	}
+
 	// Determine user prompt: -p takes priority, then -f, then stdin
 	var userPrompt string
 	interactiveMode := false // Track if user is in interactive mode (stdin input)
@@ -104,52 +576,134 @@ func main() {
 		}
 		userPrompt = strings.TrimSpace(string(content))
 		if userPrompt == "" {
-			fatal("Prompt file is empty")
+			fatalCode(codeValidationFailed, "Prompt file is empty")
 		}
 		info("Read prompt from file: %s", *promptFile)
+	} else if plan != nil {
+		// A plan answers the request it records unless -p/-f restates it
+		userPrompt = plan.OriginalRequest()
+		if userPrompt == "" {
+			fatalCode(codeValidationFailed, "%s records no Original Request; pass the topic with -p or -f", *executePlan)
+		}
+	} else if replayOf != nil {
+		// Replays answer the prior run's request unless -p/-f restates it
+		userPrompt = replayOf.Topic
+		if userPrompt == "" || userPrompt == redactedTopic {
+			fatalCode(codeValidationFailed, "The prior run did not persist its topic; pass it with -p or -f")
+		}
+	} else if resumeFrom != nil {
+		// A resumed run continues the interrupted run's request unless -p/-f restates it
+		userPrompt = resumeFrom.Topic
+		if userPrompt == "" || userPrompt == redactedTopic {
+			fatalCode(codeValidationFailed, "The interrupted run did not persist its topic; pass it with -p or -f")
+		}
 	} else {
-		fmt.Print("Enter your research topic: ")
-		reader := bufio.NewReader(os.Stdin)
-		input, err := reader.ReadString('\n')
+		fmt.Print(tr("Enter your research topic: "))
+		input, err := stdinAnswers.ReadString('\n')
 		if err != nil {
 			fatal("Failed to read input: %v", err)
 		}
 		userPrompt = strings.TrimSpace(input)
 		if userPrompt == "" {
-			fatal("Research topic cannot be empty")
+			fatalCode(codeValidationFailed, "Research topic cannot be empty")
 		}
-		interactiveMode = true // User entered via stdin, enable interactive plan approval
+		// User entered via stdin: the planner discusses the plan with them,
+		// unless the orchestrator has them review it
+		interactiveMode = !config.Interactive.ReviewPlan
 	}
 
-	// Use current directory as working directory
+	// Each run works in a session of its own under ./research/, unless it
+	// resumes one or --in-place keeps it in the current directory
 	absWorkDir, err := filepath.Abs(".")
 	if err != nil {
 		fatal("Failed to resolve working directory: %v", err)
 	}
+	switch {
+	case resumeFrom != nil:
+		absWorkDir, _ = filepath.Abs(resumeDir)
+	case *workdir != "":
+		if absWorkDir, err = filepath.Abs(*workdir); err == nil {
+			err = os.MkdirAll(absWorkDir, 0755)
+		}
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot use %s as the workspace: %v", *workdir, err)
+		}
+	case !*inPlace:
+		if absWorkDir, err = sessionWorkspace(*session, userPrompt); err != nil {
+			fatalCode(codeValidationFailed, "Cannot create the session: %v", err)
+		}
+		if err := seedSessionContext(absWorkDir); err != nil {
+			fatalCode(codeValidationFailed, "Cannot create the session: %v", err)
+		}
+	}
+	runWorkDir = absWorkDir
+	sessionsRoot, _ := filepath.Abs(sessionsDirName)
 
-	// Detect or validate agent
+	// Detect or validate agent; a resumed run keeps its agent and model
 	agentName := *agent
+	if agentName == "" && resumeFrom != nil {
+		agentName = resumeFrom.Agent
+	}
+	if *model == "" && resumeFrom != nil {
+		*model = resumeFrom.Model
+	}
 	if agentName == "" {
 		agentName = detectAgent()
+		if agentName == "" && agentSandbox != nil {
+			fatalCode(codeAgentNotFound, "No agent to detect: with --sandbox the agent CLIs run in the image, so pass --agent")
+		}
 		if agentName == "" {
-			fatal("No supported agent CLI found. Install one of: copilot, claude, gemini")
+			fatalCode(codeAgentNotFound, "No supported agent CLI found. Install one of: %s", agentNames())
 		}
 		info("Auto-detected agent: %s", agentName)
 	} else {
-		if _, ok := agentConfigs[agentName]; !ok {
-			fatal("Unknown agent: %s. Supported: copilot, claude, gemini", agentName)
+		checkAgent(agentName, *model)
+	}
+	runAgents := []string{agentName}
+	for _, p := range agentPhases {
+		// The research phase runs either the supervisor or the executors
+		if (p.key == "executor") != (maxParallel > 0) && (p.key == "executor" || p.key == "research-supervisor") {
+			continue
 		}
-		if !isCommandAvailable(agentConfigs[agentName].Command) {
-			fatal("Agent '%s' is not installed or not in PATH", agentName)
+		a, m := phaseAgent(p.key, agentName, *model)
+		if a == agentName && m == *model {
+			continue
+		}
+		if a != agentName {
+			checkAgent(a, m)
+			if !slices.Contains(runAgents, a) {
+				runAgents = append(runAgents, a)
+			}
+		}
+		if m == "" {
+			m = tr("the agent's default")
+		}
+		info("The %s runs %s with model %s", p.key, a, m)
+	}
+	if config.Cost.BudgetUSD > 0 {
+		for _, a := range runAgents {
+			if !agentConfigs[a].Usage {
+				warn("--budget cannot be enforced while %s runs: it reports no token usage", a)
+			}
 		}
 	}
 
 	// Get prompts directory (relative to executable or current directory)
-	promptsDir := findPromptsDir()
-	if promptsDir == "" {
-		fatal("Cannot find prompts/deep-research directory")
+	promptsOverride = *promptsDirFlag
+	promptsDir, err := findPromptsDir()
+	if err != nil {
+		fatal("Cannot load prompts: %v", err)
 	}
 	info("Using prompts from: %s", promptsDir)
+	if agentSandbox != nil {
+		agentSandbox.promptsDir = promptsDir
+		info("Agents run in %s", agentSandbox.describe())
+	}
+	// Agents this binary runs itself (--agent api) read the same pack
+	os.Setenv(promptsEnv, promptsDir)
+	if filepath.Dir(absWorkDir) == sessionsRoot {
+		info("Session: %s", filepath.Base(absWorkDir))
+	}
 	info("Working directory: %s", absWorkDir)
 	if *model != "" {
 		info("Using model: %s", *model)
@@ -160,8 +714,48 @@ func main() {
 
 	// Initialize log file
 	initLogFile(absWorkDir)
+	initEventsFile(absWorkDir)
 	defer closeLogFile()
 
+	// Record the run in run-manifest.json and load phase history for ETAs
+	history = loadHistory()
+	manifestTopic := userPrompt
+	if !config.Privacy.PersistPrompts || config.Privacy.Ephemeral {
+		manifestTopic = redactedTopic
+	}
+	if resumeFrom == nil || continueManifest(absWorkDir, promptsDir) != nil {
+		initManifest(absWorkDir, promptsDir, manifestTopic, agentName, *model)
+	}
+	if resumeFrom != nil {
+		progress = resumeFrom
+		progress.Agent, progress.Model = agentName, *model
+		if progress.Phase == "" {
+			info("Resuming run %s: no phase had completed, starting over with the planner", progress.RunID)
+		} else {
+			info("Resuming run %s after %s", progress.RunID, progress.lastPhase())
+		}
+		logEntry("INFO", "RESUME", progress.Iteration, "Resuming interrupted run", map[string]string{
+			"run_id": progress.RunID,
+			"phase":  progress.Phase,
+		})
+	} else if replayOf == nil {
+		initState(absWorkDir, manifest.RunID, manifestTopic, agentName, *model)
+	}
+	ctx := watchBudget(handleInterrupts(absWorkDir), *model)
+	startTracing(config.Observability, manifest.RunID, agentName, *model)
+	if addr := config.Observability.MetricsAddr; addr != "" {
+		if err := startMetrics(addr, absWorkDir, manifest.RunID); err != nil {
+			fatalCode(codeValidationFailed, "Cannot serve metrics on %s: %v", addr, err)
+		}
+		info("Serving Prometheus metrics at http://%s/metrics", addr)
+	}
+	applyRetention(absWorkDir)
+	enforceCacheQuota()
+	stopAssetQuota := watchAssetQuota(absWorkDir)
+	// Remove stale outcome files from a previous run
+	os.Remove(filepath.Join(absWorkDir, failureFileName))
+	os.Remove(filepath.Join(absWorkDir, resultFileName))
+
 	// Log boot
 	logEntry("INFO", "BOOT", 0, "Orchestrator started", map[string]string{
 		"agent":    agentName,
@@ -169,180 +763,259 @@ func main() {
 		"work_dir": absWorkDir,
 	})
 
-	// ========== PHASE 1: PLANNER ==========
-	phase("PLANNER", "Creating research plan")
-	logEntry("INFO", "DISPATCH", 0, "Dispatching Planner agent", map[string]string{
-		"phase":       "PLANNER",
-		"interactive": fmt.Sprintf("%v", interactiveMode),
-	})
-
-	if interactiveMode {
-		// Interactive mode: launch agent in conversation mode
-		// Write complete instructions to a temp file so agent gets all context in one place
-		info("Interactive mode: You can discuss and refine the research plan with the agent")
-		info("The agent will automatically exit after creating task.md")
-		fmt.Println()
-
-		// Create lock file - agent will delete it when task.md is created
-		lockDir := filepath.Join(absWorkDir, ".locks")
-		if err := os.MkdirAll(lockDir, 0755); err != nil {
-			fatal("Failed to create .locks dir: %v", err)
-		}
-		plannerLockFile := filepath.Join(lockDir, ".planner.lock")
-		// Remove stale lock file if exists from previous run
-		if fileExists(plannerLockFile) {
-			os.Remove(plannerLockFile)
+	if config.Git.Enabled {
+		if workspaceRepo, err = openGitRepo(absWorkDir); err != nil {
+			fatalCode(codeValidationFailed, "Cannot version the workspace with --git: %v", err)
 		}
-		if err := os.WriteFile(plannerLockFile, []byte("planner in progress"), 0644); err != nil {
-			fatal("Failed to create lock file: %v", err)
+		info("Committing the artifacts to the git repository %s after each phase", workspaceRepo.top)
+	}
+
+	if config.Zotero.ImportCollection != "" {
+		if n, err := importZoteroCollection(absWorkDir); err != nil {
+			warn("Zotero import failed: %v", err)
+		} else {
+			info("Imported %d pre-approved source(s) from Zotero into %s", n, preapprovedSourcesFile)
 		}
+	}
 
-		// Create combined instruction file with planner.md content + parameters
-		plannerContent, err := os.ReadFile(filepath.Join(promptsDir, "planner.md"))
+	if len(contextSpecs) > 0 {
+		files, err := ingestContext(absWorkDir, contextSpecs)
 		if err != nil {
-			fatal("Failed to read planner.md: %v", err)
+			fatalCode(codeValidationFailed, "Context ingestion failed: %v", err)
 		}
-		defer os.Remove(plannerLockFile)
-
-		combinedPrompt := fmt.Sprintf(`# Research Planner Task
-
-## Environment Parameters
-
-- **WORKING_DIR**: %s
-- **APPROVAL_MODE**: INTERACTIVE
-- **USER_REQUEST**: %s
-
----
-
-%s
-`, absWorkDir, userPrompt, string(plannerContent))
+		info("Ingested context: %s", strings.Join(files, ", "))
+	}
 
-		// Write to tmp/planner_task.md
-		taskFile := filepath.Join(absWorkDir, "tmp", "planner_task.md")
-		if err := os.MkdirAll(filepath.Dir(taskFile), 0755); err != nil {
-			fatal("Failed to create tmp dir: %v", err)
+	if replayOf != nil {
+		n, err := seedReplay(*replay, absWorkDir)
+		if err != nil {
+			fatalCode(codeValidationFailed, "Cannot replay %s: %v", *replay, err)
 		}
-		if err := os.WriteFile(taskFile, []byte(combinedPrompt), 0644); err != nil {
-			fatal("Failed to write planner task: %v", err)
+		os.Setenv(offlineEnv, "1")
+		manifest.ReplayOf = replayOf.RunID
+		manifest.save()
+		info("Replaying run %s from %s (%d cached file(s)); agents work offline", replayOf.RunID, *replay, n)
+		logEntry("INFO", "REPLAY", 0, "Seeded workspace from prior run", map[string]string{
+			"source": *replay,
+			"run_id": replayOf.RunID,
+			"from":   *replayFrom,
+			"files":  fmt.Sprintf("%d", n),
+		})
+	}
+
+	if plan != nil {
+		if err := seedPlan(*executePlan, absWorkDir); err != nil {
+			fatalCode(codeValidationFailed, "Cannot execute %s: %v", *executePlan, err)
 		}
+		manifest.PlanFrom, _ = filepath.Abs(*executePlan)
+		manifest.save()
+		// Research starts from the plan as if this run's planner had written it
+		progress.completed("PLANNER", 0)
+		info("Executing the plan in %s (%d pending task(s)); the planner is skipped", *executePlan, len(plan.Pending()))
+		logEntry("INFO", "PLAN", 0, "Seeded workspace with an existing plan", map[string]string{
+			"source": manifest.PlanFrom,
+			"tasks":  fmt.Sprintf("%d", len(plan.Pending())),
+		})
+	}
 
-		// Prompt with lock file deletion instruction
-		initialPrompt := "Read tmp/planner_task.md and follow ALL instructions. The file contains the complete research planner guide and your specific task parameters. CRITICAL: YOU MUST DELETE .locks/.planner.lock AFTER YOU HAVE CREATED task.md FILE!"
+	prepareSourceCache(absWorkDir)
+	prepareKnowledge(absWorkDir, userPrompt)
 
-		if err := runAgentInteractiveWithLock(agentName, *model, initialPrompt, absWorkDir, plannerLockFile); err != nil {
-			logEntry("ERROR", "AGENT_FAILED", 0, "Planner failed", map[string]string{
-				"error": err.Error(),
-			})
-			fatal("Planner failed: %v", err)
-		}
-	} else {
-		// Non-interactive mode (-p or -f): auto-approve the plan
-		plannerPrompt := buildPlannerPrompt(promptsDir, absWorkDir, userPrompt, true) // AUTO_APPROVE mode
-		if err := runAgent(agentName, *model, plannerPrompt, absWorkDir); err != nil {
-			logEntry("ERROR", "AGENT_FAILED", 0, "Planner failed", map[string]string{
-				"error": err.Error(),
-			})
-			fatal("Planner failed: %v", err)
+	if *tui {
+		if !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb" {
+			warn("--tui needs a terminal; showing the console output instead")
+		} else if dash, err = startDashboard(absWorkDir, userPrompt); err != nil {
+			warn("Could not start the dashboard: %v", err)
 		}
 	}
 
-	// Verify task.md was created
-	taskFile := filepath.Join(absWorkDir, "task.md")
-	if !fileExists(taskFile) {
-		logEntry("ERROR", "STATE_WRITE", 0, "Planner did not create task.md", nil)
-		fatal("Planner did not create task.md")
+	// ========== WORKFLOW ==========
+	// A replay starts from the prior run's plan, a resumed run from its own
+	req := orchestrator.Request{
+		Topic:         userPrompt,
+		WorkDir:       absWorkDir,
+		MaxIterations: maxIterations,
+		Stop:          config.Reflection.policy(),
+		Interactive:   interactiveMode,
+		PlanOnly:      *planOnly,
+		Handoffs:      handoffMode(),
+		Layout:        config.Workspace.layout(),
+	}
+	req.ReportLanguage, req.Translations = config.Report.language(), config.Report.translations()
+	if config.Verification.Mode == verifyResource {
+		req.VerifyRounds = config.Verification.Rounds
+	}
+	firstIteration, supervised, researched := progress.resumePoint(taskFilePath(absWorkDir))
+	switch {
+	case replayOf != nil && *replayFrom == replaySynthesis:
+		// A synthesis replay reuses the recorded findings as they are
+		req.From = orchestrator.Step{Phase: orchestrator.PhaseSynthesizer}
+	case researched:
+		req.From = orchestrator.Step{Phase: orchestrator.PhaseSynthesizer, Iteration: firstIteration}
+	case supervised:
+		// The interrupted run completed this iteration's supervisor
+		req.From = orchestrator.Step{Phase: orchestrator.PhaseReflector, Iteration: firstIteration}
+	case replayOf != nil || progress.planned():
+		req.From = orchestrator.Step{Phase: orchestrator.PhaseResearch, Iteration: firstIteration}
+	}
+	if req.From.Phase == "" && config.Clarify.Enabled {
+		req.Clarifications = answeredClarifications(absWorkDir, resumeFrom != nil)
+	}
+	if req.From.Phase != "" && !fileExists(taskFilePath(absWorkDir)) {
+		logEntry("ERROR", "STATE_WRITE", 0, "Planner did not create "+req.Layout.TaskFile, nil)
+		fatalCode(codeArtifactMissing, "Planner did not create %s", req.Layout.TaskFile)
+	}
+
+	workflow := &researchWorkflow{
+		agentName:   agentName,
+		model:       *model,
+		promptsDir:  promptsDir,
+		workDir:     absWorkDir,
+		topic:       userPrompt,
+		interactive: interactiveMode,
+		reviewPlan:  config.Interactive.ReviewPlan,
+		clarify:     config.Clarify.Enabled,
+		sectioned:   *sectioned,
+	}
+	if supervised {
+		workflow.assets = updateAssetManifest(absWorkDir)
+	}
+	iterationsUsed := req.From.Iteration
+	// A resumed run whose synthesizer had completed only checks the citations
+	// again, if asked to, translates the report and redoes the post-processing
+	switch {
+	case !progress.synthesized():
+		iterationsUsed = workflow.run(ctx, req).Iterations
+	case config.Verification.Mode != verifyOff:
+		req.From.Phase = orchestrator.PhaseVerifier
+		iterationsUsed = workflow.run(ctx, req).Iterations
+	case len(req.Translations) > 0:
+		req.From.Phase = orchestrator.PhaseTranslator
+		iterationsUsed = workflow.run(ctx, req).Iterations
+	}
+	dash.stop()
+	if *planOnly {
+		finishPlanOnly(absWorkDir)
+		return
 	}
-	logEntry("INFO", "AGENT_DONE", 0, "Planner completed", map[string]string{
-		"output": "task.md",
-	})
-	success("Research plan created: task.md")
-
-	// ========== RESEARCH LOOP ==========
-	maxIterations := 10
-	for iteration := 1; iteration <= maxIterations; iteration++ {
-		// ========== PHASE 2: RESEARCH-SUPERVISOR ==========
-		phase("RESEARCH-SUPERVISOR", fmt.Sprintf("Executing research tasks (iteration %d)", iteration))
-		logEntry("INFO", "DISPATCH", iteration, "Dispatching Research-Supervisor", map[string]string{
-			"phase":     "RESEARCH-SUPERVISOR",
-			"iteration": fmt.Sprintf("%d", iteration),
-		})
 
-		supervisorPrompt := buildSupervisorPrompt(promptsDir, absWorkDir)
-		if err := runAgent(agentName, *model, supervisorPrompt, absWorkDir); err != nil {
-			logEntry("ERROR", "AGENT_FAILED", iteration, "Research-Supervisor failed", map[string]string{
-				"error": err.Error(),
+	if chartFormat != chartsOff {
+		if n, err := addCharts(absWorkDir, chartFormat); err != nil {
+			warn("Could not add charts to %s: %v", req.Layout.ReportFile, err)
+		} else if n > 0 {
+			info("Added %d %s chart(s) to %s", n, chartFormat, req.Layout.ReportFile)
+			logEntry("INFO", "CHARTS", 0, "Charts added to report", map[string]string{
+				"format": chartFormat,
+				"count":  fmt.Sprintf("%d", n),
 			})
-			fatal("Research-Supervisor failed: %v", err)
 		}
-		logEntry("INFO", "AGENT_DONE", iteration, "Research-Supervisor completed", nil)
-		success("Research tasks completed")
-
-		// ========== PHASE 3: REFLECTOR ==========
-		phase("REFLECTOR", "Analyzing research quality")
-		logEntry("INFO", "DISPATCH", iteration, "Dispatching Reflector", map[string]string{
-			"phase": "REFLECTOR",
+	}
+	if files, err := exportDataCSV(absWorkDir); err != nil {
+		warn("Could not export data tables: %v", err)
+	} else if len(files) > 0 {
+		info("Exported %d data table(s) to %s/", len(files), dataDirName)
+		logEntry("INFO", "DATA", 0, "Data tables exported", map[string]string{
+			"files": strings.Join(files, ","),
 		})
-
-		reflectorPrompt := buildReflectorPrompt(promptsDir, absWorkDir)
-		if err := runAgent(agentName, *model, reflectorPrompt, absWorkDir); err != nil {
-			logEntry("ERROR", "AGENT_FAILED", iteration, "Reflector failed", map[string]string{
-				"error": err.Error(),
-			})
-			fatal("Reflector failed: %v", err)
+	}
+	if n, err := writeBibliography(absWorkDir); err != nil {
+		warn("Could not write bibliography: %v", err)
+	} else if n > 0 {
+		info("Bibliography with %d source(s) written to %s and %s", n, bibTeXFileName, cslJSONFileName)
+	}
+	if *xlsx {
+		if err := exportXLSX(absWorkDir); err != nil {
+			warn("Could not write %s: %v", xlsxFileName, err)
+		} else {
+			info("Data appendix written to %s", xlsxFileName)
 		}
-		logEntry("INFO", "AGENT_DONE", iteration, "Reflector completed", nil)
-		success("Reflection completed")
-
-		// Check if more research is needed
-		if !needsMoreResearch(taskFile) {
-			logEntry("INFO", "REFLECTION", iteration, "Research sufficient, proceeding to synthesis", map[string]string{
-				"recommendation": "READY_FOR_SYNTHESIS",
-			})
-			info("Reflector indicates research is sufficient")
-			break
+	}
+	if files := exportReport(absWorkDir, outputFormats); len(files) > 0 {
+		info("Report exported to %s", strings.Join(files, ", "))
+	}
+	if *zoteroExport {
+		if n, err := exportZoteroCitations(absWorkDir); err != nil {
+			warn("Zotero export failed: %v", err)
+		} else {
+			info("Added %d citation(s) to Zotero", n)
 		}
-		logEntry("INFO", "REFLECTION", iteration, "More research needed", map[string]string{
-			"recommendation": "CONTINUE_RESEARCH",
-		})
-		info("Reflector added new tasks, continuing research loop...")
 	}
 
-	// ========== PHASE 4: SYNTHESIZER ==========
-	phase("SYNTHESIZER", "Generating final report")
-	logEntry("INFO", "DISPATCH", 0, "Dispatching Synthesizer", map[string]string{
-		"phase": "SYNTHESIZER",
-	})
+	// Record license signals for archived sources and flag heavy quoting of restricted material
+	stopAssetQuota()
+	assets := updateAssetManifest(absWorkDir)
+	for _, w := range checkRestrictedQuotes(absWorkDir, assets) {
+		warn("%s", w)
+	}
+	for _, w := range checkBlockedCitations(absWorkDir, assets) {
+		warn("%s", w)
+	}
+	for _, w := range checkFigures(absWorkDir) {
+		warn("%s", w)
+	}
+	reportFreshness(absWorkDir, userPrompt, assets)
+	cacheSources(absWorkDir, assets)
+	reportSourceCache(assets)
+	storeAssets(absWorkDir, assets)
+	finishing()
+	logEntry("INFO", "COMPLETED", 0, "Research workflow completed successfully", nil)
+	manifest.finish(outcomeCompleted, "")
+	progress.clear()
+	writeResult(outcomeCompleted, "", "")
+	recordKnowledge(outcomeCompleted)
+	reportRunTelemetry()
+	notifyRunEnd(outcomeCompleted, "", "")
+	history.recordIterations(agentName, *model, iterationsUsed)
+	printSummary(buildSummary(absWorkDir))
+	applyPrivacyPolicy(absWorkDir)
+	if *reproBundle {
+		exportReproBundle(absWorkDir, promptsDir)
+	}
+	success("Research complete! Report saved to: %s", req.Layout.ReportFile)
+	if len(req.Translations) > 0 {
+		var files []string
+		for _, lang := range req.Translations {
+			files = append(files, req.Layout.TranslationFile(lang))
+		}
+		info("Translations saved to: %s", strings.Join(files, ", "))
+	}
 
-	synthesizerPrompt := buildSynthesizerPrompt(promptsDir, absWorkDir, userPrompt)
-	if err := runAgent(agentName, *model, synthesizerPrompt, absWorkDir); err != nil {
-		logEntry("ERROR", "AGENT_FAILED", 0, "Synthesizer failed", map[string]string{
-			"error": err.Error(),
-		})
-		fatal("Synthesizer failed: %v", err)
+	viewPath := reportViewPath(absWorkDir)
+	info("Report: %s", viewPath)
+	if *openReport {
+		if err := openInViewer(viewPath); err != nil {
+			warn("Could not open report: %v", err)
+		}
 	}
+}
 
-	reportFile := filepath.Join(absWorkDir, "report.md")
-	if !fileExists(reportFile) {
-		logEntry("ERROR", "STATE_WRITE", 0, "Synthesizer did not create report.md", nil)
-		fatal("Synthesizer did not create report.md")
+// checkAgent exits unless agentName is a known agent that can run model
+func checkAgent(agentName, model string) {
+	if _, ok := agentConfigs[agentName]; !ok {
+		fatalCode(codeValidationFailed, "Unknown agent: %s. Supported: %s", agentName, agentNames())
+	}
+	if check := agentConfigs[agentName].Check; check != nil {
+		if err := check(model); err != nil {
+			fatalCode(codeAgentNotFound, "Agent '%s' cannot run: %v", agentName, err)
+		}
+	} else if agentSandbox == nil && !isCommandAvailable(agentConfigs[agentName].Command) {
+		// A sandboxed agent is installed in the image
+		fatalCode(codeAgentNotFound, "Agent '%s' is not installed or not in PATH", agentName)
 	}
-	logEntry("INFO", "AGENT_DONE", 0, "Synthesizer completed", map[string]string{
-		"output": "report.md",
-	})
-	logEntry("INFO", "COMPLETED", 0, "Research workflow completed successfully", nil)
-	success("Research complete! Report saved to: report.md")
 }
 
 // detectAgent finds the first available agent CLI
 func detectAgent() string {
-	// Priority order
-	priority := []string{"claude", "copilot", "gemini"}
-	for _, name := range priority {
+	for _, name := range agentPriority {
 		cfg := agentConfigs[name]
 		if isCommandAvailable(cfg.Command) {
 			return name
 		}
 	}
+	// Without an agent CLI, talk to a provider API if a key is set
+	if agentConfigs[apiAgentName].Check("") == nil {
+		return apiAgentName
+	}
 	return ""
 }
 
@@ -355,82 +1028,103 @@ func isCommandAvailable(cmd string) bool {
 // runAgentInteractive runs the agent in interactive/conversation mode
 // Uses -i flag to start interactive mode with an initial prompt
 // Then connects stdin/stdout/stderr directly for user interaction
-func runAgentInteractive(agentName, model, initialPrompt, workDir string) error {
-	return runAgentInteractiveWithLock(agentName, model, initialPrompt, workDir, "")
+func runAgentInteractive(ctx context.Context, agentName, model, initialPrompt, workDir string) error {
+	return runAgentInteractiveUntil(ctx, agentName, model, initialPrompt, workDir, nil)
 }
 
-// runAgentInteractiveWithLock runs the agent in interactive mode with optional lock file monitoring
-// If lockFile is provided, the function monitors it and terminates the agent when the lock is deleted
-func runAgentInteractiveWithLock(agentName, model, initialPrompt, workDir, lockFile string) error {
+// runAgentInteractiveUntil runs the agent in interactive mode. With a
+// completion watch, it ends the session once the agent signals that it is
+// done, interrupting the agent and killing it only if it does not exit.
+func runAgentInteractiveUntil(ctx context.Context, agentName, model, initialPrompt, workDir string, until *plannerCompletion) error {
 	cfg := agentConfigs[agentName]
 
 	// For agents that support -i (like copilot), pass the prompt directly
 	// For others (like claude), we need to use a file-based approach
 	args := cfg.InteractiveArgs(initialPrompt, model, workDir)
 
+	// The agent needs the terminal to itself
+	defer dash.suspend()()
+
 	// Show user instructions
-	fmt.Println()
-	fmt.Printf("%s╔════════════════════════════════════════════════════════════════╗%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║  INTERACTIVE PLANNER MODE                                      ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s╠════════════════════════════════════════════════════════════════╣%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║  The agent will process your research request and generate     ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║  a research plan. You can then discuss and refine the plan.    ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║                                                                ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║  When you approve the plan, the agent will create task.md      ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s║  and the workflow will automatically continue.                 ║%s\n", colorCyan, colorReset)
-	fmt.Printf("%s╚════════════════════════════════════════════════════════════════╝%s\n", colorCyan, colorReset)
-	fmt.Println()
+	if plainOutput {
+		info("%s: %s", tr("INTERACTIVE PLANNER MODE"), tr("The agent will process your research request and generate a research plan. You can then discuss and refine the plan."))
+		info("When you approve the plan, the agent will create %s and the workflow will automatically continue.", taskFileName(workDir))
+	} else {
+		printBanner(tr("INTERACTIVE PLANNER MODE"), []string{
+			tr("The agent will process your research request and generate a research plan. You can then discuss and refine the plan."),
+			fmt.Sprintf(tr("When you approve the plan, the agent will create %s and the workflow will automatically continue."), taskFileName(workDir)),
+		})
+	}
 
 	info("Starting %s in interactive mode with -i flag...", agentName)
 
-	cmd := exec.Command(cfg.Command, args...)
-	cmd.Dir = workDir
-
-	// Connect all stdio directly to terminal for full interaction
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd, cleanup, err := interactiveCommand(cfg, args, workDir)
+	if err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	defer cleanup()
 
-	// Start the agent process
-	if err := cmd.Start(); err != nil {
+	// The agent gets a terminal of its own where possible, with the console
+	// proxied to it, else the console itself
+	session, err := startInteractiveAgent(cmd, filepath.Join(workDir, "logs", plannerSessionLog))
+	if err != nil {
 		return fmt.Errorf("failed to start agent: %w", err)
 	}
+	defer session.end()
+	agentsRunning.Add(1)
+	defer agentsRunning.Done()
+	defer context.AfterFunc(ctx, func() { killProcessTree(cmd) })()
 
 	// Channel to signal process completion
-	done := make(chan error, 1)
+	exited := make(chan error, 1)
 	go func() {
-		done <- cmd.Wait()
+		exited <- cmd.Wait()
 	}()
 
-	// If lock file is provided, monitor it
-	if lockFile != "" {
+	var finished *completion
+	if until == nil {
+		err = <-exited
+	} else {
+		// Stop watching once the agent exits on its own
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		result := make(chan error, 1)
 		go func() {
-			for {
-				time.Sleep(500 * time.Millisecond)
-				if _, err := os.Stat(lockFile); os.IsNotExist(err) {
-					// Lock file deleted, wait a moment for agent to finish any output
-					time.Sleep(1 * time.Second)
-					fmt.Println()
-					info("Lock file deleted, terminating agent...")
-					// Terminate the agent process
-					if cmd.Process != nil {
-						cmd.Process.Kill()
-					}
-					return
-				}
-			}
+			err := <-exited
+			stopWatch()
+			result <- err
 		}()
+		if done, ok := until.wait(watchCtx); ok {
+			finished = &done
+			// Give the agent a moment to finish its output
+			time.Sleep(time.Second)
+			session.restoreConsole()
+			fmt.Println()
+			info("The planner is done (%s), stopping the agent...", done.reason)
+			stopWatch()
+			err = stopGracefully(cmd, result)
+		} else {
+			err = <-result
+			// The agent may have signalled completion just before it exited
+			if done, ok := until.check(); ok && ctx.Err() == nil {
+				finished = &done
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return withCode(codeInterrupted, fmt.Errorf("agent stopped: %w", context.Cause(ctx)))
 	}
 
-	// Wait for process to complete (either naturally or killed)
-	err := <-done
-	
-	// If we killed the process due to lock deletion, that's not an error
-	if lockFile != "" {
-		if _, statErr := os.Stat(lockFile); os.IsNotExist(statErr) {
-			// Lock was deleted, process was killed intentionally
-			return nil
+	if finished != nil {
+		logEntry("INFO", "COMPLETION", 0, "Interactive planner finished", map[string]string{
+			"signal":  finished.reason,
+			"status":  finished.marker.Status,
+			"message": finished.marker.Message,
+		})
+		if finished.marker.Status == doneFailed {
+			return withCode(codeAgentFailed, fmt.Errorf("the planner reported a failure: %s", orDash(finished.marker.Message)))
 		}
+		// Exiting on the interrupt deepresearch sent is not an error
+		return nil
 	}
 
 	if err != nil {
@@ -440,66 +1134,36 @@ func runAgentInteractiveWithLock(agentName, model, initialPrompt, workDir, lockF
 	return nil
 }
 
-// runAgent executes an agent with the given prompt (non-interactive mode)
-func runAgent(agentName, model, prompt, workDir string) error {
-	return runAgentWithOptions(agentName, model, prompt, workDir, false)
+// runAgent executes an agent with the given prompt (non-interactive mode),
+// retrying failed runs as run.retries allows
+func runAgent(ctx context.Context, agentName, model, prompt, workDir string) error {
+	return runAgentWith(ctx, agentCommand, agentName, model, prompt, workDir)
 }
 
-// runAgentWithOptions executes an agent with the given prompt by calling PowerShell
-// If interactive is true, stdin is connected to allow user interaction with the agent
-func runAgentWithOptions(agentName, model, prompt, workDir string, interactive bool) error {
-	cfg := agentConfigs[agentName]
-	args := cfg.Args(prompt, model, workDir)
+// runAgentWith is runAgent starting the agent's process as command builds it
+func runAgentWith(ctx context.Context, command agentCommandFunc, agentName, model, prompt, workDir string) error {
+	return withRetries(ctx, func() error {
+		return runAgentWithOptions(ctx, command, agentName, model, prompt, workDir, false)
+	})
+}
 
-	// Write prompt to a temp file to avoid command line escaping issues
-	tmpFile, err := os.CreateTemp("", "deepresearch-prompt-*.txt")
+// runAgentWithOptions executes an agent with the given prompt, directly or via
+// PowerShell depending on execution.mode
+// If interactive is true, stdin is connected to allow user interaction with the agent
+func runAgentWithOptions(ctx context.Context, command agentCommandFunc, agentName, model, prompt, workDir string, interactive bool) (err error) {
+	observed := observeAgentRun(agentName, model, "")
+	defer func() { observed(err) }()
+	cmd, shown, cleanup, err := command(agentConfigs[agentName], model, prompt, workDir)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
 	}
-	tmpPromptPath := tmpFile.Name()
-	defer os.Remove(tmpPromptPath)
-
-	if _, err := tmpFile.WriteString(prompt); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("failed to write prompt to temp file: %w", err)
-	}
-	tmpFile.Close()
-
-	// Build PowerShell command that reads prompt from file
-	// $p = Get-Content -Raw 'tempfile'; copilot -p $p --yolo --add-dir ...
-	var psArgs []string
-	for i, arg := range args {
-		if i == 1 && arg == prompt {
-			// Skip the prompt, we'll inject it via variable
-			continue
-		}
-		if i == 0 {
-			// Skip -p, we'll add it with the variable
-			continue
-		}
-		// Quote if contains spaces
-		if strings.ContainsAny(arg, " \t") {
-			psArgs = append(psArgs, fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", "''")))
-		} else {
-			psArgs = append(psArgs, arg)
-		}
-	}
-
-	psScript := fmt.Sprintf(
-		"$p = Get-Content -Raw '%s'; & '%s' -p $p %s",
-		strings.ReplaceAll(tmpPromptPath, "'", "''"),
-		cfg.Command,
-		strings.Join(psArgs, " "),
-	)
+	defer cleanup()
 
 	modeStr := "non-interactive"
 	if interactive {
 		modeStr = "interactive"
 	}
-	info("Executing via PowerShell (%s): %s -p <prompt> %s", modeStr, cfg.Command, strings.Join(psArgs, " "))
-
-	cmd := exec.Command("pwsh", "-NoProfile", "-Command", psScript)
-	cmd.Dir = workDir
+	info("Executing (%s): %s", modeStr, shown)
 
 	if interactive {
 		// Interactive mode: connect stdin/stdout/stderr directly
@@ -509,48 +1173,86 @@ func runAgentWithOptions(agentName, model, prompt, workDir string, interactive b
 		cmd.Stderr = os.Stderr
 
 		// Run and wait
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("agent exited with error: %w", err)
-		}
-	} else {
-		// Non-interactive mode: stream output but don't connect stdin
-		// Create pipes for stdout and stderr
-		stdout, err := cmd.StdoutPipe()
+		p, err := startLimited(ctx, cmd, false)
 		if err != nil {
-			return fmt.Errorf("failed to create stdout pipe: %w", err)
+			return fmt.Errorf("failed to start agent: %w", err)
 		}
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return fmt.Errorf("failed to create stderr pipe: %w", err)
+		if err := p.Wait(); err != nil {
+			return err
 		}
-
-		// Start the command
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("failed to start agent: %w", err)
+	} else {
+		// Non-interactive mode: stream output with a heartbeat spinner during silent stretches
+		alog := openAgentLog(workDir, "", shown)
+		hb := startHeartbeat(agentName)
+		err := streamAgent(ctx, cmd, agentConsole(hb, os.Stdout), agentConsole(hb, os.Stderr), alog)
+		hb.Stop()
+		alog.Close()
+		if err != nil {
+			return err
 		}
+	}
 
-		// Stream output in real-time
-		go streamOutput(stdout, os.Stdout)
-		go streamOutput(stderr, os.Stderr)
+	return nil
+}
 
-		// Wait for completion
-		if err := cmd.Wait(); err != nil {
-			return fmt.Errorf("agent exited with error: %w", err)
-		}
+// streamAgent runs a non-interactive agent under the configured resource limits,
+// streaming its output to stdout and stderr (without connecting stdin) and
+// into its agent log
+func streamAgent(ctx context.Context, cmd *exec.Cmd, stdout, stderr io.Writer, alog *agentLog) error {
+	outPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	errPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
-	return nil
+	p, err := startLimited(ctx, cmd, true)
+	if err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); streamOutput(outPipe, stdout, nil, alog.stream("stdout")) }()
+	go func() { defer wg.Done(); streamOutput(errPipe, stderr, agentStderrTail, alog.stream("stderr")) }()
+
+	// Wait for output to drain, then for completion
+	wg.Wait()
+	return p.Wait()
 }
 
-// streamOutput copies from reader to writer line by line
-func streamOutput(r io.Reader, w io.Writer) {
+// streamOutput copies from reader to writer line by line with secrets
+// redacted, metering the usage the agent reports; tail, if set, also keeps the
+// lines and record, if set, logs them
+func streamOutput(r io.Reader, w io.Writer, tail *lineRing, record func(string)) {
 	scanner := bufio.NewScanner(r)
-	// Increase buffer size for long lines
+	// Increase buffer size for long lines; a stream-json event carries whole tool results
 	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	scanner.Buffer(buf, 64*1024*1024)
 	for scanner.Scan() {
-		fmt.Fprintln(w, scanner.Text())
+		raw := scanner.Text()
+		meter.observe(raw)
+		text, ok := streamJSONText(raw)
+		if !ok {
+			text = raw
+		} else if text == "" {
+			continue
+		}
+		for _, line := range strings.Split(text, "\n") {
+			line = agentRedactor().redact(line)
+			agentOutputTail.Add(line)
+			if tail != nil {
+				tail.Add(line)
+			}
+			if record != nil {
+				record(line)
+			}
+			fmt.Fprintln(w, line)
+		}
 	}
+	// Keep draining a line too long to scan, so the agent never blocks on a full pipe
+	io.Copy(io.Discard, r)
 }
 
 // ========== LOGGING ==========
@@ -571,16 +1273,37 @@ func initLogFile(baseDir string) {
 func closeLogFile() {
 	if logFile != nil {
 		logFile.Close()
+		logFile = nil
 	}
+	closeEventsFile()
 }
 
-// logEntry writes a log entry to orchestrator.log
-// Format: [TIMESTAMP] [LEVEL] [TYPE] [ITER] | summary | field1=value1, field2=value2
+// Formats of orchestrator.log (--log-format)
+const (
+	logText = "text"
+	logJSON = "json"
+)
+
+// logFormat is the format of orchestrator.log
+var logFormat = logText
+
+// logEntry writes a log entry to orchestrator.log and the event stream
+// Text format: [TIMESTAMP] [LEVEL] [TYPE] [ITER] | summary | field1=value1, field2=value2
+// JSON format: one runEvent per line, as in events.jsonl
 func logEntry(level, logType string, iteration int, summary string, fields map[string]string) {
+	ev := newEvent(level, logType, iteration, summary, fields)
+	emitEvent(ev)
 	if logFile == nil {
 		return
 	}
 
+	if logFormat == logJSON {
+		if data, err := json.Marshal(ev); err == nil {
+			logFile.Write(append(data, '\n'))
+		}
+		return
+	}
+
 	timestamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
 
 	// Build fields string
@@ -605,47 +1328,17 @@ func logEntry(level, logType string, iteration int, summary string, fields map[s
 	logFile.WriteString(line)
 }
 
-// findPromptsDir locates the prompts/deep-research directory
-func findPromptsDir() string {
-	// Try relative to current directory
-	candidates := []string{
-		"prompts/deep-research",
-		"../prompts/deep-research",
-		"../../prompts/deep-research",
-	}
-
-	// Also try relative to executable
-	if execPath, err := os.Executable(); err == nil {
-		execDir := filepath.Dir(execPath)
-		candidates = append(candidates,
-			filepath.Join(execDir, "prompts/deep-research"),
-			filepath.Join(execDir, "../prompts/deep-research"),
-			filepath.Join(execDir, "../../prompts/deep-research"),
-		)
-	}
-
-	for _, candidate := range candidates {
-		if abs, err := filepath.Abs(candidate); err == nil {
-			if _, err := os.Stat(abs); err == nil {
-				return abs
-			}
-		}
-	}
-	return ""
-}
-
 // createDirs creates necessary directories
 func createDirs(baseDir string) {
 	dirs := []string{
-		"assets/web",
-		"assets/pdf",
-		"assets/images",
-		"assets/audio",
-		"assets/ebook",
-		"logs",
-	}
-	for _, dir := range dirs {
-		path := filepath.Join(baseDir, dir)
+		assetsPath(baseDir, "web"),
+		assetsPath(baseDir, "pdf"),
+		assetsPath(baseDir, "images"),
+		assetsPath(baseDir, "audio"),
+		assetsPath(baseDir, "ebook"),
+		filepath.Join(baseDir, "logs"),
+	}
+	for _, path := range dirs {
 		if err := os.MkdirAll(path, 0755); err != nil {
 			fatal("Failed to create directory %s: %v", path, err)
 		}
@@ -660,107 +1353,61 @@ func fileExists(path string) bool {
 
 // needsMoreResearch checks if task.md indicates more research is needed
 func needsMoreResearch(taskFile string) bool {
-	content, err := os.ReadFile(taskFile)
+	f, err := taskfile.Read(taskFile)
 	if err != nil {
 		return false
 	}
-
-	contentStr := strings.ToLower(string(content))
-
-	// Check for incomplete tasks ([ ] instead of [x])
-	if strings.Contains(contentStr, "- [ ]") {
-		return true
-	}
-
-	// Check for explicit status
-	if strings.Contains(contentStr, "status: researching") ||
-		strings.Contains(contentStr, "recommendation: continue_research") ||
-		strings.Contains(contentStr, "recommendation: add_conflict_tasks") {
-		return true
-	}
-
-	return false
+	return f.NeedsMoreResearch()
 }
 
 // ========== PROMPT BUILDERS ==========
 
 func buildPlannerPrompt(promptsDir, workDir, userPrompt string, skipApproval bool) string {
-	plannerFile := filepath.Join(promptsDir, "planner.md")
-	approvalMode := "INTERACTIVE"
-	if skipApproval {
-		approvalMode = "AUTO_APPROVE"
-	}
-
-	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
-
-WORKING_DIR: %s
-APPROVAL_MODE: %s
-`, plannerFile, workDir, approvalMode)
-
-	if userPrompt != "" {
-		prompt += fmt.Sprintf(`
-USER_REQUEST: %s
-`, userPrompt)
-	} else {
-		prompt += `
-USER_REQUEST: (Read from stdin - wait for user input)
-`
-	}
-
-	prompt += `
-TASK: Create a research plan based on the user request. Generate task.md with the research DAG.
-OUTPUT: task.md in WORKING_DIR
-
-IMPORTANT: 
-- Directories (assets/, logs/) are ALREADY created by the orchestrator
-- Do NOT run any shell/terminal commands
-- Only use file creation tools to create task.md
-`
-	return prompt
+	return orchestrator.PlannerPrompt(promptsDir, workDir, userPrompt, skipApproval) + buildLayoutNote(workDir) +
+		buildPreapprovedNote(workDir) + buildInternalSourcesNote() + buildContextNote(workDir) + buildKnowledgeNote(workDir, false) + buildRevisionNote(workDir)
 }
 
 func buildSupervisorPrompt(promptsDir, workDir string) string {
-	supervisorFile := filepath.Join(promptsDir, "research-supervisor.md")
-	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
-
-WORKING_DIR: %s
-TASK: Execute all pending research tasks (E* tasks) in task.md. Dispatch Executor agents as needed.
-Update task.md with results. Exit when all E* tasks are complete.
-`, supervisorFile, workDir)
+	return orchestrator.SupervisorPrompt(promptsDir, workDir) + buildLayoutNote(workDir) + buildLintNote(taskFilePath(workDir)) +
+		buildPreapprovedNote(workDir) + buildCachedSourcesNote(workDir) + buildToolsNote(workDir) + buildContextNote(workDir) + buildRemoteExecutorsNote(workDir)
 }
 
 func buildReflectorPrompt(promptsDir, workDir string) string {
-	reflectorFile := filepath.Join(promptsDir, "reflector.md")
-	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
-
-WORKING_DIR: %s
-TASK: Analyze the research quality in task.md. Check completeness, conflicts, and gaps.
-If more research is needed, add new tasks to task.md and set recommendation.
-If research is sufficient, set status to SYNTHESIZING.
-`, reflectorFile, workDir)
+	return orchestrator.ReflectorPrompt(promptsDir, workDir) + buildLayoutNote(workDir) + buildLintNote(taskFilePath(workDir)) + buildKnowledgeNote(workDir, true)
 }
 
 func buildSynthesizerPrompt(promptsDir, workDir, originalRequest string) string {
-	synthesizerFile := filepath.Join(promptsDir, "synthesizer.md")
-	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+	return orchestrator.SynthesizerPrompt(promptsDir, workDir, originalRequest) + buildLayoutNote(workDir) + synthesisNotes(workDir)
+}
 
-WORKING_DIR: %s
-`, synthesizerFile, workDir)
+// ========== PHASE TRACKING ==========
 
-	if originalRequest != "" {
-		prompt += fmt.Sprintf(`ORIGINAL_USER_REQUEST: %s
-`, originalRequest)
-	}
+// beginPhase announces a phase, starts tracking it in the manifest and shows the ETA
+func beginPhase(name, description string, iteration int) {
+	phase(name, description)
+	agentName, model := phaseAgent(name, manifest.Agent, manifest.Model)
+	manifest.startPhase(name, iteration, agentName, model)
+	showETA(agentName, model, name, iteration)
+}
 
-	prompt += `TASK: Generate the final research report based on task.md knowledge graph.
-OUTPUT: report.md in WORKING_DIR
-`
-	return prompt
+// completePhase marks the current phase successful and records its duration in the history
+func completePhase(format string, args ...any) {
+	elapsed := phaseElapsed()
+	p := manifest.Phases[len(manifest.Phases)-1]
+	agentName, model := manifest.phaseAgent(p)
+	manifest.endPhase(nil)
+	history.recordPhase(agentName, model, p.Name, elapsed)
+	success("%s (%s)", fmt.Sprintf(tr(format), args...), formatDuration(elapsed))
 }
 
 // ========== OUTPUT HELPERS ==========
 
 func phase(name, description string) {
+	description = tr(description)
+	if plainOutput {
+		fmt.Printf("[PHASE] %s: %s\n", name, description)
+		return
+	}
 	fmt.Printf("\n%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", colorCyan, colorReset)
 	fmt.Printf("%s▶ PHASE: %s%s\n", colorCyan, name, colorReset)
 	fmt.Printf("  %s\n", description)
@@ -768,38 +1415,99 @@ func phase(name, description string) {
 }
 
 func info(format string, args ...any) {
-	fmt.Printf("%s[INFO]%s %s\n", colorBlue, colorReset, fmt.Sprintf(format, args...))
+	fmt.Printf("%s[INFO]%s %s\n", colorBlue, colorReset, fmt.Sprintf(tr(format), args...))
 }
 
 func success(format string, args ...any) {
-	fmt.Printf("%s[SUCCESS]%s %s\n", colorGreen, colorReset, fmt.Sprintf(format, args...))
+	fmt.Printf("%s[SUCCESS]%s %s\n", colorGreen, colorReset, fmt.Sprintf(tr(format), args...))
+}
+
+// warn prints a warning and records it for the end-of-run summary. The log
+// keeps the English text.
+func warn(format string, args ...any) {
+	msg := fmt.Sprintf(tr(format), args...)
+	fmt.Printf("%s[WARN]%s %s\n", colorYellow, colorReset, msg)
+	runWarnings = append(runWarnings, msg)
+	logEntry("WARN", "WARNING", 0, fmt.Sprintf(format, args...), nil)
 }
 
 func fatal(format string, args ...any) {
-	fmt.Printf("%s[ERROR]%s %s\n", colorRed, colorReset, fmt.Sprintf(format, args...))
-	os.Exit(1)
+	fatalCode("", format, args...)
+}
+
+// fatalCode reports a fatal error with a stable error code, writes the failure
+// artifacts and exits. An empty code is classified from the message. Only the
+// console sees the translated message; logs and artifacts keep the English one.
+func fatalCode(code, format string, args ...any) {
+	finishing()
+	msg := fmt.Sprintf(format, args...)
+	if code == "" {
+		code = classifyError(msg, agentOutputTail.Lines())
+	}
+	status := exitStatus(code, manifest.failedPhase())
+	fmt.Printf("%s[ERROR]%s [%s] %s\n", colorRed, colorReset, code, fmt.Sprintf(tr(format), args...))
+	logEntry("ERROR", "FATAL", 0, msg, map[string]string{"code": code, "exit_status": fmt.Sprint(status)})
+	writeFailureReport(code, msg, status)
+	if manifest != nil {
+		manifest.ErrorCode = code
+	}
+	manifest.finish(outcomeFailed, msg)
+	writeResult(outcomeFailed, code, msg)
+	recordKnowledge(outcomeFailed)
+	reportRunTelemetry()
+	notifyRunEnd(outcomeFailed, code, msg)
+	if manifest != nil {
+		applyPrivacyPolicy(manifest.WorkDir)
+	}
+	closeLogFile()
+	os.Exit(status)
 }
 
 // ANSI color codes
 var (
-	colorReset = "\033[0m"
-	colorRed   = "\033[31m"
-	colorGreen = "\033[32m"
-	colorBlue  = "\033[34m"
-	colorCyan  = "\033[36m"
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorBlue   = "\033[34m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
 )
 
+// plainOutput replaces box-drawing banners, colors and the spinner with simple
+// prefixed lines, for screen readers, dumb terminals and log aggregation
+var plainOutput bool
+
+// failMark flags a failed check in listings
+var failMark = "✗"
+
 func init() {
 	// Disable colors on Windows if not supported
 	if runtime.GOOS == "windows" {
 		// Windows Terminal and modern PowerShell support ANSI codes
 		// but we'll check for TERM or WT_SESSION
 		if os.Getenv("WT_SESSION") == "" && os.Getenv("TERM") == "" {
-			colorReset = ""
-			colorRed = ""
-			colorGreen = ""
-			colorBlue = ""
-			colorCyan = ""
+			disableColors()
 		}
 	}
+	// Output that is piped, redirected or read by a dumb terminal is plain
+	if !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb" {
+		usePlainOutput()
+	}
+}
+
+// disableColors turns off ANSI color codes
+func disableColors() {
+	colorReset = ""
+	colorRed = ""
+	colorGreen = ""
+	colorBlue = ""
+	colorYellow = ""
+	colorCyan = ""
+}
+
+// usePlainOutput switches all console output to plain mode
+func usePlainOutput() {
+	plainOutput = true
+	failMark = "[FAIL]"
+	disableColors()
 }
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// version is the orchestrator version, overridable at build time with
+// -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// manifestFileName is the name of the per-run manifest in the working directory
+const manifestFileName = "run-manifest.json"
+
+// Global run manifest, nil until initManifest is called
+var manifest *runManifest
+
+// runManifest is a self-describing record of a single orchestrator run.
+// It is written at start and rewritten after every phase transition.
+type runManifest struct {
+	SchemaVersion int    `json:"schema_version"`
+	RunID         string `json:"run_id"`
+	Topic         string `json:"topic"`
+	ReplayOf      string `json:"replay_of,omitempty"` // run ID of the run --replay started from
+	PlanFrom      string `json:"plan_from,omitempty"` // task.md --execute-plan started from
+	Agent         string `json:"agent"`
+	Model         string `json:"model,omitempty"`
+	Sandbox       string `json:"sandbox,omitempty"` // the containers the agents ran in (--sandbox)
+	WorkDir       string `json:"work_dir"`
+	// Layout tells where the run keeps task.md, report.md and assets/ in WorkDir
+	Layout     *orchestrator.Layout `json:"layout,omitempty"`
+	PromptsDir string               `json:"prompts_dir"`
+	Versions   map[string]string    `json:"versions"`
+	Prompts    map[string]string    `json:"prompt_checksums"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Phases     []phaseRecord        `json:"phases"`
+	Usage      *tokenUsage          `json:"usage,omitempty"` // of all phases, as far as the agents report it
+	// Loop tells why the research/reflection loop ended and what each iteration achieved
+	Loop      *orchestrator.LoopEnd `json:"research_loop,omitempty"`
+	Artifacts []artifactRecord      `json:"artifacts"`
+	Outcome   string                `json:"outcome"`
+	Error     string                `json:"error,omitempty"`
+	ErrorCode string                `json:"error_code,omitempty"`
+
+	path string
+}
+
+// phaseRecord captures the timing and result of one phase invocation
+type phaseRecord struct {
+	Name       string      `json:"name"`
+	Iteration  int         `json:"iteration,omitempty"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Usage      *tokenUsage `json:"usage,omitempty"`
+	// Agent and Model are set when the phase ran another agent or model than the run
+	Agent string `json:"agent,omitempty"`
+	Model string `json:"model,omitempty"`
+	// Commit is, with --git, the commit of the artifacts the phase left
+	Commit string `json:"commit,omitempty"`
+}
+
+// artifactRecord describes a file produced by the run
+type artifactRecord struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest outcomes
+const (
+	outcomeRunning   = "running"
+	outcomeCompleted = "completed"
+	outcomeFailed    = "failed"
+)
+
+// initManifest creates the run manifest and writes it to the working directory
+func initManifest(workDir, promptsDir, topic, agentName, model string) {
+	now := time.Now()
+	layout := config.Workspace.layout()
+	manifest = &runManifest{
+		SchemaVersion: 1,
+		RunID:         now.Format("20060102-150405"),
+		Topic:         topic,
+		Agent:         agentName,
+		Model:         model,
+		WorkDir:       workDir,
+		Layout:        &layout,
+		PromptsDir:    promptsDir,
+		Versions: map[string]string{
+			"orchestrator": version,
+			"go":           runtime.Version(),
+			"os":           runtime.GOOS + "/" + runtime.GOARCH,
+		},
+		Prompts:   promptChecksums(promptsDir),
+		StartedAt: now,
+		Phases:    []phaseRecord{},
+		Artifacts: []artifactRecord{},
+		Outcome:   outcomeRunning,
+		path:      filepath.Join(workDir, manifestFileName),
+	}
+	if agentSandbox != nil {
+		// The agent CLI on this machine, if any, is not the one that runs
+		manifest.Sandbox = agentSandbox.describe()
+	} else if cfg, ok := agentConfigs[agentName]; ok {
+		if v := agentVersion(cfg.Command); v != "" {
+			manifest.Versions[agentName] = v
+		}
+	}
+	manifest.save()
+}
+
+// startPhase records the beginning of a phase run by agentName with model
+func (m *runManifest) startPhase(name string, iteration int, agentName, model string) {
+	if m == nil {
+		return
+	}
+	p := phaseRecord{
+		Name:      name,
+		Iteration: iteration,
+		StartedAt: time.Now(),
+		Status:    outcomeRunning,
+	}
+	if agentName != m.Agent || model != m.Model {
+		p.Agent, p.Model = agentName, model
+	}
+	m.Phases = append(m.Phases, p)
+	m.save()
+	notifyPhase(m.Phases[len(m.Phases)-1])
+	observePhaseStart(m.Phases[len(m.Phases)-1])
+}
+
+// phaseAgent returns the agent and model a recorded phase ran with
+func (m *runManifest) phaseAgent(p phaseRecord) (string, string) {
+	if p.Agent == "" {
+		return m.Agent, m.Model
+	}
+	return p.Agent, p.Model
+}
+
+// endPhase closes the most recently started phase with the given error (nil on success)
+func (m *runManifest) endPhase(err error) {
+	if m == nil || len(m.Phases) == 0 {
+		return
+	}
+	p := &m.Phases[len(m.Phases)-1]
+	if p.FinishedAt != nil {
+		return
+	}
+	now := time.Now()
+	p.FinishedAt = &now
+	p.DurationMs = now.Sub(p.StartedAt).Milliseconds()
+	p.Status = outcomeCompleted
+	if err != nil {
+		p.Status = outcomeFailed
+		p.Error = err.Error()
+	}
+	m.save()
+	// A resumed run closes the phase the interrupted one left open; that is no news
+	if m == manifest {
+		notifyPhase(*p)
+		observePhaseEnd(*p)
+	}
+}
+
+// failedPhase is the phase a failure ends, the last one while it is running
+// or once it failed, or nil outside one
+func (m *runManifest) failedPhase() *phaseRecord {
+	if m == nil || len(m.Phases) == 0 {
+		return nil
+	}
+	if p := &m.Phases[len(m.Phases)-1]; p.Status == outcomeRunning || p.Status == outcomeFailed {
+		return p
+	}
+	return nil
+}
+
+// addUsage adds agent usage to the most recently started phase and the run total
+func (m *runManifest) addUsage(u tokenUsage) {
+	if m == nil {
+		return
+	}
+	if m.Usage == nil {
+		m.Usage = &tokenUsage{}
+	}
+	m.Usage.add(u)
+	if n := len(m.Phases); n > 0 {
+		p := &m.Phases[n-1]
+		if p.Usage == nil {
+			p.Usage = &tokenUsage{}
+		}
+		p.Usage.add(u)
+	}
+}
+
+// finish records the final outcome of the run along with the produced artifacts
+func (m *runManifest) finish(outcome, errMsg string) {
+	if m == nil {
+		return
+	}
+	if errMsg != "" {
+		m.endPhase(fmt.Errorf("%s", errMsg))
+	}
+	now := time.Now()
+	m.FinishedAt = &now
+	m.Outcome = outcome
+	m.Error = errMsg
+	m.Artifacts = collectArtifacts(m.WorkDir)
+	m.save()
+	if m == manifest {
+		traceRunEnd(outcome, errMsg)
+	}
+}
+
+// save writes the manifest atomically
+func (m *runManifest) save() {
+	if m == nil {
+		return
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return
+	}
+	writeFileAtomic(m.path, data)
+}
+
+// loadManifest reads a run manifest from a working directory
+func loadManifest(workDir string) (*runManifest, error) {
+	path := filepath.Join(workDir, manifestFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m runManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", manifestFileName, err)
+	}
+	m.path = path
+	return &m, nil
+}
+
+// promptChecksums returns the sha256 of every markdown file in the prompts directory
+func promptChecksums(promptsDir string) map[string]string {
+	sums := map[string]string{}
+	filepath.WalkDir(promptsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		if sum, err := fileSHA256(path); err == nil {
+			rel, _ := filepath.Rel(promptsDir, path)
+			sums[filepath.ToSlash(rel)] = sum
+		}
+		return nil
+	})
+	return sums
+}
+
+// collectArtifacts lists the research outputs in the working directory
+func collectArtifacts(workDir string) []artifactRecord {
+	artifacts := []artifactRecord{}
+	add := func(path string) {
+		st, err := os.Stat(path)
+		if err != nil || st.IsDir() {
+			return
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return
+		}
+		rel, _ := filepath.Rel(workDir, path)
+		artifacts = append(artifacts, artifactRecord{Path: filepath.ToSlash(rel), Size: st.Size(), SHA256: sum})
+	}
+
+	add(taskFilePath(workDir))
+	add(reportFilePath(workDir))
+	filepath.WalkDir(assetsPath(workDir), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			add(path)
+		}
+		return nil
+	})
+
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts
+}
+
+// fileSHA256 returns the hex-encoded sha256 of a file
+func fileSHA256(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// agentVersion asks the agent CLI for its version, returning "" if unavailable
+func agentVersion(command string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, command, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default endpoints of the market data providers
+const (
+	alphaVantageAPI = "https://www.alphavantage.co/query"
+	fmpAPI          = "https://financialmodelingprep.com/api/v3"
+)
+
+func init() {
+	registerConnector(&connector{
+		Name:    "market",
+		Summary: "Current market data: quotes and company fundamentals with retrieval timestamps (Alpha Vantage or FMP)",
+		Actions: map[string]string{
+			"quote":        `<SYMBOL> [SYMBOL...] — latest price, change and volume`,
+			"fundamentals": `<SYMBOL> — market cap, valuation and profitability figures`,
+		},
+		Run: runMarket,
+	})
+}
+
+// marketSnapshot is a provider-independent set of figures for one symbol
+type marketSnapshot struct {
+	Symbol   string
+	Name     string
+	Currency string
+	AsOf     string // trading day or reporting period the figures refer to
+	Fields   [][2]string
+}
+
+// marketProviders maps a provider name to its fetch function; kind is "quote" or
+// "fundamentals" and the raw response is returned for archiving
+var marketProviders = map[string]func(t *toolContext, kind, symbol string) (marketSnapshot, []byte, error){
+	"alphavantage": alphaVantageSnapshot,
+	"fmp":          fmpSnapshot,
+}
+
+func alphaVantageSnapshot(t *toolContext, kind, symbol string) (marketSnapshot, []byte, error) {
+	key := t.apiKey("ALPHAVANTAGE_API_KEY")
+	if key == "" {
+		return marketSnapshot{}, nil, fmt.Errorf("alphavantage provider requires an API key (connectors.market.api_key_env, default $ALPHAVANTAGE_API_KEY)")
+	}
+	function := "GLOBAL_QUOTE"
+	if kind == "fundamentals" {
+		function = "OVERVIEW"
+	}
+	params := url.Values{"function": {function}, "symbol": {symbol}, "apikey": {key}}
+	var resp map[string]any
+	raw, err := t.getJSON(t.endpoint(alphaVantageAPI)+"?"+params.Encode(), nil, &resp)
+	if err != nil {
+		return marketSnapshot{}, nil, err
+	}
+	// Rate limits and unknown symbols come back as 200 with a message instead of data
+	for _, k := range []string{"Note", "Information", "Error Message"} {
+		if msg, ok := resp[k].(string); ok {
+			return marketSnapshot{}, nil, fmt.Errorf("alphavantage: %s", msg)
+		}
+	}
+	s := marketSnapshot{Symbol: strings.ToUpper(symbol)}
+	if kind == "quote" {
+		q, _ := resp["Global Quote"].(map[string]any)
+		if len(q) == 0 {
+			return marketSnapshot{}, nil, fmt.Errorf("no quote for %s", symbol)
+		}
+		s.AsOf = fmt.Sprint(q["07. latest trading day"])
+		for _, k := range []string{"05. price", "09. change", "10. change percent", "02. open", "03. high", "04. low", "08. previous close", "06. volume"} {
+			s.Fields = append(s.Fields, [2]string{k[4:], fmt.Sprint(q[k])})
+		}
+		return s, raw, nil
+	}
+	if len(resp) == 0 {
+		return marketSnapshot{}, nil, fmt.Errorf("no fundamentals for %s", symbol)
+	}
+	s.Name, _ = resp["Name"].(string)
+	s.Currency, _ = resp["Currency"].(string)
+	s.AsOf, _ = resp["LatestQuarter"].(string)
+	for _, k := range []string{"Exchange", "Sector", "Industry", "MarketCapitalization", "RevenueTTM", "GrossProfitTTM", "EBITDA",
+		"ProfitMargin", "OperatingMarginTTM", "EPS", "PERatio", "PEGRatio", "PriceToBookRatio", "DividendYield",
+		"QuarterlyRevenueGrowthYOY", "52WeekHigh", "52WeekLow", "SharesOutstanding"} {
+		if v, ok := resp[k]; ok {
+			s.Fields = append(s.Fields, [2]string{k, fmt.Sprint(v)})
+		}
+	}
+	return s, raw, nil
+}
+
+func fmpSnapshot(t *toolContext, kind, symbol string) (marketSnapshot, []byte, error) {
+	key := t.apiKey("FMP_API_KEY")
+	if key == "" {
+		return marketSnapshot{}, nil, fmt.Errorf("fmp provider requires an API key (connectors.market.api_key_env, default $FMP_API_KEY)")
+	}
+	endpoint := "/quote/"
+	if kind == "fundamentals" {
+		endpoint = "/profile/"
+	}
+	var resp []map[string]any
+	raw, err := t.getJSON(t.endpoint(fmpAPI)+endpoint+url.PathEscape(symbol)+"?apikey="+url.QueryEscape(key), nil, &resp)
+	if err != nil {
+		return marketSnapshot{}, nil, err
+	}
+	if len(resp) == 0 {
+		return marketSnapshot{}, nil, fmt.Errorf("no %s for %s", kind, symbol)
+	}
+	r := resp[0]
+	s := marketSnapshot{Symbol: strings.ToUpper(symbol)}
+	keys := []string{"price", "change", "changesPercentage", "open", "dayHigh", "dayLow", "previousClose", "volume", "marketCap", "pe", "eps", "yearHigh", "yearLow"}
+	if kind == "quote" {
+		s.Name, _ = r["name"].(string)
+		if ts, ok := r["timestamp"].(float64); ok {
+			s.AsOf = time.Unix(int64(ts), 0).UTC().Format(time.RFC3339)
+		}
+	} else {
+		s.Name, _ = r["companyName"].(string)
+		s.Currency, _ = r["currency"].(string)
+		keys = []string{"exchangeShortName", "sector", "industry", "mktCap", "price", "beta", "lastDiv", "range", "fullTimeEmployees", "country", "ipoDate"}
+	}
+	for _, k := range keys {
+		if v, ok := r[k]; ok && v != nil {
+			s.Fields = append(s.Fields, [2]string{k, marketValue(v)})
+		}
+	}
+	return s, raw, nil
+}
+
+// marketValue formats a JSON value without exponent notation for large numbers
+func marketValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func runMarket(t *toolContext, action string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a ticker symbol is required")
+	}
+	provider := t.Config.Provider
+	if provider == "" {
+		provider = "alphavantage"
+	}
+	fetch, ok := marketProviders[provider]
+	if !ok {
+		return fmt.Errorf("unknown market provider %q (expected alphavantage or fmp)", provider)
+	}
+	symbols := args
+	if action == "fundamentals" {
+		symbols = args[:1]
+	}
+	for _, symbol := range symbols {
+		s, raw, err := fetch(t, action, symbol)
+		if err != nil {
+			return err
+		}
+		retrieved := time.Now().UTC().Format(time.RFC3339)
+		if _, err := t.saveAsset("data", "market_"+provider+"_"+action+"_"+symbol, "json", raw); err != nil {
+			return err
+		}
+		var body strings.Builder
+		fmt.Fprintf(&body, "# %s %s\n\nProvider: %s. Retrieved: %s. As of: %s.\n\n| Metric | Value |\n|---|---|\n",
+			strings.TrimSpace(s.Symbol+" "+s.Name), action, provider, retrieved, s.AsOf)
+		for _, f := range s.Fields {
+			fmt.Fprintf(&body, "| %s | %s |\n", f[0], mdCell(f[1]))
+		}
+		if s.Currency != "" {
+			fmt.Fprintf(&body, "\nCurrency: %s\n", s.Currency)
+		}
+		asOf := s.AsOf
+		if len(asOf) > 10 {
+			asOf = asOf[:10]
+		}
+		path, err := t.saveDocument("data", "market_"+action+"_"+symbol, [][2]string{
+			{"title", fmt.Sprintf("%s %s (%s)", s.Symbol, action, provider)},
+			{"symbol", s.Symbol},
+			{"provider", provider},
+			{"published", asOf},
+		}, body.String())
+		if err != nil {
+			return err
+		}
+		t.printf("%s\nArchived: %s\n\n", body.String(), path)
+	}
+	t.printf("Quote figures with their retrieval timestamp (\"as of <date>, <provider>\"); market data goes stale quickly.\n")
+	return nil
+}
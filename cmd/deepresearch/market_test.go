@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// alphaVantageSettings points the market connector at endpoint with a test key
+func alphaVantageSettings(t *testing.T, endpoint string) connectorConfig {
+	t.Setenv("TEST_ALPHAVANTAGE_KEY", "test-key")
+	return connectorConfig{Endpoint: endpoint, APIKeyEnv: "TEST_ALPHAVANTAGE_KEY"}
+}
+
+func TestMarketAlphaVantageQuote(t *testing.T) {
+	srv := recordedAPI(t, "market", map[string]string{"/": "av_quote.json"})
+	dir, out, err := runRecordedTool(t, "market", alphaVantageSettings(t, srv.URL), "quote", "ibm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# IBM quote",
+		"Provider: alphavantage.",
+		"As of: 2025-02-21.",
+		"| Metric | Value |",
+		"| price | 223.4300 |",
+		"| change percent | 1.0950% |",
+		"| volume | 3546124 |",
+	)
+	files := assetFiles(t, dir, "data")
+	if len(files) != 2 {
+		t.Fatalf("archived %v, want the raw quote and its summary", files)
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, ".md") {
+			doc, _ := os.ReadFile(assetsPath(dir, "data", f))
+			checkContains(t, string(doc), `symbol: "IBM"`, `published: "2025-02-21"`)
+		}
+	}
+}
+
+func TestMarketAlphaVantageFundamentals(t *testing.T) {
+	srv := recordedAPI(t, "market", map[string]string{"/": "av_overview.json"})
+	_, out, err := runRecordedTool(t, "market", alphaVantageSettings(t, srv.URL), "fundamentals", "IBM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# IBM International Business Machines fundamentals",
+		"As of: 2024-12-31.",
+		"| MarketCapitalization | 206587937000 |",
+		"| Industry | COMPUTER & OFFICE EQUIPMENT |",
+		"Currency: USD",
+	)
+}
+
+func TestMarketFMP(t *testing.T) {
+	srv := recordedAPI(t, "market", map[string]string{
+		"/quote/AAPL":   "fmp_quote_aapl.json",
+		"/quote/MSFT":   "fmp_quote_msft.json",
+		"/profile/AAPL": "fmp_profile.json",
+	})
+	settings := connectorConfig{Provider: "fmp", Endpoint: srv.URL, APIKeyEnv: "TEST_FMP_KEY"}
+	if _, _, err := runRecordedTool(t, "market", settings, "quote", "AAPL"); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("fmp without a key: error %v, want one asking for the key", err)
+	}
+	t.Setenv("TEST_FMP_KEY", "test-key")
+	dir, out, err := runRecordedTool(t, "market", settings, "quote", "AAPL", "MSFT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# AAPL Apple Inc. quote",
+		"As of: 2025-02-21T21:00:01Z.",
+		"| marketCap | 3688669260000 |",
+		"# MSFT Microsoft Corporation quote",
+		"| pe | 32.84 |",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 4 {
+		t.Errorf("archived %v, want the raw quote and summary of each symbol", files)
+	}
+
+	_, out, err = runRecordedTool(t, "market", settings, "fundamentals", "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "# AAPL Apple Inc. fundamentals", "| mktCap | 3688669260000 |", "| range | 164.08-260.1 |", "Currency: USD")
+}
+
+func TestMarketNoData(t *testing.T) {
+	for _, c := range []struct {
+		provider, file, action, want string
+	}{
+		{"alphavantage", "av_quote_empty.json", "quote", "no quote for NOSUCH"},
+		{"alphavantage", "av_overview_empty.json", "fundamentals", "no fundamentals for NOSUCH"},
+		{"fmp", "fmp_empty.json", "quote", "no quote for NOSUCH"},
+	} {
+		srv := recordedAPI(t, "market", map[string]string{"/": c.file, "/quote/NOSUCH": c.file})
+		settings := alphaVantageSettings(t, srv.URL)
+		settings.Provider = c.provider
+		dir, _, err := runRecordedTool(t, "market", settings, c.action, "NOSUCH")
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error %v, want %q", c.file, err, c.want)
+		}
+		if files := assetFiles(t, dir, "data"); len(files) != 0 {
+			t.Errorf("%s: archived %v", c.file, files)
+		}
+	}
+}
+
+func TestMarketErrors(t *testing.T) {
+	checkHTTPErrors(t, "market", alphaVantageSettings(t, ""), "quote", "IBM")
+	t.Setenv("TEST_FMP_KEY", "test-key")
+	checkHTTPErrors(t, "market", connectorConfig{Provider: "fmp", APIKeyEnv: "TEST_FMP_KEY"}, "fundamentals", "AAPL")
+
+	// Alpha Vantage reports its daily limit with 200 OK
+	srv := recordedAPI(t, "market", map[string]string{"/": "av_rate_limited.json"})
+	dir, _, err := runRecordedTool(t, "market", alphaVantageSettings(t, srv.URL), "quote", "IBM")
+	if err == nil || !strings.Contains(err.Error(), "alphavantage: Thank you for using Alpha Vantage! Our standard API rate limit") {
+		t.Errorf("rate limited: error %v, want Alpha Vantage's message", err)
+	}
+	if files := assetFiles(t, dir, "data"); len(files) != 0 {
+		t.Errorf("rate limited: archived %v", files)
+	}
+	if _, _, err := runRecordedTool(t, "market", connectorConfig{Provider: "bloomberg"}, "quote", "IBM"); err == nil {
+		t.Error("an unknown provider succeeded")
+	}
+}
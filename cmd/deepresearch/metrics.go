@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// observabilityConfig exports metrics and traces of a run, for runs monitored
+// like any other scheduled job
+type observabilityConfig struct {
+	MetricsAddr string `yaml:"metrics_addr"` // same as --metrics-addr: serve Prometheus metrics at http://ADDR/metrics while the run lasts
+	// OTLPEndpoint is the OTLP/HTTP collector traces are sent to, e.g.
+	// http://localhost:4318 (default $OTEL_EXPORTER_OTLP_ENDPOINT); same as --otlp-endpoint
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	// OTLPHeaders are added to the export requests, e.g. an API key; values
+	// may reference environment variables as $NAME
+	OTLPHeaders map[string]string `yaml:"otlp_headers"`
+	ServiceName string            `yaml:"service_name"` // default $OTEL_SERVICE_NAME, else deepresearch
+}
+
+// phaseDurationBuckets are the upper bounds, in seconds, of the phase duration histogram
+var phaseDurationBuckets = []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+
+// runMetrics holds the Prometheus metrics of the run
+type runMetrics struct {
+	workDir string
+	runID   string
+
+	mu        sync.Mutex
+	iteration int
+	phases    map[[2]string]*histogram // by phase and status
+	agentRuns map[[3]string]int        // by agent, phase and status
+	agentSecs map[[3]string]float64
+}
+
+// histogram is a cumulative Prometheus histogram over phaseDurationBuckets
+type histogram struct {
+	counts []int
+	sum    float64
+	count  int
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range phaseDurationBuckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// The metrics of the run, nil without --metrics-addr
+var metrics *runMetrics
+
+// startMetrics serves the run's metrics at http://addr/metrics
+func startMetrics(addr, workDir, runID string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	metrics = &runMetrics{
+		workDir:   workDir,
+		runID:     runID,
+		phases:    map[[2]string]*histogram{},
+		agentRuns: map[[3]string]int{},
+		agentSecs: map[[3]string]float64{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", metrics.serve)
+	go http.Serve(ln, mux)
+	return nil
+}
+
+// observePhaseStart records the iteration a phase starts in
+func observePhaseStart(p phaseRecord) {
+	tracePhaseStart(p)
+	m := metrics
+	if m == nil || p.Iteration == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.iteration = max(m.iteration, p.Iteration)
+}
+
+// observePhaseEnd records the duration and outcome of a phase
+func observePhaseEnd(p phaseRecord) {
+	tracePhaseEnd(p)
+	m := metrics
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{p.Name, p.Status}
+	h := m.phases[key]
+	if h == nil {
+		h = &histogram{counts: make([]int, len(phaseDurationBuckets))}
+		m.phases[key] = h
+	}
+	h.observe(float64(p.DurationMs) / 1000)
+}
+
+// observeAgentRun starts timing one agent invocation, an executor's if task is
+// set; the returned function records its end
+func observeAgentRun(agentName, model, task string) func(err error) {
+	phase := currentPhase()
+	start := time.Now()
+	endSpan := traceAgentRun(agentName, model, task, start)
+	return func(err error) {
+		endSpan(err)
+		m := metrics
+		if m == nil {
+			return
+		}
+		status := "completed"
+		if err != nil {
+			status = "failed"
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		key := [3]string{agentName, phase, status}
+		m.agentRuns[key]++
+		m.agentSecs[key] += time.Since(start).Seconds()
+	}
+}
+
+// serve writes the metrics in the Prometheus text format
+func (m *runMetrics) serve(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("deepresearch_run_info", "gauge", "The run being monitored.")
+	fmt.Fprintf(&b, "deepresearch_run_info%s 1\n", labels("run_id", m.runID, "version", version))
+	if manifest != nil {
+		metric("deepresearch_run_start_time_seconds", "gauge", "Start of the run, in seconds since the epoch.")
+		fmt.Fprintf(&b, "deepresearch_run_start_time_seconds %d\n", manifest.StartedAt.Unix())
+	}
+
+	if f, err := taskfile.Read(taskFilePath(m.workDir)); err == nil {
+		metric("deepresearch_tasks", "gauge", "Tasks of the research plan.")
+		fmt.Fprintf(&b, "deepresearch_tasks %d\n", len(f.Tasks))
+		metric("deepresearch_tasks_completed", "gauge", "Tasks of the research plan completed.")
+		fmt.Fprintf(&b, "deepresearch_tasks_completed %d\n", len(f.Tasks)-len(f.Pending()))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metric("deepresearch_iteration", "gauge", "Research iteration the run is in.")
+	fmt.Fprintf(&b, "deepresearch_iteration %d\n", m.iteration)
+
+	metric("deepresearch_phase_duration_seconds", "histogram", "Duration of the completed and failed workflow phases.")
+	phaseKeys := make([][2]string, 0, len(m.phases))
+	for k := range m.phases {
+		phaseKeys = append(phaseKeys, k)
+	}
+	sort.Slice(phaseKeys, func(i, j int) bool { return phaseKeys[i][0]+phaseKeys[i][1] < phaseKeys[j][0]+phaseKeys[j][1] })
+	for _, k := range phaseKeys {
+		h := m.phases[k]
+		for i, le := range phaseDurationBuckets {
+			fmt.Fprintf(&b, "deepresearch_phase_duration_seconds_bucket%s %d\n", labels("phase", k[0], "status", k[1], "le", fmt.Sprint(le)), h.counts[i])
+		}
+		fmt.Fprintf(&b, "deepresearch_phase_duration_seconds_bucket%s %d\n", labels("phase", k[0], "status", k[1], "le", "+Inf"), h.count)
+		fmt.Fprintf(&b, "deepresearch_phase_duration_seconds_sum%s %g\n", labels("phase", k[0], "status", k[1]), h.sum)
+		fmt.Fprintf(&b, "deepresearch_phase_duration_seconds_count%s %d\n", labels("phase", k[0], "status", k[1]), h.count)
+	}
+
+	agentKeys := make([][3]string, 0, len(m.agentRuns))
+	for k := range m.agentRuns {
+		agentKeys = append(agentKeys, k)
+	}
+	sort.Slice(agentKeys, func(i, j int) bool {
+		return strings.Join(agentKeys[i][:], "\x00") < strings.Join(agentKeys[j][:], "\x00")
+	})
+	metric("deepresearch_agent_runs_total", "counter", "Agent invocations, retries and executors included.")
+	for _, k := range agentKeys {
+		fmt.Fprintf(&b, "deepresearch_agent_runs_total%s %d\n", labels("agent", k[0], "phase", k[1], "status", k[2]), m.agentRuns[k])
+	}
+	metric("deepresearch_agent_run_seconds_total", "counter", "Time spent in agent invocations.")
+	for _, k := range agentKeys {
+		fmt.Fprintf(&b, "deepresearch_agent_run_seconds_total%s %g\n", labels("agent", k[0], "phase", k[1], "status", k[2]), m.agentSecs[k])
+	}
+	metric("deepresearch_agent_failures_total", "counter", "Failed agent invocations.")
+	for _, k := range agentKeys {
+		if k[2] == "failed" {
+			fmt.Fprintf(&b, "deepresearch_agent_failures_total%s %d\n", labels("agent", k[0], "phase", k[1]), m.agentRuns[k])
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, b.String())
+}
+
+// labels renders name/value pairs as a Prometheus label set
+func labels(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		parts = append(parts, pairs[i]+`="`+v+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
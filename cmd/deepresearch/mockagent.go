@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mockAgentCommand is the name the deepresearch binary answers to as the mock
+// agent; `deepresearch golden` links the binary under this name onto PATH
+const mockAgentCommand = "deepresearch-mock-agent"
+
+// mockFixtureEnv points the mock agent at the recorded fixture it replays
+const mockFixtureEnv = "DEEPRESEARCH_MOCK_FIXTURE"
+
+// mockExitFile in a fixture step makes the mock agent exit with the code it holds
+// after copying the step's files, to exercise agent failures
+const mockExitFile = ".exit"
+
+// mockOutputFile in a fixture step holds output the mock agent prints, e.g. the
+// token usage a live agent reports
+const mockOutputFile = ".output"
+
+// mockStderrFile in a fixture step holds what the mock agent writes to stderr,
+// e.g. the error of a failing agent
+const mockStderrFile = ".stderr"
+
+// mockStateDir keeps the mock agent's per-phase call counts in the workspace
+const mockStateDir = ".mock-agent"
+
+func init() {
+	// The mock agent stands in for a live agent CLI in golden runs. It is only
+	// available where mockAgentCommand is on PATH, so detectAgent never picks it.
+	args := func(prompt, model, workDir string) []string {
+		return []string{"-p", prompt}
+	}
+	agentConfigs["mock"] = AgentConfig{
+		Command:         mockAgentCommand,
+		Args:            args,
+		InteractiveArgs: args,
+		Usage:           true,
+	}
+}
+
+// isMockAgent reports whether this process was started as the mock agent
+func isMockAgent() bool {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return name == mockAgentCommand
+}
+
+var (
+	promptGuideRe   = regexp.MustCompile(`FIRST: Read (.+?)\.md and follow`)
+	executorTaskRe  = regexp.MustCompile(`(?m)^TASK: ([A-Z]+\d+) - `)
+	sectionedStepRe = regexp.MustCompile(`SYNTHESIS_MODE: SECTIONED \((outline step|section \d+)`)
+	layoutLineRe    = regexp.MustCompile(`(?m)^- (task\.md|report\.md|assets/), use (.+)$`)
+	assetsRefRe     = regexp.MustCompile(`\bassets/`)
+)
+
+// mockPhase names the step a prompt asks for after its guide: planner,
+// research-supervisor, executor-<TASK_ID>, reflector, synthesizer,
+// synthesizer-outline, synthesizer-section-N, salvage or translator
+func mockPhase(prompt string) string {
+	m := promptGuideRe.FindStringSubmatch(prompt)
+	if m == nil {
+		if strings.Contains(prompt, "TASK: Translate") {
+			return "translator"
+		}
+		return "agent"
+	}
+	phase := filepath.Base(m[1])
+	if t := executorTaskRe.FindStringSubmatch(prompt); t != nil && phase == "executor" {
+		return phase + "-" + t[1]
+	}
+	if s := sectionedStepRe.FindStringSubmatch(prompt); s != nil {
+		if s[1] == "outline step" {
+			return phase + "-outline"
+		}
+		return phase + "-" + strings.ReplaceAll(s[1], " ", "-")
+	}
+	if strings.Contains(prompt, "SYNTHESIS_MODE: PARTIAL") {
+		return "salvage"
+	}
+	return phase
+}
+
+// runMockAgent plays back a recorded agent: for the N-th call of a phase it
+// copies <fixture>/<phase>-N/ (or <fixture>/<phase>/ when no numbered step
+// exists) over the workspace in the current directory
+func runMockAgent(args []string) {
+	fs := flag.NewFlagSet(mockAgentCommand, flag.ExitOnError)
+	prompt := fs.String("p", "", "Prompt")
+	fs.Parse(args)
+
+	fixture := os.Getenv(mockFixtureEnv)
+	if fixture == "" {
+		fmt.Fprintf(os.Stderr, "%s: %s is not set\n", mockAgentCommand, mockFixtureEnv)
+		os.Exit(2)
+	}
+	phase := mockPhase(*prompt)
+	call, err := countMockCall(phase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", mockAgentCommand, err)
+		os.Exit(2)
+	}
+
+	step := filepath.Join(fixture, fmt.Sprintf("%s-%d", phase, call))
+	if !fileExists(step) {
+		step = filepath.Join(fixture, phase)
+	}
+	if !fileExists(step) {
+		fmt.Fprintf(os.Stderr, "%s: no fixture for %s call %d (expected %s-%d/ or %s/ in %s)\n", mockAgentCommand, phase, call, phase, call, phase, fixture)
+		os.Exit(1)
+	}
+	n, err := copyFixture(step, ".", mockLayout(*prompt))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", mockAgentCommand, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: %s call %d: wrote %d file(s) from %s\n", mockAgentCommand, phase, call, n, filepath.Base(step))
+	if data, err := os.ReadFile(filepath.Join(step, mockOutputFile)); err == nil {
+		os.Stdout.Write(data)
+	}
+	if data, err := os.ReadFile(filepath.Join(step, mockStderrFile)); err == nil {
+		os.Stderr.Write(data)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(step, mockExitFile)); err == nil {
+		code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: invalid %s in %s\n", mockAgentCommand, mockExitFile, step)
+			code = 1
+		}
+		os.Exit(code)
+	}
+}
+
+// countMockCall records another call of phase and returns its 1-based number
+func countMockCall(phase string) (int, error) {
+	if err := os.MkdirAll(mockStateDir, 0755); err != nil {
+		return 0, err
+	}
+	path := filepath.Join(mockStateDir, phase)
+	call := 1
+	if data, err := os.ReadFile(path); err == nil {
+		n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+		call = n + 1
+	}
+	return call, os.WriteFile(path, []byte(strconv.Itoa(call)), 0644)
+}
+
+// mockLayout maps task.md, report.md and assets/ to the paths the LAYOUT
+// note of prompt gives them, as a live agent follows it
+func mockLayout(prompt string) map[string]string {
+	layout := map[string]string{}
+	for _, m := range layoutLineRe.FindAllStringSubmatch(prompt, -1) {
+		layout[m[1]] = strings.TrimSpace(m[2])
+	}
+	return layout
+}
+
+// copyFixture copies the files of a fixture step into dst, replacing
+// {{WORKING_DIR}} in text files with the absolute workspace path. Fixtures are
+// recorded in the default layout; their files and references to assets/ are
+// moved to the paths layout gives them.
+func copyFixture(step, dst string, layout map[string]string) (int, error) {
+	workDir, err := filepath.Abs(dst)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = filepath.WalkDir(step, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(step, path)
+		if rel == mockExitFile || rel == mockOutputFile || rel == mockStderrFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if textAssetExts[strings.ToLower(filepath.Ext(path))] {
+			text := strings.ReplaceAll(string(data), "{{WORKING_DIR}}", workDir)
+			if assets, ok := layout["assets/"]; ok {
+				text = assetsRefRe.ReplaceAllLiteralString(text, assets)
+			}
+			data = []byte(text)
+		}
+		slashRel := filepath.ToSlash(rel)
+		switch assets, ok := layout["assets/"]; {
+		case layout[slashRel] != "":
+			slashRel = layout[slashRel]
+		case ok && strings.HasPrefix(slashRel, "assets/"):
+			slashRel = assets + strings.TrimPrefix(slashRel, "assets/")
+		}
+		target := filepath.Join(dst, filepath.FromSlash(slashRel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		n++
+		return os.WriteFile(target, data, 0644)
+	})
+	return n, err
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default endpoints of the news providers
+const (
+	gdeltAPI   = "https://api.gdeltproject.org/api/v2/doc/doc"
+	newsAPIURL = "https://newsapi.org/v2/everything"
+)
+
+func init() {
+	registerConnector(&connector{
+		Name:    "news",
+		Summary: "Dated news articles with outlet metadata (GDELT by default, or a NewsAPI-compatible provider)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 20] [-days 30] [-lang en] — recent articles, saved to assets/web`,
+		},
+		Run: runNews,
+	})
+}
+
+// newsArticle is a provider-independent news search result
+type newsArticle struct {
+	Title     string
+	URL       string
+	Outlet    string
+	Published time.Time
+	Language  string
+	Country   string
+	Summary   string
+}
+
+// newsProviders maps a provider name to its search function; each returns the
+// articles and the raw response
+var newsProviders = map[string]func(t *toolContext, query string, limit, days int, lang string) ([]newsArticle, []byte, error){
+	"gdelt":   searchGDELT,
+	"newsapi": searchNewsAPI,
+}
+
+// gdeltLanguages maps ISO 639-1 codes to GDELT's sourcelang names
+var gdeltLanguages = map[string]string{
+	"en": "english", "de": "german", "fr": "french", "es": "spanish", "pt": "portuguese",
+	"it": "italian", "nl": "dutch", "ru": "russian", "zh": "chinese", "ja": "japanese",
+	"ko": "korean", "ar": "arabic", "hi": "hindi",
+}
+
+func searchGDELT(t *toolContext, query string, limit, days int, lang string) ([]newsArticle, []byte, error) {
+	if name, ok := gdeltLanguages[lang]; ok {
+		query += " sourcelang:" + name
+	}
+	params := url.Values{
+		"query":      {query},
+		"mode":       {"ArtList"},
+		"format":     {"json"},
+		"sort":       {"DateDesc"},
+		"maxrecords": {fmt.Sprint(min(limit, 250))},
+		"timespan":   {fmt.Sprintf("%dd", days)},
+	}
+	var resp struct {
+		Articles []struct {
+			URL      string `json:"url"`
+			Title    string `json:"title"`
+			SeenDate string `json:"seendate"`
+			Domain   string `json:"domain"`
+			Language string `json:"language"`
+			Country  string `json:"sourcecountry"`
+		} `json:"articles"`
+	}
+	raw, err := t.getJSON(t.endpoint(gdeltAPI)+"?"+params.Encode(), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// GDELT answers errors, including its rate limit, with a plain-text 200
+	if !json.Valid(raw) {
+		msg, _, _ := strings.Cut(strings.TrimSpace(string(raw)), "\n")
+		return nil, nil, fmt.Errorf("GDELT: %s", msg)
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, nil, err
+	}
+	var articles []newsArticle
+	for _, a := range resp.Articles {
+		published, _ := time.Parse("20060102T150405Z", a.SeenDate)
+		articles = append(articles, newsArticle{
+			Title: a.Title, URL: a.URL, Outlet: a.Domain, Published: published,
+			Language: a.Language, Country: a.Country,
+		})
+	}
+	return articles, raw, nil
+}
+
+func searchNewsAPI(t *toolContext, query string, limit, days int, lang string) ([]newsArticle, []byte, error) {
+	key := t.apiKey("NEWSAPI_KEY")
+	if key == "" {
+		return nil, nil, fmt.Errorf("newsapi provider requires an API key (connectors.news.api_key_env, default $NEWSAPI_KEY)")
+	}
+	params := url.Values{
+		"q":        {query},
+		"pageSize": {fmt.Sprint(min(limit, 100))},
+		"sortBy":   {"publishedAt"},
+		"from":     {time.Now().AddDate(0, 0, -days).Format("2006-01-02")},
+	}
+	if lang != "" {
+		params.Set("language", lang)
+	}
+	var resp struct {
+		Articles []struct {
+			Source struct {
+				Name string `json:"name"`
+			} `json:"source"`
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			PublishedAt string `json:"publishedAt"`
+			Description string `json:"description"`
+			Content     string `json:"content"`
+		} `json:"articles"`
+	}
+	raw, err := t.getJSON(t.endpoint(newsAPIURL)+"?"+params.Encode(), map[string]string{"X-Api-Key": key}, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	var articles []newsArticle
+	for _, a := range resp.Articles {
+		published, _ := time.Parse(time.RFC3339, a.PublishedAt)
+		summary := a.Description
+		if a.Content != "" {
+			summary += "\n\n" + a.Content
+		}
+		articles = append(articles, newsArticle{
+			Title: a.Title, URL: a.URL, Outlet: a.Source.Name, Published: published, Language: lang, Summary: summary,
+		})
+	}
+	return articles, raw, nil
+}
+
+func runNews(t *toolContext, action string, args []string) error {
+	fs, q, n := searchFlagSet(action, 20)
+	days := fs.Int("days", 30, "How many days back to search")
+	lang := fs.String("lang", "", "Source language (ISO 639-1)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query, err := queryArg(fs, *q)
+	if err != nil {
+		return err
+	}
+	provider := t.Config.Provider
+	if provider == "" {
+		provider = "gdelt"
+	}
+	search, ok := newsProviders[provider]
+	if !ok {
+		return fmt.Errorf("unknown news provider %q (expected gdelt or newsapi)", provider)
+	}
+	articles, raw, err := search(t, query, *n, *days, *lang)
+	if err != nil {
+		return err
+	}
+	rawPath, err := t.saveAsset("data", "news_"+provider+"_"+query, "json", raw)
+	if err != nil {
+		return err
+	}
+
+	t.printf("%d article(s) from %s. Raw results: %s\n\n", len(articles), provider, rawPath)
+	t.printf("| Published | Outlet | Title | URL | Archived |\n|---|---|---|---|---|\n")
+	for _, a := range articles {
+		date := ""
+		if !a.Published.IsZero() {
+			date = a.Published.Format("2006-01-02")
+		}
+		body := "# " + a.Title + "\n\n" + a.Summary
+		if a.Summary == "" {
+			body += "(Headline only — fetch the URL for the full article.)"
+		}
+		path, err := t.saveDocument("web", a.Outlet+"_"+a.Title, [][2]string{
+			{"title", a.Title},
+			{"url", a.URL},
+			{"outlet", a.Outlet},
+			{"published", date},
+			{"language", a.Language},
+			{"country", a.Country},
+			{"provider", provider},
+		}, body)
+		if err != nil {
+			return err
+		}
+		t.printf("| %s | %s | %s | %s | %s |\n", date, mdCell(a.Outlet), mdCell(a.Title), a.URL, path)
+	}
+	if len(articles) > 0 {
+		t.printf("\nRegister each article you rely on as its own source (outlet, publication date and URL).\n")
+	}
+	return nil
+}
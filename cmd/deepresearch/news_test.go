@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewsGDELT(t *testing.T) {
+	srv := recordedAPI(t, "news", map[string]string{"/": "gdelt.json"})
+	dir, out, err := runRecordedTool(t, "news", connectorConfig{Endpoint: srv.URL}, "search", "-q", "heat pumps", "-days", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 article(s) from gdelt.",
+		"| Published | Outlet | Title | URL | Archived |",
+		`| 2025-02-20 | reuters.com | Heat pump sales rebound in Germany \| subsidies | https://www.reuters.com/business/energy/heat-pump-sales-2025-02-20/ | assets/web/`,
+		"| 2025-02-18 | nrk.no | Varmepumper i norske hjem |",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "news-gdelt-heat-pumps") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+	web := assetFiles(t, dir, "web")
+	if len(web) != 2 {
+		t.Fatalf("archived %v, want one document per article", web)
+	}
+	doc, err := os.ReadFile(assetsPath(dir, "web", web[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, string(doc), `published: "2025-02-18"`, `outlet: "nrk.no"`, "(Headline only")
+}
+
+func TestNewsAPI(t *testing.T) {
+	srv := recordedAPI(t, "news", map[string]string{"/": "newsapi.json"})
+	settings := connectorConfig{Provider: "newsapi", Endpoint: srv.URL, APIKeyEnv: "TEST_NEWSAPI_KEY"}
+	if _, _, err := runRecordedTool(t, "news", settings, "search", "-q", "heat pumps"); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("newsapi without a key: error %v, want one asking for the key", err)
+	}
+	t.Setenv("TEST_NEWSAPI_KEY", "test-key")
+	dir, out, err := runRecordedTool(t, "news", settings, "search", "-q", "heat pumps", "-lang", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "1 article(s) from newsapi.", "| 2025-02-19 | BBC News | Why Norway loves heat pumps | https://www.bbc.co.uk/news/science-environment-64891234 |")
+	web := assetFiles(t, dir, "web")
+	if len(web) != 1 {
+		t.Fatalf("archived %v, want the article", web)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "web", web[0]))
+	checkContains(t, string(doc), "Two thirds of Norwegian homes", "highest share of heat pumps")
+}
+
+func TestNewsNoResults(t *testing.T) {
+	srv := recordedAPI(t, "news", map[string]string{"/": "gdelt_empty.json"})
+	dir, out, err := runRecordedTool(t, "news", connectorConfig{Endpoint: srv.URL}, "search", "-q", "no such story")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "0 article(s) from gdelt.")
+	if web := assetFiles(t, dir, "web"); len(web) != 0 {
+		t.Errorf("archived %v without results", web)
+	}
+}
+
+func TestNewsErrors(t *testing.T) {
+	checkHTTPErrors(t, "news", connectorConfig{}, "search", "-q", "heat pumps")
+	srv := recordedAPI(t, "news", map[string]string{"/": "gdelt_rate_limited.txt"})
+	if _, _, err := runRecordedTool(t, "news", connectorConfig{Endpoint: srv.URL}, "search", "-q", "heat pumps"); err == nil || !strings.Contains(err.Error(), "GDELT: Please limit requests") {
+		t.Errorf("GDELT rate limit: error %v, want GDELT's message", err)
+	}
+	if _, _, err := runRecordedTool(t, "news", connectorConfig{Provider: "bing"}, "search", "-q", "heat pumps"); err == nil {
+		t.Error("an unknown provider succeeded")
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notifyConfig sends the progress and outcome of a run to a webhook, Slack or
+// the desktop, for runs that finish while no one is watching
+type notifyConfig struct {
+	Webhook string `yaml:"webhook"` // same as --notify-webhook: URL every event is POSTed to as JSON
+	// Headers are added to the webhook requests, e.g. Authorization; values
+	// may reference environment variables as $NAME
+	Headers map[string]string `yaml:"headers"`
+	// Events limits the webhook to these events (default: all of phase_started,
+	// phase_completed, plan_ready, report_ready and run_failed)
+	Events         []string `yaml:"events"`
+	Slack          string   `yaml:"slack_webhook"` // same as --notify-slack: Slack incoming webhook URL for the end of a run
+	Desktop        bool     `yaml:"desktop"`       // same as --notify-desktop: desktop notification at the end of a run
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
+
+// Notification events
+const (
+	notifyPhaseStarted   = "phase_started"
+	notifyPhaseCompleted = "phase_completed" // also when the phase failed; see status
+	notifyPlanReady      = "plan_ready"      // a --plan-only run wrote task.md
+	notifyReportReady    = "report_ready"    // the run completed, or ended with a partial report
+	notifyRunFailed      = "run_failed"      // the run failed or was interrupted without a report
+)
+
+// notifyEvents are all notification events
+var notifyEvents = []string{notifyPhaseStarted, notifyPhaseCompleted, notifyPlanReady, notifyReportReady, notifyRunFailed}
+
+// notifyFlushTimeout bounds how long the end of a run waits for notifications still queued
+const notifyFlushTimeout = 15 * time.Second
+
+// notification is the JSON body POSTed to the webhook
+type notification struct {
+	Schema     int    `json:"schema"`
+	Event      string `json:"event"`
+	Time       string `json:"ts"`
+	RunID      string `json:"run_id,omitempty"`
+	Topic      string `json:"topic,omitempty"`
+	WorkDir    string `json:"work_dir,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	Iteration  int    `json:"iteration,omitempty"`
+	Status     string `json:"status,omitempty"`      // of a completed phase: completed or failed
+	DurationMs int64  `json:"duration_ms,omitempty"` // of a completed phase, or of the run
+	Outcome    string `json:"outcome,omitempty"`     // of the run, on plan_ready, report_ready and run_failed
+	Plan       string `json:"plan,omitempty"`        // path of task.md, on plan_ready
+	Report     string `json:"report,omitempty"`      // path of report.md, on report_ready
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"`
+}
+
+// notifier delivers notifications in order on its own goroutine, so a slow
+// endpoint never holds up the run
+type notifier struct {
+	cfg     notifyConfig
+	events  map[string]bool // sent to the webhook
+	client  *http.Client
+	queue   chan notification
+	pending sync.WaitGroup
+	warned  map[string]bool // channels that failed before; later failures only go to the log
+}
+
+// The notifier of the run, nil when no notification is configured
+var notifications *notifier
+
+// validateNotifyConfig checks the notification settings
+func validateNotifyConfig(cfg notifyConfig) error {
+	for _, u := range []string{cfg.Webhook, cfg.Slack} {
+		if u == "" {
+			continue
+		}
+		if p, err := url.Parse(os.ExpandEnv(u)); err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+			return fmt.Errorf("%s is not an http(s) URL", u)
+		}
+	}
+	for _, e := range cfg.Events {
+		if !slices.Contains(notifyEvents, e) {
+			return fmt.Errorf("unknown event %q (expected %s)", e, strings.Join(notifyEvents, ", "))
+		}
+	}
+	if cfg.TimeoutSeconds < 1 {
+		return fmt.Errorf("timeout_seconds must be 1 or more")
+	}
+	return nil
+}
+
+// startNotifications starts delivering notifications if any are configured
+func startNotifications(cfg notifyConfig) {
+	if cfg.Webhook == "" && cfg.Slack == "" && !cfg.Desktop {
+		return
+	}
+	events := cfg.Events
+	if len(events) == 0 {
+		events = notifyEvents
+	}
+	n := &notifier{
+		cfg:    cfg,
+		events: map[string]bool{},
+		client: &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+		queue:  make(chan notification, 64),
+		warned: map[string]bool{},
+	}
+	for _, e := range events {
+		n.events[e] = true
+	}
+	notifications = n
+	go n.loop()
+}
+
+// notify queues an event, filling in the run it belongs to
+func notify(ev notification) {
+	n := notifications
+	if n == nil {
+		return
+	}
+	ev.Schema = 1
+	ev.Time = time.Now().Format(time.RFC3339)
+	if manifest != nil {
+		ev.RunID, ev.Topic, ev.WorkDir = manifest.RunID, manifest.Topic, manifest.WorkDir
+	}
+	n.pending.Add(1)
+	n.queue <- ev
+}
+
+// notifyPhase queues the start or the end of a phase
+func notifyPhase(p phaseRecord) {
+	ev := notification{Event: notifyPhaseStarted, Phase: p.Name, Iteration: p.Iteration}
+	if p.FinishedAt != nil {
+		ev.Event, ev.Status, ev.DurationMs, ev.Error = notifyPhaseCompleted, p.Status, p.DurationMs, p.Error
+	}
+	notify(ev)
+}
+
+// notifyRunEnd sends the outcome of the run, plan_ready or report_ready if it
+// left a plan to review or a report, and waits for the notifications still queued
+func notifyRunEnd(outcome, code, msg string) {
+	if notifications == nil || manifest == nil {
+		return
+	}
+	ev := notification{Event: notifyRunFailed, Outcome: outcome, Error: msg, Code: code}
+	if n := len(manifest.Phases); n > 0 && code != "" {
+		ev.Phase = manifest.Phases[n-1].Name
+	}
+	if manifest.FinishedAt != nil {
+		ev.DurationMs = manifest.FinishedAt.Sub(manifest.StartedAt).Milliseconds()
+	}
+	if report := reportFilePath(manifest.WorkDir); (outcome == outcomeCompleted || outcome == outcomePartial) && fileExists(report) {
+		ev.Event, ev.Report = notifyReportReady, report
+	}
+	if outcome == outcomePlanned {
+		ev.Event, ev.Plan = notifyPlanReady, taskFilePath(manifest.WorkDir)
+	}
+	notify(ev)
+	flushNotifications(notifyFlushTimeout)
+}
+
+// flushNotifications waits up to timeout for the queued notifications to be delivered
+func flushNotifications(timeout time.Duration) {
+	n := notifications
+	if n == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logEntry("WARN", "NOTIFY", 0, "Notifications still undelivered at exit", nil)
+	}
+}
+
+func (n *notifier) loop() {
+	for ev := range n.queue {
+		if n.events[ev.Event] && n.cfg.Webhook != "" {
+			n.deliver("webhook", func() error { return n.postWebhook(ev) })
+		}
+		if ev.Event != notifyPhaseStarted && ev.Event != notifyPhaseCompleted {
+			if n.cfg.Slack != "" {
+				n.deliver("slack", func() error { return n.postSlack(ev) })
+			}
+			if n.cfg.Desktop {
+				n.deliver("desktop", func() error { return desktopNotify("deepresearch", notificationText(ev)) })
+			}
+		}
+		n.pending.Done()
+	}
+}
+
+// deliver sends one notification over a channel; the first failure of a
+// channel is also shown on the console, none of them affects the run
+func (n *notifier) deliver(channel string, send func() error) {
+	err := send()
+	if err == nil {
+		return
+	}
+	if !n.warned[channel] {
+		n.warned[channel] = true
+		warn("Could not send %s notification: %v", channel, err)
+		return
+	}
+	logEntry("WARN", "NOTIFY", 0, "Could not send notification", map[string]string{
+		"channel": channel,
+		"error":   err.Error(),
+	})
+}
+
+// post POSTs a JSON body, treating any status but 2xx as a failure
+func (n *notifier) post(endpoint string, body any, headers map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, os.ExpandEnv(endpoint), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "deepresearch/"+version)
+	for k, v := range headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func (n *notifier) postWebhook(ev notification) error {
+	return n.post(n.cfg.Webhook, ev, n.cfg.Headers)
+}
+
+func (n *notifier) postSlack(ev notification) error {
+	return n.post(n.cfg.Slack, map[string]string{"text": notificationText(ev)}, nil)
+}
+
+// notificationText is the human-readable form of the outcome of a run, for
+// Slack and the desktop
+func notificationText(ev notification) string {
+	topic := ev.Topic
+	if topic == "" || topic == redactedTopic {
+		topic = filepath.Base(ev.WorkDir)
+	}
+	topic = truncateRunes(strings.Join(strings.Fields(topic), " "), 120)
+	switch {
+	case ev.Event == notifyPlanReady:
+		return fmt.Sprintf("Research plan ready for review: %s\n%s", topic, ev.Plan)
+	case ev.Event == notifyReportReady && ev.Outcome == outcomePartial:
+		return fmt.Sprintf("Partial report ready: %s (%s)\n%s", topic, ev.Error, ev.Report)
+	case ev.Event == notifyReportReady:
+		return fmt.Sprintf("Research complete: %s\n%s", topic, ev.Report)
+	}
+	if ev.Outcome == outcomeInterrupted {
+		return fmt.Sprintf("Research interrupted: %s\nContinue it with --resume in %s", topic, ev.WorkDir)
+	}
+	where := ""
+	if ev.Phase != "" {
+		where = " in " + ev.Phase
+	}
+	return fmt.Sprintf("Research failed%s: %s\n[%s] %s", where, topic, ev.Code, ev.Error)
+}
+
+// desktopNotify shows a notification with the platform's own tools
+func desktopNotify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		// A balloon tip of a tray icon, which needs no module beyond .NET
+		script := `Add-Type -AssemblyName System.Windows.Forms; $n = New-Object System.Windows.Forms.NotifyIcon; ` +
+			`$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; ` +
+			`$n.ShowBalloonTip(10000, $env:DR_NOTIFY_TITLE, $env:DR_NOTIFY_BODY, 'Info'); Start-Sleep -Seconds 10; $n.Dispose()`
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(os.Environ(), "DR_NOTIFY_TITLE="+title, "DR_NOTIFY_BODY="+body)
+		// The balloon stays up while PowerShell runs; the run does not wait for it
+		return cmd.Start()
+	default:
+		cmd = exec.Command("notify-send", "--app-name=deepresearch", title, body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// reportViewPath returns the best file to show the user: the rendered HTML
+// report if one was exported, otherwise report.md
+func reportViewPath(workDir string) string {
+	if html := filepath.Join(workDir, filepath.FromSlash(exportedReportName(workDir, formatHTML))); fileExists(html) {
+		return html
+	}
+	return reportFilePath(workDir)
+}
+
+// openInViewer opens a file with the platform's default application
+func openInViewer(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	case "darwin":
+		cmd = exec.Command("open", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// openAlexAPI is the default OpenAlex API base URL
+const openAlexAPI = "https://api.openalex.org"
+
+func init() {
+	registerConnector(&connector{
+		Name:    "openalex",
+		Summary: "OpenAlex scholarly search: papers, authors, venues and the citation graph (free, no key)",
+		Actions: map[string]string{
+			"works":   `-q "query" [-n 10] [-from 2020] [-oa] — search papers`,
+			"work":    `<W123|doi> — one paper with abstract`,
+			"authors": `-q "name" [-n 10] — search authors`,
+			"venues":  `-q "name" [-n 10] — search journals/conferences`,
+			"cites":   `<W123> [-n 25] — papers citing a work`,
+			"refs":    `<W123> [-n 25] — papers a work references`,
+		},
+		Run: runOpenAlex,
+	})
+}
+
+// openAlexWork is the subset of an OpenAlex work record that is reported
+type openAlexWork struct {
+	ID              string `json:"id"`
+	DOI             string `json:"doi"`
+	Title           string `json:"display_name"`
+	PublicationYear int    `json:"publication_year"`
+	CitedByCount    int    `json:"cited_by_count"`
+	PrimaryLocation struct {
+		Source struct {
+			Name string `json:"display_name"`
+		} `json:"source"`
+	} `json:"primary_location"`
+	OpenAccess struct {
+		IsOA  bool   `json:"is_oa"`
+		OAURL string `json:"oa_url"`
+	} `json:"open_access"`
+	Authorships []struct {
+		Author struct {
+			Name string `json:"display_name"`
+		} `json:"author"`
+	} `json:"authorships"`
+	ReferencedWorks       []string         `json:"referenced_works"`
+	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+}
+
+// shortID strips the OpenAlex URL prefix from an ID
+func shortID(id string) string {
+	return strings.TrimPrefix(id, "https://openalex.org/")
+}
+
+// abstract rebuilds the plain-text abstract from OpenAlex's inverted index
+func (w openAlexWork) abstract() string {
+	n := 0
+	for _, positions := range w.AbstractInvertedIndex {
+		for _, p := range positions {
+			n = max(n, p+1)
+		}
+	}
+	words := make([]string, n)
+	for word, positions := range w.AbstractInvertedIndex {
+		for _, p := range positions {
+			words[p] = word
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+}
+
+func (w openAlexWork) authors(limit int) string {
+	var names []string
+	for i, a := range w.Authorships {
+		if i == limit {
+			names = append(names, "et al.")
+			break
+		}
+		names = append(names, a.Author.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func runOpenAlex(t *toolContext, action string, args []string) error {
+	base := t.endpoint(openAlexAPI)
+	params := url.Values{}
+	if mailto := config.Crossref.Mailto; mailto != "" {
+		params.Set("mailto", mailto) // OpenAlex polite pool
+	}
+
+	switch action {
+	case "works", "authors", "venues":
+		fs, q, n := searchFlagSet(action, 10)
+		from := fs.Int("from", 0, "Only works published in or after this year")
+		oa := fs.Bool("oa", false, "Only open-access works")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		params.Set("search", query)
+		params.Set("per-page", fmt.Sprint(min(*n, 200)))
+		var filters []string
+		if *from > 0 && action == "works" {
+			filters = append(filters, fmt.Sprintf("from_publication_date:%d-01-01", *from))
+		}
+		if *oa && action == "works" {
+			filters = append(filters, "is_oa:true")
+		}
+		if len(filters) > 0 {
+			params.Set("filter", strings.Join(filters, ","))
+		}
+		endpoint := map[string]string{"works": "works", "authors": "authors", "venues": "sources"}[action]
+		return openAlexList(t, base+"/"+endpoint+"?"+params.Encode(), action, "openalex_"+action+"_"+query)
+
+	case "work":
+		if len(args) == 0 {
+			return fmt.Errorf("a work ID or DOI is required")
+		}
+		id := args[0]
+		if doi := extractDOI(id); doi != "" {
+			id = "doi:" + doi
+		}
+		var w openAlexWork
+		raw, err := t.getJSON(base+"/works/"+url.PathEscape(id)+"?"+params.Encode(), nil, &w)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "openalex_work_"+shortID(w.ID), "json", raw)
+		if err != nil {
+			return err
+		}
+		t.printf("## %s\n\n", w.Title)
+		t.printf("- OpenAlex: %s\n- DOI: %s\n- Year: %d\n- Venue: %s\n- Authors: %s\n- Cited by: %d\n- References: %d\n",
+			shortID(w.ID), w.DOI, w.PublicationYear, w.PrimaryLocation.Source.Name, w.authors(10), w.CitedByCount, len(w.ReferencedWorks))
+		if w.OpenAccess.OAURL != "" {
+			t.printf("- Open access: %s\n", w.OpenAccess.OAURL)
+		}
+		if abs := w.abstract(); abs != "" {
+			t.printf("\n%s\n", abs)
+		}
+		t.printf("\nArchived: %s\n", path)
+		return nil
+
+	case "cites", "refs":
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		n := fs.Int("n", 25, "Maximum number of results")
+		if err := fs.Parse(reorderArgs(args)); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return fmt.Errorf("a work ID is required")
+		}
+		id := shortID(fs.Arg(0))
+		if action == "cites" {
+			params.Set("filter", "cites:"+id)
+			params.Set("sort", "cited_by_count:desc")
+		} else {
+			var w openAlexWork
+			if _, err := t.getJSON(base+"/works/"+url.PathEscape(id)+"?select=id,referenced_works", nil, &w); err != nil {
+				return err
+			}
+			if len(w.ReferencedWorks) == 0 {
+				t.printf("%s has no recorded references.\n", id)
+				return nil
+			}
+			var ids []string
+			for i, r := range w.ReferencedWorks {
+				if i == 100 {
+					break
+				}
+				ids = append(ids, shortID(r))
+			}
+			params.Set("filter", "openalex_id:"+strings.Join(ids, "|"))
+			params.Set("sort", "cited_by_count:desc")
+		}
+		params.Set("per-page", fmt.Sprint(min(*n, 200)))
+		return openAlexList(t, base+"/works?"+params.Encode(), "works", "openalex_"+action+"_"+id)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// openAlexList runs a list query, archives the response and prints a result table
+func openAlexList(t *toolContext, u, kind, assetName string) error {
+	var resp struct {
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+		Results []struct {
+			openAlexWork
+			WorksCount       int    `json:"works_count"`
+			HostOrg          string `json:"host_organization_name"`
+			LastInstitutions []struct {
+				Name string `json:"display_name"`
+			} `json:"last_known_institutions"`
+		} `json:"results"`
+	}
+	raw, err := t.getJSON(u, nil, &resp)
+	if err != nil {
+		return err
+	}
+	path, err := t.saveAsset("data", assetName, "json", raw)
+	if err != nil {
+		return err
+	}
+	t.printf("%d match(es), showing %d. Archived: %s\n\n", resp.Meta.Count, len(resp.Results), path)
+	switch kind {
+	case "works":
+		t.printf("| OpenAlex | Year | Title | Authors | Venue | Cited by | DOI | Open access |\n|---|---|---|---|---|---|---|---|\n")
+		for _, r := range resp.Results {
+			t.printf("| %s | %d | %s | %s | %s | %d | %s | %s |\n", shortID(r.ID), r.PublicationYear, mdCell(r.Title),
+				mdCell(r.authors(3)), mdCell(r.PrimaryLocation.Source.Name), r.CitedByCount, r.DOI, r.OpenAccess.OAURL)
+		}
+	case "authors":
+		t.printf("| OpenAlex | Name | Institution | Works | Cited by |\n|---|---|---|---|---|\n")
+		for _, r := range resp.Results {
+			inst := ""
+			if len(r.LastInstitutions) > 0 {
+				inst = r.LastInstitutions[0].Name
+			}
+			t.printf("| %s | %s | %s | %d | %d |\n", shortID(r.ID), mdCell(r.Title), mdCell(inst), r.WorksCount, r.CitedByCount)
+		}
+	case "venues":
+		t.printf("| OpenAlex | Venue | Publisher | Works | Cited by |\n|---|---|---|---|---|\n")
+		for _, r := range resp.Results {
+			t.printf("| %s | %s | %s | %d | %d |\n", shortID(r.ID), mdCell(r.Title), mdCell(r.HostOrg), r.WorksCount, r.CitedByCount)
+		}
+	}
+	return nil
+}
+
+// reorderArgs moves positional arguments after flags so "W123 -n 5" parses like "-n 5 W123"
+func reorderArgs(args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if strings.HasPrefix(a, "-") {
+			flags = append(flags, a)
+			if !strings.Contains(a, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				flags = append(flags, args[i+1])
+				i++
+			}
+			continue
+		}
+		positional = append(positional, a)
+	}
+	return append(flags, positional...)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpenAlexWorks(t *testing.T) {
+	srv := recordedAPI(t, "openalex", map[string]string{"/works": "works.json"})
+	dir, out, err := runRecordedTool(t, "openalex", connectorConfig{Endpoint: srv.URL}, "works", "-q", "retrieval augmented generation", "-n", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"1342 match(es), showing 2.",
+		"| OpenAlex | Year | Title | Authors | Venue | Cited by | DOI | Open access |",
+		"| W3027879771 | 2020 | Retrieval-Augmented Generation for Knowledge-Intensive NLP Tasks | Patrick Lewis, Ethan Perez, Aleksandra Piktus, et al. | arXiv (Cornell University) | 4211 | https://doi.org/10.48550/arxiv.2005.11401 | https://arxiv.org/pdf/2005.11401 |",
+		`| W4389520012 | 2023 | 检索增强生成 \| 综述 | 王芳 |  | 12 |  |  |`,
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "openalex-works-retrieval") {
+		t.Errorf("archived %v, want the works response", files)
+	}
+}
+
+func TestOpenAlexWork(t *testing.T) {
+	srv := recordedAPI(t, "openalex", map[string]string{"/works/doi:10.48550/arXiv.2005.11401": "work.json"})
+	dir, out, err := runRecordedTool(t, "openalex", connectorConfig{Endpoint: srv.URL}, "work", "https://doi.org/10.48550/arXiv.2005.11401")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"## Retrieval-Augmented Generation for Knowledge-Intensive NLP Tasks",
+		"- OpenAlex: W3027879771",
+		"- References: 2",
+		"Large pre-trained language models store factual knowledge.",
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "openalex-work-w3027879771") {
+		t.Errorf("archived %v, want the work record", files)
+	}
+}
+
+func TestOpenAlexNoResults(t *testing.T) {
+	srv := recordedAPI(t, "openalex", map[string]string{"/works": "works_empty.json"})
+	_, out, err := runRecordedTool(t, "openalex", connectorConfig{Endpoint: srv.URL}, "works", "-q", "no such topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "0 match(es), showing 0.")
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+}
+
+func TestOpenAlexErrors(t *testing.T) {
+	checkHTTPErrors(t, "openalex", connectorConfig{}, "works", "-q", "rag")
+	if _, _, err := runRecordedTool(t, "openalex", connectorConfig{}, "works"); err == nil {
+		t.Error("works without a query succeeded")
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// maxParallel is how many executor tasks the orchestrator runs at once; 0
+// leaves the research phase to the Research-Supervisor agent
+var maxParallel int
+
+// executorIDBlock is how many source and fact IDs each executor of a wave may use
+const executorIDBlock = 100
+
+// defaultRegistryHeader is the Source Registry layout the planner template uses,
+// for task.md files that lack one
+var defaultRegistryHeader = []string{"ID", "URL", "Title", "Type", "Date Accessed", "Local Path"}
+
+var (
+	sourceNumRe = regexp.MustCompile(`\bS(\d+)\b`)
+	factNumRe   = regexp.MustCompile(`\bFact-(\d+)\b`)
+)
+
+// readyTasks returns the tasks of task.md that can start, leaving out those
+// that already failed in this research phase
+func readyTasks(f *taskfile.File, failed map[string]bool) []taskfile.Task {
+	var ready []taskfile.Task
+	for _, t := range f.Ready() {
+		if !failed[t.ID] {
+			ready = append(ready, t)
+		}
+	}
+	return ready
+}
+
+// nextIDBlock returns the first source and fact numbers above every ID task.md
+// already uses, rounded up to a fresh executorIDBlock
+func nextIDBlock(content string) (source, fact int) {
+	highest := func(re *regexp.Regexp) int {
+		max := 0
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > max {
+				max = n
+			}
+		}
+		return (max/executorIDBlock+1)*executorIDBlock + 1
+	}
+	return highest(sourceNumRe), highest(factNumRe)
+}
+
+// buildExecutorPrompt builds the instructions of one executor task dispatched by
+// the orchestrator, reserving it a range of source and fact IDs
+func buildExecutorPrompt(promptsDir, workDir string, t taskfile.Task, source, fact int) string {
+	executorFile := filepath.Join(promptsDir, "executor.md")
+	last := executorIDBlock - 1
+	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+TASK: %s - %s
+
+ID_RANGE: Other executors run at the same time. Number your sources S%d-S%d (also in asset file
+names, e.g. assets/web/s%d_name.md) and your facts Fact-%d to Fact-%d so nothing collides.
+
+REMINDER:
+- Write structured results to logs/%s_result.md
+- Log actions to logs/%s.log
+- Do NOT edit task.md; the orchestrator merges your results into it
+`, executorFile, workDir, t.ID, t.Description, source, source+last, source, fact, fact+last, t.ID, t.ID) +
+		buildLayoutNote(workDir) + buildPreapprovedNote(workDir) + buildCachedSourcesNote(workDir) + buildToolsNote(workDir) + buildContextNote(workDir) + buildReplayNote()
+}
+
+// prefixWriter labels each line of an executor's output with its task ID
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+}
+
+func (pw prefixWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(pw.w, pw.prefix+string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// runExecutors is the research phase with --max-parallel: the orchestrator
+// dispatches the ready E* tasks of task.md as separate executor agents, at most
+// maxParallel at once, and merges their results into task.md. Tasks whose
+// dependencies complete run in the next wave. Failed tasks stay pending for the
+// next iteration; only a phase where every task failed is an error.
+func runExecutors(ctx context.Context, agentName, model, promptsDir, workDir string, iteration int) error {
+	taskFile := taskFilePath(workDir)
+	var broker taskBroker
+	if config.Workers.Broker != "" {
+		var err error
+		if broker, err = openTaskBroker(config.Workers); err != nil {
+			return fmt.Errorf("cannot connect to the workers broker: %w", err)
+		}
+		defer broker.Close()
+	}
+
+	failed := map[string]bool{}
+	var firstErr error
+	completed := 0
+	for wave := 1; ; wave++ {
+		data, err := os.ReadFile(taskFile)
+		if err != nil {
+			return err
+		}
+		ready := readyTasks(taskfile.Parse(data), failed)
+		if len(ready) == 0 {
+			break
+		}
+		var ids []string
+		for _, t := range ready {
+			ids = append(ids, t.ID)
+		}
+		info("Wave %d: dispatching %s (up to %d at once)", wave, strings.Join(ids, ", "), maxParallel)
+		logEntry("INFO", "BATCH_DISPATCH", iteration, "Dispatching executor wave", map[string]string{
+			"wave":         strconv.Itoa(wave),
+			"task_ids":     strings.Join(ids, ","),
+			"max_parallel": strconv.Itoa(maxParallel),
+		})
+
+		errs, durations := runWave(ctx, agentName, model, promptsDir, workDir, ready, string(data), broker)
+
+		// Merge in DAG order so task.md does not depend on which executor finished first
+		if data, err = os.ReadFile(taskFile); err != nil {
+			return err
+		}
+		content := string(data)
+		for i, t := range ready {
+			result, err := os.ReadFile(filepath.Join(workDir, "logs", t.ID+"_result.md"))
+			if errs[i] == nil && err != nil {
+				errs[i] = fmt.Errorf("executor did not write logs/%s_result.md", t.ID)
+			}
+			if errs[i] != nil {
+				failed[t.ID] = true
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+				fields := failureFields(errs[i])
+				fields["task_id"] = t.ID
+				fields["duration_ms"] = strconv.FormatInt(durations[i].Milliseconds(), 10)
+				logEntry("ERROR", "EXECUTOR_FAILED", iteration, "Executor failed", fields)
+				warn("%s failed: %v", t.ID, errs[i])
+				content = appendScratchpad(content, fmt.Sprintf("Iteration %d: %s failed: %v", iteration, t.ID, errs[i]))
+				continue
+			}
+			var facts, sources int
+			content, facts, sources = mergeExecutorResult(content, t, string(result))
+			completed++
+			logEntry("INFO", "EXECUTOR_DONE", iteration, "Executor completed", map[string]string{
+				"task_id":     t.ID,
+				"duration_ms": strconv.FormatInt(durations[i].Milliseconds(), 10),
+				"facts":       strconv.Itoa(facts),
+				"sources":     strconv.Itoa(sources),
+			})
+			note := fmt.Sprintf("Iteration %d: %s complete: %d fact(s), %d source(s)", iteration, t.ID, facts, sources)
+			if facts == 0 && sources == 0 {
+				warn("%s reported no facts or sources; check logs/%s_result.md", t.ID, t.ID)
+				note += fmt.Sprintf(" (see logs/%s_result.md)", t.ID)
+			} else {
+				success("%s completed: %d fact(s), %d source(s) (%s)", t.ID, facts, sources, formatDuration(durations[i]))
+			}
+			content = appendScratchpad(content, note)
+		}
+		if err := taskfile.WriteFile(taskFile, []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		if completed == 0 {
+			return fmt.Errorf("all %d executor task(s) failed, first: %w", len(failed), firstErr)
+		}
+		warn("%d research task(s) failed and stay pending: %s", len(failed), strings.Join(sortedKeys(failed), ", "))
+	}
+	return nil
+}
+
+// runWave runs the executors of one wave, at most maxParallel at once, and
+// returns each one's error and duration
+func runWave(ctx context.Context, agentName, model, promptsDir, workDir string, tasks []taskfile.Task, content string, broker taskBroker) ([]error, []time.Duration) {
+	errs := make([]error, len(tasks))
+	durations := make([]time.Duration, len(tasks))
+	source, fact := nextIDBlock(content)
+	var inputs []byte
+	if broker != nil {
+		// Inputs every executor may read; its outputs come back in the result
+		var err error
+		if inputs, _, err = packFiles(workDir, []string{taskFileName(workDir), contextDirName, manifestFileName}, time.Time{}); err != nil {
+			for i := range errs {
+				errs[i] = fmt.Errorf("cannot pack task inputs: %w", err)
+			}
+			return errs, durations
+		}
+	}
+
+	// Prompts go to tmp/ as with supervisor-dispatched executors
+	os.MkdirAll(filepath.Join(workDir, "tmp"), 0755)
+	cmds := make([]*exec.Cmd, len(tasks))
+	shownCmds := make([]string, len(tasks))
+	prompts := make([]string, len(tasks))
+	for i, t := range tasks {
+		prompts[i] = buildExecutorPrompt(promptsDir, workDir, t, source+i*executorIDBlock, fact+i*executorIDBlock)
+		os.WriteFile(filepath.Join(workDir, "tmp", t.ID+"_prompt.txt"), []byte(prompts[i]), 0644)
+		// A result left by an earlier failed attempt must not be merged
+		os.Remove(filepath.Join(workDir, "logs", t.ID+"_result.md"))
+		if broker != nil {
+			continue
+		}
+		cmd, shown, cleanup, err := agentCommand(agentConfigs[agentName], model, prompts[i], workDir)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		defer cleanup()
+		cmds[i], shownCmds[i] = cmd, shown
+		info("Executing %s: %s", t.ID, shown)
+	}
+
+	hb := startHeartbeat(fmt.Sprintf("%d executor(s)", len(tasks)))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		if errs[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			start := time.Now()
+			observed := observeAgentRun(agentName, model, t.ID)
+			if broker != nil {
+				var worker string
+				var n int
+				worker, n, errs[i] = dispatchRemote(ctx, broker, workDir, t.ID, prompts[i], inputs)
+				if errs[i] == nil {
+					fmt.Fprintf(hb.Writer(os.Stdout), "[%s] completed by %s (%d file(s))\n", t.ID, worker, n)
+				}
+			} else {
+				alog := openAgentLog(workDir, t.ID, shownCmds[i])
+				errs[i] = streamAgent(ctx, cmds[i], prefixWriter{"[" + t.ID + "] ", agentConsole(hb, os.Stdout)}, prefixWriter{"[" + t.ID + "] ", agentConsole(hb, os.Stderr)}, alog)
+				alog.Close()
+			}
+			durations[i] = time.Since(start)
+			observed(errs[i])
+		}()
+	}
+	wg.Wait()
+	hb.Stop()
+	return errs, durations
+}
+
+// mergeExecutorResult adds the Extracted Facts and Sources Archived of an
+// executor's result to task.md and marks the task complete. Sources whose URL is
+// already registered keep their registered ID. It returns the new content and
+// the number of facts and sources merged.
+func mergeExecutorResult(content string, t taskfile.Task, result string) (string, int, int) {
+	f := taskfile.Parse([]byte(content))
+	f.MarkDone(t.ID)
+	lines := strings.Split(string(f.Bytes()), "\n")
+
+	// The result's sources and facts
+	var header []string
+	var rows []map[string]string
+	var urls []string // of rows
+	var facts []string
+	section := ""
+	for _, line := range strings.Split(strings.ReplaceAll(result, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			section = strings.ToLower(m[1])
+			header = nil
+			continue
+		}
+		switch {
+		case strings.Contains(section, "sources"):
+			if !strings.HasPrefix(trimmed, "|") {
+				continue
+			}
+			cells := splitRow(trimmed)
+			if header == nil {
+				header = cells
+				continue
+			}
+			if !sourceIDRe.MatchString(cells[0]) {
+				continue
+			}
+			row := map[string]string{}
+			for i, h := range header {
+				if i < len(cells) {
+					row[strings.ToLower(h)] = cells[i]
+				}
+			}
+			rows = append(rows, row)
+			urls = append(urls, rowURL(header, cells))
+		case strings.Contains(section, "facts"):
+			if factLineRe.MatchString(line) {
+				facts = append(facts, "- "+strings.TrimLeft(trimmed, "-* "))
+			} else if len(facts) > 0 && (strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "*")) {
+				facts = append(facts, "  - "+strings.TrimLeft(trimmed, "-* "))
+			}
+		}
+	}
+	factCount := 0
+	for _, f := range facts {
+		if factLineRe.MatchString(f) {
+			factCount++
+		}
+	}
+
+	// Sources, reusing the registered ID of a URL another executor already archived
+	reg, regEnd, regHeader := sourceRegistry(lines)
+	known := map[string]string{}
+	if reg >= 0 {
+		for _, line := range lines[reg+1 : regEnd] {
+			cells := splitRow(line)
+			if len(cells) < 2 || !sourceIDRe.MatchString(cells[0]) {
+				continue
+			}
+			if url := rowURL(regHeader, cells); url != "" {
+				known[url] = cells[0]
+			}
+		}
+	}
+	columns := regHeader
+	if columns == nil {
+		columns = defaultRegistryHeader
+	}
+	alias := map[string]string{}
+	var newRows []string
+	for i, row := range rows {
+		if existing, ok := known[urls[i]]; ok {
+			alias[row["id"]] = existing
+			continue
+		}
+		var out []string
+		for _, h := range columns {
+			v := row[strings.ToLower(h)]
+			if v == "" && strings.Contains(strings.ToLower(h), "date") {
+				v = time.Now().Format("2006-01-02")
+			}
+			out = append(out, v)
+		}
+		newRows = append(newRows, "| "+strings.Join(out, " | ")+" |")
+	}
+	if len(alias) > 0 {
+		for i, f := range facts {
+			facts[i] = factSrcRe.ReplaceAllStringFunc(f, func(id string) string {
+				if a, ok := alias[id]; ok {
+					return a
+				}
+				return id
+			})
+		}
+	}
+	if len(newRows) > 0 {
+		table := []string{"| " + strings.Join(columns, " | ") + " |", "|" + strings.Repeat("----|", len(columns))}
+		switch {
+		case reg < 0:
+			// No registry yet: add one at the end of the Knowledge Graph
+			at := sectionEnd(lines, sectionStart(lines, "knowledge graph"))
+			lines = insertLines(lines, at, append(append([]string{"", "### Source Registry"}, table...), newRows...))
+		case regHeader == nil:
+			lines = insertLines(lines, reg+1, append(table, newRows...))
+		default:
+			lines = insertLines(lines, regEnd, newRows)
+		}
+	}
+
+	// Facts, under a heading for the task at the end of the Knowledge Graph
+	// (before a Source Registry kept inside it)
+	if len(facts) > 0 {
+		kg := sectionStart(lines, "knowledge graph")
+		at := sectionEnd(lines, kg)
+		if r, _, _ := sourceRegistry(lines); r > kg && r < at && kg >= 0 {
+			at = r
+		}
+		for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+		title := strings.TrimSpace(strings.SplitN(t.Description, "|", 2)[0])
+		block := append([]string{"", fmt.Sprintf("### %s: %s", t.ID, title)}, facts...)
+		if kg < 0 {
+			block = append([]string{"", "## Knowledge Graph"}, block...)
+		}
+		if at < len(lines) && strings.TrimSpace(lines[at]) != "" {
+			block = append(block, "")
+		}
+		lines = insertLines(lines, at, block)
+	}
+	return strings.Join(lines, "\n"), factCount, len(newRows) + len(alias)
+}
+
+// sourceRegistry locates the Source Registry: its heading line (-1 if there is
+// none), the line after its table and the table's column names (nil if the
+// heading has no table)
+func sourceRegistry(lines []string) (int, int, []string) {
+	for i, line := range lines {
+		m := headingRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || !strings.Contains(strings.ToLower(m[1]), "source registry") {
+			continue
+		}
+		var header []string
+		end := i + 1
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if strings.HasPrefix(trimmed, "|") {
+				if header == nil {
+					header = splitRow(trimmed)
+				}
+				end = j + 1
+				continue
+			}
+			if trimmed != "" || header != nil {
+				break
+			}
+		}
+		return i, end, header
+	}
+	return -1, len(lines), nil
+}
+
+// rowURL returns the URL cell of a Source Registry row: its URL column, else
+// the first cell holding a URL, "" if it has none
+func rowURL(header, cells []string) string {
+	for i, h := range header {
+		if strings.EqualFold(h, "url") && i < len(cells) {
+			return strings.Trim(cells[i], "`")
+		}
+	}
+	for _, c := range cells {
+		c = strings.Trim(c, "`")
+		if strings.HasPrefix(c, "http://") || strings.HasPrefix(c, "https://") {
+			return c
+		}
+	}
+	return ""
+}
+
+// sectionStart returns the line of the "## <title>" heading, -1 if missing
+func sectionStart(lines []string, title string) int {
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") && strings.EqualFold(strings.TrimSpace(trimmed[3:]), title) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sectionEnd returns the line after the section starting at start: the next
+// "## " heading, a "---" rule, or the end of the file
+func sectionEnd(lines []string, start int) int {
+	if start < 0 {
+		return len(lines)
+	}
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "## ") || trimmed == "---" {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// insertLines inserts block before line at
+func insertLines(lines []string, at int, block []string) []string {
+	out := make([]string, 0, len(lines)+len(block))
+	out = append(out, lines[:at]...)
+	out = append(out, block...)
+	return append(out, lines[at:]...)
+}
+
+// appendScratchpad adds a note to the end of the Scratchpad, if task.md has one
+func appendScratchpad(content, note string) string {
+	lines := strings.Split(content, "\n")
+	start := sectionStart(lines, "scratchpad")
+	if start < 0 {
+		return content
+	}
+	at := sectionEnd(lines, start)
+	for at > start+1 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+	return strings.Join(insertLines(lines, at, []string{"- " + note}), "\n")
+}
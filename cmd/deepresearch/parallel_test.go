@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+func TestMergeExecutorResultMatchesTheURLColumn(t *testing.T) {
+	content := `# Research Task: Heat pumps
+
+## Research DAG
+
+- [ ] E2: Heat pump market share in Germany | Priority: HIGH
+
+## Knowledge Graph
+
+### Source Registry
+| ID | URL | Title | Type | Date Accessed | Local Path |
+|----|-----|-------|------|---------------|------------|
+| S101 | https://example.org/no/heat-pumps | Heat pumps in Norwegian households | Web | 2025-03-01 | assets/web/s101.md |
+`
+	// The archive link is a URL too; only the URL column identifies the source
+	result := `## Execution Report: E2
+
+### Sources Archived
+
+| ID | Archive | URL | Title | Type | Date Accessed | Local Path |
+|----|---------|-----|-------|------|---------------|------------|
+| S202 | https://web.archive.org/web/2025/https://example.org/no/heat-pumps | https://example.org/no/heat-pumps | Heat pumps in Norway | Web | 2025-03-01 | assets/web/s202.md |
+
+### Extracted Facts
+
+[Fact-202] About 60% of Norwegian households heat with a heat pump
+- Source: S202
+`
+	task := taskfile.Task{ID: "E2", Description: "Heat pump market share in Germany"}
+	// Map order differs between runs, so merge repeatedly
+	for range 20 {
+		merged, facts, _ := mergeExecutorResult(content, task, result)
+		if facts != 1 {
+			t.Fatalf("merged %d fact(s), want 1", facts)
+		}
+		if strings.Contains(merged, "| S202 |") {
+			t.Fatalf("S202 was registered again instead of reusing S101:\n%s", merged)
+		}
+		if !strings.Contains(merged, "- Source: S101") {
+			t.Fatalf("the fact does not cite S101:\n%s", merged)
+		}
+	}
+}
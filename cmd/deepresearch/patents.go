@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Default endpoints of the patent providers
+const (
+	epoOPSAPI      = "https://ops.epo.org/3.2"
+	patentsViewAPI = "https://search.patentsview.org/api/v1"
+)
+
+func init() {
+	registerConnector(&connector{
+		Name:    "patents",
+		Summary: "Patent search with abstracts and claims (EPO OPS by default, or USPTO PatentsView)",
+		Actions: map[string]string{
+			"search": `-q "query" [-n 10] — matching patents with applicant and date`,
+			"get":    `<publication number> — abstract and claims, saved to assets/patents`,
+		},
+		Run: runPatents,
+	})
+}
+
+// patentRecord is a provider-independent patent document
+type patentRecord struct {
+	Number    string
+	Title     string
+	Applicant string
+	Date      string // YYYY-MM-DD
+	Abstract  string
+	Claims    []string
+}
+
+// patentProvider searches and fetches patents from one database
+type patentProvider interface {
+	Search(query string, limit int) ([]patentRecord, []byte, error)
+	Get(number string) (patentRecord, error)
+}
+
+func runPatents(t *toolContext, action string, args []string) error {
+	var p patentProvider
+	switch t.Config.Provider {
+	case "", "epo":
+		p = &epoProvider{t: t}
+	case "patentsview":
+		p = &patentsViewProvider{t: t}
+	default:
+		return fmt.Errorf("unknown patents provider %q (expected epo or patentsview)", t.Config.Provider)
+	}
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		records, raw, err := p.Search(query, *n)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "patents_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		t.printf("%d patent(s). Raw results: %s\n\n| Number | Date | Title | Applicant |\n|---|---|---|---|\n", len(records), path)
+		for _, r := range records {
+			t.printf("| %s | %s | %s | %s |\n", r.Number, r.Date, mdCell(r.Title), mdCell(r.Applicant))
+		}
+		t.printf("\nUse `get <number>` to archive the abstract and claims of a patent you cite.\n")
+		return nil
+
+	case "get":
+		if len(args) == 0 {
+			return fmt.Errorf("a publication number is required")
+		}
+		r, err := p.Get(args[0])
+		if err != nil {
+			return err
+		}
+		var body strings.Builder
+		fmt.Fprintf(&body, "# %s — %s\n\n## Abstract\n\n%s\n", r.Number, r.Title, r.Abstract)
+		if len(r.Claims) > 0 {
+			body.WriteString("\n## Claims\n\n")
+			for i, c := range r.Claims {
+				fmt.Fprintf(&body, "%d. %s\n", i+1, strings.TrimSpace(c))
+			}
+		}
+		path, err := t.saveDocument("patents", r.Number+"_"+r.Title, [][2]string{
+			{"title", r.Title},
+			{"number", r.Number},
+			{"applicant", r.Applicant},
+			{"published", r.Date},
+			{"url", "https://patents.google.com/patent/" + strings.ReplaceAll(r.Number, " ", "")},
+		}, body.String())
+		if err != nil {
+			return err
+		}
+		t.printf("%s\nArchived: %s\n", body.String(), path)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// epoProvider queries the European Patent Office Open Patent Services (OAuth client credentials)
+type epoProvider struct {
+	t     *toolContext
+	token string
+}
+
+// authorize exchanges the consumer key and secret ("key:secret" in the configured
+// environment variable, default $EPO_OPS_CREDENTIALS) for an access token
+func (e *epoProvider) authorize() error {
+	if e.token != "" {
+		return nil
+	}
+	key, secret, ok := strings.Cut(e.t.apiKey("EPO_OPS_CREDENTIALS"), ":")
+	if !ok {
+		return fmt.Errorf("EPO OPS requires credentials as key:secret (connectors.patents.api_key_env, default $EPO_OPS_CREDENTIALS)")
+	}
+	req, err := http.NewRequest(http.MethodPost, e.t.endpoint(epoOPSAPI)+"/auth/accesstoken",
+		strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(key, secret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := e.t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("EPO OPS authentication returned %s", resp.Status)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return err
+	}
+	e.token = tok.AccessToken
+	return nil
+}
+
+func (e *epoProvider) get(path string) (any, []byte, error) {
+	if err := e.authorize(); err != nil {
+		return nil, nil, err
+	}
+	var doc any
+	raw, err := e.t.getJSON(e.t.endpoint(epoOPSAPI)+"/rest-services"+path, map[string]string{"Authorization": "Bearer " + e.token}, &doc)
+	return doc, raw, err
+}
+
+func (e *epoProvider) Search(query string, limit int) ([]patentRecord, []byte, error) {
+	// Plain words become a title/abstract query; CQL (containing "=") is passed through
+	cql := query
+	if !strings.Contains(query, "=") {
+		cql = fmt.Sprintf("ta=%q", query)
+	}
+	doc, raw, err := e.get(fmt.Sprintf("/published-data/search/biblio?q=%s&Range=1-%d", url.QueryEscape(cql), min(limit, 100)))
+	// OPS answers a search without matches with 404
+	var status *httpStatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		return nil, []byte("{}"), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	results := jsonAt(doc, "ops:world-patent-data", "ops:biblio-search", "ops:search-result", "exchange-documents")
+	var records []patentRecord
+	for _, d := range jsonList(results) {
+		records = append(records, epoRecord(jsonAt(d, "exchange-document")))
+	}
+	return records, raw, nil
+}
+
+func (e *epoProvider) Get(number string) (patentRecord, error) {
+	num := url.PathEscape(strings.ReplaceAll(number, " ", ""))
+	doc, _, err := e.get("/published-data/publication/epodoc/" + num + "/biblio")
+	if err != nil {
+		return patentRecord{}, err
+	}
+	docs := jsonList(jsonAt(doc, "ops:world-patent-data", "exchange-documents"))
+	if len(docs) == 0 {
+		return patentRecord{}, fmt.Errorf("patent %s not found", number)
+	}
+	r := epoRecord(jsonAt(docs[0], "exchange-document"))
+	// Claims are only available for some authorities (EP, WO); a missing claims document is not an error
+	if claims, _, err := e.get("/published-data/publication/epodoc/" + num + "/claims"); err == nil {
+		text := jsonAt(claims, "ops:world-patent-data", "ftxt:fulltext-documents", "ftxt:fulltext-document", "claims")
+		for _, c := range jsonList(text) {
+			for _, ct := range jsonList(jsonAt(c, "claim", "claim-text")) {
+				r.Claims = append(r.Claims, jsonText(ct))
+			}
+		}
+	}
+	return r, nil
+}
+
+// epoRecord extracts the fields of an OPS exchange-document
+func epoRecord(d any) patentRecord {
+	r := patentRecord{
+		Number: jsonText(jsonAt(d, "@country")) + jsonText(jsonAt(d, "@doc-number")) + jsonText(jsonAt(d, "@kind")),
+	}
+	biblio := jsonAt(d, "bibliographic-data")
+	for _, t := range jsonList(jsonAt(biblio, "invention-title")) {
+		if r.Title == "" || jsonText(jsonAt(t, "@lang")) == "en" {
+			r.Title = jsonText(t)
+		}
+	}
+	if applicants := jsonList(jsonAt(biblio, "parties", "applicants", "applicant")); len(applicants) > 0 {
+		r.Applicant = jsonText(jsonAt(applicants[0], "applicant-name", "name"))
+	}
+	for _, id := range jsonList(jsonAt(biblio, "publication-reference", "document-id")) {
+		if date := jsonText(jsonAt(id, "date")); len(date) == 8 {
+			r.Date = date[:4] + "-" + date[4:6] + "-" + date[6:]
+		}
+	}
+	for _, a := range jsonList(jsonAt(d, "abstract")) {
+		if r.Abstract == "" || jsonText(jsonAt(a, "@lang")) == "en" {
+			var paras []string
+			for _, p := range jsonList(jsonAt(a, "p")) {
+				paras = append(paras, jsonText(p))
+			}
+			r.Abstract = strings.Join(paras, "\n\n")
+		}
+	}
+	return r
+}
+
+// jsonAt walks nested JSON objects by key
+func jsonAt(v any, keys ...string) any {
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// jsonList treats a single JSON value as a one-element list (OPS collapses one-item arrays)
+func jsonList(v any) []any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return x
+	}
+	return []any{v}
+}
+
+// jsonText returns the text of an OPS value ({"$": "text"} or a plain string)
+func jsonText(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case map[string]any:
+		if s, ok := x["$"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// patentsViewProvider queries the USPTO PatentsView search API (US patents, API key required)
+type patentsViewProvider struct {
+	t *toolContext
+}
+
+func (p *patentsViewProvider) query(path string, q, f, o any, out any) ([]byte, error) {
+	key := p.t.apiKey("PATENTSVIEW_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("PatentsView requires an API key (connectors.patents.api_key_env, default $PATENTSVIEW_API_KEY)")
+	}
+	params := url.Values{}
+	for name, v := range map[string]any{"q": q, "f": f, "o": o} {
+		if v != nil {
+			data, _ := json.Marshal(v)
+			params.Set(name, string(data))
+		}
+	}
+	return p.t.getJSON(p.t.endpoint(patentsViewAPI)+path+"?"+params.Encode(), map[string]string{"X-Api-Key": key}, out)
+}
+
+type patentsViewPatent struct {
+	ID        string `json:"patent_id"`
+	Title     string `json:"patent_title"`
+	Date      string `json:"patent_date"`
+	Abstract  string `json:"patent_abstract"`
+	Assignees []struct {
+		Organization string `json:"assignee_organization"`
+	} `json:"assignees"`
+}
+
+func (r patentsViewPatent) record() patentRecord {
+	rec := patentRecord{Number: "US" + r.ID, Title: r.Title, Date: r.Date, Abstract: r.Abstract}
+	if len(r.Assignees) > 0 {
+		rec.Applicant = r.Assignees[0].Organization
+	}
+	return rec
+}
+
+var patentsViewFields = []string{"patent_id", "patent_title", "patent_date", "patent_abstract", "assignees.assignee_organization"}
+
+func (p *patentsViewProvider) Search(query string, limit int) ([]patentRecord, []byte, error) {
+	var resp struct {
+		Patents []patentsViewPatent `json:"patents"`
+	}
+	q := map[string]any{"_text_any": map[string]string{"patent_abstract": query}}
+	raw, err := p.query("/patent/", q, patentsViewFields, map[string]int{"size": min(limit, 1000)}, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []patentRecord
+	for _, r := range resp.Patents {
+		records = append(records, r.record())
+	}
+	return records, raw, nil
+}
+
+func (p *patentsViewProvider) Get(number string) (patentRecord, error) {
+	id := strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(number, " ", "")), "US")
+	var resp struct {
+		Patents []patentsViewPatent `json:"patents"`
+	}
+	if _, err := p.query("/patent/", map[string]string{"patent_id": id}, patentsViewFields, nil, &resp); err != nil {
+		return patentRecord{}, err
+	}
+	if len(resp.Patents) == 0 {
+		return patentRecord{}, fmt.Errorf("patent %s not found", number)
+	}
+	r := resp.Patents[0].record()
+	var claims struct {
+		Claims []struct {
+			Text string `json:"claim_text"`
+		} `json:"g_claims"`
+	}
+	// As with OPS, a missing claims document still returns the abstract
+	if _, err := p.query("/g_claim/", map[string]string{"patent_id": id}, []string{"claim_text", "claim_sequence"},
+		map[string]int{"size": 1000}, &claims); err == nil {
+		for _, c := range claims.Claims {
+			r.Claims = append(r.Claims, c.Text)
+		}
+	}
+	return r, nil
+}
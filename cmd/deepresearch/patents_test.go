@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const epoBiblio = "/rest-services/published-data/publication/epodoc/EP4123456A1/biblio"
+
+// epoSettings points the patents connector at endpoint with test credentials
+func epoSettings(t *testing.T, endpoint string) connectorConfig {
+	t.Setenv("TEST_EPO_CREDENTIALS", "test-key:test-secret")
+	return connectorConfig{Endpoint: endpoint, APIKeyEnv: "TEST_EPO_CREDENTIALS"}
+}
+
+// epoFault serves an OPS access token and answers everything else with status
+// and the recorded fault
+func epoFault(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	token, err := os.ReadFile(filepath.Join("testdata", "connectors", "patents", "epo_token.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fault, err := os.ReadFile(filepath.Join("testdata", "connectors", "patents", "epo_no_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/accesstoken" {
+			w.Write(token)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write(fault)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPatentsEPOSearch(t *testing.T) {
+	srv := recordedAPI(t, "patents", map[string]string{
+		"/auth/accesstoken":                           "epo_token.json",
+		"/rest-services/published-data/search/biblio": "epo_search.json",
+	})
+	dir, out, err := runRecordedTool(t, "patents", epoSettings(t, srv.URL), "search", "-q", "solid state battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 patent(s).",
+		"| Number | Date | Title | Applicant |",
+		"| EP4123456A1 | 2023-02-01 | SOLID STATE BATTERY WITH SULFIDE ELECTROLYTE | TOYOTA MOTOR CO LTD [JP] |",
+		`| WO2024012345A1 | 2024-01-18 | ANODE-FREE SOLID STATE CELL \| LITHIUM METAL | QUANTUMSCAPE BATTERY INC [US] |`,
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "patents-solid-state-battery") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestPatentsEPOGet(t *testing.T) {
+	srv := recordedAPI(t, "patents", map[string]string{
+		"/auth/accesstoken": "epo_token.json",
+		epoBiblio:           "epo_biblio.json",
+		strings.Replace(epoBiblio, "biblio", "claims", 1): "epo_claims.json",
+	})
+	dir, out, err := runRecordedTool(t, "patents", epoSettings(t, srv.URL), "get", "EP 4123456 A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# EP4123456A1 — SOLID STATE BATTERY WITH SULFIDE ELECTROLYTE",
+		"between a cathode and an anode.\n\nThe electrolyte layer suppresses dendrite growth",
+		"2. The battery of claim 1, wherein the electrolyte is Li6PS5Cl.",
+	)
+	files := assetFiles(t, dir, "patents")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "ep4123456a1-solid-state-battery") {
+		t.Fatalf("archived %v, want the patent", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "patents", files[0]))
+	checkContains(t, string(doc), `applicant: "TOYOTA MOTOR CO LTD [JP]"`, `url: "https://patents.google.com/patent/EP4123456A1"`, "## Claims")
+}
+
+func TestPatentsView(t *testing.T) {
+	srv := recordedAPI(t, "patents", map[string]string{
+		"/patent/":  "patentsview.json",
+		"/g_claim/": "patentsview_claims.json",
+	})
+	settings := connectorConfig{Provider: "patentsview", Endpoint: srv.URL, APIKeyEnv: "TEST_PATENTSVIEW_KEY"}
+	if _, _, err := runRecordedTool(t, "patents", settings, "search", "-q", "hybrid electrolyte"); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("PatentsView without a key: error %v, want one asking for the key", err)
+	}
+	t.Setenv("TEST_PATENTSVIEW_KEY", "test-key")
+	_, out, err := runRecordedTool(t, "patents", settings, "search", "-q", "hybrid electrolyte")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "1 patent(s).", "| US11870034 | 2024-01-09 | Solid-state battery having a hybrid electrolyte | Solid Power Operating, Inc. |")
+
+	dir, out, err := runRecordedTool(t, "patents", settings, "get", "US11870034")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "ceramic-polymer hybrid electrolyte", "2. The battery cell of claim 1, wherein the hybrid electrolyte contains a sulfide glass.")
+	if files := assetFiles(t, dir, "patents"); len(files) != 1 {
+		t.Errorf("archived %v, want the patent", files)
+	}
+}
+
+func TestPatentsNoResults(t *testing.T) {
+	srv := epoFault(t, http.StatusNotFound)
+	dir, out, err := runRecordedTool(t, "patents", epoSettings(t, srv.URL), "search", "-q", "no such invention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "0 patent(s).")
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+	if files := assetFiles(t, dir, "data"); len(files) != 1 {
+		t.Errorf("archived %v, want the (empty) results", files)
+	}
+}
+
+func TestPatentsErrors(t *testing.T) {
+	// Authentication and the search itself may both be throttled
+	checkHTTPErrors(t, "patents", epoSettings(t, ""), "search", "-q", "solid state battery")
+	srv := epoFault(t, http.StatusTooManyRequests)
+	if _, _, err := runRecordedTool(t, "patents", epoSettings(t, srv.URL), "search", "-q", "solid state battery"); err == nil || !strings.Contains(err.Error(), "Too Many Requests") {
+		t.Errorf("throttled search: error %v, want one naming the status", err)
+	}
+	if _, _, err := runRecordedTool(t, "patents", epoSettings(t, srv.URL), "get", "EP4123456A1"); err == nil {
+		t.Error("a throttled get succeeded")
+	}
+
+	t.Setenv("TEST_EPO_CREDENTIALS", "")
+	if _, _, err := runRecordedTool(t, "patents", connectorConfig{APIKeyEnv: "TEST_EPO_CREDENTIALS"}, "search", "-q", "battery"); err == nil || !strings.Contains(err.Error(), "key:secret") {
+		t.Errorf("EPO without credentials: error %v, want one asking for them", err)
+	}
+	if _, _, err := runRecordedTool(t, "patents", connectorConfig{Provider: "google"}, "search", "-q", "battery"); err == nil {
+		t.Error("an unknown provider succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// blockInfo describes why an archived fetch looks paywalled or blocked
+type blockInfo struct {
+	Reason  string   `json:"reason"`
+	Signals []string `json:"signals"`
+}
+
+// blockRule maps content signals to a block reason
+type blockRule struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+var blockRules = []blockRule{
+	{regexp.MustCompile(`(?i)subscribe (?:now )?to (?:continue|keep) reading|to continue reading,? (?:please )?(?:subscribe|sign in|log in)|this (?:article|content) is (?:only )?(?:available )?(?:for|to) (?:subscribers|members)|you(?:'ve| have) reached your (?:free )?(?:article|story) limit|already a subscriber\?`), "paywall"},
+	{regexp.MustCompile(`(?i)\b403 forbidden\b|\baccess denied\b|you don't have permission to access`), "access denied"},
+	{regexp.MustCompile(`(?i)verify you are (?:a )?human|are you a robot|cf-browser-verification|just a moment\.\.\.|captcha`), "bot check"},
+	{regexp.MustCompile(`(?i)please enable javascript|javascript is (?:disabled|required)`), "javascript required"},
+	{regexp.MustCompile(`(?i)\b404 not found\b|page (?:not found|cannot be found)`), "not found"},
+}
+
+// minUsefulText is the visible-text size below which a blocked-looking page is treated as not retrieved
+const minUsefulText = 3000
+
+var htmlTagRe = regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<[^>]+>`)
+
+// detectBlockedFile inspects a text asset for paywall / blocked-fetch markers.
+// Only short pages are flagged, so articles that merely mention "subscribe" in a
+// footer are not reported.
+func detectBlockedFile(path string) *blockInfo {
+	if !textAssetExts[strings.ToLower(filepath.Ext(path))] {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	text := strings.Join(strings.Fields(htmlTagRe.ReplaceAllString(string(content), " ")), " ")
+
+	var blocked *blockInfo
+	for _, rule := range blockRules {
+		if m := rule.re.FindString(text); m != "" {
+			if blocked == nil {
+				blocked = &blockInfo{Reason: rule.reason}
+			}
+			blocked.Signals = append(blocked.Signals, m)
+		}
+	}
+	if blocked == nil || len(text) >= minUsefulText {
+		return nil
+	}
+	return blocked
+}
+
+// blockedAssets returns the manifest entries that look paywalled or blocked
+func blockedAssets(m *assetManifest) []assetEntry {
+	if m == nil {
+		return nil
+	}
+	var blocked []assetEntry
+	for _, a := range m.Assets {
+		if a.Blocked != nil {
+			blocked = append(blocked, a)
+		}
+	}
+	return blocked
+}
+
+// recordBlockedGaps logs each blocked fetch as a research gap
+func recordBlockedGaps(m *assetManifest, iteration int) {
+	for _, a := range blockedAssets(m) {
+		logEntry("WARN", "GAP", iteration, "Source content not retrieved", map[string]string{
+			"asset":  a.Path,
+			"source": a.SourceID,
+			"url":    a.URL,
+			"reason": a.Blocked.Reason,
+		})
+	}
+}
+
+// buildBlockedSourcesNote returns extra reflector instructions listing blocked
+// fetches, or "" if there are none
+func buildBlockedSourcesNote(m *assetManifest) string {
+	blocked := blockedAssets(m)
+	if len(blocked) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+BLOCKED_SOURCES: The orchestrator detected fetches whose content was NOT actually retrieved
+(paywall, access denied, bot check). Facts must NOT be attributed to these sources.
+`)
+	for _, a := range blocked {
+		id := a.SourceID
+		if id == "" {
+			id = "(unregistered)"
+		}
+		fmt.Fprintf(&b, "- %s %s — %s (%s)\n", id, a.URL, a.Path, a.Blocked.Reason)
+	}
+	b.WriteString(`For each: record it under Gap Analysis in task.md, remove or downgrade any facts citing it,
+and add E* tasks that seek open-access alternatives (preprints such as arXiv/SSRN, author copies,
+institutional repositories, archived mirrors, press releases, or reputable summaries).
+`)
+	return b.String()
+}
+
+// checkBlockedCitations warns when report.md cites sources whose content was never retrieved
+func checkBlockedCitations(workDir string, m *assetManifest) []string {
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return nil
+	}
+	cited := map[string]bool{}
+	for _, c := range citationRe.FindAllStringSubmatch(string(report), -1) {
+		cited[c[1]] = true
+	}
+	var warnings []string
+	for _, a := range blockedAssets(m) {
+		if a.SourceID != "" && cited[a.SourceID] {
+			warnings = append(warnings, fmt.Sprintf("Report cites %s but its fetch looks blocked (%s): %s", a.SourceID, a.Blocked.Reason, a.Path))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,109 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClarificationsFile is where the clarifier writes its questions about the
+// request, relative to the workspace; the orchestrator adds the user's answers
+const ClarificationsFile = "clarifications.json"
+
+// MaxClarifyingQuestions bounds the questions the user is asked; the
+// clarifier is told to ask 2 to 5, or none about a clear request
+const MaxClarifyingQuestions = 5
+
+// Clarifier gets the user's answers to the questions the clarifier asked about
+// the request. It runs in the orchestrator's process rather than as an agent.
+type Clarifier interface {
+	// Answer returns one answer per question; "" leaves a question to the
+	// planner's judgment
+	Answer(ctx context.Context, questions []string) ([]string, error)
+}
+
+// Clarifications are the clarifier's questions about the request and the
+// user's answers, as ClarificationsFile holds them
+type Clarifications struct {
+	Questions []string `json:"questions"`
+	// Answers are the user's, one per question; nil until they answered
+	Answers []string `json:"answers"`
+}
+
+// Answered reports whether the user has answered the questions
+func (c *Clarifications) Answered() bool {
+	return c != nil && c.Answers != nil
+}
+
+// ReadClarifications reads and checks ClarificationsFile in workDir. Questions
+// not yet answered are put on one line each; blank ones are dropped, and those
+// after the first MaxClarifyingQuestions.
+func ReadClarifications(workDir string) (*Clarifications, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, ClarificationsFile))
+	if err != nil {
+		return nil, err
+	}
+	var c Clarifications
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %s", ClarificationsFile, describeJSONError(data, err))
+	}
+	if c.Answers != nil && len(c.Answers) != len(c.Questions) {
+		return nil, fmt.Errorf("%s has %d answer(s) to %d question(s)", ClarificationsFile, len(c.Answers), len(c.Questions))
+	}
+	if c.Answers == nil {
+		var questions []string
+		for _, q := range c.Questions {
+			if q = strings.Join(strings.Fields(q), " "); q != "" {
+				questions = append(questions, q)
+			}
+		}
+		c.Questions = questions[:min(len(questions), MaxClarifyingQuestions)]
+	}
+	return &c, nil
+}
+
+// write saves c to ClarificationsFile in workDir
+func (c *Clarifications) write(workDir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workDir, ClarificationsFile), append(data, '\n'), 0644)
+}
+
+// clarify runs the clarifier and has the Clarifier answer its questions, which
+// it records in ClarificationsFile and req for the planner
+func (o *Orchestrator) clarify(ctx context.Context, req *Request) error {
+	step := Step{Phase: PhaseClarifier}
+	// Questions left by an earlier run must not pass for this one's
+	os.Remove(filepath.Join(req.WorkDir, ClarificationsFile))
+	if _, err := o.runStep(ctx, *req, step); err != nil {
+		return err
+	}
+	c, err := ReadClarifications(req.WorkDir)
+	if err == nil && len(c.Questions) > 0 && ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	if err == nil {
+		c.Answers = []string{}
+		if len(c.Questions) > 0 {
+			c.Answers, err = o.Clarifier.Answer(ctx, c.Questions)
+		}
+	}
+	if err == nil && len(c.Answers) != len(c.Questions) {
+		err = errors.New("the answers do not match the questions")
+	}
+	if err == nil {
+		err = c.write(req.WorkDir)
+	}
+	if err != nil {
+		return &StepError{Step: step, Err: err}
+	}
+	req.Clarifications = c
+	o.emit(Event{Kind: QuestionsAnswered, Step: step, Clarifications: c})
+	return nil
+}
@@ -0,0 +1,231 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HandoffFile is the structured handoff an agent writes to the workspace when
+// it exits, next to the phase's output. The orchestrator reads it after the
+// step and moves it to HandoffDir.
+const HandoffFile = "handoff.json"
+
+// HandoffDir keeps the checked handoffs of a run, one per step, relative to the workspace
+const HandoffDir = "logs/handoffs"
+
+// HandoffSchema is the JSON Schema of HandoffFile within the prompt pack, for agents to read
+const HandoffSchema = "references/handoff.schema.json"
+
+// HandoffMode tells how the orchestrator treats the handoff of a step
+type HandoffMode string
+
+// Handoff modes
+const (
+	// HandoffOff neither asks agents for a handoff nor reads one (the zero value)
+	HandoffOff HandoffMode = ""
+	// HandoffCheck asks for a handoff and checks it when the agent wrote one
+	HandoffCheck HandoffMode = "check"
+	// HandoffRequire fails a step whose agent wrote no handoff
+	HandoffRequire HandoffMode = "require"
+)
+
+// Handoff statuses an agent may report
+const (
+	HandoffCompleted = "completed" // the phase's work is done
+	HandoffPartial   = "partial"   // done as far as it could be; follow_ups tell what is left
+	HandoffFailed    = "failed"    // the agent could not do the phase's work; error tells why
+)
+
+// Handoff is what an agent reports about its step in HandoffFile
+type Handoff struct {
+	Phase  Phase  `json:"phase"`
+	Status string `json:"status"`
+	// Outputs are the files the agent wrote or changed, relative to the workspace
+	Outputs []string `json:"outputs"`
+	// Confidence is the agent's confidence in its outputs, from 0 to 1
+	Confidence *float64 `json:"confidence,omitempty"`
+	FollowUps  []string `json:"follow_ups,omitempty"` // work the agent left for later steps
+	Summary    string   `json:"summary,omitempty"`
+	Error      string   `json:"error,omitempty"` // why the agent failed
+}
+
+// HandoffError reports a step whose agent wrote a malformed handoff, or none
+// where one is required. Problems tell the agent (or its author) what to fix.
+type HandoffError struct {
+	Step     Step
+	File     string   // relative to the workspace
+	Problems []string // empty when the handoff is missing
+}
+
+func (e *HandoffError) Error() string {
+	if len(e.Problems) == 0 {
+		return fmt.Sprintf("%s did not write %s", e.Step.Phase.Title(), e.File)
+	}
+	return fmt.Sprintf("%s wrote an invalid %s: %s", e.Step.Phase.Title(), e.File, strings.Join(e.Problems, "; "))
+}
+
+// AgentReportedError is the error of a step whose agent handed off with
+// status "failed"; a StepError names the step
+type AgentReportedError struct {
+	Step   Step
+	Reason string
+}
+
+func (e *AgentReportedError) Error() string {
+	return "the agent reported: " + e.Reason
+}
+
+// ReadHandoff reads and checks the handoff of step in workDir. It returns nil
+// and no error if there is none.
+func ReadHandoff(workDir string, step Step) (*Handoff, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, HandoffFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h, problems := ParseHandoff(data, workDir, step)
+	if len(problems) > 0 {
+		return nil, &HandoffError{Step: step, File: HandoffFile, Problems: problems}
+	}
+	return h, nil
+}
+
+// ParseHandoff decodes a handoff and checks it against the schema and the
+// workspace: the phase must be step's, outputs must exist inside workDir, and
+// a failed step must say why. It returns the problems found.
+func ParseHandoff(data []byte, workDir string, step Step) (*Handoff, []string) {
+	var h Handoff
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&h); err != nil {
+		return nil, []string{describeJSONError(data, err)}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, []string{"unexpected data after the JSON object"}
+	}
+
+	var problems []string
+	switch {
+	case h.Phase == "":
+		problems = append(problems, fmt.Sprintf(`"phase" is missing (expected %q)`, step.Phase))
+	case h.Phase != step.Phase:
+		problems = append(problems, fmt.Sprintf(`"phase" is %q, expected %q`, h.Phase, step.Phase))
+	}
+	switch h.Status {
+	case HandoffCompleted, HandoffPartial, HandoffFailed:
+	case "":
+		problems = append(problems, `"status" is missing (completed, partial or failed)`)
+	default:
+		problems = append(problems, fmt.Sprintf(`"status" is %q, expected completed, partial or failed`, h.Status))
+	}
+	if h.Status == HandoffFailed && strings.TrimSpace(h.Error) == "" {
+		problems = append(problems, `"error" must say why the step failed`)
+	}
+	if h.Outputs == nil && h.Status != HandoffFailed {
+		problems = append(problems, `"outputs" is missing (list the files written, e.g. ["task.md"])`)
+	}
+	if c := h.Confidence; c != nil && (*c < 0 || *c > 1) {
+		problems = append(problems, fmt.Sprintf(`"confidence" is %g, expected a number from 0 to 1`, *c))
+	}
+	for _, out := range h.Outputs {
+		rel := filepath.Clean(filepath.FromSlash(out))
+		switch {
+		case out == "" || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)):
+			problems = append(problems, fmt.Sprintf("output %q is not a path inside the workspace", out))
+		case h.Status != HandoffFailed:
+			if _, err := os.Stat(filepath.Join(workDir, rel)); err != nil {
+				problems = append(problems, fmt.Sprintf("output %q does not exist", out))
+			}
+		}
+	}
+	for i, f := range h.FollowUps {
+		if strings.TrimSpace(f) == "" {
+			problems = append(problems, fmt.Sprintf("follow_ups[%d] is empty", i))
+		}
+	}
+	return &h, problems
+}
+
+// describeJSONError locates a decoding error in data by line and column
+func describeJSONError(data []byte, err error) string {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset - 1 // Offset counts the offending byte
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return fmt.Sprintf("%q must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type.String()), typeErr.Value)
+		}
+		offset = typeErr.Offset
+	case errors.Is(err, io.EOF):
+		return "the file is empty"
+	}
+	msg := strings.TrimPrefix(err.Error(), "json: ")
+	if offset < 0 {
+		return msg
+	}
+	line, col := 1, 1
+	for _, b := range data[:min(max(int(offset), 0), len(data))] {
+		if b == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Sprintf("line %d, column %d: %s", line, col, msg)
+}
+
+// jsonTypeName names a Go field type as the JSON type a handoff expects
+func jsonTypeName(goType string) string {
+	switch {
+	case strings.HasPrefix(goType, "[]"):
+		return "an array of strings"
+	case strings.Contains(goType, "float"):
+		return "a number"
+	}
+	return "a string"
+}
+
+// WriteHandoff writes the handoff of a step to the workspace, for runners that
+// do a step's work themselves, e.g. by dispatching several agents
+func WriteHandoff(workDir string, h Handoff) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workDir, HandoffFile), append(data, '\n'), 0644)
+}
+
+// archiveHandoff moves the checked handoff of step to HandoffDir, e.g.
+// logs/handoffs/02-reflector.json, so the next step starts without one
+func archiveHandoff(workDir string, step Step) error {
+	dir := filepath.Join(workDir, filepath.FromSlash(HandoffDir))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%02d-%s.json", step.Iteration, strings.ToLower(string(step.Phase)))
+	return os.Rename(filepath.Join(workDir, HandoffFile), filepath.Join(dir, name))
+}
+
+// HandoffNote tells the agent of a step in a workspace laid out as layout to
+// write its handoff, or returns "" in mode HandoffOff
+func HandoffNote(guidesDir string, step Step, mode HandoffMode, layout Layout) string {
+	if mode == HandoffOff {
+		return ""
+	}
+	return fmt.Sprintf(`
+HANDOFF: As your very last action, write %s in WORKING_DIR as specified by %s, e.g.
+{"phase": %q, "status": "completed", "outputs": [%q], "confidence": 0.8, "follow_ups": [], "summary": "..."}
+Use status "partial" with follow_ups for work left undone, or "failed" with an "error" if you could not do the task.
+`, HandoffFile, filepath.Join(guidesDir, HandoffSchema), step.Phase, layout.artifactOf(step))
+}
@@ -0,0 +1,19 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// languageRe matches an ISO 639-1 code, optionally with a region or script
+// subtag, e.g. "ja", "pt-BR" or "zh-Hant"
+var languageRe = regexp.MustCompile(`^[a-z]{2}(-[A-Za-z0-9]{2,8})?$`)
+
+// CheckLanguage reports a language that is not an ISO 639-1 code such as "ja"
+// or "pt-BR"
+func CheckLanguage(lang string) error {
+	if !languageRe.MatchString(lang) {
+		return fmt.Errorf("%q is not an ISO 639-1 language code such as ja or pt-BR", lang)
+	}
+	return nil
+}
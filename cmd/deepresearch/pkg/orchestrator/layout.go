@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Default paths of the workspace files
+const (
+	DefaultTaskFile   = "task.md"
+	DefaultReportFile = "report.md"
+	DefaultAssetsDir  = "assets"
+)
+
+// Layout places the files of a workspace, as slash-separated paths relative to
+// it. The prompt guides name the files by their defaults; LayoutNote tells the
+// agents where they are instead.
+type Layout struct {
+	TaskFile   string `json:"task_file"`   // the research plan (default DefaultTaskFile)
+	ReportFile string `json:"report_file"` // the report (default DefaultReportFile)
+	AssetsDir  string `json:"assets_dir"`  // the archived sources (default DefaultAssetsDir)
+}
+
+// WithDefaults returns l with the default of each path it leaves empty, and
+// the others cleaned
+func (l Layout) WithDefaults() Layout {
+	clean := func(p, def string) string {
+		if p == "" {
+			return def
+		}
+		return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	}
+	return Layout{
+		TaskFile:   clean(l.TaskFile, DefaultTaskFile),
+		ReportFile: clean(l.ReportFile, DefaultReportFile),
+		AssetsDir:  clean(l.AssetsDir, DefaultAssetsDir),
+	}
+}
+
+// IsDefault reports whether l keeps every file at its default path
+func (l Layout) IsDefault() bool {
+	return l.WithDefaults() == Layout{}.WithDefaults()
+}
+
+// Check reports a path of l that is not inside the workspace, or files that
+// would collide
+func (l Layout) Check() error {
+	l = l.WithDefaults()
+	for _, p := range []struct{ name, path string }{
+		{"task file", l.TaskFile},
+		{"report file", l.ReportFile},
+		{"assets directory", l.AssetsDir},
+	} {
+		if p.path == "." || path.IsAbs(p.path) || p.path == ".." || strings.HasPrefix(p.path, "../") || strings.Contains(p.path, ":") {
+			return fmt.Errorf("the %s %s is not a path inside the workspace", p.name, p.path)
+		}
+	}
+	switch {
+	case l.TaskFile == l.ReportFile:
+		return fmt.Errorf("the task file and the report file are both %s", l.TaskFile)
+	case strings.HasPrefix(l.TaskFile, l.AssetsDir+"/"), strings.HasPrefix(l.ReportFile, l.AssetsDir+"/"):
+		return fmt.Errorf("the task and report files cannot be inside the assets directory %s", l.AssetsDir)
+	}
+	return nil
+}
+
+// Artifact is the file every step of phase must write; a translator step
+// writes the TranslationFile of its language instead
+func (l Layout) Artifact(phase Phase) string {
+	l = l.WithDefaults()
+	switch phase {
+	case PhaseClarifier:
+		return ClarificationsFile
+	case PhaseSynthesizer:
+		return l.ReportFile
+	}
+	return l.TaskFile
+}
+
+// artifactOf is the file step must write
+func (l Layout) artifactOf(step Step) string {
+	if step.Phase == PhaseTranslator {
+		return l.TranslationFile(step.Language)
+	}
+	return l.Artifact(step.Phase)
+}
+
+// TranslationFile is where the translator writes the report in lang, next to
+// it: report.ja.md for report.md
+func (l Layout) TranslationFile(lang string) string {
+	report := l.WithDefaults().ReportFile
+	ext := path.Ext(report)
+	return strings.TrimSuffix(report, ext) + "." + lang + ext
+}
+
+// LayoutNote tells the agent of a step where the files of a workspace laid out
+// as l are, or returns "" for the default layout
+func LayoutNote(l Layout) string {
+	if l.IsDefault() {
+		return ""
+	}
+	l = l.WithDefaults()
+	return fmt.Sprintf(`
+LAYOUT: This workspace keeps its files at other paths than these instructions and the guides name.
+Wherever they say:
+- task.md, use %s
+- report.md, use %s
+- assets/, use %s/
+All paths are relative to WORKING_DIR, including local paths in the Source Registry.
+Pass these paths on to any agent you dispatch.
+`, l.TaskFile, l.ReportFile, l.AssetsDir)
+}
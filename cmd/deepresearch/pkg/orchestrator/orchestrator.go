@@ -0,0 +1,593 @@
+// Package orchestrator runs the deep-research workflow in a workspace: an
+// optional clarifier asks the user about a vague request, a planner writes the
+// research plan to task.md, research and reflection alternate until the
+// reflector is satisfied or the iterations run out, and a synthesizer writes
+// report.md, in the language the request asks for. An optional plan reviewer
+// lets the user approve the plan first, or send it back to the planner with
+// feedback. A StopPolicy may end the loop early, e.g. once it stalls; the
+// Result tells why the loop ended. An optional verifier then checks the
+// report's citations, sending broken ones back to research, and a translator
+// may translate the report into other languages.
+//
+// Agents are pluggable through AgentRunner and their instructions through
+// Prompter; Guides builds them from a directory of prompt guides such as
+// prompts/deep-research, which package prompts embeds. The deepresearch
+// command is one client of this package, adding logging, checks and artifacts
+// around the workflow through the OnEvent callback.
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// Phase is a stage of the workflow, run by one agent
+type Phase string
+
+// Phases in the order they run
+const (
+	PhaseClarifier   Phase = "CLARIFIER"
+	PhasePlanner     Phase = "PLANNER"
+	PhaseResearch    Phase = "RESEARCH-SUPERVISOR"
+	PhaseReflector   Phase = "REFLECTOR"
+	PhaseSynthesizer Phase = "SYNTHESIZER"
+	PhaseVerifier    Phase = "VERIFIER"
+	PhaseTranslator  Phase = "TRANSLATOR"
+)
+
+// Title returns the agent name of the phase, e.g. "Research-Supervisor"
+func (p Phase) Title() string {
+	switch p {
+	case PhaseClarifier:
+		return "Clarifier"
+	case PhasePlanner:
+		return "Planner"
+	case PhaseResearch:
+		return "Research-Supervisor"
+	case PhaseReflector:
+		return "Reflector"
+	case PhaseSynthesizer:
+		return "Synthesizer"
+	case PhaseVerifier:
+		return "Verifier"
+	case PhaseTranslator:
+		return "Translator"
+	}
+	return string(p)
+}
+
+// DefaultMaxIterations bounds the research/reflection loop when the request does not
+const DefaultMaxIterations = 10
+
+// Step is one agent run of the workflow, or a round of the verifier
+type Step struct {
+	Phase Phase
+	// Iteration is the research iteration, 0 for the clarifier, the planner
+	// and the synthesizer; for the verifier it counts its rounds from 1, for
+	// the translator the languages of Request.Translations
+	Iteration int
+	Language  string // the language a translator step translates into
+}
+
+// AgentRunner runs the agent of a step in the workspace and returns once it exits
+type AgentRunner interface {
+	RunAgent(ctx context.Context, step Step, prompt string) error
+}
+
+// AgentRunnerFunc adapts a function to AgentRunner
+type AgentRunnerFunc func(ctx context.Context, step Step, prompt string) error
+
+func (f AgentRunnerFunc) RunAgent(ctx context.Context, step Step, prompt string) error {
+	return f(ctx, step, prompt)
+}
+
+// Prompter builds the instructions of a step
+type Prompter interface {
+	Prompt(step Step, req Request) (string, error)
+}
+
+// Verifier checks the citations of report.md after synthesis. It runs in the
+// orchestrator's process rather than as an agent.
+type Verifier interface {
+	// Verify checks report.md. When resource is set it may add tasks to
+	// task.md that replace broken sources and return true, asking for another
+	// research step and synthesis; otherwise it annotates report.md with
+	// what it found.
+	Verify(ctx context.Context, step Step, resource bool) (bool, error)
+}
+
+// PlanReviewer lets the user review the plan the planner wrote before research
+// starts from it. It runs in the orchestrator's process rather than as an agent.
+type PlanReviewer interface {
+	// ReviewPlan shows the user task.md, which they may edit, and returns
+	// their feedback for the planner to revise the plan with, or "" once they
+	// approve it. req.PlanFeedback holds the feedback on earlier drafts.
+	ReviewPlan(ctx context.Context, req Request) (string, error)
+}
+
+// Request describes a research run
+type Request struct {
+	Topic   string // the user's research request
+	WorkDir string // workspace holding task.md, assets/ and report.md; created if missing
+	// Layout places task.md, report.md and assets/ elsewhere in WorkDir
+	// (default: at those paths); agents are told where they are
+	Layout Layout
+	// MaxIterations bounds the research/reflection loop (default DefaultMaxIterations)
+	MaxIterations int
+	// Stop ends the research/reflection loop before MaxIterations, e.g. once
+	// it makes no progress (default: only the reflector and MaxIterations end it)
+	Stop StopPolicy
+	// Interactive has the planner discuss the plan with the user before writing
+	// task.md; the runner connects the planner to a terminal
+	Interactive bool
+	// PlanFeedback is the user's feedback on earlier drafts of task.md, oldest
+	// first, which the planner revises it for; Run adds what the
+	// Orchestrator's PlanReviewer returns
+	PlanFeedback []string
+	// ReportLanguage is the language the synthesizer writes report.md in, an
+	// ISO 639-1 code such as "ja" (default: the request's)
+	ReportLanguage string
+	// Translations are the languages the translator translates report.md into
+	// after synthesis, each to its Layout.TranslationFile, keeping the
+	// citations and the assets it shows
+	Translations []string
+	// Clarifications are the user's answers to the clarifier's questions, which
+	// the planner plans for. Run sets them with the Orchestrator's Clarifier,
+	// which it skips when they were answered before, e.g. by an interrupted run.
+	Clarifications *Clarifications
+	// From continues a workspace at a step, taking the steps before it as done.
+	// The zero value starts with the clarifier, if any, or the planner. For the
+	// synthesizer, the verifier and the translator, From.Iteration is the
+	// number of research iterations run.
+	From Step
+	// VerifyRounds bounds how often the Verifier may send broken citations
+	// back to research; 0 only annotates the report
+	VerifyRounds int
+	// PlanOnly stops the run once the planner has written task.md, e.g. to
+	// review the plan before research starts from it
+	PlanOnly bool
+	// Handoffs asks agents for a HandoffFile and checks it after each step
+	// (default HandoffOff)
+	Handoffs HandoffMode
+}
+
+// Result describes a completed run
+type Result struct {
+	TaskFile   string          // path of task.md, or where Request.Layout put it
+	Report     string          // path of report.md, or where Request.Layout put it
+	Iterations int             // research iterations run, counting those before Request.From
+	Status     taskfile.Status // status in task.md after synthesis
+	// Exhausted is set when the iterations ran out while the reflector still
+	// asked for more research
+	Exhausted bool
+	// Loop tells why the research/reflection loop ended; nil when the run
+	// started after it (Request.From) or stopped before it (Request.PlanOnly)
+	Loop *LoopEnd
+	// Translations are the paths of the translated reports, in the order of
+	// Request.Translations
+	Translations []string
+}
+
+// EventKind tells what an Event reports
+type EventKind int
+
+// Events of a run
+const (
+	StepStarted         EventKind = iota // a step is about to run
+	StepCompleted                        // a step succeeded and produced its output
+	StepFailed                           // a step failed; Run returns its StepError
+	ResearchSufficient                   // the reflector is satisfied, synthesis follows
+	ResearchContinues                    // the reflector asked for more research and the StopPolicy lets the loop go on
+	IterationsExhausted                  // more research was asked for, but no iterations are left
+	ResearchStopped                      // Continue ended the research loop before the step, synthesis follows
+	CitationsResourced                   // the verifier added tasks for broken citations, research and synthesis follow
+	LoopEnded                            // the research/reflection loop ended, for the reason Event.Loop tells
+	PlanApproved                         // the PlanReviewer approved the plan, research follows
+	PlanSentBack                         // the PlanReviewer sent the plan back with Event.Feedback, the planner runs again
+	QuestionsAnswered                    // the Clarifier answered the clarifier's questions in Event.Clarifications, planning follows
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case StepStarted:
+		return "step-started"
+	case StepCompleted:
+		return "step-completed"
+	case StepFailed:
+		return "step-failed"
+	case ResearchSufficient:
+		return "research-sufficient"
+	case ResearchContinues:
+		return "research-continues"
+	case IterationsExhausted:
+		return "iterations-exhausted"
+	case ResearchStopped:
+		return "research-stopped"
+	case CitationsResourced:
+		return "citations-resourced"
+	case LoopEnded:
+		return "loop-ended"
+	case PlanApproved:
+		return "plan-approved"
+	case PlanSentBack:
+		return "plan-sent-back"
+	case QuestionsAnswered:
+		return "questions-answered"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event reports the progress of a run
+type Event struct {
+	Kind     EventKind
+	Step     Step
+	Duration time.Duration // of the step, on StepCompleted and StepFailed
+	Err      error         // on StepFailed
+	// Handoff is what the agent handed off, on StepCompleted; nil if it wrote none
+	Handoff *Handoff
+	// Reflection is what the iteration achieved, on ResearchSufficient and ResearchContinues
+	Reflection *Reflection
+	// Loop is why the research/reflection loop ended, on LoopEnded
+	Loop *LoopEnd
+	// Feedback is what the user asked the planner to change, on PlanSentBack
+	Feedback string
+	// Clarifications are the clarifier's questions and the user's answers, on QuestionsAnswered
+	Clarifications *Clarifications
+}
+
+// StepError is the error of a failed step
+type StepError struct {
+	Step Step
+	Err  error
+}
+
+func (e *StepError) Error() string { return fmt.Sprintf("%s failed: %v", e.Step.Phase.Title(), e.Err) }
+func (e *StepError) Unwrap() error { return e.Err }
+
+// MissingArtifactError reports a step whose agent exited without writing its output
+type MissingArtifactError struct {
+	Step Step
+	File string // relative to the workspace
+}
+
+func (e *MissingArtifactError) Error() string {
+	return fmt.Sprintf("%s did not create %s", e.Step.Phase.Title(), e.File)
+}
+
+// Orchestrator runs research requests with an agent runner and prompter
+type Orchestrator struct {
+	Runner  AgentRunner
+	Prompts Prompter
+	// OnEvent, if set, is called with each event on the goroutine running Run.
+	// Callers hook their own work in here, e.g. checking task.md once a step completes.
+	OnEvent func(Event)
+	// Continue, if set, is asked before each research and reflection step
+	// whether the research loop may go on; false skips to the synthesizer,
+	// which reports the findings so far. Callers end the loop early this way,
+	// e.g. once a spending limit is reached. Before a verifier round it is
+	// asked whether broken citations may be researched again.
+	Continue func(Step) bool
+	// Verifier, if set, checks report.md after synthesis
+	Verifier Verifier
+	// Reviewer, if set, reviews the plan after the planner, which runs again
+	// with the user's feedback until they approve it
+	Reviewer PlanReviewer
+	// Clarifier, if set, has the clarifier ask about the request before the
+	// planner runs, and answers its questions
+	Clarifier Clarifier
+}
+
+// New returns an orchestrator running agents with runner and instructing them with prompts
+func New(runner AgentRunner, prompts Prompter) *Orchestrator {
+	return &Orchestrator{Runner: runner, Prompts: prompts}
+}
+
+// assetDirs are created in the assets directory before the planner runs, and
+// logs/ next to it; agents are told they exist
+var assetDirs = []string{"web", "pdf", "images", "audio", "ebook"}
+
+// Run runs the workflow for req. A failed step stops the run with a
+// *StepError; cancelling ctx stops it before the next step.
+func (o *Orchestrator) Run(ctx context.Context, req Request) (Result, error) {
+	if o.Runner == nil || o.Prompts == nil {
+		return Result{}, errors.New("orchestrator: Runner and Prompts are required")
+	}
+	if req.WorkDir == "" {
+		return Result{}, errors.New("orchestrator: Request.WorkDir is required")
+	}
+	workDir, err := filepath.Abs(req.WorkDir)
+	if err != nil {
+		return Result{}, err
+	}
+	req.WorkDir = workDir
+	if req.MaxIterations <= 0 {
+		req.MaxIterations = DefaultMaxIterations
+	}
+	from := req.From
+	if from.Phase == "" {
+		from.Phase = PhasePlanner
+	}
+	if from.Phase == PhasePlanner && req.Topic == "" {
+		return Result{}, errors.New("orchestrator: Request.Topic is required to plan the research")
+	}
+	if err := req.Layout.Check(); err != nil {
+		return Result{}, fmt.Errorf("orchestrator: Request.Layout: %w", err)
+	}
+	if req.ReportLanguage != "" {
+		if err := CheckLanguage(req.ReportLanguage); err != nil {
+			return Result{}, fmt.Errorf("orchestrator: Request.ReportLanguage: %w", err)
+		}
+	}
+	for _, lang := range req.Translations {
+		if err := CheckLanguage(lang); err != nil {
+			return Result{}, fmt.Errorf("orchestrator: Request.Translations: %w", err)
+		}
+	}
+	req.Layout = req.Layout.WithDefaults()
+	dirs := []string{filepath.Join(workDir, "logs")}
+	for _, dir := range assetDirs {
+		dirs = append(dirs, filepath.Join(workDir, filepath.FromSlash(req.Layout.AssetsDir), dir))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return Result{}, err
+		}
+	}
+
+	res := Result{
+		TaskFile: filepath.Join(workDir, filepath.FromSlash(req.Layout.TaskFile)),
+		Report:   filepath.Join(workDir, filepath.FromSlash(req.Layout.ReportFile)),
+	}
+	if from.Phase == PhasePlanner {
+		if o.Clarifier != nil && !req.Clarifications.Answered() {
+			if err := o.clarify(ctx, &req); err != nil {
+				return res, err
+			}
+		}
+		if err := o.plan(ctx, &req); err != nil {
+			return res, err
+		}
+	}
+	if req.PlanOnly {
+		return res, nil
+	}
+
+	if from.Phase == PhaseSynthesizer || from.Phase == PhaseVerifier || from.Phase == PhaseTranslator {
+		res.Iterations = from.Iteration
+	} else {
+		first, supervised := 1, false
+		switch from.Phase {
+		case PhaseResearch:
+			first = max(from.Iteration, 1)
+		case PhaseReflector:
+			first, supervised = max(from.Iteration, 1), true
+		}
+		res.Iterations = first - 1
+		start := time.Now()
+		var reflections []Reflection
+		end := func(reason StopReason, detail string) {
+			res.Loop = &LoopEnd{Reason: reason, Iteration: res.Iterations, Detail: detail, Reflections: reflections}
+		}
+		for iteration := first; iteration <= req.MaxIterations; iteration++ {
+			if ran := time.Since(start); iteration > first && req.Stop.MaxDuration > 0 && ran >= req.Stop.MaxDuration {
+				end(StopTimeLimit, fmt.Sprintf("the research loop ran %s, reaching its limit of %s", ran.Round(time.Second), req.Stop.MaxDuration))
+				break
+			}
+			research := !supervised || iteration != first
+			if research && o.stopped(Step{Phase: PhaseResearch, Iteration: iteration}) {
+				end(StopContinue, "the research loop was stopped before research")
+				break
+			}
+			res.Iterations = iteration
+			before := takeSnapshot(res.TaskFile)
+			if research {
+				if _, err := o.runStep(ctx, req, Step{Phase: PhaseResearch, Iteration: iteration}); err != nil {
+					return res, err
+				}
+			}
+			step := Step{Phase: PhaseReflector, Iteration: iteration}
+			if o.stopped(step) {
+				end(StopContinue, "the research loop was stopped before reflection")
+				break
+			}
+			handoff, err := o.runStep(ctx, req, step)
+			if err != nil {
+				return res, err
+			}
+			f, err := taskfile.Read(res.TaskFile)
+			if err != nil {
+				return res, &StepError{Step: step, Err: err}
+			}
+			r := reflect(iteration, f, before, handoff)
+			reflections = append(reflections, r)
+			if !r.MoreResearch {
+				end(StopSufficient, "the reflector asked for no more research")
+				o.emit(Event{Kind: ResearchSufficient, Step: step, Reflection: &r})
+				break
+			}
+			if reason, detail := req.Stop.stop(reflections); reason != "" {
+				end(reason, detail)
+				break
+			}
+			o.emit(Event{Kind: ResearchContinues, Step: step, Reflection: &r})
+			if iteration == req.MaxIterations {
+				res.Exhausted = true
+				end(StopMaxIterations, fmt.Sprintf("all %d iteration(s) ran while the reflector still asked for more research", req.MaxIterations))
+				o.emit(Event{Kind: IterationsExhausted, Step: step})
+			}
+		}
+		if res.Loop == nil {
+			// Continued past the last iteration
+			end(StopMaxIterations, fmt.Sprintf("all %d iteration(s) had run", req.MaxIterations))
+		}
+		o.emit(Event{Kind: LoopEnded, Step: Step{Phase: PhaseReflector, Iteration: res.Loop.Iteration}, Loop: res.Loop})
+	}
+
+	if from.Phase != PhaseVerifier && from.Phase != PhaseTranslator {
+		if _, err := o.runStep(ctx, req, Step{Phase: PhaseSynthesizer}); err != nil {
+			return res, err
+		}
+	}
+	if o.Verifier != nil && from.Phase != PhaseTranslator {
+		if err := o.verify(ctx, req, &res); err != nil {
+			return res, err
+		}
+	}
+	for i, lang := range req.Translations {
+		if _, err := o.runStep(ctx, req, Step{Phase: PhaseTranslator, Iteration: i + 1, Language: lang}); err != nil {
+			return res, err
+		}
+		res.Translations = append(res.Translations, filepath.Join(workDir, filepath.FromSlash(req.Layout.TranslationFile(lang))))
+	}
+	if f, err := taskfile.Read(res.TaskFile); err == nil {
+		res.Status = f.Status
+	}
+	return res, nil
+}
+
+// plan runs the planner and, with a Reviewer, has the user review its plan,
+// running the planner again with their feedback until they approve
+func (o *Orchestrator) plan(ctx context.Context, req *Request) error {
+	step := Step{Phase: PhasePlanner}
+	for {
+		if _, err := o.runStep(ctx, *req, step); err != nil {
+			return err
+		}
+		if o.Reviewer == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return &StepError{Step: step, Err: context.Cause(ctx)}
+		}
+		feedback, err := o.Reviewer.ReviewPlan(ctx, *req)
+		if err != nil {
+			return &StepError{Step: step, Err: err}
+		}
+		if feedback == "" {
+			o.emit(Event{Kind: PlanApproved, Step: step})
+			return nil
+		}
+		req.PlanFeedback = append(req.PlanFeedback, feedback)
+		o.emit(Event{Kind: PlanSentBack, Step: step, Feedback: feedback})
+	}
+}
+
+// verify runs verifier rounds after synthesis. While rounds are left and the
+// verifier re-sources broken citations, another research step and synthesis
+// follow each round.
+func (o *Orchestrator) verify(ctx context.Context, req Request, res *Result) error {
+	for round := 1; ; round++ {
+		step := Step{Phase: PhaseVerifier, Iteration: round}
+		research := Step{Phase: PhaseResearch, Iteration: res.Iterations + 1}
+		resource := round <= req.VerifyRounds && (o.Continue == nil || o.Continue(research))
+		more := false
+		err := o.track(ctx, step, func() (err error) {
+			more, err = o.Verifier.Verify(ctx, step, resource)
+			return err
+		})
+		if err != nil || !more {
+			return err
+		}
+		o.emit(Event{Kind: CitationsResourced, Step: step})
+		res.Iterations = research.Iteration
+		if _, err := o.runStep(ctx, req, research); err != nil {
+			return err
+		}
+		if _, err := o.runStep(ctx, req, Step{Phase: PhaseSynthesizer}); err != nil {
+			return err
+		}
+	}
+}
+
+// runStep runs the agent of a step and checks that it wrote the phase's
+// artifact and, unless handoffs are off, the handoff it wrote, which it
+// returns (nil if there is none)
+func (o *Orchestrator) runStep(ctx context.Context, req Request, step Step) (*Handoff, error) {
+	artifact := req.Layout.artifactOf(step)
+	var handoff *Handoff
+	err := o.trackHandoff(ctx, step, &handoff, func() error {
+		// A handoff left by an earlier, interrupted step must not pass for this one's
+		if req.Handoffs != HandoffOff {
+			os.Remove(filepath.Join(req.WorkDir, HandoffFile))
+		}
+		prompt, err := o.Prompts.Prompt(step, req)
+		if err == nil {
+			err = o.Runner.RunAgent(ctx, step, prompt)
+		}
+		if err == nil && req.Handoffs != HandoffOff {
+			// Checked first: a failed agent may rightly have written no artifact
+			handoff, err = o.checkHandoff(req, step)
+		}
+		if err == nil {
+			if _, statErr := os.Stat(filepath.Join(req.WorkDir, filepath.FromSlash(artifact))); statErr != nil {
+				err = &MissingArtifactError{Step: step, File: artifact}
+			}
+		}
+		return err
+	})
+	return handoff, err
+}
+
+// checkHandoff reads the handoff of a step and archives it once it passed
+func (o *Orchestrator) checkHandoff(req Request, step Step) (*Handoff, error) {
+	h, err := ReadHandoff(req.WorkDir, step)
+	switch {
+	case err != nil:
+		return nil, err
+	case h == nil:
+		if req.Handoffs == HandoffRequire {
+			return nil, &HandoffError{Step: step, File: HandoffFile}
+		}
+		return nil, nil
+	case h.Status == HandoffFailed:
+		return h, &AgentReportedError{Step: step, Reason: h.Error}
+	}
+	return h, archiveHandoff(req.WorkDir, step)
+}
+
+// track runs the work of a step between its StepStarted event and its
+// StepCompleted or StepFailed event
+func (o *Orchestrator) track(ctx context.Context, step Step, work func() error) error {
+	return o.trackHandoff(ctx, step, nil, work)
+}
+
+// trackHandoff is track for work that sets the step's handoff, which
+// StepCompleted carries
+func (o *Orchestrator) trackHandoff(ctx context.Context, step Step, handoff **Handoff, work func() error) error {
+	if ctx.Err() != nil {
+		return &StepError{Step: step, Err: context.Cause(ctx)}
+	}
+	o.emit(Event{Kind: StepStarted, Step: step})
+	start := time.Now()
+	if err := work(); err != nil {
+		o.emit(Event{Kind: StepFailed, Step: step, Duration: time.Since(start), Err: err})
+		return &StepError{Step: step, Err: err}
+	}
+	ev := Event{Kind: StepCompleted, Step: step, Duration: time.Since(start)}
+	if handoff != nil {
+		ev.Handoff = *handoff
+	}
+	o.emit(ev)
+	return nil
+}
+
+// stopped asks Continue whether step may run, reporting a stop
+func (o *Orchestrator) stopped(step Step) bool {
+	if o.Continue == nil || o.Continue(step) {
+		return false
+	}
+	o.emit(Event{Kind: ResearchStopped, Step: step})
+	return true
+}
+
+func (o *Orchestrator) emit(ev Event) {
+	if o.OnEvent != nil {
+		o.OnEvent(ev)
+	}
+}
@@ -0,0 +1,326 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// plannedTasks is the task.md the fake planner writes
+const plannedTasks = `# Heat pumps
+
+## Metadata
+- Status: RESEARCHING
+
+## Research DAG
+- [ ] E1: Adoption rates | Priority: HIGH
+`
+
+// reflected is a task.md the fake reflector leaves, with status and any
+// further metadata lines
+func reflected(status taskfile.Status, meta ...string) string {
+	s := "# Heat pumps\n\n## Metadata\n- Status: " + string(status) + "\n"
+	for _, m := range meta {
+		s += m + "\n"
+	}
+	return s + "\n## Research DAG\n- [x] E1: Adoption rates | Priority: HIGH\n"
+}
+
+// fakePrompter names the step it instructs
+type fakePrompter struct{}
+
+func (fakePrompter) Prompt(step Step, _ Request) (string, error) {
+	return fmt.Sprintf("%s %d %s", step.Phase, step.Iteration, step.Language), nil
+}
+
+// fakeAgents writes what each phase's agent would and records the steps it ran
+type fakeAgents struct {
+	t     *testing.T
+	dir   string
+	steps []Step
+	// reflect is the task.md the reflector of an iteration leaves
+	// (default: research is sufficient)
+	reflect func(iteration int) string
+	// skip is a phase whose agent exits without writing its artifact
+	skip Phase
+	// before, if set, runs first in every step; a non-nil error fails it
+	before func(ctx context.Context, step Step) error
+}
+
+func (a *fakeAgents) write(name, content string) {
+	a.t.Helper()
+	if err := os.WriteFile(filepath.Join(a.dir, name), []byte(content), 0644); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+func (a *fakeAgents) RunAgent(ctx context.Context, step Step, prompt string) error {
+	a.steps = append(a.steps, step)
+	if want, _ := (fakePrompter{}).Prompt(step, Request{}); prompt != want {
+		a.t.Errorf("%s got prompt %q, want %q", step.Phase, prompt, want)
+	}
+	if a.before != nil {
+		if err := a.before(ctx, step); err != nil {
+			return err
+		}
+	}
+	if step.Phase == a.skip {
+		return nil
+	}
+	switch step.Phase {
+	case PhasePlanner:
+		a.write("task.md", plannedTasks)
+	case PhaseResearch:
+		f, err := taskfile.Read(filepath.Join(a.dir, "task.md"))
+		if err != nil {
+			return err
+		}
+		for _, t := range f.Pending() {
+			f.MarkDone(t.ID)
+		}
+		return f.Write(filepath.Join(a.dir, "task.md"))
+	case PhaseReflector:
+		if a.reflect == nil {
+			a.write("task.md", reflected(taskfile.StatusSynthesizing))
+		} else {
+			a.write("task.md", a.reflect(step.Iteration))
+		}
+	case PhaseSynthesizer:
+		a.write("report.md", "# Report\n")
+	case PhaseTranslator:
+		a.write(Layout{}.TranslationFile(step.Language), "# Report\n")
+	}
+	return nil
+}
+
+// run runs req in a fresh workspace with agents, returning the result and the steps run
+func run(t *testing.T, ctx context.Context, agents *fakeAgents, req Request) (Result, []Step, error) {
+	t.Helper()
+	if agents.dir == "" {
+		agents.dir = t.TempDir()
+	}
+	agents.t = t
+	req.WorkDir = agents.dir
+	if req.Topic == "" {
+		req.Topic = "Heat pump adoption"
+	}
+	res, err := New(agents, fakePrompter{}).Run(ctx, req)
+	return res, agents.steps, err
+}
+
+func checkSteps(t *testing.T, got, want []Step) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("steps:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestRunStepOrder(t *testing.T) {
+	agents := &fakeAgents{reflect: func(iteration int) string {
+		if iteration == 1 {
+			return reflected(taskfile.StatusResearching)
+		}
+		return reflected(taskfile.StatusSynthesizing)
+	}}
+	var events []EventKind
+	o := New(agents, fakePrompter{})
+	o.OnEvent = func(ev Event) { events = append(events, ev.Kind) }
+	agents.t, agents.dir = t, t.TempDir()
+	res, err := o.Run(context.Background(), Request{Topic: "Heat pump adoption", WorkDir: agents.dir, Translations: []string{"ja"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, agents.steps, []Step{
+		{Phase: PhasePlanner},
+		{Phase: PhaseResearch, Iteration: 1},
+		{Phase: PhaseReflector, Iteration: 1},
+		{Phase: PhaseResearch, Iteration: 2},
+		{Phase: PhaseReflector, Iteration: 2},
+		{Phase: PhaseSynthesizer},
+		{Phase: PhaseTranslator, Iteration: 1, Language: "ja"},
+	})
+	if res.Iterations != 2 || res.Loop == nil || res.Loop.Reason != StopSufficient || res.Status != taskfile.StatusSynthesizing {
+		t.Errorf("result = %+v, want 2 iterations ended as sufficient", res)
+	}
+	if want := filepath.Join(agents.dir, "report.ja.md"); !slices.Equal(res.Translations, []string{want}) {
+		t.Errorf("translations = %v, want %s", res.Translations, want)
+	}
+	for _, kind := range []EventKind{ResearchContinues, ResearchSufficient, LoopEnded} {
+		if !slices.Contains(events, kind) {
+			t.Errorf("no %s event in %v", kind, events)
+		}
+	}
+}
+
+func TestRunStopPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        Request
+		reflect    func(iteration int) string
+		iterations int
+		reason     StopReason
+		exhausted  bool
+	}{
+		{
+			name:       "max iterations",
+			req:        Request{MaxIterations: 3},
+			reflect:    func(int) string { return reflected(taskfile.StatusResearching) },
+			iterations: 3,
+			reason:     StopMaxIterations,
+			exhausted:  true,
+		},
+		{
+			name:       "reflector sufficient",
+			req:        Request{MaxIterations: 3},
+			reflect:    func(int) string { return reflected(taskfile.StatusSynthesizing) },
+			iterations: 1,
+			reason:     StopSufficient,
+		},
+		{
+			name: "confidence",
+			req:  Request{MaxIterations: 5, Stop: StopPolicy{Confidence: 0.8}},
+			reflect: func(iteration int) string {
+				return reflected(taskfile.StatusResearching, fmt.Sprintf("- Confidence: %d%%", 50+20*iteration))
+			},
+			iterations: 2,
+			reason:     StopConfidence,
+		},
+		{
+			name: "stalled",
+			req:  Request{MaxIterations: 5, Stop: StopPolicy{Stalled: 2}},
+			reflect: func(iteration int) string {
+				return reflected(taskfile.StatusResearching, "- Open questions: 3")
+			},
+			iterations: 2,
+			reason:     StopStalled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, steps, err := run(t, context.Background(), &fakeAgents{reflect: tt.reflect}, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Iterations != tt.iterations || res.Loop == nil || res.Loop.Reason != tt.reason || res.Exhausted != tt.exhausted {
+				t.Errorf("result = %+v, loop %+v; want %d iteration(s) ended as %s", res, res.Loop, tt.iterations, tt.reason)
+			}
+			if last := steps[len(steps)-1]; last.Phase != PhaseSynthesizer {
+				t.Errorf("last step %v, want the synthesizer", last)
+			}
+		})
+	}
+}
+
+func TestRunFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       Step
+		steps      []Step
+		iterations int
+	}{
+		{
+			name: "research",
+			from: Step{Phase: PhaseResearch, Iteration: 2},
+			steps: []Step{
+				{Phase: PhaseResearch, Iteration: 2},
+				{Phase: PhaseReflector, Iteration: 2},
+				{Phase: PhaseSynthesizer},
+			},
+			iterations: 2,
+		},
+		{
+			name: "reflector",
+			from: Step{Phase: PhaseReflector, Iteration: 3},
+			steps: []Step{
+				{Phase: PhaseReflector, Iteration: 3},
+				{Phase: PhaseSynthesizer},
+			},
+			iterations: 3,
+		},
+		{
+			name:       "synthesizer",
+			from:       Step{Phase: PhaseSynthesizer, Iteration: 4},
+			steps:      []Step{{Phase: PhaseSynthesizer}},
+			iterations: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents := &fakeAgents{dir: t.TempDir()}
+			agents.t = t
+			agents.write("task.md", plannedTasks)
+			res, steps, err := run(t, context.Background(), agents, Request{From: tt.from})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSteps(t, steps, tt.steps)
+			if res.Iterations != tt.iterations {
+				t.Errorf("iterations = %d, want %d", res.Iterations, tt.iterations)
+			}
+			if (res.Loop == nil) != (tt.from.Phase == PhaseSynthesizer) {
+				t.Errorf("loop = %+v, want one only when the loop ran", res.Loop)
+			}
+		})
+	}
+}
+
+func TestRunMissingTaskFile(t *testing.T) {
+	_, steps, err := run(t, context.Background(), &fakeAgents{skip: PhasePlanner}, Request{})
+	var missing *MissingArtifactError
+	if !errors.As(err, &missing) || missing.File != "task.md" || missing.Step.Phase != PhasePlanner {
+		t.Fatalf("err = %v, want the planner's missing task.md", err)
+	}
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step.Phase != PhasePlanner {
+		t.Errorf("err = %v, want a StepError of the planner", err)
+	}
+	checkSteps(t, steps, []Step{{Phase: PhasePlanner}})
+}
+
+func TestRunCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		// exit is what the research agent returns once ctx is cancelled
+		exit   func(ctx context.Context) error
+		failed Step // the step Run reports
+	}{
+		{
+			name:   "agent killed",
+			exit:   func(ctx context.Context) error { return ctx.Err() },
+			failed: Step{Phase: PhaseResearch, Iteration: 1},
+		},
+		{
+			name:   "agent finished",
+			exit:   func(context.Context) error { return nil },
+			failed: Step{Phase: PhaseReflector, Iteration: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			agents := &fakeAgents{before: func(ctx context.Context, step Step) error {
+				if step.Phase != PhaseResearch {
+					return nil
+				}
+				cancel()
+				return tt.exit(ctx)
+			}}
+			_, steps, err := run(t, ctx, agents, Request{})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+			var stepErr *StepError
+			if !errors.As(err, &stepErr) || stepErr.Step != tt.failed {
+				t.Errorf("err = %v, want a StepError of %v", err, tt.failed)
+			}
+			checkSteps(t, steps, []Step{{Phase: PhasePlanner}, {Phase: PhaseResearch, Iteration: 1}})
+		})
+	}
+}
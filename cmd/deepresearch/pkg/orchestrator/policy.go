@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// StopPolicy ends the research/reflection loop before Request.MaxIterations
+// while the reflector still asks for more research. The zero value never does.
+type StopPolicy struct {
+	// Confidence ends the loop once the reflector records at least this
+	// confidence, from 0 to 1 (0 = off)
+	Confidence float64
+	// Stalled ends the loop after this many iterations in a row without
+	// progress: no new findings, no rise in the reflector's confidence and no
+	// fewer open questions (0 = off)
+	Stalled int
+	// MaxDuration ends the loop before the next iteration once it has run
+	// this long (0 = off)
+	MaxDuration time.Duration
+}
+
+// StopReason tells why the research/reflection loop ended
+type StopReason string
+
+// Reasons the loop ends for
+const (
+	StopSufficient    StopReason = "sufficient"     // the reflector asked for no more research
+	StopConfidence    StopReason = "confidence"     // StopPolicy.Confidence was reached
+	StopStalled       StopReason = "stalled"        // StopPolicy.Stalled iterations made no progress
+	StopTimeLimit     StopReason = "time-limit"     // StopPolicy.MaxDuration was reached
+	StopMaxIterations StopReason = "max-iterations" // Request.MaxIterations ran out
+	StopContinue      StopReason = "stopped"        // Continue ended the loop
+)
+
+// Reflection is what an iteration of the loop achieved, as task.md and the
+// reflector's handoff tell after its reflection step
+type Reflection struct {
+	Iteration      int    `json:"iteration"`
+	Recommendation string `json:"recommendation,omitempty"`
+	MoreResearch   bool   `json:"more_research"` // the reflector asked for another iteration
+	// Confidence is the reflector's, from task.md or else its handoff; nil if it gave none
+	Confidence    *float64 `json:"confidence,omitempty"`
+	OpenQuestions int      `json:"open_questions"` // -1 if the reflector did not count them
+	NewTasks      int      `json:"new_tasks"`      // tasks added during the iteration
+	NewFindings   int      `json:"new_findings"`   // Knowledge Graph findings added during the iteration
+	Pending       int      `json:"pending"`        // tasks left pending
+}
+
+// progressed reports whether r moved the research on from prev, the
+// reflection of the iteration before (nil for the first)
+func (r Reflection) progressed(prev *Reflection) bool {
+	if r.NewFindings > 0 {
+		return true
+	}
+	if prev == nil {
+		return false
+	}
+	if r.Confidence != nil && prev.Confidence != nil && *r.Confidence > *prev.Confidence {
+		return true
+	}
+	return r.OpenQuestions >= 0 && prev.OpenQuestions >= 0 && r.OpenQuestions < prev.OpenQuestions
+}
+
+// LoopEnd records why and after which iteration the research/reflection loop ended
+type LoopEnd struct {
+	Reason    StopReason `json:"reason"`
+	Iteration int        `json:"iteration"` // last iteration run
+	Detail    string     `json:"detail"`
+	// Reflections are the iterations this run reflected on, in order
+	Reflections []Reflection `json:"reflections,omitempty"`
+}
+
+// snapshot is what task.md held when an iteration started
+type snapshot struct {
+	tasks, findings map[string]bool
+}
+
+// takeSnapshot records the tasks and findings of task.md; a missing or
+// unreadable file counts as empty
+func takeSnapshot(taskFile string) snapshot {
+	s := snapshot{tasks: map[string]bool{}, findings: map[string]bool{}}
+	if f, err := taskfile.Read(taskFile); err == nil {
+		for _, t := range f.Tasks {
+			s.tasks[t.ID] = true
+		}
+		for _, fd := range f.Findings {
+			s.findings[fd.ID] = true
+		}
+	}
+	return s
+}
+
+// reflect builds the reflection of an iteration from task.md after its
+// reflection step, before the iteration started and the reflector's handoff
+func reflect(iteration int, f *taskfile.File, before snapshot, handoff *Handoff) Reflection {
+	r := Reflection{
+		Iteration:      iteration,
+		Recommendation: f.Recommendation,
+		MoreResearch:   f.NeedsMoreResearch(),
+		Confidence:     f.Confidence,
+		OpenQuestions:  f.OpenQuestions,
+		Pending:        len(f.Pending()),
+	}
+	if r.Confidence == nil && handoff != nil {
+		r.Confidence = handoff.Confidence
+	}
+	tasks, findings := map[string]bool{}, map[string]bool{}
+	for _, t := range f.Tasks {
+		if !before.tasks[t.ID] && !tasks[t.ID] {
+			tasks[t.ID] = true
+			r.NewTasks++
+		}
+	}
+	for _, fd := range f.Findings {
+		if !before.findings[fd.ID] && !findings[fd.ID] {
+			findings[fd.ID] = true
+			r.NewFindings++
+		}
+	}
+	return r
+}
+
+// stop applies p to the reflections so far, the latest last, returning why
+// the loop ends or "" if it goes on
+func (p StopPolicy) stop(reflections []Reflection) (StopReason, string) {
+	r := reflections[len(reflections)-1]
+	if p.Confidence > 0 && r.Confidence != nil && *r.Confidence >= p.Confidence {
+		return StopConfidence, fmt.Sprintf("the reflector's confidence %.2f reached %.2f", *r.Confidence, p.Confidence)
+	}
+	if p.Stalled > 0 && len(reflections) >= p.Stalled {
+		stalled := 0
+		for i := len(reflections) - 1; i >= 0 && stalled < p.Stalled; i-- {
+			var prev *Reflection
+			if i > 0 {
+				prev = &reflections[i-1]
+			}
+			if reflections[i].progressed(prev) {
+				break
+			}
+			stalled++
+		}
+		if stalled == p.Stalled {
+			return StopStalled, fmt.Sprintf("%d iteration(s) in a row added no findings and neither raised the reflector's confidence nor closed open questions", stalled)
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Guides builds the instructions of each step from the prompt guides in Dir
+// (clarifier.md, planner.md, research-supervisor.md, reflector.md,
+// synthesizer.md, report-translator.md), which the agent reads itself
+type Guides struct {
+	Dir string
+}
+
+// Prompt returns the instructions of a step
+func (g Guides) Prompt(step Step, req Request) (string, error) {
+	var prompt string
+	switch step.Phase {
+	case PhaseClarifier:
+		prompt = ClarifierPrompt(g.Dir, req.WorkDir, req.Topic)
+	case PhasePlanner:
+		prompt = PlannerPrompt(g.Dir, req.WorkDir, req.Topic, !req.Interactive) + ClarificationsNote(req.Clarifications) + PlanFeedbackNote(req.PlanFeedback)
+	case PhaseResearch:
+		prompt = SupervisorPrompt(g.Dir, req.WorkDir)
+	case PhaseReflector:
+		prompt = ReflectorPrompt(g.Dir, req.WorkDir)
+	case PhaseSynthesizer:
+		prompt = SynthesizerPrompt(g.Dir, req.WorkDir, req.Topic) + ReportLanguageNote(req.ReportLanguage)
+	case PhaseTranslator:
+		prompt = TranslatorPrompt(g.Dir, req.WorkDir, req.Layout, step.Language)
+	default:
+		return "", fmt.Errorf("no guide for phase %s", step.Phase)
+	}
+	return prompt + LayoutNote(req.Layout) + HandoffNote(g.Dir, step, req.Handoffs, req.Layout), nil
+}
+
+// ClarifierPrompt instructs the clarifier to write the questions it would ask
+// about request before planning to ClarificationsFile
+func ClarifierPrompt(guidesDir, workDir, request string) string {
+	clarifierFile := filepath.Join(guidesDir, "clarifier.md")
+	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+USER_REQUEST: %s
+
+TASK: Find what the user request leaves open that would change the research plan: scope, audience,
+time range, regions, depth, the decision it informs. Write 2 to %d clarifying questions to
+%s, or none if the request is already specific.
+OUTPUT: %s in WORKING_DIR, e.g. {"questions": ["Which countries should the comparison cover?"]}
+
+IMPORTANT:
+- Do NOT ask the user yourself; the orchestrator asks your questions and gives the planner the answers
+- Do NOT research the topic or write task.md
+- Do NOT run any shell/terminal commands
+`, clarifierFile, workDir, request, MaxClarifyingQuestions, ClarificationsFile, ClarificationsFile)
+}
+
+// ClarificationsNote gives the planner the user's answers to the clarifier's
+// questions; "" when there are none
+func ClarificationsNote(c *Clarifications) string {
+	if !c.Answered() || len(c.Questions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+CLARIFICATIONS: Before planning, the user answered these questions about the request. Plan for
+their answers; where there is none, choose a reasonable scope and record the assumption in task.md.
+`)
+	for i, q := range c.Questions {
+		a := strings.TrimSpace(c.Answers[i])
+		if a == "" {
+			a = "(no answer)"
+		}
+		fmt.Fprintf(&b, "%d. %s\n   Answer: %s\n", i+1, q, a)
+	}
+	return b.String()
+}
+
+// PlannerPrompt instructs the planner to write task.md for request. With
+// autoApprove the plan is written without discussing it with the user.
+func PlannerPrompt(guidesDir, workDir, request string, autoApprove bool) string {
+	plannerFile := filepath.Join(guidesDir, "planner.md")
+	approvalMode := "INTERACTIVE"
+	if autoApprove {
+		approvalMode = "AUTO_APPROVE"
+	}
+
+	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+APPROVAL_MODE: %s
+`, plannerFile, workDir, approvalMode)
+
+	if request != "" {
+		prompt += fmt.Sprintf(`
+USER_REQUEST: %s
+`, request)
+	} else {
+		prompt += `
+USER_REQUEST: (Read from stdin - wait for user input)
+`
+	}
+
+	return prompt + `
+TASK: Create a research plan based on the user request. Generate task.md with the research DAG.
+OUTPUT: task.md in WORKING_DIR
+
+IMPORTANT:
+- Directories (assets/, logs/) are ALREADY created by the orchestrator
+- Do NOT run any shell/terminal commands
+- Only use file creation tools to create task.md
+`
+}
+
+// PlanFeedbackNote asks the planner to revise task.md for the user's feedback
+// on its earlier drafts, oldest first; "" without feedback
+func PlanFeedbackNote(feedback []string) string {
+	if len(feedback) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+PLAN REVISION: The user reviewed the plan in task.md and asked for changes. Revise task.md in
+place to address ALL of the feedback below (the latest last), keeping what the user did not object
+to and any edits they made to the file. Do not ask the user anything; write the revised plan.
+`)
+	for i, f := range feedback {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, f)
+	}
+	return b.String()
+}
+
+// SupervisorPrompt instructs the Research-Supervisor to run the pending tasks of task.md
+func SupervisorPrompt(guidesDir, workDir string) string {
+	supervisorFile := filepath.Join(guidesDir, "research-supervisor.md")
+	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+TASK: Execute all pending research tasks (E* tasks) in task.md. Dispatch Executor agents as needed.
+Update task.md with results. Exit when all E* tasks are complete.
+`, supervisorFile, workDir)
+}
+
+// ReflectorPrompt instructs the reflector to review the research in task.md
+func ReflectorPrompt(guidesDir, workDir string) string {
+	reflectorFile := filepath.Join(guidesDir, "reflector.md")
+	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+TASK: Analyze the research quality in task.md. Check completeness, conflicts, and gaps.
+If more research is needed, add new tasks to task.md and set recommendation.
+If research is sufficient, set status to SYNTHESIZING.
+In the Metadata of task.md, also record "Confidence: <0 to 1>" (how well the findings
+answer the request) and "Open Questions: <N>" (gaps and unresolved conflicts left).
+`, reflectorFile, workDir)
+}
+
+// SynthesizerPrompt instructs the synthesizer to write report.md answering request
+func SynthesizerPrompt(guidesDir, workDir, request string) string {
+	synthesizerFile := filepath.Join(guidesDir, "synthesizer.md")
+	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+`, synthesizerFile, workDir)
+
+	if request != "" {
+		prompt += fmt.Sprintf(`ORIGINAL_USER_REQUEST: %s
+`, request)
+	}
+
+	return prompt + `TASK: Generate the final research report based on task.md knowledge graph.
+OUTPUT: report.md in WORKING_DIR
+`
+}
+
+// ReportLanguageNote asks the synthesizer to write report.md in lang; "" when
+// the report is written in the request's language
+func ReportLanguageNote(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	return fmt.Sprintf(`
+REPORT_LANGUAGE: %s (ISO 639-1). Write report.md in this language, whatever the languages of the
+request and the sources: headings, prose, tables and figure captions. Keep the citation markers
+([S1]), URLs, asset paths and the original titles of the sources as they are.
+`, lang)
+}
+
+// TranslatorPrompt instructs the translator to translate the report of a
+// workspace laid out as layout into lang, writing Layout.TranslationFile
+func TranslatorPrompt(guidesDir, workDir string, layout Layout, lang string) string {
+	translatorFile := filepath.Join(guidesDir, "report-translator.md")
+	layout = layout.WithDefaults()
+	return fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+REPORT: %s
+TARGET_LANGUAGE: %s (ISO 639-1)
+
+TASK: Translate the report into the target language, section by section, with nothing left out.
+OUTPUT: %s in WORKING_DIR
+
+IMPORTANT:
+- Keep every citation marker ([S1]), URL, image and asset path, and the source titles in the
+  references exactly as they are
+- Do NOT change the report itself, task.md or anything under assets/
+- Do NOT run any shell/terminal commands
+`, translatorFile, workDir, layout.ReportFile, lang, layout.TranslationFile(lang))
+}
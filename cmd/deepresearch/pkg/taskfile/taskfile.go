@@ -0,0 +1,418 @@
+// Package taskfile reads and writes task.md, the shared state of a research
+// run: its status, the Research DAG of tasks and the findings executors
+// recorded in the Knowledge Graph.
+//
+// Parsing is lenient, as task.md is written by agents; edits change only the
+// lines they touch so the rest of the file keeps its formatting.
+package taskfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Status is the research phase recorded in the task.md metadata
+type Status string
+
+// Statuses the planner and reflector set
+const (
+	StatusResearching  Status = "RESEARCHING"
+	StatusSynthesizing Status = "SYNTHESIZING"
+	StatusCompleted    Status = "COMPLETED"
+	StatusError        Status = "ERROR"
+)
+
+// Reflector recommendations that ask for another research iteration
+const (
+	RecommendContinue     = "CONTINUE_RESEARCH"
+	RecommendAddConflicts = "ADD_CONFLICT_TASKS"
+)
+
+// Task is a task line: "- [ ] E1: description | Priority: HIGH | Depends: E0"
+type Task struct {
+	ID           string
+	Done         bool
+	Description  string   // the rest of the line after "E1:"
+	Priority     string   // HIGH, MEDIUM, LOW or "" if not given
+	Dependencies []string // IDs of the tasks this one waits for
+	Section      string   // "## " heading the task is listed under
+	Line         int      // 1-based
+}
+
+// Finding is a Knowledge Graph entry:
+//
+//	[Fact-001] Statement extracted from source
+//	- Source: [S01]
+//	- Confidence: High
+//	- Raw_File: assets/web/s01_page.html
+type Finding struct {
+	ID         string
+	Statement  string
+	Dimension  string // heading the finding is listed under
+	Sources    []string
+	Confidence string
+	RawFile    string
+}
+
+// File is a parsed task.md
+type File struct {
+	Title          string // "# " heading
+	Status         Status // "" if the metadata has none
+	Recommendation string // upper-cased reflector recommendation, "" if none
+	Tasks          []Task
+	Findings       []Finding
+	// Confidence is the reflector's confidence that the findings answer the
+	// request, from 0 to 1; nil if it recorded none
+	Confidence *float64
+	// OpenQuestions is the number of gaps and unresolved conflicts the
+	// reflector counted, -1 if it recorded none
+	OpenQuestions int
+
+	lines      []string
+	newline    string // "\r\n" for files written with CRLF line endings, which edits keep
+	statusLine int    // index of the status line, -1 if none
+}
+
+var (
+	headingRe        = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	taskRe           = regexp.MustCompile(`^\s*[-*] \[([ xX])\] ([A-Z]+\d+):(.*)$`)
+	statusRe         = regexp.MustCompile(`(?i)^(\s*[-*]?\s*\**status\**\s*:\s*\**\s*)([A-Za-z_]*)`)
+	recommendationRe = regexp.MustCompile(`(?i)^\s*[-*]?\s*\**recommendation\**\s*:\s*\**\s*([A-Za-z_]+)`)
+	confidenceRe     = regexp.MustCompile(`(?i)^\s*[-*]?\s*\**confidence\**\s*:\s*\**\s*(\d+(?:\.\d+)?)\s*(%?)`)
+	openQuestionsRe  = regexp.MustCompile(`(?i)^\s*[-*]?\s*\**open questions\**\s*:\s*\**\s*(\d+)`)
+	dependsRe        = regexp.MustCompile(`(?i)depends(?:\s*on)?\s*:\s*\[?([^|)\]]*)`)
+	priorityRe       = regexp.MustCompile(`(?i)priority\s*:\s*([A-Za-z]+)`)
+	taskIDRe         = regexp.MustCompile(`\b[A-Z]+\d+\b`)
+	findingRe        = regexp.MustCompile(`^\s*(?:[-*]\s*)?\[(Fact-\d+)\]\s*(.*)$`)
+	findingFieldRe   = regexp.MustCompile(`^\s*[-*]\s*(Source|Confidence|Raw_File)\s*:\s*(.*)$`)
+	sourceIDRe       = regexp.MustCompile(`\bS\d+\b`)
+	placeholderRe    = regexp.MustCompile(`(?i)^\s*(?:[-*]\s*)?[(_*]*none[)_*.]*\s*$`)
+	requestRe        = regexp.MustCompile(`(?i)^\s*[-*]?\s*\**original request\**\s*:\s*\**\s*(.*)$`)
+)
+
+// Read parses the task.md at path
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Parse parses the content of a task.md. Task lines count wherever they are
+// listed, as the reflector may add tasks under headings of its own; lines in
+// code fences and HTML comments are skipped.
+func Parse(content []byte) *File {
+	f := &File{
+		lines:         strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n"),
+		newline:       "\n",
+		OpenQuestions: -1,
+		statusLine:    -1,
+	}
+	if strings.Contains(string(content), "\r\n") {
+		f.newline = "\r\n"
+	}
+	section, heading := "", ""
+	inFence, inComment := false, false
+	for i, line := range f.lines {
+		trimmed := strings.TrimSpace(line)
+		if inComment {
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "<!--") {
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			heading = strings.TrimSpace(m[2])
+			switch {
+			case m[1] == "#" && f.Title == "":
+				f.Title = heading
+			case m[1] == "##":
+				section = heading
+			}
+			continue
+		}
+
+		// The status and the reflector's verdict sit in the metadata or the YAML frontmatter above the title
+		if section == "" || strings.EqualFold(section, "metadata") {
+			if m := statusRe.FindStringSubmatch(line); m != nil && f.statusLine < 0 {
+				f.Status = Status(strings.ToUpper(m[2]))
+				f.statusLine = i
+			}
+			if m := recommendationRe.FindStringSubmatch(line); m != nil && f.Recommendation == "" {
+				f.Recommendation = strings.ToUpper(m[1])
+			}
+			if m := confidenceRe.FindStringSubmatch(line); m != nil && f.Confidence == nil {
+				if c, err := strconv.ParseFloat(m[1], 64); err == nil {
+					if m[2] == "%" || c > 1 {
+						c /= 100
+					}
+					if c <= 1 {
+						f.Confidence = &c
+					}
+				}
+			}
+			if m := openQuestionsRe.FindStringSubmatch(line); m != nil && f.OpenQuestions < 0 {
+				f.OpenQuestions, _ = strconv.Atoi(m[1])
+			}
+		}
+
+		if m := taskRe.FindStringSubmatch(line); m != nil {
+			t := Task{ID: m[2], Done: m[1] != " ", Description: strings.TrimSpace(m[3]), Section: section, Line: i + 1}
+			if d := dependsRe.FindStringSubmatch(m[3]); d != nil {
+				t.Dependencies = taskIDRe.FindAllString(d[1], -1)
+			}
+			if p := priorityRe.FindStringSubmatch(m[3]); p != nil {
+				t.Priority = strings.ToUpper(p[1])
+			}
+			f.Tasks = append(f.Tasks, t)
+			continue
+		}
+		if m := findingRe.FindStringSubmatch(line); m != nil {
+			f.Findings = append(f.Findings, Finding{ID: m[1], Statement: strings.TrimSpace(m[2]), Dimension: heading})
+			continue
+		}
+		if m := findingFieldRe.FindStringSubmatch(line); m != nil && len(f.Findings) > 0 {
+			fd := &f.Findings[len(f.Findings)-1]
+			value := strings.Trim(strings.TrimSpace(m[2]), "`")
+			switch m[1] {
+			case "Source":
+				fd.Sources = append(fd.Sources, sourceIDRe.FindAllString(value, -1)...)
+			case "Confidence":
+				fd.Confidence = value
+			case "Raw_File":
+				fd.RawFile = value
+			}
+		}
+	}
+	return f
+}
+
+// OriginalRequest returns the user's request recorded in the metadata, "" if
+// there is none. It follows the label on the same line or, after "|" or ">",
+// on the indented lines below.
+func (f *File) OriginalRequest() string {
+	for i, line := range f.lines {
+		m := requestRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if v := strings.TrimSpace(m[1]); strings.Trim(v, "|>-+") != "" {
+			return v
+		}
+		var request []string
+		for _, l := range f.lines[i+1:] {
+			if strings.TrimSpace(l) != "" && !strings.HasPrefix(l, " ") && !strings.HasPrefix(l, "\t") {
+				break
+			}
+			request = append(request, strings.TrimSpace(l))
+		}
+		return strings.TrimSpace(strings.Join(request, "\n"))
+	}
+	return ""
+}
+
+// Task returns the task with the given ID
+func (f *File) Task(id string) (Task, bool) {
+	for _, t := range f.Tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+// Pending returns the tasks not yet complete
+func (f *File) Pending() []Task {
+	var pending []Task
+	for _, t := range f.Tasks {
+		if !t.Done {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+// Ready returns the pending tasks whose dependencies are all complete, in
+// file order and once per ID
+func (f *File) Ready() []Task {
+	done := map[string]bool{}
+	for _, t := range f.Tasks {
+		if t.Done {
+			done[t.ID] = true
+		}
+	}
+	var ready []Task
+	seen := map[string]bool{}
+	for _, t := range f.Pending() {
+		if seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		ok := true
+		for _, dep := range t.Dependencies {
+			ok = ok && done[dep]
+		}
+		if ok {
+			ready = append(ready, t)
+		}
+	}
+	return ready
+}
+
+// NeedsMoreResearch reports whether the run should research another
+// iteration: tasks are pending, the status is still RESEARCHING, or the
+// reflector recommended more research
+func (f *File) NeedsMoreResearch() bool {
+	return len(f.Pending()) > 0 ||
+		f.Status == StatusResearching ||
+		f.Recommendation == RecommendContinue ||
+		f.Recommendation == RecommendAddConflicts
+}
+
+// MarkDone checks off a pending task, reporting whether one was found
+func (f *File) MarkDone(id string) bool {
+	for i, t := range f.Tasks {
+		if t.ID != id || t.Done {
+			continue
+		}
+		f.lines[t.Line-1] = strings.Replace(f.lines[t.Line-1], "[ ]", "[x]", 1)
+		f.Tasks[i].Done = true
+		return true
+	}
+	return false
+}
+
+// NextID returns the first task ID with prefix after those in the file, e.g.
+// "E4" when E3 is the highest
+func (f *File) NextID(prefix string) string {
+	highest := 0
+	for _, t := range f.Tasks {
+		if !strings.HasPrefix(t.ID, prefix) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(t.ID, prefix)); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("%s%d", prefix, highest+1)
+}
+
+// AddTask adds a pending task, description being the rest of the line after
+// "E4:". It goes after the last pending task, else under a "Pending Tasks"
+// heading (replacing a "(none)" placeholder), else at the end of the
+// Research DAG or of the file.
+func (f *File) AddTask(id, description string) {
+	at := -1
+	for _, t := range f.Pending() {
+		at = t.Line
+	}
+	if at < 0 {
+		dag := -1
+		for i, line := range f.lines {
+			m := headingRe.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+			if heading := strings.TrimSpace(m[2]); strings.EqualFold(heading, "pending tasks") {
+				at = i + 1
+				break
+			} else if m[1] == "##" && strings.EqualFold(heading, "research dag") {
+				dag = f.sectionEnd(i)
+			}
+		}
+		if at < 0 {
+			at = dag
+		}
+	}
+	if at < 0 {
+		at = len(f.lines)
+	}
+	line := fmt.Sprintf("- [ ] %s: %s", id, description)
+	if at < len(f.lines) && placeholderRe.MatchString(f.lines[at]) {
+		f.lines[at] = line
+	} else {
+		f.lines = append(f.lines[:at], append([]string{line}, f.lines[at:]...)...)
+	}
+	*f = *Parse(f.Bytes())
+}
+
+// sectionEnd returns the index after the last non-blank line of the "## "
+// section whose heading is at index start
+func (f *File) sectionEnd(start int) int {
+	end := start + 1
+	for i := start + 1; i < len(f.lines); i++ {
+		trimmed := strings.TrimSpace(f.lines[i])
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil && len(m[1]) <= 2 {
+			break
+		}
+		if trimmed != "" {
+			end = i + 1
+		}
+	}
+	return end
+}
+
+// SetStatus rewrites the status in the metadata, reporting whether task.md has
+// a status line
+func (f *File) SetStatus(s Status) bool {
+	if f.statusLine < 0 {
+		return false
+	}
+	line := f.lines[f.statusLine]
+	m := statusRe.FindStringSubmatchIndex(line)
+	f.lines[f.statusLine] = line[:m[3]] + string(s) + line[m[5]:]
+	f.Status = s
+	return true
+}
+
+// Bytes returns the content of the file with any edits applied
+func (f *File) Bytes() []byte {
+	return []byte(strings.Join(f.lines, f.newline))
+}
+
+// Write saves the file to path atomically
+func (f *File) Write(path string) error {
+	return WriteFile(path, f.Bytes())
+}
+
+// WriteFile replaces the file at path with data atomically: readers see the old
+// or the new content, never a partial write
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	mode := os.FileMode(0644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	os.Chmod(tmp.Name(), mode)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package taskfile
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// plan is a task.md as the planner writes it
+const plan = `# Heat pumps
+
+## Metadata
+- Status: RESEARCHING
+- Original request: Heat pump adoption in Norway and Germany
+
+## Research DAG
+- [x] E1: Adoption in Norway | Priority: HIGH
+- [ ] E2: Adoption in Germany | Priority: medium | Depends: E1
+- [ ] E3: Subsidies | Depends: E2, E9
+
+## Knowledge Graph
+### Adoption
+[Fact-001] Six in ten Norwegian households use a heat pump
+- Source: [S01]
+- Confidence: High
+- Raw_File: ` + "`assets/web/s01_ssb.html`" + `
+`
+
+func ids(tasks []Task) []string {
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		title    string
+		status   Status
+		tasks    []Task // ID, Done, Priority and Dependencies are compared
+		findings int
+	}{
+		{
+			name:    "plan",
+			content: plan,
+			title:   "Heat pumps",
+			status:  StatusResearching,
+			tasks: []Task{
+				{ID: "E1", Done: true, Priority: "HIGH"},
+				{ID: "E2", Priority: "MEDIUM", Dependencies: []string{"E1"}},
+				{ID: "E3", Dependencies: []string{"E2", "E9"}},
+			},
+			findings: 1,
+		},
+		{
+			name:     "CRLF",
+			content:  strings.ReplaceAll(plan, "\n", "\r\n"),
+			title:    "Heat pumps",
+			status:   StatusResearching,
+			tasks:    []Task{{ID: "E1", Done: true, Priority: "HIGH"}, {ID: "E2", Priority: "MEDIUM", Dependencies: []string{"E1"}}, {ID: "E3", Dependencies: []string{"E2", "E9"}}},
+			findings: 1,
+		},
+		{
+			name:    "no status section",
+			content: "# Plan\n\n- [ ] E1: Adoption | Depends: none\n- [X] R2: Review\n",
+			title:   "Plan",
+			tasks:   []Task{{ID: "E1"}, {ID: "R2", Done: true}},
+		},
+		{
+			name:    "tasks in code and comments",
+			content: "# Plan\n\n```\n- [ ] E1: example\n```\n<!--\n- [ ] E2: hidden\n-->\n- [ ] E3: real\n",
+			title:   "Plan",
+			tasks:   []Task{{ID: "E3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.content))
+			if f.Title != tt.title || f.Status != tt.status || len(f.Findings) != tt.findings {
+				t.Errorf("title %q, status %q, %d finding(s); want %q, %q, %d", f.Title, f.Status, len(f.Findings), tt.title, tt.status, tt.findings)
+			}
+			if len(f.Tasks) != len(tt.tasks) {
+				t.Fatalf("tasks %v, want %v", ids(f.Tasks), ids(tt.tasks))
+			}
+			for i, want := range tt.tasks {
+				got := f.Tasks[i]
+				if got.ID != want.ID || got.Done != want.Done || got.Priority != want.Priority || !slices.Equal(got.Dependencies, want.Dependencies) {
+					t.Errorf("task %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFinding(t *testing.T) {
+	f := Parse([]byte(plan))
+	fd := f.Findings[0]
+	if fd.ID != "Fact-001" || fd.Dimension != "Adoption" || !slices.Equal(fd.Sources, []string{"S01"}) || fd.Confidence != "High" || fd.RawFile != "assets/web/s01_ssb.html" {
+		t.Errorf("finding = %+v", fd)
+	}
+	if got := f.OriginalRequest(); got != "Heat pump adoption in Norway and Germany" {
+		t.Errorf("original request = %q", got)
+	}
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks string
+		ready []string
+	}{
+		{"dependencies done", "- [x] E1: a\n- [ ] E2: b | Depends: E1\n", []string{"E2"}},
+		{"dependencies pending", "- [ ] E1: a\n- [ ] E2: b | Depends: E1\n", []string{"E1"}},
+		{"some dependencies pending", "- [x] E1: a\n- [ ] E2: b\n- [ ] E3: c | Depends: E1, E2\n", []string{"E2"}},
+		{"unknown dependency", "- [ ] E1: a | Depends: E9\n", nil},
+		{"no dependencies", "- [ ] E1: a | Depends: none\n- [ ] E2: b\n", []string{"E1", "E2"}},
+		{"listed twice", "- [ ] E1: a\n## Pending Tasks\n- [ ] E1: a\n", []string{"E1"}},
+		{"all done", "- [x] E1: a\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(Parse([]byte(tt.tasks)).Ready()); !slices.Equal(got, tt.ready) {
+				t.Errorf("Ready() = %v, want %v", got, tt.ready)
+			}
+		})
+	}
+}
+
+func TestNeedsMoreResearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		more    bool
+	}{
+		{"no status section, pending", "# Plan\n- [ ] E1: a\n", true},
+		{"no status section, done", "# Plan\n- [x] E1: a\n", false},
+		{"researching", "# Plan\n- Status: RESEARCHING\n- [x] E1: a\n", true},
+		{"synthesizing", "# Plan\n- Status: SYNTHESIZING\n- [x] E1: a\n", false},
+		{"continue recommended", "# Plan\n- Status: SYNTHESIZING\n- Recommendation: continue_research\n- [x] E1: a\n", true},
+		{"conflict tasks recommended", "# Plan\n- **Recommendation**: ADD_CONFLICT_TASKS\n", true},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse([]byte(tt.content)).NeedsMoreResearch(); got != tt.more {
+				t.Errorf("NeedsMoreResearch() = %v, want %v", got, tt.more)
+			}
+		})
+	}
+}
+
+func TestEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    func(f *File) bool
+		want    string // content after the edit, "" if unchanged
+	}{
+		{
+			name:    "mark done",
+			content: plan,
+			edit:    func(f *File) bool { return f.MarkDone("E2") },
+			want:    strings.Replace(plan, "- [ ] E2:", "- [x] E2:", 1),
+		},
+		{
+			name:    "mark done twice",
+			content: strings.Replace(plan, "- [ ] E2:", "- [x] E2:", 1),
+			edit:    func(f *File) bool { return f.MarkDone("E2") },
+		},
+		{
+			name:    "mark unknown task done",
+			content: plan,
+			edit:    func(f *File) bool { return f.MarkDone("E9") },
+		},
+		{
+			name:    "set status",
+			content: plan,
+			edit:    func(f *File) bool { return f.SetStatus(StatusSynthesizing) },
+			want:    strings.Replace(plan, "Status: RESEARCHING", "Status: SYNTHESIZING", 1),
+		},
+		{
+			name:    "set bold status",
+			content: "# Plan\n\n- **Status**: **RESEARCHING**\n- [ ] E1: a\n",
+			edit:    func(f *File) bool { return f.SetStatus(StatusCompleted) },
+			want:    "# Plan\n\n- **Status**: **COMPLETED**\n- [ ] E1: a\n",
+		},
+		{
+			name:    "set status without a status line",
+			content: "# Plan\n- [ ] E1: a\n",
+			edit:    func(f *File) bool { return f.SetStatus(StatusCompleted) },
+		},
+		{
+			name:    "CRLF",
+			content: strings.ReplaceAll(plan, "\n", "\r\n"),
+			edit:    func(f *File) bool { return f.MarkDone("E3") && f.SetStatus(StatusSynthesizing) },
+			want: strings.ReplaceAll(strings.NewReplacer(
+				"- [ ] E3:", "- [x] E3:",
+				"Status: RESEARCHING", "Status: SYNTHESIZING",
+			).Replace(plan), "\n", "\r\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.content))
+			want := tt.want
+			if want == "" {
+				want = tt.content
+			}
+			if changed := tt.edit(f); changed != (tt.want != "") {
+				t.Errorf("edit reported %v, want %v", changed, tt.want != "")
+			}
+			if got := string(f.Bytes()); got != want {
+				t.Errorf("content:\n%s\nwant:\n%s", got, want)
+			}
+			// The edit shows in what Parse reads back
+			if again := Parse(f.Bytes()); again.Status != f.Status || len(again.Pending()) != len(f.Pending()) {
+				t.Errorf("parsed back status %q with %d pending, want %q with %d", again.Status, len(again.Pending()), f.Status, len(f.Pending()))
+			}
+		})
+	}
+}
+
+func TestAddTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		prefix  string
+		id      string
+		want    string
+	}{
+		{
+			name:    "after the last pending task",
+			content: "## Research DAG\n- [x] E1: a\n- [ ] E3: c\n\n## Notes\n",
+			prefix:  "E",
+			id:      "E4",
+			want:    "## Research DAG\n- [x] E1: a\n- [ ] E3: c\n- [ ] E4: new\n\n## Notes\n",
+		},
+		{
+			name:    "pending tasks placeholder",
+			content: "## Research DAG\n- [x] E1: a\n\n### Pending Tasks\n(none)\n",
+			prefix:  "R",
+			id:      "R1",
+			want:    "## Research DAG\n- [x] E1: a\n\n### Pending Tasks\n- [ ] R1: new\n",
+		},
+		{
+			name:    "end of the research DAG",
+			content: "## Research DAG\n- [x] E2: b\n\n## Knowledge Graph\n",
+			prefix:  "E",
+			id:      "E3",
+			want:    "## Research DAG\n- [x] E2: b\n- [ ] E3: new\n\n## Knowledge Graph\n",
+		},
+		{
+			name:    "end of the file",
+			content: "# Plan",
+			prefix:  "E",
+			id:      "E1",
+			want:    "# Plan\n- [ ] E1: new",
+		},
+		{
+			name:    "CRLF",
+			content: "## Research DAG\r\n- [ ] E1: a\r\n",
+			prefix:  "E",
+			id:      "E2",
+			want:    "## Research DAG\r\n- [ ] E1: a\r\n- [ ] E2: new\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.content))
+			id := f.NextID(tt.prefix)
+			if id != tt.id {
+				t.Errorf("NextID(%q) = %s, want %s", tt.prefix, id, tt.id)
+			}
+			f.AddTask(id, "new")
+			if got := string(f.Bytes()); got != tt.want {
+				t.Errorf("content:\n%q\nwant:\n%q", got, tt.want)
+			}
+			if task, ok := f.Task(id); !ok || task.Done || task.Description != "new" {
+				t.Errorf("Task(%s) = %+v, %v after adding it", id, task, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// outcomePlanned is the outcome of a --plan-only run: task.md is written and
+// research has not started
+const outcomePlanned = "planned"
+
+// readPlan reads a task.md for --execute-plan, refusing one with lint errors
+// since hand edits easily break the DAG the supervisor works from
+func readPlan(path string) (*taskfile.File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if errs := lintErrors(lintTask(string(content))); len(errs) > 0 {
+		var msgs []string
+		for _, e := range errs[:min(len(errs), 5)] {
+			msgs = append(msgs, e.String())
+		}
+		return nil, fmt.Errorf("%d lint error(s) (run `deepresearch lint %s` for details): %s", len(errs), path, strings.Join(msgs, "; "))
+	}
+	f := taskfile.Parse(content)
+	if len(f.Pending()) == 0 {
+		return nil, fmt.Errorf("no pending tasks to research")
+	}
+	return f, nil
+}
+
+// seedPlan copies the plan into the workspace as its task.md, unless it is
+// that file already
+func seedPlan(src, workDir string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	dst := taskFilePath(workDir)
+	if abs == dst {
+		return nil
+	}
+	if fileExists(dst) {
+		return fmt.Errorf("%s already holds a run (%s); execute the plan in a new session", workDir, taskFileName(workDir))
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return err
+	}
+	return taskfile.WriteFile(dst, data)
+}
+
+// finishPlanOnly ends a --plan-only run. The checkpoint stays, so --resume
+// continues with research from the plan once it is reviewed.
+func finishPlanOnly(workDir string) {
+	finishing()
+	logEntry("INFO", "COMPLETED", 0, "Research plan created; stopping as --plan-only asks", map[string]string{
+		"output": taskFileName(workDir),
+	})
+	manifest.finish(outcomePlanned, "")
+	writeResult(outcomePlanned, "", "")
+	recordKnowledge(outcomePlanned)
+	reportRunTelemetry()
+	notifyRunEnd(outcomePlanned, "", "")
+	applyPrivacyPolicy(workDir)
+	taskFile := taskFilePath(workDir)
+	success("Research plan saved to: %s", taskFile)
+	info("Review or edit it, then continue this run with --resume, or run it anywhere with --execute-plan %s", taskFile)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// stdinAnswers reads the user's input: the topic, clarifying answers and plan
+// review choices; one reader keeps what it buffered between questions
+var stdinAnswers = bufio.NewReader(os.Stdin)
+
+// errNoAnswer is the error of a question asked once stdin is closed
+var errNoAnswer = errors.New("stdin is closed")
+
+// ReviewPlan shows the plan the planner wrote and asks the user to approve it,
+// edit it in their editor, or send it back to the planner with feedback
+// (--review-plan)
+func (w *researchWorkflow) ReviewPlan(ctx context.Context, req orchestrator.Request) (string, error) {
+	taskFile := taskFilePath(w.workDir)
+	for {
+		if err := showPlan(taskFile, len(req.PlanFeedback)); err != nil {
+			return "", err
+		}
+		choice, err := readAnswer(ctx, tr("Approve the plan [a], edit it [e], or send feedback to the planner [f]: "))
+		if err != nil {
+			return "", planReviewError(err)
+		}
+		switch strings.ToLower(choice) {
+		case "a", "approve", "y", "yes":
+			return "", nil
+		case "e", "edit":
+			if err := editFile(taskFile); err != nil {
+				warn("Could not edit the plan: %v", err)
+			}
+		case "f", "feedback":
+			feedback, err := readAnswer(ctx, tr("What should the planner change? "))
+			if err != nil {
+				return "", planReviewError(err)
+			}
+			if feedback != "" {
+				return feedback, nil
+			}
+		}
+	}
+}
+
+// planReviewError explains a plan review that got no answer
+func planReviewError(err error) error {
+	if errors.Is(err, errNoAnswer) {
+		return fmt.Errorf("the plan review got no answer: %w", err)
+	}
+	return err
+}
+
+// showPlan renders task.md for review: its title and its tasks, and the
+// problems that would trip up research
+func showPlan(taskFile string, revision int) error {
+	content, err := os.ReadFile(taskFile)
+	if err != nil {
+		return err
+	}
+	f := taskfile.Parse(content)
+	title := tr("Research plan")
+	if revision > 0 {
+		title = fmt.Sprintf(tr("Research plan (revision %d)"), revision)
+	}
+	if plainOutput {
+		fmt.Printf("[PLAN] %s: %s\n", title, taskFile)
+	} else {
+		fmt.Printf("\n%s── %s ──%s\n", colorCyan, title, colorReset)
+	}
+	if f.Title != "" {
+		fmt.Printf("%s\n", f.Title)
+	}
+	section := ""
+	for _, t := range f.Tasks {
+		if t.Section != section {
+			section = t.Section
+			fmt.Printf("\n  %s\n", section)
+		}
+		mark := " "
+		if t.Done {
+			mark = "x"
+		}
+		fmt.Printf("  [%s] %s: %s\n", mark, t.ID, t.Description)
+	}
+	fmt.Println()
+	info("%d task(s); the full plan is in %s", len(f.Tasks), taskFile)
+	for _, e := range lintErrors(lintTask(string(content))) {
+		warn("%s", e.String())
+	}
+	return nil
+}
+
+// readAnswer asks question and reads a line of input, or returns the cause of
+// ctx once it ends, e.g. on Ctrl+C, and errNoAnswer once stdin is closed
+func readAnswer(ctx context.Context, question string) (string, error) {
+	fmt.Print(question)
+	type answer struct {
+		line string
+		err  error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		line, err := stdinAnswers.ReadString('\n')
+		if err != nil && line != "" {
+			err = nil
+		}
+		answers <- answer{strings.TrimSpace(line), err}
+	}()
+	select {
+	case <-ctx.Done():
+		fmt.Println()
+		return "", context.Cause(ctx)
+	case a := <-answers:
+		if a.err != nil {
+			fmt.Println()
+			return "", errNoAnswer
+		}
+		return a.line, nil
+	}
+}
+
+// editFile opens path in the user's editor, $VISUAL or $EDITOR (which may
+// carry arguments, e.g. "code --wait"), else vi or Notepad, and waits for it
+func editFile(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
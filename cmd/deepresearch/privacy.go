@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// redactedTopic replaces the research topic in persisted records when prompts are not persisted
+const redactedTopic = "(redacted)"
+
+// keptLogs are orchestrator logs that never contain prompts or fetched content
+var keptLogs = map[string]bool{
+	"orchestrator.log": true,
+	"events.jsonl":     true,
+}
+
+// applyRetention deletes persisted run files older than the configured retention period
+func applyRetention(workDir string) {
+	days := config.Privacy.RetentionDays
+	if days <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	removed := 0
+	for _, dir := range []string{"tmp", "logs", assetsDirName(workDir), sectionsDirName} {
+		filepath.WalkDir(filepath.Join(workDir, filepath.FromSlash(dir)), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if st, err := d.Info(); err == nil && st.ModTime().Before(cutoff) {
+				if os.Remove(path) == nil {
+					removed++
+				}
+			}
+			return nil
+		})
+	}
+	if removed > 0 {
+		info("Retention policy: removed %d file(s) older than %d days", removed, days)
+	}
+}
+
+// applyPrivacyPolicy removes whatever the privacy configuration says must not
+// outlive the run. It is called once the run has finished, successfully or not.
+func applyPrivacyPolicy(workDir string) {
+	p := config.Privacy
+	if p.Ephemeral || !p.PersistPrompts {
+		os.RemoveAll(filepath.Join(workDir, "tmp"))
+		os.RemoveAll(filepath.Join(workDir, ".locks"))
+	}
+	if p.Ephemeral || !p.PersistTranscripts {
+		removeTranscripts(workDir)
+	}
+	if p.Ephemeral {
+		os.RemoveAll(filepath.Join(workDir, sectionsDirName))
+	}
+	if !p.PersistFetched {
+		for _, dir := range []string{"web", "pdf", "images", "audio", "ebook"} {
+			clearDir(assetsPath(workDir, dir))
+		}
+	}
+	if p.Ephemeral {
+		info("Ephemeral mode: removed temporary files, transcripts and caches")
+	}
+}
+
+// removeTranscripts deletes agent transcripts and result files under logs/
+func removeTranscripts(workDir string) {
+	entries, err := os.ReadDir(filepath.Join(workDir, "logs"))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if keptLogs[e.Name()] || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		os.RemoveAll(filepath.Join(workDir, "logs", e.Name()))
+	}
+}
+
+// clearDir removes the contents of a directory but keeps the directory itself
+func clearDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		os.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
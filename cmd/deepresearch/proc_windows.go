@@ -0,0 +1,33 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows; killProcessTree uses taskkill /T instead
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills cmd and its process tree; Windows has no SIGTERM to
+// send a console process
+func terminateProcess(cmd *exec.Cmd) {
+	killProcessTree(cmd)
+}
+
+// interruptProcess kills cmd and its process tree; a console process cannot
+// be sent Ctrl+C on its own
+func interruptProcess(cmd *exec.Cmd) {
+	killProcessTree(cmd)
+}
+
+// killProcessTree kills cmd and every process it started
+func killProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprint(cmd.Process.Pid)).Run(); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/prompts"
+)
+
+// promptsEnv names an environment variable selecting a directory of prompt
+// files that override the built-in ones
+const promptsEnv = "DEEPRESEARCH_PROMPTS"
+
+// promptsOverride is the --prompts-dir directory; it takes precedence over $DEEPRESEARCH_PROMPTS
+var promptsOverride string
+
+// findPromptsDir returns the prompt pack the agents read: the built-in pack
+// with the files of --prompts-dir or $DEEPRESEARCH_PROMPTS layered over it.
+// Without an override, a source checkout's prompts/deep-research is used in
+// place so edits to it take effect without a rebuild.
+func findPromptsDir() (string, error) {
+	override := promptsOverride
+	if override == "" {
+		override = os.Getenv(promptsEnv)
+	}
+	if override != "" {
+		abs, err := filepath.Abs(override)
+		if err != nil {
+			return "", err
+		}
+		if st, err := os.Stat(abs); err != nil || !st.IsDir() {
+			return "", fmt.Errorf("prompts directory %s does not exist", abs)
+		}
+		return layerPrompts(abs)
+	}
+
+	candidates := []string{
+		"prompts/deep-research",
+		"cmd/deepresearch/prompts/deep-research",
+	}
+	if execPath, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(execPath), "prompts/deep-research"))
+	}
+	for _, candidate := range candidates {
+		if abs, err := filepath.Abs(candidate); err == nil && fileExists(filepath.Join(abs, "planner.md")) {
+			return abs, nil
+		}
+	}
+	return layerPrompts("")
+}
+
+// layerPrompts returns a directory holding the built-in pack with the files of
+// overlay (if set) replacing or adding to it. An overlay holding every
+// built-in file is used in place; otherwise the combined pack is written once
+// to ~/.deepresearch/prompts/<checksum>/, which agents read like any other pack.
+func layerPrompts(overlay string) (string, error) {
+	files := map[string][]byte{}
+	err := fs.WalkDir(prompts.FS, prompts.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := prompts.FS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimPrefix(p, prompts.Dir+"/")] = data
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if overlay != "" {
+		complete := true
+		for rel := range files {
+			if !fileExists(filepath.Join(overlay, filepath.FromSlash(rel))) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return overlay, nil
+		}
+		err := filepath.WalkDir(overlay, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(overlay, p)
+			files[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("cannot read prompts directory: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for rel := range files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, rel := range names {
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(files[rel]))
+		h.Write(files[rel])
+	}
+	dir := filepath.Join(promptsCacheDir(), hex.EncodeToString(h.Sum(nil))[:12])
+	if fileExists(filepath.Join(dir, "planner.md")) {
+		return dir, nil
+	}
+
+	// Write to a temporary sibling and rename it, so concurrent runs never see a partial pack
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("cannot write prompts: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".pack-")
+	if err != nil {
+		return "", fmt.Errorf("cannot write prompts: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	for _, rel := range names {
+		p := filepath.Join(tmp, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return "", fmt.Errorf("cannot write prompts: %w", err)
+		}
+		if err := os.WriteFile(p, files[rel], 0644); err != nil {
+			return "", fmt.Errorf("cannot write prompts: %w", err)
+		}
+	}
+	os.Chmod(tmp, 0755)
+	if err := os.Rename(tmp, dir); err != nil && !fileExists(filepath.Join(dir, "planner.md")) {
+		return "", fmt.Errorf("cannot write prompts: %w", err)
+	}
+	return dir, nil
+}
+
+// promptsCacheDir returns where combined prompt packs are written
+func promptsCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "deepresearch-prompts")
+	}
+	return filepath.Join(home, ".deepresearch", "prompts")
+}
@@ -0,0 +1,16 @@
+// Package prompts embeds the default deep-research prompt pack: the guides of
+// the clarifier, planner, research supervisor, executor, reflector,
+// synthesizer and report translator, with the templates and references they
+// read. Agents read the guides from disk, so callers write the pack out (see
+// fs.WalkDir) before pointing agents at it.
+package prompts
+
+import "embed"
+
+// Dir is the directory of the pack within FS
+const Dir = "deep-research"
+
+// FS holds the pack under Dir
+//
+//go:embed deep-research
+var FS embed.FS
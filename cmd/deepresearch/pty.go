@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Values of interactive.pty
+const (
+	ptyAuto = "auto" // a pseudo-terminal where the platform has one, else the console itself
+	ptyOn   = "on"   // fail when no pseudo-terminal can be allocated
+	ptyOff  = "off"  // the agent shares deepresearch's stdin, stdout and stderr
+)
+
+// plannerSessionLog records the interactive planner session, under logs/
+const plannerSessionLog = "planner-session.log"
+
+// The size of the agent's terminal when deepresearch's output is not a terminal, e.g. in CI
+const (
+	defaultTerminalCols = 120
+	defaultTerminalRows = 40
+)
+
+// sessionDrainTimeout bounds the wait for the agent's last output once it
+// exited; a process it left behind may keep the terminal open
+const sessionDrainTimeout = 2 * time.Second
+
+// errNoTerminal reports that no pseudo-terminal could be allocated
+var errNoTerminal = errors.New("no pseudo-terminal")
+
+// agentTerminal is the pseudo-terminal an interactive agent runs in: reads
+// return the agent's output, writes are its keyboard input
+type agentTerminal interface {
+	io.ReadWriteCloser
+	Resize(cols, rows int) error
+}
+
+// terminalSession proxies the console to an interactive agent's terminal and
+// records what the terminal shows
+type terminalSession struct {
+	term       agentTerminal
+	transcript *os.File
+	outDone    chan struct{}
+	stopInput  func()
+	stopResize func()
+
+	restoreOnce sync.Once
+	restore     func()
+}
+
+// agentTerminalSize is the size of the agent's terminal, that of the console if there is one
+func agentTerminalSize() (int, int) {
+	if cols, rows, ok := terminalSize(os.Stdout); ok {
+		return cols, rows
+	}
+	return defaultTerminalCols, defaultTerminalRows
+}
+
+// startInteractiveAgent starts an interactive agent in a pseudo-terminal as
+// interactive.pty allows, recording the session to transcript. Without one
+// the agent gets the console itself and the returned session does nothing.
+func startInteractiveAgent(cmd *exec.Cmd, transcript string) (*terminalSession, error) {
+	if config.Interactive.PTY != ptyOff {
+		cols, rows := agentTerminalSize()
+		t, err := startInTerminal(cmd, cols, rows)
+		switch {
+		case err == nil:
+			logEntry("INFO", "PTY", 0, "Interactive agent runs in a pseudo-terminal", map[string]string{
+				"size":       strconv.Itoa(cols) + "x" + strconv.Itoa(rows),
+				"transcript": transcript,
+			})
+			return proxyTerminal(t, transcript), nil
+		case !errors.Is(err, errNoTerminal) || config.Interactive.PTY == ptyOn:
+			return nil, err
+		}
+		logEntry("WARN", "PTY", 0, "No pseudo-terminal, the agent uses the console", map[string]string{"error": err.Error()})
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &terminalSession{}, nil
+}
+
+// proxyTerminal puts the console in raw mode, so keys reach the agent as
+// typed, and copies between it and the agent's terminal
+func proxyTerminal(t agentTerminal, transcript string) *terminalSession {
+	s := &terminalSession{term: t, outDone: make(chan struct{}), restore: func() {}}
+	var out io.Writer = os.Stdout
+	if f, err := os.Create(transcript); err == nil {
+		s.transcript = f
+		out = io.MultiWriter(os.Stdout, &transcriptWriter{w: f})
+	} else {
+		logEntry("WARN", "PTY", 0, "Could not record the session", map[string]string{"error": err.Error()})
+	}
+	restoreOutput := enableTerminalOutput()
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			s.restore = func() { term.Restore(fd, state) }
+		}
+	}
+	restoreInput := s.restore
+	s.restore = func() {
+		restoreInput()
+		restoreOutput()
+	}
+	s.stopResize = watchTerminalSize(t)
+	s.stopInput = copyConsoleInput(t)
+	go func() {
+		io.Copy(out, t)
+		close(s.outDone)
+	}()
+	return s
+}
+
+// restoreConsole takes the console out of raw mode, e.g. to print while the
+// agent is being stopped
+func (s *terminalSession) restoreConsole() {
+	if s.term != nil {
+		s.restoreOnce.Do(s.restore)
+	}
+}
+
+// end waits for the last output of the agent, which has exited, closes its
+// terminal and restores the console
+func (s *terminalSession) end() {
+	if s.term == nil {
+		return
+	}
+	select {
+	case <-s.outDone:
+	case <-time.After(sessionDrainTimeout):
+	}
+	s.term.Close()
+	<-s.outDone
+	s.stopInput()
+	s.stopResize()
+	s.restoreConsole()
+	if s.transcript != nil {
+		s.transcript.Close()
+	}
+	// The agent's last line may lack a newline, and raw output no carriage return
+	fmt.Print("\r\n")
+}
+
+// transcriptWriter writes terminal output as plain text: escape sequences are
+// dropped and carriage returns become line breaks
+type transcriptWriter struct {
+	w     io.Writer
+	state int // transcript* state of the escape sequence being skipped
+	cr    bool
+}
+
+// States of transcriptWriter
+const (
+	transcriptText = iota
+	transcriptEsc  // after ESC
+	transcriptCSI  // in ESC [ ... final byte
+	transcriptOSC  // in ESC ] ... BEL or ESC \
+	transcriptOSCEsc
+)
+
+func (t *transcriptWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch t.state {
+		case transcriptEsc:
+			switch b {
+			case '[':
+				t.state = transcriptCSI
+			case ']', 'P', '_', '^':
+				t.state = transcriptOSC
+			default:
+				t.state = transcriptText
+			}
+			continue
+		case transcriptCSI:
+			if b >= 0x40 && b <= 0x7e {
+				t.state = transcriptText
+			}
+			continue
+		case transcriptOSC:
+			switch b {
+			case 0x07:
+				t.state = transcriptText
+			case 0x1b:
+				t.state = transcriptOSCEsc
+			}
+			continue
+		case transcriptOSCEsc:
+			t.state = transcriptText
+			continue
+		}
+		if t.cr && b != '\n' {
+			out = append(out, '\n')
+		}
+		t.cr = false
+		switch {
+		case b == 0x1b:
+			t.state = transcriptEsc
+		case b == '\r':
+			t.cr = true
+		case b == '\n' || b == '\t' || b >= 0x20 && b != 0x7f:
+			out = append(out, b)
+		}
+	}
+	// Terminal output never fails the session
+	t.w.Write(out)
+	return len(p), nil
+}
@@ -0,0 +1,122 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
+)
+
+// unixTerminal is the master side of a Unix pseudo-terminal
+type unixTerminal struct {
+	*os.File
+}
+
+func (t unixTerminal) Resize(cols, rows int) error {
+	return pty.Setsize(t.File, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// startInTerminal starts cmd as the session leader of a new pseudo-terminal
+// of the given size
+func startInTerminal(cmd *exec.Cmd, cols, rows int) (agentTerminal, error) {
+	master, tty, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	defer tty.Close()
+	t := unixTerminal{master}
+	if err := t.Resize(cols, rows); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	// As its session leader the agent also gets a process group of its own,
+	// which killProcessTree ends
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// watchTerminalSize resizes t along with the console until the returned function is called
+func watchTerminalSize(t agentTerminal) func() {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-resized:
+				if cols, rows, ok := terminalSize(os.Stdout); ok {
+					t.Resize(cols, rows)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(resized)
+		close(done)
+	}
+}
+
+// inputPollMillis is how often copyConsoleInput checks whether it should stop
+const inputPollMillis = 100
+
+// copyConsoleInput copies stdin to t until the returned function is called.
+// Stdin is polled rather than read blocking, so no read is left pending to
+// swallow input meant for deepresearch after the session.
+func copyConsoleInput(t agentTerminal) func() {
+	var stopped sync.WaitGroup
+	done := make(chan struct{})
+	stopped.Add(1)
+	go func() {
+		defer stopped.Done()
+		fds := []unix.PollFd{{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN}}
+		buf := make([]byte, 4096)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			n, err := unix.Poll(fds, inputPollMillis)
+			if err == unix.EINTR || n == 0 {
+				continue
+			}
+			if err != nil || fds[0].Revents&(unix.POLLIN|unix.POLLHUP) == 0 {
+				return
+			}
+			n, err = unix.Read(int(os.Stdin.Fd()), buf)
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			if n <= 0 || err != nil {
+				return // end of input
+			}
+			if _, err := t.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		stopped.Wait()
+	}
+}
+
+// enableTerminalOutput prepares the console for the agent's terminal output;
+// Unix terminals need nothing
+func enableTerminalOutput() func() {
+	return func() {}
+}
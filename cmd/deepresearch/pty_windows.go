@@ -0,0 +1,269 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                = windows.NewLazySystemDLL("kernel32.dll")
+	procCreatePseudoConsole = kernel32.NewProc("CreatePseudoConsole")
+	procPeekConsoleInputW   = kernel32.NewProc("PeekConsoleInputW")
+	procReadConsoleInputW   = kernel32.NewProc("ReadConsoleInputW")
+)
+
+// conPTY is a Windows pseudo console (ConPTY, Windows 10 1809 and later)
+type conPTY struct {
+	console   windows.Handle
+	in        *os.File // the agent's keyboard input
+	out       *os.File // what the console shows
+	closeOnce sync.Once
+}
+
+func (c *conPTY) Read(p []byte) (int, error)  { return c.out.Read(p) }
+func (c *conPTY) Write(p []byte) (int, error) { return c.in.Write(p) }
+
+func (c *conPTY) Resize(cols, rows int) error {
+	return windows.ResizePseudoConsole(c.console, windows.Coord{X: int16(cols), Y: int16(rows)})
+}
+
+// closeConsole closes the pseudo console, which ends its output once drained
+func (c *conPTY) closeConsole() {
+	c.closeOnce.Do(func() { windows.ClosePseudoConsole(c.console) })
+}
+
+func (c *conPTY) Close() error {
+	c.closeConsole()
+	c.in.Close()
+	return c.out.Close()
+}
+
+// startInTerminal starts cmd attached to a new pseudo console of the given
+// size. exec.Cmd cannot attach one, so the process is created here and handed
+// to cmd as its Process for cmd.Wait and the process tree functions.
+func startInTerminal(cmd *exec.Cmd, cols, rows int) (agentTerminal, error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	if procCreatePseudoConsole.Find() != nil {
+		return nil, fmt.Errorf("%w: this Windows version has no ConPTY", errNoTerminal)
+	}
+	var ptyIn, inWrite, outRead, ptyOut windows.Handle
+	if err := windows.CreatePipe(&ptyIn, &inWrite, nil, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	if err := windows.CreatePipe(&outRead, &ptyOut, nil, 0); err != nil {
+		windows.CloseHandle(ptyIn)
+		windows.CloseHandle(inWrite)
+		return nil, fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	// The console holds its own references to its ends of the pipes
+	defer windows.CloseHandle(ptyIn)
+	defer windows.CloseHandle(ptyOut)
+	c := &conPTY{in: os.NewFile(uintptr(inWrite), "conpty-in"), out: os.NewFile(uintptr(outRead), "conpty-out")}
+	if err := windows.CreatePseudoConsole(windows.Coord{X: int16(cols), Y: int16(rows)}, ptyIn, ptyOut, 0, &c.console); err != nil {
+		c.in.Close()
+		c.out.Close()
+		return nil, fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+
+	process, err := createProcessInConsole(cmd, c.console)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	pid, err := windows.GetProcessId(process)
+	var p *os.Process
+	if err == nil {
+		p, err = os.FindProcess(int(pid))
+	}
+	if err != nil {
+		windows.TerminateProcess(process, 1)
+		windows.CloseHandle(process)
+		c.Close()
+		return nil, err
+	}
+	cmd.Process = p
+	// Closing the console once the agent exits ends its output after the last of it
+	go func() {
+		windows.WaitForSingleObject(process, windows.INFINITE)
+		windows.CloseHandle(process)
+		c.closeConsole()
+	}()
+	return c, nil
+}
+
+// createProcessInConsole creates cmd's process attached to console and returns its handle
+func createProcessInConsole(cmd *exec.Cmd, console windows.Handle) (windows.Handle, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return 0, err
+	}
+	defer attrs.Delete()
+	// The attribute's value is the console handle itself, not a pointer to it
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)); err != nil {
+		return 0, err
+	}
+	si := &windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	// No standard handles of deepresearch's: the agent reads and writes the console
+	si.Flags = windows.STARTF_USESTDHANDLES
+
+	cmdLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(append([]string{cmd.Path}, cmd.Args[1:]...)))
+	if err != nil {
+		return 0, err
+	}
+	var dir *uint16
+	if cmd.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(cmd.Dir); err != nil {
+			return 0, err
+		}
+	}
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT)
+	var env *uint16
+	if cmd.Env != nil {
+		flags |= windows.CREATE_UNICODE_ENVIRONMENT
+		env = environmentBlock(cmd.Env)
+	}
+	var pi windows.ProcessInformation
+	if err := windows.CreateProcess(nil, cmdLine, nil, nil, false, flags, env, dir, &si.StartupInfo, &pi); err != nil {
+		return 0, fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	windows.CloseHandle(pi.Thread)
+	return pi.Process, nil
+}
+
+// environmentBlock encodes env as a Unicode environment block
+func environmentBlock(env []string) *uint16 {
+	block := utf16.Encode([]rune(strings.Join(env, "\x00") + "\x00\x00"))
+	return &block[0]
+}
+
+// watchTerminalSize resizes t along with the console window until the
+// returned function is called; Windows has no resize signal to wait for
+func watchTerminalSize(t agentTerminal) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		lastCols, lastRows, _ := terminalSize(os.Stdout)
+		for {
+			select {
+			case <-ticker.C:
+				if cols, rows, ok := terminalSize(os.Stdout); ok && (cols != lastCols || rows != lastRows) {
+					lastCols, lastRows = cols, rows
+					t.Resize(cols, rows)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// inputRecord is a console INPUT_RECORD holding a KEY_EVENT_RECORD
+type inputRecord struct {
+	EventType       uint16
+	_               uint16
+	KeyDown         int32
+	RepeatCount     uint16
+	VirtualKeyCode  uint16
+	VirtualScanCode uint16
+	Char            uint16
+	ControlKeyState uint32
+}
+
+const keyEvent = 0x0001
+
+// modifierKeys produce no input of their own
+var modifierKeys = map[uint16]bool{
+	0x10: true, // VK_SHIFT
+	0x11: true, // VK_CONTROL
+	0x12: true, // VK_MENU
+	0x14: true, // VK_CAPITAL
+	0x5b: true, // VK_LWIN
+	0x5c: true, // VK_RWIN
+}
+
+// copyConsoleInput copies stdin to t until the returned function is called.
+// Console input is only read once a key is waiting, so no read is left
+// pending to swallow input meant for deepresearch after the session.
+func copyConsoleInput(t agentTerminal) func() {
+	var stopped sync.WaitGroup
+	done := make(chan struct{})
+	stdin := windows.Handle(os.Stdin.Fd())
+	var mode uint32
+	console := windows.GetConsoleMode(stdin, &mode) == nil
+	stopped.Add(1)
+	go func() {
+		defer stopped.Done()
+		buf := make([]byte, 4096)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if console && !keyWaiting(stdin) {
+				continue
+			}
+			var n uint32
+			if err := windows.ReadFile(stdin, buf, &n, nil); err != nil || n == 0 {
+				return // end of input
+			}
+			if _, err := t.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if !console {
+			// A pipe read cannot be abandoned; it ends with the input
+			return
+		}
+		stopped.Wait()
+	}
+}
+
+// keyWaiting waits briefly for console input and reports whether a key that
+// produces input is waiting; other events (focus, mouse, resize, modifier
+// keys) are discarded, since reading the console would block on them
+func keyWaiting(stdin windows.Handle) bool {
+	if ev, _ := windows.WaitForSingleObject(stdin, 100); ev != windows.WAIT_OBJECT_0 {
+		return false
+	}
+	var rec inputRecord
+	var n uint32
+	if r, _, _ := procPeekConsoleInputW.Call(uintptr(stdin), uintptr(unsafe.Pointer(&rec)), 1, uintptr(unsafe.Pointer(&n))); r == 0 || n == 0 {
+		return false
+	}
+	if rec.EventType == keyEvent && rec.KeyDown != 0 && !modifierKeys[rec.VirtualKeyCode] {
+		return true
+	}
+	procReadConsoleInputW.Call(uintptr(stdin), uintptr(unsafe.Pointer(&rec)), 1, uintptr(unsafe.Pointer(&n)))
+	return false
+}
+
+// enableTerminalOutput has the console interpret the escape sequences of the
+// agent's terminal output until the returned function is called
+func enableTerminalOutput() func() {
+	stdout := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if windows.GetConsoleMode(stdout, &mode) != nil {
+		return func() {}
+	}
+	windows.SetConsoleMode(stdout, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING|windows.ENABLE_PROCESSED_OUTPUT)
+	return func() { windows.SetConsoleMode(stdout, mode) }
+}
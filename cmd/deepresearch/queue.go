@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job states in the serve queue
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+	jobCanceled  = "canceled"
+)
+
+// jobFinished reports whether a job has reached a final state
+func jobFinished(status string) bool {
+	return status == jobCompleted || status == jobFailed || status == jobCanceled
+}
+
+// errJobNotFound is returned by jobStore.Get for unknown IDs
+var errJobNotFound = errors.New("job not found")
+
+// job is one research request submitted to `deepresearch serve`
+type job struct {
+	ID         string   `json:"id"`
+	Tenant     string   `json:"tenant,omitempty"`
+	Prompt     string   `json:"prompt"`
+	Agent      string   `json:"agent,omitempty"`
+	Model      string   `json:"model,omitempty"`
+	Flags      []string `json:"flags,omitempty"`    // extra research flags, e.g. ["--charts=mermaid"]
+	Priority   int      `json:"priority,omitempty"` // higher runs first among the tenant's jobs (-10..10)
+	Status     string   `json:"status"`
+	Attempts   int      `json:"attempts"`
+	ErrorCode  string   `json:"error_code,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Tokens     int      `json:"tokens,omitempty"` // agent tokens the last attempt reported
+	Dir        string   `json:"dir"`
+	CreatedAt  string   `json:"created_at"`
+	StartedAt  string   `json:"started_at,omitempty"`
+	FinishedAt string   `json:"finished_at,omitempty"`
+}
+
+// newJobID returns a sortable unique job ID ("20250101-120000-1a2b3c")
+func newJobID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// jobStore persists jobs and hands queued ones to workers. Put stores a job and,
+// when its status is queued, makes it claimable. Claim passes the queued jobs
+// (oldest first) to pick and atomically marks the chosen one running; it
+// returns nil when the queue is empty or pick chooses nothing.
+type jobStore interface {
+	Put(j *job) error
+	Get(id string) (*job, error)
+	List() ([]*job, error)
+	Claim(pick func(queued []*job) *job) (*job, error)
+	// Update applies change to the stored job and saves it in one step, so a
+	// concurrent writer cannot slip in between; change returns false to leave
+	// the job as it is. It returns the job as stored.
+	Update(id string, change func(j *job) bool) (*job, error)
+	Close() error
+}
+
+// jobStores maps a --queue backend name to its constructor; dsn is backend-specific
+var jobStores = map[string]func(dsn string) (jobStore, error){
+	"memory": func(string) (jobStore, error) { return &memoryJobStore{jobs: map[string]*job{}}, nil },
+}
+
+// registerJobStore adds a queue backend; called from init functions
+func registerJobStore(name string, open func(dsn string) (jobStore, error)) {
+	jobStores[name] = open
+}
+
+// openJobStore opens the named queue backend
+func openJobStore(name, dsn string) (jobStore, error) {
+	open, ok := jobStores[name]
+	if !ok {
+		if name == "sqlite" {
+			return nil, fmt.Errorf("the sqlite queue needs a build with cgo and -tags sqlite")
+		}
+		return nil, fmt.Errorf("unknown queue backend %q (available: %s)", name, strings.Join(sortedKeys(jobStores), ", "))
+	}
+	return open(dsn)
+}
+
+// sortJobs orders jobs by submission time
+func sortJobs(jobs []*job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt != jobs[j].CreatedAt {
+			return jobs[i].CreatedAt < jobs[j].CreatedAt
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
+
+// memoryJobStore keeps jobs in process memory; they are lost on restart
+type memoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func (s *memoryJobStore) Put(j *job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *j
+	s.jobs[j.ID] = &cp
+	return nil
+}
+
+func (s *memoryJobStore) Get(id string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, errJobNotFound
+	}
+	cp := *j
+	return &cp, nil
+}
+
+func (s *memoryJobStore) List() ([]*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*job
+	for _, j := range s.jobs {
+		cp := *j
+		jobs = append(jobs, &cp)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+func (s *memoryJobStore) Claim(pick func(queued []*job) *job) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var queued []*job
+	for _, j := range s.jobs {
+		if j.Status == jobQueued {
+			cp := *j
+			queued = append(queued, &cp)
+		}
+	}
+	if len(queued) == 0 {
+		return nil, nil
+	}
+	sortJobs(queued)
+	next := pick(queued)
+	if next == nil {
+		return nil, nil
+	}
+	s.jobs[next.ID].Status = jobRunning
+	cp := *s.jobs[next.ID]
+	return &cp, nil
+}
+
+func (s *memoryJobStore) Update(id string, change func(j *job) bool) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, errJobNotFound
+	}
+	cp := *j
+	if change(&cp) {
+		saved := cp
+		s.jobs[id] = &saved
+	} else {
+		cp = *j
+	}
+	return &cp, nil
+}
+
+func (s *memoryJobStore) Close() error { return nil }
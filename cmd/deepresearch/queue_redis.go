@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys used by the redis queue backend
+const (
+	redisJobsKey  = "deepresearch:jobs"  // hash of job ID -> job JSON
+	redisQueueKey = "deepresearch:queue" // list of queued job IDs, oldest at the tail
+)
+
+func init() {
+	registerJobStore("redis", openRedisJobStore)
+}
+
+// redisJobStore keeps jobs in Redis so they survive restarts and can be shared
+type redisJobStore struct {
+	rdb *redis.Client
+}
+
+// openRedisJobStore connects to a redis:// URL (default redis://localhost:6379/0)
+func openRedisJobStore(dsn string) (jobStore, error) {
+	if dsn == "" {
+		dsn = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return &redisJobStore{rdb: rdb}, nil
+}
+
+func (s *redisJobStore) Put(j *job) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, redisJobsKey, j.ID, data)
+	if j.Status == jobQueued {
+		pipe.LRem(ctx, redisQueueKey, 0, j.ID)
+		pipe.LPush(ctx, redisQueueKey, j.ID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisJobStore) Get(id string) (*job, error) {
+	data, err := s.rdb.HGet(context.Background(), redisJobsKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j job
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (s *redisJobStore) List() ([]*job, error) {
+	all, err := s.rdb.HGetAll(context.Background(), redisJobsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*job
+	for _, data := range all {
+		var j job
+		if json.Unmarshal([]byte(data), &j) == nil {
+			jobs = append(jobs, &j)
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// Claim removes the picked ID from the queue list; LREM is atomic, so when
+// several servers pick the same job only the one that removed it runs it
+func (s *redisJobStore) Claim(pick func(queued []*job) *job) (*job, error) {
+	ctx := context.Background()
+	for {
+		ids, err := s.rdb.LRange(ctx, redisQueueKey, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		var queued []*job
+		for i := len(ids) - 1; i >= 0; i-- { // oldest at the tail
+			j, err := s.Get(ids[i])
+			if err != nil && !errors.Is(err, errJobNotFound) {
+				return nil, err
+			}
+			if j == nil || j.Status != jobQueued {
+				s.rdb.LRem(ctx, redisQueueKey, 0, ids[i]) // stale entry
+				continue
+			}
+			queued = append(queued, j)
+		}
+		if len(queued) == 0 {
+			return nil, nil
+		}
+		j := pick(queued)
+		if j == nil {
+			return nil, nil
+		}
+		n, err := s.rdb.LRem(ctx, redisQueueKey, 0, j.ID).Result()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue // another server claimed it first
+		}
+		j.Status = jobRunning
+		data, _ := json.Marshal(j)
+		if err := s.rdb.HSet(ctx, redisJobsKey, j.ID, data).Err(); err != nil {
+			return nil, err
+		}
+		return j, nil
+	}
+}
+
+// Update watches the jobs hash and retries when another server changed it
+// before the transaction ran
+func (s *redisJobStore) Update(id string, change func(j *job) bool) (*job, error) {
+	ctx := context.Background()
+	for {
+		var result *job
+		err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			data, err := tx.HGet(ctx, redisJobsKey, id).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return errJobNotFound
+			}
+			if err != nil {
+				return err
+			}
+			var j job
+			if err := json.Unmarshal(data, &j); err != nil {
+				return err
+			}
+			stored := j
+			if !change(&j) {
+				result = &stored
+				return nil
+			}
+			updated, err := json.Marshal(&j)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, redisJobsKey, j.ID, updated)
+				if j.Status == jobQueued {
+					pipe.LRem(ctx, redisQueueKey, 0, j.ID)
+					pipe.LPush(ctx, redisQueueKey, j.ID)
+				}
+				return nil
+			})
+			result = &j
+			return err
+		}, redisJobsKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func (s *redisJobStore) Close() error { return s.rdb.Close() }
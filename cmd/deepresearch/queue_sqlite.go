@@ -0,0 +1,164 @@
+//go:build sqlite
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	registerJobStore("sqlite", openSQLiteJobStore)
+}
+
+// sqliteJobStore keeps jobs in a local SQLite database (needs cgo; build with -tags sqlite)
+type sqliteJobStore struct {
+	db *sql.DB
+}
+
+// openSQLiteJobStore opens or creates the database file named by dsn (default jobs.db)
+func openSQLiteJobStore(dsn string) (jobStore, error) {
+	if dsn == "" {
+		dsn = "jobs.db"
+	}
+	// Immediate transactions serialize Claim across connections
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite3", dsn+sep+"_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		data TEXT NOT NULL)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteJobStore{db: db}, nil
+}
+
+func (s *sqliteJobStore) Put(j *job) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO jobs (id, status, created_at, data) VALUES (?, ?, ?, ?)`,
+		j.ID, j.Status, j.CreatedAt, string(data))
+	return err
+}
+
+func (s *sqliteJobStore) Get(id string) (*job, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM jobs WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j job
+	return &j, json.Unmarshal([]byte(data), &j)
+}
+
+func (s *sqliteJobStore) List() ([]*job, error) {
+	rows, err := s.db.Query(`SELECT data FROM jobs ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []*job
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var j job
+		if json.Unmarshal([]byte(data), &j) == nil {
+			jobs = append(jobs, &j)
+		}
+	}
+	return jobs, rows.Err()
+}
+
+func (s *sqliteJobStore) Claim(pick func(queued []*job) *job) (*job, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(`SELECT data FROM jobs WHERE status = ? ORDER BY created_at, id`, jobQueued)
+	if err != nil {
+		return nil, err
+	}
+	var queued []*job
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var j job
+		if json.Unmarshal([]byte(data), &j) == nil {
+			queued = append(queued, &j)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(queued) == 0 {
+		return nil, nil
+	}
+	j := pick(queued)
+	if j == nil {
+		return nil, nil
+	}
+	j.Status = jobRunning
+	updated, _ := json.Marshal(j)
+	if _, err := tx.Exec(`UPDATE jobs SET status = ?, data = ? WHERE id = ?`, j.Status, string(updated), j.ID); err != nil {
+		return nil, err
+	}
+	return j, tx.Commit()
+}
+
+func (s *sqliteJobStore) Update(id string, change func(j *job) bool) (*job, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var data string
+	err = tx.QueryRow(`SELECT data FROM jobs WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j job
+	if err := json.Unmarshal([]byte(data), &j); err != nil {
+		return nil, err
+	}
+	stored := j
+	if !change(&j) {
+		return &stored, nil
+	}
+	updated, err := json.Marshal(&j)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE jobs SET status = ?, data = ? WHERE id = ?`, j.Status, string(updated), j.ID); err != nil {
+		return nil, err
+	}
+	return &j, tx.Commit()
+}
+
+func (s *sqliteJobStore) Close() error { return s.db.Close() }
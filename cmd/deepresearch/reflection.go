@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// reflectionConfig sets when the research/reflection loop ends before
+// run.max_iterations while the reflector still asks for more research
+type reflectionConfig struct {
+	StopConfidence float64 `yaml:"stop_confidence"` // same as --stop-confidence
+	StopStalled    int     `yaml:"stop_stalled"`    // same as --stop-stalled
+	MaxMinutes     int     `yaml:"max_minutes"`     // same as --max-research-minutes
+}
+
+// policy is the stop policy the config asks for
+func (c reflectionConfig) policy() orchestrator.StopPolicy {
+	return orchestrator.StopPolicy{
+		Confidence:  c.StopConfidence,
+		Stalled:     c.StopStalled,
+		MaxDuration: time.Duration(c.MaxMinutes) * time.Minute,
+	}
+}
+
+// stopReasonLabels name the reasons the loop ends for in the summary
+var stopReasonLabels = map[orchestrator.StopReason]string{
+	orchestrator.StopSufficient:    "research sufficient",
+	orchestrator.StopConfidence:    "confidence reached",
+	orchestrator.StopStalled:       "no progress",
+	orchestrator.StopTimeLimit:     "time limit reached",
+	orchestrator.StopMaxIterations: "iterations exhausted",
+	orchestrator.StopContinue:      "budget reached",
+}
+
+// reflectionFields are the log fields of what an iteration achieved
+func reflectionFields(r *orchestrator.Reflection) map[string]string {
+	fields := map[string]string{
+		"recommendation": r.Recommendation,
+		"new_tasks":      strconv.Itoa(r.NewTasks),
+		"new_findings":   strconv.Itoa(r.NewFindings),
+		"pending":        strconv.Itoa(r.Pending),
+	}
+	if r.Confidence != nil {
+		fields["confidence"] = fmt.Sprintf("%.2f", *r.Confidence)
+	}
+	if r.OpenQuestions >= 0 {
+		fields["open_questions"] = strconv.Itoa(r.OpenQuestions)
+	}
+	return fields
+}
+
+// recordLoopEnd logs why the research loop ended and keeps it in the run
+// manifest and the checkpoint, so a resumed run goes on to synthesis
+func recordLoopEnd(loop *orchestrator.LoopEnd) {
+	logEntry("INFO", "LOOP_END", loop.Iteration, "Research loop ended: "+loop.Detail, map[string]string{
+		"reason":     string(loop.Reason),
+		"iterations": strconv.Itoa(loop.Iteration),
+	})
+	switch loop.Reason {
+	case orchestrator.StopConfidence:
+		info("Ending the research loop: the reflector's confidence reached the --stop-confidence threshold")
+	case orchestrator.StopStalled:
+		warn("Ending the research loop: %d iteration(s) in a row made no progress (--stop-stalled)", config.Reflection.StopStalled)
+	case orchestrator.StopTimeLimit:
+		warn("Ending the research loop: it reached its limit of %d minute(s) (--max-research-minutes)", config.Reflection.MaxMinutes)
+	}
+	if manifest != nil {
+		manifest.Loop = loop
+		manifest.save()
+	}
+	progress.loopEnded(loop.Reason)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/researchpb"
+)
+
+// workerPollWait is how long a worker waits for a task before asking again
+const workerPollWait = 30 * time.Second
+
+// packFiles archives the named files and directories under dir as tar.gz.
+// Only files changed at or after since are included (zero time = all).
+func packFiles(dir string, paths []string, since time.Time) ([]byte, int, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	n := 0
+	for _, p := range paths {
+		err := filepath.WalkDir(filepath.Join(dir, p), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil || !fi.Mode().IsRegular() || fi.ModTime().Before(since) {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(data)), ModTime: fi.ModTime()}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			n++
+			_, err = tw.Write(data)
+			return err
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), n, nil
+}
+
+// unpackFiles extracts a packFiles archive into dir, rejecting paths that would
+// escape it
+func unpackFiles(dir string, data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(gz)
+	n := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !filepath.IsLocal(hdr.Name) {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return n, err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return n, err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// buildRemoteExecutorsNote tells the supervisor to hand E* tasks to remote
+// workers instead of launching executor agents itself, or "" without a broker
+func buildRemoteExecutorsNote(workDir string) string {
+	if config.Workers.Broker == "" {
+		return ""
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "deepresearch"
+	}
+	cmd := fmt.Sprintf("%s dispatch -dir %s", exe, workDir)
+	if configFile != "" {
+		cmd += " -config " + configFile
+	}
+	return fmt.Sprintf(`
+REMOTE EXECUTORS: Executor tasks run on remote workers. Do NOT launch executor agent CLIs yourself.
+Write tmp/[TASK_ID]_prompt.txt for every task in a batch as usual, then run ONE blocking command for the batch:
+  %s E1 E2 E3
+It waits until the workers finish and copies their logs/[TASK_ID]_result.md, logs/[TASK_ID].log and assets/
+into WORKING_DIR; it exits non-zero if any task failed (the output names them). Then update task.md as usual.
+`, cmd)
+}
+
+// runDispatch implements `deepresearch dispatch`: it hands executor tasks whose
+// prompts are in tmp/ to remote workers and collects their results
+func runDispatch(args []string) {
+	fs := flag.NewFlagSet("dispatch", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Research directory")
+	configPath := fs.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch dispatch [-dir DIR] [-config FILE] TASK_ID...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	workDir, err := filepath.Abs(*dir)
+	if err != nil {
+		fatal("Failed to resolve research directory: %v", err)
+	}
+	broker, err := openTaskBroker(config.Workers)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Cannot connect to the workers broker: %v", err)
+	}
+	defer broker.Close()
+	// Inputs every executor may read, and the manifest for the workspace layout;
+	// its outputs come back in the result
+	inputs, _, err := packFiles(workDir, []string{taskFileName(workDir), contextDirName, manifestFileName}, time.Time{})
+	if err != nil {
+		fatal("Cannot pack task inputs: %v", err)
+	}
+
+	var mu sync.Mutex
+	var failed []string
+	var wg sync.WaitGroup
+	for _, id := range fs.Args() {
+		prompt, err := os.ReadFile(filepath.Join(workDir, "tmp", id+"_prompt.txt"))
+		if err != nil {
+			warn("%s: %v", id, err)
+			mu.Lock()
+			failed = append(failed, id)
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			worker, n, err := dispatchRemote(context.Background(), broker, workDir, id, string(prompt), inputs)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				warn("%s failed: %v", id, err)
+				failed = append(failed, id)
+				return
+			}
+			success("%s completed by %s in %s (%d file(s))", id, worker, time.Since(start).Round(time.Second), n)
+		}()
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		fatal("%d task(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+}
+
+// dispatchRemote runs one executor task on a remote worker and copies what it
+// wrote into workDir. It returns the worker's name and the number of files.
+func dispatchRemote(ctx context.Context, broker taskBroker, workDir, id, prompt string, inputs []byte) (string, int, error) {
+	exe, _ := os.Executable()
+	promptsDir, _ := findPromptsDir()
+	t := &researchpb.Task{
+		Id: newJobID() + "-" + id, TaskId: id, Prompt: prompt,
+		WorkDir: workDir, PromptsDir: promptsDir, Exe: exe, Files: inputs,
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.Workers.TimeoutMinutes)*time.Minute)
+	defer cancel()
+	r, err := broker.Dispatch(ctx, t)
+	if err != nil {
+		return "", 0, err
+	}
+	if r.Error != "" {
+		return r.Worker, 0, fmt.Errorf("worker %s: %s", r.Worker, r.Error)
+	}
+	n, err := unpackFiles(workDir, r.Files)
+	return r.Worker, n, err
+}
+
+// runWorker implements `deepresearch worker`: it pulls executor tasks from the
+// broker and runs them with this machine's agent CLI and credentials
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	agent := fs.String("agent", "", "Agent to use: copilot, claude, gemini or one defined in ~/.deepresearch/agents.yaml (auto-detect if not specified)")
+	model := fs.String("model", "", "Model to use")
+	slots := fs.Int("slots", 0, "Tasks to run concurrently (default from config: 1)")
+	name := fs.String("name", "", "Worker name reported with results (default: host name)")
+	dir := fs.String("dir", "", "Directory for task workspaces (default: system temp)")
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	mustLoadAgents()
+	if *slots > 0 {
+		config.Workers.Slots = *slots
+	}
+	if *name == "" {
+		host, _ := os.Hostname()
+		*name = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	agentName := *agent
+	if agentName == "" {
+		if agentName = detectAgent(); agentName == "" {
+			fatalCode(codeAgentNotFound, "No supported agent CLI found. Install one of: %s", agentNames())
+		}
+	} else if _, ok := agentConfigs[agentName]; !ok {
+		fatalCode(codeValidationFailed, "Unknown agent: %s. Supported: %s", agentName, agentNames())
+	}
+	promptsDir, err := findPromptsDir()
+	if err != nil {
+		fatal("Cannot load prompts: %v", err)
+	}
+	broker, err := openTaskBroker(config.Workers)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Cannot connect to the workers broker: %v", err)
+	}
+	defer broker.Close()
+
+	info("Worker %s waiting for executor tasks (%s broker, agent %s, %d slot(s))", *name, config.Workers.Broker, agentName, config.Workers.Slots)
+	// Stop pulling tasks and kill the running executors on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	for i := 0; i < config.Workers.Slots; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				t, err := broker.Next(ctx, *name, workerPollWait)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					warn("Cannot fetch a task: %v", err)
+					time.Sleep(workerPollWait / 6)
+					continue
+				}
+				if t == nil {
+					continue
+				}
+				info("Task %s (%s) started", t.TaskId, t.Id)
+				r := runRemoteTask(ctx, t, *name, agentName, *model, promptsDir, *dir)
+				if err := broker.Complete(context.Background(), r); err != nil {
+					warn("Cannot report task %s: %v", t.TaskId, err)
+				} else if r.Error != "" {
+					warn("Task %s failed: %s", t.TaskId, r.Error)
+				} else {
+					success("Task %s completed", t.TaskId)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runRemoteTask runs one executor task in a scratch workspace and packs what the
+// executor wrote
+func runRemoteTask(ctx context.Context, t *researchpb.Task, worker, agentName, model, promptsDir, root string) *researchpb.TaskResult {
+	r := &researchpb.TaskResult{Id: t.Id, Worker: worker}
+	if !filepath.IsLocal(t.TaskId) {
+		r.Error = "invalid task ID"
+		return r
+	}
+	workDir, err := os.MkdirTemp(root, "deepresearch-"+t.TaskId+"-")
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer os.RemoveAll(workDir)
+	if _, err := unpackFiles(workDir, t.Files); err != nil {
+		r.Error = fmt.Sprintf("cannot unpack task inputs: %v", err)
+		return r
+	}
+	createDirs(workDir)
+	os.MkdirAll(filepath.Join(workDir, "tmp"), 0755)
+
+	// The prompt names paths on the dispatching machine, or prompts/ relative to it
+	exe, _ := os.Executable()
+	var pairs []string
+	for _, p := range [][2]string{{t.PromptsDir, promptsDir}, {t.WorkDir, workDir}, {t.Exe, exe}, {"prompts/deep-research", promptsDir}} {
+		if p[0] != "" {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	local := strings.NewReplacer(pairs...)
+	promptFile := filepath.Join("tmp", t.TaskId+"_prompt.txt")
+	if err := os.WriteFile(filepath.Join(workDir, promptFile), []byte(local.Replace(t.Prompt)), 0644); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	start := time.Now().Add(-time.Second)
+	err = runAgent(ctx, agentName, model, fmt.Sprintf("Read %s and follow ALL instructions in that file.", filepath.ToSlash(promptFile)), workDir)
+	if err != nil {
+		r.Error = err.Error()
+	}
+	resultFile := filepath.Join(workDir, "logs", t.TaskId+"_result.md")
+	if data, err := os.ReadFile(resultFile); err == nil {
+		// Point paths in the result back at the dispatching run
+		os.WriteFile(resultFile, []byte(strings.ReplaceAll(string(data), workDir, t.WorkDir)), 0644)
+	} else if r.Error == "" {
+		r.Error = fmt.Sprintf("executor did not write logs/%s_result.md", t.TaskId)
+	}
+	if r.Files, _, err = packFiles(workDir, []string{assetsDirName(workDir), "logs"}, start); err != nil && r.Error == "" {
+		r.Error = fmt.Sprintf("cannot pack results: %v", err)
+	}
+	return r
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown is the shared Markdown renderer (GitHub-flavored). Raw HTML and
+// javascript: links are dropped: reports quote fetched pages, and the output
+// is served by the viewer and shared as report.html.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, extension.Footnote),
+)
+
+// renderMarkdown converts Markdown source into an HTML fragment
+func renderMarkdown(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Replay modes for --replay-from
+const (
+	replaySynthesis = "synthesis" // write a new report from the recorded findings
+	replayResearch  = "research"  // re-run the research loop on the recorded plan, then synthesize
+)
+
+// offlineEnv is set for agents (and the `deepresearch tool` calls they make)
+// during a replay, which must only use the prior run's cached sources
+const offlineEnv = "DEEPRESEARCH_OFFLINE"
+
+// offline reports whether this process runs inside a replay
+func offline() bool {
+	return os.Getenv(offlineEnv) == "1"
+}
+
+// replayed reports whether rel (slash-separated, relative to the workspace) is
+// taken over by a replay into workDir, which is laid out as the prior run: its
+// task.md, pre-approved sources, assets/ and context/. Everything else
+// (report, logs, manifests) is produced anew.
+func replayed(workDir, rel string) bool {
+	for _, f := range []string{taskFileName(workDir), preapprovedSourcesFile} {
+		if rel == f {
+			return true
+		}
+	}
+	for _, d := range []string{assetsDirName(workDir), contextDirName} {
+		if strings.HasPrefix(rel, d+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isBundle reports whether src is a --repro-bundle archive rather than a workspace
+func isBundle(src string) bool {
+	st, err := os.Stat(src)
+	return err == nil && !st.IsDir()
+}
+
+// readReplayManifest returns the run manifest of the prior run in src
+func readReplayManifest(src string) (*runManifest, error) {
+	if !isBundle(src) {
+		return loadManifest(src)
+	}
+	var m *runManifest
+	err := walkBundle(src, func(rel string, r io.Reader) error {
+		if rel != manifestFileName {
+			return nil
+		}
+		m = &runManifest{}
+		return json.NewDecoder(r).Decode(m)
+	})
+	if err == nil && m == nil {
+		err = fmt.Errorf("%s has no %s", src, manifestFileName)
+	}
+	return m, err
+}
+
+// walkBundle calls fn for each workspace file in a reproducibility bundle
+func walkBundle(path string, fn func(rel string, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a reproducibility bundle: %w", path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, "workspace/")
+		if !ok || hdr.Typeflag != tar.TypeReg || !filepath.IsLocal(rel) {
+			continue
+		}
+		if err := fn(rel, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeStream copies r to path, creating parent directories
+func writeStream(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// seedReplay copies the recorded plan, findings and cached sources of the prior
+// run in src (a workspace or a --repro-bundle archive) into workDir, restoring
+// compressed and archived assets to their original paths. Returns the number
+// of files copied.
+func seedReplay(src, workDir string) (int, error) {
+	if fileExists(taskFilePath(workDir)) {
+		return 0, fmt.Errorf("%s already holds a run (%s); replay into an empty directory", workDir, taskFileName(workDir))
+	}
+	n := 0
+	copyFile := func(rel string, r io.Reader) error {
+		if !replayed(workDir, rel) {
+			return nil
+		}
+		n++
+		return writeStream(filepath.Join(workDir, filepath.FromSlash(rel)), r)
+	}
+	if isBundle(src) {
+		if err := walkBundle(src, copyFile); err != nil {
+			return n, err
+		}
+	} else {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return 0, err
+		}
+		if abs == workDir {
+			return 0, fmt.Errorf("cannot replay a run into its own workspace")
+		}
+		err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(abs, path)
+			rel = filepath.ToSlash(rel)
+			if !replayed(workDir, rel) {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return copyFile(rel, f)
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	if !fileExists(taskFilePath(workDir)) {
+		return n, fmt.Errorf("%s has no %s to replay", src, taskFileName(workDir))
+	}
+	return n, restoreStoredAssets(workDir)
+}
+
+// restoreStoredAssets undoes storage.compress_kb and storage.archive_dir in a
+// seeded workspace so agents find every source at its registered path. The
+// asset manifest is removed afterwards; the run rebuilds it.
+func restoreStoredAssets(workDir string) error {
+	m, err := loadAssetManifest(workDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, a := range m.Assets {
+		if a.Stored == "" {
+			continue
+		}
+		path := filepath.Join(workDir, filepath.FromSlash(a.Path))
+		if a.Text != "" {
+			os.Remove(filepath.Join(workDir, filepath.FromSlash(a.Text)))
+		}
+		if fileExists(path) {
+			continue
+		}
+		if filepath.IsAbs(a.Stored) {
+			f, err := os.Open(a.Stored)
+			if err != nil {
+				warn("Archived asset %s is unavailable: %v", a.Path, err)
+				continue
+			}
+			err = writeStream(path, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		gzPath := filepath.Join(workDir, filepath.FromSlash(a.Stored))
+		f, err := os.Open(gzPath)
+		if err != nil {
+			warn("Compressed asset %s is unavailable: %v", a.Path, err)
+			continue
+		}
+		zr, err := gzip.NewReader(f)
+		if err == nil {
+			err = writeStream(path, zr)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", a.Stored, err)
+		}
+		os.Remove(gzPath)
+	}
+	return os.Remove(assetsPath(workDir, assetManifestName))
+}
+
+// buildReplayNote keeps agents of a replay to the prior run's material, or ""
+// outside a replay
+func buildReplayNote() string {
+	if !offline() {
+		return ""
+	}
+	return `
+OFFLINE REPLAY: This run replays a prior run for comparison. Use ONLY the sources already archived
+under assets/ and the findings in task.md. Do not browse, search or download anything, and do not
+call ` + "`deepresearch tool`" + ` (it is disabled). If the material is insufficient, record the gap instead
+of fetching more.
+`
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// reportConfig sets the language of the report: the synthesizer writes it in
+// that language, or a translator translates it after synthesis (the
+// TRANSLATOR phase), writing report.<lang>.md next to report.md
+type reportConfig struct {
+	// Languages are ISO 639-1 codes, same as --report-lang: the synthesizer
+	// writes report.md in the only one, or, with Translate, the translator
+	// translates it into each
+	Languages []string `yaml:"languages"`
+	Translate bool     `yaml:"translate"` // same as --translate-report
+}
+
+// language is the language the synthesizer writes report.md in, "" for the request's
+func (c reportConfig) language() string {
+	if c.Translate || len(c.Languages) == 0 {
+		return ""
+	}
+	return c.Languages[0]
+}
+
+// translations are the languages the translator translates report.md into
+func (c reportConfig) translations() []string {
+	if !c.Translate {
+		return nil
+	}
+	return c.Languages
+}
+
+// parseReportLanguages validates a comma-separated --report-lang value
+func parseReportLanguages(s string) ([]string, error) {
+	var langs []string
+	for _, lang := range strings.Split(s, ",") {
+		if lang = strings.TrimSpace(lang); lang == "" {
+			continue
+		}
+		if err := orchestrator.CheckLanguage(lang); err != nil {
+			return nil, err
+		}
+		if slices.Contains(langs, lang) {
+			return nil, fmt.Errorf("%s is listed twice", lang)
+		}
+		langs = append(langs, lang)
+	}
+	return langs, nil
+}
+
+// checkReportLanguage warns when the report, or a translation of it, at rel
+// in workDir reads as another language than lang. Languages detectLanguage
+// cannot tell, and reports too short to tell, pass.
+func checkReportLanguage(workDir, rel, lang string, iteration int) {
+	want, _, _ := strings.Cut(lang, "-")
+	if !detectsLanguage(want) {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return
+	}
+	// Link targets are URLs and paths, in no language
+	got := detectLanguage(mdLinkRe.ReplaceAllString(string(data), "$1"))
+	if got == "" || got == want {
+		return
+	}
+	logEntry("WARN", "REPORT_LANGUAGE", iteration, "Report is not in the requested language", map[string]string{
+		"file":     rel,
+		"expected": lang,
+		"detected": got,
+	})
+	warn("%s reads as %s rather than %s", rel, got, lang)
+}
+
+// checkTranslation warns about the citations and figures of report.md that its
+// translation at rel in workDir lacks
+func checkTranslation(workDir, rel string, iteration int) {
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return
+	}
+	translation, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return
+	}
+	lost := func(re *regexp.Regexp) []string {
+		kept := map[string]bool{}
+		for _, m := range re.FindAllStringSubmatch(string(translation), -1) {
+			kept[m[1]] = true
+		}
+		var lost []string
+		for _, m := range re.FindAllStringSubmatch(string(report), -1) {
+			if !kept[m[1]] && !slices.Contains(lost, m[1]) {
+				lost = append(lost, m[1])
+			}
+		}
+		return lost
+	}
+	citations, figures := lost(citationRe), lost(imageRefRe)
+	if len(citations) == 0 && len(figures) == 0 {
+		return
+	}
+	logEntry("WARN", "TRANSLATION", iteration, "Translation lacks citations or figures of the report", map[string]string{
+		"file":      rel,
+		"citations": strings.Join(citations, ","),
+		"figures":   strings.Join(figures, ","),
+	})
+	if len(citations) > 0 {
+		warn("%s lacks %d citation(s) of %s: %s", rel, len(citations), reportFileName(workDir), strings.Join(citations, ", "))
+	}
+	if len(figures) > 0 {
+		warn("%s lacks %d figure(s) of %s: %s", rel, len(figures), reportFileName(workDir), strings.Join(figures, ", "))
+	}
+}
+
+// translatedReports are the translations of the report in workDir, relative to it
+func translatedReports(workDir string) []string {
+	pattern := filepath.Join(workDir, filepath.FromSlash(workspaceLayout(workDir).TranslationFile("*")))
+	matches, _ := filepath.Glob(pattern)
+	var files []string
+	for _, m := range matches {
+		if rel, err := filepath.Rel(workDir, m); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	return files
+}
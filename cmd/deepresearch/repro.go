@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reproBundleName is the archive --repro-bundle writes to the working directory
+const reproBundleName = "repro-bundle.tar.gz"
+
+// reproWorkspace returns the files and directories of workDir a
+// reproducibility bundle captures (whatever the privacy policy left behind)
+func reproWorkspace(workDir string) ([]string, []string) {
+	files := []string{taskFileName(workDir), reportFileName(workDir), manifestFileName, resultFileName, failureFileName}
+	dirs := []string{assetsDirName(workDir), "logs", "tmp", sectionsDirName, contextDirName}
+	return files, dirs
+}
+
+// bundleIndex is bundle.json, the table of contents of a reproducibility bundle
+type bundleIndex struct {
+	CreatedAt time.Time        `json:"created_at"`
+	RunID     string           `json:"run_id,omitempty"`
+	Files     []artifactRecord `json:"files"`
+}
+
+// bundleWriter streams files into a tar.gz, recording their checksums
+type bundleWriter struct {
+	tw    *tar.Writer
+	index bundleIndex
+}
+
+// add copies the file at path into the bundle as name; missing files are skipped
+func (b *bundleWriter) add(name, path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil || !st.Mode().IsRegular() {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: st.Size(), ModTime: st.ModTime()}
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(b.tw, h), f); err != nil {
+		return err
+	}
+	b.index.Files = append(b.index.Files, artifactRecord{Path: name, Size: st.Size(), SHA256: hex.EncodeToString(h.Sum(nil))})
+	return nil
+}
+
+// addBytes stores data in the bundle as name
+func (b *bundleWriter) addBytes(name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	b.index.Files = append(b.index.Files, artifactRecord{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	return nil
+}
+
+// addDir adds every file under dir, named prefix/<path relative to dir>
+func (b *bundleWriter) addDir(prefix, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return b.add(prefix+"/"+filepath.ToSlash(rel), path)
+	})
+}
+
+// envRefRe matches a value that only names an environment variable, optionally
+// after an authorization scheme such as Bearer
+var envRefRe = regexp.MustCompile(`^([A-Za-z]+ )?\$(\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)$`)
+
+// envRefOnly returns s if it is a $NAME reference, and "" if it may hold a
+// literal secret
+func envRefOnly(s string) string {
+	if envRefRe.MatchString(s) {
+		return s
+	}
+	return ""
+}
+
+// envRefHeaders returns headers with every literal value blanked
+func envRefHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := maps.Clone(headers)
+	for k, v := range out {
+		out[k] = envRefOnly(v)
+	}
+	return out
+}
+
+// bundleConfig returns the effective configuration of the run for the bundle.
+// Secrets are only ever named by environment variable: webhook URLs and
+// headers given literally are blanked. Server settings, which may hold DSN
+// credentials, do not affect a run and are left out.
+func bundleConfig() ([]byte, error) {
+	cfg := config
+	cfg.Server = serverConfig{}
+	if u, err := url.Parse(cfg.Workers.Addr); err == nil && u.User != nil {
+		u.User = nil
+		cfg.Workers.Addr = u.String()
+	}
+	cfg.Notify.Webhook = envRefOnly(cfg.Notify.Webhook)
+	cfg.Notify.Slack = envRefOnly(cfg.Notify.Slack)
+	cfg.Notify.Headers = envRefHeaders(cfg.Notify.Headers)
+	cfg.Observability.OTLPHeaders = envRefHeaders(cfg.Observability.OTLPHeaders)
+	return yaml.Marshal(cfg)
+}
+
+// writeReproBundle packs what is needed to understand or replay the run into
+// repro-bundle.tar.gz: the prompts, the effective config, the run manifest with
+// agent and model versions, fetched assets (including archived ones), task.md,
+// the report, generated agent instructions and transcripts. bundle.json lists
+// every file with its checksum. Returns the bundle path and file count.
+func writeReproBundle(workDir, promptsDir string) (string, int, error) {
+	path := filepath.Join(workDir, reproBundleName)
+	f, err := os.CreateTemp(workDir, reproBundleName+".*.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	gz := gzip.NewWriter(f)
+	b := &bundleWriter{tw: tar.NewWriter(gz), index: bundleIndex{CreatedAt: time.Now()}}
+	if manifest != nil {
+		b.index.RunID = manifest.RunID
+	}
+
+	err = func() error {
+		files, dirs := reproWorkspace(workDir)
+		for _, name := range files {
+			if err := b.add("workspace/"+name, filepath.Join(workDir, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+		for _, dir := range dirs {
+			if err := b.addDir("workspace/"+dir, filepath.Join(workDir, filepath.FromSlash(dir))); err != nil {
+				return err
+			}
+		}
+		// Raw assets moved to storage.archive_dir go back to their workspace paths
+		if m, err := loadAssetManifest(workDir); err == nil && config.Privacy.PersistFetched {
+			for _, a := range m.Assets {
+				if filepath.IsAbs(a.Stored) {
+					if err := b.add("workspace/"+a.Path, a.Stored); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err := b.addDir("prompts", promptsDir); err != nil {
+			return err
+		}
+		cfg, err := bundleConfig()
+		if err != nil {
+			return err
+		}
+		if err := b.addBytes("config.yaml", cfg); err != nil {
+			return err
+		}
+		index, err := json.MarshalIndent(b.index, "", "  ")
+		if err != nil {
+			return err
+		}
+		return b.addBytes("bundle.json", index)
+	}()
+	if err == nil {
+		err = b.tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", 0, err
+	}
+	return path, len(b.index.Files), nil
+}
+
+// reproBundleOmissions names what the privacy policy kept out of the bundle
+func reproBundleOmissions() []string {
+	p := config.Privacy
+	var omitted []string
+	if p.Ephemeral || !p.PersistPrompts {
+		omitted = append(omitted, "agent instructions")
+	}
+	if p.Ephemeral || !p.PersistTranscripts {
+		omitted = append(omitted, "transcripts")
+	}
+	if !p.PersistFetched {
+		omitted = append(omitted, "fetched sources")
+	}
+	return omitted
+}
+
+// exportReproBundle writes the bundle at the end of a run, reporting the outcome
+func exportReproBundle(workDir, promptsDir string) {
+	path, n, err := writeReproBundle(workDir, promptsDir)
+	if err != nil {
+		warn("Reproducibility bundle failed: %v", err)
+		return
+	}
+	info("Reproducibility bundle (%d files): %s", n, path)
+	if omitted := reproBundleOmissions(); len(omitted) > 0 {
+		warn("The privacy policy kept %s out of the bundle", strings.Join(omitted, ", "))
+	}
+}
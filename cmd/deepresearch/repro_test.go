@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBundleConfigDropsLiteralSecrets(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.Notify.Webhook = "https://hooks.example.com/run?token=literal-webhook"
+	config.Notify.Slack = "https://hooks.slack.com/services/T0/B0/literal-slack"
+	config.Notify.Headers = map[string]string{"Authorization": "Bearer literal-header", "X-Token": "$HOOK_TOKEN", "Proxy-Authorization": "Bearer $PROXY_TOKEN"}
+	config.Observability.OTLPHeaders = map[string]string{"api-key": "literal-otlp", "x-key": "${OTLP_KEY}"}
+
+	data, err := bundleConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, secret := range []string{"literal-webhook", "literal-slack", "literal-header", "literal-otlp"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("bundle config contains %q:\n%s", secret, out)
+		}
+	}
+	for _, ref := range []string{"$HOOK_TOKEN", "Bearer $PROXY_TOKEN", "${OTLP_KEY}"} {
+		if !strings.Contains(out, ref) {
+			t.Errorf("bundle config lost the reference %q:\n%s", ref, out)
+		}
+	}
+	if config.Notify.Headers["Authorization"] != "Bearer literal-header" {
+		t.Error("bundleConfig modified the run's config")
+	}
+}
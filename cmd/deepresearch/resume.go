@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// stateDirName holds orchestrator state inside the workspace
+const stateDirName = ".deepresearch"
+
+// stateFileName is the checkpoint --resume continues from
+const stateFileName = "state.json"
+
+// Global run progress, nil until initState or --resume sets it
+var progress *runState
+
+// runState records how far a run got, rewritten after every phase that
+// completes so an interrupted run can pick up where it stopped
+type runState struct {
+	SchemaVersion int    `json:"schema_version"`
+	RunID         string `json:"run_id"`
+	Topic         string `json:"topic"` // redactedTopic unless prompts may be persisted
+	Agent         string `json:"agent"`
+	Model         string `json:"model,omitempty"`
+	Phase         string `json:"phase"`               // last phase that completed, "" if none
+	Iteration     int    `json:"iteration,omitempty"` // research iteration Phase completed in
+	// LoopEnded is why the research loop ended, once it has
+	LoopEnded string    `json:"loop_ended,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	path string
+}
+
+// statePath returns the checkpoint path of a workspace
+func statePath(workDir string) string {
+	return filepath.Join(workDir, stateDirName, stateFileName)
+}
+
+// initState starts the checkpoint of a new run
+func initState(workDir, runID, topic, agentName, model string) {
+	progress = &runState{
+		SchemaVersion: 1,
+		RunID:         runID,
+		Topic:         topic,
+		Agent:         agentName,
+		Model:         model,
+		path:          statePath(workDir),
+	}
+	progress.save()
+}
+
+// loadState reads the checkpoint of an interrupted run in workDir
+func loadState(workDir string) (*runState, error) {
+	path := statePath(workDir)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("nothing to resume: %s not found (it is removed when a run completes)", filepath.Join(stateDirName, stateFileName))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s runState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", stateFileName, err)
+	}
+	s.path = path
+	return &s, nil
+}
+
+// completed records that a phase finished successfully
+func (s *runState) completed(phase string, iteration int) {
+	if s == nil {
+		return
+	}
+	s.Phase = phase
+	s.Iteration = iteration
+	s.save()
+}
+
+// loopEnded records that the research loop ended, and why
+func (s *runState) loopEnded(reason orchestrator.StopReason) {
+	if s == nil {
+		return
+	}
+	s.LoopEnded = string(reason)
+	s.save()
+}
+
+// save writes the checkpoint atomically
+func (s *runState) save() {
+	if s == nil {
+		return
+	}
+	s.UpdatedAt = time.Now()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return
+	}
+	writeFileAtomic(s.path, data)
+}
+
+// clear removes the checkpoint once the run has completed
+func (s *runState) clear() {
+	if s != nil {
+		os.Remove(s.path)
+		os.Remove(filepath.Dir(s.path)) // only if nothing else lives there
+	}
+}
+
+// planned reports whether the planner had completed
+func (s *runState) planned() bool {
+	return s != nil && s.Phase != ""
+}
+
+// synthesized reports whether the synthesizer had completed
+func (s *runState) synthesized() bool {
+	return s != nil && s.Phase == "SYNTHESIZER"
+}
+
+// resumePoint returns the research iteration to continue with and whether its
+// supervisor had already completed. done is set when the research loop had
+// finished: it recorded why, the reflector was satisfied or the iterations ran out.
+func (s *runState) resumePoint(taskFile string) (iteration int, supervised, done bool) {
+	if s == nil {
+		return 1, false, false
+	}
+	switch s.Phase {
+	case "RESEARCH-SUPERVISOR":
+		return s.Iteration, true, false
+	case "REFLECTOR":
+		if s.LoopEnded != "" || !needsMoreResearch(taskFile) || s.Iteration >= maxIterations {
+			return s.Iteration, false, true
+		}
+		return s.Iteration + 1, false, false
+	case "SYNTHESIZER":
+		return s.Iteration, false, true
+	}
+	return 1, false, false
+}
+
+// continueManifest reopens the run manifest of the interrupted run, marking the
+// phase it was in as interrupted, so the resumed run adds to the same record
+func continueManifest(workDir, promptsDir string) error {
+	m, err := loadManifest(workDir)
+	if err != nil {
+		return err
+	}
+	if n := len(m.Phases); n > 0 && m.Phases[n-1].Status == outcomeRunning {
+		m.endPhase(fmt.Errorf("interrupted"))
+	}
+	layout := config.Workspace.layout()
+	m.Layout = &layout
+	m.PromptsDir = promptsDir
+	m.Prompts = promptChecksums(promptsDir)
+	m.FinishedAt = nil
+	m.Outcome = outcomeRunning
+	m.Error = ""
+	m.ErrorCode = ""
+	manifest = m
+	m.save()
+	return nil
+}
+
+// lastPhase describes the last completed phase, e.g. "REFLECTOR #2"
+func (s *runState) lastPhase() string {
+	if s.Iteration > 0 && s.Phase != "SYNTHESIZER" {
+		return fmt.Sprintf("%s #%d", s.Phase, s.Iteration)
+	}
+	return s.Phase
+}
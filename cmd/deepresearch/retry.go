@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// runConfig bounds the research loop and retries failed agent runs
+type runConfig struct {
+	MaxIterations       int `yaml:"max_iterations"`        // research/reflection iterations (default 10)
+	Retries             int `yaml:"retries"`               // extra attempts for a failed or timed-out agent run
+	RetryBackoffSeconds int `yaml:"retry_backoff_seconds"` // delay before the first retry, doubled for each further one (default 30)
+	// Handoff is check (default: agents write handoff.json, checked when
+	// present), require (a step without one fails) or off; same as --handoff
+	Handoff string `yaml:"handoff"`
+}
+
+// maxRetryBackoff caps the delay between two attempts
+const maxRetryBackoff = 10 * time.Minute
+
+// retryable reports whether an agent failure may succeed on another attempt;
+// a missing agent, invalid input or an exhausted budget will not
+func retryable(err error) bool {
+	switch errorCode(err) {
+	case codeAgentNotFound, codeValidationFailed, codeBudgetExceeded, codeInterrupted:
+		return false
+	}
+	return true
+}
+
+// retryBackoff returns the delay before the given retry (1-based)
+func retryBackoff(retry int) time.Duration {
+	d := time.Duration(config.Run.RetryBackoffSeconds) * time.Second
+	for i := 1; i < retry && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// withRetries runs an agent, retrying failures up to run.retries times with
+// exponential backoff and logging each failed attempt. A cancelled ctx ends
+// the retries.
+func withRetries(ctx context.Context, run func() error) error {
+	attempts := config.Run.Retries + 1
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= attempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		delay := retryBackoff(attempt)
+		fields := failureFields(err)
+		fields["code"] = errorCode(err)
+		fields["attempt"] = fmt.Sprintf("%d", attempt)
+		fields["max_attempts"] = fmt.Sprintf("%d", attempts)
+		fields["retry_in_ms"] = fmt.Sprintf("%d", delay.Milliseconds())
+		logEntry("WARN", "AGENT_RETRY", currentIteration(), "Agent run failed, retrying", fields)
+		warn("Agent attempt %d/%d failed: %v; retrying in %s", attempt, attempts, err, formatDuration(delay))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// currentPhase returns the name of the running phase, "" outside one
+func currentPhase() string {
+	if manifest == nil || len(manifest.Phases) == 0 {
+		return ""
+	}
+	if p := manifest.Phases[len(manifest.Phases)-1]; p.Status == outcomeRunning {
+		return p.Name
+	}
+	return ""
+}
+
+// currentIteration returns the iteration of the running phase
+func currentIteration() int {
+	if manifest == nil || len(manifest.Phases) == 0 {
+		return 0
+	}
+	return manifest.Phases[len(manifest.Phases)-1].Iteration
+}
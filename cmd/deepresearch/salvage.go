@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Salvage policies for --salvage
+const (
+	salvageAsk  = "ask"  // prompt the user when stdin is a terminal
+	salvageAuto = "auto" // always run a degraded synthesis
+	salvageOff  = "off"  // never salvage, fail immediately
+)
+
+// salvagePolicy is set from --salvage
+var salvagePolicy = salvageAsk
+
+// partialReportMarker is the banner placed at the top of salvaged reports
+const partialReportMarker = "**PARTIAL REPORT**"
+
+// outcomePartial is the manifest outcome of a run that ended with a salvaged report
+const outcomePartial = "partial"
+
+// hasFindings reports whether research has produced anything worth synthesizing:
+// completed tasks or registered sources in task.md, or executor result files
+func hasFindings(workDir string) bool {
+	_, completed, sources := taskStats(taskFilePath(workDir))
+	if completed > 0 || sources > 0 {
+		return true
+	}
+	results, _ := filepath.Glob(filepath.Join(workDir, "logs", "E*_result.md"))
+	return len(results) > 0
+}
+
+// confirmSalvage decides, according to the salvage policy, whether to run a degraded synthesis
+func confirmSalvage(reason string) bool {
+	switch salvagePolicy {
+	case salvageAuto:
+		return true
+	case salvageOff:
+		return false
+	}
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	fmt.Printf("\n%s[WARN]%s %s\n", colorYellow, colorReset, reason)
+	fmt.Print(tr("Research has gathered findings. Generate a partial report from them? [y/N]: "))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// trySalvage is called when the run is about to abort. If research has produced
+// findings and the policy allows it, it runs a degraded synthesis clearly marked as
+// partial and exits. It returns normally when salvage is not attempted or fails,
+// leaving the caller to report the original error.
+func trySalvage(ctx context.Context, agentName, model, promptsDir, workDir, userPrompt, reason string) {
+	if !hasFindings(workDir) || !confirmSalvage(reason) {
+		return
+	}
+	// The run still fails as the phase it aborts in does
+	code := classifyError(reason, agentOutputTail.Lines())
+	status := exitStatus(code, manifest.failedPhase())
+	manifest.endPhase(fmt.Errorf("%s", reason))
+	logEntry("WARN", "SALVAGE", 0, "Running degraded synthesis over gathered findings", map[string]string{
+		"reason": reason,
+	})
+
+	beginPhase("SYNTHESIZER", "Generating partial report from gathered findings", 0)
+	prompt := buildSalvagePrompt(promptsDir, workDir, userPrompt, reason)
+	if err := runAgent(ctx, agentName, model, prompt, workDir); err != nil {
+		manifest.endPhase(err)
+		warn("Salvage synthesis failed: %v", err)
+		return
+	}
+	reportFile := reportFilePath(workDir)
+	if !fileExists(reportFile) {
+		manifest.endPhase(fmt.Errorf("synthesizer did not create %s", reportFileName(workDir)))
+		warn("Salvage synthesis did not create %s", reportFileName(workDir))
+		return
+	}
+	if err := markReportPartial(reportFile, reason); err != nil {
+		warn("Could not mark report as partial: %v", err)
+	}
+	completePhase("Partial report created: %s", reportFileName(workDir))
+	warn("Run aborted (%s); %s is PARTIAL", reason, reportFileName(workDir))
+
+	finishing()
+	logEntry("WARN", "COMPLETED", 0, "Research workflow ended with a partial report", map[string]string{
+		"reason": reason,
+	})
+	manifest.ErrorCode = code
+	manifest.finish(outcomePartial, reason)
+	writeResult(outcomePartial, manifest.ErrorCode, reason)
+	recordKnowledge(outcomePartial)
+	notifyRunEnd(outcomePartial, manifest.ErrorCode, reason)
+	printSummary(buildSummary(workDir))
+	applyPrivacyPolicy(workDir)
+	closeLogFile()
+	os.Exit(status)
+}
+
+// markReportPartial prepends the partial-report banner unless the agent already added it
+func markReportPartial(reportFile, reason string) error {
+	content, err := os.ReadFile(reportFile)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), partialReportMarker) {
+		return nil
+	}
+	banner := fmt.Sprintf("> [!WARNING]\n> %s — the research run was aborted (%s). "+
+		"This report was synthesized from the findings gathered so far and may be incomplete.\n\n", partialReportMarker, reason)
+	return os.WriteFile(reportFile, append([]byte(banner), content...), 0644)
+}
+
+func buildSalvagePrompt(promptsDir, workDir, originalRequest, reason string) string {
+	return buildSynthesizerPrompt(promptsDir, workDir, originalRequest) + fmt.Sprintf(`
+SYNTHESIS_MODE: PARTIAL
+ABORT_REASON: %s
+The research phase was aborted before completion. Synthesize ONLY from the findings that exist
+in task.md and logs/E*_result.md. Start the report with a "%s" warning banner, and list every
+incomplete task and unanswered research dimension under Limitations & Caveats.
+`, reason, partialReportMarker)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job priorities accepted by the API; higher runs first among a tenant's jobs
+const (
+	minJobPriority = -10
+	maxJobPriority = 10
+)
+
+// tenantUsage is a tenant's current share of the server, as seen by the scheduler
+type tenantUsage struct {
+	Running     int
+	TokensToday int
+}
+
+// usageByTenant counts running jobs and the tokens of jobs finished today per tenant
+func (s *jobServer) usageByTenant() (map[string]*tenantUsage, error) {
+	jobs, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	usage := map[string]*tenantUsage{}
+	for _, j := range jobs {
+		u, ok := usage[j.Tenant]
+		if !ok {
+			u = &tenantUsage{}
+			usage[j.Tenant] = u
+		}
+		if j.Status == jobRunning {
+			u.Running++
+		}
+		if strings.HasPrefix(j.FinishedAt, today) {
+			u.TokensToday += j.Tokens
+		}
+	}
+	return usage, nil
+}
+
+// holdReason returns why the scheduler holds back t's queued jobs, or "" if one
+// may start now
+func holdReason(t *tenant, u *tenantUsage) string {
+	if u == nil {
+		return ""
+	}
+	if t.cfg.MaxRunningJobs > 0 && u.Running >= t.cfg.MaxRunningJobs {
+		return fmt.Sprintf("tenant is running %d job(s) (limit %d)", u.Running, t.cfg.MaxRunningJobs)
+	}
+	if t.cfg.MaxDailyTokens > 0 && u.TokensToday >= t.cfg.MaxDailyTokens {
+		return fmt.Sprintf("tenant used %d tokens today (limit %d); waiting until tomorrow", u.TokensToday, t.cfg.MaxDailyTokens)
+	}
+	return ""
+}
+
+// pickJob chooses the next job to run among tenants within their quotas. The
+// tenant running the fewest jobs goes first, so one tenant's batch cannot starve
+// the others whatever priority it asks for; priority only orders a tenant's own
+// jobs, then the oldest job goes first.
+func (s *jobServer) pickJob(queued []*job, usage map[string]*tenantUsage) *job {
+	running := func(j *job) int {
+		if u := usage[j.Tenant]; u != nil {
+			return u.Running
+		}
+		return 0
+	}
+	var best *job
+	for _, j := range queued {
+		// Jobs of removed tenants still start, so run() can fail them
+		if t, ok := s.tenants[j.Tenant]; ok && holdReason(t, usage[j.Tenant]) != "" {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = j
+		case running(j) != running(best):
+			if running(j) < running(best) {
+				best = j
+			}
+		case j.Priority > best.Priority:
+			best = j
+		}
+	}
+	return best
+}
+
+// claimNext claims the job pickJob chooses. Local workers claim one at a time so
+// each sees the usage left by the previous claim.
+func (s *jobServer) claimNext() (*job, error) {
+	s.claimMu.Lock()
+	defer s.claimMu.Unlock()
+	usage, err := s.usageByTenant()
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Claim(func(queued []*job) *job { return s.pickJob(queued, usage) })
+}
+
+// jobTokens returns the tokens a run used: as metered from its agents' output,
+// else as the agents recorded them in task.md (0 if unknown)
+func jobTokens(dir string) int {
+	if m, err := loadManifest(dir); err == nil && m.Usage != nil {
+		return int(m.Usage.tokens())
+	}
+	content, err := os.ReadFile(taskFilePath(dir))
+	if err != nil {
+		return 0
+	}
+	m := totalTokensRe.FindStringSubmatch(string(content))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestHoldReason(t *testing.T) {
+	limited := &tenant{Name: "a", cfg: tenantConfig{MaxRunningJobs: 2, MaxDailyTokens: 1000}}
+	tests := []struct {
+		name  string
+		t     *tenant
+		usage *tenantUsage
+		held  bool
+	}{
+		{"no usage yet", limited, nil, false},
+		{"within quotas", limited, &tenantUsage{Running: 1, TokensToday: 999}, false},
+		{"running limit reached", limited, &tenantUsage{Running: 2}, true},
+		{"daily tokens used up", limited, &tenantUsage{TokensToday: 1000}, true},
+		{"unlimited", &tenant{Name: "b"}, &tenantUsage{Running: 50, TokensToday: 1 << 30}, false},
+	}
+	for _, tt := range tests {
+		if got := holdReason(tt.t, tt.usage); (got != "") != tt.held {
+			t.Errorf("%s: holdReason = %q, want held %v", tt.name, got, tt.held)
+		}
+	}
+}
+
+func TestPickJob(t *testing.T) {
+	s := &jobServer{tenants: map[string]*tenant{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+		"c": {Name: "c", cfg: tenantConfig{MaxRunningJobs: 1}},
+	}}
+	tests := []struct {
+		name   string
+		queued []*job
+		usage  map[string]*tenantUsage
+		want   string
+	}{
+		{
+			name:   "empty queue",
+			queued: nil,
+			want:   "",
+		},
+		{
+			name:   "oldest first",
+			queued: []*job{{ID: "1", Tenant: "a"}, {ID: "2", Tenant: "a"}},
+			want:   "1",
+		},
+		{
+			name:   "priority orders a tenant's own jobs",
+			queued: []*job{{ID: "1", Tenant: "a"}, {ID: "2", Tenant: "a", Priority: 5}, {ID: "3", Tenant: "a", Priority: 5}},
+			want:   "2",
+		},
+		{
+			name:   "priority does not jump ahead of a less busy tenant",
+			queued: []*job{{ID: "1", Tenant: "a", Priority: 10}, {ID: "2", Tenant: "b", Priority: -10}},
+			usage:  map[string]*tenantUsage{"a": {Running: 1}},
+			want:   "2",
+		},
+		{
+			name:   "idle tenants by priority",
+			queued: []*job{{ID: "1", Tenant: "a"}, {ID: "2", Tenant: "b", Priority: 3}},
+			want:   "2",
+		},
+		{
+			name:   "least busy tenant first",
+			queued: []*job{{ID: "1", Tenant: "a"}, {ID: "2", Tenant: "b"}},
+			usage:  map[string]*tenantUsage{"a": {Running: 3}, "b": {Running: 1}},
+			want:   "2",
+		},
+		{
+			name:   "tenant at its quota is held",
+			queued: []*job{{ID: "1", Tenant: "c", Priority: 10}, {ID: "2", Tenant: "a"}},
+			usage:  map[string]*tenantUsage{"a": {Running: 4}, "c": {Running: 1}},
+			want:   "2",
+		},
+		{
+			name:   "everything held",
+			queued: []*job{{ID: "1", Tenant: "c"}},
+			usage:  map[string]*tenantUsage{"c": {Running: 1}},
+			want:   "",
+		},
+		{
+			name:   "removed tenant still starts",
+			queued: []*job{{ID: "1", Tenant: "gone"}},
+			want:   "1",
+		},
+	}
+	for _, tt := range tests {
+		got := ""
+		if j := s.pickJob(tt.queued, tt.usage); j != nil {
+			got = j.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: picked %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
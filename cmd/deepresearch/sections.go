@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sectionsDirName holds the outline and per-section files in sectioned synthesis mode
+const sectionsDirName = "sections"
+
+var (
+	// outlineItemRe matches numbered or bulleted outline entries: "1. Executive Summary" or "- References"
+	outlineItemRe = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*])\s+(.+?)\s*$`)
+	slugRe        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// reportSection is one entry of the synthesis outline
+type reportSection struct {
+	Title string
+	File  string // path relative to the working directory
+}
+
+// runSectionedSynthesis produces the report section by section: the agent first writes
+// sections/outline.md, then each section is written by its own agent call into
+// sections/NN-slug.md, and the orchestrator assembles them into report.md.
+// Sections that already exist are kept, so re-running after a crash only writes the rest.
+func runSectionedSynthesis(ctx context.Context, agentName, model, promptsDir, workDir, originalRequest string) error {
+	dir := filepath.Join(workDir, sectionsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", sectionsDirName, err)
+	}
+
+	outlineFile := filepath.Join(dir, "outline.md")
+	if !fileExists(outlineFile) {
+		info("Writing report outline: %s/outline.md", sectionsDirName)
+		if err := runAgent(ctx, agentName, model, buildOutlinePrompt(promptsDir, workDir, originalRequest), workDir); err != nil {
+			return fmt.Errorf("outline failed: %w", err)
+		}
+	}
+	sections, err := readOutline(outlineFile)
+	if err != nil {
+		return err
+	}
+
+	for i, sec := range sections {
+		if fileExists(filepath.Join(workDir, sec.File)) {
+			info("Section %d/%d already written: %s", i+1, len(sections), sec.Title)
+			continue
+		}
+		info("Writing section %d/%d: %s", i+1, len(sections), sec.Title)
+		prompt := buildSectionPrompt(promptsDir, workDir, originalRequest, sections, i)
+		if err := runAgent(ctx, agentName, model, prompt, workDir); err != nil {
+			return fmt.Errorf("section %q failed: %w", sec.Title, err)
+		}
+		if !fileExists(filepath.Join(workDir, sec.File)) {
+			return fmt.Errorf("agent did not create %s", sec.File)
+		}
+		logEntry("INFO", "SECTION_DONE", 0, "Report section written", map[string]string{
+			"section": sec.Title,
+			"file":    sec.File,
+		})
+	}
+
+	_, err = assembleSections(workDir, false)
+	return err
+}
+
+// slugify turns a title into a file-name fragment
+func slugify(s string) string {
+	s = strings.Trim(slugRe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(s) > 40 {
+		s = strings.TrimRight(s[:40], "-")
+	}
+	return s
+}
+
+// readOutline parses sections/outline.md into the ordered list of sections
+func readOutline(outlineFile string) ([]reportSection, error) {
+	content, err := os.ReadFile(outlineFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outline: %w", err)
+	}
+	var sections []reportSection
+	for _, line := range strings.Split(string(content), "\n") {
+		m := outlineItemRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		title := strings.Trim(m[1], "#* ")
+		slug := slugify(title)
+		if slug == "" {
+			slug = "section"
+		}
+		sections = append(sections, reportSection{
+			Title: title,
+			File:  filepath.ToSlash(filepath.Join(sectionsDirName, fmt.Sprintf("%02d-%s.md", len(sections)+1, slug))),
+		})
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("outline %s lists no sections", outlineFile)
+	}
+	return sections, nil
+}
+
+// assembleSections concatenates the written sections, in outline order, into report.md.
+// When partial is true a notice is prepended and missing sections are listed.
+// It returns the number of sections included.
+func assembleSections(workDir string, partial bool) (int, error) {
+	sections, err := readOutline(filepath.Join(workDir, sectionsDirName, "outline.md"))
+	if err != nil {
+		return 0, err
+	}
+
+	var body strings.Builder
+	var missing []string
+	included := 0
+	for _, sec := range sections {
+		content, err := os.ReadFile(filepath.Join(workDir, sec.File))
+		if err != nil {
+			missing = append(missing, sec.Title)
+			continue
+		}
+		body.WriteString(strings.TrimSpace(string(content)))
+		body.WriteString("\n\n")
+		included++
+	}
+	if included == 0 {
+		return 0, fmt.Errorf("no report sections have been written")
+	}
+
+	var report strings.Builder
+	if partial || len(missing) > 0 {
+		report.WriteString("> [!WARNING]\n> **PARTIAL REPORT** — synthesis did not complete.")
+		if len(missing) > 0 {
+			report.WriteString(" Missing sections: " + strings.Join(missing, ", ") + ".")
+		}
+		report.WriteString("\n\n")
+	}
+	report.WriteString(body.String())
+
+	if err := os.WriteFile(reportFilePath(workDir), []byte(report.String()), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", reportFileName(workDir), err)
+	}
+	info("Assembled %d/%d sections into %s", included, len(sections), reportFileName(workDir))
+	return included, nil
+}
+
+func buildOutlinePrompt(promptsDir, workDir, originalRequest string) string {
+	synthesizerFile := filepath.Join(promptsDir, "synthesizer.md")
+	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+SYNTHESIS_MODE: SECTIONED (outline step)
+`, synthesizerFile, workDir)
+
+	if originalRequest != "" {
+		prompt += fmt.Sprintf(`ORIGINAL_USER_REQUEST: %s
+`, originalRequest)
+	}
+
+	prompt += fmt.Sprintf(`TASK: Plan the final report based on task.md knowledge graph. Do NOT write the report itself.
+OUTPUT: %s/outline.md in WORKING_DIR — a numbered list with one top-level report section per line
+(e.g. "1. Executive Summary"), in final order, following the report structure in the synthesizer guide.
+The last section MUST be the complete References / Source Registry.
+`, sectionsDirName)
+	return prompt + buildLayoutNote(workDir)
+}
+
+func buildSectionPrompt(promptsDir, workDir, originalRequest string, sections []reportSection, index int) string {
+	synthesizerFile := filepath.Join(promptsDir, "synthesizer.md")
+	sec := sections[index]
+
+	var outline strings.Builder
+	for i, s := range sections {
+		marker := " "
+		if i == index {
+			marker = ">"
+		}
+		fmt.Fprintf(&outline, "%s %d. %s\n", marker, i+1, s.Title)
+	}
+
+	prompt := fmt.Sprintf(`FIRST: Read %s and follow ALL instructions.
+
+WORKING_DIR: %s
+SYNTHESIS_MODE: SECTIONED (section %d of %d)
+`, synthesizerFile, workDir, index+1, len(sections))
+
+	if originalRequest != "" {
+		prompt += fmt.Sprintf(`ORIGINAL_USER_REQUEST: %s
+`, originalRequest)
+	}
+
+	prompt += fmt.Sprintf(`
+REPORT OUTLINE (current section marked with >):
+%s
+TASK: Write ONLY the section "%s" of the final report, based on task.md knowledge graph.
+Start with its heading. Earlier sections are in %s/ and may be read for consistency.
+OUTPUT: %s in WORKING_DIR
+Do NOT write report.md — the orchestrator assembles it from the sections.
+`, outline.String(), sec.Title, sectionsDirName, sec.File)
+	return prompt + buildLayoutNote(workDir) + synthesisNotes(workDir)
+}
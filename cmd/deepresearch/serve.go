@@ -0,0 +1,808 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// jobPollInterval is how often idle workers check the queue for jobs submitted
+// by other servers sharing the same backend
+const jobPollInterval = 2 * time.Second
+
+// allowedJobFlags are the research flags a submitted job may pass through
+var allowedJobFlags = map[string]bool{
+	"charts":         true,
+	"sectioned":      true,
+	"xlsx":           true,
+	"max-source-age": true,
+	"max-iterations": true,
+	"salvage":        true,
+}
+
+// serverConfig configures `deepresearch serve`
+type serverConfig struct {
+	Addr     string `yaml:"addr"`
+	Root     string `yaml:"root"`      // directory holding one workspace per job
+	Workers  int    `yaml:"workers"`   // jobs run concurrently
+	Retries  int    `yaml:"retries"`   // extra attempts for a failed job
+	Queue    string `yaml:"queue"`     // memory, sqlite or redis
+	QueueDSN string `yaml:"queue_dsn"` // sqlite database file or redis:// URL
+	GRPCAddr string `yaml:"grpc_addr"` // also serve the gRPC API here ("" = REST only)
+	Executor string `yaml:"executor"`  // local (child processes) or kubernetes (one Job per research job)
+	// Coordination shares job leases and progress checkpoints between replicas:
+	// file (a directory, shared over NFS for several replicas) or redis
+	Coordination    string `yaml:"coordination"`
+	CoordinationDSN string `yaml:"coordination_dsn"` // directory (default <root>/.coordination) or redis:// URL
+	// Kubernetes configures the kubernetes executor
+	Kubernetes kubeConfig `yaml:"kubernetes"`
+	// Tenants enables API-token authentication with one isolated workspace root
+	// per team; without tenants the server is open to anyone who can reach it
+	Tenants map[string]tenantConfig `yaml:"tenants"`
+}
+
+// jobServer runs queued research jobs as child processes, one workspace each
+type jobServer struct {
+	store   jobStore
+	tenants map[string]*tenant
+	runner  jobRunner
+	coord   coordinator
+	replica string // lease owner name of this server process
+	retries int
+	claimMu sync.Mutex
+	wake    chan struct{}
+}
+
+// jobRequest is the body of POST /jobs
+type jobRequest struct {
+	Prompt   string   `json:"prompt"`
+	Agent    string   `json:"agent"`
+	Model    string   `json:"model"`
+	Flags    []string `json:"flags"`
+	Priority int      `json:"priority"`
+}
+
+// runServe implements `deepresearch serve`: an HTTP API that queues research
+// requests and processes them with background workers
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	addr := fs.String("addr", "", "Address to listen on (default from config: 127.0.0.1:8090)")
+	root := fs.String("root", "", "Directory for job workspaces (default from config: ./runs)")
+	workers := fs.Int("workers", 0, "Number of jobs to run concurrently (default from config: 2)")
+	retries := fs.Int("retries", -1, "Extra attempts for a failed job (default from config: 1)")
+	queue := fs.String("queue", "", "Queue backend: memory (lost on restart), sqlite (needs -tags sqlite), redis (default from config: memory)")
+	queueDSN := fs.String("queue-dsn", "", "SQLite database file or redis:// URL for the queue")
+	executor := fs.String("executor", "", "Where jobs run: local, kubernetes (default from config: local)")
+	coordination := fs.String("coordination", "", "Lease and checkpoint backend shared by replicas: file, redis (default from config: file)")
+	grpcAddr := fs.String("grpc-addr", "", "Also serve the gRPC API on this address (default from config: off)")
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	mustLoadAgents()
+	cfg := &config.Server
+	if *addr != "" {
+		cfg.Addr = *addr
+	}
+	if *root != "" {
+		cfg.Root = *root
+	}
+	if *workers > 0 {
+		cfg.Workers = *workers
+	}
+	if *retries >= 0 {
+		cfg.Retries = *retries
+	}
+	if *queue != "" {
+		cfg.Queue = *queue
+	}
+	if *queueDSN != "" {
+		cfg.QueueDSN = *queueDSN
+	}
+	if *executor != "" {
+		cfg.Executor = *executor
+	}
+	if *coordination != "" {
+		cfg.Coordination = *coordination
+	}
+	if *grpcAddr != "" {
+		cfg.GRPCAddr = *grpcAddr
+	}
+
+	rootDir, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		fatal("Failed to resolve job root: %v", err)
+	}
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		fatal("Failed to create job root: %v", err)
+	}
+	if cfg.Queue == "sqlite" && cfg.QueueDSN == "" {
+		cfg.QueueDSN = filepath.Join(rootDir, "jobs.db")
+	}
+	store, err := openJobStore(cfg.Queue, cfg.QueueDSN)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Cannot open %s queue: %v", cfg.Queue, err)
+	}
+	defer store.Close()
+	if cfg.Coordination == "file" && cfg.CoordinationDSN == "" {
+		cfg.CoordinationDSN = filepath.Join(rootDir, ".coordination")
+	}
+	coord, err := openCoordinator(cfg.Coordination, cfg.CoordinationDSN)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Cannot open %s coordination: %v", cfg.Coordination, err)
+	}
+	defer coord.Close()
+	exe, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate the deepresearch executable: %v", err)
+	}
+	serverConfigPath := ""
+	if *configPath != "" {
+		serverConfigPath, _ = filepath.Abs(*configPath)
+	}
+	tenants, err := loadTenants(cfg.Tenants, rootDir, serverConfigPath)
+	if err != nil {
+		fatalCode(codeValidationFailed, "Invalid server.tenants: %v", err)
+	}
+	if _, open := tenants[""]; open && !isLoopbackAddr(cfg.Addr) {
+		warn("No server.tenants configured: anyone who can reach %s can submit and read jobs", cfg.Addr)
+	}
+	var runner jobRunner = &localRunner{exe: exe}
+	if cfg.Executor == "kubernetes" {
+		if runner, err = newKubeRunner(cfg.Kubernetes, rootDir); err != nil {
+			fatalCode(codeValidationFailed, "Invalid server.kubernetes: %v", err)
+		}
+	} else if cfg.Executor != "local" {
+		fatalCode(codeValidationFailed, "Invalid server.executor: %s (expected local or kubernetes)", cfg.Executor)
+	}
+	host, _ := os.Hostname()
+	s := &jobServer{
+		store: store, tenants: tenants, runner: runner, coord: coord,
+		replica: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		retries: cfg.Retries, wake: make(chan struct{}, 1),
+	}
+	if n, err := s.requeueOrphans(); err != nil {
+		fatal("Cannot read the job queue: %v", err)
+	} else if n > 0 {
+		info("Re-queued %d job(s) interrupted by a stopped server", n)
+	}
+	go func() {
+		for range time.Tick(jobLeaseTTL) {
+			if n, err := s.requeueOrphans(); err != nil {
+				warn("Queue: %v", err)
+			} else if n > 0 {
+				info("Re-queued %d job(s) from a server that stopped renewing them", n)
+			}
+		}
+	}()
+	for i := 0; i < cfg.Workers; i++ {
+		go s.work()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.withTenant(s.handleSubmit))
+	mux.HandleFunc("GET /jobs", s.withTenant(s.handleList))
+	mux.HandleFunc("GET /jobs/{id}", s.withTenant(s.handleGet))
+	mux.HandleFunc("POST /jobs/{id}/cancel", s.withTenant(s.handleCancel))
+	mux.HandleFunc("GET /jobs/{id}/events", s.withTenant(s.handleEvents))
+	mux.HandleFunc("GET /jobs/{id}/report", s.withTenant(s.handleArtifact))
+	mux.HandleFunc("GET /jobs/{id}/artifacts/{path...}", s.withTenant(s.handleArtifact))
+
+	if cfg.GRPCAddr != "" {
+		go func() {
+			if err := s.serveGRPC(cfg.GRPCAddr); err != nil {
+				fatal("gRPC server failed: %v", err)
+			}
+		}()
+		info("Serving the gRPC API at %s", cfg.GRPCAddr)
+	}
+	info("Serving research jobs at http://%s (%s queue, %d worker(s), %d tenant(s), workspaces in %s)",
+		cfg.Addr, cfg.Queue, cfg.Workers, len(cfg.Tenants), rootDir)
+	if err := http.ListenAndServe(cfg.Addr, mux); err != nil {
+		fatal("Server failed: %v", err)
+	}
+}
+
+// requeueOrphans puts running jobs that no live replica holds a lease on back
+// on the queue. Taking the lease first keeps a replica that is just starting the
+// job from running it alongside the re-queued copy.
+func (s *jobServer) requeueOrphans() (int, error) {
+	jobs, err := s.store.List()
+	if err != nil {
+		return 0, err
+	}
+	reaper := s.replica + "/reaper"
+	n := 0
+	for _, j := range jobs {
+		if j.Status != jobRunning {
+			continue
+		}
+		if ok, err := s.coord.Acquire(j.ID, reaper, jobLeaseTTL); err != nil || !ok {
+			continue
+		}
+		requeued := false
+		_, err := s.store.Update(j.ID, func(cur *job) bool {
+			requeued = cur.Status == jobRunning
+			if requeued {
+				cur.Status = jobQueued
+			}
+			return requeued
+		})
+		if err != nil && err != errJobNotFound {
+			s.coord.Release(j.ID, reaper)
+			return n, err
+		}
+		if err == nil && requeued {
+			n++
+		}
+		s.coord.Release(j.ID, reaper)
+	}
+	return n, nil
+}
+
+// work claims and runs jobs until the process exits
+func (s *jobServer) work() {
+	for {
+		j, err := s.claimNext()
+		if err != nil {
+			warn("Queue: %v", err)
+		}
+		if j == nil {
+			select {
+			case <-s.wake:
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+		// The orphan reaper may have taken the job between Claim and the lease
+		if ok, err := s.coord.Acquire(j.ID, s.replica, jobLeaseTTL); err != nil || !ok {
+			if err != nil {
+				warn("Coordination: %v", err)
+			}
+			continue
+		}
+		// Start the attempt unless the job was canceled or re-queued since Claim
+		started := false
+		cur, err := s.store.Update(j.ID, func(cur *job) bool {
+			started = cur.Status == jobRunning
+			if started {
+				cur.Attempts++
+				cur.StartedAt = time.Now().Format(time.RFC3339)
+				cur.FinishedAt, cur.ErrorCode, cur.Error = "", "", ""
+			}
+			return started
+		})
+		if err != nil || !started {
+			s.coord.Release(j.ID, s.replica)
+			continue
+		}
+		s.run(cur)
+		s.coord.Release(j.ID, s.replica)
+	}
+}
+
+// run executes the started attempt of a job as a child deepresearch process
+// and records the outcome from its result.json
+func (s *jobServer) run(j *job) {
+	info("Job %s: attempt %d started", j.ID, j.Attempts)
+	t, ok := s.tenants[j.Tenant]
+	if !ok {
+		s.finish(j, codeValidationFailed, fmt.Sprintf("tenant %q is no longer configured", j.Tenant))
+		return
+	}
+
+	logDir := filepath.Join(j.Dir, "logs")
+	os.MkdirAll(logDir, 0755)
+	logOut, err := os.OpenFile(filepath.Join(logDir, "job.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		s.finish(j, codeInternal, fmt.Sprintf("cannot open job log: %v", err))
+		return
+	}
+	// The job's directory is its workspace. The job's own flags come last, so
+	// none of them can keep the server's from being parsed.
+	args := []string{"--in-place"}
+	if t.configPath != "" {
+		args = append(args, "--config", t.configPath)
+	}
+	if j.Agent != "" {
+		args = append(args, "--agent", j.Agent)
+	}
+	if j.Model != "" {
+		args = append(args, "--model", j.Model)
+	}
+	args = append(args, "-p", j.Prompt)
+	args = append(args, j.Flags...)
+	canceled := make(chan struct{})
+	done := make(chan struct{})
+	var lost atomic.Bool
+	go s.monitor(j, canceled, done, &lost)
+	runErr := s.runner.Run(j, args, logOut, canceled)
+	close(done)
+	logOut.Close()
+	if lost.Load() {
+		warn("Job %s: lease lost to another server; abandoning attempt %d", j.ID, j.Attempts)
+		return
+	}
+	j.Tokens = jobTokens(j.Dir)
+
+	var res runResult
+	if data, err := os.ReadFile(filepath.Join(j.Dir, resultFileName)); err == nil {
+		json.Unmarshal(data, &res)
+	}
+	if runErr == nil && (res.Outcome == outcomeCompleted || res.Outcome == outcomePartial) {
+		s.finish(j, "", "")
+		return
+	}
+	code, msg := res.ErrorCode, res.Error
+	if msg == "" && runErr != nil {
+		msg = runErr.Error()
+	}
+	if code == "" {
+		code = codeInternal
+	}
+	s.finish(j, code, msg)
+}
+
+// monitor renews the job's lease and checkpoints its progress while it runs. It
+// closes canceled, which makes the runner stop the run, once the job is canceled
+// or its lease was lost (setting lost).
+func (s *jobServer) monitor(j *job, canceled chan<- struct{}, done <-chan struct{}, lost *atomic.Bool) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			s.coord.SaveCheckpoint(j.ID, readCheckpoint(j, s.replica))
+			return
+		case <-ticker.C:
+			if cur, err := s.store.Get(j.ID); err == nil && cur.Status == jobCanceled {
+				close(canceled)
+				return
+			}
+			if ok, err := s.coord.Acquire(j.ID, s.replica, jobLeaseTTL); err == nil && !ok {
+				lost.Store(true)
+				close(canceled)
+				return
+			}
+			if err := s.coord.SaveCheckpoint(j.ID, readCheckpoint(j, s.replica)); err != nil {
+				warn("Coordination: cannot checkpoint job %s: %v", j.ID, err)
+			}
+		}
+	}
+}
+
+// jobRunner executes one attempt of a job: deepresearch with args in the job's
+// workspace, output written to log. It stops the run when canceled is closed.
+type jobRunner interface {
+	Run(j *job, args []string, log io.Writer, canceled <-chan struct{}) error
+}
+
+// localRunner runs jobs as child processes of the server
+type localRunner struct {
+	exe string
+}
+
+func (r *localRunner) Run(j *job, args []string, log io.Writer, canceled <-chan struct{}) error {
+	cmd := exec.Command(r.exe, args...)
+	cmd.Dir = j.Dir
+	cmd.Stdout, cmd.Stderr = log, log
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-canceled:
+		// Let the run stop its agents and record the interruption, then kill the
+		// agent CLIs and browsers that are left, not just the run
+		terminateProcess(cmd)
+		select {
+		case err := <-exited:
+			killProcessTree(cmd)
+			return err
+		case <-time.After(shutdownGrace):
+			killProcessTree(cmd)
+			return <-exited
+		}
+	}
+}
+
+// finish records a job's outcome, re-queuing failures that may succeed on
+// retry. A cancel that reached the store first is kept.
+func (s *jobServer) finish(j *job, code, msg string) {
+	j.FinishedAt = time.Now().Format(time.RFC3339)
+	j.ErrorCode, j.Error = code, msg
+	switch {
+	case code == "":
+		j.Status = jobCompleted
+	case j.Attempts <= s.retries && code != codeValidationFailed && code != codeAgentNotFound:
+		j.Status = jobQueued
+	default:
+		j.Status = jobFailed
+	}
+	cur, err := s.store.Update(j.ID, func(cur *job) bool {
+		if cur.Status == jobCanceled {
+			return false
+		}
+		*cur = *j
+		return true
+	})
+	if err != nil {
+		warn("Queue: cannot save job %s: %v", j.ID, err)
+		return
+	}
+	switch cur.Status {
+	case jobCompleted:
+		success("Job %s: completed", j.ID)
+	case jobQueued:
+		warn("Job %s: attempt %d failed [%s] %s; retrying", j.ID, j.Attempts, code, msg)
+	case jobFailed:
+		warn("Job %s: failed [%s] %s", j.ID, code, msg)
+	}
+}
+
+// validateJobFlags rejects research flags outside allowedJobFlags, so API
+// callers cannot point a job at other files or configs on the server. Each
+// flag is one --name or --name=value: a bare word would end flag parsing in
+// the job's process.
+func validateJobFlags(flags []string) error {
+	for _, f := range flags {
+		if !strings.HasPrefix(f, "-") {
+			return fmt.Errorf("%q is not a flag (pass values as --name=value)", f)
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(f, "-"), "=")
+		if !allowedJobFlags[name] {
+			return fmt.Errorf("flag %q is not allowed (allowed: %s)", f, strings.Join(sortedKeys(allowedJobFlags), ", "))
+		}
+	}
+	return nil
+}
+
+// apiError is a request failure with the HTTP status it maps to
+type apiError struct {
+	Status int
+	Msg    string
+}
+
+func (e *apiError) Error() string { return e.Msg }
+
+// submit validates a request against the tenant's quota and queues it
+func (s *jobServer) submit(t *tenant, req jobRequest) (*job, error) {
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, &apiError{http.StatusBadRequest, "prompt is required"}
+	}
+	if _, ok := agentConfigs[req.Agent]; req.Agent != "" && !ok {
+		return nil, &apiError{http.StatusBadRequest, "unknown agent: " + req.Agent}
+	}
+	if err := validateJobFlags(req.Flags); err != nil {
+		return nil, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	if req.Priority < minJobPriority || req.Priority > maxJobPriority {
+		return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("priority must be between %d and %d", minJobPriority, maxJobPriority)}
+	}
+	reason, err := s.checkQuota(t)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, &apiError{http.StatusTooManyRequests, "quota exceeded: " + reason}
+	}
+	id := newJobID()
+	j := &job{
+		ID:        id,
+		Tenant:    t.Name,
+		Prompt:    req.Prompt,
+		Agent:     req.Agent,
+		Model:     req.Model,
+		Flags:     req.Flags,
+		Priority:  req.Priority,
+		Status:    jobQueued,
+		Dir:       filepath.Join(t.root, id),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := os.MkdirAll(j.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(j); err != nil {
+		return nil, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return j, nil
+}
+
+// lookup returns one of the tenant's jobs; other tenants' jobs are invisible
+func (s *jobServer) lookup(t *tenant, id string) (*job, error) {
+	j, err := s.store.Get(id)
+	if err == nil && j.Tenant != t.Name {
+		err = errJobNotFound
+	}
+	if err == errJobNotFound {
+		return nil, &apiError{http.StatusNotFound, err.Error()}
+	}
+	return j, err
+}
+
+// cancel stops a queued or running job. A running job's worker notices the
+// state change and kills the run, even on another server sharing the queue.
+func (s *jobServer) cancel(t *tenant, id string) (*job, error) {
+	if _, err := s.lookup(t, id); err != nil {
+		return nil, err
+	}
+	// Checked and set in one step, so a job that has just finished stays finished
+	canceled := false
+	j, err := s.store.Update(id, func(cur *job) bool {
+		canceled = cur.Status == jobQueued || cur.Status == jobRunning
+		if canceled {
+			cur.Status = jobCanceled
+			cur.FinishedAt = time.Now().Format(time.RFC3339)
+		}
+		return canceled
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !canceled {
+		return nil, &apiError{http.StatusConflict, "job already " + j.Status}
+	}
+	info("Job %s: canceled", j.ID)
+	return j, nil
+}
+
+// artifactPath resolves a workspace-relative path, refusing paths that escape
+// the job's workspace, through symlinks the agent made too, and anything but
+// regular files
+func artifactPath(j *job, rel string) (string, error) {
+	if rel == "" {
+		rel = reportFileName(j.Dir)
+	}
+	notFound := &apiError{http.StatusNotFound, rel + " does not exist"}
+	dir, err := filepath.EvalSymlinks(j.Dir)
+	if err != nil {
+		return "", notFound
+	}
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if !within(dir, path) {
+		return "", &apiError{http.StatusBadRequest, "path must stay inside the job workspace"}
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", notFound
+	}
+	if !within(dir, path) {
+		return "", &apiError{http.StatusBadRequest, "path must stay inside the job workspace"}
+	}
+	if st, err := os.Stat(path); err != nil || !st.Mode().IsRegular() {
+		return "", notFound
+	}
+	return path, nil
+}
+
+func (s *jobServer) handleSubmit(w http.ResponseWriter, r *http.Request, t *tenant) {
+	var req jobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	j, err := s.submit(t, req)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, j)
+}
+
+func (s *jobServer) handleList(w http.ResponseWriter, r *http.Request, t *tenant) {
+	all, err := s.store.List()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	jobs := []*job{}
+	for _, j := range all {
+		if j.Tenant == t.Name {
+			jobs = append(jobs, j)
+		}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *jobServer) handleGet(w http.ResponseWriter, r *http.Request, t *tenant) {
+	j, err := s.lookup(t, r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	// The checkpoint shows progress even when another replica runs the job
+	cp, _ := s.coord.LoadCheckpoint(j.ID)
+	var held string
+	if j.Status == jobQueued {
+		if usage, err := s.usageByTenant(); err == nil {
+			held = holdReason(t, usage[t.Name])
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		*job
+		Progress *checkpoint `json:"progress,omitempty"`
+		Held     string      `json:"held,omitempty"` // why the scheduler has not started it
+	}{j, cp, held})
+}
+
+func (s *jobServer) handleCancel(w http.ResponseWriter, r *http.Request, t *tenant) {
+	j, err := s.cancel(t, r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// handleEvents streams the job's events.jsonl as server-sent events, each with
+// the byte offset after it as its ID, so a client reconnecting with
+// Last-Event-ID continues where it stopped. The stream ends with an "end" event
+// once the job has finished; ?follow=false ends it after the events so far.
+func (s *jobServer) handleEvents(w http.ResponseWriter, r *http.Request, t *tenant) {
+	j, err := s.lookup(t, r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	var offset int64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if offset, err = strconv.ParseInt(id, 10, 64); err != nil || offset < 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid Last-Event-ID: "+id)
+			return
+		}
+	}
+	follow := r.URL.Query().Get("follow") != "false"
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	path := filepath.Join(j.Dir, "logs", "events.jsonl")
+	for {
+		// Check the state before reading so the final events are always sent
+		cur, err := s.store.Get(j.ID)
+		finished := err == nil && jobFinished(cur.Status)
+		lines, next, err := readEventLines(path, offset)
+		if err != nil {
+			return
+		}
+		for _, line := range lines {
+			offset += int64(len(line)) + 1
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", offset, line)
+		}
+		offset = next
+		if finished {
+			data, _ := json.Marshal(map[string]string{"status": cur.Status, "error_code": cur.ErrorCode})
+			fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+		if !follow || finished {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// handleArtifact downloads a file of the job's workspace, report.md by default
+func (s *jobServer) handleArtifact(w http.ResponseWriter, r *http.Request, t *tenant) {
+	j, err := s.lookup(t, r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	path, err := artifactPath(j, r.PathValue("path"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if filepath.Ext(path) == ".md" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	http.ServeContent(w, r, filepath.Base(path), st.ModTime(), f)
+}
+
+// readEventLines returns the complete lines of events.jsonl after offset and
+// the offset after the last of them
+func readEventLines(path string, offset int64) ([][]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, nil // the run has not started logging yet
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, err
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+	return bytes.Split(data[:end], []byte("\n")), offset + int64(end) + 1, nil
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local connections
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeAPIError writes err with its apiError status, or 500
+func writeAPIError(w http.ResponseWriter, err error) {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		writeJSONError(w, ae.Status, ae.Msg)
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, err.Error())
+}
+
+// writeJSONError writes {"error": msg}
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
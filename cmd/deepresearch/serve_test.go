@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArtifactPath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "job")
+	other := filepath.Join(root, "other")
+	for _, d := range []string{filepath.Join(dir, "assets"), other} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path string) {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(target, name string) {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "report.md"))
+	write(filepath.Join(other, "secret.txt"))
+	link(filepath.Join(other, "secret.txt"), "leak.md")
+	link(other, "other")
+	link("report.md", "alias.md")
+
+	tests := []struct {
+		rel    string
+		status int // 0 = served
+	}{
+		{"report.md", 0},
+		{"alias.md", 0},
+		{"../other/secret.txt", http.StatusBadRequest},
+		{"leak.md", http.StatusBadRequest},
+		{"other/secret.txt", http.StatusBadRequest},
+		{"assets", http.StatusNotFound},
+		{"missing.md", http.StatusNotFound},
+	}
+	j := &job{Dir: dir}
+	for _, tt := range tests {
+		path, err := artifactPath(j, tt.rel)
+		var apiErr *apiError
+		switch {
+		case tt.status == 0 && err != nil:
+			t.Errorf("artifactPath(%q) = %v, want it served", tt.rel, err)
+		case tt.status == 0:
+			if got, _ := os.ReadFile(path); string(got) != "x" {
+				t.Errorf("artifactPath(%q) = %s, not the report", tt.rel, path)
+			}
+		case !errors.As(err, &apiErr) || apiErr.Status != tt.status:
+			t.Errorf("artifactPath(%q) = %q, %v; want status %d", tt.rel, path, err, tt.status)
+		}
+	}
+}
+
+func TestFinishKeepsCancel(t *testing.T) {
+	store, err := openJobStore("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	team := &tenant{Name: "team"}
+	s := &jobServer{store: store, tenants: map[string]*tenant{team.Name: team}}
+	running := &job{ID: "j1", Tenant: team.Name, Status: jobRunning, Attempts: 1}
+	if err := store.Put(running); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cancel lands just before the worker writes the outcome
+	s.store = &cancelingStore{jobStore: store, cancel: func() {
+		if _, err := s.cancel(team, "j1"); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	s.finish(running, "", "")
+	if j, _ := store.Get("j1"); j.Status != jobCanceled {
+		t.Errorf("status after finish = %s, want %s", j.Status, jobCanceled)
+	}
+	s.store = store
+
+	// A job that finished first stays finished
+	done := &job{ID: "j2", Tenant: team.Name, Status: jobRunning, Attempts: 1}
+	store.Put(done)
+	s.finish(done, "", "")
+	var apiErr *apiError
+	if _, err := s.cancel(team, "j2"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusConflict {
+		t.Errorf("cancel of a completed job = %v, want a conflict", err)
+	}
+	if j, _ := store.Get("j2"); j.Status != jobCompleted {
+		t.Errorf("status after cancel = %s, want %s", j.Status, jobCompleted)
+	}
+}
+
+// cancelingStore runs cancel once, right before the first write
+type cancelingStore struct {
+	jobStore
+	cancel func()
+}
+
+func (s *cancelingStore) before() {
+	if cancel := s.cancel; cancel != nil {
+		s.cancel = nil
+		cancel()
+	}
+}
+
+func (s *cancelingStore) Put(j *job) error {
+	s.before()
+	return s.jobStore.Put(j)
+}
+
+func (s *cancelingStore) Update(id string, change func(j *job) bool) (*job, error) {
+	s.before()
+	return s.jobStore.Update(id, change)
+}
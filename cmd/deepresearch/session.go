@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sessionsDirName holds a workspace per run, named by its session ID, in the
+// directory deepresearch is started in
+const sessionsDirName = "research"
+
+// sessionSlugRe matches the words of a topic that name its session
+var sessionSlugRe = regexp.MustCompile(`[a-z0-9]+`)
+
+// newSessionID names a session after its start time and the first words of
+// its topic, e.g. "20261016-183305-heat-pump-adoption-in-norway"
+func newSessionID(topic string, now time.Time) string {
+	return now.Format("20060102-150405") + sessionSlug(topic)
+}
+
+// sessionSlug is the part of a session ID taken from its topic, e.g.
+// "-heat-pump-adoption-in-norway", or "" for a topic without words
+func sessionSlug(topic string) string {
+	var slug string
+	for _, w := range sessionSlugRe.FindAllString(strings.ToLower(topic), 6) {
+		if len(slug)+len(w) > 40 {
+			break
+		}
+		slug += "-" + w
+	}
+	return slug
+}
+
+// checkSessionName rejects a --session name that is not a plain directory name
+func checkSessionName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("%q is not a valid session name (expected a directory name such as heat-pumps)", name)
+	}
+	return nil
+}
+
+// sessionWorkspace returns the workspace of a run under ./research/, creating
+// it: the named session's, which an earlier run may have used, or a new one
+// named after the topic
+func sessionWorkspace(name, topic string) (string, error) {
+	root, err := filepath.Abs(sessionsDirName)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		if err := checkSessionName(name); err != nil {
+			return "", err
+		}
+		dir := filepath.Join(root, name)
+		return dir, os.MkdirAll(dir, 0755)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+	id := newSessionID(topic, time.Now())
+	for n := 2; ; n++ {
+		dir := filepath.Join(root, id)
+		// Mkdir fails when a session of the same second and topic exists
+		if err := os.Mkdir(dir, 0755); err == nil {
+			return dir, nil
+		} else if !os.IsExist(err) {
+			return "", err
+		}
+		id = fmt.Sprintf("%s-%d", newSessionID(topic, time.Now()), n)
+	}
+}
+
+// seedSessionContext copies the context/ folder of the current directory, if
+// any, into a session so the run frames its research with it
+func seedSessionContext(dir string) error {
+	return filepath.WalkDir(contextDirName, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == contextDirName {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, path)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, data, 0644)
+	})
+}
+
+// resumeWorkspace finds the run --resume continues: the named session, the
+// current directory when it holds a checkpoint, or else the session under
+// ./research/ whose checkpoint was updated last
+func resumeWorkspace(name string) (string, error) {
+	if name != "" {
+		if err := checkSessionName(name); err != nil {
+			return "", err
+		}
+		return filepath.Abs(filepath.Join(sessionsDirName, name))
+	}
+	if fileExists(statePath(".")) {
+		return filepath.Abs(".")
+	}
+	sessions, err := loadSessions(sessionsDirName)
+	if err != nil {
+		return "", err
+	}
+	var latest *sessionInfo
+	for i, s := range sessions {
+		if s.state != nil && (latest == nil || s.state.UpdatedAt.After(latest.state.UpdatedAt)) {
+			latest = &sessions[i]
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("nothing to resume: no session in %s/ has a %s checkpoint (it is removed when a run completes)", sessionsDirName, filepath.Join(stateDirName, stateFileName))
+	}
+	return latest.Dir, nil
+}
+
+// sessionInfo describes a past run for list, show and clean
+type sessionInfo struct {
+	ID        string    `json:"id"`
+	Dir       string    `json:"dir"`
+	Topic     string    `json:"topic,omitempty"`
+	Agent     string    `json:"agent,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// Outcome is the manifest's, or "unknown" for a run that did not write one
+	Outcome    string      `json:"outcome"`
+	ErrorCode  string      `json:"error_code,omitempty"`
+	Iterations int         `json:"iterations"`
+	Usage      *tokenUsage `json:"usage,omitempty"`
+	Resumable  bool        `json:"resumable"` // an interrupted run --resume continues
+	Report     bool        `json:"report"`
+
+	manifest *runManifest
+	state    *runState
+}
+
+// loadSessions reads the sessions under root, newest first. A missing root
+// has no sessions.
+func loadSessions(root string) ([]sessionInfo, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []sessionInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Join(root, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, loadSession(e.Name(), dir))
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].StartedAt.After(sessions[j].StartedAt) })
+	return sessions, nil
+}
+
+// loadSession describes the session in dir
+func loadSession(id, dir string) sessionInfo {
+	s := sessionInfo{ID: id, Dir: dir, Outcome: "unknown"}
+	if st, err := os.Stat(dir); err == nil {
+		s.StartedAt = st.ModTime()
+	}
+	if m, err := loadManifest(dir); err == nil {
+		s.manifest = m
+		s.Topic, s.Agent, s.Model = m.Topic, m.Agent, m.Model
+		s.StartedAt, s.Outcome, s.ErrorCode, s.Usage = m.StartedAt, m.Outcome, m.ErrorCode, m.Usage
+		for _, p := range m.Phases {
+			s.Iterations = max(s.Iterations, p.Iteration)
+		}
+	}
+	if st, err := loadState(dir); err == nil {
+		s.state = st
+		s.Resumable = true
+		if s.Topic == "" {
+			s.Topic = st.Topic
+		}
+	}
+	s.Report = fileExists(reportFilePath(dir))
+	return s
+}
+
+// findSession returns the session of an ID or a prefix only it starts with
+func findSession(root, id string) (sessionInfo, error) {
+	sessions, err := loadSessions(root)
+	if err != nil {
+		return sessionInfo{}, err
+	}
+	var matches []sessionInfo
+	for _, s := range sessions {
+		if s.ID == id {
+			return s, nil
+		}
+		if strings.HasPrefix(s.ID, id) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return sessionInfo{}, fmt.Errorf("no session %q in %s/ (see deepresearch list)", id, root)
+	case 1:
+		return matches[0], nil
+	}
+	var ids []string
+	for _, s := range matches {
+		ids = append(ids, s.ID)
+	}
+	return sessionInfo{}, fmt.Errorf("session %q is ambiguous: %s", id, strings.Join(ids, ", "))
+}
+
+// status renders the outcome of a session for the listing
+func (s sessionInfo) status() string {
+	switch {
+	case s.Resumable && s.Outcome != outcomeCompleted:
+		return s.Outcome + " (resumable)"
+	case s.ErrorCode != "":
+		return s.Outcome + " (" + s.ErrorCode + ")"
+	}
+	return s.Outcome
+}
+
+// cost renders the estimated spend of a session, "-" if unknown
+func (s sessionInfo) cost() string {
+	if s.Usage == nil {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", s.Usage.CostUSD)
+}
+
+// runList implements `deepresearch list`: the sessions under ./research/
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	root := fs.String("root", sessionsDirName, "Directory holding the sessions")
+	asJSON := fs.Bool("json", false, "Print the sessions as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch list [-root DIR] [-json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	sessions, err := loadSessions(*root)
+	if err != nil {
+		fatal("Cannot list sessions: %v", err)
+	}
+	if *asJSON {
+		if sessions == nil {
+			sessions = []sessionInfo{}
+		}
+		data, _ := json.MarshalIndent(sessions, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(sessions) == 0 {
+		info("No sessions in %s/", *root)
+		return
+	}
+	width := len("SESSION")
+	for _, s := range sessions {
+		width = max(width, len(s.ID))
+	}
+	fmt.Printf("%-*s  %-16s  %-28s  %4s  %8s  %s\n", width, "SESSION", "STARTED", "OUTCOME", "ITER", "COST", "TOPIC")
+	for _, s := range sessions {
+		topic := strings.Join(strings.Fields(s.Topic), " ")
+		if r := []rune(topic); len(r) > 60 {
+			topic = string(r[:57]) + "..."
+		}
+		fmt.Printf("%-*s  %-16s  %-28s  %4d  %8s  %s\n", width, s.ID, s.StartedAt.Local().Format("2006-01-02 15:04"), s.status(), s.Iterations, s.cost(), topic)
+	}
+}
+
+// runShow implements `deepresearch show <id>`: a session's run in detail
+func runShow(args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	root := fs.String("root", sessionsDirName, "Directory holding the sessions")
+	asJSON := fs.Bool("json", false, "Print the session's run manifest as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch show [-root DIR] [-json] <session>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	s, err := findSession(*root, fs.Arg(0))
+	if err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	if *asJSON {
+		if s.manifest == nil {
+			fatalCode(codeArtifactMissing, "Session %s has no %s", s.ID, manifestFileName)
+		}
+		data, _ := json.MarshalIndent(s.manifest, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Printf("  %-12s %s\n", label, value)
+		}
+	}
+	fmt.Printf("Session %s\n", s.ID)
+	field("Directory:", s.Dir)
+	field("Topic:", strings.Join(strings.Fields(s.Topic), " "))
+	agent := s.Agent
+	if s.Model != "" {
+		agent += " (" + s.Model + ")"
+	}
+	field("Agent:", agent)
+	field("Started:", s.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	if m := s.manifest; m != nil && m.FinishedAt != nil {
+		field("Finished:", fmt.Sprintf("%s (%s)", m.FinishedAt.Local().Format("2006-01-02 15:04:05"), formatDuration(m.FinishedAt.Sub(m.StartedAt))))
+	}
+	field("Outcome:", s.status())
+	if m := s.manifest; m != nil {
+		field("Error:", m.Error)
+	}
+	if s.state != nil && s.Outcome != outcomeCompleted {
+		field("Resume:", fmt.Sprintf("deepresearch --resume --session %s (last completed: %s)", s.ID, orDash(s.state.lastPhase())))
+	}
+	planned, completed, sources := taskStats(taskFilePath(s.Dir))
+	field("Tasks:", fmt.Sprintf("%d / %d completed", completed, planned))
+	field("Sources:", fmt.Sprintf("%d", sources))
+	if s.Usage != nil {
+		field("Cost:", fmt.Sprintf("$%.2f (%s input + %s output tokens)", s.Usage.CostUSD, formatTokens(s.Usage.InputTokens), formatTokens(s.Usage.OutputTokens)))
+	}
+	field("Size:", humanBytes(dirSize(s.Dir)))
+
+	if m := s.manifest; m != nil && len(m.Phases) > 0 {
+		fmt.Println()
+		fmt.Printf("  %-28s %10s  %-10s %9s\n", "Phase", "Duration", "Status", "Cost")
+		for _, p := range m.Phases {
+			name := p.Name
+			if p.Iteration > 0 {
+				name = fmt.Sprintf("%s #%d", p.Name, p.Iteration)
+			}
+			cost := "-"
+			if p.Usage != nil {
+				cost = fmt.Sprintf("$%.2f", p.Usage.CostUSD)
+			}
+			fmt.Printf("  %-28s %10s  %-10s %9s\n", name, formatDuration(time.Duration(p.DurationMs)*time.Millisecond), p.Status, cost)
+		}
+	}
+	if m := s.manifest; m != nil && len(m.Artifacts) > 0 {
+		fmt.Println()
+		fmt.Println("  Artifacts:")
+		for _, a := range m.Artifacts {
+			fmt.Printf("    %-40s %10s\n", a.Path, humanBytes(a.Size))
+		}
+	}
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// sessionScratch are the parts of a workspace clean -keep-report removes, besides
+// its assets/: what the run fetched and how it got there, not what it produced
+var sessionScratch = []string{"logs", "tmp", ".locks", stateDirName, contextDirName, "repro-bundle.tar.gz"}
+
+// runClean implements `deepresearch clean`: removes sessions, or with
+// -keep-report only their fetched sources, transcripts and checkpoints
+func runClean(args []string) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	root := fs.String("root", sessionsDirName, "Directory holding the sessions")
+	all := fs.Bool("all", false, "Select every session")
+	olderThan := fs.Int("older-than", 0, "Select sessions started more than this many days ago")
+	status := fs.String("status", "", "Select sessions with these outcomes, comma-separated: completed, planned, partial, failed, interrupted, unknown")
+	keepReport := fs.Bool("keep-report", false, "Keep the report, plan and manifest; remove only fetched sources, logs and checkpoints")
+	force := fs.Bool("force", false, "Also clean sessions whose run may still be going on")
+	dryRun := fs.Bool("dry-run", false, "List what would be removed without removing it")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: deepresearch clean [-all | -older-than DAYS | -status LIST | <session>...] [-keep-report] [-dry-run]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !*all && *olderThan <= 0 && *status == "" && fs.NArg() == 0 {
+		fatalCode(codeValidationFailed, "Name the sessions to clean, or select them with -all, -older-than or -status")
+	}
+
+	var selected []sessionInfo
+	if fs.NArg() > 0 {
+		for _, id := range fs.Args() {
+			s, err := findSession(*root, id)
+			if err != nil {
+				fatalCode(codeValidationFailed, "%v", err)
+			}
+			selected = append(selected, s)
+		}
+	} else {
+		sessions, err := loadSessions(*root)
+		if err != nil {
+			fatal("Cannot list sessions: %v", err)
+		}
+		outcomes := map[string]bool{}
+		for _, o := range strings.Split(*status, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				outcomes[o] = true
+			}
+		}
+		cutoff := time.Now().AddDate(0, 0, -*olderThan)
+		for _, s := range sessions {
+			if *olderThan > 0 && !s.StartedAt.Before(cutoff) {
+				continue
+			}
+			if len(outcomes) > 0 && !outcomes[s.Outcome] {
+				continue
+			}
+			selected = append(selected, s)
+		}
+	}
+
+	var freed int64
+	cleaned := 0
+	for _, s := range selected {
+		// A run killed outright also stays "running"; -force cleans it
+		if s.Outcome == outcomeRunning && !*force {
+			warn("Skipping %s: its run may still be going on (use -force to clean it anyway)", s.ID)
+			continue
+		}
+		targets := []string{s.Dir}
+		if *keepReport {
+			targets = nil
+			for _, name := range append([]string{assetsDirName(s.Dir)}, sessionScratch...) {
+				if path := filepath.Join(s.Dir, filepath.FromSlash(name)); fileExists(path) {
+					targets = append(targets, path)
+				}
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		var size int64
+		for _, t := range targets {
+			size += dirSize(t)
+		}
+		if *dryRun {
+			fmt.Printf("Would remove %s (%s)\n", displayTargets(s, targets), orDash(humanBytes(size)))
+			continue
+		}
+		failed := false
+		for _, t := range targets {
+			if err := os.RemoveAll(t); err != nil {
+				warn("Could not remove %s: %v", t, err)
+				failed = true
+			}
+		}
+		if !failed {
+			fmt.Printf("Removed %s (%s)\n", displayTargets(s, targets), orDash(humanBytes(size)))
+			freed += size
+			cleaned++
+		}
+	}
+	if !*dryRun {
+		success("Cleaned %d session(s), freeing %s", cleaned, orDash(humanBytes(freed)))
+	}
+}
+
+// displayTargets names what clean removes from a session
+func displayTargets(s sessionInfo, targets []string) string {
+	if len(targets) == 1 && targets[0] == s.Dir {
+		return s.ID
+	}
+	var names []string
+	for _, t := range targets {
+		names = append(names, filepath.Base(t))
+	}
+	return fmt.Sprintf("%s: %s", s.ID, strings.Join(names, ", "))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// outcomeInterrupted is the manifest and result outcome of an interrupted run
+const outcomeInterrupted = "interrupted"
+
+// shutdownGrace is how long an interrupted run waits for its agents to exit
+// (and serve for an interrupted run) before giving up on them
+const shutdownGrace = 10 * time.Second
+
+// repeatSignalWindow is how soon after the first signal a repeat counts as a
+// duplicate delivery rather than a request to exit at once
+const repeatSignalWindow = time.Second
+
+var (
+	// exitMu serializes the ways a run ends: a failure being reported and an
+	// interruption must not write their outcome files over each other
+	exitMu     sync.Mutex
+	finishOnce sync.Once
+	// agentsRunning counts the agent processes started by startLimited
+	agentsRunning sync.WaitGroup
+)
+
+// interruptedError is the cancellation cause of a run stopped by a signal
+type interruptedError struct {
+	sig os.Signal
+}
+
+func (e interruptedError) Error() string { return fmt.Sprintf("interrupted by %s", e.sig) }
+
+// exitCode returns the conventional exit status of a process killed by the signal: 128+N
+func (e interruptedError) exitCode() int {
+	if s, ok := e.sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 130
+}
+
+// handleInterrupts returns a context that is cancelled on SIGINT or SIGTERM.
+// The signal stops the running agents with everything they spawned, removes
+// the run's .locks/ and tmp/, records the interruption in the logs, manifest
+// and result.json, and exits with 128+signal (130 for Ctrl-C). The checkpoint
+// is kept, so --resume continues the run. Another signal (Ctrl-C again) stops
+// the agents and exits at once, skipping the rest.
+func handleInterrupts(workDir string) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		cause := interruptedError{sig: <-sigs}
+		received := time.Now()
+		go func() {
+			for range sigs {
+				// timeout(1) and service managers may deliver the same signal twice
+				if time.Since(received) < repeatSignalWindow {
+					continue
+				}
+				cancel(cause)
+				waitAgents(time.Second)
+				os.Exit(cause.exitCode())
+			}
+		}()
+		exitMu.Lock() // a failure being reported finishes (and exits) first
+		dash.stop()
+		fmt.Println()
+		warn("Received %s, stopping the running agents...", cause.sig)
+		cancel(cause)
+		waitAgents(shutdownGrace)
+
+		os.RemoveAll(filepath.Join(workDir, ".locks"))
+		os.RemoveAll(filepath.Join(workDir, "tmp"))
+		logEntry("WARN", "INTERRUPTED", currentIteration(), "Run interrupted", map[string]string{
+			"code":   codeInterrupted,
+			"signal": cause.sig.String(),
+		})
+		if manifest != nil {
+			manifest.ErrorCode = codeInterrupted
+		}
+		manifest.finish(outcomeInterrupted, cause.Error())
+		writeResult(outcomeInterrupted, codeInterrupted, cause.Error())
+		recordKnowledge(outcomeInterrupted)
+		reportRunTelemetry()
+		notifyRunEnd(outcomeInterrupted, codeInterrupted, cause.Error())
+		closeLogFile()
+		warn("Run interrupted; continue it with --resume")
+		os.Exit(cause.exitCode())
+	}()
+	return ctx
+}
+
+// finishing claims the end of the run for a path that reports its own outcome
+// and exits; a signal from then on ends the process without the interruption
+// handling
+func finishing() {
+	finishOnce.Do(func() {
+		exitMu.Lock()
+		dash.stop()
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+	})
+}
+
+// waitAgents waits up to timeout for the running agents to exit
+func waitAgents(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		agentsRunning.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		warn("Agents still running after %s; leaving them", formatDuration(timeout))
+	}
+}
+
+// interrupted reports whether err comes from a run stopped by a signal
+func interrupted(err error) bool {
+	var ie interruptedError
+	return errors.As(err, &ie) || errorCode(err) == codeInterrupted
+}
+
+// awaitShutdown blocks a run stopped by a signal until the interruption
+// handling exits the process
+func awaitShutdown() {
+	exitMu.Lock()
+	select {}
+}
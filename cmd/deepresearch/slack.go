@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackAPI is the default Slack Web API base URL
+const slackAPI = "https://slack.com/api"
+
+// maxSlackMessages caps how many top-level messages one export reads
+const maxSlackMessages = 1000
+
+func init() {
+	registerConnector(&connector{
+		Name:    "slack",
+		Summary: "Export a Slack channel or thread into context/ as a readable transcript (needs a bot/user token)",
+		Actions: map[string]string{
+			"export": `<channel ID> [-thread TS] [-days 30] — channel history with threads, or one thread`,
+		},
+		Run: runSlack,
+	})
+	registerContextIngester("slack", ingestSlack)
+}
+
+// slackMessage is the subset of a Slack message that is exported
+type slackMessage struct {
+	TS         string `json:"ts"`
+	User       string `json:"user"`
+	BotID      string `json:"bot_id"`
+	Username   string `json:"username"`
+	Text       string `json:"text"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+	Subtype    string `json:"subtype"`
+}
+
+// slackClient is a minimal Slack Web API client with a user-name cache
+type slackClient struct {
+	t     *toolContext
+	token string
+	users map[string]string
+}
+
+func newSlackClient(t *toolContext) (*slackClient, error) {
+	token := t.apiKey("SLACK_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("slack requires a token with channels:history and users:read (connectors.slack.api_key_env, default $SLACK_TOKEN)")
+	}
+	return &slackClient{t: t, token: token, users: map[string]string{}}, nil
+}
+
+// call invokes a Web API method; Slack reports failures as {"ok": false, "error": ...}
+func (c *slackClient) call(method string, params url.Values, out any) error {
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	raw, err := c.t.getJSON(c.t.endpoint(slackAPI)+"/"+method+"?"+params.Encode(), map[string]string{"Authorization": "Bearer " + c.token}, &status)
+	if err != nil {
+		return err
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// messages pages through conversations.history or conversations.replies
+func (c *slackClient) messages(method string, params url.Values, limit int) ([]slackMessage, error) {
+	var all []slackMessage
+	for {
+		var resp struct {
+			Messages []slackMessage `json:"messages"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		params.Set("limit", "200")
+		if err := c.call(method, params, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Messages...)
+		if resp.Metadata.NextCursor == "" || len(all) >= limit {
+			break
+		}
+		params.Set("cursor", resp.Metadata.NextCursor)
+	}
+	return all, nil
+}
+
+// userName resolves a user ID to a display name
+func (c *slackClient) userName(m slackMessage) string {
+	if m.User == "" {
+		if m.Username != "" {
+			return m.Username
+		}
+		return "bot"
+	}
+	if name, ok := c.users[m.User]; ok {
+		return name
+	}
+	var resp struct {
+		User struct {
+			Name    string `json:"name"`
+			Profile struct {
+				DisplayName string `json:"display_name"`
+				RealName    string `json:"real_name"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	name := m.User
+	if err := c.call("users.info", url.Values{"user": {m.User}}, &resp); err == nil {
+		for _, n := range []string{resp.User.Profile.DisplayName, resp.User.Profile.RealName, resp.User.Name} {
+			if n != "" {
+				name = n
+				break
+			}
+		}
+	}
+	c.users[m.User] = name
+	return name
+}
+
+// slackMentionRe matches user mentions (<@U123>) and channel or link markup (<#C1|name>, <url|label>)
+var slackMentionRe = regexp.MustCompile(`<([@#!]?)([^>|]+)(?:\|([^>]+))?>`)
+
+// text turns Slack message markup into readable text
+func (c *slackClient) text(s string) string {
+	s = slackMentionRe.ReplaceAllStringFunc(s, func(m string) string {
+		p := slackMentionRe.FindStringSubmatch(m)
+		switch {
+		case p[1] == "@":
+			return "@" + c.userName(slackMessage{User: p[2]})
+		case p[3] != "":
+			return p[3]
+		}
+		return p[2]
+	})
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">").Replace(s)
+}
+
+// slackTime formats a message timestamp ("1700000000.123456")
+func slackTime(ts string) string {
+	secs, _ := strconv.ParseFloat(ts, 64)
+	return time.Unix(int64(secs), 0).UTC().Format("2006-01-02 15:04")
+}
+
+// exportSlack renders a channel (or one thread when threadTS is set) as Markdown
+// and returns the file name and content
+func exportSlack(t *toolContext, channel, threadTS string, days int) (string, string, error) {
+	c, err := newSlackClient(t)
+	if err != nil {
+		return "", "", err
+	}
+	var info struct {
+		Channel struct {
+			Name  string `json:"name"`
+			Topic struct {
+				Value string `json:"value"`
+			} `json:"topic"`
+		} `json:"channel"`
+	}
+	if err := c.call("conversations.info", url.Values{"channel": {channel}}, &info); err != nil {
+		return "", "", err
+	}
+	name := info.Channel.Name
+	if name == "" {
+		name = channel
+	}
+
+	var top []slackMessage
+	if threadTS != "" {
+		top = []slackMessage{{TS: threadTS, ThreadTS: threadTS, ReplyCount: 1}}
+	} else {
+		params := url.Values{"channel": {channel}, "oldest": {fmt.Sprint(time.Now().AddDate(0, 0, -days).Unix())}}
+		if top, err = c.messages("conversations.history", params, maxSlackMessages); err != nil {
+			return "", "", err
+		}
+		sort.Slice(top, func(i, j int) bool { return top[i].TS < top[j].TS })
+	}
+
+	var b strings.Builder
+	scope := fmt.Sprintf("last %d days", days)
+	if threadTS != "" {
+		scope = "thread " + threadTS
+	}
+	fmt.Fprintf(&b, "# Slack #%s (%s)\n\n", name, scope)
+	if info.Channel.Topic.Value != "" {
+		fmt.Fprintf(&b, "Topic: %s\n\n", info.Channel.Topic.Value)
+	}
+	fmt.Fprintf(&b, "Exported %s. Messages are opinions of the participants, not verified facts.\n\n", time.Now().UTC().Format(time.RFC3339))
+	count := 0
+	for _, m := range top {
+		if m.Subtype == "channel_join" || m.Subtype == "channel_leave" {
+			continue
+		}
+		thread := []slackMessage{m}
+		if m.ReplyCount > 0 {
+			replies, err := c.messages("conversations.replies", url.Values{"channel": {channel}, "ts": {m.TS}}, maxSlackMessages)
+			if err != nil {
+				return "", "", err
+			}
+			if len(replies) > 0 {
+				thread = replies // the parent message comes first
+			}
+		}
+		for i, r := range thread {
+			indent := ""
+			if i > 0 {
+				indent = "  "
+			}
+			text := strings.ReplaceAll(strings.TrimSpace(c.text(r.Text)), "\n", "\n"+indent+"  ")
+			fmt.Fprintf(&b, "%s- **%s** (%s): %s\n", indent, c.userName(r), slackTime(r.TS), text)
+			count++
+		}
+	}
+	if count == 0 {
+		return "", "", fmt.Errorf("no messages in #%s for %s", name, scope)
+	}
+	file := "slack-" + slugify(name)
+	if threadTS != "" {
+		file += "-" + strings.ReplaceAll(threadTS, ".", "-")
+	}
+	return file + ".md", b.String(), nil
+}
+
+// ingestSlack handles --context slack:CHANNEL[/THREAD_TS]; channels cover the last 30 days
+func ingestSlack(t *toolContext, arg string) (map[string]string, error) {
+	channel, thread, _ := strings.Cut(arg, "/")
+	name, content, err := exportSlack(t, channel, thread, 30)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{name: content}, nil
+}
+
+func runSlack(t *toolContext, action string, args []string) error {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	thread := fs.String("thread", "", "Export only this thread (parent message timestamp)")
+	days := fs.Int("days", 30, "How many days of channel history to export")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("a channel ID is required")
+	}
+	name, content, err := exportSlack(t, fs.Arg(0), *thread, *days)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(t.WorkDir, contextDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		return err
+	}
+	t.printf("%s\nSaved to %s/%s (research context, not a citable source).\n", content, contextDirName, name)
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// stackExchangeAPI is the default Stack Exchange API base URL
+const stackExchangeAPI = "https://api.stackexchange.com/2.3"
+
+// Vote threshold above which an accepted answer counts as medium credibility
+const stackExchangeTrustedScore = 10
+
+func init() {
+	registerConnector(&connector{
+		Name:    "stackexchange",
+		Summary: "Stack Overflow and other Stack Exchange Q&A with accepted answers and vote counts (free, optional key)",
+		Actions: map[string]string{
+			"search":   `-q "query" [-n 10] [-site stackoverflow] [-tagged go;http] — questions by relevance`,
+			"question": `<question id> [-site stackoverflow] [-answers 3] — question, accepted and top answers, saved to assets/qa`,
+		},
+		Run: runStackExchange,
+	})
+}
+
+// seQuestion is the subset of a Stack Exchange question that is reported
+type seQuestion struct {
+	ID           int      `json:"question_id"`
+	Title        string   `json:"title"`
+	Link         string   `json:"link"`
+	Score        int      `json:"score"`
+	AnswerCount  int      `json:"answer_count"`
+	IsAnswered   bool     `json:"is_answered"`
+	AcceptedID   int      `json:"accepted_answer_id"`
+	Tags         []string `json:"tags"`
+	CreationDate int64    `json:"creation_date"`
+	LastActivity int64    `json:"last_activity_date"`
+	Body         string   `json:"body"`
+}
+
+// seAnswer is the subset of a Stack Exchange answer that is reported
+type seAnswer struct {
+	ID           int   `json:"answer_id"`
+	Score        int   `json:"score"`
+	IsAccepted   bool  `json:"is_accepted"`
+	CreationDate int64 `json:"creation_date"`
+	LastEdit     int64 `json:"last_edit_date"`
+	Owner        struct {
+		Name       string `json:"display_name"`
+		Reputation int    `json:"reputation"`
+	} `json:"owner"`
+	Body string `json:"body"`
+}
+
+// answerCredibility rates an answer for the Source Registry: accepted answers with
+// enough votes are Medium, everything else Low
+func answerCredibility(a seAnswer) string {
+	if a.IsAccepted && a.Score >= stackExchangeTrustedScore {
+		return "Medium"
+	}
+	return "Low"
+}
+
+// seText converts Stack Exchange HTML bodies to readable text, keeping code blocks
+func seText(s string) string {
+	s = strings.NewReplacer("<pre><code>", "\n```\n", "</code></pre>", "\n```\n", "<code>", "`", "</code>", "`",
+		"<p>", "", "</p>", "\n", "<li>", "- ", "<br>", "\n", "<br/>", "\n").Replace(s)
+	return strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(s, "")))
+}
+
+// seGet is getJSON with the error message Stack Exchange sends along with a
+// failure status (e.g. a throttle violation and when more requests are available)
+func seGet(t *toolContext, u string, out any) ([]byte, error) {
+	raw, err := t.getJSON(u, nil, out)
+	var status *httpStatusError
+	if errors.As(err, &status) {
+		var e struct {
+			Name    string `json:"error_name"`
+			Message string `json:"error_message"`
+		}
+		if json.Unmarshal(status.Body, &e) == nil && e.Message != "" {
+			return nil, fmt.Errorf("%w: %s: %s", err, e.Name, e.Message)
+		}
+	}
+	return raw, err
+}
+
+func runStackExchange(t *toolContext, action string, args []string) error {
+	base := t.endpoint(stackExchangeAPI)
+	params := url.Values{}
+	if key := t.apiKey("STACKEXCHANGE_KEY"); key != "" {
+		params.Set("key", key) // raises the daily quota
+	}
+
+	switch action {
+	case "search":
+		fs, q, n := searchFlagSet(action, 10)
+		site := fs.String("site", "stackoverflow", "Stack Exchange site (stackoverflow, serverfault, superuser, ...)")
+		tagged := fs.String("tagged", "", "Semicolon-separated tags")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		params.Set("q", query)
+		params.Set("site", *site)
+		params.Set("order", "desc")
+		params.Set("sort", "relevance")
+		params.Set("pagesize", fmt.Sprint(min(*n, 100)))
+		if *tagged != "" {
+			params.Set("tagged", *tagged)
+		}
+		var resp struct {
+			Items []seQuestion `json:"items"`
+		}
+		raw, err := seGet(t, base+"/search/advanced?"+params.Encode(), &resp)
+		if err != nil {
+			return err
+		}
+		path, err := t.saveAsset("data", "stackexchange_"+*site+"_"+query, "json", raw)
+		if err != nil {
+			return err
+		}
+		t.printf("%d question(s) on %s. Raw results: %s\n\n| ID | Asked | Score | Answers | Accepted | Title | Tags |\n|---|---|---|---|---|---|---|\n", len(resp.Items), *site, path)
+		for _, q := range resp.Items {
+			accepted := "no"
+			if q.AcceptedID != 0 {
+				accepted = "yes"
+			}
+			t.printf("| %d | %s | %d | %d | %s | %s | %s |\n", q.ID, time.Unix(q.CreationDate, 0).UTC().Format("2006-01-02"),
+				q.Score, q.AnswerCount, accepted, mdCell(html.UnescapeString(q.Title)), strings.Join(q.Tags, ", "))
+		}
+		t.printf("\nUse `question <ID>` to archive the accepted and top answers of a question you cite.\n")
+		return nil
+
+	case "question":
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		site := fs.String("site", "stackoverflow", "Stack Exchange site")
+		maxAnswers := fs.Int("answers", 3, "Top answers to include besides the accepted one")
+		if err := fs.Parse(reorderArgs(args)); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return fmt.Errorf("a question ID is required")
+		}
+		id := url.PathEscape(fs.Arg(0))
+		params.Set("site", *site)
+		params.Set("filter", "withbody")
+		var qs struct {
+			Items []seQuestion `json:"items"`
+		}
+		if _, err := seGet(t, base+"/questions/"+id+"?"+params.Encode(), &qs); err != nil {
+			return err
+		}
+		if len(qs.Items) == 0 {
+			return fmt.Errorf("question %s not found on %s", fs.Arg(0), *site)
+		}
+		q := qs.Items[0]
+		params.Set("order", "desc")
+		params.Set("sort", "votes")
+		params.Set("pagesize", "30")
+		var as struct {
+			Items []seAnswer `json:"items"`
+		}
+		if _, err := seGet(t, base+"/questions/"+id+"/answers?"+params.Encode(), &as); err != nil {
+			return err
+		}
+
+		// The accepted answer first, then the highest-voted others
+		var answers []seAnswer
+		for _, a := range as.Items {
+			if a.IsAccepted {
+				answers = append(answers, a)
+			}
+		}
+		for _, a := range as.Items {
+			if !a.IsAccepted && len(answers) < *maxAnswers+1 {
+				answers = append(answers, a)
+			}
+		}
+
+		title := html.UnescapeString(q.Title)
+		credibility := "Low"
+		var body strings.Builder
+		fmt.Fprintf(&body, "# %s\n\n*%s — score %d, %d answers, asked %s, tags: %s.*\n\n## Question\n\n%s\n",
+			title, *site, q.Score, q.AnswerCount, time.Unix(q.CreationDate, 0).UTC().Format("2006-01-02"), strings.Join(q.Tags, ", "), seText(q.Body))
+		for _, a := range answers {
+			label := "Answer"
+			if a.IsAccepted {
+				label = "Accepted answer"
+				credibility = answerCredibility(a)
+			}
+			updated := a.CreationDate
+			if a.LastEdit > updated {
+				updated = a.LastEdit
+			}
+			fmt.Fprintf(&body, "\n## %s (score %d, by %s, reputation %d, updated %s)\n\n%s\n", label, a.Score,
+				html.UnescapeString(a.Owner.Name), a.Owner.Reputation, time.Unix(updated, 0).UTC().Format("2006-01-02"), seText(a.Body))
+		}
+		path, err := t.saveDocument("qa", *site+"_"+fs.Arg(0)+"_"+title, [][2]string{
+			{"title", title},
+			{"url", q.Link},
+			{"site", *site},
+			{"score", fmt.Sprint(q.Score)},
+			{"credibility", strings.ToLower(credibility)},
+			{"published", time.Unix(q.LastActivity, 0).UTC().Format("2006-01-02")},
+		}, body.String())
+		if err != nil {
+			return err
+		}
+		t.printf("%s\nArchived: %s\n\nRegister with Credibility `%s` (accepted answers with >= %d votes are Medium, other answers Low); check that the answer still applies to current versions.\n",
+			body.String(), path, credibility, stackExchangeTrustedScore)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStackExchangeSearch(t *testing.T) {
+	srv := recordedAPI(t, "stackexchange", map[string]string{"/search/advanced": "search.json"})
+	dir, out, err := runRecordedTool(t, "stackexchange", connectorConfig{Endpoint: srv.URL}, "search", "-q", "http timeout", "-tagged", "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"2 question(s) on stackoverflow.",
+		"| ID | Asked | Score | Answers | Accepted | Title | Tags |",
+		"| 16895294 | 2013-06-04 | 412 | 7 | yes | How to set timeout for http.Get() requests in Golang? | go, http, timeout |",
+		`| 77490000 | 2023-11-14 | 0 | 0 | no | Client.Timeout vs context & deadlines \| which wins? | go |`,
+	)
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "stackexchange-stackoverflow-http-timeout") {
+		t.Errorf("archived %v, want the raw results", files)
+	}
+}
+
+func TestStackExchangeQuestion(t *testing.T) {
+	srv := recordedAPI(t, "stackexchange", map[string]string{
+		"/questions/16895294":         "question.json",
+		"/questions/16895294/answers": "answers.json",
+	})
+	dir, out, err := runRecordedTool(t, "stackexchange", connectorConfig{Endpoint: srv.URL}, "question", "16895294", "-answers", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# How to set timeout for http.Get() requests in Golang?",
+		"*stackoverflow — score 412, 7 answers, asked 2013-06-04, tags: go, http, timeout.*",
+		"I'm making a URL fetcher in Go and want `http.Get` to give up",
+		"## Accepted answer (score 208, by Jörg W, reputation 1520, updated 2017-07-14)\n\nUse a `net.Dialer` with a deadline.",
+		"## Answer (score 512, by Volker, reputation 28770, updated 2013-06-04)",
+		"```\nclient := http.Client{Timeout: 5 * time.Second}",
+		"Register with Credibility `Medium`",
+	)
+	if strings.Contains(out, "drive_by") {
+		t.Errorf("included more answers than asked for:\n%s", out)
+	}
+	files := assetFiles(t, dir, "qa")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "stackoverflow-16895294-how-to-set") {
+		t.Fatalf("archived %v, want the question", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "qa", files[0]))
+	checkContains(t, string(doc), `credibility: "medium"`, `published: "2023-11-14"`)
+}
+
+func TestStackExchangeNoResults(t *testing.T) {
+	srv := recordedAPI(t, "stackexchange", map[string]string{
+		"/search/advanced":   "empty.json",
+		"/questions/1234567": "question_missing.json",
+	})
+	_, out, err := runRecordedTool(t, "stackexchange", connectorConfig{Endpoint: srv.URL}, "search", "-q", "no such question", "-site", "serverfault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "0 question(s) on serverfault.")
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+	dir, _, err := runRecordedTool(t, "stackexchange", connectorConfig{Endpoint: srv.URL}, "question", "1234567")
+	if err == nil || !strings.Contains(err.Error(), "question 1234567 not found on stackoverflow") {
+		t.Errorf("missing question: error %v", err)
+	}
+	if files := assetFiles(t, dir, "qa"); len(files) != 0 {
+		t.Errorf("missing question: archived %v", files)
+	}
+}
+
+func TestStackExchangeErrors(t *testing.T) {
+	checkHTTPErrors(t, "stackexchange", connectorConfig{}, "search", "-q", "http timeout")
+	checkHTTPErrors(t, "stackexchange", connectorConfig{}, "question", "16895294")
+	// Stack Exchange throttles with 400 and explains why in the body
+	srv := recordedFault(t, "stackexchange", "throttled.json", http.StatusBadRequest)
+	_, _, err := runRecordedTool(t, "stackexchange", connectorConfig{Endpoint: srv.URL}, "search", "-q", "http timeout")
+	if err == nil || !strings.Contains(err.Error(), "throttle_violation: too many requests from this IP, more requests available in 82360 seconds") {
+		t.Errorf("throttled: error %v, want Stack Exchange's message", err)
+	}
+}
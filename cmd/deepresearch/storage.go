@@ -0,0 +1,402 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// storageConfig caps the disk space fetched material may take (0 = unlimited).
+// Over the cap, the least recently modified files are evicted first.
+type storageConfig struct {
+	MaxAssetsMB int    `yaml:"max_assets_mb"` // assets/ of one run; sources cited in task.md are never evicted
+	CacheDir    string `yaml:"cache_dir"`     // shared cache (default ~/.deepresearch/cache)
+	MaxCacheMB  int    `yaml:"max_cache_mb"`
+	// Fetched sources are kept in the shared cache for later runs to reuse
+	// until CacheTTLDays after they were fetched (0 = no expiry)
+	CacheTTLDays int  `yaml:"cache_ttl_days"`
+	NoCache      bool `yaml:"no_cache"` // same as --no-cache: neither reuse nor cache fetched sources
+	// After synthesis, gzip text and HTML assets larger than CompressKB, and move
+	// raw assets to ArchiveDir, leaving their extracted text in the workspace
+	CompressKB int    `yaml:"compress_kb"`
+	ArchiveDir string `yaml:"archive_dir"`
+}
+
+// assetQuotaInterval is how often the asset quota is checked during a run
+const assetQuotaInterval = 30 * time.Second
+
+// quotaWarnRatio is the share of a quota at which a run is warned it is filling up
+const quotaWarnRatio = 0.9
+
+// cacheDir returns the shared cache directory ("" if the home directory is unknown)
+func cacheDir() string {
+	if config.Storage.CacheDir != "" {
+		return config.Storage.CacheDir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deepresearch", "cache")
+}
+
+// storedFile is a file counted against a quota
+type storedFile struct {
+	rel  string // slash-separated, relative to the quota's directory
+	size int64
+	mod  time.Time
+}
+
+// dirFiles lists the files under dir and their total size
+func dirFiles(dir string) ([]storedFile, int64) {
+	var files []storedFile
+	var total int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, storedFile{rel: filepath.ToSlash(rel), size: st.Size(), mod: st.ModTime()})
+		total += st.Size()
+		return nil
+	})
+	return files, total
+}
+
+// evictLRU removes the least recently modified files under dir until it fits in
+// limit bytes, skipping files keep reports. It returns the removed files and the
+// size left.
+func evictLRU(dir string, limit int64, keep func(rel string) bool) ([]storedFile, int64) {
+	files, total := dirFiles(dir)
+	if total <= limit {
+		return nil, total
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+	var removed []storedFile
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if keep != nil && keep(f.rel) {
+			continue
+		}
+		if os.Remove(filepath.Join(dir, filepath.FromSlash(f.rel))) == nil {
+			removed = append(removed, f)
+			total -= f.size
+		}
+	}
+	return removed, total
+}
+
+// assetQuota enforces storage.max_assets_mb on one run's assets/
+type assetQuota struct {
+	workDir    string
+	limit      int64
+	warnedFull bool
+	warnedOver bool
+}
+
+// citedAssets returns the assets/ files task.md relies on: local copies in the
+// Source Registry and the raw files of Knowledge Graph facts
+func citedAssets(workDir string) map[string]bool {
+	taskFile := taskFilePath(workDir)
+	cited := map[string]bool{}
+	for _, src := range readSourceRegistry(workDir) {
+		if src.LocalPath != "" {
+			cited[src.LocalPath] = true
+		}
+	}
+	for _, f := range readFacts(taskFile) {
+		if isAssetPath(workDir, f.RawFile) {
+			cited[filepath.ToSlash(f.RawFile)] = true
+		}
+	}
+	return cited
+}
+
+// check evicts uncited assets once assets/ outgrows the quota and warns as it
+// fills up or when cited sources alone exceed it
+func (q *assetQuota) check() {
+	dir := assetsPath(q.workDir)
+	cited := citedAssets(q.workDir)
+	prev, _ := loadAssetManifest(q.workDir)
+	standIns := storedStandIns(prev)
+	removed, total := evictLRU(dir, q.limit, func(rel string) bool {
+		rel = assetRel(q.workDir, rel)
+		return cited[rel] || standIns[rel] || rel == assetRel(q.workDir, assetManifestName) || rel == assetRel(q.workDir, citationMetadataName)
+	})
+	if len(removed) > 0 {
+		var freed int64
+		for _, f := range removed {
+			freed += f.size
+			logEntry("WARN", "ASSET_EVICTED", 0, "Evicted asset over storage quota", map[string]string{
+				"path": assetRel(q.workDir, f.rel),
+				"size": fmt.Sprintf("%d", f.size),
+			})
+		}
+		warn("assets/ exceeded storage.max_assets_mb (%d MB): evicted %d uncited file(s), %.1f MB", q.limit>>20, len(removed), float64(freed)/(1<<20))
+	}
+	switch {
+	case total > q.limit:
+		if !q.warnedOver {
+			warn("assets/ holds %.1f MB of cited sources, over storage.max_assets_mb (%d MB); raise the limit or expect the disk to fill", float64(total)/(1<<20), q.limit>>20)
+			q.warnedOver = true
+		}
+	case float64(total) >= quotaWarnRatio*float64(q.limit):
+		if !q.warnedFull {
+			warn("assets/ is at %.1f of %d MB (storage.max_assets_mb); beyond it the oldest uncited files are evicted", float64(total)/(1<<20), q.limit>>20)
+			q.warnedFull = true
+		}
+	}
+}
+
+// watchAssetQuota checks the asset quota periodically while agents fetch sources.
+// The returned function stops watching after a final check.
+func watchAssetQuota(workDir string) func() {
+	if config.Storage.MaxAssetsMB <= 0 {
+		return func() {}
+	}
+	q := &assetQuota{workDir: workDir, limit: int64(config.Storage.MaxAssetsMB) << 20}
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(assetQuotaInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				q.check()
+				return
+			case <-ticker.C:
+				q.check()
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// enforceCacheQuota trims the shared cache to storage.max_cache_mb
+func enforceCacheQuota() {
+	dir := cacheDir()
+	if config.Storage.MaxCacheMB <= 0 || dir == "" {
+		return
+	}
+	limit := int64(config.Storage.MaxCacheMB) << 20
+	removed, total := evictLRU(dir, limit, isSourceCacheIndex)
+	if len(removed) > 0 {
+		info("Shared cache exceeded storage.max_cache_mb (%d MB): evicted %d least recently used file(s)", config.Storage.MaxCacheMB, len(removed))
+	} else if float64(total) >= quotaWarnRatio*float64(limit) {
+		warn("Shared cache %s is at %.1f of %d MB (storage.max_cache_mb)", dir, float64(total)/(1<<20), config.Storage.MaxCacheMB)
+	}
+}
+
+// plainTextExts are assets that already are text and stay in the workspace when
+// raw assets are archived
+var plainTextExts = map[string]bool{".md": true, ".txt": true}
+
+// reportedAssets are the files of workDir that report.md or its translations
+// embed, link to or name, such as figures and the local archives of the Source
+// Registry, as slash-separated paths relative to workDir
+func reportedAssets(workDir string, m *assetManifest) map[string]bool {
+	reported := map[string]bool{}
+	for _, rel := range append([]string{reportFileName(workDir)}, translatedReports(workDir)...) {
+		report, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		// Local paths are relative to the report, or, as agents are told, to the workspace
+		for _, dest := range reportDestinations(report) {
+			dest, _, _ = strings.Cut(dest, "#")
+			if p, err := url.PathUnescape(dest); err == nil {
+				dest = p
+			}
+			reported[path.Join(path.Dir(rel), dest)] = true
+			reported[path.Clean(dest)] = true
+		}
+		for _, a := range m.Assets {
+			if strings.Contains(string(report), a.Path) {
+				reported[a.Path] = true
+			}
+		}
+	}
+	return reported
+}
+
+// storeAssets compresses and archives the run's assets as storage configures,
+// recording where each file went in the asset manifest. It runs once the report
+// no longer needs the raw files; those it shows or links to stay as they are.
+func storeAssets(workDir string, m *assetManifest) {
+	cfg := config.Storage
+	if m == nil || (cfg.ArchiveDir == "" && cfg.CompressKB <= 0) {
+		return
+	}
+	reported := reportedAssets(workDir, m)
+	archived, compressed := 0, 0
+	var archiveRoot string
+	if cfg.ArchiveDir != "" {
+		runID := time.Now().Format("20060102-150405")
+		if manifest != nil {
+			runID = manifest.RunID
+		}
+		archiveRoot = filepath.Join(cfg.ArchiveDir, filepath.Base(workDir)+"-"+runID)
+	}
+	for i := range m.Assets {
+		a := &m.Assets[i]
+		if a.Stored != "" || a.Kind == "translations" || reported[a.Path] {
+			continue
+		}
+		src := filepath.Join(workDir, filepath.FromSlash(a.Path))
+		ext := strings.ToLower(filepath.Ext(a.Path))
+		switch {
+		case archiveRoot != "" && !plainTextExts[ext]:
+			if text, ok := extractText(src); ok && (ext == ".html" || ext == ".htm") {
+				a.Text = a.Path + ".txt"
+				if err := os.WriteFile(filepath.Join(workDir, filepath.FromSlash(a.Text)), []byte(text), 0644); err != nil {
+					warn("Could not keep the text of %s: %v", a.Path, err)
+					a.Text = ""
+				}
+			}
+			dst := filepath.Join(archiveRoot, filepath.FromSlash(a.Path))
+			if err := moveFile(src, dst); err != nil {
+				warn("Could not archive %s: %v", a.Path, err)
+				continue
+			}
+			a.Stored = dst
+			archived++
+		case cfg.CompressKB > 0 && textAssetExts[ext] && a.Size > int64(cfg.CompressKB)<<10:
+			if err := gzipFile(src, src+".gz"); err != nil {
+				warn("Could not compress %s: %v", a.Path, err)
+				continue
+			}
+			a.Stored = a.Path + ".gz"
+			compressed++
+		}
+	}
+	if archived+compressed == 0 {
+		return
+	}
+	if err := m.save(workDir); err != nil {
+		logEntry("WARN", "ASSETS", 0, "Could not write asset manifest", map[string]string{"error": err.Error()})
+	}
+	logEntry("INFO", "ASSETS_STORED", 0, "Compressed and archived assets", map[string]string{
+		"compressed": fmt.Sprintf("%d", compressed),
+		"archived":   fmt.Sprintf("%d", archived),
+		"archive":    archiveRoot,
+	})
+	if compressed > 0 {
+		info("Compressed %d large text asset(s) to .gz", compressed)
+	}
+	if archived > 0 {
+		info("Archived %d raw asset(s) to %s (see assets/manifest.json)", archived, archiveRoot)
+	}
+}
+
+// gzipFile compresses src into dst and removes src
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// moveFile moves src to dst, copying when they are on different file systems
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// keepStoredAssets carries entries for compressed and archived assets from the
+// previous manifest into m, since rescans no longer find their files at Path
+func keepStoredAssets(prev, m *assetManifest) {
+	if prev == nil {
+		return
+	}
+	listed := map[string]bool{}
+	for _, a := range m.Assets {
+		listed[a.Path] = true
+	}
+	for _, a := range prev.Assets {
+		if a.Stored != "" && !listed[a.Path] {
+			m.Assets = append(m.Assets, a)
+		}
+	}
+}
+
+// storedStandIns returns the workspace files that stand in for stored assets:
+// their .gz copies and extracted text
+func storedStandIns(prev *assetManifest) map[string]bool {
+	standIns := map[string]bool{}
+	if prev == nil {
+		return standIns
+	}
+	for _, a := range prev.Assets {
+		if a.Stored != "" {
+			standIns[filepath.ToSlash(a.Stored)] = true
+		}
+		if a.Text != "" {
+			standIns[a.Text] = true
+		}
+	}
+	return standIns
+}
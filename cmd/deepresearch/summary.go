@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// Warnings collected during the run, reported in the end-of-run summary
+var runWarnings []string
+
+var (
+	// sourceRowRe matches Source Registry rows such as "| S01 | https://... |"
+	sourceRowRe = regexp.MustCompile(`(?m)^\|\s*S\d+\s*\|`)
+	// totalTokensRe matches the cost_tracking.total_tokens frontmatter field
+	totalTokensRe = regexp.MustCompile(`(?m)^\s*total_tokens:\s*(\d+)`)
+)
+
+// runSummary is the overview printed when a run finishes
+type runSummary struct {
+	Phases         []phaseRecord
+	Iterations     int
+	MaxIterations  int
+	LoopEnd        string // why the research loop ended, "" if it did not run
+	TasksPlanned   int
+	TasksCompleted int
+	Sources        int
+	TotalTokens    int         // as the agents recorded it in task.md
+	Usage          *tokenUsage // as the agents reported it in their output
+	Elapsed        time.Duration
+	Warnings       []string
+}
+
+// taskStats counts planned and completed DAG tasks and registered sources in task.md
+func taskStats(taskFile string) (planned, completed, sources int) {
+	content, err := os.ReadFile(taskFile)
+	if err != nil {
+		return 0, 0, 0
+	}
+	f := taskfile.Parse(content)
+	sources = len(sourceRowRe.FindAllString(string(content), -1))
+	return len(f.Tasks), len(f.Tasks) - len(f.Pending()), sources
+}
+
+// buildSummary gathers the run overview from the manifest and task.md
+func buildSummary(workDir string) runSummary {
+	s := runSummary{
+		MaxIterations: maxIterations,
+		Warnings:      runWarnings,
+	}
+	taskFile := taskFilePath(workDir)
+	s.TasksPlanned, s.TasksCompleted, s.Sources = taskStats(taskFile)
+	if content, err := os.ReadFile(taskFile); err == nil {
+		if m := totalTokensRe.FindStringSubmatch(string(content)); m != nil {
+			s.TotalTokens, _ = strconv.Atoi(m[1])
+		}
+	}
+	if manifest != nil {
+		s.Phases = manifest.Phases
+		for _, p := range manifest.Phases {
+			if p.Iteration > s.Iterations {
+				s.Iterations = p.Iteration
+			}
+		}
+		s.Elapsed = time.Since(manifest.StartedAt)
+		s.Usage = manifest.Usage
+		if manifest.Loop != nil {
+			s.LoopEnd = stopReasonLabels[manifest.Loop.Reason]
+		}
+	}
+	if models := meter.unpricedModels(); len(models) > 0 {
+		s.Warnings = append(s.Warnings, fmt.Sprintf(tr("No price for %s; their tokens are not in the estimated cost (set cost.prices)"), strings.Join(models, ", ")))
+	}
+	if config.Cost.BudgetUSD > 0 && s.Usage == nil {
+		s.Warnings = append(s.Warnings, tr("--budget had no effect: the agent reported no token usage"))
+	}
+	return s
+}
+
+// printSummary prints the end-of-run summary table and writes it to the log
+func printSummary(s runSummary) {
+	if plainOutput {
+		fmt.Printf("[SUMMARY] %s\n", tr("Run summary"))
+	} else {
+		fmt.Printf("\n%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", colorCyan, colorReset)
+		fmt.Printf("%s▶ %s%s\n", colorCyan, tr("RUN SUMMARY"), colorReset)
+		fmt.Printf("%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", colorCyan, colorReset)
+	}
+
+	// Labels are padded by display width so translated tables stay aligned
+	// Token and cost columns appear once an agent reported its usage
+	if s.Usage != nil {
+		fmt.Printf("  %s %s  %s %s %s\n", padRight(tr("Phase"), 28), padLeft(tr("Duration"), 10), padRight(tr("Status"), 10), padLeft(tr("Tokens"), 8), padLeft(tr("Cost"), 9))
+		fmt.Printf("  %-28s %10s  %-10s %8s %9s\n", strings.Repeat("-", 28), strings.Repeat("-", 10), strings.Repeat("-", 10), strings.Repeat("-", 8), strings.Repeat("-", 9))
+	} else {
+		fmt.Printf("  %s %s  %s\n", padRight(tr("Phase"), 28), padLeft(tr("Duration"), 10), tr("Status"))
+		fmt.Printf("  %-28s %10s  %s\n", strings.Repeat("-", 28), strings.Repeat("-", 10), "------")
+	}
+	for _, p := range s.Phases {
+		name := p.Name
+		if p.Iteration > 0 {
+			name = fmt.Sprintf("%s #%d", p.Name, p.Iteration)
+		}
+		duration := formatDuration(time.Duration(p.DurationMs) * time.Millisecond)
+		switch {
+		case s.Usage == nil:
+			fmt.Printf("  %-28s %10s  %s\n", name, duration, p.Status)
+		case p.Usage == nil:
+			fmt.Printf("  %-28s %10s  %-10s %8s %9s\n", name, duration, p.Status, "-", "-")
+		default:
+			fmt.Printf("  %-28s %10s  %-10s %8s %9s\n", name, duration, p.Status, formatTokens(p.Usage.tokens()), fmt.Sprintf("$%.2f", p.Usage.CostUSD))
+		}
+	}
+	fmt.Println()
+
+	// The log keeps the English cost
+	cost, shownCost := "n/a", tr("n/a")
+	switch {
+	case s.Usage != nil:
+		cost = fmt.Sprintf("$%.2f (%s input + %s output tokens)", s.Usage.CostUSD, formatTokens(s.Usage.InputTokens), formatTokens(s.Usage.OutputTokens))
+		shownCost = fmt.Sprintf(tr("$%.2f (%s input + %s output tokens)"), s.Usage.CostUSD, formatTokens(s.Usage.InputTokens), formatTokens(s.Usage.OutputTokens))
+	case s.TotalTokens > 0:
+		cost = fmt.Sprintf("%d tokens", s.TotalTokens)
+		shownCost = fmt.Sprintf(tr("%d tokens"), s.TotalTokens)
+	}
+	if config.Cost.BudgetUSD > 0 {
+		cost += fmt.Sprintf(" of $%.2f budget", config.Cost.BudgetUSD)
+		shownCost += fmt.Sprintf(tr(" of $%.2f budget"), config.Cost.BudgetUSD)
+	}
+	label := func(l string) string { return padRight(tr(l), 20) }
+	fmt.Printf("  %s %s\n", label("Total time:"), formatDuration(s.Elapsed))
+	fmt.Printf("  %s %d / %d\n", label("Iterations:"), s.Iterations, s.MaxIterations)
+	if s.LoopEnd != "" {
+		fmt.Printf("  %s %s\n", label("Loop ended:"), tr(s.LoopEnd))
+	}
+	fmt.Printf("  %s "+tr("%d / %d completed")+"\n", label("Tasks:"), s.TasksCompleted, s.TasksPlanned)
+	fmt.Printf("  %s %d\n", label("Sources:"), s.Sources)
+	fmt.Printf("  %s %s\n", label("Estimated cost:"), shownCost)
+
+	if len(s.Warnings) > 0 {
+		fmt.Printf("\n  %s%s%s\n", colorYellow, tr("Warnings:"), colorReset)
+		for _, w := range s.Warnings {
+			fmt.Printf("  - %s\n", w)
+		}
+	}
+	fmt.Println()
+
+	var usage tokenUsage
+	if s.Usage != nil {
+		usage = *s.Usage
+	}
+	logEntry("INFO", "SUMMARY", 0, "Run summary", map[string]string{
+		"elapsed":         formatDuration(s.Elapsed),
+		"iterations":      strconv.Itoa(s.Iterations),
+		"loop_ended":      s.LoopEnd,
+		"tasks_planned":   strconv.Itoa(s.TasksPlanned),
+		"tasks_completed": strconv.Itoa(s.TasksCompleted),
+		"sources":         strconv.Itoa(s.Sources),
+		"cost":            cost,
+		"input_tokens":    strconv.FormatInt(usage.InputTokens, 10),
+		"output_tokens":   strconv.FormatInt(usage.OutputTokens, 10),
+		"cost_usd":        fmt.Sprintf("%.4f", usage.CostUSD),
+		"warnings":        strconv.Itoa(len(s.Warnings)),
+	})
+}
+
+// formatDuration renders a duration rounded to the second, e.g. "1h02m05s"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	sec := d / time.Second
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, sec)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%02ds", m, sec)
+	}
+	return fmt.Sprintf("%ds", sec)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// telemetryConfig controls anonymous usage reporting, which is off unless the
+// user opts in. Reports never contain topics, prompts, paths, models, sources or
+// report content.
+type telemetryConfig struct {
+	Mode     string `yaml:"mode"`     // off (default), local (record only) or on (record and send)
+	Endpoint string `yaml:"endpoint"` // collector that mode "on" POSTs each report to
+}
+
+// Telemetry modes
+const (
+	telemetryOff   = "off"
+	telemetryLocal = "local"
+	telemetryOn    = "on"
+)
+
+// telemetryLogName records every report under ~/.deepresearch, sent or not, so
+// users can inspect exactly what leaves their machine
+const telemetryLogName = "telemetry.jsonl"
+
+// telemetryTimeout bounds how long the end of a run waits for the collector
+const telemetryTimeout = 5 * time.Second
+
+// telemetryPhase is the coarse record of one phase
+type telemetryPhase struct {
+	Name        string `json:"name"`
+	Iteration   int    `json:"iteration,omitempty"`
+	DurationSec int64  `json:"duration_sec"`
+	Status      string `json:"status"`
+}
+
+// telemetryReport is everything a report contains
+type telemetryReport struct {
+	Schema      int              `json:"schema"`
+	InstallID   string           `json:"install_id"` // random, generated on first opt-in; not derived from the machine
+	Date        string           `json:"date"`       // day only
+	Version     string           `json:"version"`
+	OS          string           `json:"os"`
+	Arch        string           `json:"arch"`
+	Command     string           `json:"command"`
+	Agent       string           `json:"agent,omitempty"`
+	Outcome     string           `json:"outcome,omitempty"`
+	ErrorCode   string           `json:"error_code,omitempty"`
+	DurationSec int64            `json:"duration_sec,omitempty"`
+	Iterations  int              `json:"iterations,omitempty"`
+	Phases      []telemetryPhase `json:"phases,omitempty"`
+}
+
+// telemetryMode returns the effective mode; DO_NOT_TRACK=1 always wins
+func telemetryMode() string {
+	if os.Getenv("DO_NOT_TRACK") == "1" {
+		return telemetryOff
+	}
+	switch config.Telemetry.Mode {
+	case telemetryLocal, telemetryOn:
+		return config.Telemetry.Mode
+	}
+	return telemetryOff
+}
+
+// telemetryDir returns ~/.deepresearch ("" if the home directory is unknown)
+func telemetryDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deepresearch")
+}
+
+// telemetryInstallID returns the random installation ID, creating it on first use
+func telemetryInstallID(dir string) string {
+	path := filepath.Join(dir, "telemetry-id")
+	if data, err := os.ReadFile(path); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(path, []byte(id+"\n"), 0644)
+	return id
+}
+
+// newTelemetryReport starts a report for command
+func newTelemetryReport(command string) telemetryReport {
+	return telemetryReport{
+		Schema:  1,
+		Date:    time.Now().UTC().Format("2006-01-02"),
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Command: command,
+	}
+}
+
+// runTelemetry summarizes a finished research run from its manifest
+func runTelemetry(m *runManifest) telemetryReport {
+	command := "run"
+	if m.ReplayOf != "" {
+		command = "replay"
+	}
+	r := newTelemetryReport(command)
+	r.Agent = m.Agent
+	r.Outcome = m.Outcome
+	r.ErrorCode = m.ErrorCode
+	if m.FinishedAt != nil {
+		r.DurationSec = int64(m.FinishedAt.Sub(m.StartedAt).Seconds())
+	}
+	for _, p := range m.Phases {
+		r.Iterations = max(r.Iterations, p.Iteration)
+		r.Phases = append(r.Phases, telemetryPhase{
+			Name:        p.Name,
+			Iteration:   p.Iteration,
+			DurationSec: p.DurationMs / 1000,
+			Status:      p.Status,
+		})
+	}
+	return r
+}
+
+// sendTelemetry records r in the local telemetry log and, in mode "on", posts
+// it to the collector. Failures never affect the run.
+func sendTelemetry(r telemetryReport) {
+	mode := telemetryMode()
+	dir := telemetryDir()
+	if mode == telemetryOff || dir == "" {
+		return
+	}
+	r.InstallID = telemetryInstallID(dir)
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if f, err := os.OpenFile(filepath.Join(dir, telemetryLogName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+		f.Write(append(data, '\n'))
+		f.Close()
+	}
+	if mode != telemetryOn {
+		return
+	}
+	if config.Telemetry.Endpoint == "" {
+		logEntry("WARN", "TELEMETRY", 0, "telemetry.mode is on but telemetry.endpoint is not set; report recorded locally only", nil)
+		return
+	}
+	client := &http.Client{Timeout: telemetryTimeout}
+	resp, err := client.Post(config.Telemetry.Endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		logEntry("WARN", "TELEMETRY", 0, "Could not send usage report", map[string]string{"error": err.Error()})
+		return
+	}
+	resp.Body.Close()
+}
+
+// reportRunTelemetry sends the report of the run the manifest describes
+func reportRunTelemetry() {
+	if manifest != nil {
+		sendTelemetry(runTelemetry(manifest))
+	}
+}
+
+// reportCommandTelemetry sends the report of a subcommand that returned
+func reportCommandTelemetry(name string) {
+	// Not every subcommand reads the config file
+	if configFile == "" {
+		loadConfig(defaultConfigPath(), false)
+	}
+	sendTelemetry(newTelemetryReport(name))
+}
+
+// runTelemetryCommand implements `deepresearch telemetry`: shows whether usage
+// reporting is on and the reports recorded so far, i.e. exactly what was or
+// would have been sent
+func runTelemetryCommand(args []string) {
+	fs := flag.NewFlagSet("telemetry", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file (default ~/.deepresearch/config.yaml)")
+	last := fs.Int("n", 10, "Show the last N recorded reports (0 = all)")
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	mode := telemetryMode()
+	switch {
+	case os.Getenv("DO_NOT_TRACK") == "1":
+		info("Telemetry: off (DO_NOT_TRACK=1)")
+	case mode == telemetryOn && config.Telemetry.Endpoint != "":
+		info("Telemetry: on, reports are sent to %s", config.Telemetry.Endpoint)
+	case mode == telemetryOn:
+		info("Telemetry: on, but telemetry.endpoint is not set; reports are only recorded")
+	case mode == telemetryLocal:
+		info("Telemetry: local, reports are recorded but never sent")
+	default:
+		info("Telemetry: off. Opt in with --telemetry=local or on, or telemetry.mode in config.yaml")
+	}
+
+	path := filepath.Join(telemetryDir(), telemetryLogName)
+	f, err := os.Open(path)
+	if err != nil {
+		info("No reports recorded in %s", path)
+		example, _ := json.MarshalIndent(newTelemetryReport("run"), "", "  ")
+		fmt.Printf("A report looks like this, plus agent, outcome, error code, iterations and phase durations:\n%s\n", example)
+		return
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	info("%d report(s) recorded in %s", len(lines), path)
+	if *last > 0 && len(lines) > *last {
+		lines = lines[len(lines)-*last:]
+	}
+	for _, l := range lines {
+		var out bytes.Buffer
+		if json.Indent(&out, []byte(l), "", "  ") == nil {
+			fmt.Println(out.String())
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tenantConfig isolates one team on a shared `deepresearch serve`
+type tenantConfig struct {
+	TokenEnv      string `yaml:"token_env"`       // environment variable holding the tenant's API token
+	Root          string `yaml:"root"`            // workspace root (default <server root>/<tenant>; relative to the server root)
+	Config        string `yaml:"config"`          // config file the tenant's runs use (default: the server's)
+	MaxActiveJobs int    `yaml:"max_active_jobs"` // queued plus running jobs (0 = unlimited)
+	MaxDiskMB     int    `yaml:"max_disk_mb"`     // total size of the tenant's workspaces (0 = unlimited)
+	// The scheduler holds queued jobs back while these are reached (0 = unlimited)
+	MaxRunningJobs int `yaml:"max_running_jobs"` // concurrently running jobs
+	MaxDailyTokens int `yaml:"max_daily_tokens"` // agent tokens of jobs finished today
+}
+
+// tenant is a resolved tenant with its token and directories
+type tenant struct {
+	Name       string
+	token      string
+	root       string
+	configPath string
+	cfg        tenantConfig
+}
+
+// loadTenants resolves server.tenants. Without tenants the server runs in open
+// mode with a single anonymous tenant that owns the whole root.
+func loadTenants(cfgs map[string]tenantConfig, rootDir, serverConfigPath string) (map[string]*tenant, error) {
+	tenants := map[string]*tenant{}
+	if len(cfgs) == 0 {
+		tenants[""] = &tenant{root: rootDir, configPath: serverConfigPath}
+		return tenants, nil
+	}
+	tokens := map[string]string{}
+	for _, name := range sortedKeys(cfgs) {
+		c := cfgs[name]
+		if c.TokenEnv == "" {
+			return nil, fmt.Errorf("tenant %s: token_env is required", name)
+		}
+		token := os.Getenv(c.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("tenant %s: $%s is not set", name, c.TokenEnv)
+		}
+		if other, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("tenants %s and %s share a token", other, name)
+		}
+		tokens[token] = name
+		t := &tenant{Name: name, token: token, cfg: c, configPath: serverConfigPath}
+		switch {
+		case c.Root == "":
+			t.root = filepath.Join(rootDir, slugify(name))
+		case filepath.IsAbs(c.Root):
+			t.root = c.Root
+		default:
+			t.root = filepath.Join(rootDir, c.Root)
+		}
+		if c.Config != "" {
+			abs, err := filepath.Abs(c.Config)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := os.Stat(abs); err != nil {
+				return nil, fmt.Errorf("tenant %s: %w", name, err)
+			}
+			t.configPath = abs
+		}
+		if err := os.MkdirAll(t.root, 0755); err != nil {
+			return nil, err
+		}
+		tenants[name] = t
+	}
+	return tenants, nil
+}
+
+// authenticate returns the tenant owning the bearer token in an Authorization
+// header (HTTP or gRPC metadata). In open mode every request belongs to the
+// anonymous tenant.
+func (s *jobServer) authenticate(authorization string) *tenant {
+	if t, ok := s.tenants[""]; ok {
+		return t
+	}
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	for _, t := range s.tenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1 {
+			return t
+		}
+	}
+	return nil
+}
+
+// withTenant rejects unauthenticated requests and passes the caller's tenant on
+func (s *jobServer) withTenant(h func(w http.ResponseWriter, r *http.Request, t *tenant)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t := s.authenticate(r.Header.Get("Authorization"))
+		if t == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="deepresearch"`)
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid API token")
+			return
+		}
+		h(w, r, t)
+	}
+}
+
+// checkQuota returns why t may not submit another job, or "" if it may
+func (s *jobServer) checkQuota(t *tenant) (string, error) {
+	if t.cfg.MaxActiveJobs > 0 {
+		jobs, err := s.store.List()
+		if err != nil {
+			return "", err
+		}
+		active := 0
+		for _, j := range jobs {
+			if j.Tenant == t.Name && (j.Status == jobQueued || j.Status == jobRunning) {
+				active++
+			}
+		}
+		if active >= t.cfg.MaxActiveJobs {
+			return fmt.Sprintf("tenant has %d active job(s) (limit %d)", active, t.cfg.MaxActiveJobs), nil
+		}
+	}
+	if t.cfg.MaxDiskMB > 0 {
+		if used := dirSize(t.root) >> 20; used >= int64(t.cfg.MaxDiskMB) {
+			return fmt.Sprintf("tenant workspaces use %d MB (limit %d MB)", used, t.cfg.MaxDiskMB), nil
+		}
+	}
+	return "", nil
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if fi, err := d.Info(); err == nil {
+				total += fi.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
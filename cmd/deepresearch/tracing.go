@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceFlushTimeout bounds how long the end of a run waits for the collector
+const traceFlushTimeout = 10 * time.Second
+
+// OTLP span status codes
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+// span is a finished or running span of the run's trace: the run, a phase,
+// or an agent invocation
+type span struct {
+	id, parent string
+	name       string
+	start, end time.Time
+	attrs      map[string]any
+	err        string
+}
+
+// tracer records the run as one trace and sends finished spans to an OTLP/HTTP
+// collector as JSON, after every phase and at the end of the run
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	traceID  string
+
+	mu       sync.Mutex
+	root     *span
+	phase    *span   // running phase
+	finished []*span // not yet sent
+	sending  sync.WaitGroup
+	warned   bool
+}
+
+// The tracer of the run, nil when no collector is configured
+var traces *tracer
+
+// otlpEndpoint returns the URL traces are POSTed to, from the config or the
+// standard OpenTelemetry environment variables ("" = tracing off)
+func otlpEndpoint(cfg observabilityConfig) string {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" {
+		return ""
+	}
+	endpoint := cfg.OTLPEndpoint
+	if endpoint == "" {
+		if e := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); e != "" {
+			return e // used as is
+		}
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return ""
+	}
+	if strings.HasSuffix(endpoint, "/v1/traces") {
+		return endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+}
+
+// validateObservabilityConfig checks the metrics and tracing settings
+func validateObservabilityConfig(cfg observabilityConfig) error {
+	if endpoint := otlpEndpoint(cfg); endpoint != "" {
+		if p, err := url.Parse(os.ExpandEnv(endpoint)); err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+			return fmt.Errorf("OTLP endpoint %s is not an http(s) URL", endpoint)
+		}
+	}
+	return nil
+}
+
+// startTracing opens the run's trace if a collector is configured
+func startTracing(cfg observabilityConfig, runID, agentName, model string) {
+	endpoint := otlpEndpoint(cfg)
+	if endpoint == "" {
+		return
+	}
+	headers := map[string]string{}
+	// OTEL_EXPORTER_OTLP_HEADERS is key1=value1,key2=value2 with URL-encoded values
+	for _, kv := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			if dec, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+				headers[strings.TrimSpace(k)] = dec
+			}
+		}
+	}
+	for k, v := range cfg.OTLPHeaders {
+		headers[k] = os.ExpandEnv(v)
+	}
+	service := cfg.ServiceName
+	if service == "" {
+		service = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if service == "" {
+		service = "deepresearch"
+	}
+	t := &tracer{
+		endpoint: os.ExpandEnv(endpoint),
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: traceFlushTimeout},
+		traceID:  randomHex(16),
+	}
+	t.root = &span{id: randomHex(8), name: "research", start: time.Now(), attrs: map[string]any{
+		"deepresearch.run_id": runID,
+		"deepresearch.agent":  agentName,
+		"deepresearch.model":  model,
+	}}
+	traces = t
+	logEntry("INFO", "TRACE", 0, "Tracing the run", map[string]string{
+		"trace_id": t.traceID,
+		"endpoint": t.endpoint,
+	})
+}
+
+// tracePhaseStart opens the span of a phase
+func tracePhaseStart(p phaseRecord) {
+	t := traces
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	agentName, model := manifest.phaseAgent(p)
+	t.phase = &span{id: randomHex(8), parent: t.root.id, name: p.Name, start: p.StartedAt, attrs: map[string]any{
+		"deepresearch.phase":     p.Name,
+		"deepresearch.iteration": p.Iteration,
+		"deepresearch.agent":     agentName,
+		"deepresearch.model":     model,
+	}}
+}
+
+// tracePhaseEnd closes the span of the phase and sends the finished spans
+func tracePhaseEnd(p phaseRecord) {
+	t := traces
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if s := t.phase; s != nil && s.name == p.Name {
+		s.end, s.err = *p.FinishedAt, p.Error
+		if p.Usage != nil {
+			s.attrs["deepresearch.input_tokens"] = p.Usage.InputTokens
+			s.attrs["deepresearch.output_tokens"] = p.Usage.OutputTokens
+			s.attrs["deepresearch.cost_usd"] = p.Usage.CostUSD
+		}
+		t.finished = append(t.finished, s)
+		t.phase = nil
+	}
+	batch := t.finished
+	t.finished = nil
+	t.mu.Unlock()
+	t.send(batch)
+}
+
+// traceAgentRun opens the span of one agent invocation, a child of the running
+// phase; the returned function closes it
+func traceAgentRun(agentName, model, task string, start time.Time) func(err error) {
+	t := traces
+	if t == nil {
+		return func(error) {}
+	}
+	t.mu.Lock()
+	parent := t.root.id
+	if t.phase != nil {
+		parent = t.phase.id
+	}
+	t.mu.Unlock()
+	s := &span{id: randomHex(8), parent: parent, name: "agent " + agentName, start: start, attrs: map[string]any{
+		"deepresearch.agent": agentName,
+		"deepresearch.model": model,
+	}}
+	if task != "" {
+		s.attrs["deepresearch.task_id"] = task
+	}
+	return func(err error) {
+		s.end = time.Now()
+		if err != nil {
+			s.err = err.Error()
+			if code := errorCode(err); code != "" {
+				s.attrs["deepresearch.error_code"] = code
+			}
+		}
+		t.mu.Lock()
+		t.finished = append(t.finished, s)
+		t.mu.Unlock()
+	}
+}
+
+// traceRunEnd closes the run's span and waits for the remaining spans to be sent
+func traceRunEnd(outcome, errMsg string) {
+	t := traces
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if s := t.phase; s != nil {
+		s.end, s.err = now, errMsg
+		t.finished = append(t.finished, s)
+		t.phase = nil
+	}
+	t.root.end, t.root.err = now, errMsg
+	t.root.attrs["deepresearch.outcome"] = outcome
+	batch := append(t.finished, t.root)
+	t.finished = nil
+	t.mu.Unlock()
+	t.send(batch)
+	t.sending.Wait()
+}
+
+// send exports spans in the background; a collector that cannot be reached
+// is reported once and never affects the run
+func (t *tracer) send(spans []*span) {
+	if len(spans) == 0 {
+		return
+	}
+	t.sending.Add(1)
+	go func() {
+		defer t.sending.Done()
+		err := t.post(spans)
+		if err == nil {
+			return
+		}
+		t.mu.Lock()
+		first := !t.warned
+		t.warned = true
+		t.mu.Unlock()
+		if first {
+			warn("Could not send traces to %s: %v", t.endpoint, err)
+		}
+		logEntry("WARN", "TRACE", 0, "Could not send traces", map[string]string{
+			"spans": strconv.Itoa(len(spans)),
+			"error": err.Error(),
+		})
+	}()
+}
+
+// post sends spans as an OTLP/HTTP JSON export request
+func (t *tracer) post(spans []*span) error {
+	var out []map[string]any
+	for _, s := range spans {
+		status := map[string]any{"code": otlpStatusOK}
+		if s.err != "" {
+			status = map[string]any{"code": otlpStatusError, "message": s.err}
+		}
+		o := map[string]any{
+			"traceId":           t.traceID,
+			"spanId":            s.id,
+			"name":              s.name,
+			"kind":              1, // internal
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attrs),
+			"status":            status,
+		}
+		if s.parent != "" {
+			o["parentSpanId"] = s.parent
+		}
+		out = append(out, o)
+	}
+	body := map[string]any{"resourceSpans": []any{map[string]any{
+		"resource": map[string]any{"attributes": otlpAttributes(map[string]any{
+			"service.name":    t.service,
+			"service.version": version,
+		})},
+		"scopeSpans": []any{map[string]any{
+			"scope": map[string]any{"name": "deepresearch", "version": version},
+			"spans": out,
+		}},
+	}}}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "deepresearch/"+version)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// otlpAttributes converts attributes to OTLP key/value pairs; empty strings are left out
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	var out []map[string]any
+	for _, k := range sortedKeys(attrs) {
+		var value map[string]any
+		switch v := attrs[k].(type) {
+		case string:
+			if v == "" {
+				continue
+			}
+			value = map[string]any{"stringValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": k, "value": value})
+	}
+	return out
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// translationsDirName holds translated working notes, in assets/
+const translationsDirName = "translations"
+
+// translationConfig controls how non-target-language sources are translated
+type translationConfig struct {
+	TargetLanguage string `yaml:"target_language"` // ISO 639-1 working language (default "en")
+	Engine         string `yaml:"engine"`          // agent, libretranslate, deepl, none
+	Endpoint       string `yaml:"endpoint"`        // API endpoint for libretranslate/deepl
+	APIKeyEnv      string `yaml:"api_key_env"`     // environment variable holding the API key
+	MaxChars       int    `yaml:"max_chars"`       // text sent per source to HTTP engines
+}
+
+// translator translates plain text between languages
+type translator interface {
+	Translate(text, from, to string) (string, error)
+}
+
+// extractText returns the readable text of a text asset (HTML tags stripped)
+func extractText(path string) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !textAssetExts[ext] {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	text := string(data)
+	if ext == ".html" || ext == ".htm" {
+		text = strings.Join(strings.Fields(htmlTagRe.ReplaceAllString(text, " ")), " ")
+	}
+	return text, true
+}
+
+// translationPath returns where the working-language note for an asset of
+// workDir is written, relative to it
+func translationPath(workDir string, asset assetEntry, target string) string {
+	base := strings.TrimSuffix(filepath.Base(asset.Path), filepath.Ext(asset.Path))
+	return assetRel(workDir, translationsDirName+"/"+fmt.Sprintf("%s_%s.%s.md", asset.Kind, base, target))
+}
+
+// pendingTranslations returns the foreign-language assets that have no translation yet
+func pendingTranslations(workDir string, m *assetManifest) []assetEntry {
+	target := config.Translation.TargetLanguage
+	var pending []assetEntry
+	for _, a := range m.Assets {
+		if a.Language == "" || a.Language == target || a.Kind == "translations" || a.Blocked != nil {
+			continue
+		}
+		if !fileExists(filepath.Join(workDir, translationPath(workDir, a, target))) {
+			pending = append(pending, a)
+		}
+	}
+	return pending
+}
+
+// translateForeignSources writes working-language notes for sources in other
+// languages using the configured engine, then links them in the asset manifest
+func translateForeignSources(ctx context.Context, agentName, model, workDir string, m *assetManifest) {
+	cfg := config.Translation
+	if cfg.Engine == "none" || m == nil {
+		return
+	}
+	pending := pendingTranslations(workDir, m)
+	if len(pending) == 0 {
+		linkTranslations(workDir, m)
+		return
+	}
+	if err := os.MkdirAll(assetsPath(workDir, translationsDirName), 0755); err != nil {
+		warn("Could not create %s: %v", assetRel(workDir, translationsDirName), err)
+		return
+	}
+	info("Translating %d non-%s source(s) with %s", len(pending), cfg.TargetLanguage, cfg.Engine)
+
+	if cfg.Engine == "agent" {
+		if err := runAgent(ctx, agentName, model, buildTranslatePrompt(workDir, pending), workDir); err != nil {
+			warn("Translation agent failed: %v", err)
+		}
+	} else {
+		tr, err := newTranslator(cfg)
+		if err != nil {
+			warn("Translation disabled: %v", err)
+			return
+		}
+		for _, a := range pending {
+			text, _ := extractText(filepath.Join(workDir, a.Path))
+			if runes := []rune(text); cfg.MaxChars > 0 && len(runes) > cfg.MaxChars {
+				text = string(runes[:cfg.MaxChars])
+			}
+			out, err := tr.Translate(text, a.Language, cfg.TargetLanguage)
+			if err != nil {
+				warn("Could not translate %s: %v", a.Path, err)
+				continue
+			}
+			note := translationHeader(a, cfg.TargetLanguage, cfg.Engine) + out + "\n"
+			if err := os.WriteFile(filepath.Join(workDir, translationPath(workDir, a, cfg.TargetLanguage)), []byte(note), 0644); err != nil {
+				warn("Could not write translation for %s: %v", a.Path, err)
+			}
+		}
+	}
+	linkTranslations(workDir, m)
+	if err := m.save(workDir); err != nil {
+		logEntry("WARN", "ASSETS", 0, "Could not write asset manifest", map[string]string{"error": err.Error()})
+	}
+}
+
+// linkTranslations records existing translation notes on their source assets
+func linkTranslations(workDir string, m *assetManifest) {
+	target := config.Translation.TargetLanguage
+	for i, a := range m.Assets {
+		if a.Language == "" || a.Language == target || a.Kind == "translations" {
+			continue
+		}
+		if p := translationPath(workDir, a, target); fileExists(filepath.Join(workDir, p)) {
+			m.Assets[i].Translation = p
+		}
+	}
+}
+
+// translationHeader is the provenance block at the top of every translation note
+func translationHeader(a assetEntry, target, engine string) string {
+	id := a.SourceID
+	if id == "" {
+		id = "(unregistered)"
+	}
+	return fmt.Sprintf(`> Translated working note — cite the ORIGINAL source, not this file.
+> - Source ID: %s
+> - URL: %s
+> - Original: %s (%s)
+> - Translated to: %s by %s on %s
+
+`, id, a.URL, a.Path, a.Language, target, engine, time.Now().Format("2006-01-02"))
+}
+
+func buildTranslatePrompt(workDir string, pending []assetEntry) string {
+	target := config.Translation.TargetLanguage
+	var b strings.Builder
+	fmt.Fprintf(&b, `WORKING_DIR: %s
+TASK: Translate the following archived sources into language "%s" as working notes.
+For each entry, read the ORIGINAL file and write a faithful translation of its substantive text to OUTPUT.
+Preserve numbers, names, dates, quotations and headings. Do not summarize or add information.
+Each OUTPUT file MUST start with this provenance block (fill in the values):
+
+> Translated working note — cite the ORIGINAL source, not this file.
+> - Source ID: <ID>
+> - URL: <URL>
+> - Original: <ORIGINAL> (<LANG>)
+> - Translated to: %s by agent on %s
+
+FILES:
+`, workDir, target, target, time.Now().Format("2006-01-02"))
+	for _, a := range pending {
+		id := a.SourceID
+		if id == "" {
+			id = "(unregistered)"
+		}
+		fmt.Fprintf(&b, "- ORIGINAL: %s | LANG: %s | ID: %s | URL: %s | OUTPUT: %s\n", a.Path, a.Language, id, a.URL, translationPath(workDir, a, target))
+	}
+	b.WriteString("\nDo NOT modify task.md or any original asset. Only create the OUTPUT files.\n")
+	return b.String() + buildLayoutNote(workDir)
+}
+
+// buildTranslationsNote tells downstream agents where translated notes are and how to cite them
+func buildTranslationsNote(m *assetManifest) string {
+	if m == nil {
+		return ""
+	}
+	var lines []string
+	for _, a := range m.Assets {
+		if a.Translation != "" {
+			lines = append(lines, fmt.Sprintf("- %s (%s, %s) → %s", a.Path, a.SourceID, a.Language, a.Translation))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+TRANSLATED_SOURCES: These sources are not in %s; read the translated working notes instead of
+translating yourself, but ALWAYS cite the original source ID and URL:
+%s
+`, config.Translation.TargetLanguage, strings.Join(lines, "\n"))
+}
+
+// newTranslator builds an HTTP translation engine from config
+func newTranslator(cfg translationConfig) (translator, error) {
+	key := ""
+	if cfg.APIKeyEnv != "" {
+		key = os.Getenv(cfg.APIKeyEnv)
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	switch cfg.Engine {
+	case "libretranslate":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:5000/translate"
+		}
+		return &libreTranslator{endpoint: endpoint, apiKey: key, client: client}, nil
+	case "deepl":
+		if key == "" {
+			return nil, fmt.Errorf("deepl engine requires translation.api_key_env")
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://api-free.deepl.com/v2/translate"
+		}
+		return &deeplTranslator{endpoint: endpoint, apiKey: key, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown translation engine %q (expected agent, libretranslate, deepl, none)", cfg.Engine)
+}
+
+// libreTranslator uses a LibreTranslate-compatible API
+type libreTranslator struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func (t *libreTranslator) Translate(text, from, to string) (string, error) {
+	body, _ := json.Marshal(map[string]string{
+		"q": text, "source": from, "target": to, "format": "text", "api_key": t.apiKey,
+	})
+	var out struct {
+		TranslatedText string `json:"translatedText"`
+		Error          string `json:"error"`
+	}
+	if err := postJSON(t.client, t.endpoint, nil, body, &out); err != nil {
+		return "", err
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("libretranslate: %s", out.Error)
+	}
+	return out.TranslatedText, nil
+}
+
+// deeplTranslator uses the DeepL v2 API
+type deeplTranslator struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func (t *deeplTranslator) Translate(text, from, to string) (string, error) {
+	body, _ := json.Marshal(map[string]any{
+		"text": []string{text}, "source_lang": strings.ToUpper(from), "target_lang": strings.ToUpper(to),
+	})
+	var out struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	headers := map[string]string{"Authorization": "DeepL-Auth-Key " + t.apiKey}
+	if err := postJSON(t.client, t.endpoint, headers, body, &out); err != nil {
+		return "", err
+	}
+	if len(out.Translations) == 0 {
+		return "", fmt.Errorf("deepl: empty response")
+	}
+	return out.Translations[0].Text, nil
+}
+
+// postJSON posts a JSON body and decodes a JSON response
+func postJSON(client *http.Client, url string, headers map[string]string, body []byte, out any) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
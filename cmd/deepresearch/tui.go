@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// consoleLogPath receives the console and agent output while the dashboard
+// has the terminal
+const consoleLogPath = "logs/console.log"
+
+// dashboardRefresh is how often the dashboard redraws
+const dashboardRefresh = time.Second
+
+// Terminal control sequences the dashboard draws with
+const (
+	termEnter = "\033[?1049h\033[?25l" // alternate screen, hidden cursor
+	termLeave = "\033[?25h\033[?1049l"
+	termHome  = "\033[H"
+	termEOL   = "\033[K"
+	termEOS   = "\033[J"
+	termBold  = "\033[1m"
+	termDim   = "\033[2m"
+	termReset = "\033[0m"
+	termRed   = "\033[31m"
+	termGreen = "\033[32m"
+	termCyan  = "\033[36m"
+)
+
+// The active --tui dashboard, nil without one
+var dash *dashboard
+
+// ansiEscapeRe matches the color codes kept out of logs/console.log
+var ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// dashboard is the --tui display: the phases of the run with their times, the
+// tasks of task.md and the tail of the orchestrator log, redrawn in place.
+// Console and agent output, which would scroll past otherwise, goes to
+// logs/console.log in full while it runs.
+type dashboard struct {
+	workDir, topic string
+	started        time.Time
+	term, stderr   *os.File // the terminal, taken over from os.Stdout and os.Stderr
+	pipe           *os.File // write end replacing os.Stdout and os.Stderr
+	console        *os.File
+	output         *lineRing // latest console lines, shown when the dashboard stops
+	plain          bool      // plainOutput before the dashboard, which writes plain lines to the log
+
+	mu       sync.Mutex // guards paused and drawing
+	paused   bool
+	copied   chan struct{}
+	stopLoop chan struct{}
+	loopDone chan struct{}
+	stopOnce sync.Once
+}
+
+// startDashboard takes over the terminal until stop is called
+func startDashboard(workDir, topic string) (*dashboard, error) {
+	path := filepath.Join(workDir, filepath.FromSlash(consoleLogPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	console, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		console.Close()
+		return nil, err
+	}
+	d := &dashboard{
+		workDir:  workDir,
+		topic:    topic,
+		started:  time.Now(),
+		term:     os.Stdout,
+		stderr:   os.Stderr,
+		pipe:     w,
+		console:  console,
+		output:   newLineRing(15),
+		copied:   make(chan struct{}),
+		stopLoop: make(chan struct{}),
+		loopDone: make(chan struct{}),
+		plain:    plainOutput,
+	}
+	if manifest != nil {
+		d.started = manifest.StartedAt
+	}
+	go d.copyOutput(r)
+	plainOutput = true
+	os.Stdout, os.Stderr = w, w
+	fmt.Fprint(d.term, termEnter)
+	go d.loop()
+	return d, nil
+}
+
+// copyOutput writes the captured output to logs/console.log, keeping its last lines
+func (d *dashboard) copyOutput(r *os.File) {
+	defer close(d.copied)
+	defer r.Close()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := ansiEscapeRe.ReplaceAllString(sc.Text(), "")
+		fmt.Fprintln(d.console, line)
+		if strings.TrimSpace(line) != "" {
+			d.output.Add(line)
+		}
+	}
+}
+
+func (d *dashboard) loop() {
+	defer close(d.loopDone)
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	for {
+		d.draw()
+		select {
+		case <-d.stopLoop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// suspend hands the terminal back, e.g. to an interactive agent, until the
+// returned function is called
+func (d *dashboard) suspend() func() {
+	if d == nil {
+		return func() {}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused {
+		return func() {}
+	}
+	d.paused = true
+	fmt.Fprint(d.term, termLeave)
+	os.Stdout, os.Stderr = d.term, d.stderr
+	plainOutput = d.plain
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		plainOutput = true
+		os.Stdout, os.Stderr = d.pipe, d.pipe
+		fmt.Fprint(d.term, termEnter)
+		d.paused = false
+	}
+}
+
+// stop gives the terminal back and shows the last console lines, so the
+// output of a failure stays on screen
+func (d *dashboard) stop() {
+	if d == nil {
+		return
+	}
+	d.stopOnce.Do(func() {
+		close(d.stopLoop)
+		<-d.loopDone
+		d.mu.Lock()
+		if !d.paused {
+			fmt.Fprint(d.term, termLeave)
+		}
+		d.paused = true
+		os.Stdout, os.Stderr = d.term, d.stderr
+		plainOutput = d.plain
+		d.mu.Unlock()
+		d.pipe.Close()
+		<-d.copied
+		d.console.Close()
+		for _, line := range d.output.Lines() {
+			fmt.Fprintln(d.term, line)
+		}
+		info("Full console and agent output: %s", consoleLogPath)
+	})
+}
+
+// draw renders the dashboard to fit the terminal
+func (d *dashboard) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused {
+		return
+	}
+	width, height, ok := terminalSize(d.term)
+	if !ok {
+		width, height = 80, 24
+	}
+	phases := d.phaseLines()
+	tasks, done, total := d.taskLines()
+	logs := tailFile(filepath.Join(d.workDir, "logs", "orchestrator.log"), height)
+
+	// Header, three section titles and the footer take six lines; the phases
+	// and tasks get at most a third of the rest each, the log what is left
+	room := max(height-6, 3)
+	phases = lastN(phases, min(len(phases), max(room/3, 1)))
+	tasks = tasks[:min(len(tasks), max(room/3, 1))]
+	logs = lastN(logs, max(room-len(phases)-len(tasks), 0))
+
+	var b strings.Builder
+	b.WriteString(termHome)
+	line := func(s string) {
+		b.WriteString(truncateRunes(s, width))
+		b.WriteString(termReset + termEOL + "\r\n")
+	}
+	title := func(s string) {
+		line(termBold + "── " + s + " " + strings.Repeat("─", max(width-len([]rune(s))-4, 0)))
+	}
+	line(fmt.Sprintf("%sdeepresearch%s  %s", termBold, termReset, d.topic))
+	line(termDim + d.workDir + "  " + fmt.Sprintf(tr("elapsed %s"), formatDuration(time.Since(d.started))))
+	title(tr("Phases"))
+	for _, l := range phases {
+		line(l)
+	}
+	title(fmt.Sprintf(tr("Tasks (%d/%d done)"), done, total))
+	for _, l := range tasks {
+		line(l)
+	}
+	title(tr("Log"))
+	for _, l := range logs {
+		line(termDim + l)
+	}
+	b.WriteString(termEOS)
+	b.WriteString(truncateRunes(termDim+fmt.Sprintf(tr("Agent output: %s  ·  Ctrl-C stops the run"), consoleLogPath), width) + termReset)
+	fmt.Fprint(d.term, b.String())
+}
+
+// phaseLines lists the phases recorded in run-manifest.json
+func (d *dashboard) phaseLines() []string {
+	data, err := os.ReadFile(filepath.Join(d.workDir, manifestFileName))
+	if err != nil {
+		return nil
+	}
+	var m struct {
+		Phases []phaseRecord `json:"phases"`
+	}
+	if json.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	var lines []string
+	for _, p := range m.Phases {
+		name := p.Name
+		if p.Iteration > 0 {
+			name = fmt.Sprintf("%s #%d", name, p.Iteration)
+		}
+		mark, took := "✓", time.Duration(p.DurationMs)*time.Millisecond
+		switch p.Status {
+		case outcomeRunning:
+			mark, took = termCyan+"▶", time.Since(p.StartedAt)
+		case outcomeCompleted:
+		default:
+			mark = termRed + "✗"
+		}
+		lines = append(lines, fmt.Sprintf(" %s %-28s %10s%s  %s", mark, name, formatDuration(took), termReset, p.Status))
+	}
+	return lines
+}
+
+// taskLines lists the tasks of task.md, pending ones first, and counts the
+// completed ones and all. A pending task with an executor log is running; one whose
+// dependencies are incomplete is waiting.
+func (d *dashboard) taskLines() (lines []string, done, total int) {
+	f, err := taskfile.Read(taskFilePath(d.workDir))
+	if err != nil {
+		return nil, 0, 0
+	}
+	ready := map[string]bool{}
+	for _, t := range f.Ready() {
+		ready[t.ID] = true
+	}
+	var pending, completed []string
+	for _, t := range f.Tasks {
+		desc, _, _ := strings.Cut(t.Description, "|")
+		desc = strings.TrimSpace(desc)
+		switch {
+		case t.Done:
+			completed = append(completed, fmt.Sprintf(" %s✓%s %-4s %s", termGreen, termReset, t.ID, desc))
+		case fileExists(filepath.Join(d.workDir, "logs", t.ID+".log")):
+			pending = append(pending, fmt.Sprintf(" %s●%s %-4s %s %s%s", termCyan, termReset, t.ID, desc, termDim, tr("(running)")))
+		case ready[t.ID]:
+			pending = append(pending, fmt.Sprintf(" ○ %-4s %s %s%s", t.ID, desc, termDim, tr("(ready)")))
+		default:
+			pending = append(pending, fmt.Sprintf(" · %-4s %s %s"+tr("(waiting for %s)"), t.ID, desc, termDim, strings.Join(t.Dependencies, ", ")))
+		}
+	}
+	return append(pending, completed...), len(completed), len(f.Tasks)
+}
+
+// lastN returns the last n elements of s
+func lastN(s []string, n int) []string {
+	if len(s) > n {
+		return s[len(s)-n:]
+	}
+	return s
+}
+
+// truncateRunes cuts s to width visible characters, not counting escape sequences
+func truncateRunes(s string, width int) string {
+	var b strings.Builder
+	visible, escape := 0, false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = r < '@' || r > '~' || r == '['
+		case r == '\033':
+			escape = true
+		case r == '\t' || r == '\r' || r == '\n':
+			r = ' '
+			fallthrough
+		default:
+			if visible == width {
+				return b.String()
+			}
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Citation check modes (--verify-citations): the VERIFIER phase after
+// synthesis either annotates report.md with the problems it finds, or first
+// sends them back to research as re-sourcing tasks
+const (
+	verifyOff      = "off"
+	verifyAnnotate = "annotate"
+	verifyResource = "resource"
+)
+
+// citationCheckPath records the latest citation check
+const citationCheckPath = "logs/citation-check.json"
+
+// verificationConfig tunes the citation check after synthesis
+type verificationConfig struct {
+	Mode string `yaml:"mode"` // same as --verify-citations: off (default), annotate or resource
+	// Rounds bounds the research rounds resource mode spends on broken
+	// citations before annotating what is left (default 1)
+	Rounds         int  `yaml:"rounds"`
+	CheckLinks     bool `yaml:"check_links"`     // request the cited URLs (default true); false only cross-references the workspace
+	TimeoutSeconds int  `yaml:"timeout_seconds"` // per link (default 15)
+	Concurrency    int  `yaml:"concurrency"`     // links checked at once (default 8)
+}
+
+// Problems the citation check reports
+const (
+	issueUnregistered = "unregistered" // cited, but not in the Source Registry
+	issueDeadLink     = "dead_link"    // the URL does not resolve
+	issueNoArchive    = "no_archive"   // no archived copy the claims can be checked against
+	issueBlocked      = "blocked"      // the archived copy is a paywall or bot check page
+	issueNoFinding    = "no_finding"   // no finding in task.md is drawn from the source
+)
+
+// citationIssue is a problem with a cited source or a link in the report
+type citationIssue struct {
+	Source  string `json:"source,omitempty"` // "" for a link to an unregistered page
+	URL     string `json:"url,omitempty"`
+	Problem string `json:"problem"`
+	Detail  string `json:"detail,omitempty"` // e.g. "HTTP 404"
+}
+
+// describe renders the issue for the report and the re-sourcing tasks
+func (i citationIssue) describe() string {
+	switch i.Problem {
+	case issueUnregistered:
+		return "not in the Source Registry"
+	case issueDeadLink:
+		return "dead link (" + i.Detail + ")"
+	case issueNoArchive:
+		return "no archived copy under assets/"
+	case issueBlocked:
+		return "archived copy is blocked (" + i.Detail + ")"
+	case issueNoFinding:
+		return "no finding in task.md is drawn from it"
+	}
+	return i.Problem
+}
+
+// citationCheck is the result of a VERIFIER round, saved to logs/citation-check.json
+type citationCheck struct {
+	CheckedAt time.Time       `json:"checked_at"`
+	Round     int             `json:"round"`
+	Cited     []string        `json:"cited"`             // source IDs the report cites
+	Links     int             `json:"links_checked"`     // URLs requested
+	Offline   bool            `json:"offline,omitempty"` // links were not requested: a replay, or verification.check_links is off
+	Issues    []citationIssue `json:"issues"`
+	Tasks     []string        `json:"tasks,omitempty"` // re-sourcing tasks added to task.md
+}
+
+// Verify implements orchestrator.Verifier: it checks the citations of
+// report.md, and either adds re-sourcing tasks to task.md or annotates the report
+func (w *researchWorkflow) Verify(ctx context.Context, step orchestrator.Step, resource bool) (bool, error) {
+	check, err := checkCitations(ctx, w.workDir)
+	if err != nil {
+		return false, err
+	}
+	check.Round = step.Iteration
+	for _, issue := range check.Issues {
+		logEntry("WARN", "CITATION", step.Iteration, "Citation problem", map[string]string{
+			"source":  issue.Source,
+			"url":     issue.URL,
+			"problem": issue.Problem,
+			"detail":  issue.Detail,
+		})
+	}
+	if resource && len(check.Issues) > 0 {
+		if check.Tasks, err = addResourcingTasks(w.workDir, check.Issues); err != nil {
+			return false, err
+		}
+	}
+	if len(check.Tasks) == 0 {
+		if err := annotateReport(w.workDir, check.Issues); err != nil {
+			return false, err
+		}
+	}
+	if err := saveCitationCheck(w.workDir, check); err != nil {
+		warn("Could not write %s: %v", citationCheckPath, err)
+	}
+	logEntry("INFO", "VERIFY", step.Iteration, "Citations checked", map[string]string{
+		"cited":  fmt.Sprintf("%d", len(check.Cited)),
+		"links":  fmt.Sprintf("%d", check.Links),
+		"issues": fmt.Sprintf("%d", len(check.Issues)),
+		"tasks":  strings.Join(check.Tasks, ","),
+	})
+	switch {
+	case len(check.Issues) == 0 && check.Offline:
+		info("All %d cited source(s) check out against the workspace; links were not requested", len(check.Cited))
+	case len(check.Issues) == 0:
+		info("All %d cited source(s) and %d link(s) check out", len(check.Cited), check.Links)
+	case len(check.Tasks) > 0:
+		info("Found %d citation problem(s); added re-sourcing task(s) %s", len(check.Issues), strings.Join(check.Tasks, ", "))
+	default:
+		warn("Found %d citation problem(s); marked with † in %s and listed under Citation Check", len(check.Issues), reportFileName(w.workDir))
+	}
+	return len(check.Tasks) > 0, nil
+}
+
+// checkCitations cross-references the sources report.md cites with the Source
+// Registry, the archived assets and the findings, and requests the URLs of the
+// cited sources and of the report's links, unless the run is offline or
+// verification.check_links is off
+func checkCitations(ctx context.Context, workDir string) (*citationCheck, error) {
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		return nil, err
+	}
+	report = stripCitationAnnotations(report)
+	taskFile := taskFilePath(workDir)
+	registry := map[string]registeredSource{}
+	byURL := map[string]string{}
+	for _, src := range readSourceRegistry(workDir) {
+		registry[src.ID] = src
+		if src.URL != "" {
+			byURL[src.URL] = src.ID
+		}
+	}
+	drawn := map[string]bool{}
+	for _, f := range readFacts(taskFile) {
+		for _, id := range f.Sources {
+			drawn[id] = true
+		}
+	}
+	assets, err := loadAssetManifest(workDir)
+	if err != nil {
+		assets = buildAssetManifest(workDir)
+	}
+
+	check := &citationCheck{CheckedAt: time.Now().UTC(), Offline: offline() || !config.Verification.CheckLinks}
+	cited := map[string]bool{}
+	for _, c := range citationRe.FindAllStringSubmatch(string(report), -1) {
+		cited[c[1]] = true
+	}
+	check.Cited = sortedKeys(cited)
+	links := map[string]string{} // URL -> source ID, "" for pages outside the registry
+	for _, id := range check.Cited {
+		src, ok := registry[id]
+		if !ok {
+			check.Issues = append(check.Issues, citationIssue{Source: id, Problem: issueUnregistered})
+			continue
+		}
+		if src.URL != "" {
+			links[src.URL] = id
+		}
+		if a, ok := sourceAsset(workDir, assets, src); !ok {
+			check.Issues = append(check.Issues, citationIssue{Source: id, URL: src.URL, Problem: issueNoArchive})
+		} else if a.Blocked != nil {
+			check.Issues = append(check.Issues, citationIssue{Source: id, URL: src.URL, Problem: issueBlocked, Detail: a.Blocked.Reason})
+		}
+		if !drawn[id] {
+			check.Issues = append(check.Issues, citationIssue{Source: id, URL: src.URL, Problem: issueNoFinding})
+		}
+	}
+	for _, link := range reportLinks(report) {
+		if _, ok := links[link]; !ok {
+			links[link] = byURL[link]
+		}
+	}
+	if check.Offline {
+		return check, nil
+	}
+	dead := probeLinks(ctx, sortedKeys(links))
+	check.Links = len(links)
+	for _, link := range sortedKeys(dead) {
+		check.Issues = append(check.Issues, citationIssue{Source: links[link], URL: link, Problem: issueDeadLink, Detail: dead[link]})
+	}
+	sort.SliceStable(check.Issues, func(i, j int) bool {
+		return check.Issues[i].Source != "" && (check.Issues[j].Source == "" || check.Issues[i].Source < check.Issues[j].Source)
+	})
+	return check, ctx.Err()
+}
+
+// sourceAsset finds the archived copy of a registered source
+func sourceAsset(workDir string, m *assetManifest, src registeredSource) (assetEntry, bool) {
+	for _, a := range m.Assets {
+		if (a.SourceID == src.ID || a.Path == src.LocalPath) && fileExists(filepath.Join(workDir, filepath.FromSlash(a.Path))) {
+			return a, true
+		}
+	}
+	return assetEntry{}, false
+}
+
+// reportLinks returns the web links of a report, outside code
+func reportLinks(report []byte) []string {
+	var links []string
+	for _, link := range reportDestinations(report) {
+		if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// reportDestinations returns the targets of the links and images of a report,
+// outside code
+func reportDestinations(report []byte) []string {
+	doc := markdown.Parser().Parse(text.NewReader(report))
+	seen := map[string]bool{}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var link string
+		switch n := n.(type) {
+		case *ast.Link:
+			link = string(n.Destination)
+		case *ast.Image:
+			link = string(n.Destination)
+		case *ast.AutoLink:
+			link = string(n.URL(report))
+		}
+		if link != "" {
+			seen[link] = true
+		}
+		return ast.WalkContinue, nil
+	})
+	return sortedKeys(seen)
+}
+
+// probeLinks requests each URL and returns those that do not resolve, with why
+func probeLinks(ctx context.Context, links []string) map[string]string {
+	client := &http.Client{Timeout: time.Duration(config.Verification.TimeoutSeconds) * time.Second}
+	dead := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, config.Verification.Concurrency)
+	for _, link := range links {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if reason := probeLink(ctx, client, link); reason != "" {
+				mu.Lock()
+				dead[link] = reason
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return dead
+}
+
+// probeLink returns why a URL does not resolve, or "" if it does. Servers
+// that reject HEAD get a GET; pages that exist but refuse automated requests
+// (401, 403, 429) count as resolving.
+func probeLink(ctx context.Context, client *http.Client, link string) string {
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+		if err != nil {
+			return "invalid URL"
+		}
+		req.Header.Set("User-Agent", "deepresearch/"+version)
+		resp, err := client.Do(req)
+		if err != nil {
+			var ue *url.Error
+			if errors.As(err, &ue) {
+				err = ue.Err
+			}
+			return "unreachable: " + err.Error()
+		}
+		resp.Body.Close()
+		status = resp.StatusCode
+		if status < 400 {
+			return ""
+		}
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return ""
+	}
+	return fmt.Sprintf("HTTP %d", status)
+}
+
+// citationCheckMarker starts the Citation Check section annotateReport appends
+const citationCheckMarker = "<!-- deepresearch:citation-check -->"
+
+// unverifiedMark follows citations annotateReport could not verify
+const unverifiedMark = "†"
+
+var annotatedCitationRe = regexp.MustCompile(`\[(S\d+)\]` + unverifiedMark)
+
+// stripCitationAnnotations removes an earlier annotateReport's marks and section
+func stripCitationAnnotations(report []byte) []byte {
+	if i := strings.Index(string(report), citationCheckMarker); i >= 0 {
+		report = []byte(strings.TrimRight(string(report[:i]), "\n") + "\n")
+	}
+	return annotatedCitationRe.ReplaceAll(report, []byte("[$1]"))
+}
+
+// annotateReport marks the citations of report.md whose sources have problems
+// and lists all problems in a Citation Check section at its end, replacing
+// the annotations of an earlier check
+func annotateReport(workDir string, issues []citationIssue) error {
+	path := reportFilePath(workDir)
+	report, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	report = stripCitationAnnotations(report)
+	if len(issues) > 0 {
+		flagged := map[string]bool{}
+		for _, issue := range issues {
+			if issue.Source != "" {
+				flagged[issue.Source] = true
+			}
+		}
+		report = citationRe.ReplaceAllFunc(report, func(c []byte) []byte {
+			if flagged[string(c[1:len(c)-1])] {
+				return append(append([]byte{}, c...), unverifiedMark...)
+			}
+			return c
+		})
+		var b strings.Builder
+		b.Write(report)
+		fmt.Fprintf(&b, "\n%s\n## Citation Check\n\n", citationCheckMarker)
+		fmt.Fprintf(&b, "Citations marked %s could not be fully verified against their sources.\n\n", unverifiedMark)
+		b.WriteString("| Source | URL | Problem |\n|--------|-----|---------|\n")
+		for _, issue := range issues {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", orDash(issue.Source), orDash(issue.URL), issue.describe())
+		}
+		report = []byte(b.String())
+	}
+	return taskfile.WriteFile(path, report)
+}
+
+// addResourcingTasks adds a task to task.md for each source or link with
+// problems, unless one is already pending, and sets the status back to
+// RESEARCHING. It returns the IDs of the tasks added.
+func addResourcingTasks(workDir string, issues []citationIssue) ([]string, error) {
+	taskFile := taskFilePath(workDir)
+	f, err := taskfile.Read(taskFile)
+	if err != nil {
+		return nil, err
+	}
+	problems := map[string][]string{}
+	urls := map[string]string{}
+	for _, issue := range issues {
+		key := issue.Source
+		if key == "" {
+			key = issue.URL
+		}
+		problems[key] = append(problems[key], issue.describe())
+		if issue.URL != "" {
+			urls[key] = issue.URL
+		}
+	}
+	var added []string
+	for _, key := range sortedKeys(problems) {
+		subject := key
+		if sourceIDRe.MatchString(key) {
+			subject = "[" + key + "]"
+			if urls[key] != "" {
+				subject += " " + urls[key]
+			}
+		}
+		if resourcingPending(f, subject) {
+			continue
+		}
+		id := f.NextID("E")
+		f.AddTask(id, fmt.Sprintf("Re-verify the report's claims citing %s: %s. Re-source them from a live copy, an archived snapshot (e.g. web.archive.org) or an equivalent source, archive it under assets/, and update the Source Registry and the findings that cite it | Priority: HIGH",
+			subject, strings.Join(problems[key], "; ")))
+		added = append(added, id)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	f.SetStatus(taskfile.StatusResearching)
+	return added, f.Write(taskFile)
+}
+
+// resourcingPending reports whether a pending task already re-sources subject
+func resourcingPending(f *taskfile.File, subject string) bool {
+	for _, t := range f.Pending() {
+		if strings.Contains(t.Description, "citing "+subject+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// saveCitationCheck writes logs/citation-check.json
+func saveCitationCheck(workDir string, check *citationCheck) error {
+	data, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(workDir, filepath.FromSlash(citationCheckPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// verifyReport collects what `deepresearch verify` found; problems fail the
+// verification, notes do not
+type verifyReport struct {
+	problems []string
+	notes    []string
+}
+
+func (v *verifyReport) problem(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *verifyReport) note(format string, args ...any) {
+	v.notes = append(v.notes, fmt.Sprintf(format, args...))
+}
+
+// runVerify implements `deepresearch verify`: checks that a finished workspace's
+// assets match their recorded checksums and that findings and report citations
+// only point at registered sources and archived files
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Research workspace to verify")
+	configPath := fs.String("config", "", "Config file the run used (default ~/.deepresearch/config.yaml)")
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, true); err != nil {
+			fatalCode(codeValidationFailed, "%v", err)
+		}
+	} else if err := loadConfig(defaultConfigPath(), false); err != nil {
+		fatalCode(codeValidationFailed, "%v", err)
+	}
+	workDir, err := filepath.Abs(*dir)
+	if err != nil {
+		fatal("Failed to resolve workspace: %v", err)
+	}
+
+	v := &verifyReport{}
+	assets := verifyAssets(workDir, v)
+	verifyArtifacts(workDir, v)
+	verifyReferences(workDir, assets, v)
+
+	for _, n := range v.notes {
+		warn("%s", n)
+	}
+	for _, p := range v.problems {
+		fmt.Fprintf(os.Stderr, "%s%s%s %s\n", colorRed, failMark, colorReset, p)
+	}
+	if len(v.problems) > 0 {
+		fatalCode(codeValidationFailed, "Verification failed: %d problem(s) in %s", len(v.problems), workDir)
+	}
+	success("Verified %d asset(s), findings and report citations in %s", len(assets.Assets), workDir)
+}
+
+// fetchedAsset reports whether rel, relative to workDir, lies in a directory
+// privacy.persist_fetched clears
+func fetchedAsset(workDir, rel string) bool {
+	for _, dir := range []string{"web", "pdf", "images", "audio", "ebook"} {
+		if strings.HasPrefix(rel, assetRel(workDir, dir)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// storedSHA256 hashes an asset's original content wherever it is kept now
+func storedSHA256(workDir string, a assetEntry) (string, error) {
+	path := filepath.Join(workDir, filepath.FromSlash(a.Path))
+	gzipped := false
+	switch {
+	case a.Stored == "":
+	case filepath.IsAbs(a.Stored):
+		path = a.Stored
+	default:
+		path = filepath.Join(workDir, filepath.FromSlash(a.Stored))
+		gzipped = strings.HasSuffix(a.Stored, ".gz")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gzipped {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyAssets checks every asset in assets/manifest.json against its checksum
+// and notes files under assets/ the manifest does not know
+func verifyAssets(workDir string, v *verifyReport) *assetManifest {
+	m, err := loadAssetManifest(workDir)
+	if err != nil {
+		v.problem("Cannot read %s: %v", assetRel(workDir, assetManifestName), err)
+		return &assetManifest{}
+	}
+	known := storedStandIns(m)
+	for _, a := range m.Assets {
+		known[a.Path] = true
+		sum, err := storedSHA256(workDir, a)
+		switch {
+		case os.IsNotExist(err) && fetchedAsset(workDir, a.Path) && !config.Privacy.PersistFetched:
+			// removed after the run by the privacy policy
+		case err != nil:
+			v.problem("%s: missing (%v)", a.Path, err)
+		case a.SHA256 == "":
+			v.note("%s: no checksum recorded", a.Path)
+		case sum != a.SHA256:
+			v.problem("%s: content changed since it was archived (sha256 %s, recorded %s)", a.Path, sum[:12], a.SHA256[:min(12, len(a.SHA256))])
+		}
+	}
+	filepath.WalkDir(assetsPath(workDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(workDir, path)
+		rel = filepath.ToSlash(rel)
+		if !known[rel] && rel != assetRel(workDir, assetManifestName) && rel != assetRel(workDir, citationMetadataName) {
+			v.note("%s: not in %s (added after the run?)", rel, assetRel(workDir, assetManifestName))
+		}
+		return nil
+	})
+	return m
+}
+
+// verifyArtifacts compares task.md, report.md and the asset manifest with the
+// checksums run-manifest.json recorded when the run finished
+func verifyArtifacts(workDir string, v *verifyReport) {
+	rm, err := loadManifest(workDir)
+	if err != nil {
+		v.problem("Cannot read %s: %v", manifestFileName, err)
+		return
+	}
+	if rm.Outcome == outcomeRunning {
+		v.note("%s: run has not finished; checksums may be outdated", manifestFileName)
+	}
+	recorded := map[string]string{}
+	for _, a := range rm.Artifacts {
+		recorded[a.Path] = a.SHA256
+	}
+	for _, rel := range []string{taskFileName(workDir), reportFileName(workDir), assetRel(workDir, assetManifestName)} {
+		want, ok := recorded[rel]
+		if !ok {
+			if fileExists(filepath.Join(workDir, filepath.FromSlash(rel))) {
+				v.note("%s: no checksum in %s", rel, manifestFileName)
+			}
+			continue
+		}
+		sum, err := fileSHA256(filepath.Join(workDir, filepath.FromSlash(rel)))
+		switch {
+		case err != nil:
+			v.problem("%s: missing (%v)", rel, err)
+		case sum != want:
+			v.problem("%s: modified after the run finished", rel)
+		}
+	}
+}
+
+// verifyReferences checks that findings and report citations point at
+// registered sources, and registered sources at archived files
+func verifyReferences(workDir string, m *assetManifest, v *verifyReport) {
+	taskFile, taskName := taskFilePath(workDir), taskFileName(workDir)
+	archived := map[string]bool{}
+	for _, a := range m.Assets {
+		archived[a.Path] = true
+	}
+	registered := map[string]bool{}
+	for _, src := range readSourceRegistry(workDir) {
+		registered[src.ID] = true
+		if src.LocalPath != "" && !archived[src.LocalPath] {
+			v.problem("%s: %s points at %s, which is not an archived asset", taskName, src.ID, src.LocalPath)
+		}
+	}
+	for _, f := range readFacts(taskFile) {
+		if len(f.Sources) == 0 {
+			v.problem("%s: %s cites no source", taskName, f.ID)
+		}
+		for _, id := range f.Sources {
+			if !registered[id] {
+				v.problem("%s: %s cites %s, which is not in the Source Registry", taskName, f.ID, id)
+			}
+		}
+		if f.RawFile != "" && !archived[filepath.ToSlash(f.RawFile)] {
+			v.problem("%s: %s's raw file %s is not an archived asset", taskName, f.ID, f.RawFile)
+		}
+	}
+	report, err := os.ReadFile(reportFilePath(workDir))
+	if err != nil {
+		v.note("%s: %v", reportFileName(workDir), err)
+		return
+	}
+	unknown := map[string]bool{}
+	for _, c := range citationRe.FindAllStringSubmatch(string(report), -1) {
+		if !registered[c[1]] {
+			unknown[c[1]] = true
+		}
+	}
+	for _, id := range sortedKeys(unknown) {
+		v.problem("%s cites %s, which is not in the Source Registry", reportFileName(workDir), id)
+	}
+}
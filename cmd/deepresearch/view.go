@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lonegunamnb/deepresearch/pkg/taskfile"
+)
+
+// taskDetailRe matches the description of a DAG task and captures its type, the
+// rest and its status: "Execution: Search vendor docs (Status: PENDING, DependsOn: P1)"
+var taskDetailRe = regexp.MustCompile(`^([^:]+):\s*(.*?)(?:\(Status:\s*([A-Z_]+)[^)]*\))?\s*$`)
+
+// boardTask is a row on the task board
+type boardTask struct {
+	ID          string
+	Type        string
+	Description string
+	Status      string
+	Done        bool
+}
+
+// viewPage is the data passed to the viewer page template
+type viewPage struct {
+	Title   string
+	Active  string
+	Content template.HTML
+	Tasks   []boardTask
+}
+
+var viewTemplate = template.Must(template.New("view").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - deepresearch</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; }
+nav { background: #24292f; padding: 10px 24px; }
+nav a { color: #fff; margin-right: 18px; text-decoration: none; }
+nav a.active { font-weight: bold; border-bottom: 2px solid #fff; }
+main { max-width: 960px; margin: 24px auto; padding: 0 24px; line-height: 1.6; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 6px 10px; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 12px; overflow: auto; }
+.status-COMPLETED, .status-DONE { color: #1a7f37; }
+.status-IN_PROGRESS, .status-RUNNING { color: #9a6700; }
+.status-FAILED, .status-BLOCKED { color: #cf222e; }
+</style>
+</head>
+<body>
+<nav>
+<a href="/" {{if eq .Active "report"}}class="active"{{end}}>Report</a>
+<a href="/tasks" {{if eq .Active "tasks"}}class="active"{{end}}>Task board</a>
+<a href="/assets/" {{if eq .Active "assets"}}class="active"{{end}}>Assets</a>
+</nav>
+<main>
+{{if .Tasks}}
+<table>
+<tr><th>ID</th><th>Type</th><th>Description</th><th>Status</th></tr>
+{{range .Tasks}}<tr><td>{{.ID}}</td><td>{{.Type}}</td><td>{{.Description}}</td><td class="status-{{.Status}}">{{if .Done}}✔ {{end}}{{.Status}}</td></tr>
+{{end}}
+</table>
+<hr>
+{{end}}
+{{.Content}}
+</main>
+<script>
+new EventSource("/events").onmessage = function () { location.reload(); };
+function loadScript(src, done) { var s = document.createElement("script"); s.src = src; s.onload = done; document.head.appendChild(s); }
+var mermaidBlocks = document.querySelectorAll("code.language-mermaid");
+if (mermaidBlocks.length) {
+  mermaidBlocks.forEach(function (c) { var d = document.createElement("div"); d.className = "mermaid"; d.textContent = c.textContent; c.parentNode.replaceWith(d); });
+  loadScript("https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js", function () { mermaid.initialize({ startOnLoad: false }); mermaid.run(); });
+}
+var vegaBlocks = document.querySelectorAll("code.language-vega-lite");
+if (vegaBlocks.length) {
+  loadScript("https://cdn.jsdelivr.net/npm/vega@5", function () {
+    loadScript("https://cdn.jsdelivr.net/npm/vega-lite@5", function () {
+      loadScript("https://cdn.jsdelivr.net/npm/vega-embed@6", function () {
+        vegaBlocks.forEach(function (c) { var d = document.createElement("div"); var pre = c.parentNode; pre.replaceWith(d); vegaEmbed(d, JSON.parse(c.textContent)); });
+      });
+    });
+  });
+}
+</script>
+</body>
+</html>
+`))
+
+// runView implements `deepresearch view`: serves the workspace on localhost
+// with live reload while a run is in progress
+func runView(args []string) {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Research workspace to serve")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	fs.Parse(args)
+
+	workDir, err := filepath.Abs(*dir)
+	if err != nil {
+		fatal("Failed to resolve workspace: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		serveMarkdownPage(w, reportFilePath(workDir), "Report", "report", nil)
+	})
+	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		taskFile := taskFilePath(workDir)
+		serveMarkdownPage(w, taskFile, "Task board", "tasks", readBoardTasks(taskFile))
+	})
+	mux.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.Dir(assetsPath(workDir)))))
+	if prefix := "/" + assetsDirName(workDir) + "/"; prefix != "/assets/" {
+		// Links in the report name the assets by their workspace path
+		mux.Handle(prefix, http.StripPrefix(prefix, http.FileServer(http.Dir(assetsPath(workDir)))))
+	}
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		serveReloadEvents(w, r, workDir)
+	})
+
+	info("Serving %s at http://%s (Ctrl-C to stop)", workDir, *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fatal("Viewer server failed: %v", err)
+	}
+}
+
+// serveMarkdownPage renders a workspace Markdown file inside the viewer layout
+func serveMarkdownPage(w http.ResponseWriter, path, title, active string, tasks []boardTask) {
+	page := viewPage{Title: title, Active: active, Tasks: tasks}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		page.Content = template.HTML(fmt.Sprintf("<p><em>%s has not been written yet. This page reloads automatically.</em></p>",
+			template.HTMLEscapeString(filepath.Base(path))))
+	} else if html, err := renderMarkdown(src); err != nil {
+		page.Content = template.HTML("<pre>" + template.HTMLEscapeString(string(src)) + "</pre>")
+	} else {
+		page.Content = template.HTML(html)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	viewTemplate.Execute(w, page)
+}
+
+// readBoardTasks extracts the DAG tasks from task.md for the task board
+func readBoardTasks(taskFile string) []boardTask {
+	f, err := taskfile.Read(taskFile)
+	if err != nil {
+		return nil
+	}
+	var tasks []boardTask
+	for _, task := range f.Tasks {
+		t := boardTask{ID: task.ID, Description: task.Description, Done: task.Done}
+		if m := taskDetailRe.FindStringSubmatch(task.Description); m != nil {
+			t.Type, t.Description, t.Status = strings.TrimSpace(m[1]), strings.TrimSpace(m[2]), m[3]
+		}
+		if t.Status == "" {
+			t.Status = "PENDING"
+			if t.Done {
+				t.Status = "COMPLETED"
+			}
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// serveReloadEvents streams a server-sent event whenever report.md, task.md or
+// the assets directory changes
+func serveReloadEvents(w http.ResponseWriter, r *http.Request, workDir string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	last := workspaceStamp(workDir)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if stamp := workspaceStamp(workDir); stamp != last {
+				last = stamp
+				fmt.Fprint(w, "data: reload\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// workspaceStamp summarizes the modification times of the files the viewer shows
+func workspaceStamp(workDir string) string {
+	var parts []string
+	paths := []string{reportFileName(workDir), taskFileName(workDir)}
+	for _, dir := range []string{"web", "pdf", "images", "audio", "ebook"} {
+		paths = append(paths, assetRel(workDir, dir))
+	}
+	for _, p := range paths {
+		if st, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(p))); err == nil {
+			parts = append(parts, fmt.Sprintf("%s:%d:%d", p, st.ModTime().UnixNano(), st.Size()))
+		}
+	}
+	return strings.Join(parts, "|")
+}
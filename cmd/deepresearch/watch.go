@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchRevisionEnv tells a run started by --watch which revision of the brief it is
+const watchRevisionEnv = "DEEPRESEARCH_WATCH_REVISION"
+
+// watchInterval is how often --watch polls the prompt file and context/
+const watchInterval = 2 * time.Second
+
+// watchFingerprint hashes the prompt file's content and the names, sizes and
+// modification times of everything under context/
+func watchFingerprint(promptFile, workDir string) string {
+	h := sha256.New()
+	if data, err := os.ReadFile(promptFile); err == nil {
+		h.Write(data)
+	}
+	filepath.WalkDir(filepath.Join(workDir, contextDirName), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			fmt.Fprintf(h, "%s|%d|%d\n", path, fi.Size(), fi.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// withoutWatchFlag returns the command-line arguments minus --watch
+func withoutWatchFlag(args []string) []string {
+	var out []string
+	for _, a := range args {
+		name, _, _ := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if strings.HasPrefix(a, "-") && name == "watch" {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// runWatch re-runs the research workflow whenever the -f prompt file or the
+// context/ directory changes. Each run is a child process, given extra args on
+// top of the watch's, so a failed run does not end the watch; later runs are
+// told to extend the existing plan.
+func runWatch(promptFile, workDir string, extra []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate the deepresearch executable: %v", err)
+	}
+	args := append(withoutWatchFlag(os.Args[1:]), extra...)
+	for revision := 1; ; revision++ {
+		info("Watch: starting run %d", revision)
+		cmd := exec.Command(exe, args...)
+		cmd.Dir = workDir
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		cmd.Env = append(os.Environ(), watchRevisionEnv+"="+strconv.Itoa(revision))
+		if err := cmd.Run(); err != nil {
+			warn("Watch: run %d failed: %v", revision, err)
+		}
+		// Context ingestion during the run rewrites context/, so compare against the post-run state
+		fingerprint := watchFingerprint(promptFile, workDir)
+		info("Watch: waiting for changes to %s or %s/ (Ctrl+C to stop)", promptFile, contextDirName)
+		for {
+			time.Sleep(watchInterval)
+			if next := watchFingerprint(promptFile, workDir); next != fingerprint {
+				// Let editors and exports finish writing before starting the next run
+				time.Sleep(watchInterval)
+				if watchFingerprint(promptFile, workDir) == next {
+					break
+				}
+				fingerprint = next
+			}
+		}
+		info("Watch: change detected")
+	}
+}
+
+// buildRevisionNote tells the planner that a --watch re-run should extend the
+// previous plan rather than start over, or "" for a first run
+func buildRevisionNote(workDir string) string {
+	revision, _ := strconv.Atoi(os.Getenv(watchRevisionEnv))
+	if revision < 2 || !fileExists(taskFilePath(workDir)) {
+		return ""
+	}
+	return fmt.Sprintf(`
+REVISED BRIEF (watch run %d): The user edited the research brief or the context material after a
+previous run. task.md, assets/ and report.md from that run are still in WORKING_DIR. Update task.md
+in place: keep completed tasks and their findings that still serve the revised brief, drop tasks
+that no longer apply, and add pending tasks only for what is new or changed. Do not re-research
+questions that are already answered.
+`, revision)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// wikidataAPI is the default Wikidata base URL; Wikipedia URLs are derived from -lang
+const wikidataAPI = "https://www.wikidata.org"
+
+// maxWikidataClaims caps how many claims of an entity are reported
+const maxWikidataClaims = 60
+
+func init() {
+	registerConnector(&connector{
+		Name:    "wiki",
+		Summary: "Wikipedia summaries and Wikidata structured facts with references: a baseline to verify entities against (free, no key)",
+		Actions: map[string]string{
+			"search":  `-q "query" [-n 10] [-lang en] — Wikipedia articles`,
+			"summary": `<article title> [-lang en] — lead summary and Wikidata ID`,
+			"facts":   `<Q-id | article title> [-lang en] [-props P31,P571] — Wikidata claims with references, saved to assets/reference`,
+		},
+		Run: runWiki,
+	})
+}
+
+// wikidataIDRe matches a Wikidata entity or property ID
+var wikidataIDRe = regexp.MustCompile(`^[QP]\d+$`)
+
+// wikiLangRe matches a Wikipedia language edition, such as en or zh-yue
+var wikiLangRe = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]+)?$`)
+
+// wikiSummary is the Wikipedia REST page summary
+type wikiSummary struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Extract      string `json:"extract"`
+	Timestamp    string `json:"timestamp"`
+	WikibaseItem string `json:"wikibase_item"`
+	ContentURLs  struct {
+		Desktop struct {
+			Page string `json:"page"`
+		} `json:"desktop"`
+	} `json:"content_urls"`
+}
+
+// wikipediaBase returns the Wikipedia base URL for a language (see wikiLangRe)
+func wikipediaBase(t *toolContext, lang string) string {
+	return t.endpoint("https://" + lang + ".wikipedia.org")
+}
+
+func wikiSummaryOf(t *toolContext, title, lang string) (wikiSummary, error) {
+	var s wikiSummary
+	_, err := t.getJSON(wikipediaBase(t, lang)+"/api/rest_v1/page/summary/"+url.PathEscape(strings.ReplaceAll(title, " ", "_")), nil, &s)
+	return s, err
+}
+
+// wikidataSnak is a claim value or reference part
+type wikidataSnak struct {
+	Property  string `json:"property"`
+	SnakType  string `json:"snaktype"`
+	DataValue struct {
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	} `json:"datavalue"`
+}
+
+type wikidataClaim struct {
+	MainSnak   wikidataSnak              `json:"mainsnak"`
+	Rank       string                    `json:"rank"`
+	Qualifiers map[string][]wikidataSnak `json:"qualifiers"`
+	References []struct {
+		Snaks map[string][]wikidataSnak `json:"snaks"`
+	} `json:"references"`
+}
+
+type wikidataEntity struct {
+	ID     string `json:"id"`
+	Labels map[string]struct {
+		Value string `json:"value"`
+	} `json:"labels"`
+	Descriptions map[string]struct {
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Claims   map[string][]wikidataClaim `json:"claims"`
+	Modified string                     `json:"modified"`
+}
+
+func (e wikidataEntity) label(lang string) string {
+	if l, ok := e.Labels[lang]; ok {
+		return l.Value
+	}
+	if l, ok := e.Labels["en"]; ok {
+		return l.Value
+	}
+	return e.ID
+}
+
+// wikidataLabels resolves entity and property IDs to labels, 50 per request
+func wikidataLabels(t *toolContext, ids []string, lang string) map[string]string {
+	labels := map[string]string{}
+	for i := 0; i < len(ids); i += 50 {
+		params := url.Values{
+			"action": {"wbgetentities"}, "format": {"json"}, "props": {"labels"},
+			"languages": {lang + "|en"}, "ids": {strings.Join(ids[i:min(i+50, len(ids))], "|")},
+		}
+		var resp struct {
+			Entities map[string]wikidataEntity `json:"entities"`
+		}
+		if _, err := t.getJSON(t.endpoint(wikidataAPI)+"/w/api.php?"+params.Encode(), nil, &resp); err != nil {
+			continue // unresolved IDs are shown as-is
+		}
+		for id, e := range resp.Entities {
+			labels[id] = e.label(lang)
+		}
+	}
+	return labels
+}
+
+// snakIDs returns the IDs a snak refers to (its property, entity value or unit)
+func snakIDs(s wikidataSnak) []string {
+	ids := []string{s.Property}
+	v, _ := s.DataValue.Value.(map[string]any)
+	if id, ok := v["id"].(string); ok && s.DataValue.Type == "wikibase-entityid" {
+		ids = append(ids, id)
+	}
+	if unit, ok := v["unit"].(string); ok && s.DataValue.Type == "quantity" && unit != "1" {
+		ids = append(ids, unit[strings.LastIndex(unit, "/")+1:])
+	}
+	return ids
+}
+
+// snakValue renders a claim value as text, resolving entity IDs through labels
+func snakValue(s wikidataSnak, labels map[string]string) string {
+	switch s.SnakType {
+	case "novalue":
+		return "(none)"
+	case "somevalue":
+		return "(unknown)"
+	}
+	v, _ := s.DataValue.Value.(map[string]any)
+	switch s.DataValue.Type {
+	case "string":
+		return fmt.Sprint(s.DataValue.Value)
+	case "wikibase-entityid":
+		id, _ := v["id"].(string)
+		if l, ok := labels[id]; ok {
+			return l + " (" + id + ")"
+		}
+		return id
+	case "time":
+		// +2001-01-15T00:00:00Z with precision 9 = year, 10 = month, 11 = day
+		tm := strings.TrimPrefix(fmt.Sprint(v["time"]), "+")
+		switch p, _ := v["precision"].(float64); {
+		case p <= 9:
+			year, _, _ := strings.Cut(strings.TrimPrefix(tm, "-"), "-")
+			if strings.HasPrefix(tm, "-") {
+				year += " BCE"
+			}
+			return year
+		case p == 10 && len(tm) >= 7:
+			return tm[:7]
+		case len(tm) >= 10:
+			return tm[:10]
+		}
+		return tm
+	case "quantity":
+		amount := strings.TrimPrefix(fmt.Sprint(v["amount"]), "+")
+		if unit, _ := v["unit"].(string); unit != "" && unit != "1" {
+			id := unit[strings.LastIndex(unit, "/")+1:]
+			if l, ok := labels[id]; ok {
+				id = l
+			}
+			amount += " " + id
+		}
+		return amount
+	case "monolingualtext":
+		return fmt.Sprint(v["text"])
+	case "globecoordinate":
+		return fmt.Sprintf("%v, %v", v["latitude"], v["longitude"])
+	}
+	return fmt.Sprint(s.DataValue.Value)
+}
+
+// claimReference summarizes a claim's first reference (reference URL or "stated in")
+func claimReference(c wikidataClaim, labels map[string]string) string {
+	for _, r := range c.References {
+		for _, prop := range []string{"P854", "P248"} { // reference URL, stated in
+			if snaks := r.Snaks[prop]; len(snaks) > 0 {
+				return snakValue(snaks[0], labels)
+			}
+		}
+	}
+	return ""
+}
+
+func runWiki(t *toolContext, action string, args []string) error {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	q := fs.String("q", "", "Search query")
+	n := fs.Int("n", 10, "Maximum number of results")
+	lang := fs.String("lang", "en", "Wikipedia language edition and label language")
+	props := fs.String("props", "", "Only these Wikidata properties (comma-separated, e.g. P31,P571)")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if !wikiLangRe.MatchString(*lang) {
+		return fmt.Errorf("-lang %q is not a Wikipedia language code (e.g. en, de, zh-yue)", *lang)
+	}
+
+	switch action {
+	case "search":
+		query, err := queryArg(fs, *q)
+		if err != nil {
+			return err
+		}
+		var resp struct {
+			Pages []struct {
+				Key         string `json:"key"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Excerpt     string `json:"excerpt"`
+			} `json:"pages"`
+		}
+		params := url.Values{"q": {query}, "limit": {fmt.Sprint(min(*n, 100))}}
+		if _, err := t.getJSON(wikipediaBase(t, *lang)+"/w/rest.php/v1/search/page?"+params.Encode(), nil, &resp); err != nil {
+			return err
+		}
+		t.printf("| Title | Description | Excerpt |\n|---|---|---|\n")
+		for _, p := range resp.Pages {
+			t.printf("| %s | %s | %s |\n", mdCell(p.Title), mdCell(p.Description), mdCell(htmlTagRe.ReplaceAllString(p.Excerpt, "")))
+		}
+		t.printf("\nUse `summary <title>` or `facts <title>` for an entity's baseline facts.\n")
+		return nil
+
+	case "summary":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("an article title is required")
+		}
+		s, err := wikiSummaryOf(t, strings.Join(fs.Args(), " "), *lang)
+		if err != nil {
+			return err
+		}
+		t.printf("## %s\n\n*%s* (Wikidata %s, revised %s)\n\n%s\n\n%s\n", s.Title, s.Description, s.WikibaseItem, s.Timestamp, s.Extract, s.ContentURLs.Desktop.Page)
+		return nil
+
+	case "facts":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("a Wikidata ID or article title is required")
+		}
+		id := strings.Join(fs.Args(), " ")
+		if !wikidataIDRe.MatchString(id) {
+			s, err := wikiSummaryOf(t, id, *lang)
+			if err != nil {
+				return err
+			}
+			if s.WikibaseItem == "" {
+				return fmt.Errorf("%q has no Wikidata item", id)
+			}
+			id = s.WikibaseItem
+		}
+		var resp struct {
+			Entities map[string]wikidataEntity `json:"entities"`
+		}
+		raw, err := t.getJSON(t.endpoint(wikidataAPI)+"/wiki/Special:EntityData/"+id+".json", nil, &resp)
+		if err != nil {
+			return err
+		}
+		e, ok := resp.Entities[id]
+		if !ok {
+			return fmt.Errorf("wikidata entity %s not found", id)
+		}
+		if _, err := t.saveAsset("data", "wikidata_"+id, "json", raw); err != nil {
+			return err
+		}
+
+		wanted := map[string]bool{}
+		for _, p := range strings.Split(*props, ",") {
+			if p = strings.TrimSpace(strings.ToUpper(p)); p != "" {
+				wanted[p] = true
+			}
+		}
+		var pids []string
+		for p := range e.Claims {
+			if len(wanted) == 0 || wanted[p] {
+				pids = append(pids, p)
+			}
+		}
+		sort.Slice(pids, func(i, j int) bool { // P31 before P279 before P1082
+			return len(pids[i]) < len(pids[j]) || len(pids[i]) == len(pids[j]) && pids[i] < pids[j]
+		})
+
+		// Collect the claims to report (deprecated ranks excluded) and resolve their labels in one pass
+		type row struct {
+			pid   string
+			claim wikidataClaim
+		}
+		var rows []row
+		idSet := map[string]bool{}
+		for _, p := range pids {
+			for _, c := range e.Claims[p] {
+				if c.Rank == "deprecated" || len(rows) == maxWikidataClaims {
+					continue
+				}
+				rows = append(rows, row{p, c})
+				for _, x := range snakIDs(c.MainSnak) {
+					idSet[x] = true
+				}
+				for _, ref := range c.References {
+					for _, s := range ref.Snaks["P248"] {
+						for _, x := range snakIDs(s) {
+							idSet[x] = true
+						}
+					}
+				}
+			}
+		}
+		var ids []string
+		for x := range idSet {
+			ids = append(ids, x)
+		}
+		labels := wikidataLabels(t, ids, *lang)
+
+		name := e.label(*lang)
+		var body strings.Builder
+		desc := ""
+		if d, ok := e.Descriptions[*lang]; ok {
+			desc = d.Value
+		}
+		fmt.Fprintf(&body, "# %s (%s)\n\n%s\n\n| Property | Value | Rank | Reference |\n|---|---|---|---|\n", name, id, desc)
+		referenced := 0
+		for _, r := range rows {
+			prop := r.pid
+			if l, ok := labels[r.pid]; ok {
+				prop = l + " (" + r.pid + ")"
+			}
+			ref := claimReference(r.claim, labels)
+			if ref != "" {
+				referenced++
+			} else {
+				ref = "unreferenced"
+			}
+			fmt.Fprintf(&body, "| %s | %s | %s | %s |\n", mdCell(prop), mdCell(snakValue(r.claim.MainSnak, labels)), r.claim.Rank, mdCell(ref))
+		}
+		modified := e.Modified
+		if len(modified) > 10 {
+			modified = modified[:10]
+		}
+		path, err := t.saveDocument("reference", "wikidata_"+id+"_"+name, [][2]string{
+			{"title", name + " — Wikidata"},
+			{"url", "https://www.wikidata.org/wiki/" + id},
+			{"wikidata", id},
+			{"published", modified},
+		}, body.String())
+		if err != nil {
+			return err
+		}
+		t.printf("%s\n%d of %d claim(s) cite a reference. Archived: %s\n\n", body.String(), referenced, len(rows), path)
+		t.printf("Use these as a baseline: verify research findings against them and follow the referenced sources for anything you cite; unreferenced claims need independent confirmation.\n")
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// wikiAPI serves the recorded Wikipedia and Wikidata responses for Marie Curie
+func wikiAPI(t *testing.T) string {
+	return recordedAPI(t, "wiki", map[string]string{
+		"/w/rest.php/v1/search/page":            "search.json",
+		"/api/rest_v1/page/summary/Marie_Curie": "summary.json",
+		"/api/rest_v1/page/summary/Curie":       "summary_no_item.json",
+		"/wiki/Special:EntityData/Q7186.json":   "entity.json",
+		"/w/api.php":                            "labels.json",
+	}).URL
+}
+
+func TestWikiSearch(t *testing.T) {
+	_, out, err := runRecordedTool(t, "wiki", connectorConfig{Endpoint: wikiAPI(t)}, "search", "-q", "marie curie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"| Title | Description | Excerpt |",
+		"| Marie Curie | Polish-French physicist and chemist (1867–1934) | Marie Salomea Skłodowska-Curie was a Polish",
+		`| Curie (unit) | Unit of radioactivity | The curie \| Ci is a non-SI unit of radioactivity |`,
+	)
+}
+
+func TestWikiSummary(t *testing.T) {
+	_, out, err := runRecordedTool(t, "wiki", connectorConfig{Endpoint: wikiAPI(t)}, "summary", "Marie", "Curie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"## Marie Curie",
+		"(Wikidata Q7186, revised 2025-02-18T09:12:44Z)",
+		"pioneering research on radioactivity.",
+		"https://en.wikipedia.org/wiki/Marie_Curie",
+	)
+}
+
+func TestWikiFacts(t *testing.T) {
+	dir, out, err := runRecordedTool(t, "wiki", connectorConfig{Endpoint: wikiAPI(t)}, "facts", "Marie Curie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out,
+		"# Marie Curie (Q7186)",
+		"| Property | Value | Rank | Reference |",
+		"| country of citizenship (P27) | Poland (Q36) | normal | unreferenced |",
+		"| country of citizenship (P27) | France (Q142) | normal | unreferenced |",
+		"| instance of (P31) | human (Q5) | normal | data.bnf.fr (Q20666306) |",
+		"| date of birth (P569) | 1867-11-07 | normal | https://www.nobelprize.org/prizes/physics/1903/marie-curie/biographical/ |",
+		"| relative (P1038) | (unknown) | normal | unreferenced |",
+		"| number of children (P1971) | 2 | preferred | unreferenced |",
+		"2 of 6 claim(s) cite a reference.",
+	)
+	if strings.Contains(out, "1867-11 ") || strings.Index(out, "(P27)") > strings.Index(out, "(P31)") {
+		t.Errorf("deprecated claim reported or properties out of order:\n%s", out)
+	}
+	if files := assetFiles(t, dir, "data"); len(files) != 1 || !strings.HasPrefix(files[0], "wikidata-q7186") {
+		t.Errorf("archived %v, want the entity data", files)
+	}
+	files := assetFiles(t, dir, "reference")
+	if len(files) != 1 || !strings.HasPrefix(files[0], "wikidata-q7186-marie-curie") {
+		t.Fatalf("archived %v, want the facts", files)
+	}
+	doc, _ := os.ReadFile(assetsPath(dir, "reference", files[0]))
+	checkContains(t, string(doc), `url: "https://www.wikidata.org/wiki/Q7186"`, `published: "2025-02-20"`)
+
+	_, out, err = runRecordedTool(t, "wiki", connectorConfig{Endpoint: wikiAPI(t)}, "facts", "Q7186", "-props", "p31,P569", "-lang", "pl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, out, "# Maria Skłodowska-Curie (Q7186)\n\npolska fizyczka i chemiczka", "2 of 2 claim(s) cite a reference.")
+}
+
+func TestWikiNoResults(t *testing.T) {
+	srv := recordedAPI(t, "wiki", map[string]string{"/w/rest.php/v1/search/page": "search_empty.json"})
+	_, out, err := runRecordedTool(t, "wiki", connectorConfig{Endpoint: srv.URL}, "search", "-q", "no such article")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", out)
+	}
+	dir, _, err := runRecordedTool(t, "wiki", connectorConfig{Endpoint: wikiAPI(t)}, "facts", "Curie")
+	if err == nil || !strings.Contains(err.Error(), `"Curie" has no Wikidata item`) {
+		t.Errorf("disambiguation page: error %v", err)
+	}
+	if files := assetFiles(t, dir, "reference"); len(files) != 0 {
+		t.Errorf("disambiguation page: archived %v", files)
+	}
+}
+
+func TestWikiErrors(t *testing.T) {
+	checkHTTPErrors(t, "wiki", connectorConfig{}, "summary", "Marie Curie")
+	checkHTTPErrors(t, "wiki", connectorConfig{}, "facts", "Q7186")
+	if _, _, err := runRecordedTool(t, "wiki", connectorConfig{}, "summary", "Marie Curie", "-lang", "en.evil.example/"); err == nil {
+		t.Error("an invalid -lang was accepted")
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// researchWorkflow plugs the CLI into pkg/orchestrator: it runs the agents
+// (interactively, through parallel executors or section by section as the
+// flags ask), adds the CLI's notes to their prompts, and logs, lints and
+// checkpoints each step as the orchestrator reports it
+type researchWorkflow struct {
+	agentName, model    string
+	promptsDir, workDir string
+	topic               string
+	interactive         bool // discuss the plan with the user (stdin input)
+	reviewPlan          bool // have the user approve the plan (--review-plan)
+	clarify             bool // ask the user about the request before planning (--clarify)
+	sectioned           bool
+	ctx                 context.Context
+	clarifications      *orchestrator.Clarifications
+	assets              *assetManifest // after the latest research step, for the reflector's notes
+	iterations          int            // research iterations so far
+}
+
+// run runs the workflow from req.From, exiting with the failure details if a step fails
+func (w *researchWorkflow) run(ctx context.Context, req orchestrator.Request) orchestrator.Result {
+	w.ctx = ctx
+	w.iterations = req.From.Iteration
+	w.clarifications = req.Clarifications
+	var agents orchestrator.AgentRunner = hostRunner{workDir: w.workDir, agentOf: w.agentOf}
+	if agentSandbox != nil {
+		agents = &containerRunner{sb: agentSandbox, workDir: w.workDir, agentOf: w.agentOf}
+	}
+	o := orchestrator.New(w.runner(agents), w)
+	o.OnEvent = w.onEvent
+	o.Continue = func(orchestrator.Step) bool { return !meter.stopResearch() }
+	if config.Verification.Mode != verifyOff {
+		o.Verifier = w
+	}
+	if w.reviewPlan {
+		o.Reviewer = w
+	}
+	if w.clarify {
+		o.Clarifier = w
+	}
+	res, err := o.Run(ctx, req)
+	if err != nil {
+		w.fail(err)
+	}
+	return res
+}
+
+// runner runs the agents of the steps: the interactive planner, the executors
+// and the section writers as the flags ask, and otherwise agents, which runs
+// one agent per step (containerRunner with --sandbox, else hostRunner)
+func (w *researchWorkflow) runner(agents orchestrator.AgentRunner) orchestrator.AgentRunner {
+	return orchestrator.AgentRunnerFunc(func(ctx context.Context, step orchestrator.Step, prompt string) error {
+		agentName, model := w.agentOf(step.Phase)
+		switch {
+		case step.Phase == orchestrator.PhasePlanner && w.interactive:
+			return w.runInteractivePlanner(ctx)
+		case step.Phase == orchestrator.PhaseResearch && maxParallel > 0:
+			// The orchestrator dispatches the executors itself, and hands off for them
+			if err := runExecutors(ctx, agentName, model, w.promptsDir, w.workDir, step.Iteration); err != nil {
+				return err
+			}
+			return writeResearchHandoff(w.workDir, step.Iteration)
+		case step.Phase == orchestrator.PhaseSynthesizer && w.sectioned:
+			if err := runSectionedSynthesis(ctx, agentName, model, w.promptsDir, w.workDir, w.topic); err != nil {
+				return err
+			}
+			return writeSynthesisHandoff(w.workDir)
+		}
+		return agents.RunAgent(ctx, step, prompt)
+	})
+}
+
+// agentOf returns the agent and model of a phase (see config phases)
+func (w *researchWorkflow) agentOf(phase orchestrator.Phase) (string, string) {
+	return phaseAgent(string(phase), w.agentName, w.model)
+}
+
+// Prompt builds the instructions of a step
+func (w *researchWorkflow) Prompt(step orchestrator.Step, req orchestrator.Request) (string, error) {
+	note := buildHandoffNote(w.promptsDir, w.workDir, step)
+	switch step.Phase {
+	case orchestrator.PhaseClarifier:
+		return buildClarifierPrompt(w.promptsDir, w.workDir, w.topic) + note, nil
+	case orchestrator.PhasePlanner:
+		return buildPlannerPrompt(w.promptsDir, w.workDir, w.topic, !w.interactive) + orchestrator.ClarificationsNote(req.Clarifications) + orchestrator.PlanFeedbackNote(req.PlanFeedback) + note, nil
+	case orchestrator.PhaseResearch:
+		return buildSupervisorPrompt(w.promptsDir, w.workDir) + buildReplayNote() + note, nil
+	case orchestrator.PhaseReflector:
+		return buildReflectorPrompt(w.promptsDir, w.workDir) + buildBlockedSourcesNote(w.assets) + buildTranslationsNote(w.assets) + buildReplayNote() + note, nil
+	case orchestrator.PhaseSynthesizer:
+		return buildSynthesizerPrompt(w.promptsDir, w.workDir, w.topic) + note, nil
+	case orchestrator.PhaseTranslator:
+		return orchestrator.TranslatorPrompt(w.promptsDir, w.workDir, workspaceLayout(w.workDir), step.Language) + buildLayoutNote(w.workDir) + note, nil
+	}
+	return orchestrator.Guides{Dir: w.promptsDir}.Prompt(step, req)
+}
+
+// onEvent tracks, logs and checks the steps of the workflow
+func (w *researchWorkflow) onEvent(ev orchestrator.Event) {
+	step := ev.Step
+	name := string(step.Phase)
+	taskFile := taskFilePath(w.workDir)
+	switch ev.Kind {
+	case orchestrator.StepStarted:
+		if step.Iteration > 0 && step.Phase != orchestrator.PhaseVerifier && step.Phase != orchestrator.PhaseTranslator {
+			w.iterations = step.Iteration
+		}
+		w.beginStep(step)
+
+	case orchestrator.StepFailed:
+		if interrupted(ev.Err) {
+			return // logged as the interruption
+		}
+		var missing *orchestrator.MissingArtifactError
+		if errors.As(ev.Err, &missing) {
+			logEntry("ERROR", "STATE_WRITE", step.Iteration, missing.Error(), nil)
+			return
+		}
+		var invalid *orchestrator.HandoffError
+		if errors.As(ev.Err, &invalid) {
+			logEntry("ERROR", "HANDOFF", step.Iteration, invalid.Error(), map[string]string{
+				"file":     invalid.File,
+				"problems": strings.Join(invalid.Problems, " | "),
+			})
+			return
+		}
+		logEntry("ERROR", "AGENT_FAILED", step.Iteration, step.Phase.Title()+" failed", failureFields(ev.Err))
+
+	case orchestrator.StepCompleted:
+		if step.Phase != orchestrator.PhaseVerifier {
+			logHandoff(step, ev.Handoff)
+		}
+		switch step.Phase {
+		case orchestrator.PhaseClarifier:
+			logEntry("INFO", "AGENT_DONE", 0, "Clarifier completed", map[string]string{
+				"output": orchestrator.ClarificationsFile,
+			})
+			completePhase("Clarifying questions written: %s", orchestrator.ClarificationsFile)
+			// Not checkpointed: a resumed run plans with the answers the file records
+			return
+		case orchestrator.PhasePlanner:
+			logEntry("INFO", "AGENT_DONE", 0, "Planner completed", map[string]string{
+				"output": taskFileName(w.workDir),
+			})
+			completePhase("Research plan created: %s", taskFileName(w.workDir))
+			lintAfterPhase(taskFile, name, 0)
+		case orchestrator.PhaseResearch:
+			logEntry("INFO", "AGENT_DONE", step.Iteration, "Research-Supervisor completed", nil)
+			completePhase("Research tasks completed")
+			lintAfterPhase(taskFile, name, step.Iteration)
+			w.assets = updateAssetManifest(w.workDir)
+			cacheSources(w.workDir, w.assets)
+			recordBlockedGaps(w.assets, step.Iteration)
+			translateForeignSources(w.ctx, w.agentName, w.model, w.workDir, w.assets)
+		case orchestrator.PhaseReflector:
+			logEntry("INFO", "AGENT_DONE", step.Iteration, "Reflector completed", nil)
+			completePhase("Reflection completed")
+			lintAfterPhase(taskFile, name, step.Iteration)
+		case orchestrator.PhaseSynthesizer:
+			logEntry("INFO", "AGENT_DONE", 0, "Synthesizer completed", map[string]string{
+				"output": reportFileName(w.workDir),
+			})
+			completePhase("Report created: %s", reportFileName(w.workDir))
+			if lang := config.Report.language(); lang != "" {
+				checkReportLanguage(w.workDir, reportFileName(w.workDir), lang, 0)
+			}
+		case orchestrator.PhaseVerifier:
+			completePhase("Citations checked")
+			commitPhase()
+			// Not checkpointed: a resumed run checks the report again
+			return
+		case orchestrator.PhaseTranslator:
+			file := workspaceLayout(w.workDir).TranslationFile(step.Language)
+			logEntry("INFO", "AGENT_DONE", step.Iteration, "Translator completed", map[string]string{
+				"language": step.Language,
+				"output":   file,
+			})
+			completePhase("Report translated: %s", file)
+			checkReportLanguage(w.workDir, file, step.Language, step.Iteration)
+			checkTranslation(w.workDir, file, step.Iteration)
+			commitPhase()
+			// Not checkpointed: a resumed run translates the report again
+			return
+		}
+		iteration := step.Iteration
+		if step.Phase == orchestrator.PhaseSynthesizer {
+			iteration = w.iterations
+		}
+		progress.completed(name, iteration)
+		commitPhase()
+
+	case orchestrator.ResearchSufficient:
+		fields := reflectionFields(ev.Reflection)
+		fields["recommendation"] = "READY_FOR_SYNTHESIS"
+		logEntry("INFO", "REFLECTION", step.Iteration, "Research sufficient, proceeding to synthesis", fields)
+		info("Reflector indicates research is sufficient")
+
+	case orchestrator.ResearchContinues:
+		fields := reflectionFields(ev.Reflection)
+		fields["recommendation"] = "CONTINUE_RESEARCH"
+		logEntry("INFO", "REFLECTION", step.Iteration, "More research needed", fields)
+		if step.Iteration < maxIterations {
+			info("Reflector added %d new task(s), continuing research loop...", ev.Reflection.NewTasks)
+		}
+
+	case orchestrator.LoopEnded:
+		recordLoopEnd(ev.Loop)
+
+	case orchestrator.QuestionsAnswered:
+		w.clarifications = ev.Clarifications
+		answered := 0
+		for _, a := range ev.Clarifications.Answers {
+			if a != "" {
+				answered++
+			}
+		}
+		logEntry("INFO", "CLARIFY", 0, "Clarifying questions answered", map[string]string{
+			"questions": fmt.Sprintf("%d", len(ev.Clarifications.Questions)),
+			"answered":  fmt.Sprintf("%d", answered),
+		})
+		if len(ev.Clarifications.Questions) == 0 {
+			info("The clarifier found the request specific enough to plan; no questions to ask")
+		} else {
+			info("The planner gets your answers to %d of %d question(s)", answered, len(ev.Clarifications.Questions))
+		}
+
+	case orchestrator.PlanApproved:
+		logEntry("INFO", "PLAN_REVIEW", 0, "Plan approved", nil)
+		success("Plan approved")
+
+	case orchestrator.PlanSentBack:
+		fields := map[string]string{}
+		if config.Privacy.PersistPrompts && !config.Privacy.Ephemeral {
+			fields["feedback"] = ev.Feedback
+		}
+		logEntry("INFO", "PLAN_REVIEW", 0, "Plan sent back to the planner", fields)
+		info("Sending the plan back to the planner with your feedback...")
+
+	case orchestrator.IterationsExhausted:
+		warn("Reached max iterations (%d) while reflector still requested more research", maxIterations)
+
+	case orchestrator.CitationsResourced:
+		info("Researching replacements for broken citations, then synthesizing again...")
+
+	case orchestrator.ResearchStopped:
+		logEntry("INFO", "BUDGET", step.Iteration, "Budget reached, skipping to synthesis", map[string]string{
+			"skipped": name,
+		})
+		info("Budget reached; skipping %s and the rest of the research loop", step.Phase.Title())
+	}
+}
+
+// beginStep announces a step and logs its dispatch
+func (w *researchWorkflow) beginStep(step orchestrator.Step) {
+	switch step.Phase {
+	case orchestrator.PhaseClarifier:
+		beginPhase("CLARIFIER", "Finding what the request leaves open", 0)
+		logEntry("INFO", "DISPATCH", 0, "Dispatching Clarifier", map[string]string{
+			"phase": "CLARIFIER",
+		})
+	case orchestrator.PhasePlanner:
+		beginPhase("PLANNER", "Creating research plan", 0)
+		logEntry("INFO", "DISPATCH", 0, "Dispatching Planner agent", map[string]string{
+			"phase":       "PLANNER",
+			"interactive": fmt.Sprintf("%v", w.interactive),
+		})
+	case orchestrator.PhaseResearch:
+		beginPhase("RESEARCH-SUPERVISOR", fmt.Sprintf(tr("Executing research tasks (iteration %d)"), step.Iteration), step.Iteration)
+		if maxParallel > 0 {
+			logEntry("INFO", "DISPATCH", step.Iteration, "Dispatching executors", map[string]string{
+				"phase":        "RESEARCH-SUPERVISOR",
+				"iteration":    fmt.Sprintf("%d", step.Iteration),
+				"max_parallel": fmt.Sprintf("%d", maxParallel),
+			})
+		} else {
+			logEntry("INFO", "DISPATCH", step.Iteration, "Dispatching Research-Supervisor", map[string]string{
+				"phase":     "RESEARCH-SUPERVISOR",
+				"iteration": fmt.Sprintf("%d", step.Iteration),
+			})
+		}
+	case orchestrator.PhaseReflector:
+		beginPhase("REFLECTOR", "Analyzing research quality", step.Iteration)
+		logEntry("INFO", "DISPATCH", step.Iteration, "Dispatching Reflector", map[string]string{
+			"phase": "REFLECTOR",
+		})
+	case orchestrator.PhaseSynthesizer:
+		// Resolve authoritative metadata for papers before the synthesizer writes the references
+		if offline() {
+			// A replay only uses the metadata the prior run resolved
+		} else if n, err := enrichCitations(w.workDir); err != nil {
+			warn("Could not save citation metadata: %v", err)
+		} else if n > 0 {
+			info("Resolved metadata for %d source(s) via Crossref", n)
+		}
+		beginPhase("SYNTHESIZER", "Generating final report", 0)
+		logEntry("INFO", "DISPATCH", 0, "Dispatching Synthesizer", map[string]string{
+			"phase":     "SYNTHESIZER",
+			"sectioned": fmt.Sprintf("%v", w.sectioned),
+		})
+	case orchestrator.PhaseVerifier:
+		beginPhase("VERIFIER", "Checking citations", step.Iteration)
+		logEntry("INFO", "DISPATCH", step.Iteration, "Checking citations", map[string]string{
+			"phase": "VERIFIER",
+			"mode":  config.Verification.Mode,
+		})
+	case orchestrator.PhaseTranslator:
+		beginPhase("TRANSLATOR", fmt.Sprintf(tr("Translating the report into %s"), step.Language), step.Iteration)
+		logEntry("INFO", "DISPATCH", step.Iteration, "Dispatching Translator", map[string]string{
+			"phase":    "TRANSLATOR",
+			"language": step.Language,
+		})
+	}
+}
+
+// fail exits with the error of a failed step, first salvaging a partial report
+// where the findings allow it
+func (w *researchWorkflow) fail(err error) {
+	if interrupted(err) {
+		awaitShutdown()
+	}
+	var missing *orchestrator.MissingArtifactError
+	if errors.As(err, &missing) {
+		fatalCode(codeArtifactMissing, "%s", missing.Error())
+	}
+	failHandoff(err, w.promptsDir)
+	var pending *questionsPendingError
+	if errors.As(err, &pending) {
+		fatalCode(codeValidationFailed, "Answer the %d clarifying question(s) in %s, then run again with --clarify-answers %s", pending.questions, pending.path, pending.path)
+	}
+	var se *orchestrator.StepError
+	if !errors.As(err, &se) {
+		fatal("%v", err)
+	}
+	switch se.Step.Phase {
+	case orchestrator.PhaseResearch, orchestrator.PhaseReflector:
+		// A run stopped for its budget is not spent further on a salvage
+		if errorCode(se.Err) != codeBudgetExceeded {
+			agentName, model := w.agentOf(orchestrator.PhaseSynthesizer)
+			trySalvage(w.ctx, agentName, model, w.promptsDir, w.workDir, w.topic, se.Error())
+		}
+	case orchestrator.PhaseSynthesizer:
+		if w.sectioned {
+			// Keep whatever sections were written so the partial report survives
+			if n, asmErr := assembleSections(w.workDir, true); asmErr == nil {
+				warn("Partial report with %d section(s) saved to %s; written sections are kept in %s/", n, reportFileName(w.workDir), sectionsDirName)
+			}
+		}
+	}
+	code := errorCode(se.Err)
+	var reported *orchestrator.AgentReportedError
+	if errors.As(err, &reported) {
+		code = codeAgentFailed
+	}
+	fatalCode(code, "%v", se)
+}
+
+// runInteractivePlanner lets the user discuss and refine the plan with the
+// agent, which signals when it has written task.md (see plannerCompletion)
+func (w *researchWorkflow) runInteractivePlanner(ctx context.Context) error {
+	// Write complete instructions to a temp file so agent gets all context in one place
+	info("Interactive mode: You can discuss and refine the research plan with the agent")
+	info("The agent will automatically exit after creating %s", taskFileName(w.workDir))
+	fmt.Println()
+
+	until, err := newPlannerCompletion(w.workDir)
+	if err != nil {
+		return err
+	}
+	defer until.cleanup()
+
+	// Create combined instruction file with planner.md content + parameters
+	plannerContent, err := os.ReadFile(filepath.Join(w.promptsDir, "planner.md"))
+	if err != nil {
+		return fmt.Errorf("failed to read planner.md: %w", err)
+	}
+
+	combinedPrompt := fmt.Sprintf(`# Research Planner Task
+
+## Environment Parameters
+
+- **WORKING_DIR**: %s
+- **APPROVAL_MODE**: INTERACTIVE
+- **USER_REQUEST**: %s
+
+---
+
+%s
+`, w.workDir, w.topic, string(plannerContent)) + buildLayoutNote(w.workDir) + buildPreapprovedNote(w.workDir) + buildInternalSourcesNote() + buildContextNote(w.workDir) + buildKnowledgeNote(w.workDir, false) + buildRevisionNote(w.workDir) +
+		orchestrator.ClarificationsNote(w.clarifications) + buildHandoffNote(w.promptsDir, w.workDir, orchestrator.Step{Phase: orchestrator.PhasePlanner})
+
+	// Write to tmp/planner_task.md
+	taskFile := filepath.Join(w.workDir, "tmp", "planner_task.md")
+	if err := os.MkdirAll(filepath.Dir(taskFile), 0755); err != nil {
+		return fmt.Errorf("failed to create tmp dir: %w", err)
+	}
+	if err := os.WriteFile(taskFile, []byte(combinedPrompt), 0644); err != nil {
+		return fmt.Errorf("failed to write planner task: %w", err)
+	}
+
+	// Prompt with the completion signal
+	initialPrompt := fmt.Sprintf("Read tmp/planner_task.md and follow ALL instructions. The file contains the complete research planner guide and your specific task parameters. CRITICAL: AFTER YOU HAVE CREATED %s, WRITE DONE TO .locks/.planner.done (or FAILED: <reason> if you cannot create the plan)!", taskFileName(w.workDir))
+	agentName, model := w.agentOf(orchestrator.PhasePlanner)
+	return runAgentInteractiveUntil(ctx, agentName, model, initialPrompt, w.workDir, until)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lonegunamnb/deepresearch/pkg/orchestrator"
+)
+
+// workspaceConfig places the research plan, the report and the archived
+// sources inside the workspace, e.g. to fit a repository's docs/ layout
+type workspaceConfig struct {
+	TaskFile   string `yaml:"task_file"`   // same as --task-file
+	ReportFile string `yaml:"report_file"` // same as --report-file
+	AssetsDir  string `yaml:"assets_dir"`  // same as --assets-dir
+}
+
+// layout is the workspace layout the config asks for
+func (c workspaceConfig) layout() orchestrator.Layout {
+	return orchestrator.Layout{TaskFile: c.TaskFile, ReportFile: c.ReportFile, AssetsDir: c.AssetsDir}.WithDefaults()
+}
+
+// runWorkDir is the workspace of the run this process drives, laid out as
+// config.Workspace asks; empty in subcommands
+var runWorkDir string
+
+var (
+	layoutsMu sync.Mutex
+	layouts   = map[string]orchestrator.Layout{}
+)
+
+// workspaceLayout is the layout of workDir: the run's own as configured, else
+// the one the workspace's run manifest records. Workspaces of runs before
+// layouts were recorded, or of none, are laid out as configured.
+func workspaceLayout(workDir string) orchestrator.Layout {
+	abs, err := filepath.Abs(workDir)
+	if err != nil || abs == runWorkDir {
+		return config.Workspace.layout()
+	}
+	layoutsMu.Lock()
+	defer layoutsMu.Unlock()
+	if l, ok := layouts[abs]; ok {
+		return l
+	}
+	l := config.Workspace.layout()
+	if m, err := loadManifest(abs); err == nil && m.Layout != nil {
+		l = m.Layout.WithDefaults()
+	}
+	layouts[abs] = l
+	return l
+}
+
+// taskFileName is the path of task.md in workDir, relative to it and slash-separated
+func taskFileName(workDir string) string {
+	return workspaceLayout(workDir).TaskFile
+}
+
+// reportFileName is the path of report.md in workDir, relative to it and slash-separated
+func reportFileName(workDir string) string {
+	return workspaceLayout(workDir).ReportFile
+}
+
+// assetsDirName is the path of assets/ in workDir, relative to it and slash-separated
+func assetsDirName(workDir string) string {
+	return workspaceLayout(workDir).AssetsDir
+}
+
+// taskFilePath is the path of task.md in workDir
+func taskFilePath(workDir string) string {
+	return filepath.Join(workDir, filepath.FromSlash(taskFileName(workDir)))
+}
+
+// reportFilePath is the path of report.md in workDir
+func reportFilePath(workDir string) string {
+	return filepath.Join(workDir, filepath.FromSlash(reportFileName(workDir)))
+}
+
+// assetsPath joins elem to the path of assets/ in workDir
+func assetsPath(workDir string, elem ...string) string {
+	return filepath.Join(append([]string{workDir, filepath.FromSlash(assetsDirName(workDir))}, elem...)...)
+}
+
+// assetRel is the slash-separated path of a file in assets/ relative to
+// workDir, e.g. assets/web/s01_page.html for web/s01_page.html
+func assetRel(workDir, rel string) string {
+	return assetsDirName(workDir) + "/" + rel
+}
+
+// isAssetPath reports whether rel, relative to workDir, lies in assets/
+func isAssetPath(workDir, rel string) bool {
+	return strings.HasPrefix(filepath.ToSlash(rel), assetsDirName(workDir)+"/")
+}
+
+// keepLayout lays the run out as the earlier run it continues, which recorded
+// its layout, warning when the flags or the config ask for another one
+func keepLayout(recorded *orchestrator.Layout) {
+	if recorded == nil {
+		return
+	}
+	l := recorded.WithDefaults()
+	if l != config.Workspace.layout() {
+		warn("The run keeps the layout of the run it continues: task file %s, report file %s, assets directory %s", l.TaskFile, l.ReportFile, l.AssetsDir)
+	}
+	config.Workspace = workspaceConfig{TaskFile: l.TaskFile, ReportFile: l.ReportFile, AssetsDir: l.AssetsDir}
+}
+
+// buildLayoutNote tells agents where task.md, report.md and assets/ are in
+// workDir, unless they are at those paths
+func buildLayoutNote(workDir string) string {
+	return orchestrator.LayoutNote(workspaceLayout(workDir))
+}
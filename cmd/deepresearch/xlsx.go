@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxFileName is the spreadsheet appendix written next to report.md
+const xlsxFileName = "report-data.xlsx"
+
+// sheetName makes a unique Excel sheet name (max 31 chars, no []:*?/\)
+func sheetName(title string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, title)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Table"
+	}
+	if len([]rune(name)) > 28 {
+		name = string([]rune(name)[:28])
+	}
+	base := name
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s %d", base, i)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// writeSheet writes a header row and records to a sheet, storing numeric cells as numbers
+func writeSheet(f *excelize.File, sheet string, header []string, records [][]string, headerStyle int) error {
+	row := make([]any, len(header))
+	for i, h := range header {
+		row[i] = h
+	}
+	if err := f.SetSheetRow(sheet, "A1", &row); err != nil {
+		return err
+	}
+	for r, rec := range records {
+		row := make([]any, len(rec))
+		for i, v := range rec {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				row[i] = n
+			} else {
+				row[i] = v
+			}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, r+2)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	if len(header) > 0 {
+		last, _ := excelize.CoordinatesToCellName(len(header), 1)
+		f.SetCellStyle(sheet, "A1", last, headerStyle)
+		f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	}
+	return nil
+}
+
+// exportXLSX bundles the report's data tables, the source list and the findings
+// index into report-data.xlsx
+func exportXLSX(workDir string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}},
+	})
+	used := map[string]bool{}
+
+	// Sources: the Source Registry joined with what the asset manifest knows about each file
+	sheet := sheetName("Sources", used)
+	f.SetSheetName("Sheet1", sheet)
+	assets := map[string]assetEntry{}
+	if m, err := loadAssetManifest(workDir); err == nil {
+		for _, a := range m.Assets {
+			if a.SourceID != "" {
+				assets[a.SourceID] = a
+			}
+		}
+	}
+	var records [][]string
+	for _, s := range readSourceRegistry(workDir) {
+		a := assets[s.ID]
+		license, blocked := "", ""
+		if a.License != nil {
+			license = a.License.Name
+		}
+		if a.Blocked != nil {
+			blocked = a.Blocked.Reason
+		}
+		records = append(records, []string{s.ID, s.Title, s.URL, s.LocalPath, a.Published, a.Language, license, blocked})
+	}
+	if err := writeSheet(f, sheet, []string{"ID", "Title", "URL", "Local Path", "Published", "Language", "License", "Blocked"}, records, headerStyle); err != nil {
+		return err
+	}
+
+	// Findings: the Knowledge Graph index
+	sheet = sheetName("Findings", used)
+	f.NewSheet(sheet)
+	records = nil
+	for _, fc := range readFacts(taskFilePath(workDir)) {
+		records = append(records, []string{fc.ID, fc.Dimension, fc.Statement, strings.Join(fc.Sources, " "), fc.Confidence, fc.RawFile})
+	}
+	if err := writeSheet(f, sheet, []string{"ID", "Dimension", "Finding", "Sources", "Confidence", "Raw File"}, records, headerStyle); err != nil {
+		return err
+	}
+
+	// One sheet per data table in the report
+	tables, _ := reportTables(workDir)
+	for i, t := range tables {
+		title := t.Title
+		if title == "" {
+			title = fmt.Sprintf("Table %d", i+1)
+		}
+		sheet = sheetName(title, used)
+		f.NewSheet(sheet)
+		norm := normalizeTable(t)
+		if err := writeSheet(f, sheet, norm[0], norm[1:], headerStyle); err != nil {
+			return err
+		}
+	}
+	return f.SaveAs(filepath.Join(workDir, xlsxFileName))
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// zoteroAPI is the Zotero Web API base URL
+const zoteroAPI = "https://api.zotero.org"
+
+// preapprovedSourcesFile lists sources the user approved up front, relative to the working directory
+const preapprovedSourcesFile = "preapproved-sources.md"
+
+// zoteroConfig selects the Zotero library used for import and export
+type zoteroConfig struct {
+	LibraryType      string `yaml:"library_type"`      // user or group
+	LibraryID        string `yaml:"library_id"`        // numeric user or group ID
+	APIKeyEnv        string `yaml:"api_key_env"`       // environment variable holding the API key
+	ImportCollection string `yaml:"import_collection"` // collection key imported as pre-approved sources
+	ExportCollection string `yaml:"export_collection"` // collection key gathered citations are added to
+	Endpoint         string `yaml:"endpoint"`          // override the API base URL
+}
+
+// zoteroItem is the data part of a Zotero API item
+type zoteroItem struct {
+	Key              string          `json:"key,omitempty"`
+	ItemType         string          `json:"itemType"`
+	Title            string          `json:"title"`
+	Creators         []zoteroCreator `json:"creators"`
+	Date             string          `json:"date,omitempty"`
+	URL              string          `json:"url,omitempty"`
+	AccessDate       string          `json:"accessDate,omitempty"`
+	DOI              string          `json:"DOI,omitempty"`
+	PublicationTitle string          `json:"publicationTitle,omitempty"`
+	ProceedingsTitle string          `json:"proceedingsTitle,omitempty"`
+	WebsiteTitle     string          `json:"websiteTitle,omitempty"`
+	Institution      string          `json:"institution,omitempty"`
+	Publisher        string          `json:"publisher,omitempty"`
+	Extra            string          `json:"extra,omitempty"`
+	Collections      []string        `json:"collections,omitempty"`
+	Tags             []zoteroTag     `json:"tags,omitempty"`
+}
+
+type zoteroCreator struct {
+	CreatorType string `json:"creatorType"`
+	FirstName   string `json:"firstName,omitempty"`
+	LastName    string `json:"lastName,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type zoteroTag struct {
+	Tag string `json:"tag"`
+}
+
+// zoteroClient talks to one Zotero library
+type zoteroClient struct {
+	base   string
+	apiKey string
+	client *http.Client
+}
+
+func newZoteroClient(cfg zoteroConfig) (*zoteroClient, error) {
+	if cfg.LibraryID == "" {
+		return nil, fmt.Errorf("zotero.library_id is not configured")
+	}
+	kind := "users"
+	if cfg.LibraryType == "group" {
+		kind = "groups"
+	}
+	envName := cfg.APIKeyEnv
+	if envName == "" {
+		envName = "ZOTERO_API_KEY"
+	}
+	key := os.Getenv(envName)
+	if key == "" {
+		return nil, fmt.Errorf("zotero API key not set (expected in $%s)", envName)
+	}
+	api := zoteroAPI
+	if cfg.Endpoint != "" {
+		api = strings.TrimRight(cfg.Endpoint, "/")
+	}
+	return &zoteroClient{
+		base:   fmt.Sprintf("%s/%s/%s", api, kind, cfg.LibraryID),
+		apiKey: key,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (z *zoteroClient) do(method, path string, body []byte, out any) error {
+	req, err := http.NewRequest(method, z.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Zotero-API-Key", z.apiKey)
+	req.Header.Set("Zotero-API-Version", "3")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("zotero %s %s returned %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// collectionItems returns the top-level items of a collection
+func (z *zoteroClient) collectionItems(collection string) ([]zoteroItem, error) {
+	var items []zoteroItem
+	for start := 0; ; start += 100 {
+		var page []struct {
+			Data zoteroItem `json:"data"`
+		}
+		path := fmt.Sprintf("/collections/%s/items/top?format=json&limit=100&start=%d", collection, start)
+		if err := z.do(http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			if p.Data.ItemType != "attachment" && p.Data.ItemType != "note" {
+				items = append(items, p.Data)
+			}
+		}
+		if len(page) < 100 {
+			return items, nil
+		}
+	}
+}
+
+// createItems adds items to the library, 50 per request (the API limit)
+func (z *zoteroClient) createItems(items []zoteroItem) error {
+	for i := 0; i < len(items); i += 50 {
+		end := min(i+50, len(items))
+		body, err := json.Marshal(items[i:end])
+		if err != nil {
+			return err
+		}
+		// Items the API rejects are listed by their index in the request, with 200 OK
+		var result struct {
+			Failed map[string]struct {
+				Message string `json:"message"`
+			} `json:"failed"`
+		}
+		if err := z.do(http.MethodPost, "/items", body, &result); err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			indexes := slices.Sorted(maps.Keys(result.Failed))
+			return fmt.Errorf("zotero rejected %d of %d item(s): %s", len(result.Failed), end-i, result.Failed[indexes[0]].Message)
+		}
+	}
+	return nil
+}
+
+// importZoteroCollection writes the collection's items to preapproved-sources.md
+// and returns how many were imported
+func importZoteroCollection(workDir string) (int, error) {
+	z, err := newZoteroClient(config.Zotero)
+	if err != nil {
+		return 0, err
+	}
+	items, err := z.collectionItems(config.Zotero.ImportCollection)
+	if err != nil {
+		return 0, err
+	}
+	var b strings.Builder
+	b.WriteString("# Pre-approved Sources\n\n")
+	fmt.Fprintf(&b, "> Imported from Zotero collection %s on %s. The user has approved these sources.\n\n", config.Zotero.ImportCollection, time.Now().Format("2006-01-02"))
+	b.WriteString("| Zotero Key | Type | Title | Authors | Date | URL | DOI |\n|---|---|---|---|---|---|---|\n")
+	for _, it := range items {
+		var authors []string
+		for _, c := range it.Creators {
+			if c.Name != "" {
+				authors = append(authors, c.Name)
+			} else {
+				authors = append(authors, strings.TrimSpace(c.LastName+", "+c.FirstName))
+			}
+		}
+		cell := func(s string) string { return strings.ReplaceAll(s, "|", "\\|") }
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", it.Key, it.ItemType, cell(it.Title),
+			cell(strings.Join(authors, "; ")), it.Date, it.URL, it.DOI)
+	}
+	return len(items), os.WriteFile(filepath.Join(workDir, preapprovedSourcesFile), []byte(b.String()), 0644)
+}
+
+// buildPreapprovedNote points agents at preapproved-sources.md, or "" if there is none
+func buildPreapprovedNote(workDir string) string {
+	if !fileExists(filepath.Join(workDir, preapprovedSourcesFile)) {
+		return ""
+	}
+	return fmt.Sprintf(`
+PREAPPROVED_SOURCES: %s lists sources the user has already vetted in their reference manager.
+Register the relevant ones in the Source Registry before searching elsewhere, read them first,
+and treat them as trusted. Keep their URLs/DOIs exactly as listed so citations stay in sync.
+`, preapprovedSourcesFile)
+}
+
+// zoteroItemFromCitation maps a citation to the Zotero item type and fields it supports
+func zoteroItemFromCitation(c citation, collection string) zoteroItem {
+	it := zoteroItem{
+		Title:      c.Title,
+		Creators:   []zoteroCreator{},
+		Date:       c.Issued,
+		URL:        c.URL,
+		AccessDate: c.Accessed,
+		Tags:       []zoteroTag{{Tag: "deepresearch"}},
+	}
+	if collection != "" {
+		it.Collections = []string{collection}
+	}
+	for _, a := range c.Authors {
+		family, given := splitName(a)
+		if given == "" {
+			it.Creators = append(it.Creators, zoteroCreator{CreatorType: "author", Name: family})
+		} else {
+			it.Creators = append(it.Creators, zoteroCreator{CreatorType: "author", LastName: family, FirstName: given})
+		}
+	}
+	switch c.Type {
+	case "article-journal":
+		it.ItemType, it.PublicationTitle, it.DOI = "journalArticle", c.Container, c.DOI
+	case "paper-conference":
+		it.ItemType, it.ProceedingsTitle, it.DOI = "conferencePaper", c.Container, c.DOI
+	case "article-newspaper":
+		it.ItemType, it.PublicationTitle = "newspaperArticle", c.Container
+	case "report":
+		it.ItemType, it.Institution = "report", c.Publisher
+	case "book":
+		it.ItemType, it.Publisher = "book", c.Publisher
+	default:
+		it.ItemType, it.WebsiteTitle = "webpage", c.Container
+	}
+	if c.DOI != "" && it.DOI == "" {
+		it.Extra = "DOI: " + c.DOI
+	}
+	return it
+}
+
+// exportZoteroCitations adds the run's citations that are not already in the
+// imported collection to the Zotero library and returns how many were added
+func exportZoteroCitations(workDir string) (int, error) {
+	z, err := newZoteroClient(config.Zotero)
+	if err != nil {
+		return 0, err
+	}
+	known := map[string]bool{}
+	if data, err := os.ReadFile(filepath.Join(workDir, preapprovedSourcesFile)); err == nil {
+		for _, f := range strings.Fields(string(data)) {
+			known[strings.ToLower(f)] = true
+		}
+	}
+	var items []zoteroItem
+	for _, c := range buildCitations(workDir) {
+		if (c.URL != "" && known[strings.ToLower(c.URL)]) || (c.DOI != "" && known[strings.ToLower(c.DOI)]) {
+			continue
+		}
+		items = append(items, zoteroItemFromCitation(c, config.Zotero.ExportCollection))
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	return len(items), z.createItems(items)
+}
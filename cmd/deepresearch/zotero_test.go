@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useZotero points the Zotero library at endpoint with a test key for the test
+func useZotero(t *testing.T, endpoint string) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	t.Setenv("TEST_ZOTERO_KEY", "test-key")
+	config.Zotero = zoteroConfig{
+		LibraryID: "1234567", APIKeyEnv: "TEST_ZOTERO_KEY",
+		ImportCollection: "ABCD2345", ExportCollection: "EFGH6789", Endpoint: endpoint,
+	}
+}
+
+// zoteroLibrary records the items POSTed to it and answers with the recorded result file
+func zoteroLibrary(t *testing.T, file string, posted *[]zoteroItem) *httptest.Server {
+	t.Helper()
+	result, err := os.ReadFile(filepath.Join("testdata", "connectors", "zotero", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/users/1234567/items" || r.Header.Get("Zotero-API-Key") != "test-key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var items []zoteroItem
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &items); err != nil {
+			t.Error(err)
+		}
+		*posted = append(*posted, items...)
+		w.Write(result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImportZoteroCollection(t *testing.T) {
+	srv := recordedAPI(t, "zotero", map[string]string{"/users/1234567/collections/ABCD2345/items/top": "items.json"})
+	useZotero(t, srv.URL)
+	dir := t.TempDir()
+	n, err := importZoteroCollection(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("imported %d item(s), want 2 (notes skipped)", n)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, preapprovedSourcesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, string(data),
+		"Imported from Zotero collection ABCD2345",
+		"| Zotero Key | Type | Title | Authors | Date | URL | DOI |",
+		"| X42A7DEE | journalArticle | Deep learning | LeCun, Yann; Bengio, Yoshua | 2015-05-27 | https://www.nature.com/articles/nature14539 | 10.1038/nature14539 |",
+		`| B7QF2KLM | report | AI adoption \| firm-level evidence | OECD | 2024 | https://www.oecd.org/ai-adoption-2024 |  |`,
+	)
+	if strings.Contains(string(data), "N0TE1234") {
+		t.Errorf("imported a note:\n%s", data)
+	}
+	if buildPreapprovedNote(dir) == "" {
+		t.Error("no note about the imported sources")
+	}
+}
+
+func TestImportZoteroEmptyCollection(t *testing.T) {
+	srv := recordedAPI(t, "zotero", map[string]string{"/users/1234567/collections/ABCD2345/items/top": "items_empty.json"})
+	useZotero(t, srv.URL)
+	dir := t.TempDir()
+	if n, err := importZoteroCollection(dir); err != nil || n != 0 {
+		t.Fatalf("importZoteroCollection = %d, %v; want 0 items", n, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, preapprovedSourcesFile))
+	if strings.Count(string(data), "\n| ") > 1 {
+		t.Errorf("expected only the table header:\n%s", data)
+	}
+}
+
+func TestImportZoteroErrors(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(status), status)
+		}))
+		useZotero(t, srv.URL)
+		dir := t.TempDir()
+		_, err := importZoteroCollection(dir)
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("HTTP %d: error %v, want one naming the status", status, err)
+		}
+		if fileExists(filepath.Join(dir, preapprovedSourcesFile)) {
+			t.Errorf("HTTP %d: wrote %s", status, preapprovedSourcesFile)
+		}
+	}
+	useZotero(t, "")
+	t.Setenv("TEST_ZOTERO_KEY", "")
+	if _, err := importZoteroCollection(t.TempDir()); err == nil || !strings.Contains(err.Error(), "$TEST_ZOTERO_KEY") {
+		t.Errorf("without a key: error %v, want one naming the variable", err)
+	}
+}
+
+func TestExportZoteroCitations(t *testing.T) {
+	var posted []zoteroItem
+	useZotero(t, zoteroLibrary(t, "created.json", &posted).URL)
+	dir := registryWorkspace(t,
+		"| S1 | https://www.nature.com/articles/nature14539 | Deep learning | Paper | 2025-03-01 | |",
+		"| S2 | https://doi.org/10.1145/3442188.3445922 | On the dangers of stochastic parrots | Paper | 2025-03-01 | |",
+		"| S3 | https://example.com/blog/llm-costs | What LLMs cost | Web | 2025-03-02 | |",
+	)
+	// S1 came from the imported collection
+	if err := os.WriteFile(filepath.Join(dir, preapprovedSourcesFile), []byte("| X42A7DEE | journalArticle | Deep learning | | | https://www.nature.com/articles/nature14539 | |\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	n, err := exportZoteroCitations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(posted) != 2 {
+		t.Fatalf("exported %d, posted %d item(s); want S2 and S3", n, len(posted))
+	}
+	if p := posted[0]; p.ItemType != "journalArticle" || p.DOI != "10.1145/3442188.3445922" || strings.Join(p.Collections, ",") != "EFGH6789" ||
+		len(p.Tags) != 1 || p.Tags[0].Tag != "deepresearch" {
+		t.Errorf("S2 posted as %+v", p)
+	}
+	if p := posted[1]; p.ItemType != "webpage" || p.URL != "https://example.com/blog/llm-costs" || p.AccessDate != "2025-03-02" {
+		t.Errorf("S3 posted as %+v", p)
+	}
+}
+
+func TestExportZoteroRejected(t *testing.T) {
+	var posted []zoteroItem
+	useZotero(t, zoteroLibrary(t, "failed.json", &posted).URL)
+	dir := registryWorkspace(t,
+		"| S1 | https://doi.org/10.1145/3442188.3445922 | On the dangers of stochastic parrots | Paper | 2025-03-01 | |",
+		"| S2 | https://example.com/blog/llm-costs | What LLMs cost | Web | 2025-03-02 | |",
+	)
+	if _, err := exportZoteroCitations(dir); err == nil || !strings.Contains(err.Error(), "zotero rejected 1 of 2 item(s): Collection EFGH6789 not found") {
+		t.Errorf("error %v, want the rejected item reported", err)
+	}
+
+	// Nothing new to export: no request at all
+	dir = registryWorkspace(t)
+	posted = nil
+	if n, err := exportZoteroCitations(dir); err != nil || n != 0 || len(posted) != 0 {
+		t.Errorf("empty registry: exported %d (posted %d), %v", n, len(posted), err)
+	}
+}